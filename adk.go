@@ -0,0 +1,456 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adk holds the configuration of ADK deployments: the model, the
+// session, artifact and memory services, the telemetry and the budgets,
+// loaded from a YAML file and environment variables and validated at once,
+// so that the runners and the launchers of a deployment are built from
+// consistent settings.
+//
+// Example adk.yaml:
+//
+//	app_name: support
+//	model:
+//	  name: gemini-2.5-flash
+//	sessions:
+//	  backend: database
+//	  dialect: postgres
+//	  dsn: host=db user=adk
+//	artifacts:
+//	  backend: gcs
+//	  bucket: support-artifacts
+//	budgets:
+//	  max_tokens_per_invocation: 200000
+//
+// The environment variables listed in [Load] override the file.
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	gcs "google.golang.org/adk/artifact/gcsartifact"
+	s3 "google.golang.org/adk/artifact/s3artifact"
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/gemini"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/session/database"
+	"google.golang.org/genai"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// Backends of the services, see SessionConfig, ArtifactConfig and
+// MemoryConfig.
+const (
+	BackendInMemory = "inmemory"
+	BackendDatabase = "database"
+	BackendGCS      = "gcs"
+	BackendS3       = "s3"
+	BackendNone     = "none"
+)
+
+// Config is the configuration of a deployment.
+type Config struct {
+	// AppName is the name of the application of the runners.
+	AppName   string          `yaml:"app_name"`
+	Model     ModelConfig     `yaml:"model"`
+	Sessions  SessionConfig   `yaml:"sessions"`
+	Artifacts ArtifactConfig  `yaml:"artifacts"`
+	Memory    MemoryConfig    `yaml:"memory"`
+	Telemetry TelemetryConfig `yaml:"telemetry"`
+	Budgets   BudgetConfig    `yaml:"budgets"`
+}
+
+// ModelConfig configures the Gemini model returned by Config.NewModel.
+type ModelConfig struct {
+	// Name of the model, e.g. "gemini-2.5-flash".
+	Name string `yaml:"name"`
+	// APIKey of the Gemini API.
+	// Optional: the genai client reads GOOGLE_API_KEY if unset.
+	APIKey string `yaml:"api_key"`
+	// VertexAI calls the model on Vertex AI in Project and Location
+	// instead of the Gemini API.
+	VertexAI bool   `yaml:"vertex_ai"`
+	Project  string `yaml:"project"`
+	Location string `yaml:"location"`
+}
+
+// SessionConfig configures the session service.
+type SessionConfig struct {
+	// Backend is BackendInMemory or BackendDatabase.
+	// Optional: defaults to BackendInMemory.
+	Backend string `yaml:"backend"`
+	// Dialect is the database dialect registered with RegisterDialect,
+	// e.g. "postgres", for BackendDatabase.
+	Dialect string `yaml:"dialect"`
+	// DSN is the data source name of the database, for BackendDatabase.
+	DSN string `yaml:"dsn"`
+	// TTL is the lifetime of the sessions of the app, see
+	// session.ExpiryPolicy.
+	// Optional: if zero, the sessions never expire.
+	TTL time.Duration `yaml:"ttl"`
+	// ExpiryMode is session.ExpirySliding or session.ExpiryAbsolute.
+	// Optional: defaults to session.ExpirySliding.
+	ExpiryMode session.ExpiryMode `yaml:"expiry_mode"`
+}
+
+// ArtifactConfig configures the artifact service.
+type ArtifactConfig struct {
+	// Backend is BackendInMemory, BackendGCS, BackendS3 or BackendNone.
+	// Optional: defaults to BackendInMemory.
+	Backend string `yaml:"backend"`
+	// Bucket is the Google Cloud Storage bucket, for BackendGCS, or the S3
+	// bucket, for BackendS3.
+	Bucket string `yaml:"bucket"`
+	// Region is the region of the S3 bucket, for BackendS3.
+	// Optional: defaults to the AWS_REGION environment variable.
+	Region string `yaml:"region"`
+	// Endpoint is the URL of an S3-compatible server, e.g. MinIO, for
+	// BackendS3. The bucket is then addressed in the path of the URLs.
+	// Optional: defaults to Amazon S3. The credentials are read from the
+	// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables.
+	Endpoint string `yaml:"endpoint"`
+}
+
+// MemoryConfig configures the memory service.
+type MemoryConfig struct {
+	// Backend is BackendInMemory or BackendNone.
+	// Optional: defaults to BackendNone.
+	Backend string `yaml:"backend"`
+}
+
+// TelemetryConfig configures what the runners report.
+type TelemetryConfig struct {
+	// SlowSessionOperationThreshold, see
+	// runner.Config.SlowSessionOperationThreshold.
+	SlowSessionOperationThreshold time.Duration `yaml:"slow_session_operation_threshold"`
+	// Failures emits the failure events of the failed invocations, see
+	// runner.Config.Failures.
+	Failures bool `yaml:"failures"`
+	// InvocationSummary emits the usage summary of the invocations, see
+	// runner.Config.InvocationSummary.
+	InvocationSummary bool `yaml:"invocation_summary"`
+}
+
+// BudgetConfig bounds the invocations, see agent.RunConfig.
+type BudgetConfig struct {
+	MaxTokensPerInvocation int `yaml:"max_tokens_per_invocation"`
+	MaxIterations          int `yaml:"max_iterations"`
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]func(dsn string) gorm.Dialector{}
+)
+
+// RegisterDialect registers the opener of the gorm dialector of a database
+// dialect for the sessions with BackendDatabase, e.g.
+//
+//	adk.RegisterDialect("postgres", postgres.Open)
+//
+// The dialects are registered by the binaries, so that the package doesn't
+// depend on the database drivers.
+func RegisterDialect(name string, open func(dsn string) gorm.Dialector) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	dialects[name] = open
+}
+
+func dialect(name string) (func(dsn string) gorm.Dialector, bool) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	open, ok := dialects[name]
+	return open, ok
+}
+
+// envVar binds an environment variable to a field of the config.
+type envVar struct {
+	name string
+	set  func(c *Config, v string) error
+}
+
+func stringVar(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func boolVar(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		*field(c) = b
+		return err
+	}
+}
+
+func intVar(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		*field(c) = n
+		return err
+	}
+}
+
+func durationVar(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		*field(c) = d
+		return err
+	}
+}
+
+var envVars = []envVar{
+	{"ADK_APP_NAME", stringVar(func(c *Config) *string { return &c.AppName })},
+	{"ADK_MODEL", stringVar(func(c *Config) *string { return &c.Model.Name })},
+	{"GOOGLE_API_KEY", stringVar(func(c *Config) *string { return &c.Model.APIKey })},
+	{"GOOGLE_GENAI_USE_VERTEXAI", boolVar(func(c *Config) *bool { return &c.Model.VertexAI })},
+	{"GOOGLE_CLOUD_PROJECT", stringVar(func(c *Config) *string { return &c.Model.Project })},
+	{"GOOGLE_CLOUD_LOCATION", stringVar(func(c *Config) *string { return &c.Model.Location })},
+	{"ADK_SESSION_BACKEND", stringVar(func(c *Config) *string { return &c.Sessions.Backend })},
+	{"ADK_SESSION_DIALECT", stringVar(func(c *Config) *string { return &c.Sessions.Dialect })},
+	{"ADK_SESSION_DSN", stringVar(func(c *Config) *string { return &c.Sessions.DSN })},
+	{"ADK_SESSION_TTL", durationVar(func(c *Config) *time.Duration { return &c.Sessions.TTL })},
+	{"ADK_SESSION_EXPIRY_MODE", func(c *Config, v string) error {
+		c.Sessions.ExpiryMode = session.ExpiryMode(v)
+		return nil
+	}},
+	{"ADK_ARTIFACT_BACKEND", stringVar(func(c *Config) *string { return &c.Artifacts.Backend })},
+	{"ADK_ARTIFACT_BUCKET", stringVar(func(c *Config) *string { return &c.Artifacts.Bucket })},
+	{"ADK_ARTIFACT_REGION", stringVar(func(c *Config) *string { return &c.Artifacts.Region })},
+	{"ADK_ARTIFACT_ENDPOINT", stringVar(func(c *Config) *string { return &c.Artifacts.Endpoint })},
+	{"ADK_MEMORY_BACKEND", stringVar(func(c *Config) *string { return &c.Memory.Backend })},
+	{"ADK_SLOW_SESSION_OPERATION_THRESHOLD", durationVar(func(c *Config) *time.Duration { return &c.Telemetry.SlowSessionOperationThreshold })},
+	{"ADK_FAILURE_EVENTS", boolVar(func(c *Config) *bool { return &c.Telemetry.Failures })},
+	{"ADK_INVOCATION_SUMMARY", boolVar(func(c *Config) *bool { return &c.Telemetry.InvocationSummary })},
+	{"ADK_MAX_TOKENS_PER_INVOCATION", intVar(func(c *Config) *int { return &c.Budgets.MaxTokensPerInvocation })},
+	{"ADK_MAX_ITERATIONS", intVar(func(c *Config) *int { return &c.Budgets.MaxIterations })},
+}
+
+// Load returns the validated config read from the YAML file at path, if
+// not empty, with the fields overridden by the non-empty environment
+// variables:
+//
+//	ADK_APP_NAME                          app_name
+//	ADK_MODEL                             model.name
+//	GOOGLE_API_KEY                        model.api_key
+//	GOOGLE_GENAI_USE_VERTEXAI             model.vertex_ai
+//	GOOGLE_CLOUD_PROJECT                  model.project
+//	GOOGLE_CLOUD_LOCATION                 model.location
+//	ADK_SESSION_BACKEND                   sessions.backend
+//	ADK_SESSION_DIALECT                   sessions.dialect
+//	ADK_SESSION_DSN                       sessions.dsn
+//	ADK_SESSION_TTL                       sessions.ttl
+//	ADK_SESSION_EXPIRY_MODE               sessions.expiry_mode
+//	ADK_ARTIFACT_BACKEND                  artifacts.backend
+//	ADK_ARTIFACT_BUCKET                   artifacts.bucket
+//	ADK_ARTIFACT_REGION                   artifacts.region
+//	ADK_ARTIFACT_ENDPOINT                 artifacts.endpoint
+//	ADK_MEMORY_BACKEND                    memory.backend
+//	ADK_SLOW_SESSION_OPERATION_THRESHOLD  telemetry.slow_session_operation_threshold
+//	ADK_FAILURE_EVENTS                    telemetry.failures
+//	ADK_INVOCATION_SUMMARY                telemetry.invocation_summary
+//	ADK_MAX_TOKENS_PER_INVOCATION         budgets.max_tokens_per_invocation
+//	ADK_MAX_ITERATIONS                    budgets.max_iterations
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config %q: %w", path, err)
+		}
+	}
+	var errs []error
+	for _, ev := range envVars {
+		if v := os.Getenv(ev.name); v != "" {
+			if err := ev.set(cfg, v); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %w", ev.name, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate reports all the invalid settings of the config.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.AppName == "" {
+		errs = append(errs, errors.New("app_name is required"))
+	}
+	if c.Model.VertexAI && (c.Model.Project == "" || c.Model.Location == "") {
+		errs = append(errs, errors.New("model.project and model.location are required for Vertex AI"))
+	}
+	switch c.Sessions.Backend {
+	case "", BackendInMemory:
+	case BackendDatabase:
+		if _, ok := dialect(c.Sessions.Dialect); !ok {
+			errs = append(errs, fmt.Errorf("sessions.dialect %q is not registered", c.Sessions.Dialect))
+		}
+		if c.Sessions.DSN == "" {
+			errs = append(errs, errors.New("sessions.dsn is required for the database backend"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown sessions.backend %q", c.Sessions.Backend))
+	}
+	switch c.Artifacts.Backend {
+	case "", BackendInMemory, BackendNone:
+	case BackendGCS, BackendS3:
+		if c.Artifacts.Bucket == "" {
+			errs = append(errs, fmt.Errorf("artifacts.bucket is required for the %s backend", c.Artifacts.Backend))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown artifacts.backend %q", c.Artifacts.Backend))
+	}
+	if !slices.Contains([]string{"", BackendInMemory, BackendNone}, c.Memory.Backend) {
+		errs = append(errs, fmt.Errorf("unknown memory.backend %q", c.Memory.Backend))
+	}
+	if c.Sessions.TTL < 0 {
+		errs = append(errs, errors.New("sessions.ttl must not be negative"))
+	}
+	if !slices.Contains([]session.ExpiryMode{"", session.ExpirySliding, session.ExpiryAbsolute}, c.Sessions.ExpiryMode) {
+		errs = append(errs, fmt.Errorf("unknown sessions.expiry_mode %q", c.Sessions.ExpiryMode))
+	}
+	if c.Telemetry.SlowSessionOperationThreshold < 0 {
+		errs = append(errs, errors.New("telemetry.slow_session_operation_threshold must not be negative"))
+	}
+	if c.Budgets.MaxTokensPerInvocation < 0 {
+		errs = append(errs, errors.New("budgets.max_tokens_per_invocation must not be negative"))
+	}
+	if c.Budgets.MaxIterations < 0 {
+		errs = append(errs, errors.New("budgets.max_iterations must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// NewModel returns the Gemini model of the config.
+func (c *Config) NewModel(ctx context.Context) (model.LLM, error) {
+	if c.Model.Name == "" {
+		return nil, errors.New("model.name is required")
+	}
+	cc := &genai.ClientConfig{APIKey: c.Model.APIKey}
+	if c.Model.VertexAI {
+		cc = &genai.ClientConfig{Backend: genai.BackendVertexAI, Project: c.Model.Project, Location: c.Model.Location}
+	}
+	return gemini.NewModel(ctx, c.Model.Name, cc)
+}
+
+// Services are the services of a deployment.
+type Services struct {
+	Sessions session.Service
+	// Artifacts is nil for BackendNone.
+	Artifacts artifact.Service
+	// Memory is nil for BackendNone.
+	Memory memory.Service
+}
+
+// NewServices returns the services of the config.
+func (c *Config) NewServices(ctx context.Context) (*Services, error) {
+	s := &Services{}
+	switch c.Sessions.Backend {
+	case "", BackendInMemory:
+		s.Sessions = session.InMemoryService()
+	case BackendDatabase:
+		open, ok := dialect(c.Sessions.Dialect)
+		if !ok {
+			return nil, fmt.Errorf("sessions.dialect %q is not registered", c.Sessions.Dialect)
+		}
+		svc, err := database.NewSessionService(open(c.Sessions.DSN))
+		if err != nil {
+			return nil, err
+		}
+		s.Sessions = svc
+	default:
+		return nil, fmt.Errorf("unknown sessions.backend %q", c.Sessions.Backend)
+	}
+	if c.Sessions.TTL > 0 {
+		exp, ok := s.Sessions.(session.Expirer)
+		if !ok {
+			return nil, fmt.Errorf("sessions.backend %q doesn't expire sessions", c.Sessions.Backend)
+		}
+		exp.SetExpiryPolicy(c.AppName, session.ExpiryPolicy{TTL: c.Sessions.TTL, Mode: c.Sessions.ExpiryMode})
+	}
+	switch c.Artifacts.Backend {
+	case "", BackendInMemory:
+		s.Artifacts = artifact.InMemoryService()
+	case BackendGCS:
+		svc, err := gcs.NewService(ctx, c.Artifacts.Bucket)
+		if err != nil {
+			return nil, err
+		}
+		s.Artifacts = svc
+	case BackendS3:
+		svc, err := s3.NewService(s3.Config{
+			Bucket:       c.Artifacts.Bucket,
+			Region:       c.Artifacts.Region,
+			Endpoint:     c.Artifacts.Endpoint,
+			UsePathStyle: c.Artifacts.Endpoint != "",
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.Artifacts = svc
+	}
+	if c.Memory.Backend == BackendInMemory {
+		s.Memory = memory.InMemoryService()
+	}
+	return s, nil
+}
+
+// RunnerConfig returns the config of the runner of the root agent with the
+// services and the telemetry of the config.
+func (c *Config) RunnerConfig(root agent.Agent, services *Services) runner.Config {
+	cfg := runner.Config{
+		AppName:                       c.AppName,
+		Agent:                         root,
+		SessionService:                services.Sessions,
+		ArtifactService:               services.Artifacts,
+		MemoryService:                 services.Memory,
+		SlowSessionOperationThreshold: c.Telemetry.SlowSessionOperationThreshold,
+	}
+	if c.Telemetry.Failures {
+		cfg.Failures = &runner.FailureConfig{}
+	}
+	if c.Telemetry.InvocationSummary {
+		cfg.InvocationSummary = &runner.InvocationSummaryConfig{}
+	}
+	return cfg
+}
+
+// RunConfig returns the run config with the budgets of the config.
+func (c *Config) RunConfig() agent.RunConfig {
+	return agent.RunConfig{
+		MaxTokensPerInvocation: c.Budgets.MaxTokensPerInvocation,
+		MaxIterations:          c.Budgets.MaxIterations,
+	}
+}
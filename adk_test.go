@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/session"
+	"gorm.io/driver/sqlite"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "adk.yaml")
+	if err := os.WriteFile(path, []byte(`
+app_name: support
+model:
+  name: gemini-2.5-flash
+sessions:
+  backend: database
+  dialect: sqlite
+  dsn: "file::memory:"
+  ttl: 720h
+  expiry_mode: absolute
+telemetry:
+  slow_session_operation_threshold: 2s
+  failures: true
+budgets:
+  max_tokens_per_invocation: 1000
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	RegisterDialect("sqlite", sqlite.Open)
+	for _, ev := range envVars {
+		t.Setenv(ev.name, "")
+	}
+	t.Setenv("ADK_MODEL", "gemini-2.5-pro")
+	t.Setenv("ADK_MAX_ITERATIONS", "5")
+	t.Setenv("ADK_SESSION_EXPIRY_MODE", "sliding")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{
+		AppName:   "support",
+		Model:     ModelConfig{Name: "gemini-2.5-pro"},
+		Sessions:  SessionConfig{Backend: BackendDatabase, Dialect: "sqlite", DSN: "file::memory:", TTL: 720 * time.Hour, ExpiryMode: session.ExpirySliding},
+		Telemetry: TelemetryConfig{SlowSessionOperationThreshold: 2 * time.Second, Failures: true},
+		Budgets:   BudgetConfig{MaxTokensPerInvocation: 1000, MaxIterations: 5},
+	}
+	if diff := cmp.Diff(want, cfg); diff != "" {
+		t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+	}
+
+	services, err := cfg.NewServices(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if services.Sessions == nil || services.Artifacts == nil || services.Memory != nil {
+		t.Errorf("NewServices() = %+v, want sessions and artifacts only", services)
+	}
+	rc := cfg.RunnerConfig(nil, services)
+	if rc.AppName != "support" || rc.SessionService != services.Sessions || rc.Failures == nil || rc.InvocationSummary != nil {
+		t.Errorf("RunnerConfig() = %+v, want the app name, the services and the failure events", rc)
+	}
+	if got := cfg.RunConfig(); got.MaxTokensPerInvocation != 1000 || got.MaxIterations != 5 {
+		t.Errorf("RunConfig() = %+v, want the budgets", got)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for _, ev := range envVars {
+		t.Setenv(ev.name, "")
+	}
+	t.Setenv("ADK_SESSION_BACKEND", "redis")
+	t.Setenv("ADK_ARTIFACT_BACKEND", "gcs")
+	t.Setenv("ADK_MAX_TOKENS_PER_INVOCATION", "-1")
+	_, err := Load("")
+	if err == nil {
+		t.Fatal("Load() succeeded, want error")
+	}
+	for _, want := range []string{"app_name", "sessions.backend", "artifacts.bucket", "max_tokens_per_invocation"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v, want it to report %s", err, want)
+		}
+	}
+
+	t.Setenv("ADK_MAX_ITERATIONS", "many")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "ADK_MAX_ITERATIONS") {
+		t.Errorf("Load() error = %v, want the invalid ADK_MAX_ITERATIONS", err)
+	}
+}
+
+func TestNewServices_S3(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	cfg := &Config{Artifacts: ArtifactConfig{Backend: BackendS3, Bucket: "artifacts", Endpoint: "http://localhost:9000"}}
+	services, err := cfg.NewServices(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if services.Artifacts == nil {
+		t.Error("NewServices() returned no artifact service")
+	}
+}
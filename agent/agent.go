@@ -21,6 +21,7 @@ import (
 
 	"google.golang.org/adk/artifact"
 	agentinternal "google.golang.org/adk/internal/agent"
+	"google.golang.org/adk/internal/plugininternal/plugincontext"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
@@ -39,6 +40,8 @@ import (
 type Agent interface {
 	Name() string
 	Description() string
+	// Capabilities returns the structured capabilities of the agent.
+	Capabilities() Capabilities
 	Run(InvocationContext) iter.Seq2[*session.Event, error]
 	SubAgents() []Agent
 
@@ -57,6 +60,7 @@ func New(cfg Config) (Agent, error) {
 	return &agent{
 		name:                 cfg.Name,
 		description:          cfg.Description,
+		capabilities:         cfg.Capabilities,
 		subAgents:            cfg.SubAgents,
 		beforeAgentCallbacks: cfg.BeforeAgentCallbacks,
 		run:                  cfg.Run,
@@ -70,13 +74,19 @@ func New(cfg Config) (Agent, error) {
 // Config is the configuration for creating a new Agent.
 type Config struct {
 	// Name must be a non-empty string, unique within the agent tree.
-	// Agent name cannot be "user", since it's reserved for end-user's input.
+	// Agent name cannot be "user" or "developer", since they're reserved for
+	// end-user's input and for the developer messages.
 	Name string
 	// Description of the agent's capability.
 	//
 	// LLM uses this to determine whether to delegate control to the agent.
 	// One-line description is enough and preferred.
 	Description string
+	// Capabilities declare what the agent can do in a structured form. LLM
+	// uses them together with the description to determine whether to
+	// delegate control to the agent.
+	// Optional.
+	Capabilities Capabilities
 	// SubAgents are the child agents that this agent can delegate tasks to.
 	// ADK will automatically set a parent of each sub-agent to this agent to
 	// allow agent transferring across the tree.
@@ -134,6 +144,7 @@ type agent struct {
 	agentinternal.State
 
 	name, description string
+	capabilities      Capabilities
 	subAgents         []Agent
 
 	beforeAgentCallbacks []BeforeAgentCallback
@@ -149,6 +160,10 @@ func (a *agent) Description() string {
 	return a.description
 }
 
+func (a *agent) Capabilities() Capabilities {
+	return a.capabilities
+}
+
 func (a *agent) SubAgents() []Agent {
 	return a.subAgents
 }
@@ -163,11 +178,13 @@ func (a *agent) Run(ctx InvocationContext) iter.Seq2[*session.Event, error] {
 			memory:    ctx.Memory(),
 			session:   ctx.Session(),
 
-			invocationID:  ctx.InvocationID(),
-			branch:        ctx.Branch(),
-			userContent:   ctx.UserContent(),
-			runConfig:     ctx.RunConfig(),
-			endInvocation: ctx.Ended(),
+			invocationID:       ctx.InvocationID(),
+			parentInvocationID: ctx.ParentInvocationID(),
+			branch:             ctx.Branch(),
+			userContent:        ctx.UserContent(),
+			runConfig:          ctx.RunConfig(),
+			tokens:             ctx.Tokens(),
+			endInvocation:      ctx.Ended(),
 		}
 
 		event, err := runBeforeAgentCallbacks(ctx)
@@ -185,6 +202,9 @@ func (a *agent) Run(ctx InvocationContext) iter.Seq2[*session.Event, error] {
 			if event != nil && event.Author == "" {
 				event.Author = getAuthorForEvent(ctx, event)
 			}
+			if event != nil && event.InvocationID == ctx.InvocationID() && event.ParentInvocationID == "" {
+				event.ParentInvocationID = ctx.ParentInvocationID()
+			}
 			if !yield(event, err) {
 				return
 			}
@@ -213,18 +233,38 @@ func getAuthorForEvent(ctx InvocationContext, event *session.Event) string {
 	return ctx.Agent().Name()
 }
 
-// runBeforeAgentCallbacks checks if any beforeAgentCallback returns non-nil content
-// then it skips agent run and returns callback result.
-func runBeforeAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
-	agent := ctx.Agent()
+// pluginCallbacks is implemented by the plugin manager of the runner, which
+// is stored in the context.
+type pluginCallbacks interface {
+	BeforeAgentCallbacks() []BeforeAgentCallback
+	AfterAgentCallbacks() []AfterAgentCallback
+}
 
-	callbackCtx := &callbackContext{
-		Context:           ctx,
-		invocationContext: ctx,
-		actions:           &session.EventActions{StateDelta: make(map[string]any)},
+// beforeAgentCallbacks returns the callbacks of the plugins followed by the
+// callbacks of the agent.
+func beforeAgentCallbacks(ctx InvocationContext) []BeforeAgentCallback {
+	callbacks := ctx.Agent().internal().beforeAgentCallbacks
+	if p, ok := plugincontext.FromContext(ctx).(pluginCallbacks); ok {
+		callbacks = append(p.BeforeAgentCallbacks(), callbacks...)
 	}
+	return callbacks
+}
 
-	for _, callback := range ctx.Agent().internal().beforeAgentCallbacks {
+// afterAgentCallbacks returns the callbacks of the plugins followed by the
+// callbacks of the agent.
+func afterAgentCallbacks(ctx InvocationContext) []AfterAgentCallback {
+	callbacks := ctx.Agent().internal().afterAgentCallbacks
+	if p, ok := plugincontext.FromContext(ctx).(pluginCallbacks); ok {
+		callbacks = append(p.AfterAgentCallbacks(), callbacks...)
+	}
+	return callbacks
+}
+
+// runBeforeAgentCallbacks checks if any beforeAgentCallback returns non-nil content
+// then it skips agent run and returns callback result.
+func runBeforeAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
+	callbackCtx := newCallbackContext(ctx)
+	for _, callback := range beforeAgentCallbacks(ctx) {
 		content, err := callback(callbackCtx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to run before agent callback: %w", err)
@@ -232,42 +272,22 @@ func runBeforeAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
 		if content == nil {
 			continue
 		}
-
-		event := session.NewEvent(ctx.InvocationID())
-		event.LLMResponse = model.LLMResponse{
-			Content: content,
-		}
-		event.Author = agent.Name()
-		event.Branch = ctx.Branch()
-		event.Actions = *callbackCtx.actions
 		ctx.EndInvocation()
-		return event, nil
+		return callbackCtx.event(content), nil
 	}
 
 	// check if has delta create event with it
-	if len(callbackCtx.actions.StateDelta) > 0 {
-		event := session.NewEvent(ctx.InvocationID())
-		event.Author = agent.Name()
-		event.Branch = ctx.Branch()
-		event.Actions = *callbackCtx.actions
-		return event, nil
+	if callbackCtx.hasDelta() {
+		return callbackCtx.event(nil), nil
 	}
-
 	return nil, nil
 }
 
 // runAfterAgentCallbacks checks if any afterAgentCallback returns non-nil content or a state modification
 // then it create a new event with the new content and state delta.
 func runAfterAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
-	agent := ctx.Agent()
-
-	callbackCtx := &callbackContext{
-		Context:           ctx,
-		invocationContext: ctx,
-		actions:           &session.EventActions{StateDelta: make(map[string]any)},
-	}
-
-	for _, callback := range agent.internal().afterAgentCallbacks {
+	callbackCtx := newCallbackContext(ctx)
+	for _, callback := range afterAgentCallbacks(ctx) {
 		newContent, err := callback(callbackCtx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to run after agent callback: %w", err)
@@ -275,38 +295,58 @@ func runAfterAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
 		if newContent == nil {
 			continue
 		}
-
-		event := session.NewEvent(ctx.InvocationID())
-		event.LLMResponse = model.LLMResponse{
-			Content: newContent,
-		}
-		event.Author = agent.Name()
-		event.Branch = ctx.Branch()
-		event.Actions = *callbackCtx.actions
 		// TODO set context invocation ended
 		// ctx.invocationEnded = true
-		return event, nil
+		return callbackCtx.event(newContent), nil
 	}
 
 	// check if has delta create event with it
-	if len(callbackCtx.actions.StateDelta) > 0 {
-		event := session.NewEvent(ctx.InvocationID())
-		event.Author = agent.Name()
-		event.Branch = ctx.Branch()
-		event.Actions = *callbackCtx.actions
-		return event, nil
+	if callbackCtx.hasDelta() {
+		return callbackCtx.event(nil), nil
 	}
 	return nil, nil
 }
 
 // TODO: unify with internal/context.callbackContext
 
+// callbackContext is the CallbackContext of the agent callbacks. It records
+// the state changes and the saved artifacts of the callbacks in its actions,
+// which are attached to the event of the callbacks.
 type callbackContext struct {
 	context.Context
 	invocationContext InvocationContext
 	actions           *session.EventActions
 }
 
+func newCallbackContext(ctx InvocationContext) *callbackContext {
+	return &callbackContext{
+		Context:           ctx,
+		invocationContext: ctx,
+		actions:           &session.EventActions{StateDelta: make(map[string]any)},
+	}
+}
+
+// hasDelta reports whether the callbacks changed the state or saved
+// artifacts.
+func (c *callbackContext) hasDelta() bool {
+	return len(c.actions.StateDelta) > 0 || len(c.actions.ArtifactDelta) > 0
+}
+
+// event returns the event of the callbacks, with the given content and the
+// recorded actions.
+func (c *callbackContext) event(content *genai.Content) *session.Event {
+	ctx := c.invocationContext
+	event := session.NewEvent(ctx.InvocationID())
+	event.LLMResponse = model.LLMResponse{
+		Content: content,
+	}
+	event.Author = ctx.Agent().Name()
+	event.Branch = ctx.Branch()
+	event.ParentInvocationID = ctx.ParentInvocationID()
+	event.Actions = *c.actions
+	return event
+}
+
 func (c *callbackContext) AgentName() string {
 	return c.invocationContext.Agent().Name()
 }
@@ -320,7 +360,11 @@ func (c *callbackContext) State() session.State {
 }
 
 func (c *callbackContext) Artifacts() Artifacts {
-	return c.invocationContext.Artifacts()
+	artifacts := c.invocationContext.Artifacts()
+	if artifacts == nil {
+		return nil
+	}
+	return &callbackArtifacts{Artifacts: artifacts, actions: c.actions}
 }
 
 func (c *callbackContext) InvocationID() string {
@@ -377,6 +421,25 @@ func (c *callbackContextState) All() iter.Seq2[string, any] {
 	return c.ctx.invocationContext.Session().State().All()
 }
 
+// callbackArtifacts records the versions of the artifacts saved by the
+// callbacks in the artifact delta of their event.
+type callbackArtifacts struct {
+	Artifacts
+	actions *session.EventActions
+}
+
+func (a *callbackArtifacts) Save(ctx context.Context, name string, data *genai.Part) (*artifact.SaveResponse, error) {
+	resp, err := a.Artifacts.Save(ctx, name, data)
+	if err != nil {
+		return resp, err
+	}
+	if a.actions.ArtifactDelta == nil {
+		a.actions.ArtifactDelta = make(map[string]int64)
+	}
+	a.actions.ArtifactDelta[name] = resp.Version
+	return resp, nil
+}
+
 type invocationContext struct {
 	context.Context
 
@@ -385,11 +448,13 @@ type invocationContext struct {
 	memory    Memory
 	session   session.Session
 
-	invocationID  string
-	branch        string
-	userContent   *genai.Content
-	runConfig     *RunConfig
-	endInvocation bool
+	invocationID       string
+	parentInvocationID string
+	branch             string
+	userContent        *genai.Content
+	runConfig          *RunConfig
+	tokens             *TokenCounter
+	endInvocation      bool
 }
 
 func (c *invocationContext) Agent() Agent {
@@ -412,6 +477,10 @@ func (c *invocationContext) InvocationID() string {
 	return c.invocationID
 }
 
+func (c *invocationContext) ParentInvocationID() string {
+	return c.parentInvocationID
+}
+
 func (c *invocationContext) Branch() string {
 	return c.branch
 }
@@ -424,6 +493,10 @@ func (c *invocationContext) RunConfig() *RunConfig {
 	return c.runConfig
 }
 
+func (c *invocationContext) Tokens() *TokenCounter {
+	return c.tokens
+}
+
 func (c *invocationContext) EndInvocation() {
 	c.endInvocation = true
 }
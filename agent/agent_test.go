@@ -15,11 +15,13 @@
 package agent
 
 import (
+	"context"
 	"iter"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/adk/artifact"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
 	"google.golang.org/genai"
@@ -113,7 +115,8 @@ func TestAgentCallbacks(t *testing.T) {
 			}
 
 			ctx := &invocationContext{
-				agent: testAgent,
+				Context: t.Context(),
+				agent:   testAgent,
 			}
 			var gotEvents []*session.Event
 			for event, err := range testAgent.Run(ctx) {
@@ -159,6 +162,7 @@ func TestEndInvocation_EndsBeforeMainCall(t *testing.T) {
 	}
 
 	ctx := &invocationContext{
+		Context:       t.Context(),
 		agent:         testAgent,
 		endInvocation: true,
 	}
@@ -192,7 +196,8 @@ func TestEndInvocation_EndsAfterMainCall(t *testing.T) {
 	}
 
 	ctx := &invocationContext{
-		agent: testAgent,
+		Context: t.Context(),
+		agent:   testAgent,
 	}
 	var gotEvents []*session.Event
 	for event, err := range testAgent.Run(ctx) {
@@ -222,6 +227,79 @@ func TestEndInvocation_EndsAfterMainCall(t *testing.T) {
 }
 
 // TODO: create test util allowing to create custom agents, agent trees for test etc.
+// versionedArtifacts saves every artifact as version 1.
+type versionedArtifacts struct {
+	Artifacts
+	saved map[string]*genai.Part
+}
+
+func (a *versionedArtifacts) Save(ctx context.Context, name string, data *genai.Part) (*artifact.SaveResponse, error) {
+	a.saved[name] = data
+	return &artifact.SaveResponse{Version: 1}, nil
+}
+
+func TestAgentCallbacks_StateAndArtifacts(t *testing.T) {
+	resp, err := session.InMemoryService().Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifacts := &versionedArtifacts{saved: make(map[string]*genai.Part)}
+	userContent := genai.NewContentFromText("summarize the report", genai.RoleUser)
+
+	custom := &customAgent{}
+	testAgent, err := New(Config{
+		Name: "test",
+		BeforeAgentCallbacks: []BeforeAgentCallback{
+			func(ctx CallbackContext) (*genai.Content, error) {
+				if err := ctx.State().Set("request", ctx.UserContent().Parts[0].Text); err != nil {
+					return nil, err
+				}
+				_, err := ctx.Artifacts().Save(ctx, "request.txt", genai.NewPartFromText(ctx.UserContent().Parts[0].Text))
+				return nil, err
+			},
+		},
+		Run: custom.Run,
+	})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	ctx := &invocationContext{
+		Context:     t.Context(),
+		agent:       testAgent,
+		session:     resp.Session,
+		artifacts:   artifacts,
+		userContent: userContent,
+	}
+	var gotEvents []*session.Event
+	for event, err := range testAgent.Run(ctx) {
+		if err != nil {
+			t.Fatalf("unexpected error from the agent: %v", err)
+		}
+		gotEvents = append(gotEvents, event)
+	}
+
+	if len(gotEvents) != 2 {
+		t.Fatalf("got %d events, want the callback event and the agent event", len(gotEvents))
+	}
+	wantActions := session.EventActions{
+		StateDelta:    map[string]any{"request": "summarize the report"},
+		ArtifactDelta: map[string]int64{"request.txt": 1},
+	}
+	if diff := cmp.Diff(wantActions, gotEvents[0].Actions); diff != "" {
+		t.Errorf("callback event actions mismatch (-want +got):\n%s", diff)
+	}
+	if got, err := resp.Session.State().Get("request"); err != nil || got != "summarize the report" {
+		t.Errorf("session state request = %v, %v", got, err)
+	}
+	if _, ok := artifacts.saved["request.txt"]; !ok {
+		t.Errorf("artifact request.txt was not saved")
+	}
+	if custom.callCounter != 1 {
+		t.Errorf("agent ran %d times, want 1", custom.callCounter)
+	}
+}
+
 type customAgent struct {
 	callCounter   int
 	endInvocation bool
@@ -0,0 +1,288 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package agentconfig builds agent trees from declarative YAML agent
+// configs, in the Agent Config format used by adk-python.
+//
+// Example root_agent.yaml:
+//
+//	agent_class: LlmAgent
+//	name: assistant
+//	model: gemini-2.5-flash
+//	instruction: You are a helpful assistant.
+//	tools:
+//	  - name: google_search
+//	sub_agents:
+//	  - config_path: researcher.yaml
+//	  - code: billing_agent
+//
+// Tools are resolved by name with a [ToolRegistry]. Sub-agents are either
+// loaded from other config files (relative to the referencing file) or
+// referenced by name from the agents defined in Go code.
+package agentconfig
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/agent/workflowagents/loopagent"
+	"google.golang.org/adk/agent/workflowagents/parallelagent"
+	"google.golang.org/adk/agent/workflowagents/sequentialagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/gemini"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+	"gopkg.in/yaml.v3"
+)
+
+// Agent classes supported in the agent_class field.
+const (
+	ClassLLMAgent        = "LlmAgent"
+	ClassSequentialAgent = "SequentialAgent"
+	ClassParallelAgent   = "ParallelAgent"
+	ClassLoopAgent       = "LoopAgent"
+)
+
+// AgentConfig is the YAML representation of an agent.
+type AgentConfig struct {
+	// AgentClass is one of the Class* constants. Defaults to ClassLLMAgent.
+	AgentClass  string `yaml:"agent_class"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Capabilities of the agent, e.g.
+	//
+	//	capabilities:
+	//	  domains: [billing]
+	//	  cost_tier: low
+	Capabilities agent.Capabilities `yaml:"capabilities"`
+
+	// LlmAgent fields.
+	Model                    string       `yaml:"model"`
+	StaticInstruction        string       `yaml:"static_instruction"`
+	Instruction              string       `yaml:"instruction"`
+	GlobalInstruction        string       `yaml:"global_instruction"`
+	Tools                    []ToolConfig `yaml:"tools"`
+	OutputKey                string       `yaml:"output_key"`
+	IncludeContents          string       `yaml:"include_contents"`
+	DisallowTransferToParent bool         `yaml:"disallow_transfer_to_parent"`
+	DisallowTransferToPeers  bool         `yaml:"disallow_transfer_to_peers"`
+
+	// LoopAgent fields.
+	MaxIterations uint `yaml:"max_iterations"`
+
+	// ParallelAgent fields.
+	MaxConcurrency int `yaml:"max_concurrency"`
+	MaxTokens      int `yaml:"max_tokens"`
+
+	SubAgents []SubAgentConfig `yaml:"sub_agents"`
+}
+
+// ToolConfig references a tool registered in the [ToolRegistry].
+type ToolConfig struct {
+	Name string `yaml:"name"`
+	// Args are passed to the tool factory.
+	// Optional.
+	Args map[string]any `yaml:"args"`
+}
+
+// SubAgentConfig references a sub-agent. Exactly one of the fields must be
+// set.
+type SubAgentConfig struct {
+	// ConfigPath is the path of the sub-agent config file, relative to the
+	// file of the parent agent.
+	ConfigPath string `yaml:"config_path"`
+	// Code is the name of an agent from Config.Agents.
+	Code string `yaml:"code"`
+}
+
+// Config is used to load agent configs.
+type Config struct {
+	// Tools resolves the tool names.
+	// Optional: if not set, only the built-in tools are available.
+	Tools *ToolRegistry
+	// Agents are the agents defined in code, which can be referenced as
+	// sub-agents by name.
+	// Optional.
+	Agents map[string]agent.Agent
+	// Model creates the model of an LlmAgent from the model name.
+	// Optional: if not set, Gemini models are created with the default client
+	// configuration.
+	Model func(ctx context.Context, name string) (model.LLM, error)
+}
+
+// Load builds the agent tree defined by the config file at the given path.
+func Load(ctx context.Context, path string, cfg Config) (agent.Agent, error) {
+	if cfg.Tools == nil {
+		cfg.Tools = NewToolRegistry()
+	}
+	if cfg.Model == nil {
+		cfg.Model = func(ctx context.Context, name string) (model.LLM, error) {
+			return gemini.NewModel(ctx, name, &genai.ClientConfig{})
+		}
+	}
+	l := &loader{cfg: cfg, loading: make(map[string]bool)}
+	return l.load(ctx, path)
+}
+
+// Parse decodes an agent config.
+func Parse(data []byte) (*AgentConfig, error) {
+	var ac AgentConfig
+	if err := yaml.Unmarshal(data, &ac); err != nil {
+		return nil, fmt.Errorf("failed to parse agent config: %w", err)
+	}
+	if ac.Name == "" {
+		return nil, fmt.Errorf("agent config has no name")
+	}
+	if ac.AgentClass == "" {
+		ac.AgentClass = ClassLLMAgent
+	}
+	return &ac, nil
+}
+
+type loader struct {
+	cfg Config
+	// loading holds the config files being loaded, to detect cycles.
+	loading map[string]bool
+}
+
+func (l *loader) load(ctx context.Context, path string) (agent.Agent, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve agent config path: %w", err)
+	}
+	if l.loading[path] {
+		return nil, fmt.Errorf("agent config %q references itself", path)
+	}
+	l.loading[path] = true
+	defer delete(l.loading, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent config: %w", err)
+	}
+	ac, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	a, err := l.build(ctx, ac, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build agent %q from %s: %w", ac.Name, path, err)
+	}
+	return a, nil
+}
+
+func (l *loader) build(ctx context.Context, ac *AgentConfig, dir string) (agent.Agent, error) {
+	var subAgents []agent.Agent
+	for _, sc := range ac.SubAgents {
+		sub, err := l.subAgent(ctx, sc, dir)
+		if err != nil {
+			return nil, err
+		}
+		subAgents = append(subAgents, sub)
+	}
+
+	base := agent.Config{
+		Name:         ac.Name,
+		Description:  ac.Description,
+		Capabilities: ac.Capabilities,
+		SubAgents:    subAgents,
+	}
+	switch ac.AgentClass {
+	case ClassLLMAgent:
+		return l.buildLLMAgent(ctx, ac, subAgents)
+	case ClassSequentialAgent:
+		return sequentialagent.New(sequentialagent.Config{AgentConfig: base})
+	case ClassParallelAgent:
+		return parallelagent.New(parallelagent.Config{AgentConfig: base, MaxConcurrency: ac.MaxConcurrency, MaxTokens: ac.MaxTokens})
+	case ClassLoopAgent:
+		return loopagent.New(loopagent.Config{AgentConfig: base, MaxIterations: ac.MaxIterations})
+	default:
+		return nil, fmt.Errorf("unsupported agent_class %q", ac.AgentClass)
+	}
+}
+
+func (l *loader) subAgent(ctx context.Context, sc SubAgentConfig, dir string) (agent.Agent, error) {
+	switch {
+	case sc.ConfigPath != "" && sc.Code != "":
+		return nil, fmt.Errorf("sub-agent must set only one of config_path and code")
+	case sc.ConfigPath != "":
+		path := sc.ConfigPath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		return l.load(ctx, path)
+	case sc.Code != "":
+		a, ok := l.cfg.Agents[sc.Code]
+		if !ok {
+			return nil, fmt.Errorf("unknown code agent %q", sc.Code)
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("sub-agent must set config_path or code")
+	}
+}
+
+func (l *loader) buildLLMAgent(ctx context.Context, ac *AgentConfig, subAgents []agent.Agent) (agent.Agent, error) {
+	if ac.Model == "" {
+		return nil, fmt.Errorf("model is required for %s", ClassLLMAgent)
+	}
+	m, err := l.cfg.Model(ctx, ac.Model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create model %q: %w", ac.Model, err)
+	}
+
+	var tools []tool.Tool
+	for _, tc := range ac.Tools {
+		t, err := l.cfg.Tools.Tool(tc.Name, tc.Args)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, t)
+	}
+
+	var includeContents llmagent.IncludeContents
+	switch ac.IncludeContents {
+	case "", string(llmagent.IncludeContentsDefault):
+		includeContents = llmagent.IncludeContentsDefault
+	case string(llmagent.IncludeContentsNone):
+		includeContents = llmagent.IncludeContentsNone
+	default:
+		return nil, fmt.Errorf("unsupported include_contents %q", ac.IncludeContents)
+	}
+
+	var staticInstruction *genai.Content
+	if ac.StaticInstruction != "" {
+		staticInstruction = genai.NewContentFromText(ac.StaticInstruction, genai.RoleUser)
+	}
+
+	return llmagent.New(llmagent.Config{
+		Name:                     ac.Name,
+		Description:              ac.Description,
+		Capabilities:             ac.Capabilities,
+		SubAgents:                subAgents,
+		Model:                    m,
+		StaticInstruction:        staticInstruction,
+		Instruction:              ac.Instruction,
+		GlobalInstruction:        ac.GlobalInstruction,
+		Tools:                    tools,
+		OutputKey:                ac.OutputKey,
+		IncludeContents:          includeContents,
+		DisallowTransferToParent: ac.DisallowTransferToParent,
+		DisallowTransferToPeers:  ac.DisallowTransferToPeers,
+	})
+}
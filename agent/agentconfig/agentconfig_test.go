@@ -0,0 +1,184 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentconfig_test
+
+import (
+	"context"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/agentconfig"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// tree returns the names of the agents in the tree, in depth-first order.
+func tree(a agent.Agent) []string {
+	names := []string{a.Name()}
+	for _, sub := range a.SubAgents() {
+		names = append(names, tree(sub)...)
+	}
+	return names
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"root_agent.yaml": `
+name: root
+model: test-model
+instruction: You are a helpful assistant.
+tools:
+  - name: google_search
+  - name: get_weather
+sub_agents:
+  - config_path: pipeline.yaml
+`,
+		"pipeline.yaml": `
+agent_class: SequentialAgent
+name: pipeline
+sub_agents:
+  - code: coder
+  - config_path: loop.yaml
+`,
+		"loop.yaml": `
+agent_class: LoopAgent
+name: loop
+max_iterations: 3
+sub_agents:
+  - config_path: reviewer.yaml
+`,
+		"reviewer.yaml": `
+name: reviewer
+model: other-model
+capabilities:
+  domains: [code_review]
+  cost_tier: low
+tools:
+  - name: exit_loop
+`,
+	})
+
+	weatherTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather",
+	}, func(tool.Context, map[string]any) (map[string]any, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := agentconfig.NewToolRegistry()
+	tools.RegisterTools(weatherTool)
+
+	coder, err := agent.New(agent.Config{
+		Name: "coder",
+		Run: func(agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(func(*session.Event, error) bool) {}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var models []string
+	root, err := agentconfig.Load(t.Context(), filepath.Join(dir, "root_agent.yaml"), agentconfig.Config{
+		Tools:  tools,
+		Agents: map[string]agent.Agent{"coder": coder},
+		Model: func(_ context.Context, name string) (model.LLM, error) {
+			models = append(models, name)
+			return &testutil.MockModel{}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"root", "pipeline", "coder", "loop", "reviewer"}, tree(root)); diff != "" {
+		t.Errorf("agent tree mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"other-model", "test-model"}, models); diff != "" {
+		t.Errorf("created models mismatch (-want +got):\n%s", diff)
+	}
+	reviewer := root.SubAgents()[0].SubAgents()[1].SubAgents()[0]
+	if diff := cmp.Diff(agent.Capabilities{Domains: []string{"code_review"}, CostTier: "low"}, reviewer.Capabilities()); diff != "" {
+		t.Errorf("reviewer capabilities mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "missing name",
+			files:   map[string]string{"root_agent.yaml": "model: test-model\n"},
+			wantErr: "no name",
+		},
+		{
+			name:    "unknown tool",
+			files:   map[string]string{"root_agent.yaml": "name: root\nmodel: test-model\ntools:\n  - name: unknown\n"},
+			wantErr: `unknown tool "unknown"`,
+		},
+		{
+			name:    "unknown agent class",
+			files:   map[string]string{"root_agent.yaml": "name: root\nagent_class: FancyAgent\n"},
+			wantErr: `unsupported agent_class "FancyAgent"`,
+		},
+		{
+			name:    "unknown code agent",
+			files:   map[string]string{"root_agent.yaml": "name: root\nagent_class: SequentialAgent\nsub_agents:\n  - code: missing\n"},
+			wantErr: `unknown code agent "missing"`,
+		},
+		{
+			name:    "cycle",
+			files:   map[string]string{"root_agent.yaml": "name: root\nagent_class: SequentialAgent\nsub_agents:\n  - config_path: root_agent.yaml\n"},
+			wantErr: "references itself",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			_, err := agentconfig.Load(t.Context(), filepath.Join(dir, "root_agent.yaml"), agentconfig.Config{
+				Model: func(context.Context, string) (model.LLM, error) {
+					return &testutil.MockModel{}, nil
+				},
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
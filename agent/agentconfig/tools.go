@@ -0,0 +1,87 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentconfig
+
+import (
+	"fmt"
+	"sync"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/exitlooptool"
+	"google.golang.org/adk/tool/geminitool"
+	"google.golang.org/adk/tool/loadartifactstool"
+)
+
+// ToolFactory creates a tool from the args set in the agent config.
+type ToolFactory func(args map[string]any) (tool.Tool, error)
+
+// ToolRegistry resolves the tool names used in agent configs to the tool
+// implementations. It is safe for concurrent use.
+type ToolRegistry struct {
+	mu        sync.RWMutex
+	factories map[string]ToolFactory
+}
+
+// NewToolRegistry returns a registry with the built-in tools:
+// google_search, exit_loop and load_artifacts.
+func NewToolRegistry() *ToolRegistry {
+	r := &ToolRegistry{factories: make(map[string]ToolFactory)}
+	r.Register("google_search", func(map[string]any) (tool.Tool, error) {
+		return geminitool.GoogleSearch{}, nil
+	})
+	r.Register("exit_loop", func(map[string]any) (tool.Tool, error) {
+		return exitlooptool.New()
+	})
+	r.Register("load_artifacts", func(map[string]any) (tool.Tool, error) {
+		return loadartifactstool.New(), nil
+	})
+	return r
+}
+
+// Register registers the tool factory under the given name, replacing the
+// previously registered one.
+func (r *ToolRegistry) Register(name string, factory ToolFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[name] = factory
+}
+
+// RegisterTools registers the tools under their names. The tools don't
+// accept args.
+func (r *ToolRegistry) RegisterTools(tools ...tool.Tool) {
+	for _, t := range tools {
+		r.Register(t.Name(), func(args map[string]any) (tool.Tool, error) {
+			if len(args) > 0 {
+				return nil, fmt.Errorf("tool %q does not accept args", t.Name())
+			}
+			return t, nil
+		})
+	}
+}
+
+// Tool creates the tool registered under the given name.
+func (r *ToolRegistry) Tool(name string, args map[string]any) (tool.Tool, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown tool %q", name)
+	}
+	t, err := factory(args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tool %q: %w", name, err)
+	}
+	return t, nil
+}
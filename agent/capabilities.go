@@ -0,0 +1,38 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+// Capabilities describe what an agent can do in a structured form, in
+// addition to its free-text description.
+//
+// LLM agents render the capabilities of their transfer targets in the
+// transfer instructions, which helps the model to pick the right agent in
+// large agent trees.
+type Capabilities struct {
+	// Domains the agent is competent in, e.g. "billing" or "travel".
+	Domains []string `json:"domains,omitempty" yaml:"domains"`
+	// Languages the agent can converse in, e.g. "en" or "de".
+	Languages []string `json:"languages,omitempty" yaml:"languages"`
+	// Tools available to the agent, e.g. "issue_refund".
+	Tools []string `json:"tools,omitempty" yaml:"tools"`
+	// CostTier of the agent, e.g. "low" or "high". Cheaper agents are
+	// preferred when several agents match the request.
+	CostTier string `json:"cost_tier,omitempty" yaml:"cost_tier"`
+}
+
+// IsZero reports whether no capabilities are declared.
+func (c Capabilities) IsZero() bool {
+	return len(c.Domains) == 0 && len(c.Languages) == 0 && len(c.Tools) == 0 && c.CostTier == ""
+}
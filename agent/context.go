@@ -72,6 +72,12 @@ type InvocationContext interface {
 	Session() session.Session
 
 	InvocationID() string
+	// ParentInvocationID returns the ID of the invocation which started this
+	// invocation: the invocation of the agent which called this agent as a
+	// tool, or of the tool or callback which ran a runner with its context.
+	// The agent transfers and the sub-agents of the workflow agents run in
+	// the invocation of their caller. Empty for the root invocation.
+	ParentInvocationID() string
 
 	// Branch of the invocation context.
 	// The format is like agent_1.agent_2.agent_3, where agent_1 is the parent
@@ -89,6 +95,11 @@ type InvocationContext interface {
 	// RunConfig stores the runtime configuration used during this invocation.
 	RunConfig() *RunConfig
 
+	// Tokens counts the tokens used by the model calls of the invocation.
+	// The invocations of the agents it transfers to or calls as tools share
+	// the counter.
+	Tokens() *TokenCounter
+
 	// EndInvocation ends the current invocation. This stops any planned agent
 	// calls.
 	EndInvocation()
@@ -114,9 +125,18 @@ type ReadonlyContext interface {
 }
 
 // CallbackContext is passed to user callbacks during agent execution.
+//
+// The changes made through the context are recorded in the actions of the
+// event of the callbacks, so that they are persisted with the session and
+// visible to the rest of the invocation.
 type CallbackContext interface {
 	ReadonlyContext
 
+	// Artifacts of the session. The versions of the saved artifacts are
+	// recorded in the ArtifactDelta of the event. It's nil if the runner has
+	// no artifact service.
 	Artifacts() Artifacts
+	// State of the session. The values set are recorded in the StateDelta
+	// of the event.
 	State() session.State
 }
@@ -0,0 +1,27 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import "errors"
+
+var (
+	// ErrMaxIterations is wrapped by the error ending the run of an agent
+	// which reached RunConfig.MaxIterations.
+	ErrMaxIterations = errors.New("maximum number of iterations reached")
+	// ErrInvocationCancelled is wrapped, along with the error of the
+	// context, by the error ending an invocation whose context was canceled
+	// or timed out.
+	ErrInvocationCancelled = errors.New("invocation cancelled")
+)
@@ -17,15 +17,20 @@ package llmagent
 import (
 	"fmt"
 	"iter"
+	"slices"
 	"strings"
+	"time"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/codeexecutor"
 	agentinternal "google.golang.org/adk/internal/agent"
 	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/llminternal"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/summarizer"
 	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/toolresult"
 	"google.golang.org/genai"
 )
 
@@ -51,6 +56,21 @@ func New(cfg Config) (agent.Agent, error) {
 		afterToolCallbacks = append(afterToolCallbacks, llminternal.AfterToolCallback(c))
 	}
 
+	beforeTransferCallbacks := make([]llminternal.BeforeTransferCallback, 0, len(cfg.BeforeTransferCallbacks))
+	for _, c := range cfg.BeforeTransferCallbacks {
+		beforeTransferCallbacks = append(beforeTransferCallbacks, llminternal.BeforeTransferCallback(c))
+	}
+
+	requestProcessors := make([]func(agent.InvocationContext, *model.LLMRequest) error, 0, len(cfg.RequestProcessors))
+	for _, p := range cfg.RequestProcessors {
+		requestProcessors = append(requestProcessors, p)
+	}
+
+	responseProcessors := make([]func(agent.InvocationContext, *model.LLMRequest, *model.LLMResponse) error, 0, len(cfg.ResponseProcessors))
+	for _, p := range cfg.ResponseProcessors {
+		responseProcessors = append(responseProcessors, p)
+	}
+
 	a := &llmAgent{
 		beforeModelCallbacks: beforeModelCallbacks,
 		model:                cfg.Model,
@@ -61,9 +81,21 @@ func New(cfg Config) (agent.Agent, error) {
 		inputSchema:          cfg.InputSchema,
 		outputSchema:         cfg.OutputSchema,
 
+		beforeTransferCallbacks: beforeTransferCallbacks,
+
+		requestProcessors:  requestProcessors,
+		responseProcessors: responseProcessors,
+
 		State: llminternal.State{
-			Model:                    cfg.Model,
-			GenerateContentConfig:    cfg.GenerateContentConfig,
+			Model:                 cfg.Model,
+			GenerateContentConfig: cfg.GenerateContentConfig,
+			Generation: llminternal.GenerationParams{
+				Temperature:     cfg.Temperature,
+				TopP:            cfg.TopP,
+				MaxOutputTokens: cfg.MaxOutputTokens,
+				SafetySettings:  cfg.SafetySettings,
+				StopSequences:   cfg.StopSequences,
+			},
 			Tools:                    cfg.Tools,
 			Toolsets:                 cfg.Toolsets,
 			DisallowTransferToParent: cfg.DisallowTransferToParent,
@@ -72,17 +104,103 @@ func New(cfg Config) (agent.Agent, error) {
 			OutputSchema:             cfg.OutputSchema,
 			// TODO: internal type for includeContents
 			IncludeContents:           string(cfg.IncludeContents),
+			StaticInstruction:         cfg.StaticInstruction,
 			Instruction:               cfg.Instruction,
 			InstructionProvider:       llminternal.InstructionProvider(cfg.InstructionProvider),
 			GlobalInstruction:         cfg.GlobalInstruction,
 			GlobalInstructionProvider: llminternal.InstructionProvider(cfg.GlobalInstructionProvider),
 			OutputKey:                 cfg.OutputKey,
+			MaxInlineArtifactBytes:    cfg.MaxInlineArtifactBytes,
+			CacheAwareOrdering:        cfg.CacheAwareOrdering,
+			DebugCacheStability:       cfg.DebugCacheStability,
+			DebugRequestDiff:          cfg.DebugRequestDiff,
+			MaxConcurrentToolCalls:    cfg.MaxConcurrentToolCalls,
+			ToolTimeout:               cfg.ToolTimeout,
+			StripThoughts:             cfg.StripThoughts,
+			CodeExecutor:              cfg.CodeExecutor,
 		},
 	}
+	if t := cfg.Temperature; t != nil && (*t < 0 || *t > 2) {
+		return nil, fmt.Errorf("temperature of agent %q must be in [0, 2], got %v", cfg.Name, *t)
+	}
+	if p := cfg.TopP; p != nil && (*p < 0 || *p > 1) {
+		return nil, fmt.Errorf("top_p of agent %q must be in [0, 1], got %v", cfg.Name, *p)
+	}
+	if cfg.MaxConcurrentToolCalls < 0 {
+		return nil, fmt.Errorf("max_concurrent_tool_calls of agent %q must not be negative, got %d", cfg.Name, cfg.MaxConcurrentToolCalls)
+	}
+	if cfg.ToolTimeout < 0 {
+		return nil, fmt.Errorf("tool timeout of agent %q must not be negative, got %v", cfg.Name, cfg.ToolTimeout)
+	}
+	if cfg.MaxOutputTokens < 0 {
+		return nil, fmt.Errorf("max_output_tokens of agent %q must not be negative, got %d", cfg.Name, cfg.MaxOutputTokens)
+	}
+	if w := cfg.ContextWindow; w != nil {
+		if w.MaxEvents <= 0 && w.MaxTokens <= 0 {
+			return nil, fmt.Errorf("context window of agent %q must limit MaxEvents or MaxTokens", cfg.Name)
+		}
+		if w.SummaryBatch < 0 {
+			return nil, fmt.Errorf("summary batch of the context window of agent %q must not be negative, got %d", cfg.Name, w.SummaryBatch)
+		}
+		a.ContextWindow = &llminternal.ContextWindow{
+			MaxEvents:    w.MaxEvents,
+			MaxTokens:    w.MaxTokens,
+			CountTokens:  w.CountTokens,
+			SummaryBatch: w.SummaryBatch,
+		}
+		if w.Summarize {
+			s, err := newSummarizer(w.Summarizer, w.SummaryModel, cfg.Model, summarizer.PurposeCompaction, w.SummaryInstruction)
+			if err != nil {
+				return nil, fmt.Errorf("context window of agent %q: %w", cfg.Name, err)
+			}
+			a.ContextWindow.Summarizer = s
+		}
+	}
+	if pr := cfg.PartialResponse; pr != nil {
+		switch pr.Policy {
+		case PartialResponseFinalize, PartialResponseContinue:
+		default:
+			return nil, fmt.Errorf("unknown partial response policy %q of agent %q", pr.Policy, cfg.Name)
+		}
+		maxContinuations := pr.MaxContinuations
+		if maxContinuations <= 0 {
+			maxContinuations = 1
+		}
+		a.PartialResponse = &llminternal.PartialResponseRecovery{
+			Policy:           llminternal.PartialResponsePolicy(pr.Policy),
+			MaxContinuations: maxContinuations,
+			Annotation:       pr.Annotation,
+		}
+	}
+	if l := cfg.ToolResultLimit; l != nil {
+		if l.MaxBytes <= 0 {
+			return nil, fmt.Errorf("tool result limit of agent %q must be positive, got %d", cfg.Name, l.MaxBytes)
+		}
+		strategy := l.Strategy
+		if strategy == nil {
+			strategy = toolresult.Truncate()
+		}
+		a.ToolResultLimit = &llminternal.ToolResultLimit{MaxBytes: l.MaxBytes, Strategy: strategy}
+	}
+	if lo := cfg.LongOutput; lo != nil {
+		maxContinuations := lo.MaxContinuations
+		if maxContinuations <= 0 {
+			maxContinuations = 3
+		}
+		a.LongOutput = &llminternal.LongOutput{MaxContinuations: maxContinuations}
+	}
+	if h := cfg.HandoffSummary; h != nil {
+		s, err := newSummarizer(h.Summarizer, h.Model, cfg.Model, summarizer.PurposeHandoff, h.Instruction)
+		if err != nil {
+			return nil, fmt.Errorf("handoff summary of agent %q: %w", cfg.Name, err)
+		}
+		a.handoffSummarizer = s
+	}
 
 	baseAgent, err := agent.New(agent.Config{
 		Name:                 cfg.Name,
 		Description:          cfg.Description,
+		Capabilities:         cfg.Capabilities,
 		SubAgents:            cfg.SubAgents,
 		BeforeAgentCallbacks: cfg.BeforeAgentCallbacks,
 		Run:                  a.run,
@@ -102,13 +220,19 @@ func New(cfg Config) (agent.Agent, error) {
 // Config of the LLMAgent.
 type Config struct {
 	// Name must be a non-empty string, unique within the agent tree.
-	// Agent name cannot be "user", since it's reserved for end-user's input.
+	// Agent name cannot be "user" or "developer", since they're reserved for
+	// end-user's input and for the developer messages.
 	Name string
 	// Description of the agent's capability.
 	//
 	// LLM uses this to determine whether to delegate control to the agent.
 	// One-line description is enough and preferred.
 	Description string
+	// Capabilities declare what the agent can do in a structured form. LLM
+	// uses them together with the description to determine whether to
+	// delegate control to the agent.
+	// Optional.
+	Capabilities agent.Capabilities
 	// SubAgents are the child agents that this agent can delegate tasks to.
 	// ADK will automatically set a parent of each sub-agent to this agent to
 	// allow agent transferring across the tree.
@@ -139,6 +263,18 @@ type Config struct {
 	// safety settings, etc.
 	GenerateContentConfig *genai.GenerateContentConfig
 
+	// Temperature, TopP, MaxOutputTokens, SafetySettings and StopSequences
+	// are the generation parameters of the agent, so that the agents of a
+	// tree can generate differently with the same model. The set ones
+	// override the parameters of GenerateContentConfig.
+	// Optional: if nil or zero, the parameter of GenerateContentConfig, or
+	// the default of the model, is used.
+	Temperature     *float32
+	TopP            *float32
+	MaxOutputTokens int32
+	SafetySettings  []*genai.SafetySetting
+	StopSequences   []string
+
 	// BeforeModelCallbacks will be called in the order they are provided until
 	// there's a callback that returns a non-nil LLMResponse or error. Then
 	// actual LLM call is skipped, and the returned response/error is used.
@@ -157,6 +293,28 @@ type Config struct {
 	// usage, or perform post-processing on the raw `LLMResponse`.
 	AfterModelCallbacks []AfterModelCallback
 
+	// RequestProcessors shape the requests sent to the model, e.g. to add
+	// domain-specific contents or instructions. They run in the order they
+	// are provided, after the built-in request processors, which build the
+	// instructions and the contents, and before the tools add their
+	// declarations and the BeforeModelCallbacks run. An error ends the run
+	// of the agent.
+	RequestProcessors []RequestProcessor
+	// ResponseProcessors process the responses of the model, including the
+	// partial ones. They run in the order they are provided, after the
+	// built-in response processors and before the function calls of the
+	// response are executed. An error ends the run of the agent.
+	ResponseProcessors []ResponseProcessor
+
+	// StaticInstruction is sent as the system instruction as is: it's not
+	// treated as a template and doesn't change between requests, which makes
+	// it cacheable by the model provider (context caching).
+	//
+	// When it is set, Instruction (or InstructionProvider) becomes the
+	// dynamic part of the instructions: it's sent as user content after the
+	// conversation history, right before the latest user input, so it can be
+	// personalized per request without invalidating the cached prefix.
+	StaticInstruction *genai.Content
 	// Instruction is set for the LLM model guiding the agent's behavior.
 	//
 	// The string is treated as a template:
@@ -165,12 +323,16 @@ type Config struct {
 	//  - key_name must match "^[a-zA-Z_][a-zA-Z0-9_]*$", otherwise it will be
 	//    treated as a literal.
 	//  - {artifact.key_name} can be used to insert the text content of the
-	//    artifact named key_name.
+	//    artifact named key_name. Other artifacts, e.g. images or PDFs, are
+	//    sent to the model after the instructions.
 	//
 	// If the state variable or artifact does not exist, the agent will raise an
 	// error. If you want to ignore the error, you can append a ? to the
 	// variable name as in {var?} to make it optional.
 	//
+	// Segments marked with model.Sensitive, e.g. proprietary prompt content,
+	// are sent to the model but redacted from the telemetry and debug logs.
+	// This applies to all the instructions.
 	Instruction string
 	// InstructionProvider allows to create instructions dynamically based on
 	// the agent context.
@@ -187,7 +349,8 @@ type Config struct {
 	//  - key_name must match "^[a-zA-Z_][a-zA-Z0-9_]*$", otherwise it will be
 	//    treated as a literal.
 	//  - {artifact.key_name} can be used to insert the text content of the
-	//    artifact named key_name.
+	//    artifact named key_name. Other artifacts, e.g. images or PDFs, are
+	//    sent to the model after the instructions.
 	//
 	// If the state variable or artifact does not exist, the agent will raise an
 	// error. If you want to ignore the error, you can append a ? to the
@@ -209,9 +372,86 @@ type Config struct {
 	DisallowTransferToParent bool
 	// DisallowTransferToPeers prevents transferring to peer agents.
 	DisallowTransferToPeers bool
+	// BeforeTransferCallbacks are called in the order they are provided when
+	// the agent transfers control to another agent, before the flow switches
+	// to the target agent. They can veto, redirect or augment the transfer.
+	BeforeTransferCallbacks []BeforeTransferCallback
+	// HandoffSummary, if set, makes the agent generate a summary of the
+	// conversation for the target agent when it transfers control to it.
+	// The target agent sees the summary at the beginning of its history
+	// instead of the raw events of other agents which preceded it.
+	HandoffSummary *HandoffSummaryConfig
 
 	// Whether to include contents (conversation history) in the model request.
 	IncludeContents IncludeContents
+	// PartialResponse, if set, recovers the text streamed by the model in
+	// SSE mode when the stream terminates early, e.g. because the connection
+	// dropped or the model stopped with finish reason OTHER, instead of
+	// discarding the text the user already saw.
+	PartialResponse *PartialResponseConfig
+	// LongOutput, if set, continues the text responses which stop at the
+	// output token limit of the model (finish reason MAX_TOKENS) instead of
+	// committing the truncated text. The model is asked to continue where it
+	// stopped, and the parts are stitched into one final response, dropping
+	// the text the continuation repeats at the seam. In streaming mode the
+	// continuations stream in after the text already streamed.
+	LongOutput *LongOutputConfig
+	// ContextWindow, if set, limits the conversation history sent to the
+	// model, so that long sessions don't exceed the context window of the
+	// model. The oldest history is dropped first.
+	ContextWindow *ContextWindowConfig
+
+	// CacheAwareOrdering orders the sections of model requests to maximize
+	// the prompt-cache hits of the model provider.
+	//
+	// When set, the stable sections (instructions without placeholders and
+	// tool declarations sorted by name) form the request prefix, while the
+	// instructions which may change between calls (instruction providers and
+	// instructions with placeholders) are sent as user content after the
+	// conversation history, right before the latest user input.
+	CacheAwareOrdering bool
+	// DebugCacheStability logs which bytes of the request prefix (system
+	// instruction and tools) changed since the previous model call of the
+	// agent in the invocation. Use it to find the sources of prompt-cache
+	// misses.
+	DebugCacheStability bool
+	// DebugRequestDiff logs the changes of the model request between the
+	// steps of an invocation (contents added and removed, system instruction
+	// and tool changes) and attaches them to the call_llm trace spans. Use it
+	// to find processors which unexpectedly change the prompt between steps.
+	DebugRequestDiff bool
+
+	// MaxConcurrentToolCalls is how many of the function calls of a single
+	// model response run at the same time. The function responses are merged
+	// in the order of the calls regardless of when the tools finish. With a
+	// limit above 1, the tools of the agent and its BeforeToolCallbacks and
+	// AfterToolCallbacks must be safe for concurrent use.
+	// Optional: zero or 1 runs the calls sequentially.
+	MaxConcurrentToolCalls int
+	// ToolTimeout bounds the duration of the tool calls of the agent. When a
+	// tool exceeds it, its context is canceled and the model receives an
+	// error function response instead of the invocation waiting for the
+	// tool. The timeout documented by a tool (see tool.Requirements), e.g.
+	// functiontool.Config.Timeout, takes precedence.
+	// Optional: zero means no timeout.
+	ToolTimeout time.Duration
+	// ToolResultLimit, if set, limits the size of the function responses
+	// sent to the model, so that a verbose tool doesn't fill the context
+	// window.
+	ToolResultLimit *ToolResultLimitConfig
+	// StripThoughts removes the thought parts (the reasoning of the model,
+	// see genai.ThinkingConfig.IncludeThoughts) from the events of the
+	// agent, so that they are neither yielded nor persisted in the session
+	// history. By default, the thoughts are persisted, but the runner only
+	// yields them if agent.RunConfig.IncludeThoughts is set.
+	StripThoughts bool
+	// CodeExecutor, if set, executes the code the model writes: the result
+	// of each ExecutableCode part ending a model response is appended to
+	// the response, and the model is called again with it.
+	// codeexecutor.BuiltIn enables the code execution tool of the model
+	// instead, which executes the code itself.
+	// Optional.
+	CodeExecutor codeexecutor.Executor
 
 	// TODO(ngeorgy): consider to switch to jsonschema for input and output schema.
 	// The input schema when agent is used as a tool.
@@ -254,6 +494,13 @@ type Config struct {
 	// - Extracts agent reply for later use, such as in tools, callbacks, etc.
 	// - Connects agents to coordinate with each other.
 	OutputKey string
+
+	// MaxInlineArtifactBytes is the size above which the artifacts
+	// referenced in the instructions or in the tool results, see
+	// artifact.Reference, are uploaded to the model provider, if the model
+	// is a model.FileUploader, instead of being inlined in the requests.
+	// Optional: defaults to 10 MiB.
+	MaxInlineArtifactBytes int
 }
 
 // BeforeModelCallback that is called before sending a request to the model.
@@ -268,8 +515,19 @@ type BeforeModelCallback func(ctx agent.CallbackContext, llmRequest *model.LLMRe
 // is replaced with the returned response/error.
 type AfterModelCallback func(ctx agent.CallbackContext, llmResponse *model.LLMResponse, llmResponseError error) (*model.LLMResponse, error)
 
+// RequestProcessor modifies the request before it is sent to the model, see
+// Config.RequestProcessors.
+type RequestProcessor func(ctx agent.InvocationContext, req *model.LLMRequest) error
+
+// ResponseProcessor modifies the response of the model to the request, see
+// Config.ResponseProcessors.
+type ResponseProcessor func(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error
+
 // BeforeToolCallback is a function type executed before a tool's Run method is invoked.
 //
+// If Config.MaxConcurrentToolCalls is above 1, the callback is called
+// concurrently for the function calls of a model response.
+//
 // Parameters:
 //   - ctx: The tool.Context for the current tool execution.
 //   - tool: The tool.Tool instance that is about to be executed.
@@ -279,6 +537,12 @@ type BeforeToolCallback func(ctx tool.Context, tool tool.Tool, args map[string]a
 // AfterToolCallback is a function type executed after a tool's Run method has completed,
 // regardless of whether the tool returned a result or an error.
 //
+// It can set ctx.Actions().SkipSummarization to return the function response
+// to the user without another model call.
+//
+// If Config.MaxConcurrentToolCalls is above 1, the callback is called
+// concurrently for the function calls of a model response.
+//
 // Parameters:
 //   - ctx:    The tool.Context for the tool execution.
 //   - tool:   The tool.Tool instance that was executed.
@@ -287,6 +551,128 @@ type BeforeToolCallback func(ctx tool.Context, tool tool.Tool, args map[string]a
 //   - err:    The error returned by the tool's Run method.
 type AfterToolCallback func(ctx tool.Context, tool tool.Tool, args map[string]any, result map[string]any, err error) (map[string]any, error)
 
+// BeforeTransferCallback is called when the model requested a transfer to the
+// targetAgent with the transfer_to_agent tool.
+//
+// It returns the name of the agent to transfer to:
+//   - targetAgent continues the transfer,
+//   - the name of another transfer target redirects the transfer. Redirecting
+//     to an agent that is not a transfer target fails the run,
+//   - an empty string vetoes the transfer and the remaining callbacks are
+//     skipped. The current agent continues its turn.
+//
+// If it returns non-nil content, e.g. a handoff summary for the target agent,
+// the content is emitted as an event of the current agent before the
+// transfer.
+type BeforeTransferCallback func(ctx agent.CallbackContext, targetAgent string) (string, *genai.Content, error)
+
+// HandoffSummaryConfig configures the handoff summaries generated on agent
+// transfer.
+type HandoffSummaryConfig struct {
+	// Summarizer generates the summaries, with the purpose
+	// summarizer.PurposeHandoff. If set, Model and Instruction are ignored.
+	// Optional: defaults to a summarizer backed by Model.
+	Summarizer summarizer.Summarizer
+	// Model generates the summaries.
+	// Optional: defaults to the model of the agent.
+	Model model.LLM
+	// Instruction tells the model what to keep in the summary. It is sent
+	// together with the name and the description of the target agent.
+	// Optional: defaults to a request for a compact summary of the facts,
+	// decisions and open questions relevant to the target agent.
+	Instruction string
+}
+
+// ContextWindowConfig limits the conversation history sent to the model.
+// At least one of MaxEvents and MaxTokens must be set.
+type ContextWindowConfig struct {
+	// MaxEvents is the maximum number of history contents in the request.
+	MaxEvents int
+	// MaxTokens is the maximum estimated number of tokens of the history in
+	// the request. The latest content is always kept.
+	MaxTokens int
+	// CountTokens estimates the number of tokens of a content.
+	// Optional: defaults to a quarter of the size of its JSON encoding.
+	CountTokens func(*genai.Content) int
+
+	// Summarize makes the model summarize the dropped history instead of
+	// forgetting it. The summary is sent at the beginning of the history and
+	// is extended as more history is dropped. It is stored in the session as
+	// an event, see session.Compaction, so that it is reused by the later
+	// invocations.
+	Summarize bool
+	// SummaryBatch is the number of contents dropped beyond the window each
+	// time the summary is extended, so that the summary model is called once
+	// per batch rather than on every step.
+	// Optional: defaults to 10.
+	SummaryBatch int
+	// Summarizer generates the summaries, with the purpose
+	// summarizer.PurposeCompaction. If set, SummaryModel and
+	// SummaryInstruction are ignored.
+	// Optional: defaults to a summarizer backed by SummaryModel.
+	Summarizer summarizer.Summarizer
+	// SummaryModel generates the summaries.
+	// Optional: defaults to the model of the agent.
+	SummaryModel model.LLM
+	// SummaryInstruction tells the model what to keep in the summary.
+	// Optional: defaults to a request for a compact summary of the facts,
+	// decisions, tool results and open questions.
+	SummaryInstruction string
+}
+
+// PartialResponseConfig configures the recovery of the responses which were
+// interrupted while streaming.
+type PartialResponseConfig struct {
+	Policy PartialResponsePolicy
+	// MaxContinuations is the number of continuations requested by
+	// PartialResponseContinue before the text is finalized.
+	// Optional: defaults to 1.
+	MaxContinuations int
+	// Annotation is appended to the text of the finalized responses, e.g.
+	// " [interrupted]". The finalized responses are also marked with the
+	// "partial_response" key in session.Event.CustomMetadata, with the
+	// reason of the interruption as value.
+	// Optional.
+	Annotation string
+}
+
+// ToolResultLimitConfig limits the size of the function responses.
+type ToolResultLimitConfig struct {
+	// MaxBytes is the maximum size of the JSON encoding of a function
+	// response. The larger responses are reduced by Strategy, and truncated
+	// if they still exceed it.
+	MaxBytes int
+	// Strategy reduces the function responses larger than MaxBytes, e.g.
+	// toolresult.Summarize or toolresult.SaveAsArtifact.
+	// Optional: defaults to toolresult.Truncate.
+	Strategy toolresult.Strategy
+}
+
+// LongOutputConfig configures the continuation of the responses which reach
+// the output token limit of the model.
+type LongOutputConfig struct {
+	// MaxContinuations is the maximum number of continuations of a
+	// response. If the last continuation reaches the limit too, the stitched
+	// response keeps the finish reason MAX_TOKENS.
+	// Optional: defaults to 3.
+	MaxContinuations int
+}
+
+// PartialResponsePolicy is what the agent does with the text streamed by the
+// model when the stream terminates early.
+type PartialResponsePolicy string
+
+const (
+	// PartialResponseFinalize commits the streamed text as the response of
+	// the model.
+	PartialResponseFinalize PartialResponsePolicy = "finalize"
+	// PartialResponseContinue asks the model to continue the streamed text,
+	// and commits the streamed text followed by the continuation. The user
+	// sees the continuation stream in after the text already streamed. If
+	// the continuations terminate early too, the text is finalized.
+	PartialResponseContinue PartialResponsePolicy = "continue"
+)
+
 // IncludeContents controls what parts of prior conversation history is received by llmagent.
 type IncludeContents string
 
@@ -310,6 +696,12 @@ type llmAgent struct {
 	beforeToolCallbacks []llminternal.BeforeToolCallback
 	afterToolCallbacks  []llminternal.AfterToolCallback
 
+	beforeTransferCallbacks []llminternal.BeforeTransferCallback
+	handoffSummarizer       summarizer.Summarizer
+
+	requestProcessors  []func(agent.InvocationContext, *model.LLMRequest) error
+	responseProcessors []func(agent.InvocationContext, *model.LLMRequest, *model.LLMResponse) error
+
 	inputSchema  *genai.Schema
 	outputSchema *genai.Schema
 }
@@ -330,16 +722,22 @@ func (a *llmAgent) run(ctx agent.InvocationContext) iter.Seq2[*session.Event, er
 
 	f := &llminternal.Flow{
 		Model:                a.model,
-		RequestProcessors:    llminternal.DefaultRequestProcessors,
-		ResponseProcessors:   llminternal.DefaultResponseProcessors,
+		RequestProcessors:    slices.Concat(llminternal.DefaultRequestProcessors, a.requestProcessors),
+		ResponseProcessors:   slices.Concat(llminternal.DefaultResponseProcessors, a.responseProcessors),
 		BeforeModelCallbacks: a.beforeModelCallbacks,
 		AfterModelCallbacks:  a.afterModelCallbacks,
 		BeforeToolCallbacks:  a.beforeToolCallbacks,
 		AfterToolCallbacks:   a.afterToolCallbacks,
+
+		BeforeTransferCallbacks: a.beforeTransferCallbacks,
+		HandoffSummarizer:       a.handoffSummarizer,
 	}
 
 	return func(yield func(*session.Event, error) bool) {
 		for ev, err := range f.Run(ctx) {
+			if ev != nil && ev.InvocationID == ctx.InvocationID() && ev.ParentInvocationID == "" {
+				ev.ParentInvocationID = ctx.ParentInvocationID()
+			}
 			a.maybeSaveOutputToState(ev)
 			if !yield(ev, err) {
 				return
@@ -385,6 +783,26 @@ func (a *llmAgent) maybeSaveOutputToState(event *session.Event) {
 	}
 }
 
+// newSummarizer returns the configured summarizer or else a summarizer
+// backed by the configured model, falling back to the model of the agent.
+// It returns nil if there is no model.
+func newSummarizer(s summarizer.Summarizer, llm, agentModel model.LLM, purpose summarizer.Purpose, instruction string) (summarizer.Summarizer, error) {
+	if s != nil {
+		return s, nil
+	}
+	if llm == nil {
+		llm = agentModel
+	}
+	if llm == nil {
+		return nil, nil
+	}
+	var instructions map[summarizer.Purpose]string
+	if instruction != "" {
+		instructions = map[summarizer.Purpose]string{purpose: instruction}
+	}
+	return summarizer.New(summarizer.Config{Model: llm, Instructions: instructions})
+}
+
 // InstructionProvider allows to create instructions dynamically. It is called
 // on each agent invocation.
 //
@@ -15,25 +15,40 @@
 package llmagent_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"iter"
+	"log"
 	"net/http"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/agent/workflowagents/sequentialagent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/codeexecutor"
 	"google.golang.org/adk/internal/httprr"
 	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/tool/functiontool"
 
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/model/gemini"
+	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/summarizer"
 	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/toolresult"
 	"google.golang.org/genai"
 )
 
@@ -106,7 +121,7 @@ func TestLLMAgentStreamingModeSSE(t *testing.T) {
 		t.Fatalf("NewLLMAgent failed: %v", err)
 	}
 	testRunner := testutil.NewTestAgentRunner(t, a)
-	stream := testRunner.RunContentWithConfig(t, "test_session", genai.NewContentFromText("What is the sum of the first 50 prime numbers?", "user"), agent.RunConfig{StreamingMode: agent.StreamingModeSSE})
+	stream := testRunner.RunContentWithConfig(t, "test_session", genai.NewContentFromText("What is the sum of the first 50 prime numbers?", "user"), agent.RunConfig{StreamingMode: agent.StreamingModeSSE, IncludeThoughts: true})
 	events, err := testutil.CollectEvents(stream)
 	gotThought := false
 	numContents := 0
@@ -925,6 +940,47 @@ func TestAgentTransfer(t *testing.T) {
 		})
 	})
 
+	t.Run("auto_to_custom", func(t *testing.T) {
+		// root_agent -- sub_agent_1 (custom)
+		// sub_agent_1 ends its run with a non-final event. The invocation
+		// must not return to root_agent's loop, whose model has no more
+		// responses.
+		model := testModel(transferCall("sub_agent_1"))
+
+		lookupCall := genai.NewContentFromFunctionCall("lookup", map[string]any{}, "model")
+		subAgent1, err := agent.New(agent.Config{
+			Name: "sub_agent_1",
+			Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+				return func(yield func(*session.Event, error) bool) {
+					ev := session.NewEvent(ctx.InvocationID())
+					ev.Author = "sub_agent_1"
+					ev.Content = lookupCall
+					yield(ev, nil)
+				}
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to create subAgent1: %v", err)
+		}
+
+		rootAgent, err := llmagent.New(llmagent.Config{
+			Name:      "root_agent",
+			Model:     model,
+			SubAgents: []agent.Agent{subAgent1},
+		})
+		if err != nil {
+			t.Fatalf("failed to create rootAgent: %v", err)
+		}
+
+		check(t, rootAgent, [][]content{
+			0: {
+				{"root_agent", transferCall("sub_agent_1").Parts},
+				{"root_agent", transferResponse().Parts},
+				{"sub_agent_1", lookupCall.Parts},
+			},
+		})
+	})
+
 	// TODO: cover cases similar to adk-python's
 	// tests/unittests/flows/llm_flows/test_agent_transfer.py
 	//   - test_auto_to_sequential
@@ -968,3 +1024,2065 @@ type roundTripperFunc func(*http.Request) (*http.Response, error)
 func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return fn(req)
 }
+
+func TestSubInvocations(t *testing.T) {
+	model := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromFunctionCall("transfer_to_agent", map[string]any{"agent_name": "sub_agent"}, genai.RoleModel),
+		genai.NewContentFromText("response", genai.RoleModel),
+	}}
+	subAgent, err := llmagent.New(llmagent.Config{
+		Name:  "sub_agent",
+		Model: model,
+	})
+	if err != nil {
+		t.Fatalf("failed to create subAgent: %v", err)
+	}
+	rootAgent, err := llmagent.New(llmagent.Config{
+		Name:      "root_agent",
+		Model:     model,
+		SubAgents: []agent.Agent{subAgent},
+	})
+	if err != nil {
+		t.Fatalf("failed to create rootAgent: %v", err)
+	}
+
+	runner := testutil.NewTestAgentRunner(t, rootAgent)
+	invocations := make(map[string]string) // author -> invocation ID
+	parents := make(map[string]string)     // author -> parent invocation ID
+	for ev, err := range runner.Run(t, "session_id", "hello") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id, ok := invocations[ev.Author]; ok && id != ev.InvocationID {
+			t.Errorf("events of %q have different invocation IDs: %q and %q", ev.Author, id, ev.InvocationID)
+		}
+		invocations[ev.Author] = ev.InvocationID
+		parents[ev.Author] = ev.ParentInvocationID
+	}
+
+	if parents["root_agent"] == "" {
+		t.Errorf("root_agent events have no parent invocation, want the runner invocation")
+	}
+	if invocations["sub_agent"] == invocations["root_agent"] {
+		t.Errorf("sub_agent runs in the invocation of root_agent, want a sub-invocation")
+	}
+	if got, want := parents["sub_agent"], invocations["root_agent"]; got != want {
+		t.Errorf("sub_agent parent invocation = %q, want %q", got, want)
+	}
+}
+
+func TestCacheAwareOrdering(t *testing.T) {
+	newTool := func(name string) tool.Tool {
+		t.Helper()
+		tl, err := functiontool.New(functiontool.Config{
+			Name:        name,
+			Description: name,
+		}, func(tool.Context, map[string]any) (map[string]any, error) {
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tl
+	}
+
+	var req *model.LLMRequest
+	a, err := llmagent.New(llmagent.Config{
+		Name:               "agent",
+		Model:              &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("ok", genai.RoleModel)}},
+		GlobalInstruction:  "Be nice.",
+		Instruction:        "The user is {name}.",
+		Tools:              []tool.Tool{newTool("b_tool"), newTool("a_tool")},
+		CacheAwareOrdering: true,
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{
+			func(ctx agent.CallbackContext, llmRequest *model.LLMRequest) (*model.LLMResponse, error) {
+				req = llmRequest
+				return nil, nil
+			},
+		},
+		DisallowTransferToParent: true,
+		DisallowTransferToPeers:  true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	runner := testutil.NewTestAgentRunner(t, a)
+	runner.SetInitSessionState(map[string]any{"name": "Ann"})
+	if _, err := testutil.CollectEvents(runner.Run(t, "session_id", "hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(genai.NewContentFromText("Be nice.", genai.RoleUser), req.Config.SystemInstruction); diff != "" {
+		t.Errorf("system instruction mismatch (-want +got):\n%s", diff)
+	}
+	wantContents := []*genai.Content{
+		genai.NewContentFromText("The user is Ann.", genai.RoleUser),
+		genai.NewContentFromText("hello", genai.RoleUser),
+	}
+	if diff := cmp.Diff(wantContents, req.Contents); diff != "" {
+		t.Errorf("contents mismatch (-want +got):\n%s", diff)
+	}
+	var names []string
+	for _, t := range req.Config.Tools {
+		for _, decl := range t.FunctionDeclarations {
+			names = append(names, decl.Name)
+		}
+	}
+	if diff := cmp.Diff([]string{"a_tool", "b_tool"}, names); diff != "" {
+		t.Errorf("function declarations mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStaticInstruction(t *testing.T) {
+	var req *model.LLMRequest
+	a, err := llmagent.New(llmagent.Config{
+		Name:              "agent",
+		Model:             &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("ok", genai.RoleModel)}},
+		StaticInstruction: genai.NewContentFromText("You are a {helpful} assistant.", genai.RoleUser),
+		Instruction:       "The user is {name}.",
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{
+			func(ctx agent.CallbackContext, llmRequest *model.LLMRequest) (*model.LLMResponse, error) {
+				req = llmRequest
+				return nil, nil
+			},
+		},
+		DisallowTransferToParent: true,
+		DisallowTransferToPeers:  true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	runner := testutil.NewTestAgentRunner(t, a)
+	runner.SetInitSessionState(map[string]any{"name": "Ann"})
+	if _, err := testutil.CollectEvents(runner.Run(t, "session_id", "hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The static instruction is not a template.
+	if diff := cmp.Diff(genai.NewContentFromText("You are a {helpful} assistant.", genai.RoleUser), req.Config.SystemInstruction); diff != "" {
+		t.Errorf("system instruction mismatch (-want +got):\n%s", diff)
+	}
+	wantContents := []*genai.Content{
+		genai.NewContentFromText("The user is Ann.", genai.RoleUser),
+		genai.NewContentFromText("hello", genai.RoleUser),
+	}
+	if diff := cmp.Diff(wantContents, req.Contents); diff != "" {
+		t.Errorf("contents mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBeforeTransferCallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		callback    llmagent.BeforeTransferCallback
+		wantAuthors []string
+		wantTexts   []string
+		wantErr     bool
+	}{
+		{
+			name: "continue",
+			callback: func(ctx agent.CallbackContext, targetAgent string) (string, *genai.Content, error) {
+				return targetAgent, nil, nil
+			},
+			wantAuthors: []string{"root_agent", "root_agent", "billing_agent"},
+			wantTexts:   []string{"billing_agent response"},
+		},
+		{
+			name: "redirect with summary",
+			callback: func(ctx agent.CallbackContext, targetAgent string) (string, *genai.Content, error) {
+				return "support_agent", genai.NewContentFromText("Summary: the user needs help.", genai.RoleModel), nil
+			},
+			wantAuthors: []string{"root_agent", "root_agent", "root_agent", "support_agent"},
+			wantTexts:   []string{"Summary: the user needs help.", "support_agent response"},
+		},
+		{
+			name: "veto",
+			callback: func(ctx agent.CallbackContext, targetAgent string) (string, *genai.Content, error) {
+				return "", nil, nil
+			},
+			wantAuthors: []string{"root_agent", "root_agent", "root_agent"},
+			wantTexts:   []string{"root_agent response"},
+		},
+		{
+			name: "redirect to unknown agent",
+			callback: func(ctx agent.CallbackContext, targetAgent string) (string, *genai.Content, error) {
+				return "unknown_agent", nil, nil
+			},
+			wantAuthors: []string{"root_agent"},
+			wantErr:     true,
+		},
+		{
+			name: "redirect to itself",
+			callback: func(ctx agent.CallbackContext, targetAgent string) (string, *genai.Content, error) {
+				return "root_agent", nil, nil
+			},
+			wantAuthors: []string{"root_agent"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newSubAgent := func(name string) agent.Agent {
+				a, err := llmagent.New(llmagent.Config{
+					Name:  name,
+					Model: &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText(name+" response", genai.RoleModel)}},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return a
+			}
+			rootAgent, err := llmagent.New(llmagent.Config{
+				Name: "root_agent",
+				Model: &testutil.MockModel{Responses: []*genai.Content{
+					genai.NewContentFromFunctionCall("transfer_to_agent", map[string]any{"agent_name": "billing_agent"}, genai.RoleModel),
+					genai.NewContentFromText("root_agent response", genai.RoleModel),
+				}},
+				SubAgents:               []agent.Agent{newSubAgent("billing_agent"), newSubAgent("support_agent")},
+				BeforeTransferCallbacks: []llmagent.BeforeTransferCallback{tt.callback},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotAuthors, gotTexts []string
+			var gotErr error
+			for ev, err := range testutil.NewTestAgentRunner(t, rootAgent).Run(t, "session_id", "hello") {
+				if err != nil {
+					gotErr = err
+					break
+				}
+				gotAuthors = append(gotAuthors, ev.Author)
+				if ev.Content != nil && ev.Content.Parts[0].Text != "" {
+					gotTexts = append(gotTexts, ev.Content.Parts[0].Text)
+				}
+			}
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.wantAuthors, gotAuthors); diff != "" {
+				t.Errorf("event authors mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantTexts, gotTexts); diff != "" {
+				t.Errorf("event texts mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandoffSummary(t *testing.T) {
+	subModel := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("Your invoice is paid.", genai.RoleModel)}}
+	subAgent, err := llmagent.New(llmagent.Config{
+		Name:        "billing_agent",
+		Description: "Handles billing questions.",
+		Model:       subModel,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	summaryModel := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("The user asks about invoice 42.", genai.RoleModel)}}
+	rootAgent, err := llmagent.New(llmagent.Config{
+		Name: "root_agent",
+		Model: &testutil.MockModel{Responses: []*genai.Content{
+			genai.NewContentFromFunctionCall("transfer_to_agent", map[string]any{"agent_name": "billing_agent"}, genai.RoleModel),
+		}},
+		SubAgents:      []agent.Agent{subAgent},
+		HandoffSummary: &llmagent.HandoffSummaryConfig{Model: summaryModel},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var summaryEvent *session.Event
+	for ev, err := range testutil.NewTestAgentRunner(t, rootAgent).Run(t, "session_id", "Is invoice 42 paid?") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Actions.HandoffSummaryFor != "" {
+			summaryEvent = ev
+		}
+	}
+
+	if summaryEvent == nil {
+		t.Fatal("no handoff summary event")
+	}
+	if summaryEvent.Author != "root_agent" || summaryEvent.Actions.HandoffSummaryFor != "billing_agent" {
+		t.Errorf("summary event author = %q, for = %q, want root_agent, billing_agent", summaryEvent.Author, summaryEvent.Actions.HandoffSummaryFor)
+	}
+	if len(summaryModel.Requests) != 1 {
+		t.Fatalf("summary model was called %d times, want 1", len(summaryModel.Requests))
+	}
+	if prompt := summaryModel.Requests[0].Contents[len(summaryModel.Requests[0].Contents)-1].Parts[0].Text; !strings.Contains(prompt, "Handles billing questions.") {
+		t.Errorf("summary prompt = %q, want the description of the target agent", prompt)
+	}
+
+	if len(subModel.Requests) != 1 {
+		t.Fatalf("billing_agent model was called %d times, want 1", len(subModel.Requests))
+	}
+	want := []*genai.Content{
+		{Role: genai.RoleUser, Parts: []*genai.Part{{Text: "For context:"}, {Text: "[root_agent] said: The user asks about invoice 42."}}},
+		genai.NewContentFromText("Is invoice 42 paid?", genai.RoleUser),
+	}
+	if diff := cmp.Diff(want, subModel.Requests[0].Contents); diff != "" {
+		t.Errorf("billing_agent contents mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestToolAuth(t *testing.T) {
+	authConfig := &auth.Config{
+		AuthScheme:        &auth.Scheme{Type: "apiKey", Name: "X-API-Key", In: "header"},
+		RawAuthCredential: &auth.Credential{AuthType: auth.CredentialTypeAPIKey},
+	}
+	type Args struct{}
+	type Result struct {
+		Status string `json:"status"`
+	}
+	getData, err := functiontool.New(functiontool.Config{
+		Name:        "get_data",
+		Description: "returns the data of the user",
+	}, func(ctx tool.Context, _ Args) (Result, error) {
+		cred, err := ctx.Credential(authConfig)
+		if err != nil {
+			return Result{}, err
+		}
+		if cred == nil {
+			ctx.RequestCredential(authConfig)
+			return Result{Status: "pending authorization"}, nil
+		}
+		return Result{Status: "authorized with " + cred.APIKey}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromFunctionCall("get_data", map[string]any{}, genai.RoleModel),
+		genai.NewContentFromText("Here is your data.", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "agent",
+		Model: m,
+		Tools: []tool.Tool{getData},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	// The tool requests the credential.
+	events, err := testutil.CollectEvents(runner.Run(t, "session_id", "get my data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	authEvent := events[len(events)-1]
+	calls := authEvent.LLMResponse.Content.Parts
+	if len(calls) != 1 || calls[0].FunctionCall == nil || calls[0].FunctionCall.Name != auth.RequestCredentialFunctionName {
+		t.Fatalf("last event parts = %+v, want a %s function call", calls, auth.RequestCredentialFunctionName)
+	}
+	authCall := calls[0].FunctionCall
+	if diff := cmp.Diff([]string{authCall.ID}, authEvent.LongRunningToolIDs); diff != "" {
+		t.Errorf("LongRunningToolIDs mismatch (-want +got):\n%s", diff)
+	}
+	toolCallID := events[0].LLMResponse.Content.Parts[0].FunctionCall.ID
+	if got := authCall.Args["functionCallId"]; got != toolCallID {
+		t.Errorf("functionCallId = %v, want %q", got, toolCallID)
+	}
+	if len(m.Requests) != 1 {
+		t.Errorf("model was called %d times before the credential was provided, want 1", len(m.Requests))
+	}
+
+	// The client provides the credential and the tool is resumed.
+	response := map[string]any{
+		"authScheme":              authConfig.AuthScheme,
+		"rawAuthCredential":       authConfig.RawAuthCredential,
+		"exchangedAuthCredential": &auth.Credential{AuthType: auth.CredentialTypeAPIKey, APIKey: "secret"},
+	}
+	msg := &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{{
+		FunctionResponse: &genai.FunctionResponse{ID: authCall.ID, Name: auth.RequestCredentialFunctionName, Response: response},
+	}}}
+	events, err = testutil.CollectEvents(runner.RunContent(t, "session_id", msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want the function response and the model response", len(events))
+	}
+	want := &genai.FunctionResponse{ID: toolCallID, Name: "get_data", Response: map[string]any{"status": "authorized with secret"}}
+	if diff := cmp.Diff(want, events[0].LLMResponse.Content.Parts[0].FunctionResponse); diff != "" {
+		t.Errorf("function response mismatch (-want +got):\n%s", diff)
+	}
+	if len(events[0].Actions.StateDelta) != 0 {
+		t.Errorf("function response state delta = %v, want the credential removed", events[0].Actions.StateDelta)
+	}
+	if got := events[1].LLMResponse.Content.Parts[0].Text; got != "Here is your data." {
+		t.Errorf("final response = %q, want %q", got, "Here is your data.")
+	}
+	// The credential request and the pending response are not sent to the model.
+	wantContents := []*genai.Content{
+		genai.NewContentFromText("get my data", genai.RoleUser),
+		genai.NewContentFromFunctionCall("get_data", map[string]any{}, genai.RoleModel),
+		genai.NewContentFromFunctionResponse("get_data", want.Response, genai.RoleUser),
+	}
+	if diff := cmp.Diff(wantContents, m.Requests[len(m.Requests)-1].Contents); diff != "" {
+		t.Errorf("contents sent to the model mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDebugCacheStability(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	echo, err := functiontool.New(functiontool.Config{
+		Name:        "echo",
+		Description: "echoes the input",
+	}, func(_ tool.Context, args map[string]any) (map[string]any, error) {
+		return args, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	a, err := llmagent.New(llmagent.Config{
+		Name: "agent",
+		Model: &testutil.MockModel{Responses: []*genai.Content{
+			genai.NewContentFromFunctionCall("echo", map[string]any{"text": "hi"}, genai.RoleModel),
+			genai.NewContentFromText("done", genai.RoleModel),
+			genai.NewContentFromText("done again", genai.RoleModel),
+		}},
+		Tools: []tool.Tool{echo},
+		// The instruction changes on every model call.
+		InstructionProvider: func(agent.ReadonlyContext) (string, error) {
+			calls++
+			return fmt.Sprintf("Call %d.", calls), nil
+		},
+		DebugCacheStability: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+	for _, session := range []string{"session_1", "session_2"} {
+		if _, err := testutil.CollectEvents(runner.Run(t, session, "hello")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The prefixes are compared between the steps of an invocation only,
+	// not with the other sessions.
+	if got := strings.Count(buf.String(), "prompt cache: request prefix"); got != 1 {
+		t.Errorf("logged %d prefix changes, want 1 for the second step of the first invocation:\n%s", got, buf.String())
+	}
+}
+
+func TestDebugRequestDiff(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	echo, err := functiontool.New(functiontool.Config{
+		Name:        "echo",
+		Description: "echoes the input",
+	}, func(_ tool.Context, args map[string]any) (map[string]any, error) {
+		return args, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name: "agent",
+		Model: &testutil.MockModel{Responses: []*genai.Content{
+			genai.NewContentFromFunctionCall("echo", map[string]any{"text": "hi"}, genai.RoleModel),
+			genai.NewContentFromText("done", genai.RoleModel),
+		}},
+		Tools:            []tool.Tool{echo},
+		DebugRequestDiff: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session_id", "hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := buf.String(), `diff="contents: 1 kept, 0 removed, 2 added" perturbed=false`; !strings.Contains(got, want) || !strings.Contains(got, "agent=agent") {
+		t.Errorf("log = %q, want it to contain %q for the agent", got, want)
+	}
+	if got := strings.Count(buf.String(), "INFO request diff"); got != 1 {
+		t.Errorf("logged %d request diffs, want 1 for the second step", got)
+	}
+}
+
+func TestAudioTranscriptionEvents(t *testing.T) {
+	m := &interruptedModel{calls: []interruptedCall{{responses: []*model.LLMResponse{
+		{InputTranscription: &genai.Transcription{Text: "I need a refund", Finished: true}},
+		{OutputTranscription: &genai.Transcription{Text: "Sure", Finished: true}},
+		{Content: genai.NewContentFromText("Sure", genai.RoleModel), TurnComplete: true},
+	}}}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for ev, err := range testutil.NewTestAgentRunner(t, a).Run(t, "session_id", "audio") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case ev.InputTranscription != nil:
+			got = append(got, "input: "+ev.InputTranscription.Text)
+		case ev.OutputTranscription != nil:
+			got = append(got, "output: "+ev.OutputTranscription.Text)
+		}
+	}
+	if diff := cmp.Diff([]string{"input: I need a refund", "output: Sure"}, got); diff != "" {
+		t.Errorf("transcription events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// partialModel streams the text in chunks, without the aggregated response.
+type partialModel struct {
+	chunks   []string
+	requests []*model.LLMRequest
+}
+
+func (m *partialModel) Name() string { return "partial" }
+
+func (m *partialModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.requests = append(m.requests, req)
+		if !stream {
+			yield(&model.LLMResponse{Content: genai.NewContentFromText(strings.Join(m.chunks, ""), genai.RoleModel), TurnComplete: true}, nil)
+			return
+		}
+		for _, chunk := range m.chunks {
+			if !yield(&model.LLMResponse{Content: genai.NewContentFromText(chunk, genai.RoleModel), Partial: true}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestStreamingPartialEvents(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		model model.LLM
+	}{
+		{
+			name:  "model aggregating the partial responses",
+			model: &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("Hello", genai.RoleModel), genai.NewContentFromText(" world", genai.RoleModel)}, StreamResponsesCount: 2},
+		},
+		{
+			name:  "model streaming only partial responses",
+			model: &partialModel{chunks: []string{"Hello", " world"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := llmagent.New(llmagent.Config{Name: "agent", Model: tc.model, OutputKey: "answer"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner := testutil.NewTestAgentRunner(t, a)
+			events, err := testutil.CollectEvents(runner.RunContentWithConfig(t, "session_id", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{StreamingMode: agent.StreamingModeSSE}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			type got struct {
+				Text                  string
+				Partial, TurnComplete bool
+				StateDelta            map[string]any
+			}
+			var gotEvents []got
+			for _, ev := range events {
+				gotEvents = append(gotEvents, got{ev.Content.Parts[0].Text, ev.Partial, ev.TurnComplete, ev.Actions.StateDelta})
+			}
+			want := []got{
+				{Text: "Hello", Partial: true, StateDelta: map[string]any{}},
+				{Text: " world", Partial: true, StateDelta: map[string]any{}},
+				{Text: "Hello world", TurnComplete: true, StateDelta: map[string]any{"answer": "Hello world"}},
+			}
+			if diff := cmp.Diff(want, gotEvents); diff != "" {
+				t.Errorf("events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStreamingCommitsFinalEvent(t *testing.T) {
+	m := &partialModel{chunks: []string{"Hello", " world"}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+	sse := agent.RunConfig{StreamingMode: agent.StreamingModeSSE}
+	for _, msg := range []string{"hi", "again"} {
+		if _, err := testutil.CollectEvents(runner.RunContentWithConfig(t, "session_id", genai.NewContentFromText(msg, genai.RoleUser), sse)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the aggregated response is in the history.
+	want := []*genai.Content{
+		genai.NewContentFromText("hi", genai.RoleUser),
+		genai.NewContentFromText("Hello world", genai.RoleModel),
+		genai.NewContentFromText("again", genai.RoleUser),
+	}
+	if diff := cmp.Diff(want, m.requests[1].Contents); diff != "" {
+		t.Errorf("second request contents mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStreamingAggregatesAcrossEmptyChunks(t *testing.T) {
+	m := &partialModel{chunks: []string{"Hello", "", " world"}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, OutputKey: "answer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+	events, err := testutil.CollectEvents(runner.RunContentWithConfig(t, "session_id", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{StreamingMode: agent.StreamingModeSSE}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := events[len(events)-1]
+	if last.Partial || last.Content.Parts[0].Text != "Hello world" {
+		t.Errorf("last event = %+v, want the final response with the text of all chunks", last.Content)
+	}
+}
+
+func TestAudioTranscriptionConfig(t *testing.T) {
+	m := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("hi", genai.RoleModel)}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := agent.RunConfig{
+		InputAudioTranscription:  &genai.AudioTranscriptionConfig{},
+		OutputAudioTranscription: &genai.AudioTranscriptionConfig{},
+	}
+	if _, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).RunContentWithConfig(t, "session_id", genai.NewContentFromText("hello", genai.RoleUser), cfg)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &genai.LiveConnectConfig{
+		InputAudioTranscription:  &genai.AudioTranscriptionConfig{},
+		OutputAudioTranscription: &genai.AudioTranscriptionConfig{},
+	}
+	if diff := cmp.Diff(want, m.Requests[0].LiveConnectConfig); diff != "" {
+		t.Errorf("LiveConnectConfig mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRealtimeInputConfig(t *testing.T) {
+	m := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("hi", genai.RoleModel)}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	realtimeInput := &genai.RealtimeInputConfig{
+		AutomaticActivityDetection: &genai.AutomaticActivityDetection{
+			StartOfSpeechSensitivity: genai.StartSensitivityLow,
+			SilenceDurationMs:        genai.Ptr[int32](500),
+		},
+		ActivityHandling: genai.ActivityHandlingNoInterruption,
+	}
+	proactivity := &genai.ProactivityConfig{ProactiveAudio: genai.Ptr(true)}
+	cfg := agent.RunConfig{RealtimeInputConfig: realtimeInput, Proactivity: proactivity}
+	if _, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).RunContentWithConfig(t, "session_id", genai.NewContentFromText("hello", genai.RoleUser), cfg)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &genai.LiveConnectConfig{RealtimeInputConfig: realtimeInput, Proactivity: proactivity}
+	if diff := cmp.Diff(want, m.Requests[0].LiveConnectConfig); diff != "" {
+		t.Errorf("LiveConnectConfig mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// usageModel returns the responses in order, reporting the token usage.
+type usageModel struct {
+	responses []*genai.Content
+	tokens    int32
+	calls     int
+}
+
+func (m *usageModel) Name() string { return "usage" }
+
+func (m *usageModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		resp := m.responses[m.calls]
+		m.calls++
+		yield(&model.LLMResponse{
+			Content:       resp,
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: m.tokens - 10, CandidatesTokenCount: 10, TotalTokenCount: m.tokens},
+		}, nil)
+	}
+}
+
+func TestTokenBudget(t *testing.T) {
+	echoTool, err := functiontool.New(functiontool.Config{
+		Name:        "echo",
+		Description: "echoes the input",
+	}, func(ctx tool.Context, args map[string]any) (map[string]any, error) {
+		return args, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		budget     int
+		wantCalls  int
+		wantBudget bool
+	}{
+		{name: "no budget", budget: 0, wantCalls: 2},
+		{name: "budget fits", budget: 10000, wantCalls: 2},
+		{name: "budget exceeded", budget: 1000, wantCalls: 1, wantBudget: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &usageModel{tokens: 1000, responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("echo", map[string]any{"text": "hi"}, genai.RoleModel),
+				genai.NewContentFromText("done", genai.RoleModel),
+			}}
+			a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, Tools: []tool.Tool{echoTool}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var events []*session.Event
+			var runErr error
+			runner := testutil.NewTestAgentRunner(t, a)
+			for ev, err := range runner.RunContentWithConfig(t, "session_id", genai.NewContentFromText("hello", genai.RoleUser), agent.RunConfig{MaxTokensPerInvocation: tc.budget}) {
+				if err != nil {
+					runErr = err
+					break
+				}
+				events = append(events, ev)
+			}
+
+			if m.calls != tc.wantCalls {
+				t.Errorf("model called %d times, want %d", m.calls, tc.wantCalls)
+			}
+			if !tc.wantBudget {
+				if runErr != nil {
+					t.Fatalf("run error = %v", runErr)
+				}
+				return
+			}
+			var budgetErr *agent.TokenBudgetExceededError
+			if !errors.As(runErr, &budgetErr) {
+				t.Fatalf("run error = %v, want *agent.TokenBudgetExceededError", runErr)
+			}
+			if budgetErr.Budget != tc.budget || budgetErr.Used <= tc.budget || budgetErr.Agent != "agent" {
+				t.Errorf("budget error = %+v", budgetErr)
+			}
+			last := events[len(events)-1]
+			if last.ErrorCode != "TOKEN_BUDGET_EXCEEDED" || last.Author != "agent" {
+				t.Errorf("last event has error code %q and author %q, want TOKEN_BUDGET_EXCEEDED by agent", last.ErrorCode, last.Author)
+			}
+		})
+	}
+}
+
+func TestTokenBudget_UsageCountedOnce(t *testing.T) {
+	usage := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 500, CandidatesTokenCount: 100, TotalTokenCount: 600}
+	// Like Gemini, the model reports the usage of the call both on the
+	// aggregated response and on the last chunk.
+	m := &interruptedModel{calls: []interruptedCall{{responses: []*model.LLMResponse{
+		partialText("do"),
+		{Content: genai.NewContentFromText("done", genai.RoleModel), UsageMetadata: usage},
+		{UsageMetadata: usage},
+	}}}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var used agent.TokenUsage
+	probe, err := agent.New(agent.Config{
+		Name: "probe",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			used = ctx.Tokens().Usage()
+			return func(func(*session.Event, error) bool) {}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := sequentialagent.New(sequentialagent.Config{AgentConfig: agent.Config{Name: "root", SubAgents: []agent.Agent{a, probe}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, root)
+	cfg := agent.RunConfig{StreamingMode: agent.StreamingModeSSE}
+	for _, err := range runner.RunContentWithConfig(t, "session_id", genai.NewContentFromText("hello", genai.RoleUser), cfg) {
+		if err != nil {
+			t.Fatalf("run error = %v", err)
+		}
+	}
+	if want := (agent.TokenUsage{PromptTokens: 500, CandidatesTokens: 100, TotalTokens: 600}); used != want {
+		t.Errorf("token usage = %+v, want %+v", used, want)
+	}
+}
+
+// interruptedModel streams the responses of each call in order, and ends the
+// call with its error, if any.
+type interruptedModel struct {
+	calls    []interruptedCall
+	requests []*model.LLMRequest
+}
+
+type interruptedCall struct {
+	responses []*model.LLMResponse
+	err       error
+}
+
+func (m *interruptedModel) Name() string { return "interrupted" }
+
+func (m *interruptedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		call := m.calls[len(m.requests)]
+		m.requests = append(m.requests, req)
+		for _, resp := range call.responses {
+			if !yield(resp, nil) {
+				return
+			}
+		}
+		if call.err != nil {
+			yield(nil, call.err)
+		}
+	}
+}
+
+func partialText(text string) *model.LLMResponse {
+	return &model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel), Partial: true}
+}
+
+func TestPartialResponseRecovery(t *testing.T) {
+	errDropped := errors.New("connection reset")
+	for _, tc := range []struct {
+		name      string
+		cfg       *llmagent.PartialResponseConfig
+		calls     []interruptedCall
+		wantText  string
+		wantErr   bool
+		wantMeta  map[string]any
+		wantCalls int
+	}{
+		{
+			name:      "no recovery",
+			calls:     []interruptedCall{{responses: []*model.LLMResponse{partialText("Hello wor")}, err: errDropped}},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "finalize on error",
+			cfg:       &llmagent.PartialResponseConfig{Policy: llmagent.PartialResponseFinalize, Annotation: " [interrupted]"},
+			calls:     []interruptedCall{{responses: []*model.LLMResponse{partialText("Hello"), partialText(" wor")}, err: errDropped}},
+			wantText:  "Hello wor [interrupted]",
+			wantMeta:  map[string]any{"partial_response": "connection reset", "partial_response_continuations": 0},
+			wantCalls: 1,
+		},
+		{
+			name: "finalize on finish reason OTHER",
+			cfg:  &llmagent.PartialResponseConfig{Policy: llmagent.PartialResponseFinalize},
+			calls: []interruptedCall{{responses: []*model.LLMResponse{
+				partialText("Hello wor"),
+				{Content: genai.NewContentFromText("Hello wor", genai.RoleModel), FinishReason: genai.FinishReasonOther},
+				{ErrorCode: "OTHER", FinishReason: genai.FinishReasonOther},
+			}}},
+			wantText:  "Hello wor",
+			wantMeta:  map[string]any{"partial_response": "finish reason OTHER", "partial_response_continuations": 0},
+			wantCalls: 1,
+		},
+		{
+			name: "continue",
+			cfg:  &llmagent.PartialResponseConfig{Policy: llmagent.PartialResponseContinue},
+			calls: []interruptedCall{
+				{responses: []*model.LLMResponse{partialText("Hello wor")}, err: errDropped},
+				{responses: []*model.LLMResponse{
+					partialText("ld!"),
+					{Content: genai.NewContentFromText("ld!", genai.RoleModel)},
+				}},
+			},
+			wantText:  "Hello world!",
+			wantMeta:  map[string]any{"partial_response_continuations": 1},
+			wantCalls: 2,
+		},
+		{
+			name: "continuation interrupted too",
+			cfg:  &llmagent.PartialResponseConfig{Policy: llmagent.PartialResponseContinue},
+			calls: []interruptedCall{
+				{responses: []*model.LLMResponse{partialText("Hello wor")}, err: errDropped},
+				{responses: []*model.LLMResponse{partialText("ld")}, err: errDropped},
+			},
+			wantText:  "Hello world",
+			wantMeta:  map[string]any{"partial_response": "connection reset", "partial_response_continuations": 1},
+			wantCalls: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &interruptedModel{calls: tc.calls}
+			a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, PartialResponse: tc.cfg})
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner := testutil.NewTestAgentRunner(t, a)
+			events, err := testutil.CollectEvents(runner.RunContentWithConfig(t, "session_id", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{StreamingMode: agent.StreamingModeSSE}))
+			if len(m.requests) != tc.wantCalls {
+				t.Errorf("model called %d times, want %d", len(m.requests), tc.wantCalls)
+			}
+			if tc.wantErr {
+				if !errors.Is(err, errDropped) {
+					t.Errorf("run error = %v, want %v", err, errDropped)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			final := events[len(events)-1]
+			if final.Partial || !final.IsFinalResponse() {
+				t.Fatalf("last event is not a final response: %+v", final)
+			}
+			if got := final.Content.Parts[0].Text; got != tc.wantText {
+				t.Errorf("final text = %q, want %q", got, tc.wantText)
+			}
+			if diff := cmp.Diff(tc.wantMeta, final.CustomMetadata); diff != "" {
+				t.Errorf("final metadata mismatch (-want +got):\n%s", diff)
+			}
+			if tc.wantCalls > 1 {
+				contents := m.requests[1].Contents
+				if got := contents[len(contents)-2]; got.Role != genai.RoleModel || got.Parts[0].Text != "Hello wor" {
+					t.Errorf("continuation request has the model content %+v, want the interrupted text", got)
+				}
+			}
+		})
+	}
+}
+
+func TestPartialResponseRecovery_SeveralFinalResponses(t *testing.T) {
+	m := &interruptedModel{calls: []interruptedCall{
+		{responses: []*model.LLMResponse{partialText("Hello wor")}, err: errors.New("connection reset")},
+		// The continuation stream yields two final responses.
+		{responses: []*model.LLMResponse{
+			partialText("ld"),
+			{Content: genai.NewContentFromText("ld", genai.RoleModel)},
+			{Content: genai.NewContentFromText("!", genai.RoleModel), FinishReason: genai.FinishReasonStop},
+		}},
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, PartialResponse: &llmagent.PartialResponseConfig{Policy: llmagent.PartialResponseContinue}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+	events, err := testutil.CollectEvents(runner.RunContentWithConfig(t, "session_id", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{StreamingMode: agent.StreamingModeSSE}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text strings.Builder
+	for _, ev := range events {
+		if !ev.Partial {
+			for _, p := range ev.Content.Parts {
+				text.WriteString(p.Text)
+			}
+		}
+	}
+	if got := text.String(); got != "Hello world!" {
+		t.Errorf("final text = %q, want the recovered text once", got)
+	}
+}
+
+func TestPartialResponseInvalidPolicy(t *testing.T) {
+	_, err := llmagent.New(llmagent.Config{
+		Name:            "agent",
+		Model:           &testutil.MockModel{},
+		PartialResponse: &llmagent.PartialResponseConfig{Policy: "retry"},
+	})
+	if err == nil {
+		t.Errorf("llmagent.New() with an unknown policy succeeded, want error")
+	}
+}
+
+// sizedModel records the requests and replies "ok".
+type sizedModel struct {
+	requests []*model.LLMRequest
+}
+
+func (m *sizedModel) Name() string { return "sized-test-model" }
+
+func (m *sizedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.requests = append(m.requests, req)
+		yield(&model.LLMResponse{Content: genai.NewContentFromText("ok", genai.RoleModel)}, nil)
+	}
+}
+
+func TestSkipSummarization(t *testing.T) {
+	skip := func(ctx tool.Context) { ctx.Actions().SkipSummarization = true }
+	for _, tc := range []struct {
+		name          string
+		toolSkips     bool
+		callbackSkips bool
+		wantCalls     int
+	}{
+		{name: "summarized", wantCalls: 2},
+		{name: "tool skips", toolSkips: true, wantCalls: 1},
+		{name: "after tool callback skips", callbackSkips: true, wantCalls: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := functiontool.New(functiontool.Config{Name: "report", Description: "returns the report"},
+				func(ctx tool.Context, _ map[string]any) (map[string]any, error) {
+					if tc.toolSkips {
+						skip(ctx)
+					}
+					return map[string]any{"rows": []any{1, 2, 3}}, nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var afterTool []llmagent.AfterToolCallback
+			if tc.callbackSkips {
+				afterTool = append(afterTool, func(ctx tool.Context, _ tool.Tool, _, result map[string]any, err error) (map[string]any, error) {
+					skip(ctx)
+					return nil, nil
+				})
+			}
+			m := &testutil.MockModel{Responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("report", map[string]any{}, genai.RoleModel),
+				genai.NewContentFromText("The report has 3 rows.", genai.RoleModel),
+			}}
+			a, err := llmagent.New(llmagent.Config{
+				Name:               "agent",
+				Model:              m,
+				Tools:              []tool.Tool{report},
+				AfterToolCallbacks: afterTool,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var last *session.Event
+			for ev, err := range testutil.NewTestAgentRunner(t, a).Run(t, "session_id", "get the report") {
+				if err != nil {
+					t.Fatal(err)
+				}
+				last = ev
+			}
+			if len(m.Requests) != tc.wantCalls {
+				t.Errorf("model called %d times, want %d", len(m.Requests), tc.wantCalls)
+			}
+			if !last.IsFinalResponse() {
+				t.Errorf("last event %+v is not a final response", last)
+			}
+			if got := last.Actions.SkipSummarization; got != (tc.wantCalls == 1) {
+				t.Errorf("last event SkipSummarization = %v", got)
+			}
+		})
+	}
+}
+
+// uploadingModel is a MockModel which uploads files.
+type uploadingModel struct {
+	testutil.MockModel
+	uploads []string
+}
+
+func (m *uploadingModel) UploadFile(ctx context.Context, displayName string, blob *genai.Blob) (*genai.FileData, error) {
+	m.uploads = append(m.uploads, displayName)
+	return &genai.FileData{FileURI: "files/" + displayName, MIMEType: blob.MIMEType}, nil
+}
+
+func TestArtifactReferences(t *testing.T) {
+	ctx := t.Context()
+	artifacts := artifact.InMemoryService()
+	logo := genai.NewPartFromBytes([]byte("png"), "image/png")
+	if _, err := artifacts.Save(ctx, &artifact.SaveRequest{AppName: "test_app", UserID: "test_user", SessionID: "session_id", FileName: "logo.png", Part: logo}); err != nil {
+		t.Fatal(err)
+	}
+	chart := genai.NewPartFromBytes([]byte("large chart"), "image/png")
+	renderChart, err := functiontool.New(functiontool.Config{Name: "render_chart", Description: "renders the chart"},
+		func(ctx tool.Context, _ map[string]any) (map[string]any, error) {
+			resp, err := ctx.Artifacts().Save(ctx, "chart.png", chart)
+			if err != nil {
+				return nil, err
+			}
+			// The reference keeps pointing to the saved version.
+			if _, err := ctx.Artifacts().Save(ctx, "chart.png", genai.NewPartFromBytes([]byte("new"), "image/png")); err != nil {
+				return nil, err
+			}
+			return map[string]any{"chart": artifact.Reference("chart.png", resp.Version)}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &uploadingModel{MockModel: testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromFunctionCall("render_chart", map[string]any{}, genai.RoleModel),
+		genai.NewContentFromText("The chart shows growth.", genai.RoleModel),
+	}}}
+	a, err := llmagent.New(llmagent.Config{
+		Name:                   "agent",
+		Model:                  m,
+		Instruction:            "Use the colors of the logo {artifact.logo.png}.",
+		Tools:                  []tool.Tool{renderChart},
+		MaxInlineArtifactBytes: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "test_app", UserID: "test_user", SessionID: "session_id"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "test_app", Agent: a, SessionService: sessionService, ArtifactService: artifacts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range r.Run(ctx, "test_user", "session_id", genai.NewContentFromText("chart the sales", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(m.Requests) != 2 {
+		t.Fatalf("model called %d times, want 2", len(m.Requests))
+	}
+	req := m.Requests[1]
+	if got := req.Config.SystemInstruction.Parts[0].Text; !strings.Contains(got, `[artifact "logo.png"]`) {
+		t.Errorf("system instruction = %q, want the logo reference", got)
+	}
+	logoContent := &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{genai.NewPartFromText("Artifact logo.png is:"), logo}}
+	if diff := cmp.Diff(logoContent, req.Contents[0]); diff != "" {
+		t.Errorf("logo content mismatch (-want +got):\n%s", diff)
+	}
+	chartContent := &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+		genai.NewPartFromText("Artifact chart.png is:"),
+		{FileData: &genai.FileData{FileURI: "files/chart.png", MIMEType: "image/png"}},
+	}}
+	if diff := cmp.Diff(chartContent, req.Contents[len(req.Contents)-1]); diff != "" {
+		t.Errorf("chart content mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"chart.png"}, m.uploads); diff != "" {
+		t.Errorf("uploads mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestContextWindowExceeded(t *testing.T) {
+	model.RegisterCapabilities("sized-test-model", model.Capabilities{InputTokenLimit: 300})
+	long := strings.Repeat("a", 800)
+
+	for _, tc := range []struct {
+		name         string
+		window       *llmagent.ContextWindowConfig
+		wantContents []int
+		wantErr      bool
+	}{
+		{name: "request too large", wantContents: []int{1}, wantErr: true},
+		{name: "compacted", window: &llmagent.ContextWindowConfig{MaxEvents: 100}, wantContents: []int{1, 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &sizedModel{}
+			a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, ContextWindow: tc.window})
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner := testutil.NewTestAgentRunner(t, a)
+			var runErr error
+			for _, msg := range []string{long, long} {
+				for _, err := range runner.Run(t, "session_id", msg) {
+					if err != nil {
+						runErr = err
+					}
+				}
+			}
+
+			var got []int
+			for _, req := range m.requests {
+				got = append(got, len(req.Contents))
+			}
+			if diff := cmp.Diff(tc.wantContents, got); diff != "" {
+				t.Errorf("model request contents mismatch (-want +got):\n%s", diff)
+			}
+			if !tc.wantErr {
+				if runErr != nil {
+					t.Fatalf("run error = %v", runErr)
+				}
+				return
+			}
+			var sizeErr *model.ContextWindowExceededError
+			if !errors.As(runErr, &sizeErr) {
+				t.Fatalf("run error = %v, want *model.ContextWindowExceededError", runErr)
+			}
+			if sizeErr.Model != "sized-test-model" || sizeErr.Limit != 300 || sizeErr.Tokens <= 300 {
+				t.Errorf("context window error = %+v", sizeErr)
+			}
+		})
+	}
+}
+
+func TestContextWindowExceeded_InlineMedia(t *testing.T) {
+	model.RegisterCapabilities("sized-test-model", model.Capabilities{InputTokenLimit: 300})
+	m := &sizedModel{}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+	// The image is counted as media, not as its 3 MB of base64 data.
+	msg := genai.NewContentFromParts([]*genai.Part{
+		genai.NewPartFromText("Describe the image."),
+		genai.NewPartFromBytes(make([]byte, 3<<20), "image/png"),
+	}, genai.RoleUser)
+	for _, err := range runner.RunContent(t, "session_id", msg) {
+		if err != nil {
+			t.Fatalf("run error = %v", err)
+		}
+	}
+	if len(m.requests) != 1 {
+		t.Errorf("model requests = %d, want 1", len(m.requests))
+	}
+}
+
+func TestContextWindowSummary(t *testing.T) {
+	var requests []*summarizer.Request
+	m := &sizedModel{}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "agent",
+		Model: m,
+		ContextWindow: &llmagent.ContextWindowConfig{
+			MaxEvents: 4,
+			Summarize: true,
+			Summarizer: summarizerFunc(func(_ context.Context, req *summarizer.Request) (string, error) {
+				requests = append(requests, req)
+				return fmt.Sprintf("summary %d", len(requests)), nil
+			}),
+			SummaryBatch: 4,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+	var compactions []*session.Compaction
+	for i := range 6 {
+		for ev, err := range runner.Run(t, "session_id", fmt.Sprintf("u%d", i+1)) {
+			if err != nil {
+				t.Fatalf("run error = %v", err)
+			}
+			if ev.Actions.Compaction != nil {
+				compactions = append(compactions, ev.Actions.Compaction)
+			}
+		}
+	}
+
+	// The history is summarized once per batch, and the summary is reused
+	// from the session by the later invocations.
+	wantCompactions := []*session.Compaction{{Contents: 4, Summary: "summary 1"}, {Contents: 8, Summary: "summary 2"}}
+	if diff := cmp.Diff(wantCompactions, compactions); diff != "" {
+		t.Errorf("compactions mismatch (-want +got):\n%s", diff)
+	}
+	if len(requests) != 2 || requests[1].Previous != "summary 1" || len(requests[1].Contents) != 4 {
+		t.Errorf("summary requests = %+v, want 2 requests, the second extending the first summary", requests)
+	}
+	var got []int
+	for _, req := range m.requests {
+		got = append(got, len(req.Contents))
+	}
+	if diff := cmp.Diff([]int{1, 3, 2, 4, 2, 4}, got); diff != "" {
+		t.Errorf("model request contents mismatch (-want +got):\n%s", diff)
+	}
+	if text := m.requests[5].Contents[0].Parts[0].Text; !strings.HasSuffix(text, "summary 2") {
+		t.Errorf("first content of the last request = %q, want the summary", text)
+	}
+}
+
+func TestGenerationParams(t *testing.T) {
+	var req *model.LLMRequest
+	baseConfig := &genai.GenerateContentConfig{
+		Temperature:   genai.Ptr[float32](0.9),
+		TopK:          genai.Ptr[float32](40),
+		StopSequences: []string{"END"},
+	}
+	safety := []*genai.SafetySetting{{Category: genai.HarmCategoryHarassment, Threshold: genai.HarmBlockThresholdBlockLowAndAbove}}
+	a, err := llmagent.New(llmagent.Config{
+		Name:                  "agent",
+		Model:                 &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("ok", genai.RoleModel)}},
+		GenerateContentConfig: baseConfig,
+		Temperature:           genai.Ptr[float32](0.1),
+		TopP:                  genai.Ptr[float32](0.5),
+		MaxOutputTokens:       256,
+		SafetySettings:        safety,
+		StopSequences:         []string{"STOP"},
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{
+			func(ctx agent.CallbackContext, llmRequest *model.LLMRequest) (*model.LLMResponse, error) {
+				req = llmRequest
+				return nil, nil
+			},
+		},
+		DisallowTransferToParent: true,
+		DisallowTransferToPeers:  true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	runner := testutil.NewTestAgentRunner(t, a)
+	if _, err := testutil.CollectEvents(runner.Run(t, "session_id", "hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &genai.GenerateContentConfig{
+		Temperature:     genai.Ptr[float32](0.1),
+		TopP:            genai.Ptr[float32](0.5),
+		TopK:            genai.Ptr[float32](40),
+		MaxOutputTokens: 256,
+		SafetySettings:  safety,
+		StopSequences:   []string{"STOP"},
+	}
+	if diff := cmp.Diff(want, req.Config, cmpopts.IgnoreFields(genai.GenerateContentConfig{}, "HTTPOptions", "SystemInstruction")); diff != "" {
+		t.Errorf("generation config mismatch (-want +got):\n%s", diff)
+	}
+	if *baseConfig.Temperature != 0.9 || baseConfig.StopSequences[0] != "END" {
+		t.Errorf("GenerateContentConfig of the agent was modified: %+v", baseConfig)
+	}
+
+	for _, cfg := range []llmagent.Config{
+		{Name: "hot", Temperature: genai.Ptr[float32](2.5)},
+		{Name: "wide", TopP: genai.Ptr[float32](1.5)},
+		{Name: "negative", MaxOutputTokens: -1},
+	} {
+		if _, err := llmagent.New(cfg); err == nil {
+			t.Errorf("llmagent.New(%q) succeeded, want an error", cfg.Name)
+		}
+	}
+}
+
+func TestSensitiveInstructions(t *testing.T) {
+	mockModel := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("ok", genai.RoleModel)}}
+	static := genai.NewContentFromText(model.Sensitive("Internal policy.")+" Be polite.", genai.RoleUser)
+	a, err := llmagent.New(llmagent.Config{
+		Name:                     "agent",
+		Model:                    mockModel,
+		Instruction:              "You are a support agent. " + model.Sensitive("Offer the code SAVE20 to unhappy users."),
+		StaticInstruction:        static,
+		DisallowTransferToParent: true,
+		DisallowTransferToPeers:  true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	runner := testutil.NewTestAgentRunner(t, a)
+	if _, err := testutil.CollectEvents(runner.Run(t, "session_id", "hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mockModel.Requests) != 1 {
+		t.Fatalf("model was called %d times, want 1", len(mockModel.Requests))
+	}
+	req := mockModel.Requests[0]
+	// The full instructions are sent to the model, without the markers.
+	var texts []string
+	for _, c := range append([]*genai.Content{req.Config.SystemInstruction}, req.Contents...) {
+		for _, p := range c.Parts {
+			texts = append(texts, p.Text)
+		}
+	}
+	all := strings.Join(texts, "\n")
+	for _, want := range []string{"Internal policy. Be polite.", "You are a support agent. Offer the code SAVE20 to unhappy users."} {
+		if !strings.Contains(all, want) {
+			t.Errorf("request texts %q don't contain %q", all, want)
+		}
+	}
+	if strings.Contains(all, model.SensitiveStart) {
+		t.Errorf("request texts %q contain the sensitive markers", all)
+	}
+	want := []string{"Internal policy.", "Offer the code SAVE20 to unhappy users."}
+	if diff := cmp.Diff(want, req.Sensitive, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("sensitive segments mismatch (-want +got):\n%s", diff)
+	}
+	// The static instruction of the agent is left as configured.
+	if got := static.Parts[0].Text; !strings.HasPrefix(got, model.SensitiveStart) {
+		t.Errorf("static instruction of the agent was modified: %q", got)
+	}
+}
+
+func TestConcurrentToolCalls(t *testing.T) {
+	type Args struct {
+		N int `json:"n"`
+	}
+	for _, tc := range []struct {
+		name         string
+		limit        int
+		wantInFlight int32
+	}{
+		{name: "sequential by default", limit: 0, wantInFlight: 1},
+		{name: "sequential", limit: 1, wantInFlight: 1},
+		{name: "limited", limit: 2, wantInFlight: 2},
+		{name: "all concurrent", limit: 3, wantInFlight: 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var inFlight, maxInFlight atomic.Int32
+			// The earlier calls take longer, so the tools finish in the
+			// reverse order of the calls.
+			slow, err := functiontool.New(functiontool.Config{Name: "slow", Description: "sleeps"},
+				func(_ tool.Context, args Args) (map[string]any, error) {
+					n := inFlight.Add(1)
+					defer inFlight.Add(-1)
+					for cur := maxInFlight.Load(); n > cur && !maxInFlight.CompareAndSwap(cur, n); cur = maxInFlight.Load() {
+					}
+					time.Sleep(time.Duration(3-args.N) * 20 * time.Millisecond)
+					return map[string]any{"n": args.N}, nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			calls := &genai.Content{Role: genai.RoleModel}
+			for i := range 3 {
+				calls.Parts = append(calls.Parts, &genai.Part{FunctionCall: &genai.FunctionCall{
+					ID: fmt.Sprint(i), Name: "slow", Args: map[string]any{"n": i},
+				}})
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name: "agent",
+				Model: &testutil.MockModel{Responses: []*genai.Content{
+					calls,
+					genai.NewContentFromText("done", genai.RoleModel),
+				}},
+				Tools:                  []tool.Tool{slow},
+				MaxConcurrentToolCalls: tc.limit,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotIDs []string
+			for ev, err := range testutil.NewTestAgentRunner(t, a).Run(t, "session", "go") {
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, p := range ev.Content.Parts {
+					if p.FunctionResponse != nil {
+						gotIDs = append(gotIDs, p.FunctionResponse.ID)
+					}
+				}
+			}
+			if diff := cmp.Diff([]string{"0", "1", "2"}, gotIDs); diff != "" {
+				t.Errorf("function response IDs mismatch (-want +got):\n%s", diff)
+			}
+			if got := maxInFlight.Load(); got != tc.wantInFlight {
+				t.Errorf("max concurrent tool calls = %d, want %d", got, tc.wantInFlight)
+			}
+		})
+	}
+
+	if _, err := llmagent.New(llmagent.Config{Name: "agent", MaxConcurrentToolCalls: -1}); err == nil {
+		t.Error("llmagent.New() with negative MaxConcurrentToolCalls succeeded, want error")
+	}
+}
+
+func TestConcurrentToolCalls_MergedActions(t *testing.T) {
+	type Args struct {
+		Key string `json:"key"`
+	}
+	write, err := functiontool.New(functiontool.Config{Name: "write", Description: "writes the key"},
+		func(ctx tool.Context, args Args) (map[string]any, error) {
+			if err := ctx.State().Set(args.Key, true); err != nil {
+				return nil, err
+			}
+			if _, err := ctx.Artifacts().Save(ctx, args.Key+".txt", genai.NewPartFromText(args.Key)); err != nil {
+				return nil, err
+			}
+			return map[string]any{}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name: "agent",
+		Model: &testutil.MockModel{Responses: []*genai.Content{
+			{Role: genai.RoleModel, Parts: []*genai.Part{
+				{FunctionCall: &genai.FunctionCall{ID: "1", Name: "write", Args: map[string]any{"key": "a"}}},
+				{FunctionCall: &genai.FunctionCall{ID: "2", Name: "write", Args: map[string]any{"key": "b"}}},
+			}},
+			genai.NewContentFromText("done", genai.RoleModel),
+		}},
+		Tools:                  []tool.Tool{write},
+		MaxConcurrentToolCalls: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService, ArtifactService: artifact.InMemoryService()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var responseEvent *session.Event
+	for ev, err := range r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("go", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ev.Content.Parts) > 0 && ev.Content.Parts[0].FunctionResponse != nil {
+			responseEvent = ev
+		}
+	}
+	if responseEvent == nil {
+		t.Fatal("no function response event")
+	}
+	if diff := cmp.Diff(map[string]any{"a": true, "b": true}, responseEvent.Actions.StateDelta); diff != "" {
+		t.Errorf("state delta mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]int64{"a.txt": 1, "b.txt": 1}, responseEvent.Actions.ArtifactDelta); diff != "" {
+		t.Errorf("artifact delta mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLongOutput(t *testing.T) {
+	truncated := func(text string, tokens int32) *model.LLMResponse {
+		return &model.LLMResponse{
+			Content:       genai.NewContentFromText(text, genai.RoleModel),
+			FinishReason:  genai.FinishReasonMaxTokens,
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 10, CandidatesTokenCount: tokens, TotalTokenCount: 10 + tokens},
+		}
+	}
+	for _, tc := range []struct {
+		name             string
+		cfg              *llmagent.LongOutputConfig
+		calls            []interruptedCall
+		wantText         string
+		wantFinishReason genai.FinishReason
+		wantTotalTokens  int32
+		wantCalls        int
+	}{
+		{
+			name:             "disabled",
+			calls:            []interruptedCall{{responses: []*model.LLMResponse{truncated("The quick brown fox jumps", 5)}}},
+			wantText:         "The quick brown fox jumps",
+			wantFinishReason: genai.FinishReasonMaxTokens,
+			wantTotalTokens:  15,
+			wantCalls:        1,
+		},
+		{
+			name: "stitched without the repeated text",
+			cfg:  &llmagent.LongOutputConfig{},
+			calls: []interruptedCall{
+				{responses: []*model.LLMResponse{truncated("The quick brown fox jumps", 5)}},
+				{responses: []*model.LLMResponse{{
+					Content:       genai.NewContentFromText("fox jumps over the lazy dog.", genai.RoleModel),
+					FinishReason:  genai.FinishReasonStop,
+					UsageMetadata: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 20, CandidatesTokenCount: 6, TotalTokenCount: 26},
+				}}},
+			},
+			wantText:         "The quick brown fox jumps over the lazy dog.",
+			wantFinishReason: genai.FinishReasonStop,
+			wantTotalTokens:  41,
+			wantCalls:        2,
+		},
+		{
+			name: "continuations exhausted",
+			cfg:  &llmagent.LongOutputConfig{MaxContinuations: 1},
+			calls: []interruptedCall{
+				{responses: []*model.LLMResponse{truncated("The quick brown fox jumps", 5)}},
+				{responses: []*model.LLMResponse{truncated(" over the", 2)}},
+			},
+			wantText:         "The quick brown fox jumps over the",
+			wantFinishReason: genai.FinishReasonMaxTokens,
+			wantTotalTokens:  27,
+			wantCalls:        2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &interruptedModel{calls: tc.calls}
+			a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, LongOutput: tc.cfg})
+			if err != nil {
+				t.Fatal(err)
+			}
+			events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session_id", "write a report"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m.requests) != tc.wantCalls {
+				t.Errorf("model called %d times, want %d", len(m.requests), tc.wantCalls)
+			}
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want 1 stitched event", len(events))
+			}
+			final := events[0]
+			if got := final.Content.Parts[0].Text; got != tc.wantText {
+				t.Errorf("final text = %q, want %q", got, tc.wantText)
+			}
+			if final.FinishReason != tc.wantFinishReason {
+				t.Errorf("final finish reason = %v, want %v", final.FinishReason, tc.wantFinishReason)
+			}
+			if got := final.UsageMetadata.TotalTokenCount; got != tc.wantTotalTokens {
+				t.Errorf("final total tokens = %d, want %d", got, tc.wantTotalTokens)
+			}
+			if tc.wantCalls > 1 {
+				contents := m.requests[1].Contents
+				if got := contents[len(contents)-2]; got.Role != genai.RoleModel || got.Parts[0].Text != "The quick brown fox jumps" {
+					t.Errorf("continuation request has the model content %+v, want the truncated text", got)
+				}
+			}
+		})
+	}
+}
+
+func TestLongOutput_SeveralFinalResponses(t *testing.T) {
+	m := &interruptedModel{calls: []interruptedCall{
+		{responses: []*model.LLMResponse{{
+			Content:      genai.NewContentFromText("The quick brown fox jumps", genai.RoleModel),
+			FinishReason: genai.FinishReasonMaxTokens,
+		}}},
+		// The continuation stream yields two final responses.
+		{responses: []*model.LLMResponse{
+			{Content: genai.NewContentFromText(" over the lazy", genai.RoleModel)},
+			{Content: genai.NewContentFromText(" dog.", genai.RoleModel), FinishReason: genai.FinishReasonStop},
+		}},
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, LongOutput: &llmagent.LongOutputConfig{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session_id", "write a report"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text strings.Builder
+	for _, ev := range events {
+		for _, p := range ev.Content.Parts {
+			text.WriteString(p.Text)
+		}
+	}
+	if got, want := text.String(), "The quick brown fox jumps over the lazy dog."; got != want {
+		t.Errorf("final text = %q, want %q", got, want)
+	}
+}
+
+func TestToolTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		agentTimeout time.Duration
+		toolTimeout  time.Duration
+		block        bool
+		wantErr      string
+		wantState    map[string]any
+	}{
+		{name: "agent timeout", agentTimeout: 20 * time.Millisecond, block: true, wantErr: "timed out after 20ms"},
+		{name: "tool timeout precedes agent timeout", agentTimeout: time.Hour, toolTimeout: 20 * time.Millisecond, block: true, wantErr: "timed out after 20ms"},
+		{name: "in time", agentTimeout: time.Hour, wantState: map[string]any{"done": true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			canceled := make(chan struct{})
+			wait, err := functiontool.New(functiontool.Config{Name: "wait", Description: "waits", Timeout: tc.toolTimeout},
+				func(ctx tool.Context, _ struct{}) (map[string]any, error) {
+					if tc.block {
+						<-ctx.Done()
+						close(canceled)
+						return nil, ctx.Err()
+					}
+					if err := ctx.State().Set("done", true); err != nil {
+						return nil, err
+					}
+					return map[string]any{"ok": true}, nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := &testutil.MockModel{Responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("wait", nil, genai.RoleModel),
+				genai.NewContentFromText("done", genai.RoleModel),
+			}}
+			a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, Tools: []tool.Tool{wait}, ToolTimeout: tc.agentTimeout})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var resp *genai.FunctionResponse
+			var state map[string]any
+			for ev, err := range testutil.NewTestAgentRunner(t, a).Run(t, "session", "go") {
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, p := range ev.Content.Parts {
+					if p.FunctionResponse != nil {
+						resp, state = p.FunctionResponse, ev.Actions.StateDelta
+					}
+				}
+			}
+			if resp == nil {
+				t.Fatal("no function response")
+			}
+			if tc.wantErr != "" {
+				if got := fmt.Sprint(resp.Response["error"]); !strings.Contains(got, tc.wantErr) {
+					t.Errorf("function response error = %q, want it to contain %q", got, tc.wantErr)
+				}
+				select {
+				case <-canceled:
+				case <-time.After(time.Second):
+					t.Error("the context of the timed-out tool wasn't canceled")
+				}
+			}
+			if tc.wantState != nil {
+				if diff := cmp.Diff(tc.wantState, state); diff != "" {
+					t.Errorf("state delta mismatch (-want +got):\n%s", diff)
+				}
+			}
+			if len(m.Requests) != 2 {
+				t.Errorf("model called %d times, want 2", len(m.Requests))
+			}
+		})
+	}
+
+	if _, err := llmagent.New(llmagent.Config{Name: "agent", ToolTimeout: -time.Second}); err == nil {
+		t.Error("llmagent.New() with negative ToolTimeout succeeded, want error")
+	}
+}
+
+type summarizerFunc func(context.Context, *summarizer.Request) (string, error)
+
+func (f summarizerFunc) Summarize(ctx context.Context, req *summarizer.Request) (string, error) {
+	return f(ctx, req)
+}
+
+func TestToolResultLimit(t *testing.T) {
+	const maxBytes = 200
+	rows := strings.Repeat("row,", 250)
+	for _, tc := range []struct {
+		name     string
+		strategy toolresult.Strategy
+		rows     string
+		wantKey  string
+	}{
+		{name: "small result", rows: "row", wantKey: "rows"},
+		{name: "truncate", rows: rows, wantKey: "truncated_result"},
+		{
+			name: "summarize",
+			strategy: toolresult.Summarize(summarizerFunc(func(_ context.Context, req *summarizer.Request) (string, error) {
+				if req.Purpose != summarizer.PurposeToolResult {
+					t.Errorf("summary purpose = %q, want %q", req.Purpose, summarizer.PurposeToolResult)
+				}
+				return "250 rows", nil
+			})),
+			rows:    rows,
+			wantKey: "summarized_result",
+		},
+		{name: "save as artifact", strategy: toolresult.SaveAsArtifact(), rows: rows, wantKey: "artifact"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := t.Context()
+			query, err := functiontool.New(functiontool.Config{Name: "query", Description: "queries the rows"},
+				func(tool.Context, struct{}) (map[string]any, error) {
+					return map[string]any{"rows": tc.rows}, nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := &testutil.MockModel{Responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("query", nil, genai.RoleModel),
+				genai.NewContentFromText("done", genai.RoleModel),
+			}}
+			a, err := llmagent.New(llmagent.Config{
+				Name:            "agent",
+				Model:           m,
+				Tools:           []tool.Tool{query},
+				ToolResultLimit: &llmagent.ToolResultLimitConfig{MaxBytes: maxBytes, Strategy: tc.strategy},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			artifacts := artifact.InMemoryService()
+			sessionService := session.InMemoryService()
+			if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "test_app", UserID: "test_user", SessionID: "session_id"}); err != nil {
+				t.Fatal(err)
+			}
+			r, err := runner.New(runner.Config{AppName: "test_app", Agent: a, SessionService: sessionService, ArtifactService: artifacts})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, err := range r.Run(ctx, "test_user", "session_id", genai.NewContentFromText("query", genai.RoleUser), agent.RunConfig{}) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if len(m.Requests) != 2 {
+				t.Fatalf("model called %d times, want 2", len(m.Requests))
+			}
+			contents := m.Requests[1].Contents
+			resp := contents[len(contents)-1].Parts[0].FunctionResponse.Response
+			if size := toolresult.Size(resp); size > maxBytes {
+				t.Errorf("function response has %d bytes, want at most %d: %v", size, maxBytes, resp)
+			}
+			if _, ok := resp[tc.wantKey]; !ok {
+				t.Errorf("function response = %v, want the key %q", resp, tc.wantKey)
+			}
+			if name, ok := resp["artifact"].(string); ok {
+				got, err := artifacts.Load(ctx, &artifact.LoadRequest{AppName: "test_app", UserID: "test_user", SessionID: "session_id", FileName: name})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := `{"rows":"` + rows + `"}`; got.Part.Text != want {
+					t.Errorf("saved artifact = %q, want the full result", got.Part.Text)
+				}
+			}
+		})
+	}
+
+	if _, err := llmagent.New(llmagent.Config{Name: "agent", ToolResultLimit: &llmagent.ToolResultLimitConfig{}}); err == nil {
+		t.Error("llmagent.New() without ToolResultLimit.MaxBytes succeeded, want error")
+	}
+}
+
+func TestThoughts(t *testing.T) {
+	thought := &genai.Part{Text: "The answer is 6 times 7.", Thought: true}
+	answer := genai.NewPartFromText("42")
+	for _, tc := range []struct {
+		name            string
+		stripThoughts   bool
+		includeThoughts bool
+		wantYielded     [][]*genai.Part
+		wantThought     []bool
+		wantPersisted   []*genai.Part
+	}{
+		{
+			name:          "withheld from the caller",
+			wantYielded:   [][]*genai.Part{{answer}},
+			wantThought:   []bool{false},
+			wantPersisted: []*genai.Part{thought, answer},
+		},
+		{
+			name:            "included",
+			includeThoughts: true,
+			wantYielded:     [][]*genai.Part{{thought}, {thought, answer}},
+			wantThought:     []bool{true, false},
+			wantPersisted:   []*genai.Part{thought, answer},
+		},
+		{
+			name:            "stripped",
+			stripThoughts:   true,
+			includeThoughts: true,
+			wantYielded:     [][]*genai.Part{{answer}},
+			wantThought:     []bool{false},
+			wantPersisted:   []*genai.Part{answer},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := t.Context()
+			m := &interruptedModel{calls: []interruptedCall{{responses: []*model.LLMResponse{
+				{Content: genai.NewContentFromParts([]*genai.Part{thought}, genai.RoleModel), Partial: true},
+				// The thought is moved before the answer.
+				{Content: genai.NewContentFromParts([]*genai.Part{answer, thought}, genai.RoleModel)},
+			}}}}
+			a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, StripThoughts: tc.stripThoughts})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessionService := session.InMemoryService()
+			if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "test_app", UserID: "test_user", SessionID: "session_id"}); err != nil {
+				t.Fatal(err)
+			}
+			r, err := runner.New(runner.Config{AppName: "test_app", Agent: a, SessionService: sessionService})
+			if err != nil {
+				t.Fatal(err)
+			}
+			events, err := testutil.CollectEvents(r.Run(ctx, "test_user", "session_id", genai.NewContentFromText("what is 6 times 7?", genai.RoleUser), agent.RunConfig{IncludeThoughts: tc.includeThoughts}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotYielded [][]*genai.Part
+			var gotThought []bool
+			for _, ev := range events {
+				gotYielded = append(gotYielded, ev.Content.Parts)
+				gotThought = append(gotThought, ev.Thought)
+			}
+			if diff := cmp.Diff(tc.wantYielded, gotYielded); diff != "" {
+				t.Errorf("yielded parts mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantThought, gotThought); diff != "" {
+				t.Errorf("yielded thought flags mismatch (-want +got):\n%s", diff)
+			}
+
+			resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: "test_app", UserID: "test_user", SessionID: "session_id"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			last := resp.Session.Events().At(resp.Session.Events().Len() - 1)
+			if diff := cmp.Diff(tc.wantPersisted, last.Content.Parts); diff != "" {
+				t.Errorf("persisted parts mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// codeExecutorFunc is a codeexecutor.Executor calling the function.
+type codeExecutorFunc func(ctx context.Context, code *genai.ExecutableCode) (*genai.CodeExecutionResult, error)
+
+func (f codeExecutorFunc) Execute(ctx context.Context, code *genai.ExecutableCode) (*genai.CodeExecutionResult, error) {
+	return f(ctx, code)
+}
+
+func TestCodeExecutor(t *testing.T) {
+	code := &genai.ExecutableCode{Code: "print(2 + 2)", Language: genai.LanguagePython}
+	codeContent := genai.NewContentFromParts([]*genai.Part{genai.NewPartFromText("Let me compute it."), {ExecutableCode: code}}, genai.RoleModel)
+	hasCodeExecutionTool := func(req *model.LLMRequest) bool {
+		return req.Config != nil && slices.ContainsFunc(req.Config.Tools, func(t *genai.Tool) bool { return t.CodeExecution != nil })
+	}
+
+	t.Run("executed", func(t *testing.T) {
+		var executed []string
+		executor := codeExecutorFunc(func(ctx context.Context, code *genai.ExecutableCode) (*genai.CodeExecutionResult, error) {
+			executed = append(executed, code.Code)
+			return &genai.CodeExecutionResult{Outcome: genai.OutcomeOK, Output: "4\n"}, nil
+		})
+		m := &testutil.MockModel{Responses: []*genai.Content{codeContent, genai.NewContentFromText("2 + 2 is 4.", genai.RoleModel)}}
+		a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, CodeExecutor: executor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session_id", "what is 2 + 2?"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"print(2 + 2)"}, executed); diff != "" {
+			t.Errorf("executed code mismatch (-want +got):\n%s", diff)
+		}
+		result := &genai.Part{CodeExecutionResult: &genai.CodeExecutionResult{Outcome: genai.OutcomeOK, Output: "4\n"}}
+		wantCode := genai.NewContentFromParts(append(slices.Clone(codeContent.Parts), result), genai.RoleModel)
+		if len(events) != 2 {
+			t.Fatalf("got %d events, want the code event and the answer", len(events))
+		}
+		if diff := cmp.Diff(wantCode, events[0].Content); diff != "" {
+			t.Errorf("code event content mismatch (-want +got):\n%s", diff)
+		}
+		if len(m.Requests) != 2 {
+			t.Fatalf("model called %d times, want 2", len(m.Requests))
+		}
+		contents := m.Requests[1].Contents
+		if diff := cmp.Diff(wantCode, contents[len(contents)-1]); diff != "" {
+			t.Errorf("second request last content mismatch (-want +got):\n%s", diff)
+		}
+		if hasCodeExecutionTool(m.Requests[0]) {
+			t.Error("request has the code execution tool, want none with a client-side executor")
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		executor := codeExecutorFunc(func(ctx context.Context, code *genai.ExecutableCode) (*genai.CodeExecutionResult, error) {
+			return nil, errors.New("sandbox unavailable")
+		})
+		m := &testutil.MockModel{Responses: []*genai.Content{codeContent, genai.NewContentFromText("I could not compute it.", genai.RoleModel)}}
+		a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, CodeExecutor: executor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session_id", "what is 2 + 2?"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts := events[0].Content.Parts
+		want := &genai.CodeExecutionResult{Outcome: genai.OutcomeFailed, Output: "sandbox unavailable"}
+		if diff := cmp.Diff(want, parts[len(parts)-1].CodeExecutionResult); diff != "" {
+			t.Errorf("code execution result mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	for _, tc := range []struct {
+		name     string
+		executor codeexecutor.Executor
+		cfg      agent.RunConfig
+		wantLive bool
+	}{
+		{name: "built-in", executor: codeexecutor.BuiltIn()},
+		{name: "compositional function calling", cfg: agent.RunConfig{SupportCFC: true}, wantLive: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("4", genai.RoleModel)}}
+			a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, CodeExecutor: tc.executor})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).RunContentWithConfig(t, "session_id", genai.NewContentFromText("what is 2 + 2?", genai.RoleUser), tc.cfg)); err != nil {
+				t.Fatal(err)
+			}
+			req := m.Requests[0]
+			if got := hasCodeExecutionTool(req); got == tc.wantLive {
+				t.Errorf("request has the code execution tool = %v, want %v", got, !tc.wantLive)
+			}
+			gotLive := req.LiveConnectConfig != nil && slices.ContainsFunc(req.LiveConnectConfig.Tools, func(t *genai.Tool) bool { return t.CodeExecution != nil })
+			if gotLive != tc.wantLive {
+				t.Errorf("live connection has the code execution tool = %v, want %v", gotLive, tc.wantLive)
+			}
+		})
+	}
+}
+
+func TestProcessors(t *testing.T) {
+	m := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("hello", genai.RoleModel)}}
+	var order []string
+	a, err := llmagent.New(llmagent.Config{
+		Name:        "agent",
+		Model:       m,
+		Instruction: "Be helpful.",
+		RequestProcessors: []llmagent.RequestProcessor{
+			func(ctx agent.InvocationContext, req *model.LLMRequest) error {
+				// The built-in processors have run.
+				if req.Config == nil || req.Config.SystemInstruction == nil || len(req.Contents) != 1 {
+					t.Errorf("request processor got request %+v, want the instructions and contents", req)
+				}
+				order = append(order, "request")
+				req.Contents = append(req.Contents, genai.NewContentFromText("The customer is a premium member.", genai.RoleUser))
+				return nil
+			},
+		},
+		ResponseProcessors: []llmagent.ResponseProcessor{
+			func(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
+				order = append(order, "response")
+				resp.Content.Parts[0].Text = strings.ToUpper(resp.Content.Parts[0].Text)
+				return nil
+			},
+		},
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{
+			func(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+				order = append(order, "before model")
+				return nil, nil
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"request", "before model", "response"}, order); diff != "" {
+		t.Errorf("call order mismatch (-want +got):\n%s", diff)
+	}
+	if got := len(m.Requests[0].Contents); got != 2 {
+		t.Errorf("model request has %d contents, want the user input and the processor's content", got)
+	}
+	if got := events[len(events)-1].Content.Parts[0].Text; got != "HELLO" {
+		t.Errorf("final response = %q, want %q", got, "HELLO")
+	}
+
+	a, err = llmagent.New(llmagent.Config{
+		Name:  "agent",
+		Model: &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("hello", genai.RoleModel)}},
+		RequestProcessors: []llmagent.RequestProcessor{
+			func(agent.InvocationContext, *model.LLMRequest) error { return errors.New("rejected") },
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "hi")); err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("run error = %v, want the error of the request processor", err)
+	}
+}
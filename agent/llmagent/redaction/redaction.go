@@ -0,0 +1,353 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redaction redacts personal data, e.g. emails and credit card
+// numbers, from the requests sent to the model of an LLM agent, and
+// restores it in the responses, see llmagent.Config.RequestProcessors.
+//
+// Each redacted value is replaced with a placeholder made of the name of its
+// pattern and of a keyed hash of the value, e.g. "[EMAIL_1f3a9c0b]", so a
+// value has the same placeholder in every request of the conversation.
+package redaction
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Pattern matches the values to redact.
+type Pattern struct {
+	// Name prefixes the placeholders of the values, e.g. "EMAIL". It must be
+	// made of uppercase letters, digits and underscores.
+	Name   string
+	Regexp *regexp.Regexp
+	// Valid filters the matches of Regexp, e.g. with a checksum.
+	// Optional: if nil, every match is redacted.
+	Valid func(string) bool
+}
+
+// The built-in patterns.
+var (
+	// Email matches email addresses.
+	Email = Pattern{
+		Name:   "EMAIL",
+		Regexp: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	}
+	// CreditCard matches the card numbers of 13 to 19 digits, possibly
+	// grouped with spaces or dashes, which pass the Luhn checksum.
+	CreditCard = Pattern{
+		Name:   "CREDIT_CARD",
+		Regexp: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Valid:  luhn,
+	}
+)
+
+// Config configures a Redactor.
+type Config struct {
+	// Patterns are the values to redact.
+	// Optional: defaults to Email and CreditCard.
+	Patterns []Pattern
+	// Restore replaces the placeholders in the responses of the model, in
+	// their text and in the arguments of their function calls, with the
+	// values found in the session. Otherwise, the placeholders are kept.
+	Restore bool
+	// Key is the key of the hashes of the placeholders. Set it to keep the
+	// placeholders stable across processes, e.g. for context caching.
+	// Optional: defaults to a random key per Redactor.
+	Key []byte
+}
+
+// Redactor redacts the patterns from the requests sent to the model.
+type Redactor struct {
+	patterns []Pattern
+	restore  bool
+	key      []byte
+	// placeholder matches the placeholders of the patterns.
+	placeholder *regexp.Regexp
+}
+
+// New creates a Redactor.
+func New(cfg Config) (*Redactor, error) {
+	patterns := cfg.Patterns
+	if len(patterns) == 0 {
+		patterns = []Pattern{Email, CreditCard}
+	}
+	validName := regexp.MustCompile(`^[A-Z0-9_]+$`)
+	names := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		if !validName.MatchString(p.Name) {
+			return nil, fmt.Errorf("invalid pattern name %q: want uppercase letters, digits and underscores", p.Name)
+		}
+		if p.Regexp == nil {
+			return nil, fmt.Errorf("pattern %q has no regexp", p.Name)
+		}
+		names = append(names, p.Name)
+	}
+	key := cfg.Key
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate the key: %w", err)
+		}
+	}
+	return &Redactor{
+		patterns:    patterns,
+		restore:     cfg.Restore,
+		key:         key,
+		placeholder: regexp.MustCompile(`\[(?:` + strings.Join(names, "|") + `)_[0-9a-f]{8}\]`),
+	}, nil
+}
+
+// Redact returns the text with the values of the patterns replaced with
+// their placeholders.
+func (r *Redactor) Redact(text string) string {
+	for _, p := range r.patterns {
+		text = p.Regexp.ReplaceAllStringFunc(text, func(v string) string {
+			if p.Valid != nil && !p.Valid(v) {
+				return v
+			}
+			return r.placeholderOf(p, v)
+		})
+	}
+	return text
+}
+
+// placeholderOf returns the placeholder of the value of the pattern.
+func (r *Redactor) placeholderOf(p Pattern, v string) string {
+	h := hmac.New(sha256.New, r.key)
+	h.Write([]byte(p.Name + "\x00" + v))
+	return "[" + p.Name + "_" + hex.EncodeToString(h.Sum(nil)[:4]) + "]"
+}
+
+// RequestProcessor returns the request processor redacting the contents of
+// the requests: their text, the arguments of their function calls and the
+// function responses. The instructions aren't redacted. The contents of the
+// session are left untouched.
+func (r *Redactor) RequestProcessor() llmagent.RequestProcessor {
+	return func(ctx agent.InvocationContext, req *model.LLMRequest) error {
+		for i, c := range req.Contents {
+			req.Contents[i] = r.redactContent(c)
+		}
+		return nil
+	}
+}
+
+// redactContent returns the content, or a copy of it if it had values to
+// redact.
+func (r *Redactor) redactContent(c *genai.Content) *genai.Content {
+	if c == nil {
+		return nil
+	}
+	var parts []*genai.Part
+	for i, p := range c.Parts {
+		redacted := r.redactPart(p)
+		if redacted == p {
+			continue
+		}
+		if parts == nil {
+			parts = append([]*genai.Part(nil), c.Parts...)
+		}
+		parts[i] = redacted
+	}
+	if parts == nil {
+		return c
+	}
+	return &genai.Content{Role: c.Role, Parts: parts}
+}
+
+// redactPart returns the part, or a redacted copy of it.
+func (r *Redactor) redactPart(p *genai.Part) *genai.Part {
+	if p == nil {
+		return nil
+	}
+	switch {
+	case p.Text != "":
+		if text := r.Redact(p.Text); text != p.Text {
+			copied := *p
+			copied.Text = text
+			return &copied
+		}
+	case p.FunctionCall != nil:
+		if args, changed := mapStrings(p.FunctionCall.Args, r.Redact); changed {
+			call := *p.FunctionCall
+			call.Args = args
+			copied := *p
+			copied.FunctionCall = &call
+			return &copied
+		}
+	case p.FunctionResponse != nil:
+		if response, changed := mapStrings(p.FunctionResponse.Response, r.Redact); changed {
+			resp := *p.FunctionResponse
+			resp.Response = response
+			copied := *p
+			copied.FunctionResponse = &resp
+			return &copied
+		}
+	}
+	return p
+}
+
+// ResponseProcessor returns the response processor restoring the values of
+// the placeholders in the responses, if Config.Restore is set. The values
+// are looked up in the events of the session and in the user content of the
+// invocation. A placeholder split across partial responses is only restored
+// in the final response.
+func (r *Redactor) ResponseProcessor() llmagent.ResponseProcessor {
+	return func(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
+		if !r.restore || resp.Content == nil {
+			return nil
+		}
+		var values map[string]string
+		restore := func(s string) string {
+			if !r.placeholder.MatchString(s) {
+				return s
+			}
+			if values == nil {
+				values = r.sessionValues(ctx)
+			}
+			return r.placeholder.ReplaceAllStringFunc(s, func(ph string) string {
+				if v, ok := values[ph]; ok {
+					return v
+				}
+				return ph
+			})
+		}
+		for _, p := range resp.Content.Parts {
+			switch {
+			case p.Text != "":
+				p.Text = restore(p.Text)
+			case p.FunctionCall != nil:
+				p.FunctionCall.Args, _ = mapStrings(p.FunctionCall.Args, restore)
+			}
+		}
+		return nil
+	}
+}
+
+// sessionValues returns the values of the patterns found in the session and
+// in the user content, by placeholder.
+func (r *Redactor) sessionValues(ctx agent.InvocationContext) map[string]string {
+	values := make(map[string]string)
+	collect := func(s string) string {
+		for _, p := range r.patterns {
+			for _, v := range p.Regexp.FindAllString(s, -1) {
+				if p.Valid == nil || p.Valid(v) {
+					values[r.placeholderOf(p, v)] = v
+				}
+			}
+		}
+		return s
+	}
+	contents := []*genai.Content{ctx.UserContent()}
+	if s := ctx.Session(); s != nil {
+		for ev := range s.Events().All() {
+			contents = append(contents, ev.Content)
+		}
+	}
+	for _, c := range contents {
+		if c == nil {
+			continue
+		}
+		for _, p := range c.Parts {
+			switch {
+			case p.Text != "":
+				collect(p.Text)
+			case p.FunctionCall != nil:
+				mapStrings(p.FunctionCall.Args, collect)
+			case p.FunctionResponse != nil:
+				mapStrings(p.FunctionResponse.Response, collect)
+			}
+		}
+	}
+	return values
+}
+
+// mapStrings applies f to the strings of the JSON-like map, recursively. It
+// returns a copy of the map if f changed any string, or m otherwise.
+func mapStrings(m map[string]any, f func(string) string) (map[string]any, bool) {
+	var out map[string]any
+	for k, v := range m {
+		mapped, changed := mapValue(v, f)
+		if !changed {
+			continue
+		}
+		if out == nil {
+			out = maps.Clone(m)
+		}
+		out[k] = mapped
+	}
+	if out == nil {
+		return m, false
+	}
+	return out, true
+}
+
+func mapValue(v any, f func(string) string) (any, bool) {
+	switch v := v.(type) {
+	case string:
+		mapped := f(v)
+		return mapped, mapped != v
+	case map[string]any:
+		return mapStrings(v, f)
+	case []any:
+		var out []any
+		for i, e := range v {
+			mapped, changed := mapValue(e, f)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = append([]any(nil), v...)
+			}
+			out[i] = mapped
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	}
+	return v, false
+}
+
+// luhn reports whether the digits of the card number pass the Luhn
+// checksum.
+func luhn(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redaction_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/agent/llmagent/redaction"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/genai"
+)
+
+func TestRedactor(t *testing.T) {
+	r, err := redaction.New(redaction.Config{Restore: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	email := r.Redact("alice@example.com")
+	if !regexp.MustCompile(`^\[EMAIL_[0-9a-f]{8}\]$`).MatchString(email) {
+		t.Fatalf("Redact() = %q, want an email placeholder", email)
+	}
+
+	m := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromText("I sent the receipt to "+email+".", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{
+		Name:               "agent",
+		Model:              m,
+		RequestProcessors:  []llmagent.RequestProcessor{r.RequestProcessor()},
+		ResponseProcessors: []llmagent.ResponseProcessor{r.ResponseProcessor()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := "I'm alice@example.com, my card is 4111 1111 1111 1111, my order is 4111 1111 1111 1112."
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := m.Requests[0].Contents[0].Parts[0].Text
+	if strings.Contains(sent, "alice@example.com") || strings.Contains(sent, "4111 1111 1111 1111") {
+		t.Errorf("text sent to the model = %q, want the email and the card number redacted", sent)
+	}
+	if !strings.Contains(sent, email) || !strings.Contains(sent, "[CREDIT_CARD_") {
+		t.Errorf("text sent to the model = %q, want the placeholders", sent)
+	}
+	if !strings.Contains(sent, "4111 1111 1111 1112") {
+		t.Errorf("text sent to the model = %q, want the number failing the checksum kept", sent)
+	}
+	if got, want := events[len(events)-1].Content.Parts[0].Text, "I sent the receipt to alice@example.com."; got != want {
+		t.Errorf("final response = %q, want %q", got, want)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for _, p := range []redaction.Pattern{
+		{Name: "lower", Regexp: regexp.MustCompile(`x`)},
+		{Name: "NO_REGEXP"},
+	} {
+		if _, err := redaction.New(redaction.Config{Patterns: []redaction.Pattern{p}}); err == nil {
+			t.Errorf("New() with pattern %q succeeded, want an error", p.Name)
+		}
+	}
+}
@@ -35,6 +35,10 @@ func (a *testAgent) Description() string {
 	panic("not implemented")
 }
 
+func (a *testAgent) Capabilities() Capabilities {
+	panic("not implemented")
+}
+
 func (a *testAgent) Run(InvocationContext) iter.Seq2[*session.Event, error] {
 	panic("not implemented")
 }
@@ -16,15 +16,18 @@
 package remoteagent
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"iter"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/a2aproject/a2a-go/a2a"
 	"github.com/a2aproject/a2a-go/a2aclient"
 	"github.com/a2aproject/a2a-go/a2aclient/agentcard"
+	"golang.org/x/sync/singleflight"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/internal/converters"
 	"google.golang.org/adk/server/adka2a"
@@ -38,6 +41,7 @@ type A2AConfig struct {
 
 	// AgentCardSource can be either an http(s) URL or a local file path. If a2a.AgentCard
 	// is not provided, the source is used to resolve the card during the first agent invocation.
+	// The resolved card is reused by the subsequent invocations.
 	AgentCard       *a2a.AgentCard
 	AgentCardSource string
 	// CardResolveOptions can be used to provide a set of agencard.Resolver configurations.
@@ -67,17 +71,18 @@ func NewA2A(cfg A2AConfig) (agent.Agent, error) {
 }
 
 type a2aAgent struct {
+	mu           sync.Mutex
 	resolvedCard *a2a.AgentCard
+	resolving    singleflight.Group
 }
 
 func (a *a2aAgent) run(ctx agent.InvocationContext, cfg A2AConfig) iter.Seq2[*session.Event, error] {
 	return func(yield func(*session.Event, error) bool) {
-		card, err := resolveAgentCard(ctx, cfg)
+		card, err := a.agentCard(ctx, cfg)
 		if err != nil {
 			yield(toErrorEvent(ctx, fmt.Errorf("agent card resolution failed: %w", err)), nil)
 			return
 		}
-		a.resolvedCard = card
 
 		var client *a2aclient.Client
 		if cfg.ClientFactory != nil {
@@ -131,7 +136,40 @@ func (a *a2aAgent) run(ctx agent.InvocationContext, cfg A2AConfig) iter.Seq2[*se
 	}
 }
 
-func resolveAgentCard(ctx agent.InvocationContext, cfg A2AConfig) (*a2a.AgentCard, error) {
+// agentCard returns the card of the remote agent, resolving it on the first
+// call. Failed resolutions are retried by the next invocation.
+//
+// The concurrent invocations share the in-flight resolution, and stop waiting
+// for it when their context is done.
+func (a *a2aAgent) agentCard(ctx agent.InvocationContext, cfg A2AConfig) (*a2a.AgentCard, error) {
+	a.mu.Lock()
+	card := a.resolvedCard
+	a.mu.Unlock()
+	if card != nil {
+		return card, nil
+	}
+	resolved := a.resolving.DoChan("", func() (any, error) {
+		card, err := resolveAgentCard(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		a.mu.Lock()
+		a.resolvedCard = card
+		a.mu.Unlock()
+		return card, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-resolved:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*a2a.AgentCard), nil
+	}
+}
+
+func resolveAgentCard(ctx context.Context, cfg A2AConfig) (*a2a.AgentCard, error) {
 	if cfg.AgentCard != nil {
 		return cfg.AgentCard, nil
 	}
@@ -149,12 +187,12 @@ func resolveAgentCard(ctx agent.InvocationContext, cfg A2AConfig) (*a2a.AgentCar
 		return nil, fmt.Errorf("failed to read agent card from %q: %w", cfg.AgentCardSource, err)
 	}
 
-	var card *a2a.AgentCard
-	if err := json.Unmarshal(fileBytes, card); err != nil {
+	var card a2a.AgentCard
+	if err := json.Unmarshal(fileBytes, &card); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal an agent card: %w", err)
 	}
 
-	return card, nil
+	return &card, nil
 }
 
 func newMessage(ctx agent.InvocationContext) (*a2a.Message, error) {
@@ -195,7 +233,7 @@ func updateCustomMetadata(event *session.Event, request *a2a.MessageSendParams,
 		if v == nil {
 			continue
 		}
-		payload, err := converters.ToMapStructure(request)
+		payload, err := converters.ToMapStructure(v)
 		if err == nil {
 			event.CustomMetadata[adka2a.ToADKMetaKey(k)] = payload
 		} else {
@@ -23,8 +23,12 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/a2aproject/a2a-go/a2a"
 	"github.com/a2aproject/a2a-go/a2aclient"
@@ -503,8 +507,10 @@ func TestRemoteAgent_ResolvesAgentCard(t *testing.T) {
 	executor := newA2AEventReplay(t, remoteEvents)
 	go startA2AServer(t, executor, listener)
 
+	var cardRequests atomic.Int32
 	mux := http.NewServeMux()
 	mux.HandleFunc("/.well-known/agent-card.json", func(w http.ResponseWriter, r *http.Request) {
+		cardRequests.Add(1)
 		card := &a2a.AgentCard{PreferredTransport: a2a.TransportProtocolGRPC, URL: "passthrough:///bufnet", Capabilities: a2a.AgentCapabilities{Streaming: true}}
 		if err := json.NewEncoder(w).Encode(card); err != nil {
 			t.Errorf("json.Encode(agentCard) error = %v", err)
@@ -518,17 +524,129 @@ func TestRemoteAgent_ResolvesAgentCard(t *testing.T) {
 		t.Fatalf("remoteagent.NewA2A() error = %v", err)
 	}
 
+	ignoreFields := []cmp.Option{
+		cmpopts.IgnoreFields(model.LLMResponse{}, "CustomMetadata"),
+	}
+	for range 2 {
+		ictx := newInvocationContext(t, []*session.Event{newUserHello()})
+		gotEvents, err := runAndCollect(ictx, remoteAgent)
+		if err != nil {
+			t.Fatalf("agent.Run() error = %v", err)
+		}
+
+		gotResponses := toLLMResponses(gotEvents)
+		if diff := cmp.Diff(wantResponses, gotResponses, ignoreFields...); diff != "" {
+			t.Fatalf("agent.Run() wrong result (+got,-want):\ngot = %+v\nwant = %+v\ndiff = %s", gotResponses, wantResponses, diff)
+		}
+	}
+	if got := cardRequests.Load(); got != 1 {
+		t.Errorf("agent card was fetched %d times, want 1", got)
+	}
+}
+
+func TestRemoteAgent_ResolvesAgentCardConcurrently(t *testing.T) {
+	remoteEvents := []a2a.Event{a2a.NewMessage(a2a.MessageRoleAgent, a2a.TextPart{Text: "Hello!"})}
+	wantResponses := []model.LLMResponse{{Content: genai.NewContentFromText("Hello!", genai.RoleModel)}}
+
+	listener := bufconn.Listen(connBufSize)
+	executor := newA2AEventReplay(t, remoteEvents)
+	go startA2AServer(t, executor, listener)
+
+	var cardRequests atomic.Int32
+	requested, release := make(chan struct{}), make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/agent-card.json", func(w http.ResponseWriter, r *http.Request) {
+		if cardRequests.Add(1) == 1 {
+			close(requested)
+		}
+		<-release
+		card := &a2a.AgentCard{PreferredTransport: a2a.TransportProtocolGRPC, URL: "passthrough:///bufnet", Capabilities: a2a.AgentCapabilities{Streaming: true}}
+		if err := json.NewEncoder(w).Encode(card); err != nil {
+			t.Errorf("json.Encode(agentCard) error = %v", err)
+		}
+	})
+	cardServer := httptest.NewServer(mux)
+	t.Cleanup(cardServer.Close)
+
+	remoteAgent, err := NewA2A(A2AConfig{Name: "a2a", AgentCardSource: cardServer.URL, ClientFactory: newTestClientFactory(listener)})
+	if err != nil {
+		t.Fatalf("remoteagent.NewA2A() error = %v", err)
+	}
+
+	type result struct {
+		events []*session.Event
+		err    error
+	}
+	first := make(chan result, 1)
+	go func() {
+		events, err := runAndCollect(newInvocationContext(t, []*session.Event{newUserHello()}), remoteAgent)
+		first <- result{events, err}
+	}()
+	<-requested
+
+	// An invocation cancelled while the card is being fetched doesn't wait
+	// for the fetch.
+	ctx, cancel := context.WithCancel(t.Context())
+	cancelled := make(chan result, 1)
+	go func() {
+		ic := icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{Session: newInvocationContext(t, nil).Session()})
+		events, err := runAndCollect(ic, remoteAgent)
+		cancelled <- result{events, err}
+	}()
+	cancel()
+	select {
+	case got := <-cancelled:
+		if got.err != nil || len(got.events) != 1 || got.events[0].ErrorMessage == "" {
+			t.Errorf("cancelled agent.Run() = %+v, %v, want an error event", got.events, got.err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Error("cancelled agent.Run() waited for the agent card fetch")
+	}
+	close(release)
+
+	got := <-first
+	if got.err != nil {
+		t.Fatalf("agent.Run() error = %v", got.err)
+	}
+	if diff := cmp.Diff(wantResponses, toLLMResponses(got.events), cmpopts.IgnoreFields(model.LLMResponse{}, "CustomMetadata")); diff != "" {
+		t.Errorf("agent.Run() wrong result (+got,-want):\n%s", diff)
+	}
+	if got := cardRequests.Load(); got != 1 {
+		t.Errorf("agent card was fetched %d times, want 1", got)
+	}
+}
+
+func TestRemoteAgent_ResolvesAgentCardFromFile(t *testing.T) {
+	remoteEvents := []a2a.Event{a2a.NewMessage(a2a.MessageRoleAgent, a2a.TextPart{Text: "Hello!"})}
+	wantResponses := []model.LLMResponse{{Content: genai.NewContentFromText("Hello!", genai.RoleModel)}}
+
+	listener := bufconn.Listen(connBufSize)
+	executor := newA2AEventReplay(t, remoteEvents)
+	go startA2AServer(t, executor, listener)
+
+	card := &a2a.AgentCard{PreferredTransport: a2a.TransportProtocolGRPC, URL: "passthrough:///bufnet", Capabilities: a2a.AgentCapabilities{Streaming: true}}
+	cardBytes, err := json.Marshal(card)
+	if err != nil {
+		t.Fatalf("json.Marshal(agentCard) error = %v", err)
+	}
+	cardPath := filepath.Join(t.TempDir(), "agent-card.json")
+	if err := os.WriteFile(cardPath, cardBytes, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	remoteAgent, err := NewA2A(A2AConfig{Name: "a2a", AgentCardSource: cardPath, ClientFactory: newTestClientFactory(listener)})
+	if err != nil {
+		t.Fatalf("remoteagent.NewA2A() error = %v", err)
+	}
+
 	ictx := newInvocationContext(t, []*session.Event{newUserHello()})
 	gotEvents, err := runAndCollect(ictx, remoteAgent)
 	if err != nil {
 		t.Fatalf("agent.Run() error = %v", err)
 	}
 
-	ignoreFields := []cmp.Option{
-		cmpopts.IgnoreFields(model.LLMResponse{}, "CustomMetadata"),
-	}
 	gotResponses := toLLMResponses(gotEvents)
-	if diff := cmp.Diff(wantResponses, gotResponses, ignoreFields...); diff != "" {
+	if diff := cmp.Diff(wantResponses, gotResponses, cmpopts.IgnoreFields(model.LLMResponse{}, "CustomMetadata")); diff != "" {
 		t.Fatalf("agent.Run() wrong result (+got,-want):\ngot = %+v\nwant = %+v\ndiff = %s", gotResponses, wantResponses, diff)
 	}
 }
@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remoteagent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/a2aproject/a2a-go/a2a"
+	"google.golang.org/adk/agent"
+)
+
+// ImportA2A resolves the agent card of the remote agent described by cfg and
+// creates the agent from it, e.g. to compose agents of other frameworks
+// served with the A2A protocol. The name and the description of cfg, when
+// not set, are derived from the card: the description includes the skills
+// of the remote agent, so that the LLM agents know when to transfer to it.
+func ImportA2A(ctx context.Context, cfg A2AConfig) (agent.Agent, error) {
+	if cfg.AgentCard == nil && cfg.AgentCardSource == "" {
+		return nil, fmt.Errorf("either AgentCard or AgentCardSource must be provided")
+	}
+	card, err := resolveAgentCard(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("agent card resolution failed: %w", err)
+	}
+	cfg.AgentCard = card
+	if cfg.Name == "" {
+		if cfg.Name = agentName(card.Name); cfg.Name == "" {
+			return nil, fmt.Errorf("agent card has no usable name %q, set A2AConfig.Name", card.Name)
+		}
+	}
+	if cfg.Description == "" {
+		cfg.Description = describe(card)
+	}
+	return NewA2A(cfg)
+}
+
+// agentName converts the name of an agent card to an identifier, e.g.
+// "Currency Converter" to "currency_converter".
+func agentName(name string) string {
+	var sb strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if underscore && sb.Len() > 0 {
+				sb.WriteByte('_')
+			}
+			underscore = false
+			sb.WriteRune(r)
+			continue
+		}
+		underscore = true
+	}
+	id := sb.String()
+	if id != "" && unicode.IsDigit(rune(id[0])) {
+		id = "agent_" + id
+	}
+	return id
+}
+
+// describe returns the description of the agent of the card, listing its
+// skills.
+func describe(card *a2a.AgentCard) string {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSpace(card.Description))
+	if len(card.Skills) == 0 {
+		return sb.String()
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("Skills:")
+	for _, skill := range card.Skills {
+		name := skill.Name
+		if name == "" {
+			name = skill.ID
+		}
+		fmt.Fprintf(&sb, "\n- %s", name)
+		if d := strings.TrimSpace(skill.Description); d != "" {
+			fmt.Fprintf(&sb, ": %s", d)
+		}
+		if len(skill.Examples) > 0 {
+			examples := make([]string, len(skill.Examples))
+			for i, e := range skill.Examples {
+				examples[i] = fmt.Sprintf("%q", e)
+			}
+			fmt.Fprintf(&sb, " (e.g. %s)", strings.Join(examples, ", "))
+		}
+	}
+	return sb.String()
+}
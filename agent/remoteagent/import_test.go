@@ -0,0 +1,84 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remoteagent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/a2aproject/a2a-go/a2a"
+)
+
+func TestImportA2A(t *testing.T) {
+	card := &a2a.AgentCard{
+		Name:        "Currency Converter (v2)",
+		Description: "Converts amounts between currencies.",
+		URL:         "passthrough:///bufnet",
+		Skills: []a2a.AgentSkill{
+			{ID: "convert", Name: "Convert", Description: "Converts an amount.", Examples: []string{"100 USD to EUR"}},
+			{ID: "rates"},
+		},
+	}
+	cardBytes, err := json.Marshal(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cardPath := filepath.Join(t.TempDir(), "agent-card.json")
+	if err := os.WriteFile(cardPath, cardBytes, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name            string
+		cfg             A2AConfig
+		wantName        string
+		wantDescription string
+	}{
+		{
+			name:     "derived from the card",
+			cfg:      A2AConfig{AgentCardSource: cardPath},
+			wantName: "currency_converter_v2",
+			wantDescription: "Converts amounts between currencies.\n" +
+				"Skills:\n" +
+				"- Convert: Converts an amount. (e.g. \"100 USD to EUR\")\n" +
+				"- rates",
+		},
+		{
+			name:            "set",
+			cfg:             A2AConfig{Name: "fx", Description: "Foreign exchange.", AgentCard: card},
+			wantName:        "fx",
+			wantDescription: "Foreign exchange.",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := ImportA2A(t.Context(), tc.cfg)
+			if err != nil {
+				t.Fatalf("ImportA2A() error = %v", err)
+			}
+			if a.Name() != tc.wantName {
+				t.Errorf("Name() = %q, want %q", a.Name(), tc.wantName)
+			}
+			if a.Description() != tc.wantDescription {
+				t.Errorf("Description() = %q, want %q", a.Description(), tc.wantDescription)
+			}
+		})
+	}
+
+	if _, err := ImportA2A(t.Context(), A2AConfig{AgentCard: &a2a.AgentCard{Name: "***"}}); err == nil {
+		t.Error("ImportA2A() succeeded for a card without usable name, want an error")
+	}
+}
@@ -14,6 +14,11 @@
 
 package agent
 
+import (
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
 // StreamingMode defines the streaming mode for agent execution.
 type StreamingMode string
 
@@ -32,4 +37,100 @@ type RunConfig struct {
 	// If true, ADK runner will save each part of the user input that is a blob
 	// (e.g., images, files) as an artifact.
 	SaveInputBlobsAsArtifacts bool
+	// InputAudioTranscription enables the transcription of the user audio
+	// input in live (bidi) mode. The transcriptions are set in
+	// session.Event.InputTranscription.
+	// Optional.
+	InputAudioTranscription *genai.AudioTranscriptionConfig
+	// OutputAudioTranscription enables the transcription of the model audio
+	// output in live (bidi) mode. The transcriptions are set in
+	// session.Event.OutputTranscription.
+	// Optional.
+	OutputAudioTranscription *genai.AudioTranscriptionConfig
+	// RealtimeInputConfig configures the handling of the user audio input in
+	// live (bidi) mode: the automatic (server-side) voice activity detection
+	// and its sensitivity, and whether the user activity interrupts the model
+	// (barge-in).
+	// Optional: if nil, the defaults of the model apply.
+	RealtimeInputConfig *genai.RealtimeInputConfig
+	// Proactivity configures the proactivity of the model in live (bidi)
+	// mode, e.g. to let the model stay silent when the user speech isn't
+	// addressed to it.
+	// Optional.
+	Proactivity *genai.ProactivityConfig
+	// SupportCFC enables the compositional function calling of live models:
+	// the model may write code which calls the tools. It enables the code
+	// execution tool in the config of the live connection of the requests
+	// (model.LLMRequest.LiveConnectConfig), not in the generate content
+	// config, and requires a live model.
+	SupportCFC bool
+	// EventFilter selects the events yielded by the runner. The events which
+	// are filtered out are still appended to the session, and errors are
+	// always yielded.
+	// Optional: if nil, all events are yielded.
+	EventFilter EventFilter
+	// MaxTokensPerInvocation is the token budget of the invocation, counted
+	// over the model calls of all its agents, see InvocationContext.Tokens.
+	// Before each model call, the prompt is estimated; if the call would
+	// exceed the budget, the invocation ends with an event with the error
+	// code "TOKEN_BUDGET_EXCEEDED", followed by a
+	// [*TokenBudgetExceededError].
+	// Optional: if zero, the tokens are not limited.
+	MaxTokensPerInvocation int
+	// MaxIterations is the maximum number of model calls of an LLM agent in
+	// one run, e.g. to stop a model which keeps calling tools. The run of
+	// an agent which reaches it ends with an error wrapping
+	// [ErrMaxIterations].
+	// Optional: if zero, the model calls are not limited.
+	MaxIterations int
+	// IncludeThoughts makes the runner yield the thought parts of the model
+	// responses (see genai.Part.Thought), e.g. to display the reasoning of
+	// the model. By default, the thought parts are removed from the yielded
+	// events and the thought-only events (see model.LLMResponse.Thought) are
+	// not yielded; the events appended to the session are not changed.
+	IncludeThoughts bool
+}
+
+// EventFilter reports whether the event should be yielded to the caller of
+// the runner.
+type EventFilter func(*session.Event) bool
+
+// FinalResponsesOnly is an [EventFilter] which yields only the final
+// responses of the agents.
+func FinalResponsesOnly(e *session.Event) bool {
+	return e.IsFinalResponse()
+}
+
+// NoPartials is an [EventFilter] which drops the partial events streamed
+// in [StreamingModeSSE].
+func NoPartials(e *session.Event) bool {
+	return !e.Partial
+}
+
+// NoToolEvents is an [EventFilter] which drops the function call and function
+// response events. Long-running function calls are kept, as the caller is
+// expected to respond to them.
+func NoToolEvents(e *session.Event) bool {
+	if len(e.LongRunningToolIDs) > 0 || e.Content == nil {
+		return true
+	}
+	for _, p := range e.Content.Parts {
+		if p != nil && (p.FunctionCall != nil || p.FunctionResponse != nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllOf returns an [EventFilter] which yields the events accepted by all the
+// filters.
+func AllOf(filters ...EventFilter) EventFilter {
+	return func(e *session.Event) bool {
+		for _, f := range filters {
+			if !f(e) {
+				return false
+			}
+		}
+		return true
+	}
 }
@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"fmt"
+	"sync"
+)
+
+// TokenUsage is the number of tokens used by model calls.
+type TokenUsage struct {
+	PromptTokens     int
+	CandidatesTokens int
+	TotalTokens      int
+}
+
+// TokenCounter counts the tokens used by the model calls of an invocation,
+// including the calls of the agents it transfers to or calls as tools. The
+// usage reported by the model is counted; when the model doesn't report it,
+// the usage is estimated. It is safe for concurrent use.
+type TokenCounter struct {
+	mu    sync.Mutex
+	usage TokenUsage
+}
+
+// Add adds the usage of a model call.
+func (c *TokenCounter) Add(u TokenUsage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.usage.PromptTokens += u.PromptTokens
+	c.usage.CandidatesTokens += u.CandidatesTokens
+	c.usage.TotalTokens += u.TotalTokens
+}
+
+// Usage returns the tokens used so far.
+func (c *TokenCounter) Usage() TokenUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.usage
+}
+
+// TokenBudgetExceededCode is the error code of the event which ends an
+// invocation which ran out of its token budget.
+const TokenBudgetExceededCode = "TOKEN_BUDGET_EXCEEDED"
+
+// TokenBudgetExceededError is returned when an invocation runs out of its
+// token budget, see RunConfig.MaxTokensPerInvocation.
+type TokenBudgetExceededError struct {
+	// Agent is the agent which was about to call the model.
+	Agent string
+	// Budget is the maximum number of tokens of the invocation.
+	Budget int
+	// Used is the number of tokens used, including the estimated prompt of
+	// the model call which would exceed the budget.
+	Used int
+}
+
+func (e *TokenBudgetExceededError) Error() string {
+	return fmt.Sprintf("agent %q exceeded the token budget of the invocation: %d tokens used, budget is %d", e.Agent, e.Used, e.Budget)
+}
@@ -30,6 +30,21 @@ import (
 type Config struct {
 	// Basic agent setup.
 	AgentConfig agent.Config
+
+	// MaxConcurrency is the maximum number of sub-agents running at once,
+	// e.g. to bound the rate-limit pressure of a wide fan-out. The other
+	// sub-agents are queued and start, in order, as the running ones finish.
+	// Optional: if zero, all the sub-agents run at once.
+	MaxConcurrency int
+	// MaxTokens is the token budget shared by all the sub-agents of a run,
+	// rather than by each branch. It is counted and enforced like
+	// agent.RunConfig.MaxTokensPerInvocation: the sub-agent whose model
+	// call would exceed it ends with an *agent.TokenBudgetExceededError,
+	// which stops the other sub-agents. The budget of the invocation, if
+	// any, still applies.
+	// Optional: if zero, the tokens are only limited by the budget of the
+	// invocation.
+	MaxTokens int
 }
 
 // New creates a ParallelAgent.
@@ -44,8 +59,16 @@ func New(cfg Config) (agent.Agent, error) {
 	if cfg.AgentConfig.Run != nil {
 		return nil, fmt.Errorf("ParallelAgent doesn't allow custom Run implementations")
 	}
+	if cfg.MaxConcurrency < 0 {
+		return nil, fmt.Errorf("max concurrency must be >= 0, got %d", cfg.MaxConcurrency)
+	}
+	if cfg.MaxTokens < 0 {
+		return nil, fmt.Errorf("max tokens must be >= 0, got %d", cfg.MaxTokens)
+	}
 
-	cfg.AgentConfig.Run = run
+	cfg.AgentConfig.Run = func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+		return run(ctx, cfg)
+	}
 
 	parallelAgent, err := agent.New(cfg.AgentConfig)
 	if err != nil {
@@ -63,7 +86,7 @@ func New(cfg Config) (agent.Agent, error) {
 	return parallelAgent, nil
 }
 
-func run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+func run(ctx agent.InvocationContext, cfg Config) iter.Seq2[*session.Event, error] {
 	curAgent := ctx.Agent()
 
 	var (
@@ -71,33 +94,47 @@ func run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 		doneChan              = make(chan bool)
 		resultsChan           = make(chan result)
 	)
-
-	for _, sa := range ctx.Agent().SubAgents() {
-		branch := fmt.Sprintf("%s.%s", curAgent.Name(), sa.Name())
-		if ctx.Branch() != "" {
-			branch = fmt.Sprintf("%s.%s", ctx.Branch(), branch)
-		}
-		subAgent := sa
-		errGroup.Go(func() error {
-			subCtx := icontext.NewInvocationContext(errGroupCtx, icontext.InvocationContextParams{
-				Artifacts:   ctx.Artifacts(),
-				Memory:      ctx.Memory(),
-				Session:     ctx.Session(),
-				Branch:      branch,
-				Agent:       subAgent,
-				UserContent: ctx.UserContent(),
-				RunConfig:   ctx.RunConfig(),
-			})
-
-			if err := runSubAgent(subCtx, subAgent, resultsChan, doneChan); err != nil {
-				return fmt.Errorf("failed to run sub-agent %q: %w", subAgent.Name(), err)
-			}
-
-			return nil
-		})
+	if cfg.MaxConcurrency > 0 {
+		errGroup.SetLimit(cfg.MaxConcurrency)
 	}
+	runConfig := sharedBudget(ctx, cfg.MaxTokens)
 
+	// The sub-agents are started in the background, since starting the
+	// queued ones blocks until the running ones finish.
 	go func() {
+		for _, sa := range ctx.Agent().SubAgents() {
+			branch := fmt.Sprintf("%s.%s", curAgent.Name(), sa.Name())
+			if ctx.Branch() != "" {
+				branch = fmt.Sprintf("%s.%s", ctx.Branch(), branch)
+			}
+			subAgent := sa
+			errGroup.Go(func() error {
+				// A queued sub-agent isn't started once the run stopped.
+				select {
+				case <-doneChan:
+					return nil
+				case <-errGroupCtx.Done():
+					return nil
+				default:
+				}
+
+				subCtx := icontext.NewInvocationContext(errGroupCtx, icontext.InvocationContextParams{
+					Artifacts:   ctx.Artifacts(),
+					Memory:      ctx.Memory(),
+					Session:     ctx.Session(),
+					Branch:      branch,
+					Agent:       subAgent,
+					UserContent: ctx.UserContent(),
+					RunConfig:   runConfig,
+				})
+
+				if err := runSubAgent(subCtx, subAgent, resultsChan, doneChan); err != nil {
+					return fmt.Errorf("failed to run sub-agent %q: %w", subAgent.Name(), err)
+				}
+
+				return nil
+			})
+		}
 		_ = errGroup.Wait() // this error is already sent to the user via iterator
 		close(resultsChan)
 	}()
@@ -138,6 +175,25 @@ func runSubAgent(ctx agent.InvocationContext, agent agent.Agent, results chan<-
 	return nil
 }
 
+// sharedBudget returns the run config of the sub-agents, whose token budget
+// is the tokens of the invocation used so far plus maxTokens, so that the
+// sub-agents share maxTokens through the token counter of the invocation.
+func sharedBudget(ctx agent.InvocationContext, maxTokens int) *agent.RunConfig {
+	cfg := ctx.RunConfig()
+	if maxTokens <= 0 || ctx.Tokens() == nil {
+		return cfg
+	}
+	var shared agent.RunConfig
+	if cfg != nil {
+		shared = *cfg
+	}
+	budget := ctx.Tokens().Usage().TotalTokens + maxTokens
+	if shared.MaxTokensPerInvocation <= 0 || budget < shared.MaxTokensPerInvocation {
+		shared.MaxTokensPerInvocation = budget
+	}
+	return &shared
+}
+
 type result struct {
 	event *session.Event
 	err   error
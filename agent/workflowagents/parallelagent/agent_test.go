@@ -16,17 +16,22 @@ package parallelagent_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"iter"
 	"math/rand/v2"
 	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
 	"google.golang.org/adk/agent/workflowagents/loopagent"
 	"google.golang.org/adk/agent/workflowagents/parallelagent"
+	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
@@ -224,3 +229,83 @@ func customRun(id int, agentErr error) func(agent.InvocationContext) iter.Seq2[*
 		}
 	}
 }
+
+func TestParallelAgent_WorkerPool(t *testing.T) {
+	var running, maxRunning, runs atomic.Int32
+	var subAgents []agent.Agent
+	for i := range 6 {
+		subAgents = append(subAgents, must(agent.New(agent.Config{
+			Name: fmt.Sprintf("sub%d", i),
+			Run: func(agent.InvocationContext) iter.Seq2[*session.Event, error] {
+				return func(yield func(*session.Event, error) bool) {
+					n := running.Add(1)
+					defer running.Add(-1)
+					for {
+						m := maxRunning.Load()
+						if n <= m || maxRunning.CompareAndSwap(m, n) {
+							break
+						}
+					}
+					runs.Add(1)
+					time.Sleep(5 * time.Millisecond)
+					yield(&session.Event{LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("done", genai.RoleModel)}}, nil)
+				}
+			},
+		})))
+	}
+	a, err := parallelagent.New(parallelagent.Config{
+		AgentConfig:    agent.Config{Name: "pool", SubAgents: subAgents},
+		MaxConcurrency: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := runAgent(t, a)
+	if len(events) != 6 || runs.Load() != 6 {
+		t.Errorf("got %d events from %d runs, want 6 from 6", len(events), runs.Load())
+	}
+	if got := maxRunning.Load(); got > 2 {
+		t.Errorf("%d sub-agents ran at once, want at most 2", got)
+	}
+}
+
+func TestParallelAgent_SharedTokenBudget(t *testing.T) {
+	var subAgents []agent.Agent
+	for i := range 3 {
+		subAgents = append(subAgents, must(llmagent.New(llmagent.Config{
+			Name:        fmt.Sprintf("sub%d", i),
+			Model:       &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("done", genai.RoleModel)}},
+			Instruction: strings.Repeat("Research the topic thoroughly. ", 20),
+		})))
+	}
+	a, err := parallelagent.New(parallelagent.Config{
+		AgentConfig: agent.Config{Name: "fanout", SubAgents: subAgents},
+		MaxTokens:   10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, runErr := range testutil.NewTestAgentRunner(t, a).Run(t, "session_id", "go") {
+		if runErr != nil && err == nil {
+			err = runErr
+		}
+	}
+	var budgetErr *agent.TokenBudgetExceededError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("run error = %v, want a TokenBudgetExceededError", err)
+	}
+	if budgetErr.Budget != 10 {
+		t.Errorf("budget = %d, want 10", budgetErr.Budget)
+	}
+}
+
+func runAgent(t *testing.T, a agent.Agent) []*session.Event {
+	t.Helper()
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session_id", "go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
@@ -12,12 +12,22 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package tests
+// Package artifacttest provides a conformance test suite for the
+// implementations of [artifact.Service], so that the services backed by
+// other storages can check that they behave like the services of this module.
+//
+// Run the suite from a test of the implementation:
+//
+//	func TestConformance(t *testing.T) {
+//		artifacttest.Run(t, func(t *testing.T) artifact.Service {
+//			return newTestService(t)
+//		})
+//	}
+package artifacttest
 
 import (
 	"context"
 	"errors"
-	"fmt"
 	"io/fs"
 	"slices"
 	"testing"
@@ -27,37 +37,26 @@ import (
 	"google.golang.org/genai"
 )
 
-func TestArtifactService(t *testing.T, name string, factory func(t *testing.T) (artifact.Service, error)) {
-	t.Run(fmt.Sprintf("Test%sArtifactService", name), func(t *testing.T) {
-		ctx := t.Context()
-		// Create the service using the factory for this sub-test
-		srv, err := factory(t)
-		if err != nil {
-			t.Fatalf("Failed to set up service: %v", err)
-		}
-		testArtifactService(ctx, t, srv, name)
-	})
-	t.Run(fmt.Sprintf("Test%sArtifactService_Empty", name), func(t *testing.T) {
-		ctx := t.Context()
-		// Create the service using the factory for this sub-test
-		srv, err := factory(t)
-		if err != nil {
-			t.Fatalf("Failed to set up service: %v", err)
-		}
-		testArtifactService_Empty(ctx, t, srv, name)
-	})
-	t.Run(fmt.Sprintf("Test%sArtifactService_UserScoped", name), func(t *testing.T) {
-		ctx := t.Context()
-		// Create the service using the factory for this sub-test
-		srv, err := factory(t)
-		if err != nil {
-			t.Fatalf("Failed to set up service: %v", err)
-		}
-		testArtifactService_UserScoped(ctx, t, srv, name)
-	})
+// Run runs the conformance suite against the services created by
+// newService, one per subtest.
+func Run(t *testing.T, newService func(t *testing.T) artifact.Service) {
+	for _, tc := range []struct {
+		name string
+		test func(ctx context.Context, t *testing.T, srv artifact.Service)
+	}{
+		{"Basic", testBasic},
+		{"Empty", testEmpty},
+		{"UserScoped", testUserScoped},
+		{"Isolation", testIsolation},
+		{"SaveAfterDelete", testSaveAfterDelete},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t.Context(), t, newService(t))
+		})
+	}
 }
 
-func testArtifactService(ctx context.Context, t *testing.T, srv artifact.Service, testSuffix string) {
+func testBasic(ctx context.Context, t *testing.T, srv artifact.Service) {
 	appName := "testapp"
 	userID := "testuser"
 	sessionID := "testsession"
@@ -91,7 +90,7 @@ func testArtifactService(ctx context.Context, t *testing.T, srv artifact.Service
 		}
 	}
 
-	t.Run(fmt.Sprintf("Load_%s", testSuffix), func(t *testing.T) {
+	t.Run("Load", func(t *testing.T) {
 		fileName := "file1"
 		for _, tc := range []struct {
 			name    string
@@ -112,7 +111,7 @@ func testArtifactService(ctx context.Context, t *testing.T, srv artifact.Service
 		}
 	})
 
-	t.Run(fmt.Sprintf("List_%s", testSuffix), func(t *testing.T) {
+	t.Run("List", func(t *testing.T) {
 		resp, err := srv.List(ctx, &artifact.ListRequest{
 			AppName: appName, UserID: userID, SessionID: sessionID,
 		})
@@ -127,7 +126,7 @@ func testArtifactService(ctx context.Context, t *testing.T, srv artifact.Service
 		}
 	})
 
-	t.Run(fmt.Sprintf("Versions_%s", testSuffix), func(t *testing.T) {
+	t.Run("Versions", func(t *testing.T) {
 		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{
 			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "file1",
 		})
@@ -150,7 +149,7 @@ func testArtifactService(ctx context.Context, t *testing.T, srv artifact.Service
 		t.Fatalf("Delete(file1@v3) failed: %v", err)
 	}
 
-	t.Run(fmt.Sprintf("LoadAfterDeleteVersion3_%s", testSuffix), func(t *testing.T) {
+	t.Run("LoadAfterDeleteVersion3", func(t *testing.T) {
 		resp, err := srv.Load(ctx, &artifact.LoadRequest{
 			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "file1",
 		})
@@ -170,7 +169,7 @@ func testArtifactService(ctx context.Context, t *testing.T, srv artifact.Service
 		t.Fatalf("Delete(file1) failed: %v", err)
 	}
 
-	t.Run(fmt.Sprintf("LoadAfterDelete_%s", testSuffix), func(t *testing.T) {
+	t.Run("LoadAfterDelete", func(t *testing.T) {
 		got, err := srv.Load(ctx, &artifact.LoadRequest{
 			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "file1",
 		})
@@ -179,7 +178,7 @@ func testArtifactService(ctx context.Context, t *testing.T, srv artifact.Service
 		}
 	})
 
-	t.Run(fmt.Sprintf("ListAfterDelete_%s", testSuffix), func(t *testing.T) {
+	t.Run("ListAfterDelete", func(t *testing.T) {
 		resp, err := srv.List(ctx, &artifact.ListRequest{
 			AppName: appName, UserID: userID, SessionID: sessionID,
 		})
@@ -194,7 +193,7 @@ func testArtifactService(ctx context.Context, t *testing.T, srv artifact.Service
 		}
 	})
 
-	t.Run(fmt.Sprintf("VersionsAfterDelete_%s", testSuffix), func(t *testing.T) {
+	t.Run("VersionsAfterDelete", func(t *testing.T) {
 		got, err := srv.Versions(ctx, &artifact.VersionsRequest{
 			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "file1",
 		})
@@ -216,7 +215,7 @@ func testArtifactService(ctx context.Context, t *testing.T, srv artifact.Service
 	}
 }
 
-func testArtifactService_UserScoped(ctx context.Context, t *testing.T, srv artifact.Service, testSuffix string) {
+func testUserScoped(ctx context.Context, t *testing.T, srv artifact.Service) {
 	appName := "testapp"
 	userID := "testuser"
 	sessionID := "testsession"
@@ -250,7 +249,7 @@ func testArtifactService_UserScoped(ctx context.Context, t *testing.T, srv artif
 		}
 	}
 
-	t.Run(fmt.Sprintf("Load_%s", testSuffix), func(t *testing.T) {
+	t.Run("Load", func(t *testing.T) {
 		fileName := "user:file1"
 		for _, tc := range []struct {
 			name    string
@@ -271,7 +270,7 @@ func testArtifactService_UserScoped(ctx context.Context, t *testing.T, srv artif
 		}
 	})
 
-	t.Run(fmt.Sprintf("List_%s", testSuffix), func(t *testing.T) {
+	t.Run("List", func(t *testing.T) {
 		resp, err := srv.List(ctx, &artifact.ListRequest{
 			AppName: appName, UserID: userID, SessionID: sessionID,
 		})
@@ -285,7 +284,7 @@ func testArtifactService_UserScoped(ctx context.Context, t *testing.T, srv artif
 		}
 	})
 
-	t.Run(fmt.Sprintf("Versions_%s", testSuffix), func(t *testing.T) {
+	t.Run("Versions", func(t *testing.T) {
 		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{
 			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "user:file1",
 		})
@@ -308,7 +307,7 @@ func testArtifactService_UserScoped(ctx context.Context, t *testing.T, srv artif
 		t.Fatalf("Delete(user:file1@v3) failed: %v", err)
 	}
 
-	t.Run(fmt.Sprintf("LoadAfterDeleteVersion3_%s", testSuffix), func(t *testing.T) {
+	t.Run("LoadAfterDeleteVersion3", func(t *testing.T) {
 		resp, err := srv.Load(ctx, &artifact.LoadRequest{
 			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "user:file1",
 		})
@@ -328,7 +327,7 @@ func testArtifactService_UserScoped(ctx context.Context, t *testing.T, srv artif
 		t.Fatalf("Delete(user:file1) failed: %v", err)
 	}
 
-	t.Run(fmt.Sprintf("LoadAfterDelete_%s", testSuffix), func(t *testing.T) {
+	t.Run("LoadAfterDelete", func(t *testing.T) {
 		got, err := srv.Load(ctx, &artifact.LoadRequest{
 			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "user:file1",
 		})
@@ -337,7 +336,7 @@ func testArtifactService_UserScoped(ctx context.Context, t *testing.T, srv artif
 		}
 	})
 
-	t.Run(fmt.Sprintf("ListAfterDelete_%s", testSuffix), func(t *testing.T) {
+	t.Run("ListAfterDelete", func(t *testing.T) {
 		resp, err := srv.List(ctx, &artifact.ListRequest{
 			AppName: appName, UserID: userID, SessionID: sessionID,
 		})
@@ -352,7 +351,7 @@ func testArtifactService_UserScoped(ctx context.Context, t *testing.T, srv artif
 		}
 	})
 
-	t.Run(fmt.Sprintf("VersionsAfterDelete_%s", testSuffix), func(t *testing.T) {
+	t.Run("VersionsAfterDelete", func(t *testing.T) {
 		got, err := srv.Versions(ctx, &artifact.VersionsRequest{
 			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "user:file1",
 		})
@@ -374,29 +373,29 @@ func testArtifactService_UserScoped(ctx context.Context, t *testing.T, srv artif
 	}
 }
 
-func testArtifactService_Empty(ctx context.Context, t *testing.T, srv artifact.Service, testSuffix string) {
-	t.Run(fmt.Sprintf("Load_%s", testSuffix), func(t *testing.T) {
+func testEmpty(ctx context.Context, t *testing.T, srv artifact.Service) {
+	t.Run("Load", func(t *testing.T) {
 		got, err := srv.Load(ctx, &artifact.LoadRequest{
 			AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
 		if !errors.Is(err, fs.ErrNotExist) {
 			t.Fatalf("List() = (%v, %v), want error(%v)", got, err, fs.ErrNotExist)
 		}
 	})
-	t.Run(fmt.Sprintf("List_%s", testSuffix), func(t *testing.T) {
+	t.Run("List", func(t *testing.T) {
 		_, err := srv.List(ctx, &artifact.ListRequest{
 			AppName: "app", UserID: "user", SessionID: "session"})
 		if err != nil {
 			t.Fatalf("List() failed: %v", err)
 		}
 	})
-	t.Run(fmt.Sprintf("Delete_%s", testSuffix), func(t *testing.T) {
+	t.Run("Delete", func(t *testing.T) {
 		err := srv.Delete(ctx, &artifact.DeleteRequest{
 			AppName: "app", UserID: "user", SessionID: "session", FileName: "file1"})
 		if err != nil {
 			t.Fatalf("Delete() failed: %v", err)
 		}
 	})
-	t.Run(fmt.Sprintf("Versions_%s", testSuffix), func(t *testing.T) {
+	t.Run("Versions", func(t *testing.T) {
 		got, err := srv.Versions(ctx, &artifact.VersionsRequest{
 			AppName: "app", UserID: "user", SessionID: "session", FileName: "file1"})
 		if !errors.Is(err, fs.ErrNotExist) {
@@ -404,3 +403,82 @@ func testArtifactService_Empty(ctx context.Context, t *testing.T, srv artifact.S
 		}
 	})
 }
+
+func testIsolation(ctx context.Context, t *testing.T, srv artifact.Service) {
+	for _, r := range []*artifact.SaveRequest{
+		{AppName: "app", UserID: "user1", SessionID: "session1", FileName: "file", Part: genai.NewPartFromBytes([]byte("user1 session1"), "text/plain")},
+		{AppName: "app", UserID: "user1", SessionID: "session2", FileName: "file", Part: genai.NewPartFromBytes([]byte("user1 session2"), "text/plain")},
+		{AppName: "app", UserID: "user2", SessionID: "session1", FileName: "file", Part: genai.NewPartFromBytes([]byte("user2 session1"), "text/plain")},
+		{AppName: "other", UserID: "user1", SessionID: "session1", FileName: "file", Part: genai.NewPartFromBytes([]byte("other user1 session1"), "text/plain")},
+	} {
+		got, err := srv.Save(ctx, r)
+		if err != nil || got.Version != 1 {
+			t.Fatalf("Save(%s/%s/%s) = (%v, %v), want (1, nil)", r.AppName, r.UserID, r.SessionID, got, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		appName, userID, sessionID string
+		want                       string
+	}{
+		{"app", "user1", "session1", "user1 session1"},
+		{"app", "user1", "session2", "user1 session2"},
+		{"app", "user2", "session1", "user2 session1"},
+		{"other", "user1", "session1", "other user1 session1"},
+	} {
+		got, err := srv.Load(ctx, &artifact.LoadRequest{
+			AppName: tc.appName, UserID: tc.userID, SessionID: tc.sessionID, FileName: "file",
+		})
+		want := genai.NewPartFromBytes([]byte(tc.want), "text/plain")
+		if err != nil || !cmp.Equal(got.Part, want) {
+			t.Errorf("Load(%s/%s/%s) = (%v, %v), want (%v, nil)", tc.appName, tc.userID, tc.sessionID, got, err, want)
+		}
+	}
+
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{
+		AppName: "app", UserID: "user1", SessionID: "session1", FileName: "file",
+	}); err != nil {
+		t.Fatalf("Delete(app/user1/session1) failed: %v", err)
+	}
+	resp, err := srv.List(ctx, &artifact.ListRequest{AppName: "app", UserID: "user1", SessionID: "session2"})
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"file"}, resp.FileNames); diff != "" {
+		t.Errorf("List(app/user1/session2) mismatch after deleting another session's file (-want +got):\n%s", diff)
+	}
+}
+
+func testSaveAfterDelete(ctx context.Context, t *testing.T, srv artifact.Service) {
+	save := func(want int64) {
+		t.Helper()
+		got, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromBytes([]byte("data"), "application/octet-stream"),
+		})
+		if err != nil || got.Version != want {
+			t.Fatalf("Save() = (%v, %v), want (%v, nil)", got, err, want)
+		}
+	}
+	remove := func(version int64) {
+		t.Helper()
+		if err := srv.Delete(ctx, &artifact.DeleteRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: version,
+		}); err != nil {
+			t.Fatalf("Delete(%v) failed: %v", version, err)
+		}
+	}
+
+	save(1)
+	save(2)
+	save(3)
+	// Deleting an older version keeps the numbering.
+	remove(2)
+	save(4)
+	// Deleting the latest version makes its number available again.
+	remove(4)
+	save(4)
+	// Deleting all the versions restarts the numbering.
+	remove(0)
+	save(1)
+}
@@ -26,7 +26,7 @@ import (
 
 	"cloud.google.com/go/storage"
 	"google.golang.org/adk/artifact"
-	"google.golang.org/adk/internal/artifact/tests"
+	"google.golang.org/adk/artifact/artifacttest"
 	"google.golang.org/api/iterator"
 )
 
@@ -42,10 +42,13 @@ func newGCSArtifactServiceForTesting(bucketName string) (artifact.Service, error
 }
 
 func TestGCSArtifactService(t *testing.T) {
-	factory := func(t *testing.T) (artifact.Service, error) {
-		return newGCSArtifactServiceForTesting("new")
-	}
-	tests.TestArtifactService(t, "GCS", factory)
+	artifacttest.Run(t, func(t *testing.T) artifact.Service {
+		s, err := newGCSArtifactServiceForTesting("new")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
 }
 
 // ---------------------------------- Mock Implementations -----------------------------------
@@ -18,12 +18,11 @@ import (
 	"testing"
 
 	"google.golang.org/adk/artifact"
-	"google.golang.org/adk/internal/artifact/tests"
+	"google.golang.org/adk/artifact/artifacttest"
 )
 
 func TestInMemoryArtifactService(t *testing.T) {
-	factory := func(t *testing.T) (artifact.Service, error) {
-		return artifact.InMemoryService(), nil
-	}
-	tests.TestArtifactService(t, "InMemory", factory)
+	artifacttest.Run(t, func(t *testing.T) artifact.Service {
+		return artifact.InMemoryService()
+	})
 }
@@ -0,0 +1,45 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+// ReferenceKey is the key of the name of the artifact in the artifact
+// references, see Reference.
+const ReferenceKey = "adk_artifact"
+
+// ReferenceVersionKey is the key of the version of the artifact in the
+// artifact references, see Reference.
+const ReferenceVersionKey = "version"
+
+// Reference returns a reference to the given version of the artifact with
+// the given name, to return in the results of the tools, e.g.
+//
+//	resp, err := ctx.Artifacts().Save(ctx, "report.pdf", part)
+//	if err != nil {
+//		return nil, err
+//	}
+//	return map[string]any{"report": artifact.Reference("report.pdf", resp.Version)}, nil
+//
+// The LLM agents send the referenced artifacts to the model after the tool
+// results, so that the model can reason over files without the tools
+// inlining them. The version is recorded in the tool result, so that the
+// history keeps referring to the same data when the artifact is saved
+// again. A zero version references the latest version.
+func Reference(name string, version int64) map[string]any {
+	ref := map[string]any{ReferenceKey: name}
+	if version != 0 {
+		ref[ReferenceVersionKey] = version
+	}
+	return ref
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/artifact/artifacttest"
+	"google.golang.org/genai"
+)
+
+func TestS3ArtifactService(t *testing.T) {
+	artifacttest.Run(t, newServiceForTesting)
+}
+
+func TestS3ArtifactService_ConcurrentSave(t *testing.T) {
+	srv := newServiceForTesting(t)
+	const n = 4
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := srv.Save(t.Context(), &artifact.SaveRequest{
+				AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+				Part: genai.NewPartFromText("data"),
+			}); err != nil {
+				t.Errorf("Save() failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	resp, err := srv.Versions(t.Context(), &artifact.VersionsRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+	})
+	if err != nil {
+		t.Fatalf("Versions() failed: %v", err)
+	}
+	got := resp.Versions
+	slices.Sort(got)
+	if diff := cmp.Diff([]int64{1, 2, 3, 4}, got); diff != "" {
+		t.Errorf("Versions() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// The get-vanilla example of the AWS Signature Version 4 test suite.
+	req := httptest.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := credentials{accessKeyID: "AKIDEXAMPLE", secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	emptyHash := sha256.Sum256(nil)
+	sign(req, creds, "us-east-1", "service", hex.EncodeToString(emptyHash[:]), time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+}
+
+func TestNewService(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+	}{
+		{"missing bucket", Config{AccessKeyID: "key", SecretAccessKey: "secret"}},
+		{"missing credentials", Config{Bucket: "bucket"}},
+		{"invalid endpoint", Config{Bucket: "bucket", AccessKeyID: "key", SecretAccessKey: "secret", Endpoint: "localhost"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewService(tc.cfg); err == nil {
+				t.Errorf("NewService() succeeded, want error")
+			}
+		})
+	}
+}
+
+// newServiceForTesting creates a service of a fake S3 server.
+func newServiceForTesting(t *testing.T) artifact.Service {
+	server := httptest.NewServer(&fakeS3{bucket: "bucket", objects: map[string]fakeObject{}})
+	t.Cleanup(server.Close)
+	srv, err := NewService(Config{
+		Bucket:          "bucket",
+		Endpoint:        server.URL,
+		UsePathStyle:    true,
+		AccessKeyID:     "test-key",
+		SecretAccessKey: "test-secret",
+	})
+	if err != nil {
+		t.Fatalf("NewService() failed: %v", err)
+	}
+	return srv
+}
+
+// fakeS3 serves the subset of the S3 API used by the service, for a single
+// bucket addressed in the path.
+type fakeS3 struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	contentType string
+	data        []byte
+}
+
+// fakePageSize is small so that the listings are paginated.
+const fakePageSize = 2
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") ||
+		r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+		return
+	}
+	path, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket)
+	if !ok {
+		http.Error(w, "<Error><Code>NoSuchBucket</Code></Error>", http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(path, "/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, r)
+	case r.Method == http.MethodPut:
+		if _, ok := f.objects[key]; ok && r.Header.Get("If-None-Match") == "*" {
+			http.Error(w, "<Error><Code>PreconditionFailed</Code></Error>", http.StatusPreconditionFailed)
+			return
+		}
+		f.objects[key] = fakeObject{contentType: r.Header.Get("Content-Type"), data: body}
+	case r.Method == http.MethodGet:
+		obj, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Write(obj.data)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	prefix, after := r.URL.Query().Get("prefix"), r.URL.Query().Get("continuation-token")
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) && key > after {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	var result listBucketResult
+	if len(keys) > fakePageSize {
+		keys = keys[:fakePageSize]
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[len(keys)-1]
+	}
+	for _, key := range keys {
+		result.Contents = append(result.Contents, struct {
+			Key string `xml:"Key"`
+		}{key})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,291 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package s3 provides an implementation of the [artifact.Service] interface
+// storing the artifacts in an Amazon S3 bucket or in the bucket of an
+// S3-compatible server, e.g. MinIO.
+//
+// The artifacts are stored like in the gcs package, one object per version
+// named appName/userID/sessionID/fileName/version, and the versions are
+// numbered like in [artifact.InMemoryService].
+package s3
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+)
+
+// maxSaveAttempts bounds the attempts of Save to claim a version number
+// concurrently claimed by other saves.
+const maxSaveAttempts = 5
+
+// Config is the configuration of the S3 artifact service.
+type Config struct {
+	// Bucket is the bucket storing the artifacts.
+	Bucket string
+	// Region is the region of the bucket.
+	// Optional: defaults to the AWS_REGION environment variable, or to
+	// "us-east-1".
+	Region string
+	// Endpoint is the URL of the S3 API, e.g. "http://localhost:9000" for a
+	// local MinIO server.
+	// Optional: defaults to the Amazon S3 endpoint of the region.
+	Endpoint string
+	// UsePathStyle addresses the bucket in the path of the URLs rather than
+	// in their host name, as most S3-compatible servers require.
+	UsePathStyle bool
+	// AccessKeyID, SecretAccessKey and SessionToken are the credentials.
+	// Optional: default to the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+	// AWS_SESSION_TOKEN environment variables.
+	AccessKeyID, SecretAccessKey, SessionToken string
+	// HTTPClient sends the requests.
+	// Optional: defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// s3Service is an S3 implementation of the Service.
+type s3Service struct {
+	client *client
+}
+
+// NewService creates an S3 artifact service.
+func NewService(cfg Config) (artifact.Service, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("failed to create s3 service: missing bucket")
+	}
+	region := cmp.Or(cfg.Region, os.Getenv("AWS_REGION"), "us-east-1")
+	creds := credentials{
+		accessKeyID:     cmp.Or(cfg.AccessKeyID, os.Getenv("AWS_ACCESS_KEY_ID")),
+		secretAccessKey: cmp.Or(cfg.SecretAccessKey, os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		sessionToken:    cmp.Or(cfg.SessionToken, os.Getenv("AWS_SESSION_TOKEN")),
+	}
+	if creds.accessKeyID == "" || creds.secretAccessKey == "" {
+		return nil, errors.New("failed to create s3 service: missing credentials")
+	}
+	endpoint, err := url.Parse(cmp.Or(cfg.Endpoint, "https://s3."+region+".amazonaws.com"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 service: invalid endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("failed to create s3 service: invalid endpoint %q", cfg.Endpoint)
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &s3Service{client: &client{
+		httpClient:   httpClient,
+		endpoint:     endpoint,
+		bucket:       cfg.Bucket,
+		region:       region,
+		usePathStyle: cfg.UsePathStyle,
+		creds:        creds,
+		now:          time.Now,
+	}}, nil
+}
+
+// fileHasUserNamespace checks if a filename indicates a user-namespaced object.
+func fileHasUserNamespace(filename string) bool {
+	return strings.HasPrefix(filename, "user:")
+}
+
+// buildObjectKey constructs the key of the object of an artifact version.
+func buildObjectKey(appName, userID, sessionID, fileName string, version int64) string {
+	return buildObjectKeyPrefix(appName, userID, sessionID, fileName) + strconv.FormatInt(version, 10)
+}
+
+func buildObjectKeyPrefix(appName, userID, sessionID, fileName string) string {
+	if fileHasUserNamespace(fileName) {
+		return fmt.Sprintf("%s/%s/user/%s/", appName, userID, fileName)
+	}
+	return fmt.Sprintf("%s/%s/%s/%s/", appName, userID, sessionID, fileName)
+}
+
+func buildSessionPrefix(appName, userID, sessionID string) string {
+	return fmt.Sprintf("%s/%s/%s/", appName, userID, sessionID)
+}
+
+func buildUserPrefix(appName, userID string) string {
+	return fmt.Sprintf("%s/%s/user/", appName, userID)
+}
+
+// Save implements [artifact.Service]
+func (s *s3Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	contentType, data := "text/plain", []byte(req.Part.Text)
+	if req.Part.InlineData != nil {
+		contentType, data = req.Part.InlineData.MIMEType, req.Part.InlineData.Data
+	}
+
+	// The version objects are written only if they don't exist yet, so a
+	// concurrent save of the same artifact makes this one retry with the
+	// next version rather than overwrite it.
+	for attempt := 1; ; attempt++ {
+		versions, err := s.versions(ctx, req.AppName, req.UserID, req.SessionID, req.FileName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list artifact versions: %w", err)
+		}
+		nextVersion := int64(1)
+		if len(versions) > 0 {
+			nextVersion = slices.Max(versions) + 1
+		}
+		key := buildObjectKey(req.AppName, req.UserID, req.SessionID, req.FileName, nextVersion)
+		err = s.client.putObject(ctx, key, contentType, data, true)
+		if errors.Is(err, errPreconditionFailed) && attempt < maxSaveAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write artifact to S3: %w", err)
+		}
+		return &artifact.SaveResponse{Version: nextVersion}, nil
+	}
+}
+
+// Delete implements [artifact.Service]
+func (s *s3Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	appName, userID, sessionID, fileName := req.AppName, req.UserID, req.SessionID, req.FileName
+
+	// Delete specific version
+	if req.Version != 0 {
+		if err := s.client.deleteObject(ctx, buildObjectKey(appName, userID, sessionID, fileName, req.Version)); err != nil {
+			return fmt.Errorf("failed to delete artifact: %w", err)
+		}
+		return nil
+	}
+
+	// Delete all versions
+	versions, err := s.versions(ctx, appName, userID, sessionID, fileName)
+	if err != nil {
+		return fmt.Errorf("failed to fetch versions on delete artifact: %w", err)
+	}
+	g, gctx := errgroup.WithContext(ctx)
+	for _, version := range versions {
+		g.Go(func() error {
+			key := buildObjectKey(appName, userID, sessionID, fileName, version)
+			if err := s.client.deleteObject(gctx, key); err != nil {
+				return fmt.Errorf("failed to delete artifact %s: %w", key, err)
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// Load implements [artifact.Service]
+func (s *s3Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	appName, userID, sessionID, fileName := req.AppName, req.UserID, req.SessionID, req.FileName
+
+	version := req.Version
+	if version == 0 {
+		versions, err := s.versions(ctx, appName, userID, sessionID, fileName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list artifact versions: %w", err)
+		}
+		if len(versions) == 0 {
+			return nil, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+		}
+		version = slices.Max(versions)
+	}
+
+	data, contentType, err := s.client.getObject(ctx, buildObjectKey(appName, userID, sessionID, fileName, version))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load artifact: %w", err)
+	}
+	return &artifact.LoadResponse{Part: genai.NewPartFromBytes(data, contentType)}, nil
+}
+
+// List implements [artifact.Service]
+func (s *s3Service) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	filenames := map[string]bool{}
+	for _, prefix := range []string{
+		buildSessionPrefix(req.AppName, req.UserID, req.SessionID),
+		buildUserPrefix(req.AppName, req.UserID),
+	} {
+		keys, err := s.client.listObjects(ctx, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list artifacts: %w", err)
+		}
+		for _, key := range keys {
+			// The key is prefix/fileName/version.
+			fileName, _, ok := strings.Cut(strings.TrimPrefix(key, prefix), "/")
+			if !ok {
+				continue
+			}
+			filenames[fileName] = true
+		}
+	}
+	return &artifact.ListResponse{FileNames: slices.Sorted(maps.Keys(filenames))}, nil
+}
+
+// versions returns the versions of the artifact, or none if it doesn't exist.
+func (s *s3Service) versions(ctx context.Context, appName, userID, sessionID, fileName string) ([]int64, error) {
+	prefix := buildObjectKeyPrefix(appName, userID, sessionID, fileName)
+	keys, err := s.client.listObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int64, 0, len(keys))
+	for _, key := range keys {
+		version, err := strconv.ParseInt(strings.TrimPrefix(key, prefix), 10, 64)
+		// if the object name is not a version number, just ignore it
+		if err != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// Versions implements [artifact.Service] and returns an error if no versions are found.
+func (s *s3Service) Versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	versions, err := s.versions(ctx, req.AppName, req.UserID, req.SessionID, req.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifact versions: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+	}
+	return &artifact.VersionsResponse{Versions: versions}, nil
+}
+
+var _ artifact.Service = (*s3Service)(nil)
@@ -0,0 +1,189 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auth provides the types used by tools to request credentials from
+// the client (end-user credentials, EUC) and to exchange them for tokens.
+//
+// The flow mirrors adk-python:
+//  1. A tool calls tool.Context.RequestCredential with a [Config] describing
+//     the auth scheme and the raw credential (e.g. OAuth2 client ID).
+//  2. The agent emits a long-running function call named
+//     [RequestCredentialFunctionName] with [RequestCredentialArgs]. For the
+//     OAuth2 authorization code flow, the exchanged credential contains the
+//     URI the user should visit.
+//  3. The client responds with a function response of the same name and ID,
+//     whose response is the [Config] with the exchanged credential filled
+//     in (e.g. the OAuth2 redirect URI with the authorization code).
+//  4. ADK exchanges the credential for a token with [Exchange] and runs the
+//     tool again, which reads the token with tool.Context.Credential.
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// RequestCredentialFunctionName is the name of the function call emitted to
+// ask the client for a credential.
+const RequestCredentialFunctionName = "adk_request_credential"
+
+// CredentialType is the type of a [Credential].
+type CredentialType string
+
+const (
+	CredentialTypeAPIKey         CredentialType = "apiKey"
+	CredentialTypeHTTP           CredentialType = "http"
+	CredentialTypeOAuth2         CredentialType = "oauth2"
+	CredentialTypeOpenIDConnect  CredentialType = "openIdConnect"
+	CredentialTypeServiceAccount CredentialType = "serviceAccount"
+)
+
+// Credential holds the data used to authenticate, e.g. an API key, an OAuth2
+// client or an OAuth2 access token.
+type Credential struct {
+	AuthType CredentialType `json:"authType"`
+	// ResourceRef identifies the credential in an external credential store.
+	ResourceRef    string          `json:"resourceRef,omitempty"`
+	APIKey         string          `json:"apiKey,omitempty"`
+	HTTP           *HTTPAuth       `json:"http,omitempty"`
+	ServiceAccount *ServiceAccount `json:"serviceAccount,omitempty"`
+	OAuth2         *OAuth2Auth     `json:"oauth2,omitempty"`
+}
+
+// HTTPAuth is an HTTP authentication credential, e.g. a bearer token.
+type HTTPAuth struct {
+	// Scheme is the HTTP authorization scheme, e.g. "basic" or "bearer".
+	Scheme      string          `json:"scheme"`
+	Credentials HTTPCredentials `json:"credentials"`
+}
+
+// HTTPCredentials are the values used with an HTTP authorization scheme.
+type HTTPCredentials struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// ServiceAccount is a Google Cloud service account credential.
+type ServiceAccount struct {
+	// ServiceAccountCredential is the JSON key of the service account.
+	ServiceAccountCredential json.RawMessage `json:"serviceAccountCredential,omitempty"`
+	Scopes                   []string        `json:"scopes,omitempty"`
+	// UseDefaultCredential uses the application default credentials instead
+	// of the JSON key.
+	UseDefaultCredential bool `json:"useDefaultCredential,omitempty"`
+}
+
+// OAuth2Auth is an OAuth2 client and the state of its authorization.
+type OAuth2Auth struct {
+	ClientID     string `json:"clientId,omitempty"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+	// AuthURI is the URI the user visits to authorize the client.
+	AuthURI     string `json:"authUri,omitempty"`
+	State       string `json:"state,omitempty"`
+	RedirectURI string `json:"redirectUri,omitempty"`
+	// AuthResponseURI is the URI the user was redirected to after the
+	// authorization, which contains the authorization code.
+	AuthResponseURI string `json:"authResponseUri,omitempty"`
+	AuthCode        string `json:"authCode,omitempty"`
+	AccessToken     string `json:"accessToken,omitempty"`
+	RefreshToken    string `json:"refreshToken,omitempty"`
+	// ExpiresAt is the expiry of the access token in seconds since epoch.
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
+}
+
+// Scheme is an OpenAPI security scheme.
+type Scheme struct {
+	// Type is one of "apiKey", "http", "oauth2" or "openIdConnect".
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	// Name and In locate the API key, e.g. Name: "X-API-Key", In: "header".
+	Name string `json:"name,omitempty"`
+	In   string `json:"in,omitempty"`
+	// Scheme is the HTTP authorization scheme, e.g. "bearer".
+	Scheme           string      `json:"scheme,omitempty"`
+	BearerFormat     string      `json:"bearerFormat,omitempty"`
+	Flows            *OAuthFlows `json:"flows,omitempty"`
+	OpenIDConnectURL string      `json:"openIdConnectUrl,omitempty"`
+}
+
+// OAuthFlows are the OAuth2 flows supported by a [Scheme].
+type OAuthFlows struct {
+	Implicit          *OAuthFlow `json:"implicit,omitempty"`
+	Password          *OAuthFlow `json:"password,omitempty"`
+	ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty"`
+	AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty"`
+}
+
+// OAuthFlow describes an OAuth2 flow.
+type OAuthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	RefreshURL       string            `json:"refreshUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes,omitempty"`
+}
+
+// Config describes the credential a tool needs.
+type Config struct {
+	AuthScheme *Scheme `json:"authScheme"`
+	// RawAuthCredential is the credential the tool starts with, e.g. the
+	// OAuth2 client ID and secret.
+	RawAuthCredential *Credential `json:"rawAuthCredential,omitempty"`
+	// ExchangedAuthCredential is the credential filled in by ADK and the
+	// client during the auth flow.
+	ExchangedAuthCredential *Credential `json:"exchangedAuthCredential,omitempty"`
+	// CredentialKey identifies the credential in the session state and in
+	// the [CredentialService].
+	// Optional: if empty, a key is derived from the scheme and the raw
+	// credential, see [Config.Key].
+	CredentialKey string `json:"credentialKey,omitempty"`
+	// Scope of the credential in the [CredentialService].
+	// Optional: defaults to [ScopeUser].
+	Scope Scope `json:"scope,omitempty"`
+}
+
+// Scope defines which sessions share a credential stored in the
+// [CredentialService].
+type Scope string
+
+const (
+	// ScopeUser shares the credential between the sessions of the user.
+	ScopeUser Scope = "user"
+	// ScopeSession keeps the credential in the session which obtained it.
+	ScopeSession Scope = "session"
+)
+
+// Key returns the key which identifies the credential: CredentialKey if set,
+// or a hash of the auth scheme and the raw credential.
+func (c *Config) Key() string {
+	if c.CredentialKey != "" {
+		return c.CredentialKey
+	}
+	b, _ := json.Marshal(struct {
+		Scheme     *Scheme     `json:"s"`
+		Credential *Credential `json:"c"`
+	}{c.AuthScheme, c.RawAuthCredential})
+	sum := sha256.Sum256(b)
+	return "adk_" + hex.EncodeToString(sum[:8])
+}
+
+// RequestCredentialArgs are the args of the [RequestCredentialFunctionName]
+// function call.
+type RequestCredentialArgs struct {
+	// FunctionCallID is the ID of the function call of the tool which
+	// requested the credential.
+	FunctionCallID string  `json:"functionCallId"`
+	AuthConfig     *Config `json:"authConfig"`
+}
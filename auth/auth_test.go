@@ -0,0 +1,205 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/auth"
+)
+
+func oauth2Config(tokenURL string) *auth.Config {
+	return &auth.Config{
+		AuthScheme: &auth.Scheme{
+			Type: "oauth2",
+			Flows: &auth.OAuthFlows{AuthorizationCode: &auth.OAuthFlow{
+				AuthorizationURL: "https://example.com/authorize",
+				TokenURL:         tokenURL,
+				Scopes:           map[string]string{"read": "read the data"},
+			}},
+		},
+		RawAuthCredential: &auth.Credential{
+			AuthType: auth.CredentialTypeOAuth2,
+			OAuth2: &auth.OAuth2Auth{
+				ClientID:     "client",
+				ClientSecret: "secret",
+				RedirectURI:  "https://app.example.com/callback",
+			},
+		},
+	}
+}
+
+func TestConfig_Key(t *testing.T) {
+	cfg := oauth2Config("https://example.com/token")
+	if got, want := cfg.Key(), oauth2Config("https://example.com/token").Key(); got != want {
+		t.Errorf("Key() = %q for the same config, want %q", got, want)
+	}
+	if got, other := cfg.Key(), oauth2Config("https://other.example.com/token").Key(); got == other {
+		t.Errorf("Key() = %q for different auth schemes", got)
+	}
+	withExchanged := oauth2Config("https://example.com/token")
+	withExchanged.ExchangedAuthCredential = &auth.Credential{AuthType: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Auth{AuthCode: "code"}}
+	if got, want := withExchanged.Key(), cfg.Key(); got != want {
+		t.Errorf("Key() = %q with an exchanged credential, want %q", got, want)
+	}
+	cfg.CredentialKey = "my_key"
+	if got := cfg.Key(); got != "my_key" {
+		t.Errorf("Key() = %q, want the credential key", got)
+	}
+}
+
+func TestPrepareRequest(t *testing.T) {
+	cfg := oauth2Config("https://example.com/token")
+	req, err := auth.PrepareRequest(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ExchangedAuthCredential != nil {
+		t.Error("PrepareRequest modified the config")
+	}
+	oauth := req.ExchangedAuthCredential.OAuth2
+	u, err := url.Parse(oauth.AuthURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Host != "example.com" || q.Get("client_id") != "client" || q.Get("scope") != "read" || q.Get("state") != oauth.State || oauth.State == "" {
+		t.Errorf("AuthURI = %q, state = %q, want the authorization URL of the client", oauth.AuthURI, oauth.State)
+	}
+
+	apiKey := &auth.Config{
+		AuthScheme:        &auth.Scheme{Type: "apiKey", Name: "key", In: "query"},
+		RawAuthCredential: &auth.Credential{AuthType: auth.CredentialTypeAPIKey},
+	}
+	req, err = auth.PrepareRequest(apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(apiKey, req); diff != "" {
+		t.Errorf("PrepareRequest() mismatch for an API key (-want +got):\n%s", diff)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		if got := r.PostForm.Get("code"); got != "the_code" {
+			t.Errorf("token request code = %q, want %q", got, "the_code")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "access",
+			"refresh_token": "refresh",
+			"token_type":    "Bearer",
+		})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		exchanged *auth.OAuth2Auth
+	}{
+		{
+			name:      "auth code",
+			exchanged: &auth.OAuth2Auth{ClientID: "client", AuthCode: "the_code"},
+		},
+		{
+			name:      "auth response URI",
+			exchanged: &auth.OAuth2Auth{ClientID: "client", AuthResponseURI: "https://app.example.com/callback?code=the_code&state=s"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := oauth2Config(server.URL)
+			cfg.ExchangedAuthCredential = &auth.Credential{AuthType: auth.CredentialTypeOAuth2, OAuth2: tt.exchanged}
+			cred, err := auth.Exchange(t.Context(), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cred.OAuth2.AccessToken != "access" || cred.OAuth2.RefreshToken != "refresh" {
+				t.Errorf("Exchange() = %+v, want the tokens", cred.OAuth2)
+			}
+		})
+	}
+
+	t.Run("API key", func(t *testing.T) {
+		cfg := &auth.Config{
+			AuthScheme:              &auth.Scheme{Type: "apiKey", Name: "key", In: "query"},
+			ExchangedAuthCredential: &auth.Credential{AuthType: auth.CredentialTypeAPIKey, APIKey: "key"},
+		}
+		cred, err := auth.Exchange(t.Context(), cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(cfg.ExchangedAuthCredential, cred); diff != "" {
+			t.Errorf("Exchange() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("no credential", func(t *testing.T) {
+		if _, err := auth.Exchange(t.Context(), &auth.Config{}); err == nil {
+			t.Error("Exchange() succeeded, want error")
+		}
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		if got := r.PostForm.Get("refresh_token"); got != "refresh" {
+			t.Errorf("token request refresh_token = %q, want %q", got, "refresh")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "new_access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+	defer server.Close()
+	cfg := oauth2Config(server.URL)
+
+	valid := &auth.Credential{AuthType: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Auth{
+		AccessToken: "access", RefreshToken: "refresh", ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}}
+	got, refreshed, err := auth.Refresh(t.Context(), cfg, valid)
+	if err != nil || refreshed || got != valid {
+		t.Errorf("Refresh(valid) = (%v, %v, %v), want the credential unchanged", got, refreshed, err)
+	}
+
+	expired := &auth.Credential{AuthType: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Auth{
+		AccessToken: "access", RefreshToken: "refresh", ExpiresAt: time.Now().Add(-time.Hour).Unix(),
+	}}
+	got, refreshed, err = auth.Refresh(t.Context(), cfg, expired)
+	if err != nil || !refreshed {
+		t.Fatalf("Refresh(expired) = (%v, %v, %v), want a refreshed credential", got, refreshed, err)
+	}
+	if got.OAuth2.AccessToken != "new_access" || got.OAuth2.RefreshToken != "refresh" || got.OAuth2.ExpiresAt <= time.Now().Unix() {
+		t.Errorf("Refresh(expired) = %+v, want the new access token", got.OAuth2)
+	}
+	if expired.OAuth2.AccessToken != "access" {
+		t.Error("Refresh modified the credential")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// CredentialService stores the credentials obtained with the auth flow, so
+// that the tools don't ask the user for them again. The credentials are
+// identified by the app name, the user ID, the credential key and, for
+// session scoped credentials, the session ID.
+type CredentialService interface {
+	// Load returns the stored credential, or nil if there is none.
+	Load(ctx context.Context, req *LoadCredentialRequest) (*Credential, error)
+	// Save stores the credential, replacing the previous one.
+	Save(ctx context.Context, req *SaveCredentialRequest) error
+	// Delete deletes the credential. Deleting a non-existing credential is
+	// not an error.
+	Delete(ctx context.Context, req *DeleteCredentialRequest) error
+}
+
+// CredentialID identifies a credential in the [CredentialService].
+type CredentialID struct {
+	AppName, UserID string
+	// SessionID is empty for user scoped credentials.
+	SessionID string
+	// Key is the credential key, see [Config.Key].
+	Key string
+}
+
+// Validate checks that the required fields are set.
+func (id CredentialID) Validate() error {
+	var missing []string
+	if id.AppName == "" {
+		missing = append(missing, "AppName")
+	}
+	if id.UserID == "" {
+		missing = append(missing, "UserID")
+	}
+	if id.Key == "" {
+		missing = append(missing, "Key")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("invalid credential ID: missing required fields: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// LoadCredentialRequest is the parameter for [CredentialService.Load].
+type LoadCredentialRequest struct {
+	ID CredentialID
+}
+
+// SaveCredentialRequest is the parameter for [CredentialService.Save].
+type SaveCredentialRequest struct {
+	ID         CredentialID
+	Credential *Credential
+}
+
+// DeleteCredentialRequest is the parameter for [CredentialService.Delete].
+type DeleteCredentialRequest struct {
+	ID CredentialID
+}
+
+// inMemoryCredentialService is an in-memory implementation of the
+// CredentialService. It is primarily for testing and demonstration purposes.
+type inMemoryCredentialService struct {
+	mu          sync.RWMutex
+	credentials map[CredentialID]*Credential
+}
+
+// InMemoryCredentialService returns a new in-memory credential service.
+func InMemoryCredentialService() CredentialService {
+	return &inMemoryCredentialService{credentials: make(map[CredentialID]*Credential)}
+}
+
+func (s *inMemoryCredentialService) Load(ctx context.Context, req *LoadCredentialRequest) (*Credential, error) {
+	if err := req.ID.Validate(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.credentials[req.ID], nil
+}
+
+func (s *inMemoryCredentialService) Save(ctx context.Context, req *SaveCredentialRequest) error {
+	if err := req.ID.Validate(); err != nil {
+		return err
+	}
+	if req.Credential == nil {
+		return fmt.Errorf("invalid save request: missing required fields: Credential")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.credentials[req.ID] = req.Credential
+	return nil
+}
+
+func (s *inMemoryCredentialService) Delete(ctx context.Context, req *DeleteCredentialRequest) error {
+	if err := req.ID.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.credentials, req.ID)
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/auth"
+)
+
+func TestCredentialService(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	tests := []struct {
+		name       string
+		newService func(t *testing.T) auth.CredentialService
+	}{
+		{
+			name: "in memory",
+			newService: func(t *testing.T) auth.CredentialService {
+				return auth.InMemoryCredentialService()
+			},
+		},
+		{
+			name: "file",
+			newService: func(t *testing.T) auth.CredentialService {
+				s, err := auth.NewFileCredentialService(auth.FileCredentialServiceConfig{Dir: t.TempDir(), EncryptionKey: key})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return s
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := t.Context()
+			s := tt.newService(t)
+			id := auth.CredentialID{AppName: "app", UserID: "user", Key: "key"}
+			sessionID := auth.CredentialID{AppName: "app", UserID: "user", SessionID: "s1", Key: "key"}
+			cred := &auth.Credential{AuthType: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Auth{AccessToken: "token", ExpiresAt: 42}}
+
+			if got, err := s.Load(ctx, &auth.LoadCredentialRequest{ID: id}); err != nil || got != nil {
+				t.Errorf("Load() = (%v, %v), want no credential", got, err)
+			}
+			if err := s.Save(ctx, &auth.SaveCredentialRequest{ID: id, Credential: cred}); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Load(ctx, &auth.LoadCredentialRequest{ID: id})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(cred, got); diff != "" {
+				t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+			}
+			if got, err := s.Load(ctx, &auth.LoadCredentialRequest{ID: sessionID}); err != nil || got != nil {
+				t.Errorf("Load(session scoped) = (%v, %v), want no credential", got, err)
+			}
+
+			if err := s.Delete(ctx, &auth.DeleteCredentialRequest{ID: id}); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := s.Load(ctx, &auth.LoadCredentialRequest{ID: id}); err != nil || got != nil {
+				t.Errorf("Load() after Delete() = (%v, %v), want no credential", got, err)
+			}
+			if err := s.Delete(ctx, &auth.DeleteCredentialRequest{ID: id}); err != nil {
+				t.Errorf("Delete() of a deleted credential failed: %v", err)
+			}
+
+			if err := s.Save(ctx, &auth.SaveCredentialRequest{ID: auth.CredentialID{AppName: "app"}, Credential: cred}); err == nil {
+				t.Error("Save() with an incomplete ID succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestFileCredentialService_Encrypted(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	s, err := auth.NewFileCredentialService(auth.FileCredentialServiceConfig{Dir: dir, EncryptionKey: bytes.Repeat([]byte{1}, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := auth.CredentialID{AppName: "app", UserID: "user", Key: "key"}
+	if err := s.Save(ctx, &auth.SaveCredentialRequest{ID: id, Credential: &auth.Credential{APIKey: "secret"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("credential files = (%v, %v), want one file", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("secret")) {
+		t.Error("credential file contains the API key in plain text")
+	}
+
+	other, err := auth.NewFileCredentialService(auth.FileCredentialServiceConfig{Dir: dir, EncryptionKey: bytes.Repeat([]byte{2}, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Load(ctx, &auth.LoadCredentialRequest{ID: id}); err == nil {
+		t.Error("Load() with another encryption key succeeded, want error")
+	}
+
+	if _, err := auth.NewFileCredentialService(auth.FileCredentialServiceConfig{Dir: dir, EncryptionKey: []byte("short")}); err == nil {
+		t.Error("NewFileCredentialService() with an invalid key succeeded, want error")
+	}
+}
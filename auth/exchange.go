@@ -0,0 +1,208 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// PrepareRequest returns a copy of the config to send to the client. For the
+// OAuth2 authorization code flow, the exchanged credential contains the URI
+// the user should visit to authorize the client.
+func PrepareRequest(cfg *Config) (*Config, error) {
+	req := *cfg
+	if req.ExchangedAuthCredential != nil || req.RawAuthCredential == nil || req.RawAuthCredential.OAuth2 == nil {
+		return &req, nil
+	}
+	oauthCfg, ok := oauth2Config(&req, req.RawAuthCredential.OAuth2)
+	if !ok {
+		return &req, nil
+	}
+	if oauthCfg.Endpoint.AuthURL == "" {
+		return nil, fmt.Errorf("auth scheme has no authorization URL")
+	}
+
+	exchanged := *req.RawAuthCredential
+	oauth := *exchanged.OAuth2
+	oauth.State = uuid.NewString()
+	oauth.AuthURI = oauthCfg.AuthCodeURL(oauth.State, oauth2.AccessTypeOffline, oauth2.SetAuthURLParam("prompt", "consent"))
+	exchanged.OAuth2 = &oauth
+	req.ExchangedAuthCredential = &exchanged
+	return &req, nil
+}
+
+// Exchange returns the credential the tool can use, based on the config
+// returned by the client:
+//   - an OAuth2 authorization code (or the redirect URI containing it) is
+//     exchanged for an access token,
+//   - a service account is exchanged for a bearer token,
+//   - otherwise, the exchanged credential (or the raw credential, if there's
+//     no exchanged one) is returned as is.
+func Exchange(ctx context.Context, cfg *Config) (*Credential, error) {
+	cred := cfg.ExchangedAuthCredential
+	if cred == nil {
+		cred = cfg.RawAuthCredential
+	}
+	if cred == nil {
+		return nil, fmt.Errorf("auth config has no credential")
+	}
+
+	switch {
+	case cred.ServiceAccount != nil:
+		return exchangeServiceAccount(ctx, cred.ServiceAccount)
+	case cred.OAuth2 != nil && cred.OAuth2.AccessToken == "" && (cred.OAuth2.AuthCode != "" || cred.OAuth2.AuthResponseURI != ""):
+		return exchangeAuthCode(ctx, cfg, cred)
+	default:
+		return cred, nil
+	}
+}
+
+// refreshSkew is how long before its expiry an access token is refreshed.
+const refreshSkew = time.Minute
+
+// Refresh returns a credential with a new OAuth2 access token if the access
+// token of cred has expired and cred has a refresh token. It returns false if
+// the credential didn't need to be refreshed.
+func Refresh(ctx context.Context, cfg *Config, cred *Credential) (*Credential, bool, error) {
+	if cred == nil || cred.OAuth2 == nil || cred.OAuth2.RefreshToken == "" || cred.OAuth2.ExpiresAt == 0 {
+		return cred, false, nil
+	}
+	if time.Now().Add(refreshSkew).Before(time.Unix(cred.OAuth2.ExpiresAt, 0)) {
+		return cred, false, nil
+	}
+
+	client := *cred.OAuth2
+	if client.ClientID == "" && cfg.RawAuthCredential != nil && cfg.RawAuthCredential.OAuth2 != nil {
+		client.ClientID = cfg.RawAuthCredential.OAuth2.ClientID
+		client.ClientSecret = cfg.RawAuthCredential.OAuth2.ClientSecret
+	}
+	oauthCfg, ok := oauth2Config(cfg, &client)
+	if !ok {
+		return nil, false, fmt.Errorf("auth scheme has no token URL")
+	}
+	if refreshURL := cfg.AuthScheme.Flows.AuthorizationCode.RefreshURL; refreshURL != "" {
+		oauthCfg.Endpoint.TokenURL = refreshURL
+	}
+	if oauthCfg.Endpoint.TokenURL == "" {
+		return nil, false, fmt.Errorf("auth scheme has no token URL")
+	}
+	token, err := oauthCfg.TokenSource(ctx, &oauth2.Token{
+		RefreshToken: cred.OAuth2.RefreshToken,
+		Expiry:       time.Unix(cred.OAuth2.ExpiresAt, 0),
+	}).Token()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to refresh access token: %w", err)
+	}
+
+	refreshed := *cred
+	oauth := *cred.OAuth2
+	oauth.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		oauth.RefreshToken = token.RefreshToken
+	}
+	oauth.ExpiresAt = 0
+	if !token.Expiry.IsZero() {
+		oauth.ExpiresAt = token.Expiry.Unix()
+	}
+	refreshed.OAuth2 = &oauth
+	return &refreshed, true, nil
+}
+
+func exchangeAuthCode(ctx context.Context, cfg *Config, cred *Credential) (*Credential, error) {
+	code := cred.OAuth2.AuthCode
+	if code == "" {
+		u, err := url.Parse(cred.OAuth2.AuthResponseURI)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse auth response URI: %w", err)
+		}
+		code = u.Query().Get("code")
+		if code == "" {
+			return nil, fmt.Errorf("auth response URI has no authorization code")
+		}
+	}
+	oauthCfg, ok := oauth2Config(cfg, cred.OAuth2)
+	if !ok || oauthCfg.Endpoint.TokenURL == "" {
+		return nil, fmt.Errorf("auth scheme has no token URL")
+	}
+	token, err := oauthCfg.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	exchanged := *cred
+	oauth := *cred.OAuth2
+	oauth.AccessToken = token.AccessToken
+	oauth.RefreshToken = token.RefreshToken
+	if !token.Expiry.IsZero() {
+		oauth.ExpiresAt = token.Expiry.Unix()
+	}
+	exchanged.OAuth2 = &oauth
+	return &exchanged, nil
+}
+
+func exchangeServiceAccount(ctx context.Context, sa *ServiceAccount) (*Credential, error) {
+	var (
+		creds *google.Credentials
+		err   error
+	)
+	if sa.UseDefaultCredential {
+		creds, err = google.FindDefaultCredentials(ctx, sa.Scopes...)
+	} else {
+		creds, err = google.CredentialsFromJSON(ctx, sa.ServiceAccountCredential, sa.Scopes...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service account credentials: %w", err)
+	}
+	token, err := creds.TokenSource.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service account token: %w", err)
+	}
+	return &Credential{
+		AuthType: CredentialTypeHTTP,
+		HTTP: &HTTPAuth{
+			Scheme:      "bearer",
+			Credentials: HTTPCredentials{Token: token.AccessToken},
+		},
+	}, nil
+}
+
+// oauth2Config returns the OAuth2 client config for the authorization code
+// flow of the auth scheme. It returns false if the scheme doesn't support
+// the flow.
+func oauth2Config(cfg *Config, client *OAuth2Auth) (*oauth2.Config, bool) {
+	if cfg.AuthScheme == nil || cfg.AuthScheme.Flows == nil || cfg.AuthScheme.Flows.AuthorizationCode == nil {
+		return nil, false
+	}
+	flow := cfg.AuthScheme.Flows.AuthorizationCode
+	return &oauth2.Config{
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  flow.AuthorizationURL,
+			TokenURL: flow.TokenURL,
+		},
+		RedirectURL: client.RedirectURI,
+		Scopes:      slices.Sorted(maps.Keys(flow.Scopes)),
+	}, true
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileCredentialServiceConfig is used to create a file based
+// [CredentialService].
+type FileCredentialServiceConfig struct {
+	// Dir is the directory storing the credentials. It is created if it
+	// doesn't exist.
+	Dir string
+	// EncryptionKey is the AES key encrypting the credentials, of 16, 24 or
+	// 32 bytes.
+	EncryptionKey []byte
+}
+
+// fileCredentialService stores each credential in its own file, encrypted
+// with AES-GCM. The file names are derived from the credential IDs, which
+// are also authenticated with the credentials, so a credential file can't be
+// swapped for another.
+type fileCredentialService struct {
+	dir  string
+	aead cipher.AEAD
+}
+
+// NewFileCredentialService returns a credential service persisting the
+// credentials in encrypted files.
+func NewFileCredentialService(cfg FileCredentialServiceConfig) (CredentialService, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("credentials directory is required")
+	}
+	block, err := aes.NewCipher(cfg.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	return &fileCredentialService{dir: cfg.Dir, aead: aead}, nil
+}
+
+func (s *fileCredentialService) Load(ctx context.Context, req *LoadCredentialRequest) (*Credential, error) {
+	name, err := fileName(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential: %w", err)
+	}
+	n := s.aead.NonceSize()
+	if len(data) < n {
+		return nil, fmt.Errorf("credential file %s is corrupted", name)
+	}
+	plaintext, err := s.aead.Open(nil, data[:n], data[n:], []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credential: %w", err)
+	}
+	var cred Credential
+	if err := json.Unmarshal(plaintext, &cred); err != nil {
+		return nil, fmt.Errorf("failed to decode credential: %w", err)
+	}
+	return &cred, nil
+}
+
+func (s *fileCredentialService) Save(ctx context.Context, req *SaveCredentialRequest) error {
+	name, err := fileName(req.ID)
+	if err != nil {
+		return err
+	}
+	if req.Credential == nil {
+		return fmt.Errorf("invalid save request: missing required fields: Credential")
+	}
+	plaintext, err := json.Marshal(req.Credential)
+	if err != nil {
+		return fmt.Errorf("failed to encode credential: %w", err)
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	data := s.aead.Seal(nonce, nonce, plaintext, []byte(name))
+
+	// Write to a temporary file first, so a crash doesn't leave a partially
+	// written credential.
+	f, err := os.CreateTemp(s.dir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save credential: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to save credential: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to save credential: %w", err)
+	}
+	if err := os.Rename(f.Name(), filepath.Join(s.dir, name)); err != nil {
+		return fmt.Errorf("failed to save credential: %w", err)
+	}
+	return nil
+}
+
+func (s *fileCredentialService) Delete(ctx context.Context, req *DeleteCredentialRequest) error {
+	name, err := fileName(req.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete credential: %w", err)
+	}
+	return nil
+}
+
+// fileName returns the name of the file storing the credential. The IDs are
+// hashed, so they can contain any character.
+func fileName(id CredentialID) (string, error) {
+	if err := id.Validate(); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(id)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]) + ".cred", nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eval provides a launcher which runs eval sets against the root agent
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/adk/cmd/launcher"
+	"google.golang.org/adk/cmd/launcher/universal"
+	"google.golang.org/adk/eval"
+	"google.golang.org/adk/internal/cli/util"
+)
+
+// evalConfig contains command-line params for eval launcher
+type evalConfig struct {
+	evalSetFiles    string // comma-separated list of eval set files
+	configFile      string // optional JSON file with the criteria
+	outputFile      string // optional file to export results to
+	printDetails    bool
+	evalSetPaths    []string
+	criteria        map[string]float64
+	outputFormatted bool
+}
+
+// evalLauncher runs eval sets against an agent
+type evalLauncher struct {
+	flags  *flag.FlagSet
+	config *evalConfig
+}
+
+// NewLauncher creates new eval launcher
+func NewLauncher() launcher.SubLauncher {
+	config := &evalConfig{}
+
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	fs.StringVar(&config.evalSetFiles, "evalset", "", "comma-separated list of eval set files (required)")
+	fs.StringVar(&config.configFile, "config", "", `JSON file with the eval criteria, e.g. {"criteria": {"tool_trajectory_avg_score": 1.0}}. Defaults are used if not set`)
+	fs.StringVar(&config.outputFile, "output", "", "file to export eval results to (JSON)")
+	fs.BoolVar(&config.printDetails, "print_detailed_results", false, "prints scores of every invocation")
+	fs.BoolVar(&config.outputFormatted, "output_indent", true, "indents the exported JSON")
+
+	return &evalLauncher{config: config, flags: fs}
+}
+
+// Run implements launcher.SubLauncher. It runs all eval sets and prints the results.
+// Returns an error if any of the eval cases failed.
+func (l *evalLauncher) Run(ctx context.Context, config *launcher.Config) error {
+	if config.AgentLoader == nil {
+		return fmt.Errorf("agent loader is required")
+	}
+	rootAgent := config.AgentLoader.RootAgent()
+
+	var results []*eval.SetResult
+	failed := 0
+	for _, path := range l.config.evalSetPaths {
+		set, err := eval.LoadEvalSet(path)
+		if err != nil {
+			return err
+		}
+		result, err := eval.Run(ctx, eval.Config{
+			Agent:           rootAgent,
+			SessionService:  config.SessionService,
+			ArtifactService: config.ArtifactService,
+			Criteria:        l.config.criteria,
+		}, set)
+		if err != nil {
+			return fmt.Errorf("failed to run eval set %q: %w", path, err)
+		}
+		l.printResult(result)
+		failed += result.Failed()
+		results = append(results, result)
+	}
+
+	if l.config.outputFile != "" {
+		if err := l.export(results); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d eval cases failed", failed)
+	}
+	return nil
+}
+
+func (l *evalLauncher) printResult(result *eval.SetResult) {
+	fmt.Printf("Eval set %s: %d passed, %d failed\n", result.EvalSetID, len(result.CaseResults)-result.Failed(), result.Failed())
+	for _, c := range result.CaseResults {
+		fmt.Printf("  %s: %s\n", c.EvalID, c.FinalStatus)
+		for _, m := range c.MetricResults {
+			fmt.Printf("    %s: %.3f (threshold %.3f) %s\n", m.MetricName, m.Score, m.Threshold, m.Status)
+		}
+		if !l.config.printDetails {
+			continue
+		}
+		for i, inv := range c.InvocationResults {
+			fmt.Printf("    invocation #%d\n", i+1)
+			for _, m := range inv.MetricResults {
+				fmt.Printf("      %s: %.3f %s\n", m.MetricName, m.Score, m.Status)
+			}
+		}
+	}
+}
+
+func (l *evalLauncher) export(results []*eval.SetResult) error {
+	var data []byte
+	var err error
+	if l.config.outputFormatted {
+		data, err = json.MarshalIndent(results, "", "  ")
+	} else {
+		data, err = json.Marshal(results)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode eval results: %w", err)
+	}
+	if err := os.WriteFile(l.config.outputFile, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write eval results: %w", err)
+	}
+	return nil
+}
+
+// Parse implements launcher.SubLauncher. After parsing eval-specific
+// arguments returns remaining un-parsed arguments
+func (l *evalLauncher) Parse(args []string) ([]string, error) {
+	err := l.flags.Parse(args)
+	if err != nil || !l.flags.Parsed() {
+		return nil, fmt.Errorf("failed to parse flags: %v", err)
+	}
+	l.config.evalSetPaths = nil
+	for _, p := range strings.Split(l.config.evalSetFiles, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			l.config.evalSetPaths = append(l.config.evalSetPaths, p)
+		}
+	}
+	if len(l.config.evalSetPaths) == 0 {
+		return nil, fmt.Errorf("at least one eval set file must be provided with -evalset")
+	}
+	if l.config.configFile != "" {
+		criteria, err := loadCriteria(l.config.configFile)
+		if err != nil {
+			return nil, err
+		}
+		l.config.criteria = criteria
+	}
+	return l.flags.Args(), nil
+}
+
+func loadCriteria(path string) (map[string]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read eval config %q: %w", path, err)
+	}
+	var cfg struct {
+		Criteria map[string]float64 `json:"criteria"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse eval config %q: %w", path, err)
+	}
+	return cfg.Criteria, nil
+}
+
+// Keyword implements launcher.SubLauncher. Returns the command-line keyword for this launcher.
+func (l *evalLauncher) Keyword() string {
+	return "eval"
+}
+
+// CommandLineSyntax implements launcher.SubLauncher. Returns the command-line syntax for the eval launcher.
+func (l *evalLauncher) CommandLineSyntax() string {
+	return util.FormatFlagUsage(l.flags)
+}
+
+// SimpleDescription implements launcher.SubLauncher. Returns a simple description of the eval launcher.
+func (l *evalLauncher) SimpleDescription() string {
+	return "runs eval sets against the agent and reports the scores."
+}
+
+// Execute implements launcher.Launcher. It parses arguments and runs the launcher.
+func (l *evalLauncher) Execute(ctx context.Context, config *launcher.Config, args []string) error {
+	remainingArgs, err := l.Parse(args)
+	if err != nil {
+		return fmt.Errorf("cannot parse args: %w", err)
+	}
+	// do not accept additional arguments
+	err = universal.ErrorOnUnparsedArgs(remainingArgs)
+	if err != nil {
+		return fmt.Errorf("cannot parse all the arguments: %w", err)
+	}
+	return l.Run(ctx, config)
+}
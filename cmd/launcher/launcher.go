@@ -19,9 +19,11 @@ import (
 	"context"
 
 	"github.com/a2aproject/a2a-go/a2asrv"
+	"google.golang.org/adk"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
 	"google.golang.org/adk/memory"
+	"google.golang.org/adk/server/adkrest/authz"
 	"google.golang.org/adk/session"
 )
 
@@ -58,4 +60,23 @@ type Config struct {
 	MemoryService   memory.Service
 	AgentLoader     agent.Loader
 	A2AOptions      []a2asrv.RequestHandlerOption
+	// APIAuthorizer authorizes the requests to the REST API, distinguishing
+	// the user operations from the admin ones, see package authz.
+	// Optional: if nil, all the requests are allowed.
+	APIAuthorizer authz.Authorizer
+}
+
+// NewConfig returns the config of the launchers of the agents of the loader
+// with the services of the deployment config, see adk.Load.
+func NewConfig(ctx context.Context, cfg *adk.Config, loader agent.Loader) (*Config, error) {
+	services, err := cfg.NewServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		SessionService:  services.Sessions,
+		ArtifactService: services.Artifacts,
+		MemoryService:   services.Memory,
+		AgentLoader:     loader,
+	}, nil
 }
@@ -20,7 +20,6 @@ import (
 	"fmt"
 	"net/url"
 
-	a2acore "github.com/a2aproject/a2a-go/a2a"
 	"github.com/a2aproject/a2a-go/a2asrv"
 	"github.com/gorilla/mux"
 	"google.golang.org/adk/cmd/launcher"
@@ -83,31 +82,18 @@ func (a *a2aLauncher) SetupSubrouters(router *mux.Router, config *launcher.Confi
 		return err
 	}
 
-	rootAgent := config.AgentLoader.RootAgent()
-	agentCard := &a2acore.AgentCard{
-		Name:                              rootAgent.Name(),
-		Description:                       rootAgent.Description(),
-		DefaultInputModes:                 []string{"text/plain"},
-		DefaultOutputModes:                []string{"text/plain"},
-		URL:                               publicURL,
-		PreferredTransport:                a2acore.TransportProtocolJSONRPC,
-		Skills:                            adka2a.BuildAgentSkills(rootAgent),
-		Capabilities:                      a2acore.AgentCapabilities{Streaming: true},
-		SupportsAuthenticatedExtendedCard: false,
-	}
+	agent := config.AgentLoader.RootAgent()
+	agentCard := adka2a.NewAgentCard(agent, publicURL)
 	router.Handle(a2asrv.WellKnownAgentCardPath, a2asrv.NewStaticAgentCardHandler(agentCard))
 
-	agent := config.AgentLoader.RootAgent()
-	executor := adka2a.NewExecutor(adka2a.ExecutorConfig{
+	router.Handle(apiPath, adka2a.NewJSONRPCHandler(adka2a.ExecutorConfig{
 		RunnerConfig: runner.Config{
 			AppName:         agent.Name(),
 			Agent:           agent,
 			SessionService:  config.SessionService,
 			ArtifactService: config.ArtifactService,
 		},
-	})
-	reqHandler := a2asrv.NewHandler(executor, config.A2AOptions...)
-	router.Handle(apiPath, a2asrv.NewJSONRPCHandler(reqHandler))
+	}, config.A2AOptions...))
 	return nil
 }
 
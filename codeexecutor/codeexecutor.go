@@ -0,0 +1,54 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codeexecutor defines the executors of the code written by the
+// models, see llmagent.Config.CodeExecutor.
+package codeexecutor
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genai"
+)
+
+// Executor executes the code of the ExecutableCode parts of the model
+// responses. The result is sent back to the model, which continues with the
+// output of the code.
+type Executor interface {
+	// Execute runs the code and returns its outcome and output. The
+	// failures of the code itself are reported in the result; an error
+	// reports that the code could not be run.
+	Execute(ctx context.Context, code *genai.ExecutableCode) (*genai.CodeExecutionResult, error)
+}
+
+// BuiltIn returns the executor of the code execution tool of the model, e.g.
+// the Gemini code execution: the code is run by the model, which returns it
+// together with its result, so the executor only enables the tool in the
+// model requests.
+func BuiltIn() Executor {
+	return builtIn{}
+}
+
+// IsBuiltIn reports whether the executor is the one returned by [BuiltIn].
+func IsBuiltIn(e Executor) bool {
+	_, ok := e.(builtIn)
+	return ok
+}
+
+type builtIn struct{}
+
+func (builtIn) Execute(ctx context.Context, code *genai.ExecutableCode) (*genai.CodeExecutionResult, error) {
+	return nil, errors.New("the code is executed by the model")
+}
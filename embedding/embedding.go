@@ -0,0 +1,49 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package embedding defines the Embedder interface computing the embeddings
+// of texts, shared by the features comparing texts by meaning, e.g. the
+// memory service and the semantic cache, and its implementations backed by
+// the Gemini API and Vertex AI.
+package embedding
+
+import (
+	"context"
+	"math"
+)
+
+// Embedder computes the embeddings of texts. The implementations must be
+// safe for concurrent use.
+type Embedder interface {
+	// Embed returns the embeddings of the texts, in the same order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Cosine returns the cosine similarity of two embeddings, between -1 and 1,
+// or 0 if they have different dimensions or one of them is zero.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedding_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/embedding"
+)
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		a, b []float32
+		want float64
+	}{
+		{a: []float32{1, 0}, b: []float32{2, 0}, want: 1},
+		{a: []float32{1, 0}, b: []float32{0, 1}, want: 0},
+		{a: []float32{1, 1}, b: []float32{-1, -1}, want: -1},
+		{a: []float32{1, 0}, b: []float32{1, 0, 0}, want: 0},
+		{a: []float32{0, 0}, b: []float32{1, 0}, want: 0},
+	}
+	for _, tt := range tests {
+		if got := embedding.Cosine(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Cosine(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNewGemini(t *testing.T) {
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/models/gemini-embedding-001:batchEmbedContents") {
+			http.Error(w, "unexpected path "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Requests []struct {
+				TaskType string `json:"taskType"`
+				Content  struct {
+					Parts []struct {
+						Text string `json:"text"`
+					} `json:"parts"`
+				} `json:"content"`
+			} `json:"requests"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		batches = append(batches, len(body.Requests))
+		var embeddings []map[string]any
+		for _, req := range body.Requests {
+			if req.TaskType != embedding.DefaultTaskType {
+				http.Error(w, "unexpected task type "+req.TaskType, http.StatusBadRequest)
+				return
+			}
+			embeddings = append(embeddings, map[string]any{"values": []float32{float32(len(req.Content.Parts[0].Text))}})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"embeddings": embeddings})
+	}))
+	defer srv.Close()
+
+	e, err := embedding.NewGemini(t.Context(), embedding.GeminiConfig{APIKey: "key", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	var want [][]float32
+	for i := range 150 {
+		text := fmt.Sprint(i)
+		texts = append(texts, text)
+		want = append(want, []float32{float32(len(text))})
+	}
+	got, err := e.Embed(t.Context(), texts)
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Embed() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{100, 50}, batches); diff != "" {
+		t.Errorf("batches mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/genai"
+)
+
+// Default values of the [GeminiConfig] and [VertexAIConfig] fields.
+const (
+	DefaultModel    = "gemini-embedding-001"
+	DefaultTaskType = "SEMANTIC_SIMILARITY"
+)
+
+// maxBatch is the maximum number of texts embedded by a request.
+const maxBatch = 100
+
+// GeminiConfig is used to create an Embedder backed by the Gemini API.
+type GeminiConfig struct {
+	// Model is the embedding model.
+	// Optional: defaults to [DefaultModel].
+	Model string
+	// APIKey is the Gemini API key.
+	// Optional: defaults to the GOOGLE_API_KEY or GEMINI_API_KEY
+	// environment variable.
+	APIKey string
+	// TaskType is the intended use of the embeddings, e.g.
+	// "RETRIEVAL_QUERY".
+	// Optional: defaults to [DefaultTaskType].
+	TaskType string
+	// Dimensions is the size of the embeddings, for the models supporting
+	// reduced dimensions.
+	// Optional: if zero, the default size of the model.
+	Dimensions int32
+	// BaseURL is the endpoint of the API, e.g. of a proxy.
+	// Optional.
+	BaseURL string
+	// HTTPClient sends the requests.
+	// Optional: defaults to the client of the genai SDK.
+	HTTPClient *http.Client
+}
+
+// NewGemini returns an Embedder backed by the Gemini API.
+func NewGemini(ctx context.Context, cfg GeminiConfig) (Embedder, error) {
+	return newGenAI(ctx, &genai.ClientConfig{
+		Backend:     genai.BackendGeminiAPI,
+		APIKey:      cfg.APIKey,
+		HTTPClient:  cfg.HTTPClient,
+		HTTPOptions: genai.HTTPOptions{BaseURL: cfg.BaseURL},
+	}, cfg.Model, cfg.TaskType, cfg.Dimensions)
+}
+
+// VertexAIConfig is used to create an Embedder backed by Vertex AI.
+type VertexAIConfig struct {
+	// Model is the embedding model, e.g. "text-embedding-005".
+	// Optional: defaults to [DefaultModel].
+	Model string
+	// Project and Location are the Google Cloud project and location of the
+	// model. The requests are authenticated with the application default
+	// credentials.
+	// Optional: default to the GOOGLE_CLOUD_PROJECT and
+	// GOOGLE_CLOUD_LOCATION environment variables.
+	Project  string
+	Location string
+	// TaskType is the intended use of the embeddings, e.g.
+	// "RETRIEVAL_QUERY".
+	// Optional: defaults to [DefaultTaskType].
+	TaskType string
+	// Dimensions is the size of the embeddings, for the models supporting
+	// reduced dimensions.
+	// Optional: if zero, the default size of the model.
+	Dimensions int32
+	// HTTPClient sends the requests.
+	// Optional: defaults to the client of the genai SDK.
+	HTTPClient *http.Client
+}
+
+// NewVertexAI returns an Embedder backed by Vertex AI.
+func NewVertexAI(ctx context.Context, cfg VertexAIConfig) (Embedder, error) {
+	return newGenAI(ctx, &genai.ClientConfig{
+		Backend:    genai.BackendVertexAI,
+		Project:    cfg.Project,
+		Location:   cfg.Location,
+		HTTPClient: cfg.HTTPClient,
+	}, cfg.Model, cfg.TaskType, cfg.Dimensions)
+}
+
+func newGenAI(ctx context.Context, cc *genai.ClientConfig, modelName, taskType string, dimensions int32) (Embedder, error) {
+	client, err := genai.NewClient(ctx, cc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the genai client: %w", err)
+	}
+	config := &genai.EmbedContentConfig{TaskType: taskType}
+	if config.TaskType == "" {
+		config.TaskType = DefaultTaskType
+	}
+	if dimensions > 0 {
+		config.OutputDimensionality = &dimensions
+	}
+	if modelName == "" {
+		modelName = DefaultModel
+	}
+	return &genaiEmbedder{client: client, model: modelName, config: config}, nil
+}
+
+type genaiEmbedder struct {
+	client *genai.Client
+	model  string
+	config *genai.EmbedContentConfig
+}
+
+func (e *genaiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxBatch {
+		batch := texts[start:min(start+maxBatch, len(texts))]
+		contents := make([]*genai.Content, len(batch))
+		for i, text := range batch {
+			contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+		}
+		resp, err := e.client.Models.EmbedContent(ctx, e.model, contents, e.config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed the texts: %w", err)
+		}
+		if len(resp.Embeddings) != len(batch) {
+			return nil, fmt.Errorf("failed to embed the texts: got %d embeddings for %d texts", len(resp.Embeddings), len(batch))
+		}
+		for _, emb := range resp.Embeddings {
+			embeddings = append(embeddings, emb.Values)
+		}
+	}
+	return embeddings, nil
+}
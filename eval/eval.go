@@ -0,0 +1,307 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// Config is used to run an eval set.
+type Config struct {
+	// AppName is used for the sessions created for eval cases, unless an eval
+	// case overrides it in its SessionInput.
+	AppName string
+	// Agent under evaluation.
+	Agent agent.Agent
+
+	// SessionService is used to create the sessions of eval cases.
+	// Optional: if not set, an in-memory service is used.
+	SessionService session.Service
+	// optional
+	ArtifactService artifact.Service
+
+	// Criteria maps metric names to their passing thresholds. Only the
+	// metrics listed in the criteria are evaluated.
+	// Optional: if empty, DefaultCriteria are used.
+	Criteria map[string]float64
+	// Evaluators are custom evaluators, e.g. created with
+	// NewRubricEvaluator. They are used for the criteria matching their
+	// names, in addition to the built-in evaluators.
+	// Optional.
+	Evaluators []Evaluator
+
+	// SandboxTools makes the evals reproducible and free of side effects:
+	// the tools are never run, their calls are served from the matching
+	// ToolFixtures or EvalCase.ToolFixtures instead. The calls matching no
+	// fixture fail and are reported in CaseResult.UnmatchedToolCalls. The
+	// agent transfers aren't sandboxed.
+	SandboxTools bool
+	// ToolFixtures are the recorded tool results shared by all eval cases in
+	// the sandbox mode. The fixtures of an eval case are matched first.
+	// Optional.
+	ToolFixtures []*ToolFixture
+}
+
+// Status is the outcome of an evaluation.
+type Status string
+
+const (
+	StatusPassed       Status = "PASSED"
+	StatusFailed       Status = "FAILED"
+	StatusNotEvaluated Status = "NOT_EVALUATED"
+)
+
+// MetricResult is the score of a single metric.
+type MetricResult struct {
+	MetricName string  `json:"metric_name"`
+	Score      float64 `json:"score"`
+	Threshold  float64 `json:"threshold"`
+	Status     Status  `json:"eval_status"`
+}
+
+// InvocationResult holds the scores of one invocation of an eval case.
+type InvocationResult struct {
+	ActualInvocation   *Invocation     `json:"actual_invocation"`
+	ExpectedInvocation *Invocation     `json:"expected_invocation"`
+	MetricResults      []*MetricResult `json:"eval_metric_results"`
+}
+
+// CaseResult is the result of a single eval case.
+type CaseResult struct {
+	EvalSetID         string              `json:"eval_set_id"`
+	EvalID            string              `json:"eval_id"`
+	SessionID         string              `json:"session_id"`
+	FinalStatus       Status              `json:"final_eval_status"`
+	MetricResults     []*MetricResult     `json:"overall_eval_metric_results"`
+	InvocationResults []*InvocationResult `json:"eval_metric_result_per_invocation"`
+	// UnmatchedToolCalls are the tool calls which no fixture matched in the
+	// sandbox mode, see Config.SandboxTools.
+	UnmatchedToolCalls []*UnmatchedToolCall `json:"unmatched_tool_calls,omitempty"`
+}
+
+// SetResult is the result of an eval set.
+type SetResult struct {
+	EvalSetID   string        `json:"eval_set_id"`
+	CaseResults []*CaseResult `json:"eval_case_results"`
+}
+
+// Failed returns the number of failed eval cases.
+func (r *SetResult) Failed() int {
+	n := 0
+	for _, c := range r.CaseResults {
+		if c.FinalStatus == StatusFailed {
+			n++
+		}
+	}
+	return n
+}
+
+// Run replays all eval cases of the set against the configured agent and
+// scores them.
+func Run(ctx context.Context, cfg Config, set *EvalSet) (*SetResult, error) {
+	if cfg.Agent == nil {
+		return nil, fmt.Errorf("agent is required")
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = cfg.Agent.Name()
+	}
+	if cfg.SessionService == nil {
+		cfg.SessionService = session.InMemoryService()
+	}
+
+	result := &SetResult{EvalSetID: set.EvalSetID}
+	for _, c := range set.EvalCases {
+		caseResult, err := runCase(ctx, cfg, c)
+		if err != nil {
+			return nil, fmt.Errorf("eval case %q failed: %w", c.EvalID, err)
+		}
+		caseResult.EvalSetID = set.EvalSetID
+		result.CaseResults = append(result.CaseResults, caseResult)
+	}
+	return result, nil
+}
+
+func runCase(ctx context.Context, cfg Config, c *EvalCase) (*CaseResult, error) {
+	appName, userID := cfg.AppName, "eval_user"
+	var state map[string]any
+	if in := c.SessionInput; in != nil {
+		if in.AppName != "" {
+			appName = in.AppName
+		}
+		if in.UserID != "" {
+			userID = in.UserID
+		}
+		state = in.State
+	}
+
+	var sandbox *toolSandbox
+	var plugins []*plugin.Plugin
+	if cfg.SandboxTools {
+		sandbox = newToolSandbox(c.ToolFixtures, cfg.ToolFixtures)
+		p, err := sandbox.plugin()
+		if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, p)
+	}
+
+	r, err := runner.New(runner.Config{
+		AppName:         appName,
+		Agent:           cfg.Agent,
+		SessionService:  cfg.SessionService,
+		ArtifactService: cfg.ArtifactService,
+		Plugins:         plugins,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runner: %w", err)
+	}
+
+	resp, err := cfg.SessionService.Create(ctx, &session.CreateRequest{
+		AppName: appName,
+		UserID:  userID,
+		State:   state,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	sessionID := resp.Session.ID()
+
+	var actual []*Invocation
+	for _, expected := range c.Conversation {
+		inv, err := infer(ctx, r, userID, sessionID, expected.UserContent)
+		if err != nil {
+			return nil, err
+		}
+		actual = append(actual, inv)
+	}
+
+	caseResult, err := Evaluate(ctx, cfg, actual, c.Conversation)
+	if err != nil {
+		return nil, err
+	}
+	caseResult.EvalID = c.EvalID
+	caseResult.SessionID = sessionID
+	if sandbox != nil {
+		caseResult.UnmatchedToolCalls = sandbox.unmatchedCalls()
+	}
+	return caseResult, nil
+}
+
+// infer sends the user content to the agent and collects the resulting invocation.
+func infer(ctx context.Context, r *runner.Runner, userID, sessionID string, userContent *genai.Content) (*Invocation, error) {
+	inv := &Invocation{
+		UserContent:      userContent,
+		IntermediateData: &IntermediateData{},
+	}
+	for ev, err := range r.Run(ctx, userID, sessionID, userContent, agent.RunConfig{}) {
+		if err != nil {
+			return nil, fmt.Errorf("agent run failed: %w", err)
+		}
+		if ev != nil {
+			inv.addEvent(ev)
+		}
+	}
+	return inv, nil
+}
+
+// Evaluate scores the actual invocations against the expected ones with the
+// evaluators of all metrics from the criteria. Only cfg.Criteria and
+// cfg.Evaluators are used.
+//
+// The actual invocations can come from a recorded session, see
+// [InvocationsFromEvents].
+func Evaluate(ctx context.Context, cfg Config, actual, expected []*Invocation) (*CaseResult, error) {
+	if len(actual) != len(expected) {
+		return nil, fmt.Errorf("got %d invocations, want %d", len(actual), len(expected))
+	}
+	criteria := cfg.Criteria
+	if len(criteria) == 0 {
+		criteria = DefaultCriteria
+	}
+	evaluators := map[string]Evaluator{
+		MetricToolTrajectoryAvgScore: NewToolTrajectoryEvaluator(),
+		MetricResponseMatchScore:     NewResponseMatchEvaluator(),
+	}
+	for _, e := range cfg.Evaluators {
+		evaluators[e.Name()] = e
+	}
+
+	names := make([]string, 0, len(criteria))
+	for name := range criteria {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := &CaseResult{FinalStatus: StatusNotEvaluated}
+	for i := range expected {
+		result.InvocationResults = append(result.InvocationResults, &InvocationResult{
+			ActualInvocation:   actual[i],
+			ExpectedInvocation: expected[i],
+		})
+	}
+
+	for _, name := range names {
+		e, ok := evaluators[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown metric %q", name)
+		}
+		threshold := criteria[name]
+
+		var total float64
+		for _, ir := range result.InvocationResults {
+			s, err := e.Evaluate(ctx, ir.ActualInvocation, ir.ExpectedInvocation)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate metric %q: %w", name, err)
+			}
+			ir.MetricResults = append(ir.MetricResults, newMetricResult(name, s, threshold))
+			total += s
+		}
+		overall := &MetricResult{MetricName: name, Threshold: threshold, Status: StatusNotEvaluated}
+		if n := len(result.InvocationResults); n > 0 {
+			overall = newMetricResult(name, total/float64(n), threshold)
+		}
+		result.MetricResults = append(result.MetricResults, overall)
+
+		switch {
+		case overall.Status == StatusFailed:
+			result.FinalStatus = StatusFailed
+		case overall.Status == StatusPassed && result.FinalStatus == StatusNotEvaluated:
+			result.FinalStatus = StatusPassed
+		}
+	}
+	return result, nil
+}
+
+func newMetricResult(name string, score, threshold float64) *MetricResult {
+	status := StatusFailed
+	if score >= threshold {
+		status = StatusPassed
+	}
+	return &MetricResult{
+		MetricName: name,
+		Score:      score,
+		Threshold:  threshold,
+		Status:     status,
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+const testEvalSet = `{
+  "eval_set_id": "weather",
+  "eval_cases": [
+    {
+      "eval_id": "case_1",
+      "conversation": [
+        {
+          "invocation_id": "inv-1",
+          "user_content": {"parts": [{"text": "What is the weather in Paris?"}], "role": "user"},
+          "final_response": {"parts": [{"text": "It is sunny in Paris."}], "role": "model"},
+          "intermediate_data": {
+            "tool_uses": [{"name": "get_weather", "args": {"city": "Paris"}}],
+            "intermediate_responses": []
+          }
+        }
+      ],
+      "session_input": {"app_name": "weather_app", "user_id": "u1", "state": {}}
+    }
+  ]
+}`
+
+func TestLoadEvalSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weather.evalset.json")
+	if err := os.WriteFile(path, []byte(testEvalSet), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := LoadEvalSet(path)
+	if err != nil {
+		t.Fatalf("LoadEvalSet() error = %v", err)
+	}
+	if set.EvalSetID != "weather" || len(set.EvalCases) != 1 {
+		t.Fatalf("LoadEvalSet() = %+v, want one case in eval set 'weather'", set)
+	}
+	inv := set.EvalCases[0].Conversation[0]
+	if got := contentText(inv.UserContent); got != "What is the weather in Paris?" {
+		t.Errorf("user content = %q", got)
+	}
+	if uses := inv.toolUses(); len(uses) != 1 || uses[0].Name != "get_weather" || uses[0].Args["city"] != "Paris" {
+		t.Errorf("tool uses = %+v", uses)
+	}
+	if got := set.EvalCases[0].SessionInput.AppName; got != "weather_app" {
+		t.Errorf("session input app name = %q", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weather.evalset.json")
+	if err := os.WriteFile(path, []byte(testEvalSet), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	set, err := LoadEvalSet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		City string `json:"city"`
+	}
+	weatherTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather in a city",
+	}, func(ctx tool.Context, a args) (map[string]any, error) {
+		return map[string]any{"weather": "sunny"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		responses  []*genai.Content
+		wantStatus Status
+	}{
+		{
+			name: "passed",
+			responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel),
+				genai.NewContentFromText("It is sunny in Paris.", genai.RoleModel),
+			},
+			wantStatus: StatusPassed,
+		},
+		{
+			name: "wrong tool args",
+			responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "London"}, genai.RoleModel),
+				genai.NewContentFromText("It is sunny in Paris.", genai.RoleModel),
+			},
+			wantStatus: StatusFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := llmagent.New(llmagent.Config{
+				Name:  "weather_agent",
+				Model: &testutil.MockModel{Responses: tt.responses},
+				Tools: []tool.Tool{weatherTool},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Run(t.Context(), Config{Agent: a}, set)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if len(result.CaseResults) != 1 {
+				t.Fatalf("Run() returned %d case results, want 1", len(result.CaseResults))
+			}
+			if got := result.CaseResults[0].FinalStatus; got != tt.wantStatus {
+				t.Errorf("Run() final status = %v, want %v; metrics: %+v", got, tt.wantStatus, result.CaseResults[0].MetricResults)
+			}
+		})
+	}
+}
+
+func TestRouge1(t *testing.T) {
+	tests := []struct {
+		reference, candidate string
+		want                 float64
+	}{
+		{"It is sunny in Paris.", "it is sunny in paris", 1},
+		{"the cat sat", "the dog sat", 2.0 / 3},
+		{"hello", "goodbye", 0},
+		{"", "", 1},
+	}
+	for _, tt := range tests {
+		if got := rouge1(tt.reference, tt.candidate); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("rouge1(%q, %q) = %v, want %v", tt.reference, tt.candidate, got, tt.want)
+		}
+	}
+}
+
+func TestRubricEvaluator(t *testing.T) {
+	judge := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromText(`[{"rubric_id": 1, "verdict": "yes"}, {"rubric_id": 2, "verdict": "no"}]`, genai.RoleModel),
+		genai.NewContentFromText("```json\n[{\"rubric_id\": 1, \"verdict\": \"yes\"}, {\"rubric_id\": 2, \"verdict\": \"yes\"}]\n```", genai.RoleModel),
+	}}
+	e, err := NewRubricEvaluator(RubricConfig{
+		Model:      judge,
+		Rubrics:    []string{"The response mentions Paris.", "The response mentions the temperature."},
+		NumSamples: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Name(); got != MetricRubricBasedResponseQuality {
+		t.Errorf("Name() = %q, want %q", got, MetricRubricBasedResponseQuality)
+	}
+
+	inv := &Invocation{
+		UserContent:   genai.NewContentFromText("What is the weather in Paris?", genai.RoleUser),
+		FinalResponse: genai.NewContentFromText("It is sunny in Paris.", genai.RoleModel),
+	}
+	got, err := e.Evaluate(t.Context(), inv, &Invocation{})
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if want := 0.75; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Evaluate() = %v, want %v", got, want)
+	}
+	if len(judge.Requests) != 2 {
+		t.Errorf("judge was called %d times, want 2", len(judge.Requests))
+	}
+}
+
+func TestInvocationsFromEvents(t *testing.T) {
+	event := func(author string, content *genai.Content) *session.Event {
+		ev := session.NewEvent("inv")
+		ev.Author = author
+		ev.LLMResponse = model.LLMResponse{Content: content}
+		return ev
+	}
+	call := genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel)
+	events := []*session.Event{
+		event("agent", genai.NewContentFromText("Welcome!", genai.RoleModel)),
+		event("user", genai.NewContentFromText("Weather in Paris?", genai.RoleUser)),
+		event("agent", call),
+		event("user", genai.NewContentFromFunctionResponse("get_weather", map[string]any{"weather": "sunny"}, genai.RoleUser)),
+		event("agent", genai.NewContentFromText("Sunny.", genai.RoleModel)),
+		event("user", genai.NewContentFromText("Thanks", genai.RoleUser)),
+		event("agent", genai.NewContentFromText("You're welcome.", genai.RoleModel)),
+	}
+
+	got := InvocationsFromEvents(slices.Values(events))
+	want := []*Invocation{
+		{
+			InvocationID:     "inv",
+			UserContent:      events[1].Content,
+			FinalResponse:    events[4].Content,
+			IntermediateData: &IntermediateData{ToolUses: []*genai.FunctionCall{call.Parts[0].FunctionCall}},
+		},
+		{
+			InvocationID:     "inv",
+			UserContent:      events[5].Content,
+			FinalResponse:    events[6].Content,
+			IntermediateData: &IntermediateData{},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmp.FilterPath(func(p cmp.Path) bool {
+		return p.Last().String() == ".CreationTimestamp"
+	}, cmp.Ignore())); diff != "" {
+		t.Errorf("InvocationsFromEvents() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+type constEvaluator float64
+
+func (e constEvaluator) Name() string { return "const" }
+
+func (e constEvaluator) Evaluate(context.Context, *Invocation, *Invocation) (float64, error) {
+	return float64(e), nil
+}
+
+func TestEvaluate_CustomEvaluator(t *testing.T) {
+	invs := []*Invocation{{UserContent: genai.NewContentFromText("hi", genai.RoleUser)}}
+	tests := []struct {
+		name       string
+		criteria   map[string]float64
+		wantStatus Status
+		wantErr    bool
+	}{
+		{name: "passed", criteria: map[string]float64{"const": 0.5}, wantStatus: StatusPassed},
+		{name: "failed", criteria: map[string]float64{"const": 0.9}, wantStatus: StatusFailed},
+		{name: "unknown metric", criteria: map[string]float64{"unknown": 0.5}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Evaluate(t.Context(), Config{
+				Criteria:   tt.criteria,
+				Evaluators: []Evaluator{constEvaluator(0.6)},
+			}, invs, invs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.FinalStatus != tt.wantStatus {
+				t.Errorf("Evaluate() status = %v, want %v", got.FinalStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRun_SandboxTools(t *testing.T) {
+	weatherTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather in a city",
+	}, func(ctx tool.Context, a map[string]any) (map[string]any, error) {
+		t.Errorf("get_weather called with %v in the sandbox mode", a)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris", "days": 1}, genai.RoleModel),
+		genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "London"}, genai.RoleModel),
+		genai.NewContentFromText("It is sunny in Paris.", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "weather_agent",
+		Model: llm,
+		Tools: []tool.Tool{weatherTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := &EvalSet{
+		EvalSetID: "weather",
+		EvalCases: []*EvalCase{{
+			EvalID: "case_1",
+			Conversation: []*Invocation{{
+				UserContent:   genai.NewContentFromText("What is the weather in Paris?", genai.RoleUser),
+				FinalResponse: genai.NewContentFromText("It is sunny in Paris.", genai.RoleModel),
+			}},
+			ToolFixtures: []*ToolFixture{{
+				ToolName: "get_weather",
+				Args:     map[string]any{"city": "Paris", "days": 1.0},
+				Response: map[string]any{"weather": "sunny"},
+			}},
+		}},
+	}
+
+	result, err := Run(t.Context(), Config{
+		Agent:        a,
+		Criteria:     map[string]float64{MetricResponseMatchScore: 0.8},
+		SandboxTools: true,
+	}, set)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	got := result.CaseResults[0]
+	if got.FinalStatus != StatusPassed {
+		t.Errorf("Run() final status = %v, want %v", got.FinalStatus, StatusPassed)
+	}
+	wantUnmatched := []*UnmatchedToolCall{{ToolName: "get_weather", Args: map[string]any{"city": "London"}}}
+	if diff := cmp.Diff(wantUnmatched, got.UnmatchedToolCalls); diff != "" {
+		t.Errorf("Run() unmatched tool calls mismatch (-want +got):\n%s", diff)
+	}
+
+	// The second request holds the response of the fixture.
+	contents := llm.Requests[1].Contents
+	resp := contents[len(contents)-1].Parts[0].FunctionResponse
+	if diff := cmp.Diff(map[string]any{"weather": "sunny"}, resp.Response); diff != "" {
+		t.Errorf("function response mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eval allows to evaluate agents against eval sets.
+//
+// An eval set is a collection of eval cases. Each eval case is a
+// conversation (a list of user turns with the expected tool uses and final
+// responses) which is replayed against an agent using the [runner.Runner].
+// The actual invocations are then scored by the evaluators of the configured
+// metrics: the built-in tool trajectory and response match evaluators, or
+// custom ones like the LLM-as-judge rubric evaluator. Invocations recorded in
+// existing sessions can be scored as well, see [InvocationsFromEvents] and
+// [Evaluate].
+//
+// The eval set file format is the same as the one used by adk-python, so the
+// eval sets can be shared between the implementations.
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"google.golang.org/genai"
+)
+
+// EvalSet is a collection of eval cases.
+type EvalSet struct {
+	EvalSetID         string      `json:"eval_set_id"`
+	Name              string      `json:"name,omitempty"`
+	Description       string      `json:"description,omitempty"`
+	EvalCases         []*EvalCase `json:"eval_cases"`
+	CreationTimestamp float64     `json:"creation_timestamp,omitempty"`
+}
+
+// EvalCase is a single conversation which is replayed against an agent.
+type EvalCase struct {
+	EvalID string `json:"eval_id"`
+	// Conversation is the list of the expected invocations. The user content
+	// of each invocation is sent to the agent in order.
+	Conversation []*Invocation `json:"conversation"`
+	// SessionInput is used to create the session the conversation runs in.
+	// Optional.
+	SessionInput *SessionInput `json:"session_input,omitempty"`
+	// ToolFixtures are the recorded tool results of the case, used in the
+	// sandbox mode, see Config.SandboxTools.
+	// Optional.
+	ToolFixtures      []*ToolFixture `json:"tool_fixtures,omitempty"`
+	CreationTimestamp float64        `json:"creation_timestamp,omitempty"`
+}
+
+// Invocation is a single turn of a conversation: the user content, the tools
+// used by the agent to handle it and the final response.
+type Invocation struct {
+	InvocationID      string            `json:"invocation_id,omitempty"`
+	UserContent       *genai.Content    `json:"user_content"`
+	FinalResponse     *genai.Content    `json:"final_response,omitempty"`
+	IntermediateData  *IntermediateData `json:"intermediate_data,omitempty"`
+	CreationTimestamp float64           `json:"creation_timestamp,omitempty"`
+}
+
+// IntermediateData holds the data produced by an agent before the final
+// response.
+type IntermediateData struct {
+	// ToolUses are the function calls made by the agent, in order.
+	ToolUses []*genai.FunctionCall `json:"tool_uses,omitempty"`
+}
+
+// SessionInput contains the values used to initialize the session of an eval case.
+type SessionInput struct {
+	AppName string         `json:"app_name,omitempty"`
+	UserID  string         `json:"user_id,omitempty"`
+	State   map[string]any `json:"state,omitempty"`
+}
+
+// LoadEvalSet reads an eval set from the given JSON file.
+func LoadEvalSet(path string) (*EvalSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read eval set %q: %w", path, err)
+	}
+	var set EvalSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse eval set %q: %w", path, err)
+	}
+	if set.EvalSetID == "" {
+		return nil, fmt.Errorf("eval set %q has no eval_set_id", path)
+	}
+	return &set, nil
+}
+
+func (inv *Invocation) toolUses() []*genai.FunctionCall {
+	if inv == nil || inv.IntermediateData == nil {
+		return nil
+	}
+	return inv.IntermediateData.ToolUses
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"iter"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// InvocationsFromEvents converts recorded session events into invocations
+// which can be scored with [Evaluate]. Every user message starts a new
+// invocation, the events which follow it up to the next user message are the
+// agent's handling of that message. Events before the first user message are
+// ignored.
+//
+// For example, use session.Events().All() to evaluate a stored session.
+func InvocationsFromEvents(events iter.Seq[*session.Event]) []*Invocation {
+	var invocations []*Invocation
+	var cur *Invocation
+	for ev := range events {
+		if ev == nil {
+			continue
+		}
+		if ev.Author == genai.RoleUser {
+			if ev.Content == nil || hasFunctionResponse(ev.Content) {
+				continue
+			}
+			cur = &Invocation{
+				InvocationID:      ev.InvocationID,
+				UserContent:       ev.Content,
+				IntermediateData:  &IntermediateData{},
+				CreationTimestamp: float64(ev.Timestamp.UnixNano()) / 1e9,
+			}
+			invocations = append(invocations, cur)
+			continue
+		}
+		if cur != nil {
+			cur.addEvent(ev)
+		}
+	}
+	return invocations
+}
+
+// addEvent records the tool uses and the final response of an agent event.
+func (inv *Invocation) addEvent(ev *session.Event) {
+	if ev.Content == nil {
+		return
+	}
+	if inv.InvocationID == "" {
+		inv.InvocationID = ev.InvocationID
+	}
+	for _, p := range ev.Content.Parts {
+		if p.FunctionCall != nil {
+			inv.IntermediateData.ToolUses = append(inv.IntermediateData.ToolUses, p.FunctionCall)
+		}
+	}
+	if ev.IsFinalResponse() {
+		inv.FinalResponse = ev.Content
+	}
+}
+
+func hasFunctionResponse(c *genai.Content) bool {
+	for _, p := range c.Parts {
+		if p != nil && p.FunctionResponse != nil {
+			return true
+		}
+	}
+	return false
+}
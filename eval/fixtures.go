@@ -0,0 +1,103 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"fmt"
+	"sync"
+
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/tool"
+)
+
+// transferToAgentTool is the tool of the agent transfers, which is never
+// sandboxed so that the agent tree behaves as in production.
+const transferToAgentTool = "transfer_to_agent"
+
+// ToolFixture is a recorded result of a tool call. In the sandbox mode, see
+// Config.SandboxTools, the tool calls are served from the fixtures instead of
+// running the tools.
+type ToolFixture struct {
+	ToolName string `json:"tool_name"`
+	// Args are compared to the arguments of the calls as JSON values, so
+	// numbers match regardless of their Go type. Missing and empty arguments
+	// are equal.
+	Args map[string]any `json:"args,omitempty"`
+	// Response is the result of the call.
+	Response map[string]any `json:"response,omitempty"`
+	// Error, if set, is reported to the model as the error of the call.
+	Error string `json:"error,omitempty"`
+}
+
+// UnmatchedToolCall is a tool call of the sandbox mode which no fixture
+// matches. The model is told that the tool failed.
+type UnmatchedToolCall struct {
+	ToolName string         `json:"tool_name"`
+	Args     map[string]any `json:"args,omitempty"`
+}
+
+// toolSandbox serves the tool calls of an eval case from the fixtures.
+type toolSandbox struct {
+	fixtures []*ToolFixture
+
+	mu        sync.Mutex
+	unmatched []*UnmatchedToolCall
+}
+
+func newToolSandbox(fixtures ...[]*ToolFixture) *toolSandbox {
+	s := &toolSandbox{}
+	for _, f := range fixtures {
+		s.fixtures = append(s.fixtures, f...)
+	}
+	return s
+}
+
+func (s *toolSandbox) plugin() (*plugin.Plugin, error) {
+	return plugin.New(plugin.Config{
+		Name:               "eval_tool_sandbox",
+		BeforeToolCallback: s.beforeTool,
+	})
+}
+
+// beforeTool returns the response of the first fixture matching the call,
+// so that the tool isn't run.
+func (s *toolSandbox) beforeTool(_ tool.Context, t tool.Tool, args map[string]any) (map[string]any, error) {
+	if t.Name() == transferToAgentTool {
+		return nil, nil
+	}
+	for _, f := range s.fixtures {
+		if f.ToolName != t.Name() || !argsEqual(f.Args, args) {
+			continue
+		}
+		if f.Error != "" {
+			return map[string]any{"error": f.Error}, nil
+		}
+		if f.Response == nil {
+			return map[string]any{}, nil
+		}
+		return f.Response, nil
+	}
+	s.mu.Lock()
+	s.unmatched = append(s.unmatched, &UnmatchedToolCall{ToolName: t.Name(), Args: args})
+	s.mu.Unlock()
+	return map[string]any{"error": fmt.Sprintf("no recorded result for the call of tool %q with these arguments", t.Name())}, nil
+}
+
+// unmatchedCalls returns the calls which no fixture matched, in order.
+func (s *toolSandbox) unmatchedCalls() []*UnmatchedToolCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.unmatched
+}
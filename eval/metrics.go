@@ -0,0 +1,187 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"google.golang.org/genai"
+)
+
+// Names of the built-in metrics.
+const (
+	// MetricToolTrajectoryAvgScore compares the tool uses of the actual and
+	// expected invocations. An invocation scores 1 if the agent called exactly
+	// the expected tools, with the same arguments and in the same order, and 0
+	// otherwise. The metric is the average score over all invocations.
+	MetricToolTrajectoryAvgScore = "tool_trajectory_avg_score"
+	// MetricResponseMatchScore is the ROUGE-1 F-measure between the actual and
+	// the expected final responses, averaged over all invocations.
+	MetricResponseMatchScore = "response_match_score"
+)
+
+// DefaultCriteria are the thresholds used when no criteria are configured.
+var DefaultCriteria = map[string]float64{
+	MetricToolTrajectoryAvgScore: 1.0,
+	MetricResponseMatchScore:     0.8,
+}
+
+// Evaluator scores an actual invocation against the expected one.
+//
+// The scores are in the [0, 1] range. The score of an eval case is the
+// average score of its invocations, which is compared to the threshold
+// configured in the criteria for the evaluator's name.
+type Evaluator interface {
+	// Name is the metric name of the evaluator, used in the criteria and the
+	// results.
+	Name() string
+	// Evaluate returns the score of the actual invocation.
+	Evaluate(ctx context.Context, actual, expected *Invocation) (float64, error)
+}
+
+// NewToolTrajectoryEvaluator returns the evaluator of the
+// [MetricToolTrajectoryAvgScore] metric.
+func NewToolTrajectoryEvaluator() Evaluator {
+	return &funcEvaluator{name: MetricToolTrajectoryAvgScore, score: toolTrajectoryScore}
+}
+
+// NewResponseMatchEvaluator returns the evaluator of the
+// [MetricResponseMatchScore] metric.
+func NewResponseMatchEvaluator() Evaluator {
+	return &funcEvaluator{name: MetricResponseMatchScore, score: responseMatchScore}
+}
+
+// funcEvaluator is an Evaluator computing the score with a pure function.
+type funcEvaluator struct {
+	name  string
+	score func(actual, expected *Invocation) float64
+}
+
+func (e *funcEvaluator) Name() string {
+	return e.name
+}
+
+func (e *funcEvaluator) Evaluate(_ context.Context, actual, expected *Invocation) (float64, error) {
+	return e.score(actual, expected), nil
+}
+
+func toolTrajectoryScore(actual, expected *Invocation) float64 {
+	actualUses, expectedUses := actual.toolUses(), expected.toolUses()
+	if len(actualUses) != len(expectedUses) {
+		return 0
+	}
+	for i := range actualUses {
+		if actualUses[i].Name != expectedUses[i].Name {
+			return 0
+		}
+		if !argsEqual(actualUses[i].Args, expectedUses[i].Args) {
+			return 0
+		}
+	}
+	return 1
+}
+
+// argsEqual reports whether both argument maps hold the same values.
+// Missing and empty maps are considered equal.
+func argsEqual(a, b map[string]any) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(normalizeJSON(a), normalizeJSON(b))
+}
+
+// normalizeJSON converts numbers to float64 so that arguments produced by the
+// model compare equal to arguments decoded from JSON.
+func normalizeJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, val := range v {
+			m[k] = normalizeJSON(val)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, val := range v {
+			s[i] = normalizeJSON(val)
+		}
+		return s
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+func responseMatchScore(actual, expected *Invocation) float64 {
+	return rouge1(contentText(expected.FinalResponse), contentText(actual.FinalResponse))
+}
+
+// rouge1 returns the ROUGE-1 F-measure of the candidate against the reference.
+func rouge1(reference, candidate string) float64 {
+	refTokens, candTokens := tokenize(reference), tokenize(candidate)
+	if len(refTokens) == 0 || len(candTokens) == 0 {
+		if len(refTokens) == len(candTokens) {
+			return 1
+		}
+		return 0
+	}
+	refCounts := make(map[string]int)
+	for _, t := range refTokens {
+		refCounts[t]++
+	}
+	overlap := 0
+	for _, t := range candTokens {
+		if refCounts[t] > 0 {
+			refCounts[t]--
+			overlap++
+		}
+	}
+	if overlap == 0 {
+		return 0
+	}
+	precision := float64(overlap) / float64(len(candTokens))
+	recall := float64(overlap) / float64(len(refTokens))
+	return 2 * precision * recall / (precision + recall)
+}
+
+// tokenize lowercases the text and splits it on non-alphanumeric runes.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func contentText(c *genai.Content) string {
+	if c == nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range c.Parts {
+		if p != nil && p.Text != "" && !p.Thought {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
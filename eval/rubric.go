@@ -0,0 +1,164 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// MetricRubricBasedResponseQuality is the default name of the rubric
+// evaluator metric.
+const MetricRubricBasedResponseQuality = "rubric_based_final_response_quality_v1"
+
+// RubricConfig is used to create an LLM-as-judge evaluator.
+type RubricConfig struct {
+	// Name of the metric.
+	// Optional: defaults to MetricRubricBasedResponseQuality.
+	Name string
+	// Model used as the judge.
+	Model model.LLM
+	// Rubrics are the properties of a good final response, e.g. "The
+	// response mentions the temperature in Celsius".
+	Rubrics []string
+	// NumSamples is the number of times the judge is asked to grade an
+	// invocation. The scores of all samples are averaged.
+	// Optional: defaults to 1.
+	NumSamples int
+}
+
+// NewRubricEvaluator returns an evaluator which asks the judge model whether
+// the final response of an invocation satisfies each of the rubrics. The
+// score of an invocation is the fraction of the satisfied rubrics.
+func NewRubricEvaluator(cfg RubricConfig) (Evaluator, error) {
+	if cfg.Model == nil {
+		return nil, fmt.Errorf("judge model is required")
+	}
+	if len(cfg.Rubrics) == 0 {
+		return nil, fmt.Errorf("at least one rubric is required")
+	}
+	if cfg.Name == "" {
+		cfg.Name = MetricRubricBasedResponseQuality
+	}
+	if cfg.NumSamples <= 0 {
+		cfg.NumSamples = 1
+	}
+	return &rubricEvaluator{cfg: cfg}, nil
+}
+
+type rubricEvaluator struct {
+	cfg RubricConfig
+}
+
+func (e *rubricEvaluator) Name() string {
+	return e.cfg.Name
+}
+
+func (e *rubricEvaluator) Evaluate(ctx context.Context, actual, expected *Invocation) (float64, error) {
+	req := &model.LLMRequest{
+		Model:    e.cfg.Model.Name(),
+		Contents: []*genai.Content{genai.NewContentFromText(e.prompt(actual, expected), genai.RoleUser)},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText(judgeInstruction, genai.RoleUser),
+			ResponseMIMEType:  "application/json",
+		},
+	}
+
+	var total float64
+	for range e.cfg.NumSamples {
+		s, err := e.sample(ctx, req)
+		if err != nil {
+			return 0, err
+		}
+		total += s
+	}
+	return total / float64(e.cfg.NumSamples), nil
+}
+
+// sample asks the judge once and returns the fraction of satisfied rubrics.
+func (e *rubricEvaluator) sample(ctx context.Context, req *model.LLMRequest) (float64, error) {
+	var text strings.Builder
+	for resp, err := range e.cfg.Model.GenerateContent(ctx, req, false) {
+		if err != nil {
+			return 0, fmt.Errorf("judge model call failed: %w", err)
+		}
+		text.WriteString(contentText(resp.Content))
+	}
+
+	verdicts, err := parseVerdicts(text.String())
+	if err != nil {
+		return 0, err
+	}
+	satisfied := 0
+	for i := range e.cfg.Rubrics {
+		if verdicts[i+1] {
+			satisfied++
+		}
+	}
+	return float64(satisfied) / float64(len(e.cfg.Rubrics)), nil
+}
+
+const judgeInstruction = `You are an impartial judge evaluating the final response of an AI agent.
+For every numbered rubric, decide whether the final response satisfies it.
+Answer with a JSON array containing one object per rubric:
+[{"rubric_id": <number>, "rationale": "<short explanation>", "verdict": "yes" or "no"}]`
+
+func (e *rubricEvaluator) prompt(actual, expected *Invocation) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "User request:\n%s\n\n", contentText(actual.UserContent))
+	if uses := actual.toolUses(); len(uses) > 0 {
+		sb.WriteString("Tool calls made by the agent:\n")
+		for _, fc := range uses {
+			args, _ := json.Marshal(fc.Args)
+			fmt.Fprintf(&sb, "- %s(%s)\n", fc.Name, args)
+		}
+		sb.WriteString("\n")
+	}
+	if ref := contentText(expected.FinalResponse); ref != "" {
+		fmt.Fprintf(&sb, "Reference response:\n%s\n\n", ref)
+	}
+	fmt.Fprintf(&sb, "Final response of the agent:\n%s\n\nRubrics:\n", contentText(actual.FinalResponse))
+	for i, r := range e.cfg.Rubrics {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, r)
+	}
+	return sb.String()
+}
+
+// parseVerdicts decodes the judge answer into a map of rubric IDs to
+// verdicts.
+func parseVerdicts(answer string) (map[int]bool, error) {
+	answer = strings.TrimSpace(answer)
+	answer = strings.TrimPrefix(answer, "```json")
+	answer = strings.TrimPrefix(answer, "```")
+	answer = strings.TrimSuffix(answer, "```")
+
+	var items []struct {
+		RubricID int    `json:"rubric_id"`
+		Verdict  string `json:"verdict"`
+	}
+	if err := json.Unmarshal([]byte(answer), &items); err != nil {
+		return nil, fmt.Errorf("failed to parse judge answer %q: %w", answer, err)
+	}
+	verdicts := make(map[int]bool, len(items))
+	for _, it := range items {
+		verdicts[it.RubricID] = strings.EqualFold(strings.TrimSpace(it.Verdict), "yes")
+	}
+	return verdicts, nil
+}
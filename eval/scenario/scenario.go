@@ -0,0 +1,427 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scenario runs declarative multi-turn conversations against an agent
+// and checks the expected behavior of every turn: the tools called, the agent
+// transfers, the session state and the response.
+//
+// Scenarios can be written in Go:
+//
+//	s := &scenario.Scenario{
+//		Name: "book a flight",
+//		Turns: []scenario.Turn{{
+//			User: "Book a flight to Paris",
+//			Expect: scenario.Expectations{
+//				Transfers: []string{"booking_agent"},
+//				ToolCalls: []scenario.ToolCall{{Name: "search_flights", Args: map[string]any{"to": "Paris"}}},
+//			},
+//		}},
+//	}
+//
+// or in YAML, see [Parse]:
+//
+//	name: book a flight
+//	turns:
+//	  - user: Book a flight to Paris
+//	    expect:
+//	      transfers: [booking_agent]
+//	      tool_calls:
+//	        - name: search_flights
+//	          args: {to: Paris}
+//	      state: {destination: Paris}
+//
+// The agent can use a real model, or a recorded or scripted one to make the
+// scenario deterministic.
+package scenario
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// transferToolName is the name of the tool used by the agents to transfer
+// the conversation. Transfers are checked with Expectations.Transfers instead
+// of Expectations.ToolCalls.
+const transferToolName = "transfer_to_agent"
+
+// Scenario is a multi-turn conversation with the expected agent behavior.
+type Scenario struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// InitialState is the state of the session the scenario runs in.
+	// Optional.
+	InitialState map[string]any `yaml:"initial_state"`
+	Turns        []Turn         `yaml:"turns"`
+}
+
+// Turn is a user message and the expected handling of it.
+type Turn struct {
+	// User is the text of the user message.
+	User   string       `yaml:"user"`
+	Expect Expectations `yaml:"expect"`
+}
+
+// Expectations describe the agent behavior in a single turn. Unset fields
+// are not checked.
+type Expectations struct {
+	// ToolCalls are the tools the agents call in this turn, in order. Agent
+	// transfers are not included, see Transfers.
+	ToolCalls []ToolCall `yaml:"tool_calls"`
+	// NoToolCalls requires that no tools, other than agent transfers, are
+	// called in this turn.
+	NoToolCalls bool `yaml:"no_tool_calls"`
+	// Transfers are the names of the agents the conversation is transferred
+	// to in this turn, in order.
+	Transfers []string `yaml:"transfers"`
+	// State are the values the session state must contain after the turn.
+	// Other state keys are not checked.
+	State map[string]any `yaml:"state"`
+	// ResponseContains are the substrings the final response must contain,
+	// compared case-insensitively.
+	ResponseContains []string `yaml:"response_contains"`
+	// Agent is the name of the agent which gives the final response.
+	Agent string `yaml:"agent"`
+}
+
+// ToolCall is an expected tool call.
+type ToolCall struct {
+	Name string `yaml:"name"`
+	// Args the call must have. Other arguments of the call are not checked.
+	Args map[string]any `yaml:"args"`
+}
+
+// Parse parses a scenario from YAML.
+func Parse(data []byte) (*Scenario, error) {
+	var s Scenario
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	if len(s.Turns) == 0 {
+		return nil, fmt.Errorf("scenario %q has no turns", s.Name)
+	}
+	return &s, nil
+}
+
+// Load reads a YAML scenario from a file.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(path[strings.LastIndexAny(path, `/\`)+1:], ".yaml")
+	}
+	return s, nil
+}
+
+// Config is used to run scenarios.
+type Config struct {
+	// AppName is used for the sessions created for scenarios.
+	// Optional: if not set, the name of the agent is used.
+	AppName string
+	// Agent under test.
+	Agent agent.Agent
+
+	// SessionService is used to create the sessions of scenarios.
+	// Optional: if not set, an in-memory service is used.
+	SessionService session.Service
+	// optional
+	ArtifactService artifact.Service
+	// RunConfig is used for every turn.
+	// Optional.
+	RunConfig agent.RunConfig
+}
+
+// Result is the outcome of a scenario.
+type Result struct {
+	Scenario  string
+	SessionID string
+	Turns     []*TurnResult
+}
+
+// TurnResult is the outcome of a single turn.
+type TurnResult struct {
+	User string
+	// Response is the text of the final response.
+	Response string
+	// Failures describe the unmet expectations of the turn.
+	Failures []string
+}
+
+// Passed reports whether all expectations of all turns are met.
+func (r *Result) Passed() bool {
+	for _, t := range r.Turns {
+		if len(t.Failures) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns a readable report of the failed turns.
+func (r *Result) String() string {
+	var b strings.Builder
+	status := "PASSED"
+	if !r.Passed() {
+		status = "FAILED"
+	}
+	fmt.Fprintf(&b, "scenario %q: %s", r.Scenario, status)
+	for i, t := range r.Turns {
+		if len(t.Failures) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n  turn %d, user: %q", i+1, t.User)
+		for _, f := range t.Failures {
+			fmt.Fprintf(&b, "\n    - %s", f)
+		}
+		fmt.Fprintf(&b, "\n    response: %q", t.Response)
+	}
+	return b.String()
+}
+
+// Run runs the turns of the scenario in a new session and checks their
+// expectations. An error is returned only if the scenario can't be run, unmet
+// expectations are reported in the result.
+func Run(ctx context.Context, cfg Config, s *Scenario) (*Result, error) {
+	if cfg.Agent == nil {
+		return nil, fmt.Errorf("agent is required")
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = cfg.Agent.Name()
+	}
+	if cfg.SessionService == nil {
+		cfg.SessionService = session.InMemoryService()
+	}
+	const userID = "scenario_user"
+
+	r, err := runner.New(runner.Config{
+		AppName:         cfg.AppName,
+		Agent:           cfg.Agent,
+		SessionService:  cfg.SessionService,
+		ArtifactService: cfg.ArtifactService,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runner: %w", err)
+	}
+	resp, err := cfg.SessionService.Create(ctx, &session.CreateRequest{
+		AppName: cfg.AppName,
+		UserID:  userID,
+		State:   s.InitialState,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	sessionID := resp.Session.ID()
+
+	result := &Result{Scenario: s.Name, SessionID: sessionID}
+	for i, turn := range s.Turns {
+		var obs observation
+		msg := genai.NewContentFromText(turn.User, genai.RoleUser)
+		for ev, err := range r.Run(ctx, userID, sessionID, msg, cfg.RunConfig) {
+			if err != nil {
+				return nil, fmt.Errorf("turn %d: agent run failed: %w", i+1, err)
+			}
+			if ev != nil {
+				obs.add(ev)
+			}
+		}
+		got, err := cfg.SessionService.Get(ctx, &session.GetRequest{
+			AppName:     cfg.AppName,
+			UserID:      userID,
+			SessionID:   sessionID,
+			Consistency: session.ConsistencyStrong,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("turn %d: failed to get session: %w", i+1, err)
+		}
+		result.Turns = append(result.Turns, &TurnResult{
+			User:     turn.User,
+			Response: obs.response,
+			Failures: turn.Expect.check(&obs, got.Session.State()),
+		})
+	}
+	return result, nil
+}
+
+// TB is the subset of testing.TB used by [Test]. It is satisfied by
+// *testing.T and *testing.B, without making the package depend on the testing
+// package.
+type TB interface {
+	Helper()
+	Context() context.Context
+	Errorf(format string, args ...any)
+}
+
+// Test runs every scenario and reports the scenarios which can't be run or
+// have unmet expectations as errors of t.
+func Test(t TB, cfg Config, scenarios ...*Scenario) {
+	t.Helper()
+	for _, s := range scenarios {
+		result, err := Run(t.Context(), cfg, s)
+		if err != nil {
+			t.Errorf("scenario %q: %v", s.Name, err)
+			continue
+		}
+		if !result.Passed() {
+			t.Errorf("%s", result)
+		}
+	}
+}
+
+// observation is what happened in a turn.
+type observation struct {
+	toolCalls []*genai.FunctionCall
+	transfers []string
+	response  string
+	agent     string
+}
+
+func (o *observation) add(ev *session.Event) {
+	if ev.Partial {
+		return
+	}
+	if ev.Actions.TransferToAgent != "" {
+		o.transfers = append(o.transfers, ev.Actions.TransferToAgent)
+	}
+	if ev.Content == nil {
+		return
+	}
+	for _, p := range ev.Content.Parts {
+		if p.FunctionCall != nil && p.FunctionCall.Name != transferToolName {
+			o.toolCalls = append(o.toolCalls, p.FunctionCall)
+		}
+	}
+	if ev.IsFinalResponse() {
+		var text strings.Builder
+		for _, p := range ev.Content.Parts {
+			if p.Text != "" && !p.Thought {
+				text.WriteString(p.Text)
+			}
+		}
+		if text.Len() > 0 {
+			o.response = text.String()
+			o.agent = ev.Author
+		}
+	}
+}
+
+func (e *Expectations) check(o *observation, state session.State) []string {
+	var failures []string
+	if e.NoToolCalls && len(o.toolCalls) > 0 {
+		failures = append(failures, fmt.Sprintf("want no tool calls, got %s", formatCalls(o.toolCalls)))
+	}
+	if len(e.ToolCalls) > 0 && !callsMatch(e.ToolCalls, o.toolCalls) {
+		failures = append(failures, fmt.Sprintf("want tool calls %s, got %s", formatExpectedCalls(e.ToolCalls), formatCalls(o.toolCalls)))
+	}
+	if len(e.Transfers) > 0 && !reflect.DeepEqual(e.Transfers, o.transfers) {
+		failures = append(failures, fmt.Sprintf("want transfers to %v, got %v", e.Transfers, o.transfers))
+	}
+	if e.Agent != "" && e.Agent != o.agent {
+		failures = append(failures, fmt.Sprintf("want final response by agent %q, got %q", e.Agent, o.agent))
+	}
+	for _, want := range e.ResponseContains {
+		if !strings.Contains(strings.ToLower(o.response), strings.ToLower(want)) {
+			failures = append(failures, fmt.Sprintf("want response containing %q", want))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(e.State)) {
+		want := e.State[key]
+		got, err := state.Get(key)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("want state %q = %v, got no value", key, want))
+			continue
+		}
+		if !valuesEqual(want, got) {
+			failures = append(failures, fmt.Sprintf("want state %q = %v, got %v", key, want, got))
+		}
+	}
+	return failures
+}
+
+func callsMatch(want []ToolCall, got []*genai.FunctionCall) bool {
+	if len(want) != len(got) {
+		return false
+	}
+	for i, w := range want {
+		if w.Name != got[i].Name {
+			return false
+		}
+		for k, v := range w.Args {
+			gv, ok := got[i].Args[k]
+			if !ok || !valuesEqual(v, gv) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// valuesEqual compares values by their JSON encoding, so that e.g. the
+// integers from YAML match the floats decoded from model responses.
+func valuesEqual(a, b any) bool {
+	na, errA := normalize(a)
+	nb, errB := normalize(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return reflect.DeepEqual(na, nb)
+}
+
+func normalize(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func formatCalls(calls []*genai.FunctionCall) string {
+	parts := make([]string, len(calls))
+	for i, c := range calls {
+		args, _ := json.Marshal(c.Args)
+		parts[i] = fmt.Sprintf("%s(%s)", c.Name, args)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func formatExpectedCalls(calls []ToolCall) string {
+	parts := make([]string, len(calls))
+	for i, c := range calls {
+		args, _ := json.Marshal(c.Args)
+		parts[i] = fmt.Sprintf("%s(%s)", c.Name, args)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
@@ -0,0 +1,280 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+const bookingScenario = `
+name: book a flight
+initial_state:
+  user_tier: gold
+turns:
+  - user: Book a flight to Paris
+    expect:
+      transfers: [booking_agent]
+      tool_calls:
+        - name: book_flight
+          args: {to: Paris}
+      state:
+        destination: Paris
+        seats: 1
+      response_contains: [booked]
+      agent: booking_agent
+  - user: Thanks!
+    expect:
+      no_tool_calls: true
+      agent: booking_agent
+`
+
+func newBookingAgent(t *testing.T, responses ...*genai.Content) agent.Agent {
+	t.Helper()
+	type args struct {
+		To string `json:"to"`
+	}
+	bookTool, err := functiontool.New(functiontool.Config{
+		Name:        "book_flight",
+		Description: "books a flight",
+	}, func(ctx tool.Context, a args) (map[string]any, error) {
+		if err := ctx.State().Set("destination", a.To); err != nil {
+			return nil, err
+		}
+		if err := ctx.State().Set("seats", 1); err != nil {
+			return nil, err
+		}
+		return map[string]any{"status": "booked"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model := &testutil.MockModel{Responses: responses}
+	booking, err := llmagent.New(llmagent.Config{
+		Name:        "booking_agent",
+		Description: "books flights",
+		Model:       model,
+		Tools:       []tool.Tool{bookTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := llmagent.New(llmagent.Config{
+		Name:      "root_agent",
+		Model:     model,
+		SubAgents: []agent.Agent{booking},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(bookingScenario))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if s.Name != "book a flight" || len(s.Turns) != 2 {
+		t.Fatalf("Parse() = %+v, want scenario 'book a flight' with 2 turns", s)
+	}
+	if got := s.InitialState["user_tier"]; got != "gold" {
+		t.Errorf("initial state user_tier = %v, want gold", got)
+	}
+	first := s.Turns[0].Expect
+	if len(first.ToolCalls) != 1 || first.ToolCalls[0].Name != "book_flight" || first.ToolCalls[0].Args["to"] != "Paris" {
+		t.Errorf("tool calls = %+v", first.ToolCalls)
+	}
+	if !s.Turns[1].Expect.NoToolCalls {
+		t.Errorf("second turn NoToolCalls = false, want true")
+	}
+
+	if _, err := Parse([]byte("name: empty")); err == nil {
+		t.Errorf("Parse() of a scenario without turns succeeded, want error")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "booking.yaml")
+	if err := os.WriteFile(path, []byte("turns:\n  - user: hi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if s.Name != "booking" {
+		t.Errorf("Load() name = %q, want the file name", s.Name)
+	}
+}
+
+func TestRun(t *testing.T) {
+	s, err := Parse([]byte(bookingScenario))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		newAgent     func(*testing.T, ...*genai.Content) agent.Agent
+		responses    []*genai.Content
+		wantFailures [][]string
+	}{
+		{
+			name:     "passed",
+			newAgent: newBookingAgent,
+			responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("transfer_to_agent", map[string]any{"agent_name": "booking_agent"}, genai.RoleModel),
+				genai.NewContentFromFunctionCall("book_flight", map[string]any{"to": "Paris"}, genai.RoleModel),
+				genai.NewContentFromText("Your flight to Paris is booked.", genai.RoleModel),
+				genai.NewContentFromText("You're welcome!", genai.RoleModel),
+			},
+			wantFailures: [][]string{nil, nil},
+		},
+		{
+			name:     "wrong behavior",
+			newAgent: newRootWithTool,
+			responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("book_flight", map[string]any{"to": "London"}, genai.RoleModel),
+				genai.NewContentFromText("Done.", genai.RoleModel),
+				genai.NewContentFromFunctionCall("book_flight", map[string]any{"to": "London"}, genai.RoleModel),
+				genai.NewContentFromText("You're welcome!", genai.RoleModel),
+			},
+			wantFailures: [][]string{
+				{
+					`want tool calls [book_flight({"to":"Paris"})], got [book_flight({"to":"London"})]`,
+					`want transfers to [booking_agent], got []`,
+					`want final response by agent "booking_agent", got "root_agent"`,
+					`want response containing "booked"`,
+					`want state "destination" = Paris, got London`,
+					`want state "seats" = 1, got no value`,
+				},
+				{
+					`want no tool calls, got [book_flight({"to":"London"})]`,
+					`want final response by agent "booking_agent", got "root_agent"`,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Run(t.Context(), Config{Agent: tt.newAgent(t, tt.responses...)}, s)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if len(result.Turns) != len(tt.wantFailures) {
+				t.Fatalf("Run() returned %d turns, want %d", len(result.Turns), len(tt.wantFailures))
+			}
+			for i, turn := range result.Turns {
+				if !slices.Equal(turn.Failures, tt.wantFailures[i]) {
+					t.Errorf("turn %d failures = %q, want %q", i+1, turn.Failures, tt.wantFailures[i])
+				}
+			}
+			if got, want := result.Passed(), tt.wantFailures[0] == nil; got != want {
+				t.Errorf("Passed() = %v, want %v; result:\n%s", got, want, result)
+			}
+		})
+	}
+}
+
+// newRootWithTool returns a single agent which books flights itself, instead
+// of transferring to the booking agent.
+func newRootWithTool(t *testing.T, responses ...*genai.Content) agent.Agent {
+	t.Helper()
+	bookTool, err := functiontool.New(functiontool.Config{
+		Name:        "book_flight",
+		Description: "books a flight",
+	}, func(ctx tool.Context, a struct {
+		To string `json:"to"`
+	}) (map[string]any, error) {
+		return map[string]any{"status": "booked"}, ctx.State().Set("destination", a.To)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "root_agent",
+		Model: &testutil.MockModel{Responses: responses},
+		Tools: []tool.Tool{bookTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestTest(t *testing.T) {
+	a := newBookingAgent(t, genai.NewContentFromText("Hello!", genai.RoleModel))
+	Test(t, Config{Agent: a}, &Scenario{
+		Name: "greeting",
+		Turns: []Turn{{
+			User:   "Hi",
+			Expect: Expectations{NoToolCalls: true, ResponseContains: []string{"hello"}, Agent: "root_agent"},
+		}},
+	})
+}
+
+// recordingTB records the errors reported to it.
+type recordingTB struct {
+	*testing.T
+	errors []string
+}
+
+func (tb *recordingTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestTest_Failures(t *testing.T) {
+	a := newBookingAgent(t, genai.NewContentFromText("Hello!", genai.RoleModel))
+	tb := &recordingTB{T: t}
+	Test(tb, Config{Agent: a}, &Scenario{
+		Name: "farewell",
+		Turns: []Turn{{
+			User:   "Bye",
+			Expect: Expectations{ResponseContains: []string{"goodbye"}},
+		}},
+	})
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], `scenario "farewell": FAILED`) {
+		t.Errorf("Test() reported %q, want the failed scenario", tb.errors)
+	}
+}
+
+func TestResultString(t *testing.T) {
+	r := &Result{Scenario: "s", Turns: []*TurnResult{
+		{User: "a"},
+		{User: "b", Response: "nope", Failures: []string{"want response containing \"yes\""}},
+	}}
+	got := r.String()
+	for _, want := range []string{`scenario "s": FAILED`, `turn 2, user: "b"`, `want response containing "yes"`, `response: "nope"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("String() = %q, want it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "turn 1") {
+		t.Errorf("String() = %q, want only the failed turns", got)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package featureflag turns optional agent behaviors, e.g. cache-aware
+// ordering or context window management, on and off at runtime, per app and
+// per user, without rebuilding or reconstructing the agents.
+//
+// A [Provider] is set with runner.Config.FeatureFlags. The processors of the
+// agents consult it on every model call. A flag which the provider doesn't
+// set leaves the behavior as configured on the agent.
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The flags consulted by the agents.
+const (
+	// CacheAwareOrdering enables the cache-aware ordering of the model
+	// requests, see llmagent.Config.CacheAwareOrdering.
+	CacheAwareOrdering = "cache_aware_ordering"
+	// ContextWindow enables the context window of the agents which configure
+	// one, see llmagent.Config.ContextWindow.
+	ContextWindow = "context_window"
+	// HandoffSummary enables the handoff summaries of the agents which
+	// configure them, see llmagent.Config.HandoffSummary.
+	HandoffSummary = "handoff_summary"
+)
+
+// Scope identifies the run a flag is evaluated for.
+type Scope struct {
+	AppName string
+	UserID  string
+}
+
+// Provider provides the values of the flags.
+type Provider interface {
+	// Lookup returns the value of the flag in the scope. ok is false if the
+	// provider doesn't set the flag.
+	//
+	// Lookup is called on every model call, so remote providers should cache
+	// the flags and must not block for long.
+	Lookup(ctx context.Context, flag string, scope Scope) (enabled, ok bool)
+}
+
+// ProviderFunc adapts a function to a [Provider], e.g. to query a remote
+// configuration service.
+type ProviderFunc func(ctx context.Context, flag string, scope Scope) (enabled, ok bool)
+
+// Lookup implements Provider.
+func (f ProviderFunc) Lookup(ctx context.Context, flag string, scope Scope) (bool, bool) {
+	return f(ctx, flag, scope)
+}
+
+// Enabled reports whether the flag is enabled in the scope. It returns
+// configured if the provider is nil or doesn't set the flag.
+func Enabled(ctx context.Context, p Provider, flag string, scope Scope, configured bool) bool {
+	if p == nil {
+		return configured
+	}
+	if enabled, ok := p.Lookup(ctx, flag, scope); ok {
+		return enabled
+	}
+	return configured
+}
+
+// Flag is the value of a flag. The most specific value set for the scope is
+// used: the value for the user, then the value for the app, then Enabled.
+type Flag struct {
+	// Enabled is the value in all scopes.
+	// Optional: if nil, the flag is only set for the listed apps and users.
+	Enabled *bool `json:"enabled,omitempty"`
+	// Apps are the values by app name.
+	Apps map[string]bool `json:"apps,omitempty"`
+	// Users are the values by user ID.
+	Users map[string]bool `json:"users,omitempty"`
+}
+
+func (f Flag) lookup(scope Scope) (bool, bool) {
+	if v, ok := f.Users[scope.UserID]; ok {
+		return v, true
+	}
+	if v, ok := f.Apps[scope.AppName]; ok {
+		return v, true
+	}
+	if f.Enabled != nil {
+		return *f.Enabled, true
+	}
+	return false, false
+}
+
+// Flags is a static [Provider] with the flags by name.
+type Flags map[string]Flag
+
+// Lookup implements Provider.
+func (f Flags) Lookup(_ context.Context, flag string, scope Scope) (bool, bool) {
+	return f[flag].lookup(scope)
+}
+
+// Env returns a [Provider] reading the flags from the environment variables
+// named after the upper-cased flags with the prefix, e.g.
+// ADK_FLAG_CONTEXT_WINDOW=false with the prefix "ADK_FLAG_". The values are
+// parsed with strconv.ParseBool. The variables are read on every lookup, so
+// the flags apply to all apps and users and change with the environment.
+func Env(prefix string) Provider {
+	return ProviderFunc(func(_ context.Context, flag string, _ Scope) (bool, bool) {
+		v, ok := os.LookupEnv(prefix + strings.ToUpper(flag))
+		if !ok {
+			return false, false
+		}
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, false
+		}
+		return enabled, true
+	})
+}
+
+// Chain returns a [Provider] returning the value of the first provider which
+// sets the flag.
+func Chain(providers ...Provider) Provider {
+	return ProviderFunc(func(ctx context.Context, flag string, scope Scope) (bool, bool) {
+		for _, p := range providers {
+			if enabled, ok := p.Lookup(ctx, flag, scope); ok {
+				return enabled, true
+			}
+		}
+		return false, false
+	})
+}
+
+// FileProvider reads the flags from a JSON file with the [Flags] by name,
+// e.g.
+//
+//	{
+//	  "context_window": {"enabled": true, "users": {"tester": false}},
+//	  "cache_aware_ordering": {"apps": {"support": true}}
+//	}
+//
+// The file is reloaded when it changes, so the flags can be changed without
+// restarting the server.
+type FileProvider struct {
+	path string
+	// checkInterval is the minimum time between the checks for changes.
+	checkInterval time.Duration
+
+	mu        sync.Mutex
+	flags     Flags
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// defaultCheckInterval is the default time between the checks for changes of
+// the flags file.
+const defaultCheckInterval = time.Second
+
+// File returns a [FileProvider] reading the file at path. It fails if the
+// file can't be read or parsed. Later errors keep the last flags read.
+func File(path string) (*FileProvider, error) {
+	p := &FileProvider{path: path, checkInterval: defaultCheckInterval}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Lookup implements Provider.
+func (p *FileProvider) Lookup(_ context.Context, flag string, scope Scope) (bool, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.lastCheck) >= p.checkInterval {
+		// Keep the last flags if the file is being rewritten.
+		_ = p.reloadLocked()
+	}
+	return p.flags[flag].lookup(scope)
+}
+
+func (p *FileProvider) reload() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.reloadLocked()
+}
+
+func (p *FileProvider) reloadLocked() error {
+	p.lastCheck = time.Now()
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read feature flags: %w", err)
+	}
+	if p.flags != nil && info.ModTime().Equal(p.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read feature flags: %w", err)
+	}
+	var flags Flags
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return fmt.Errorf("failed to parse feature flags %s: %w", p.path, err)
+	}
+	if flags == nil {
+		flags = Flags{}
+	}
+	p.flags = flags
+	p.modTime = info.ModTime()
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featureflag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFlags(t *testing.T) {
+	enabled := true
+	flags := Flags{
+		ContextWindow: {
+			Enabled: &enabled,
+			Apps:    map[string]bool{"support": false},
+			Users:   map[string]bool{"tester": true},
+		},
+		CacheAwareOrdering: {
+			Users: map[string]bool{"tester": true},
+		},
+	}
+	tests := []struct {
+		name       string
+		flag       string
+		scope      Scope
+		configured bool
+		want       bool
+	}{
+		{"default value", ContextWindow, Scope{AppName: "shop", UserID: "u1"}, false, true},
+		{"app value", ContextWindow, Scope{AppName: "support", UserID: "u1"}, true, false},
+		{"user value overrides app value", ContextWindow, Scope{AppName: "support", UserID: "tester"}, false, true},
+		{"unset for scope", CacheAwareOrdering, Scope{AppName: "shop", UserID: "u1"}, false, false},
+		{"unset for scope keeps configuration", CacheAwareOrdering, Scope{AppName: "shop", UserID: "u1"}, true, true},
+		{"unknown flag", HandoffSummary, Scope{UserID: "tester"}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Enabled(t.Context(), flags, tt.flag, tt.scope, tt.configured); got != tt.want {
+				t.Errorf("Enabled(%q, %+v, %v) = %v, want %v", tt.flag, tt.scope, tt.configured, got, tt.want)
+			}
+		})
+	}
+
+	if got := Enabled(t.Context(), nil, ContextWindow, Scope{}, true); !got {
+		t.Errorf("Enabled() with nil provider = false, want the configured value")
+	}
+}
+
+func TestEnv(t *testing.T) {
+	t.Setenv("TEST_FLAG_CONTEXT_WINDOW", "false")
+	t.Setenv("TEST_FLAG_HANDOFF_SUMMARY", "not a bool")
+	p := Env("TEST_FLAG_")
+
+	if enabled, ok := p.Lookup(t.Context(), ContextWindow, Scope{}); !ok || enabled {
+		t.Errorf("Lookup(%q) = %v, %v, want false, true", ContextWindow, enabled, ok)
+	}
+	if _, ok := p.Lookup(t.Context(), HandoffSummary, Scope{}); ok {
+		t.Errorf("Lookup(%q) with an invalid value is set, want unset", HandoffSummary)
+	}
+	if _, ok := p.Lookup(t.Context(), CacheAwareOrdering, Scope{}); ok {
+		t.Errorf("Lookup(%q) without variable is set, want unset", CacheAwareOrdering)
+	}
+}
+
+func TestChain(t *testing.T) {
+	disabled := false
+	p := Chain(
+		Flags{ContextWindow: {Users: map[string]bool{"tester": true}}},
+		Flags{ContextWindow: {Enabled: &disabled}},
+	)
+	if got := Enabled(t.Context(), p, ContextWindow, Scope{UserID: "tester"}, false); !got {
+		t.Errorf("Enabled() for tester = false, want the value of the first provider")
+	}
+	if got := Enabled(t.Context(), p, ContextWindow, Scope{UserID: "u1"}, true); got {
+		t.Errorf("Enabled() for u1 = true, want the value of the second provider")
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	write := func(data string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now()
+	write(`{"context_window": {"enabled": true}}`, start)
+
+	p, err := File(path)
+	if err != nil {
+		t.Fatalf("File() error = %v", err)
+	}
+	p.checkInterval = 0
+	if got := Enabled(t.Context(), p, ContextWindow, Scope{}, false); !got {
+		t.Errorf("Enabled() = false, want true")
+	}
+
+	// The flags are reloaded when the file changes.
+	write(`{"context_window": {"enabled": false}}`, start.Add(time.Minute))
+	if got := Enabled(t.Context(), p, ContextWindow, Scope{}, true); got {
+		t.Errorf("Enabled() after change = true, want false")
+	}
+
+	// A broken file keeps the last flags.
+	write(`{`, start.Add(2*time.Minute))
+	if got := Enabled(t.Context(), p, ContextWindow, Scope{}, true); got {
+		t.Errorf("Enabled() after broken change = true, want the last value false")
+	}
+
+	if _, err := File(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("File() of a missing file succeeded, want error")
+	}
+}
@@ -14,7 +14,15 @@
 
 package runconfig
 
-import "context"
+import (
+	"context"
+
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/featureflag"
+	"google.golang.org/adk/offline"
+	"google.golang.org/adk/replay"
+	"google.golang.org/genai"
+)
 
 type StreamingMode string
 
@@ -26,6 +34,31 @@ const (
 
 type RunConfig struct {
 	StreamingMode StreamingMode
+	// Offline replaces the models and the tools, if set.
+	Offline *offline.Profile
+	// Replay replaces the models and the tools with recorded ones, if set.
+	Replay *replay.Replayer
+	// CredentialService stores the credentials obtained by the tools, if set.
+	CredentialService auth.CredentialService
+	// InputAudioTranscription and OutputAudioTranscription enable the audio
+	// transcriptions in live mode, if set.
+	InputAudioTranscription  *genai.AudioTranscriptionConfig
+	OutputAudioTranscription *genai.AudioTranscriptionConfig
+	// RealtimeInputConfig and Proactivity configure the audio input and the
+	// proactivity of the model in live mode, if set.
+	RealtimeInputConfig *genai.RealtimeInputConfig
+	Proactivity         *genai.ProactivityConfig
+	// SupportCFC enables the compositional function calling in live mode.
+	SupportCFC bool
+	// FeatureFlags turn the optional behaviors of the agents on and off, if
+	// set.
+	FeatureFlags featureflag.Provider
+}
+
+// Live reports whether the run config sets a live (bidi) connection option.
+func (c *RunConfig) Live() bool {
+	return c.InputAudioTranscription != nil || c.OutputAudioTranscription != nil ||
+		c.RealtimeInputConfig != nil || c.Proactivity != nil || c.SupportCFC
 }
 
 func ToContext(ctx context.Context, cfg *RunConfig) context.Context {
@@ -40,3 +40,37 @@ func TestCallbackContext(t *testing.T) {
 		t.Errorf("CallbackContext(%+T) is unexpectedly an InvocationContext", got)
 	}
 }
+
+func TestSubInvocation(t *testing.T) {
+	parent := NewInvocationContext(t.Context(), InvocationContextParams{})
+	if got := parent.ParentInvocationID(); got != "" {
+		t.Errorf("root invocation has parent %q, want none", got)
+	}
+
+	// Sub-invocations are linked through any context derived from the parent.
+	child := NewInvocationContext(NewCallbackContext(parent), InvocationContextParams{})
+	if child.InvocationID() == parent.InvocationID() {
+		t.Errorf("sub-invocation reuses the parent invocation ID %q", parent.InvocationID())
+	}
+	if got, want := child.ParentInvocationID(), parent.InvocationID(); got != want {
+		t.Errorf("ParentInvocationID() = %q, want %q", got, want)
+	}
+}
+
+func TestSubInvocationSharesTokenCounter(t *testing.T) {
+	parent := NewInvocationContext(t.Context(), InvocationContextParams{})
+	child := NewInvocationContext(NewCallbackContext(parent), InvocationContextParams{})
+	if parent.Tokens() == nil || child.Tokens() != parent.Tokens() {
+		t.Fatalf("sub-invocation token counter = %p, want the parent counter %p", child.Tokens(), parent.Tokens())
+	}
+
+	child.Tokens().Add(agent.TokenUsage{PromptTokens: 5, CandidatesTokens: 2, TotalTokens: 7})
+	if got, want := parent.Tokens().Usage(), (agent.TokenUsage{PromptTokens: 5, CandidatesTokens: 2, TotalTokens: 7}); got != want {
+		t.Errorf("parent usage = %+v, want %+v", got, want)
+	}
+
+	other := NewInvocationContext(t.Context(), InvocationContextParams{})
+	if other.Tokens() == parent.Tokens() {
+		t.Errorf("unrelated invocation shares the token counter")
+	}
+}
@@ -16,9 +16,12 @@ package context
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/session"
 	"google.golang.org/genai"
 )
@@ -34,21 +37,100 @@ type InvocationContextParams struct {
 	UserContent   *genai.Content
 	RunConfig     *agent.RunConfig
 	EndInvocation bool
+	// InvocationID is the ID of the invocation.
+	// Optional: if empty, a new unique ID is generated. It is set when an
+	// interrupted invocation is resumed.
+	InvocationID string
+	// ParentInvocationID is the ID of the invocation which started this one.
+	// Optional: if empty, the invocation of ctx is used, if any. It is set
+	// when a serialized invocation is rehydrated.
+	ParentInvocationID string
+	// Tokens counts the tokens of the invocation.
+	// Optional: if nil, the counter of the parent invocation is used, or a
+	// new counter if there is none.
+	Tokens *agent.TokenCounter
+	// NewID and Now generate the IDs and timestamps of the invocation and
+	// its events.
+	// Optional: if nil, the ones of the parent invocation are used, if any.
+	NewID func() string
+	Now   func() time.Time
 }
 
+// invocationIDKey is the context key of the ID of the innermost invocation.
+// It is used to link nested invocations, e.g. agents called by the agent tool,
+// to the invocation which started them.
+type invocationIDKey struct{}
+
+// tokenCounterKey is the context key of the token counter shared by an
+// invocation and its sub-invocations.
+type tokenCounterKey struct{}
+
+// newIDKey and nowKey are the context keys of the ID generator and clock
+// shared by an invocation and its sub-invocations.
+type (
+	newIDKey struct{}
+	nowKey   struct{}
+)
+
+// IDGenerator returns the ID generator of the invocation of ctx, or nil if
+// it uses random IDs.
+func IDGenerator(ctx context.Context) func() string {
+	newID, _ := ctx.Value(newIDKey{}).(func() string)
+	return newID
+}
+
+// Clock returns the clock of the invocation of ctx, or nil if it uses
+// time.Now.
+func Clock(ctx context.Context) func() time.Time {
+	now, _ := ctx.Value(nowKey{}).(func() time.Time)
+	return now
+}
+
+// NewInvocationContext creates a new invocation with a unique ID. If ctx
+// descends from another invocation context, the new invocation is recorded as
+// its sub-invocation.
 func NewInvocationContext(ctx context.Context, params InvocationContextParams) agent.InvocationContext {
+	if params.NewID == nil {
+		params.NewID = IDGenerator(ctx)
+	} else {
+		ctx = context.WithValue(ctx, newIDKey{}, params.NewID)
+	}
+	if params.Now == nil {
+		params.Now = Clock(ctx)
+	} else {
+		ctx = context.WithValue(ctx, nowKey{}, params.Now)
+	}
+	invocationID := params.InvocationID
+	if invocationID == "" && params.NewID != nil {
+		invocationID = "e-" + params.NewID()
+	} else if invocationID == "" {
+		invocationID = "e-" + uuid.NewString()
+	}
+	parentInvocationID := params.ParentInvocationID
+	if parentInvocationID == "" {
+		parentInvocationID, _ = ctx.Value(invocationIDKey{}).(string)
+	}
+	if params.Tokens == nil {
+		params.Tokens, _ = ctx.Value(tokenCounterKey{}).(*agent.TokenCounter)
+		if params.Tokens == nil {
+			params.Tokens = &agent.TokenCounter{}
+		}
+	}
+	ctx = context.WithValue(ctx, tokenCounterKey{}, params.Tokens)
 	return &InvocationContext{
-		Context:      ctx,
-		params:       params,
-		invocationID: "e-" + uuid.NewString(),
+		Context:            context.WithValue(ctx, invocationIDKey{}, invocationID),
+		params:             params,
+		invocationID:       invocationID,
+		parentInvocationID: parentInvocationID,
 	}
 }
 
 type InvocationContext struct {
 	context.Context
 
-	params       InvocationContextParams
-	invocationID string
+	params             InvocationContextParams
+	invocationID       string
+	parentInvocationID string
 }
 
 func (c *InvocationContext) Artifacts() agent.Artifacts {
@@ -67,6 +149,10 @@ func (c *InvocationContext) InvocationID() string {
 	return c.invocationID
 }
 
+func (c *InvocationContext) ParentInvocationID() string {
+	return c.parentInvocationID
+}
+
 func (c *InvocationContext) Memory() agent.Memory {
 	return c.params.Memory
 }
@@ -79,13 +165,86 @@ func (c *InvocationContext) UserContent() *genai.Content {
 	return c.params.UserContent
 }
 
+// SetUserContent replaces the user content of the invocation, e.g. when a
+// plugin rewrote the user message.
+func (c *InvocationContext) SetUserContent(content *genai.Content) {
+	c.params.UserContent = content
+}
+
 func (c *InvocationContext) RunConfig() *agent.RunConfig {
 	return c.params.RunConfig
 }
 
+func (c *InvocationContext) Tokens() *agent.TokenCounter {
+	return c.params.Tokens
+}
+
 func (c *InvocationContext) EndInvocation() {
 	c.params.EndInvocation = true
 }
 func (c *InvocationContext) Ended() bool {
 	return c.params.EndInvocation
 }
+
+// Snapshot is the serialized state of an invocation context, from which
+// another process rehydrates the invocation to continue it.
+type Snapshot struct {
+	AppName            string `json:"appName,omitempty"`
+	UserID             string `json:"userId,omitempty"`
+	SessionID          string `json:"sessionId,omitempty"`
+	InvocationID       string `json:"invocationId"`
+	ParentInvocationID string `json:"parentInvocationId,omitempty"`
+	// Agent is the name of the agent of the invocation.
+	Agent       string         `json:"agent,omitempty"`
+	Branch      string         `json:"branch,omitempty"`
+	UserContent *genai.Content `json:"userContent,omitempty"`
+	// PendingFunctionCalls are the function calls of the invocation and of
+	// its sub-invocations which have no responses in the session yet.
+	PendingFunctionCalls []*genai.FunctionCall `json:"pendingFunctionCalls,omitempty"`
+	Ended                bool                  `json:"ended,omitempty"`
+}
+
+// MarshalJSON serializes the invocation as a [Snapshot]. The services, the
+// run config and the events of the session aren't serialized: they are
+// restored by the process which rehydrates the invocation.
+func (c *InvocationContext) MarshalJSON() ([]byte, error) {
+	snapshot := Snapshot{
+		InvocationID:       c.invocationID,
+		ParentInvocationID: c.parentInvocationID,
+		Branch:             c.params.Branch,
+		UserContent:        c.params.UserContent,
+		Ended:              c.params.EndInvocation,
+	}
+	if c.params.Agent != nil {
+		snapshot.Agent = c.params.Agent.Name()
+	}
+	if s := c.params.Session; s != nil {
+		snapshot.AppName, snapshot.UserID, snapshot.SessionID = s.AppName(), s.UserID(), s.ID()
+		snapshot.PendingFunctionCalls = c.pendingFunctionCalls()
+	}
+	return json.Marshal(snapshot)
+}
+
+// pendingFunctionCalls returns the function calls of the invocation and of
+// its sub-invocations which have no responses yet.
+func (c *InvocationContext) pendingFunctionCalls() []*genai.FunctionCall {
+	var calls []*genai.FunctionCall
+	responded := make(map[string]bool)
+	for ev := range c.params.Session.Events().All() {
+		if ev.InvocationID != c.invocationID && ev.ParentInvocationID != c.invocationID {
+			continue
+		}
+		content := utils.Content(ev)
+		calls = append(calls, utils.FunctionCalls(content)...)
+		for _, resp := range utils.FunctionResponses(content) {
+			responded[resp.ID] = true
+		}
+	}
+	var pending []*genai.FunctionCall
+	for _, call := range calls {
+		if !responded[call.ID] {
+			pending = append(pending, call)
+		}
+	}
+	return pending
+}
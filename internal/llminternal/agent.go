@@ -15,7 +15,10 @@
 package llminternal
 
 import (
+	"time"
+
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/codeexecutor"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/tool"
 	"google.golang.org/genai"
@@ -33,9 +36,14 @@ type State struct {
 	Toolsets []tool.Toolset
 
 	IncludeContents string
+	ContextWindow   *ContextWindow
 
 	GenerateContentConfig *genai.GenerateContentConfig
+	// Generation overrides the parameters of GenerateContentConfig, see
+	// generationRequestProcessor.
+	Generation GenerationParams
 
+	StaticInstruction         *genai.Content
 	Instruction               string
 	InstructionProvider       InstructionProvider
 	GlobalInstruction         string
@@ -48,6 +56,43 @@ type State struct {
 	OutputSchema *genai.Schema
 
 	OutputKey string
+
+	// MaxInlineArtifactBytes is the size above which the referenced
+	// artifacts are uploaded, see artifactReferencesRequestProcessor.
+	MaxInlineArtifactBytes int
+
+	PartialResponse *PartialResponseRecovery
+	LongOutput      *LongOutput
+
+	// MaxConcurrentToolCalls limits the function calls of a model response
+	// run concurrently, see Flow.handleFunctionCalls. Zero or 1 means the
+	// calls run sequentially.
+	MaxConcurrentToolCalls int
+	// ToolTimeout is the timeout of the tools which don't document one, see
+	// toolTimeout. Zero means no timeout.
+	ToolTimeout time.Duration
+	// ToolResultLimit limits the size of the function responses.
+	ToolResultLimit *ToolResultLimit
+	// StripThoughts removes the thought parts from the model responses, see
+	// nlPlanningResponseProcessor.
+	StripThoughts bool
+	// CodeExecutor executes the code written by the model, see
+	// codeExecutionResponseProcessor.
+	CodeExecutor codeexecutor.Executor
+
+	CacheAwareOrdering  bool
+	DebugCacheStability bool
+	DebugRequestDiff    bool
+}
+
+// GenerationParams are the generation parameters of an agent. The set ones
+// override the parameters of the GenerateContentConfig of the agent.
+type GenerationParams struct {
+	Temperature     *float32
+	TopP            *float32
+	MaxOutputTokens int32
+	SafetySettings  []*genai.SafetySetting
+	StopSequences   []string
 }
 
 type InstructionProvider func(ctx agent.ReadonlyContext) (string, error)
@@ -16,15 +16,19 @@ package llminternal
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
-	"html/template"
 	"slices"
+	"text/template"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/internal/agent/parentmap"
+	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 	"google.golang.org/genai"
 )
@@ -77,7 +81,7 @@ func AgentTransferRequestProcessor(ctx agent.InvocationContext, req *model.LLMRe
 
 	// TODO(hyangah): why do we set this up in request processor
 	// instead of registering this as a normal function tool of the Agent?
-	transferToAgentTool := &TransferToAgentTool{}
+	transferToAgentTool := &TransferToAgentTool{agent: agent, targets: targets}
 	si, err := instructionsForTransferToAgent(agent, parents[agent.Name()], targets, transferToAgentTool)
 	if err != nil {
 		return err
@@ -86,7 +90,13 @@ func AgentTransferRequestProcessor(ctx agent.InvocationContext, req *model.LLMRe
 	return appendTools(req, transferToAgentTool)
 }
 
-type TransferToAgentTool struct{}
+// TransferToAgentTool transfers the invocation to another agent.
+type TransferToAgentTool struct {
+	// agent is the agent calling the tool and targets are the agents it can
+	// transfer to. The target of the calls is validated if agent is set.
+	agent   agent.Agent
+	targets []agent.Agent
+}
 
 // Description implements tool.Tool.
 func (t *TransferToAgentTool) Description() string {
@@ -135,16 +145,88 @@ func (t *TransferToAgentTool) Run(ctx tool.Context, args any) (map[string]any, e
 	if !ok {
 		return nil, fmt.Errorf("unexpected args type: %T", args)
 	}
-	agent, ok := m["agent_name"].(string)
-	if !ok || agent == "" {
+	agentName, ok := m["agent_name"].(string)
+	if !ok || agentName == "" {
 		return nil, fmt.Errorf("empty agent_name: %v", args)
 	}
-	ctx.Actions().TransferToAgent = agent
+	if t.agent != nil && !slices.ContainsFunc(t.targets, func(a agent.Agent) bool { return a.Name() == agentName }) {
+		return t.invalidTarget(ctx, agentName), nil
+	}
+	ctx.Actions().TransferToAgent = agentName
 	return map[string]any{}, nil
 }
 
+// invalidTarget returns the function response telling the model why it
+// can't transfer to the agent and which agents it can transfer to, so that
+// it can correct the call.
+func (t *TransferToAgentTool) invalidTarget(ctx context.Context, agentName string) map[string]any {
+	reason := fmt.Sprintf("agent %q does not exist", agentName)
+	if parents := parentmap.FromContext(ctx); parents != nil {
+		if _, ok := parents[agentName]; ok || parents.RootAgent(t.agent).Name() == agentName {
+			reason = fmt.Sprintf("agent %q is not allowed to transfer to agent %q", t.agent.Name(), agentName)
+		}
+	}
+	available := make([]string, len(t.targets))
+	for i, a := range t.targets {
+		available[i] = a.Name()
+	}
+	return map[string]any{
+		"error":            reason + "; transfer to one of the available agents instead",
+		"available_agents": available,
+	}
+}
+
 var _ tool.Tool = (*TransferToAgentTool)(nil)
 
+// runBeforeTransferCallbacks lets the callbacks veto or redirect the transfer
+// to the target agent. Like the model's target, a redirected target must be
+// one of the transfer targets of the agent.
+//
+// It returns the final target, empty if the transfer was vetoed, and the
+// event with the content and state changes made by the callbacks, if any.
+func (f *Flow) runBeforeTransferCallbacks(ctx agent.InvocationContext, targetAgent string) (string, *session.Event, error) {
+	stateDelta := make(map[string]any)
+	var parts []*genai.Part
+	for _, callback := range f.BeforeTransferCallbacks {
+		cctx := icontext.NewCallbackContextWithDelta(ctx, stateDelta)
+		target, content, err := callback(cctx, targetAgent)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to run before transfer callback: %w", err)
+		}
+		if content != nil {
+			parts = append(parts, content.Parts...)
+		}
+		if target != "" && target != targetAgent && f.agentToRun(ctx, target) == nil {
+			return "", nil, fmt.Errorf("before transfer callback redirected the transfer to agent %q, which is not a transfer target of agent %q", target, ctx.Agent().Name())
+		}
+		targetAgent = target
+		if targetAgent == "" {
+			break
+		}
+	}
+	if len(parts) == 0 && len(stateDelta) == 0 {
+		return targetAgent, nil, nil
+	}
+
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	if len(parts) > 0 {
+		ev.Content = &genai.Content{Role: genai.RoleModel, Parts: parts}
+	}
+	ev.Actions.StateDelta = stateDelta
+	return targetAgent, ev, nil
+}
+
+// TransferTargets returns the agents the LLM agent a can transfer to, given
+// its parent, nil if a can't transfer at all.
+func TransferTargets(a, parent agent.Agent) []agent.Agent {
+	if !shouldUseAutoFlow(a) {
+		return nil
+	}
+	return transferTargets(a, parent)
+}
+
 func transferTargets(agent, parent agent.Agent) []agent.Agent {
 	targets := slices.Clone(agent.SubAgents())
 
@@ -249,17 +331,40 @@ func instructionsForTransferToAgent(curAgent, parent agent.Agent, targets []agen
 		parent = nil
 	}
 
+	type transferTarget struct {
+		Name, Description string
+		// Capabilities is the JSON encoding of the agent capabilities, empty
+		// if the agent declares none.
+		Capabilities string
+	}
+	var views []transferTarget
+	hasCapabilities := false
+	for _, t := range targets {
+		view := transferTarget{Name: t.Name(), Description: t.Description()}
+		if c := t.Capabilities(); !c.IsZero() {
+			b, err := json.Marshal(c)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode capabilities of agent %q: %w", t.Name(), err)
+			}
+			view.Capabilities = string(b)
+			hasCapabilities = true
+		}
+		views = append(views, view)
+	}
+
 	var buf bytes.Buffer
 	if err := transferToAgentPromptTmpl.Execute(&buf, struct {
-		AgentName string
-		Parent    agent.Agent
-		Targets   []agent.Agent
-		ToolName  string
+		AgentName       string
+		Parent          agent.Agent
+		Targets         []transferTarget
+		HasCapabilities bool
+		ToolName        string
 	}{
-		AgentName: curAgent.Name(),
-		Parent:    parent,
-		Targets:   targets,
-		ToolName:  transferTool.Name(),
+		AgentName:       curAgent.Name(),
+		Parent:          parent,
+		Targets:         views,
+		HasCapabilities: hasCapabilities,
+		ToolName:        transferTool.Name(),
 	}); err != nil {
 		return "", err
 	}
@@ -273,6 +378,14 @@ const agentTransferInstructionTemplate = `You have a list of other agents to tra
 {{range .Targets}}
 Agent name: {{.Name}}
 Agent description: {{.Description}}
+{{- if .Capabilities}}
+Agent capabilities: {{.Capabilities}}
+{{- end}}
+{{end}}{{if .HasCapabilities}}
+Agent capabilities are JSON objects with the domains the agent is competent
+in, the languages it speaks, the tools it can use and its cost tier. Transfer
+to the agent whose capabilities match the question; when several agents match
+equally, prefer the one with the lower cost tier.
 {{end}}
 If you are the best to answer the question according to your description, you
 can answer it.
@@ -405,6 +405,52 @@ func TestAgentTransferRequestProcessor(t *testing.T) {
 	})
 }
 
+func TestAgentTransferRequestProcessor_Capabilities(t *testing.T) {
+	llm := &struct{ model.LLM }{}
+	billing, err := llmagent.New(llmagent.Config{
+		Name:        "billing",
+		Description: "Handles invoices.",
+		Model:       llm,
+		Capabilities: agent.Capabilities{
+			Domains:   []string{"billing"},
+			Languages: []string{"en", "de"},
+			CostTier:  "low",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	support, err := llmagent.New(llmagent.Config{Name: "support", Description: "Answers questions.", Model: llm})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := llmagent.New(llmagent.Config{Name: "root", Model: llm, SubAgents: []agent.Agent{billing, support}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parents, err := parentmap.New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := icontext.NewInvocationContext(parentmap.ToContext(t.Context(), parents), icontext.InvocationContextParams{Agent: root})
+
+	req := &model.LLMRequest{}
+	if err := llminternal.AgentTransferRequestProcessor(ctx, req); err != nil {
+		t.Fatalf("AgentTransferRequestProcessor() = %v, want success", err)
+	}
+
+	instruction := strings.Join(utils.TextParts(req.Config.SystemInstruction), "\n")
+	for _, want := range []string{
+		"Agent name: billing\nAgent description: Handles invoices.\nAgent capabilities: {\"domains\":[\"billing\"],\"languages\":[\"en\",\"de\"],\"cost_tier\":\"low\"}\n",
+		"Agent name: support\nAgent description: Answers questions.\n\n",
+		"prefer the one with the lower cost tier",
+	} {
+		if !strings.Contains(instruction, want) {
+			t.Errorf("instruction does not contain %q, got:\n%s", want, instruction)
+		}
+	}
+}
+
 func TestAgentTransfer_ProcessRequest(t *testing.T) {
 	// First Tool
 	var req model.LLMRequest
@@ -479,6 +525,65 @@ func TestTransferToAgentToolRun(t *testing.T) {
 	})
 }
 
+func TestTransferToAgentToolRun_Targets(t *testing.T) {
+	llm := &struct{ model.LLM }{}
+	billing, err := llmagent.New(llmagent.Config{Name: "billing", Model: llm, DisallowTransferToPeers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	support, err := llmagent.New(llmagent.Config{Name: "support", Model: llm})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := llmagent.New(llmagent.Config{Name: "root", Model: llm, SubAgents: []agent.Agent{billing, support}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parents, err := parentmap.New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := icontext.NewInvocationContext(parentmap.ToContext(t.Context(), parents), icontext.InvocationContextParams{Agent: billing})
+	req := &model.LLMRequest{}
+	if err := llminternal.AgentTransferRequestProcessor(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	transferTool, ok := req.Tools["transfer_to_agent"].(*llminternal.TransferToAgentTool)
+	if !ok {
+		t.Fatalf("request tools = %v, want the transfer_to_agent tool", req.Tools)
+	}
+
+	for _, tc := range []struct {
+		target       string
+		wantTransfer string
+		wantErr      string
+	}{
+		{target: "root", wantTransfer: "root"},
+		{target: "support", wantErr: `agent "billing" is not allowed to transfer to agent "support"`},
+		{target: "ghost", wantErr: `agent "ghost" does not exist`},
+	} {
+		t.Run(tc.target, func(t *testing.T) {
+			toolCtx := toolinternal.NewToolContext(ctx, "", nil)
+			got, err := transferTool.Run(toolCtx, map[string]any{"agent_name": tc.target})
+			if err != nil {
+				t.Fatalf("Run() failed: %v", err)
+			}
+			if got := toolCtx.Actions().TransferToAgent; got != tc.wantTransfer {
+				t.Errorf("TransferToAgent = %q, want %q", got, tc.wantTransfer)
+			}
+			if tc.wantErr == "" {
+				return
+			}
+			if msg, _ := got["error"].(string); !strings.Contains(msg, tc.wantErr) {
+				t.Errorf("Run() error = %q, want it to contain %q", msg, tc.wantErr)
+			}
+			if diff := cmp.Diff([]string{"root"}, got["available_agents"]); diff != "" {
+				t.Errorf("available agents mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func stringify(v any) string {
 	s, _ := json.Marshal(v)
 	return string(s)
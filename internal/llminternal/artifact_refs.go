@@ -0,0 +1,227 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// DefaultMaxInlineArtifactBytes is the size above which the referenced
+// artifacts are uploaded if the model supports it, see model.FileUploader.
+const DefaultMaxInlineArtifactBytes = model.DefaultMaxInlineBytes
+
+// artifactRefPattern matches the references to the non-text artifacts which
+// replace the {artifact.name} placeholders in the instructions.
+var artifactRefPattern = regexp.MustCompile(`\[artifact ("(?:[^"\\]|\\.)*")\]`)
+
+// artifactRef returns the reference to the artifact in the instructions.
+func artifactRef(name string) string {
+	return fmt.Sprintf("[artifact %q]", name)
+}
+
+// artifactReferencesRequestProcessor sends the artifacts referenced in the
+// instructions, with {artifact.name} placeholders of non-text artifacts, and
+// in the function responses, with artifact.Reference, to the model. The
+// artifacts of the instructions are sent at the beginning of the contents,
+// the ones of a function response right after it. The artifacts larger than
+// the agent's MaxInlineArtifactBytes are uploaded if the model is a
+// model.FileUploader, and inlined otherwise.
+func artifactReferencesRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
+	a := asLLMAgent(ctx.Agent())
+	if a == nil || ctx.Artifacts() == nil {
+		return nil
+	}
+	loader := &artifactLoader{ctx: ctx, maxInline: a.internal().MaxInlineArtifactBytes}
+	if loader.maxInline <= 0 {
+		loader.maxInline = DefaultMaxInlineArtifactBytes
+	}
+	loader.uploader, _ = a.internal().Model.(model.FileUploader)
+
+	var contents []*genai.Content
+	found := false
+	if refs := instructionArtifactRefs(req); len(refs) > 0 {
+		c, err := loader.content(refs)
+		if err != nil {
+			return err
+		}
+		contents, found = append(contents, c), true
+	}
+	for _, c := range req.Contents {
+		contents = append(contents, c)
+		if refs := functionResponseArtifactRefs(c); len(refs) > 0 {
+			ac, err := loader.content(refs)
+			if err != nil {
+				return err
+			}
+			contents, found = append(contents, ac), true
+		}
+	}
+	if found {
+		req.Contents = contents
+	}
+	return nil
+}
+
+// artifactReference is a reference to a version of an artifact, the latest
+// one if the version is zero.
+type artifactReference struct {
+	name    string
+	version int64
+}
+
+// instructionArtifactRefs returns the references to the latest versions of
+// the artifacts referenced in the system instruction.
+func instructionArtifactRefs(req *model.LLMRequest) []artifactReference {
+	if req.Config == nil || req.Config.SystemInstruction == nil {
+		return nil
+	}
+	var refs []artifactReference
+	for _, p := range req.Config.SystemInstruction.Parts {
+		if p == nil {
+			continue
+		}
+		for _, m := range artifactRefPattern.FindAllStringSubmatch(p.Text, -1) {
+			if name, err := strconv.Unquote(m[1]); err == nil {
+				refs = append(refs, artifactReference{name: name})
+			}
+		}
+	}
+	return uniqueRefs(refs)
+}
+
+// functionResponseArtifactRefs returns the artifact references in the
+// function responses of the content.
+func functionResponseArtifactRefs(c *genai.Content) []artifactReference {
+	if c == nil {
+		return nil
+	}
+	var refs []artifactReference
+	for _, p := range c.Parts {
+		if p != nil && p.FunctionResponse != nil {
+			collectArtifactRefs(p.FunctionResponse.Response, &refs)
+		}
+	}
+	return uniqueRefs(refs)
+}
+
+func collectArtifactRefs(v any, refs *[]artifactReference) {
+	switch v := v.(type) {
+	case map[string]any:
+		if ref, ok := parseArtifactRef(v); ok {
+			*refs = append(*refs, ref)
+			return
+		}
+		for _, e := range v {
+			collectArtifactRefs(e, refs)
+		}
+	case []any:
+		for _, e := range v {
+			collectArtifactRefs(e, refs)
+		}
+	}
+}
+
+// parseArtifactRef returns the reference encoded by artifact.Reference.
+// The version is a float64 once the tool result is decoded from JSON, e.g.
+// by a persistent session service.
+func parseArtifactRef(v map[string]any) (artifactReference, bool) {
+	name, ok := v[artifact.ReferenceKey].(string)
+	if !ok {
+		return artifactReference{}, false
+	}
+	ref := artifactReference{name: name}
+	version, hasVersion := v[artifact.ReferenceVersionKey]
+	switch n := version.(type) {
+	case nil:
+	case int64:
+		ref.version = n
+	case int:
+		ref.version = int64(n)
+	case float64:
+		ref.version = int64(n)
+	default:
+		return artifactReference{}, false
+	}
+	if hasVersion {
+		return ref, len(v) == 2
+	}
+	return ref, len(v) == 1
+}
+
+func uniqueRefs(refs []artifactReference) []artifactReference {
+	slices.SortFunc(refs, func(a, b artifactReference) int {
+		return cmp.Or(strings.Compare(a.name, b.name), cmp.Compare(a.version, b.version))
+	})
+	return slices.Compact(refs)
+}
+
+// artifactLoader loads the referenced artifacts of an invocation.
+type artifactLoader struct {
+	ctx       agent.InvocationContext
+	maxInline int
+	uploader  model.FileUploader
+}
+
+// content returns the user content with the artifacts.
+func (l *artifactLoader) content(refs []artifactReference) (*genai.Content, error) {
+	c := &genai.Content{Role: genai.RoleUser}
+	for _, ref := range refs {
+		resp, err := l.load(ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load referenced artifact %s: %w", ref.name, err)
+		}
+		part, err := l.maybeUpload(ref.name, resp.Part)
+		if err != nil {
+			return nil, err
+		}
+		c.Parts = append(c.Parts, genai.NewPartFromText("Artifact "+ref.name+" is:"), part)
+	}
+	return c, nil
+}
+
+// load loads the referenced version of the artifact.
+func (l *artifactLoader) load(ref artifactReference) (*artifact.LoadResponse, error) {
+	if ref.version == 0 {
+		return l.ctx.Artifacts().Load(l.ctx, ref.name)
+	}
+	return l.ctx.Artifacts().LoadVersion(l.ctx, ref.name, int(ref.version))
+}
+
+// maybeUpload returns the part of the uploaded artifact if it's too large
+// to inline and the model supports file uploads, or else the part itself.
+func (l *artifactLoader) maybeUpload(name string, part *genai.Part) (*genai.Part, error) {
+	if l.uploader == nil || part.InlineData == nil || len(part.InlineData.Data) <= l.maxInline {
+		return part, nil
+	}
+	data, err := l.uploader.UploadFile(l.ctx, name, part.InlineData)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return part, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload referenced artifact %s: %w", name, err)
+	}
+	return &genai.Part{FileData: data}, nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// requestCredentialEvent returns the event asking the client for the
+// credentials requested by the tools in the function response event, or nil
+// if the tools didn't request any.
+//
+// The function calls of the event are long-running: the invocation ends and
+// is resumed by resumeAuthenticatedTools when the client responds.
+func requestCredentialEvent(ctx agent.InvocationContext, fnResponseEvent *session.Event) (*session.Event, error) {
+	requested := fnResponseEvent.Actions.RequestedAuthConfigs
+	if len(requested) == 0 {
+		return nil, nil
+	}
+
+	content := &genai.Content{Role: genai.RoleModel}
+	for _, fnCallID := range slices.Sorted(maps.Keys(requested)) {
+		cfg, err := auth.PrepareRequest(requested[fnCallID])
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare credential request for function call %q: %w", fnCallID, err)
+		}
+		var args map[string]any
+		if err := convert(auth.RequestCredentialArgs{FunctionCallID: fnCallID, AuthConfig: cfg}, &args); err != nil {
+			return nil, fmt.Errorf("failed to encode credential request for function call %q: %w", fnCallID, err)
+		}
+		content.Parts = append(content.Parts, &genai.Part{
+			FunctionCall: &genai.FunctionCall{Name: auth.RequestCredentialFunctionName, Args: args},
+		})
+	}
+	utils.PopulateClientFunctionCallID(content)
+
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.LLMResponse = model.LLMResponse{Content: content}
+	for _, fnCall := range utils.FunctionCalls(content) {
+		ev.LongRunningToolIDs = append(ev.LongRunningToolIDs, fnCall.ID)
+	}
+	return ev, nil
+}
+
+// resumeAuthenticatedTools handles the client responses to the credential
+// requests in the last event of the session: the credentials are exchanged
+// and the tools which requested them are called again, with the credentials
+// available through tool.Context.Credential. It returns the function response
+// event of the tools, or nil if there's nothing to resume.
+//
+// See adk-python src/google/adk/auth/auth_preprocessor.py.
+func (f *Flow) resumeAuthenticatedTools(ctx agent.InvocationContext) (*session.Event, error) {
+	events := ctx.Session().Events()
+	if events.Len() == 0 {
+		return nil, nil
+	}
+	last := events.At(events.Len() - 1)
+	if last.Author != "user" {
+		return nil, nil
+	}
+	responses := make(map[string]*genai.FunctionResponse)
+	for _, fnResp := range utils.FunctionResponses(last.LLMResponse.Content) {
+		if fnResp.Name == auth.RequestCredentialFunctionName {
+			responses[fnResp.ID] = fnResp
+		}
+	}
+	if len(responses) == 0 {
+		return nil, nil
+	}
+
+	// Exchange the credentials and find the tools to resume.
+	stateDelta := make(map[string]any)
+	toolCallIDs := make(map[string]bool)
+	for i := events.Len() - 2; i >= 0 && len(responses) > 0; i-- {
+		for _, fnCall := range utils.FunctionCalls(events.At(i).LLMResponse.Content) {
+			fnResp, ok := responses[fnCall.ID]
+			if !ok || fnCall.Name != auth.RequestCredentialFunctionName {
+				continue
+			}
+			delete(responses, fnCall.ID)
+
+			var args auth.RequestCredentialArgs
+			if err := convert(fnCall.Args, &args); err != nil {
+				return nil, fmt.Errorf("invalid credential request %q: %w", fnCall.ID, err)
+			}
+			if args.AuthConfig == nil {
+				return nil, fmt.Errorf("credential request %q has no auth config", fnCall.ID)
+			}
+			var cfg auth.Config
+			if err := convert(fnResp.Response, &cfg); err != nil {
+				return nil, fmt.Errorf("invalid credential response %q: %w", fnResp.ID, err)
+			}
+			cred, err := auth.Exchange(ctx, &cfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to exchange credential for function call %q: %w", args.FunctionCallID, err)
+			}
+			if err := toolinternal.SaveCredential(ctx, args.AuthConfig, cred); err != nil {
+				return nil, fmt.Errorf("failed to save credential for function call %q: %w", args.FunctionCallID, err)
+			}
+			// The key of the requested config is used, as the one of the
+			// returned config depends on what the client filled in.
+			stateDelta[toolinternal.CredentialStateKey(args.AuthConfig)] = cred
+			toolCallIDs[args.FunctionCallID] = true
+		}
+	}
+	if len(toolCallIDs) == 0 {
+		return nil, nil
+	}
+
+	content := &genai.Content{Role: genai.RoleModel}
+	for i := events.Len() - 2; i >= 0 && len(toolCallIDs) > 0; i-- {
+		ev := events.At(i)
+		if ev.Author != ctx.Agent().Name() {
+			continue
+		}
+		for _, fnCall := range utils.FunctionCalls(ev.LLMResponse.Content) {
+			if toolCallIDs[fnCall.ID] {
+				delete(toolCallIDs, fnCall.ID)
+				content.Parts = append(content.Parts, &genai.Part{FunctionCall: fnCall})
+			}
+		}
+	}
+	if len(content.Parts) == 0 {
+		return nil, nil
+	}
+
+	tools, err := f.toolsDict(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ev, err := f.handleFunctionCalls(ctx, tools, &model.LLMResponse{Content: content}, stateDelta)
+	if err != nil || ev == nil {
+		return ev, err
+	}
+	// The credentials must not leave the invocation.
+	for k := range stateDelta {
+		delete(ev.Actions.StateDelta, k)
+	}
+	return ev, nil
+}
+
+// convert converts between a struct and its JSON map representation.
+func convert(from, to any) error {
+	b, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, to)
+}
@@ -15,20 +15,30 @@
 package llminternal
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"iter"
 	"maps"
 	"slices"
+	"time"
 
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/featureflag"
 	"google.golang.org/adk/internal/agent/parentmap"
 	"google.golang.org/adk/internal/agent/runconfig"
 	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/logging"
 	"google.golang.org/adk/internal/telemetry"
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/offline"
+	"google.golang.org/adk/replay"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/summarizer"
 	"google.golang.org/adk/tool"
 	"google.golang.org/genai"
 )
@@ -41,6 +51,8 @@ type BeforeToolCallback func(ctx tool.Context, tool tool.Tool, args map[string]a
 
 type AfterToolCallback func(ctx tool.Context, tool tool.Tool, args map[string]any, result map[string]any, err error) (map[string]any, error)
 
+type BeforeTransferCallback func(ctx agent.CallbackContext, targetAgent string) (string, *genai.Content, error)
+
 type Flow struct {
 	Model model.LLM
 
@@ -50,11 +62,18 @@ type Flow struct {
 	AfterModelCallbacks  []AfterModelCallback
 	BeforeToolCallbacks  []BeforeToolCallback
 	AfterToolCallbacks   []AfterToolCallback
+
+	BeforeTransferCallbacks []BeforeTransferCallback
+
+	// HandoffSummarizer, if set, generates a handoff summary for the target
+	// agent on agent transfer.
+	HandoffSummarizer summarizer.Summarizer
 }
 
 var (
 	DefaultRequestProcessors = []func(ctx agent.InvocationContext, req *model.LLMRequest) error{
 		basicRequestProcessor,
+		generationRequestProcessor,
 		authPreprocessor,
 		instructionsRequestProcessor,
 		identityRequestProcessor,
@@ -65,6 +84,11 @@ var (
 		// Code execution should be after contentsRequestProcessor as it mutates the contents
 		// to optimize data files.
 		codeExecutionRequestProcessor,
+		// The referenced artifacts are sent after the contents they are
+		// referenced by.
+		artifactReferencesRequestProcessor,
+		// Deferred instructions are placed relative to the final contents.
+		deferredInstructionsRequestProcessor,
 		AgentTransferRequestProcessor,
 		removeDisplayNameIfExists,
 	}
@@ -76,9 +100,56 @@ var (
 
 func (f *Flow) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 	return func(yield func(*session.Event, error) bool) {
-		for {
+		ev, err := f.resumeAuthenticatedTools(ctx)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if ev != nil {
+			if !yield(ev, nil) {
+				return
+			}
+			authEvent, err := requestCredentialEvent(ctx, ev)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if authEvent != nil {
+				yield(authEvent, nil)
+				return
+			}
+		} else {
+			ev, err := f.resumePendingToolCalls(ctx)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if ev != nil {
+				if !yield(ev, nil) {
+					return
+				}
+				if ev.Actions.TransferToAgent != "" {
+					for ev, err := range f.transfer(ctx, ev.Actions.TransferToAgent) {
+						if !yield(ev, err) || err != nil { // forward
+							return
+						}
+					}
+					return
+				}
+			}
+		}
+		steps := &stepState{}
+		maxIterations := 0
+		if cfg := ctx.RunConfig(); cfg != nil {
+			maxIterations = cfg.MaxIterations
+		}
+		for iteration := 0; ; iteration++ {
+			if maxIterations > 0 && iteration >= maxIterations {
+				yield(nil, fmt.Errorf("agent %q: %w (%d)", ctx.Agent().Name(), agent.ErrMaxIterations, maxIterations))
+				return
+			}
 			var lastEvent *session.Event
-			for ev, err := range f.runOneStep(ctx) {
+			for ev, err := range f.runOneStep(ctx, steps) {
 				if err != nil {
 					yield(nil, err)
 					return
@@ -89,6 +160,12 @@ func (f *Flow) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error]
 				}
 				lastEvent = ev
 			}
+			// The invocation continued with the transfer target: the
+			// current agent's loop ends here, whatever the target's last
+			// event was.
+			if steps.transferred {
+				return
+			}
 			if lastEvent == nil || lastEvent.IsFinalResponse() {
 				return
 			}
@@ -102,19 +179,59 @@ func (f *Flow) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error]
 	}
 }
 
-func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+// stepState is carried between the steps of an invocation.
+type stepState struct {
+	// prevRequest is the model request of the previous step.
+	prevRequest *model.LLMRequest
+	// prevPromptPrefix is the request prefix of the previous step, see
+	// reportPromptPrefixChange.
+	prevPromptPrefix []byte
+	// transferred reports whether the step handed the invocation over to
+	// another agent.
+	transferred bool
+}
+
+func (f *Flow) runOneStep(ctx agent.InvocationContext, steps *stepState) iter.Seq2[*session.Event, error] {
 	return func(yield func(*session.Event, error) bool) {
 		req := &model.LLMRequest{}
 
+		compaction, err := compactHistory(ctx)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if compaction != nil && !yield(compaction, nil) {
+			return
+		}
+
 		// Preprocess before calling the LLM.
 		if err := f.preprocess(ctx, req); err != nil {
 			yield(nil, err)
 			return
 		}
+		steps.prevPromptPrefix = reportPromptPrefixChange(ctx, steps.prevPromptPrefix, req)
 		if ctx.Ended() {
 			return
 		}
+		if err := checkTokenBudget(ctx, req); err != nil {
+			ctx.EndInvocation()
+			if !yield(tokenBudgetExceededEvent(ctx, err), nil) {
+				return
+			}
+			yield(nil, err)
+			return
+		}
+		if err := checkRequestSize(ctx, f.Model, req); err != nil {
+			yield(nil, err)
+			return
+		}
+		if err := checkCapabilities(f.Model, req); err != nil {
+			yield(nil, err)
+			return
+		}
 		spans := telemetry.StartTrace(ctx, "call_llm")
+		reportRequestDiff(ctx, spans, steps.prevRequest, req)
+		steps.prevRequest = req
 		// Create event to pass to callback state delta
 		stateDelta := make(map[string]any)
 		// Calls the LLM.
@@ -130,20 +247,27 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 			// Skip the model response event if there is no content and no error code.
 			// This is needed for the code executor to trigger another loop according to
 			// adk-python src/google/adk/flows/llm_flows/base_llm_flow.py BaseLlmFlow._postprocess_async.
-			if resp.Content == nil && resp.ErrorCode == "" && !resp.Interrupted {
+			// The interruptions, the ends of the turns and the audio
+			// transcriptions are forwarded, as the live clients rely on them.
+			if resp.Content == nil && resp.ErrorCode == "" && !resp.Interrupted && !resp.TurnComplete &&
+				resp.InputTranscription == nil && resp.OutputTranscription == nil {
 				continue
 			}
-
-			// TODO: temporarily convert
-			tools := make(map[string]tool.Tool)
-			for k, v := range req.Tools {
-				tool, ok := v.(tool.Tool)
-				if !ok {
-					if !yield(nil, fmt.Errorf("unexpected tool type %T for tool %v", v, k)) {
-						return
-					}
+			// The partial responses streamed in SSE mode are forwarded as
+			// they are. The function calls and the state delta are handled
+			// with the final response, which is committed to the session.
+			if resp.Partial {
+				ev := f.finalizeModelResponseEvent(ctx, resp, nil, make(map[string]any))
+				if !yield(ev, nil) {
+					return
 				}
-				tools[k] = tool
+				continue
+			}
+
+			tools, err := requestTools(req)
+			if err != nil {
+				yield(nil, err)
+				return
 			}
 
 			// Build the event and yield.
@@ -152,11 +276,9 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 			if !yield(modelResponseEvent, nil) {
 				return
 			}
-			// TODO: generate and yield an auth event if needed.
-
 			// Handle function calls.
 
-			ev, err := f.handleFunctionCalls(ctx, tools, resp)
+			ev, err := f.handleFunctionCalls(ctx, tools, resp, nil)
 			if err != nil {
 				yield(nil, err)
 				return
@@ -165,9 +287,31 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 				// nothing to yield/process.
 				continue
 			}
+			var transferEvent *session.Event
+			if ev.Actions.TransferToAgent != "" {
+				ev.Actions.TransferToAgent, transferEvent, err = f.runBeforeTransferCallbacks(ctx, ev.Actions.TransferToAgent)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+			}
 			if !yield(ev, nil) {
 				return
 			}
+			// The tools wait for credentials from the client: end the
+			// invocation with the credential requests.
+			authEvent, err := requestCredentialEvent(ctx, ev)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if authEvent != nil {
+				yield(authEvent, nil)
+				return
+			}
+			if transferEvent != nil && !yield(transferEvent, nil) {
+				return
+			}
 
 			// Actually handle "transfer_to_agent" tool. The function call sets the ev.Actions.TransferToAgent field.
 			// We are followng python's execution flow which is
@@ -177,12 +321,8 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 			if ev.Actions.TransferToAgent == "" {
 				return
 			}
-			nextAgent := f.agentToRun(ctx, ev.Actions.TransferToAgent)
-			if nextAgent == nil {
-				yield(nil, fmt.Errorf("failed to find agent: %s", ev.Actions.TransferToAgent))
-				return
-			}
-			for ev, err := range nextAgent.Run(ctx) {
+			steps.transferred = true
+			for ev, err := range f.transfer(ctx, ev.Actions.TransferToAgent) {
 				if !yield(ev, err) || err != nil { // forward
 					return
 				}
@@ -191,6 +331,34 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 	}
 }
 
+// transfer continues the invocation with the agent named agentName,
+// forwarding the events of its run. The agent is located among the transfer
+// targets of the current agent in the agent tree.
+func (f *Flow) transfer(ctx agent.InvocationContext, agentName string) iter.Seq2[*session.Event, error] {
+	return func(yield func(*session.Event, error) bool) {
+		nextAgent := f.agentToRun(ctx, agentName)
+		if nextAgent == nil {
+			yield(nil, fmt.Errorf("failed to find agent: %s", agentName))
+			return
+		}
+		if f.HandoffSummarizer != nil && flagEnabled(ctx, featureflag.HandoffSummary, true) {
+			summaryEvent, err := f.handoffSummaryEvent(ctx, nextAgent)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if summaryEvent != nil && !yield(summaryEvent, nil) {
+				return
+			}
+		}
+		for ev, err := range nextAgent.Run(ctx) {
+			if !yield(ev, err) || err != nil { // forward
+				return
+			}
+		}
+	}
+}
+
 func (f *Flow) preprocess(ctx agent.InvocationContext, req *model.LLMRequest) error {
 	llmAgent, ok := ctx.Agent().(Agent)
 	if !ok {
@@ -205,17 +373,67 @@ func (f *Flow) preprocess(ctx agent.InvocationContext, req *model.LLMRequest) er
 	}
 
 	// run processors for tools.
+	tools, err := agentTools(ctx, llmAgent)
+	if err != nil {
+		return err
+	}
+	if err := toolPreprocess(ctx, req, tools); err != nil {
+		return err
+	}
+
+	state := Reveal(llmAgent)
+	if flagEnabled(ctx, featureflag.CacheAwareOrdering, state.CacheAwareOrdering) {
+		stabilizePromptPrefix(req)
+	}
+	return nil
+}
+
+// agentTools returns the tools of the agent, including the tools of its
+// toolsets.
+func agentTools(ctx agent.InvocationContext, llmAgent Agent) ([]tool.Tool, error) {
 	tools := Reveal(llmAgent).Tools
 	for _, toolSet := range Reveal(llmAgent).Toolsets {
 		tsTools, err := toolSet.Tools(icontext.NewReadonlyContext(ctx))
 		if err != nil {
-			return fmt.Errorf("failed to extract tools from the tool set %q: %w", toolSet.Name(), err)
+			return nil, &tool.CallError{Tool: toolSet.Name(), Err: fmt.Errorf("failed to extract tools from the tool set %q: %w", toolSet.Name(), err)}
 		}
 
 		tools = append(tools, tsTools...)
 	}
+	return tools, nil
+}
 
-	return toolPreprocess(ctx, req, tools)
+// toolsDict returns the tools the model can call, by name, as collected by
+// preprocess.
+func (f *Flow) toolsDict(ctx agent.InvocationContext) (map[string]tool.Tool, error) {
+	llmAgent, ok := ctx.Agent().(Agent)
+	if !ok {
+		return nil, fmt.Errorf("agent %v is not an LLMAgent", ctx.Agent().Name())
+	}
+	tools, err := agentTools(ctx, llmAgent)
+	if err != nil {
+		return nil, err
+	}
+	req := &model.LLMRequest{}
+	if err := toolPreprocess(ctx, req, tools); err != nil {
+		return nil, err
+	}
+	return requestTools(req)
+}
+
+// requestTools returns the tools registered in the request by the tool
+// preprocessing.
+func requestTools(req *model.LLMRequest) (map[string]tool.Tool, error) {
+	// TODO: temporarily convert
+	tools := make(map[string]tool.Tool)
+	for k, v := range req.Tools {
+		tool, ok := v.(tool.Tool)
+		if !ok {
+			return nil, fmt.Errorf("unexpected tool type %T for tool %v", v, k)
+		}
+		tools[k] = tool
+	}
+	return tools, nil
 }
 
 // toolPreprocess runs tool preprocess on the given request
@@ -238,7 +456,7 @@ func toolPreprocess(ctx agent.InvocationContext, req *model.LLMRequest, tools []
 
 func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, stateDelta map[string]any) iter.Seq2[*model.LLMResponse, error] {
 	return func(yield func(*model.LLMResponse, error) bool) {
-		for _, callback := range f.BeforeModelCallbacks {
+		for _, callback := range f.beforeModelCallbacks(ctx) {
 			cctx := icontext.NewCallbackContextWithDelta(ctx, stateDelta)
 			callbackResponse, callbackErr := callback(cctx, req)
 
@@ -248,7 +466,14 @@ func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, state
 			}
 		}
 
-		if f.Model == nil {
+		llm := f.Model
+		if p := offlineProfile(ctx); p != nil {
+			llm = p.Model()
+		}
+		if r := replayer(ctx); r != nil {
+			llm = r.Model(ctx.Agent().Name())
+		}
+		if llm == nil {
 			yield(nil, fmt.Errorf("agent %q has no Model configured; ensure Model is set in llmagent.Config", ctx.Agent().Name()))
 			return
 		}
@@ -258,8 +483,47 @@ func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, state
 
 		// TODO: RunLive mode when invocation_context.run_config.support_cfc is true.
 		useStream := runconfig.FromContext(ctx).StreamingMode == runconfig.StreamingModeSSE
-
-		for resp, err := range f.Model.GenerateContent(ctx, req, useStream) {
+		logger := logging.ForInvocation(ctx).With("model", llm.Name())
+		logger.Debug("model request", "contents", len(req.Contents), "stream", useStream)
+
+		// Models are expected to follow their partial responses with the
+		// aggregated response, like the Gemini model. For the models which
+		// don't, the partial text is aggregated here, so that the final
+		// response can be committed to the session.
+		aggregator := NewStreamingResponseAggregator()
+		lastPartial := false
+		generate := func(req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+			return llm.GenerateContent(ctx, req, useStream)
+		}
+		llmAgent := asLLMAgent(ctx.Agent())
+		if useStream && llmAgent != nil && llmAgent.internal().PartialResponse != nil {
+			generate = func(req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+				return llmAgent.internal().PartialResponse.generate(ctx, llm, req)
+			}
+		}
+		stream := generate(req)
+		if llmAgent != nil && llmAgent.internal().LongOutput != nil {
+			stream = llmAgent.internal().LongOutput.generate(generate, req)
+		}
+		// The usage is counted once per model call, from the last final
+		// response: the streaming models may report the same usage on
+		// several final responses, e.g. on the aggregated response and on
+		// the last chunk.
+		var final *model.LLMResponse
+		defer func() { countTokens(ctx, req, final) }()
+		for resp, err := range stream {
+			if useStream && resp != nil {
+				if resp.Partial {
+					aggregator.addPartial(resp)
+				} else {
+					aggregator.clear()
+				}
+				lastPartial = resp.Partial
+			}
+			if err == nil && resp != nil && !resp.Partial {
+				final = resp
+				logger.Debug("model response", "finish_reason", resp.FinishReason)
+			}
 			callbackResp, callbackErr := f.runAfterModelCallbacks(ctx, resp, stateDelta, err)
 			// TODO: check if we should stop iterator on the first error from stream or continue yielding next results.
 			if callbackErr != nil {
@@ -276,7 +540,8 @@ func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, state
 
 			// TODO: check if we should stop iterator on the first error from stream or continue yielding next results.
 			if err != nil {
-				yield(nil, err)
+				logger.Warn("model call failed", "error", err)
+				yield(nil, &model.CallError{Model: llm.Name(), Err: err})
 				return
 			}
 
@@ -284,11 +549,28 @@ func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, state
 				return
 			}
 		}
+		if !lastPartial {
+			return
+		}
+		resp := aggregator.Close()
+		if resp == nil {
+			return
+		}
+		final = resp
+		callbackResp, callbackErr := f.runAfterModelCallbacks(ctx, resp, stateDelta, nil)
+		if callbackErr != nil {
+			yield(nil, callbackErr)
+			return
+		}
+		if callbackResp != nil {
+			resp = callbackResp
+		}
+		yield(resp, nil)
 	}
 }
 
 func (f *Flow) runAfterModelCallbacks(ctx agent.InvocationContext, llmResp *model.LLMResponse, stateDelta map[string]any, llmErr error) (*model.LLMResponse, error) {
-	for _, callback := range f.AfterModelCallbacks {
+	for _, callback := range f.afterModelCallbacks(ctx) {
 		cctx := icontext.NewCallbackContextWithDelta(ctx, stateDelta)
 		callbackResponse, callbackErr := callback(cctx, llmResp, llmErr)
 
@@ -358,51 +640,48 @@ func findLongRunningFunctionCallIDs(c *genai.Content, tools map[string]tool.Tool
 }
 
 // handleFunctionCalls calls the functions and returns the function response event.
+// The tools see stateDelta, if not nil, as the initial state delta.
+//
+// The calls run sequentially, or concurrently up to the
+// MaxConcurrentToolCalls of the agent at a time, and their responses are
+// merged in the order of the calls. No tool runs if any of the calls refers
+// to an unknown tool.
 //
 // TODO: accept filters to include/exclude function calls.
-// TODO: check feasibility of running tool.Run concurrently.
-func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[string]tool.Tool, resp *model.LLMResponse) (*session.Event, error) {
-	var fnResponseEvents []*session.Event
-
+func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[string]tool.Tool, resp *model.LLMResponse, stateDelta map[string]any) (*session.Event, error) {
 	fnCalls := utils.FunctionCalls(resp.Content)
-	for _, fnCall := range fnCalls {
+	funcTools := make([]toolinternal.FunctionTool, len(fnCalls))
+	for i, fnCall := range fnCalls {
 		curTool, ok := toolsDict[fnCall.Name]
 		if !ok {
-			return nil, fmt.Errorf("unknown tool: %q", fnCall.Name)
+			return nil, &tool.CallError{Tool: fnCall.Name, Err: fmt.Errorf("%w: %q", tool.ErrToolNotFound, fnCall.Name)}
 		}
 		funcTool, ok := curTool.(toolinternal.FunctionTool)
 		if !ok {
-			return nil, fmt.Errorf("tool %q is not a function tool", curTool.Name())
-		}
-		toolCtx := toolinternal.NewToolContext(ctx, fnCall.ID, &session.EventActions{StateDelta: make(map[string]any)})
-		//toolCtx := tool.
-		spans := telemetry.StartTrace(ctx, "execute_tool "+fnCall.Name)
-
-		result := f.callTool(funcTool, fnCall.Args, toolCtx)
-
-		// TODO: agent.canonical_after_tool_callbacks
-		// TODO: handle long-running tool.
-		ev := session.NewEvent(ctx.InvocationID())
-		ev.LLMResponse = model.LLMResponse{
-			Content: &genai.Content{
-				Role: "user",
-				Parts: []*genai.Part{
-					{
-						FunctionResponse: &genai.FunctionResponse{
-							ID:       fnCall.ID,
-							Name:     fnCall.Name,
-							Response: result,
-						},
-					},
-				},
-			},
+			return nil, &tool.CallError{Tool: fnCall.Name, Err: fmt.Errorf("tool %q is not a function tool", curTool.Name())}
 		}
-		ev.Author = ctx.Agent().Name()
-		ev.Branch = ctx.Branch()
-		ev.Actions = *toolCtx.Actions()
-		telemetry.TraceToolCall(spans, curTool, fnCall.Args, ev)
-		fnResponseEvents = append(fnResponseEvents, ev)
+		funcTools[i] = funcTool
 	}
+
+	// Each call writes only its own slot, so the merged event keeps the call
+	// order no matter in which order the tools finish.
+	fnResponseEvents := make([]*session.Event, len(fnCalls))
+	if limit := maxConcurrentToolCalls(ctx); limit > 1 && len(fnCalls) > 1 {
+		var g errgroup.Group
+		g.SetLimit(limit)
+		for i, fnCall := range fnCalls {
+			g.Go(func() error {
+				fnResponseEvents[i] = f.handleFunctionCall(ctx, funcTools[i], fnCall, stateDelta)
+				return nil
+			})
+		}
+		_ = g.Wait()
+	} else {
+		for i, fnCall := range fnCalls {
+			fnResponseEvents[i] = f.handleFunctionCall(ctx, funcTools[i], fnCall, stateDelta)
+		}
+	}
+
 	mergedEvent, err := mergeParallelFunctionResponseEvents(fnResponseEvents)
 	if err != nil {
 		return mergedEvent, err
@@ -413,14 +692,82 @@ func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[st
 	return mergedEvent, nil
 }
 
-func (f *Flow) callTool(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context) map[string]any {
+// handleFunctionCall calls a single function and returns its function
+// response event.
+func (f *Flow) handleFunctionCall(ctx agent.InvocationContext, funcTool toolinternal.FunctionTool, fnCall *genai.FunctionCall, stateDelta map[string]any) *session.Event {
+	actions := &session.EventActions{StateDelta: make(map[string]any)}
+	maps.Copy(actions.StateDelta, stateDelta)
+	toolCtx := toolinternal.NewToolContext(ctx, fnCall.ID, actions)
+	spans := telemetry.StartTrace(ctx, "execute_tool "+fnCall.Name)
+	logger := logging.ForInvocation(ctx).With("tool", fnCall.Name, "function_call_id", fnCall.ID)
+	logger.Debug("tool call")
+
+	result := f.callTool(funcTool, fnCall.Args, toolCtx, toolTimeout(ctx, funcTool))
+	if err, ok := result["error"].(error); ok {
+		logger.Warn("tool call failed", "error", err)
+	} else {
+		logger.Debug("tool response")
+	}
+	if llmAgent := asLLMAgent(ctx.Agent()); llmAgent != nil {
+		result = llmAgent.internal().ToolResultLimit.apply(toolCtx, fnCall.Name, result)
+	}
+
+	// TODO: agent.canonical_after_tool_callbacks
+	// TODO: handle long-running tool.
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.LLMResponse = model.LLMResponse{
+		Content: &genai.Content{
+			Role: "user",
+			Parts: []*genai.Part{
+				{
+					FunctionResponse: &genai.FunctionResponse{
+						ID:       fnCall.ID,
+						Name:     fnCall.Name,
+						Response: result,
+					},
+				},
+			},
+		},
+	}
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.Actions = *toolCtx.Actions()
+	telemetry.TraceToolCall(spans, funcTool, fnCall.Args, ev)
+	return ev
+}
+
+// maxConcurrentToolCalls returns the limit of the function calls run
+// concurrently by the agent of the invocation, 0 or 1 if they run
+// sequentially.
+func maxConcurrentToolCalls(ctx agent.InvocationContext) int {
+	if llmAgent := asLLMAgent(ctx.Agent()); llmAgent != nil {
+		return llmAgent.internal().MaxConcurrentToolCalls
+	}
+	return 0
+}
+
+// toolTimeout returns the timeout of the calls of the tool: the timeout it
+// documents, or else the tool timeout of the agent of the invocation.
+func toolTimeout(ctx agent.InvocationContext, t tool.Tool) time.Duration {
+	if d, ok := t.(tool.Documented); ok {
+		if timeout := d.Requirements().Timeout; timeout > 0 {
+			return timeout
+		}
+	}
+	if llmAgent := asLLMAgent(ctx.Agent()); llmAgent != nil {
+		return llmAgent.internal().ToolTimeout
+	}
+	return 0
+}
+
+func (f *Flow) callTool(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context, timeout time.Duration) map[string]any {
 	// If the result is present, it will be used instead of calling the actual tool.
 	result, err := f.invokeBeforeToolCallbacks(tool, fArgs, toolCtx)
 	if err != nil {
 		return map[string]any{"error": fmt.Errorf("BeforeToolCallback failed: %w", err)}
 	}
 	if result == nil {
-		result, err = tool.Run(toolCtx, fArgs)
+		result, err = runTool(tool, fArgs, toolCtx, timeout)
 		if err != nil {
 			return map[string]any{"error": fmt.Errorf("tool %q failed: %w", tool.Name(), err)}
 		}
@@ -436,8 +783,97 @@ func (f *Flow) callTool(tool toolinternal.FunctionTool, fArgs map[string]any, to
 	return result
 }
 
+// runTool runs the tool, or returns its fixture in offline mode.
+//
+// If timeout is positive, the tool runs in its own goroutine and the call
+// fails once the timeout elapses, canceling the context of the tool. The
+// changes the abandoned tool makes to its actions are dropped.
+func runTool(t toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context, timeout time.Duration) (map[string]any, error) {
+	if _, ok := t.(*TransferToAgentTool); !ok {
+		if p := offlineProfile(toolCtx); p != nil {
+			if result, ok := p.ToolResult(t.Name()); ok {
+				return result, nil
+			}
+		}
+		if r := replayer(toolCtx); r != nil {
+			if result, ok := r.ToolResult(toolCtx.AgentName(), t.Name(), fArgs); ok {
+				return result, nil
+			}
+			return nil, fmt.Errorf("replay: call of tool %q with these arguments wasn't recorded", t.Name())
+		}
+	}
+	if timeout <= 0 {
+		return t.Run(toolCtx, fArgs)
+	}
+
+	ctx, cancel := context.WithTimeout(toolCtx, timeout)
+	defer cancel()
+	detached, commit := toolinternal.Detach(ctx, toolCtx)
+	type outcome struct {
+		result map[string]any
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := t.Run(detached, fArgs)
+		done <- outcome{result, err}
+	}()
+	select {
+	case o := <-done:
+		commit()
+		return o.result, o.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && toolCtx.Err() == nil {
+			return nil, fmt.Errorf("timed out after %v: %w", timeout, ctx.Err())
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// offlineProfile returns the offline profile of the run, or nil if the
+// agents run as usual.
+func offlineProfile(ctx context.Context) *offline.Profile {
+	if cfg := runconfig.FromContext(ctx); cfg != nil {
+		return cfg.Offline
+	}
+	return nil
+}
+
+// flagEnabled reports whether the feature flag is enabled for the session of
+// the invocation. It returns configured, the configuration of the agent, if
+// the run has no feature flags or they don't set the flag.
+func flagEnabled(ctx agent.InvocationContext, flag string, configured bool) bool {
+	cfg := runconfig.FromContext(ctx)
+	if cfg == nil || cfg.FeatureFlags == nil {
+		return configured
+	}
+	var scope featureflag.Scope
+	if s := ctx.Session(); s != nil {
+		scope = featureflag.Scope{AppName: s.AppName(), UserID: s.UserID()}
+	}
+	return featureflag.Enabled(ctx, cfg.FeatureFlags, flag, scope, configured)
+}
+
+func replayer(ctx context.Context) *replay.Replayer {
+	if cfg := runconfig.FromContext(ctx); cfg != nil {
+		return cfg.Replay
+	}
+	return nil
+}
+
+// summarizerFor returns the summarizer, or in offline mode a summarizer
+// backed by the model of the offline profile.
+func summarizerFor(ctx context.Context, s summarizer.Summarizer) summarizer.Summarizer {
+	if p := offlineProfile(ctx); p != nil {
+		if offlineSummarizer, err := summarizer.New(summarizer.Config{Model: p.Model()}); err == nil {
+			return offlineSummarizer
+		}
+	}
+	return s
+}
+
 func (f *Flow) invokeBeforeToolCallbacks(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context) (map[string]any, error) {
-	for _, callback := range f.BeforeToolCallbacks {
+	for _, callback := range f.beforeToolCallbacks(toolCtx) {
 		result, err := callback(toolCtx, tool, fArgs)
 		if err != nil {
 			return nil, fmt.Errorf("failed to execute callback: %w", err)
@@ -452,7 +888,7 @@ func (f *Flow) invokeBeforeToolCallbacks(tool toolinternal.FunctionTool, fArgs m
 }
 
 func (f *Flow) invokeAfterToolCallbacks(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context, fResult map[string]any, fErr error) (map[string]any, error) {
-	for _, callback := range f.AfterToolCallbacks {
+	for _, callback := range f.afterToolCallbacks(toolCtx) {
 		result, err := callback(toolCtx, tool, fArgs, fResult, fErr)
 		if err != nil {
 			return nil, fmt.Errorf("failed to execute callback: %w", err)
@@ -497,9 +933,9 @@ func mergeParallelFunctionResponseEvents(events []*session.Event) (*session.Even
 func mergeEventActions(base, other *session.EventActions) *session.EventActions {
 	// flows/llm_flows/functions.py merge_parallel_function_response_events
 	//
-	// TODO: merge_parallel_function_response_events creates a "last one wins" scenario
-	// except parts and requested_auth_configs. Check with the ADK team about
-	// the intention.
+	// The state and artifact deltas are merged key by key, so that the
+	// changes of all the calls are kept; the later calls win on the same
+	// keys.
 	if other == nil {
 		return base
 	}
@@ -516,7 +952,22 @@ func mergeEventActions(base, other *session.EventActions) *session.EventActions
 		base.Escalate = true
 	}
 	if other.StateDelta != nil {
-		base.StateDelta = other.StateDelta
+		if base.StateDelta == nil {
+			base.StateDelta = make(map[string]any)
+		}
+		maps.Copy(base.StateDelta, other.StateDelta)
+	}
+	if other.ArtifactDelta != nil {
+		if base.ArtifactDelta == nil {
+			base.ArtifactDelta = make(map[string]int64)
+		}
+		maps.Copy(base.ArtifactDelta, other.ArtifactDelta)
+	}
+	if other.RequestedAuthConfigs != nil {
+		if base.RequestedAuthConfigs == nil {
+			base.RequestedAuthConfigs = make(map[string]*auth.Config)
+		}
+		maps.Copy(base.RequestedAuthConfigs, other.RequestedAuthConfigs)
 	}
 	return base
 }
@@ -19,6 +19,7 @@ import (
 	"reflect"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/agent/runconfig"
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
 )
@@ -40,8 +41,43 @@ func basicRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) e
 		req.Config.ResponseSchema = llmAgent.internal().OutputSchema
 		req.Config.ResponseMIMEType = "application/json"
 	}
-	// TODO: missing features
-	//  populate LLMRequest LiveConnectConfig setting
+	if rc := runconfig.FromContext(ctx); rc != nil && rc.Live() {
+		req.LiveConnectConfig = &genai.LiveConnectConfig{
+			InputAudioTranscription:  rc.InputAudioTranscription,
+			OutputAudioTranscription: rc.OutputAudioTranscription,
+			RealtimeInputConfig:      rc.RealtimeInputConfig,
+			Proactivity:              rc.Proactivity,
+		}
+	}
+	return nil
+}
+
+// generationRequestProcessor merges the generation parameters of the agent
+// into the config of the request.
+func generationRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil {
+		return nil
+	}
+	params := llmAgent.internal().Generation
+	if req.Config == nil {
+		req.Config = &genai.GenerateContentConfig{}
+	}
+	if params.Temperature != nil {
+		req.Config.Temperature = params.Temperature
+	}
+	if params.TopP != nil {
+		req.Config.TopP = params.TopP
+	}
+	if params.MaxOutputTokens > 0 {
+		req.Config.MaxOutputTokens = params.MaxOutputTokens
+	}
+	if params.SafetySettings != nil {
+		req.Config.SafetySettings = params.SafetySettings
+	}
+	if params.StopSequences != nil {
+		req.Config.StopSequences = params.StopSequences
+	}
 	return nil
 }
 
@@ -0,0 +1,38 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+
+	"google.golang.org/adk/model"
+)
+
+// checkCapabilities returns an error if the request uses a feature which the
+// model doesn't support, as reported by model.CapabilitiesOf. Models with
+// unknown capabilities aren't checked.
+func checkCapabilities(llm model.LLM, req *model.LLMRequest) error {
+	if llm == nil {
+		return nil
+	}
+	caps, ok := model.CapabilitiesOf(llm)
+	if !ok {
+		return nil
+	}
+	if !caps.FunctionCalling && req.Config != nil && len(req.Config.Tools) > 0 {
+		return fmt.Errorf("model %q does not support function calling, but the request has %d tools", llm.Name(), len(req.Config.Tools))
+	}
+	return nil
+}
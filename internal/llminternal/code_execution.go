@@ -0,0 +1,92 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"slices"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/codeexecutor"
+	"google.golang.org/adk/internal/agent/runconfig"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// codeExecutionRequestProcessor enables the code execution tool of the model
+// when the code is executed by the model with the built-in code executor. The
+// compositional function calling of live models enables it in the config of
+// the live connection only, not for the generate content calls.
+//
+// reference: adk-python src/google/adk/flows/llm_flows/_code_execution.py
+func codeExecutionRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
+	if rc := runconfig.FromContext(ctx); rc != nil && rc.SupportCFC && req.LiveConnectConfig != nil {
+		req.LiveConnectConfig.Tools = withCodeExecution(req.LiveConnectConfig.Tools)
+	}
+	if !codeexecutor.IsBuiltIn(codeExecutor(ctx)) {
+		return nil
+	}
+	if req.Config == nil {
+		req.Config = &genai.GenerateContentConfig{}
+	}
+	req.Config.Tools = withCodeExecution(req.Config.Tools)
+	return nil
+}
+
+// withCodeExecution returns the tools with the code execution tool, added if
+// missing.
+func withCodeExecution(tools []*genai.Tool) []*genai.Tool {
+	if slices.ContainsFunc(tools, func(t *genai.Tool) bool { return t != nil && t.CodeExecution != nil }) {
+		return tools
+	}
+	return append(tools, &genai.Tool{CodeExecution: &genai.ToolCodeExecution{}})
+}
+
+// codeExecutionResponseProcessor executes the code which ends the final model
+// response with the code executor of the agent, and appends its result to the
+// response. The trailing result makes the flow call the model again, so that
+// the model continues with the output of the code (see
+// session.Event.IsFinalResponse).
+func codeExecutionResponseProcessor(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
+	executor := codeExecutor(ctx)
+	if executor == nil || codeexecutor.IsBuiltIn(executor) || resp == nil || resp.Partial || resp.Content == nil {
+		return nil
+	}
+	parts := resp.Content.Parts
+	if len(parts) == 0 || parts[len(parts)-1] == nil || parts[len(parts)-1].ExecutableCode == nil {
+		return nil
+	}
+	code := parts[len(parts)-1].ExecutableCode
+	result, err := executor.Execute(ctx, code)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to execute code: %w", err)
+		}
+		// The model is told about the failure, so that it can fix the code.
+		result = &genai.CodeExecutionResult{Outcome: genai.OutcomeFailed, Output: err.Error()}
+	}
+	resp.Content = &genai.Content{
+		Role:  resp.Content.Role,
+		Parts: append(slices.Clone(parts), &genai.Part{CodeExecutionResult: result}),
+	}
+	return nil
+}
+
+func codeExecutor(ctx agent.InvocationContext) codeexecutor.Executor {
+	if llmAgent := asLLMAgent(ctx.Agent()); llmAgent != nil {
+		return llmAgent.internal().CodeExecutor
+	}
+	return nil
+}
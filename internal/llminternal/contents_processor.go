@@ -21,6 +21,8 @@ import (
 	"strings"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/featureflag"
 	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
@@ -36,6 +38,20 @@ func ContentsRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest
 		// Do nothing.
 		return nil // In python, no error is yielded.
 	}
+	contents, err := historyContents(ctx, llmAgent)
+	if err != nil {
+		return err
+	}
+	if w := llmAgent.internal().ContextWindow; w != nil && flagEnabled(ctx, featureflag.ContextWindow, true) {
+		contents = w.apply(ctx, contents)
+	}
+	req.Contents = append(req.Contents, contents...)
+	return nil
+}
+
+// historyContents returns the conversation history of the agent, built from
+// the events of the session.
+func historyContents(ctx agent.InvocationContext, llmAgent Agent) ([]*genai.Content, error) {
 	fn := buildContentsDefault // "" or "default".
 	if llmAgent.internal().IncludeContents == "none" {
 		// Include current turn context only (no conversation history)
@@ -47,20 +63,20 @@ func ContentsRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest
 			events = append(events, e)
 		}
 	}
-	contents, err := fn(ctx.Agent().Name(), ctx.Branch(), events)
-	if err != nil {
-		return err
-	}
-	req.Contents = append(req.Contents, contents...)
-	return nil
+	return fn(ctx.Agent().Name(), ctx.Branch(), events)
 }
 
 // buildContentsDefault returns the contents for the LLM request by applying
 // filtering, rearrangement, and content processing to the given events.
 func buildContentsDefault(agentName, invocationBranch string, events []*session.Event) ([]*genai.Content, error) {
+	events = applyHandoffSummary(agentName, events)
+
 	// parse the events, leaving the contents and the function calls and responses from the current agent.
 	var filtered []*session.Event
 	for _, ev := range events {
+		if transcribed := transcriptionEvent(ev); transcribed != nil {
+			ev = transcribed
+		}
 		content := utils.Content(ev)
 		// Skip events without content or generated neither by user nor
 		// by model.
@@ -79,9 +95,12 @@ func buildContentsDefault(agentName, invocationBranch string, events []*session.
 		if isAuthEvent(ev) {
 			continue
 		}
-		if isOtherAgentReply(agentName, ev) {
+		switch {
+		case ev.Author == session.DeveloperAuthor:
+			filtered = append(filtered, convertDeveloperMessage(ev))
+		case isOtherAgentReply(agentName, ev):
 			filtered = append(filtered, ConvertForeignEvent(ev))
-		} else {
+		default:
 			filtered = append(filtered, ev)
 		}
 	}
@@ -431,6 +450,11 @@ func buildContentsCurrentTurnContextOnly(agentName, branch string, events []*ses
 	for i := len(events) - 1; i >= 0; i-- {
 		event := events[i]
 		if event.Author == "user" || isOtherAgentReply(agentName, event) {
+			// The developer messages added right before the turn belong
+			// to it.
+			for i > 0 && events[i-1].Author == session.DeveloperAuthor {
+				i--
+			}
 			return buildContentsDefault(agentName, branch, events[i:])
 		}
 	}
@@ -439,8 +463,52 @@ func buildContentsCurrentTurnContextOnly(agentName, branch string, events []*ses
 	return buildContentsDefault(agentName, branch, events)
 }
 
+// transcriptionEvent converts an event of a live session which only holds an
+// audio transcription into a text content event, so that the conversation
+// held in audio is part of the history, e.g. when the session is transferred
+// to another agent. It returns nil if the event has content or no
+// transcription.
+func transcriptionEvent(ev *session.Event) *session.Event {
+	if utils.Content(ev) != nil {
+		return nil
+	}
+	var author, text string
+	var role genai.Role
+	switch {
+	case ev.InputTranscription != nil && ev.InputTranscription.Text != "":
+		author, role, text = "user", genai.RoleUser, ev.InputTranscription.Text
+	case ev.OutputTranscription != nil && ev.OutputTranscription.Text != "":
+		author, role, text = ev.Author, genai.RoleModel, ev.OutputTranscription.Text
+	default:
+		return nil
+	}
+	return &session.Event{ // made-up event. Don't go through types.NewEvent.
+		Timestamp:   ev.Timestamp,
+		Author:      author,
+		LLMResponse: model.LLMResponse{Content: genai.NewContentFromText(text, role)},
+		Branch:      ev.Branch,
+	}
+}
+
 func isOtherAgentReply(currentAgentName string, ev *session.Event) bool {
-	return ev.Author != currentAgentName && ev.Author != "user"
+	return ev.Author != currentAgentName && ev.Author != "user" && ev.Author != session.DeveloperAuthor
+}
+
+// convertDeveloperMessage converts a developer message to a user content
+// which tells the model that it doesn't come from the user.
+func convertDeveloperMessage(ev *session.Event) *session.Event {
+	content := utils.Content(ev)
+	converted := &genai.Content{
+		Role:  "user",
+		Parts: []*genai.Part{{Text: "Developer message (not from the user), for context:"}},
+	}
+	converted.Parts = append(converted.Parts, content.Parts...)
+	return &session.Event{ // made-up event. Don't go through types.NewEvent.
+		Timestamp:   ev.Timestamp,
+		Author:      "user",
+		LLMResponse: model.LLMResponse{Content: converted},
+		Branch:      ev.Branch,
+	}
 }
 
 // ConvertForeignEvent converts an event authored by another agent as
@@ -487,20 +555,16 @@ func stringify(v any) string {
 	return string(s)
 }
 
-// requestEUCFunctionCallName is a special function to handle credential
-// request.
-const requestEUCFunctionCallName = "adk_request_credential"
-
 func isAuthEvent(ev *session.Event) bool {
 	c := utils.Content(ev)
 	if c == nil {
 		return false
 	}
 	for _, p := range c.Parts {
-		if p.FunctionCall != nil && p.FunctionCall.Name == requestEUCFunctionCallName {
+		if p.FunctionCall != nil && p.FunctionCall.Name == auth.RequestCredentialFunctionName {
 			return true
 		}
-		if p.FunctionResponse != nil && p.FunctionResponse.Name == requestEUCFunctionCallName {
+		if p.FunctionResponse != nil && p.FunctionResponse.Name == auth.RequestCredentialFunctionName {
 			return true
 		}
 	}
@@ -102,6 +102,39 @@ func TestContentsRequestProcessor_IncludeContents(t *testing.T) {
 			},
 		},
 	}
+	developerMessage := []*session.Event{
+		{
+			Author: "user", // History.
+			LLMResponse: model.LLMResponse{
+				Content: genai.NewContentFromText("hello", "user"),
+			},
+		},
+		{
+			Author: agentName,
+			LLMResponse: model.LLMResponse{
+				Content: genai.NewContentFromText("hi", "model"),
+			},
+		},
+		{
+			Author: session.DeveloperAuthor, // Added between the turns.
+			LLMResponse: model.LLMResponse{
+				Content: genai.NewContentFromText("the subscription was upgraded", "user"),
+			},
+		},
+		{
+			Author: "user",
+			LLMResponse: model.LLMResponse{
+				Content: genai.NewContentFromText("what's my plan?", "user"),
+			},
+		},
+	}
+	developerContent := &genai.Content{
+		Role: "user",
+		Parts: []*genai.Part{
+			{Text: "Developer message (not from the user), for context:"},
+			{Text: "the subscription was upgraded"},
+		},
+	}
 
 	t.Parallel()
 	testCases := []struct {
@@ -211,6 +244,26 @@ func TestContentsRequestProcessor_IncludeContents(t *testing.T) {
 				genai.NewContentFromFunctionResponse("func1", nil, "user"),
 			},
 		},
+		{
+			name:            "developerMessage",
+			includeContents: "default",
+			events:          developerMessage,
+			want: []*genai.Content{
+				genai.NewContentFromText("hello", "user"),
+				genai.NewContentFromText("hi", "model"),
+				developerContent,
+				genai.NewContentFromText("what's my plan?", "user"),
+			},
+		},
+		{
+			name:            "developerMessage",
+			includeContents: "none",
+			events:          developerMessage,
+			want: []*genai.Content{
+				developerContent,
+				genai.NewContentFromText("what's my plan?", "user"),
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -364,6 +417,40 @@ func TestContentsRequestProcessor(t *testing.T) {
 			},
 			want: nil,
 		},
+		{
+			name: "LiveTranscriptions",
+			events: []*session.Event{
+				{
+					Author: "rootAgent",
+					LLMResponse: model.LLMResponse{
+						InputTranscription: &genai.Transcription{Text: "I need a refund", Finished: true},
+					},
+				},
+				{
+					Author: "rootAgent",
+					LLMResponse: model.LLMResponse{
+						OutputTranscription: &genai.Transcription{Text: "Let me transfer you", Finished: true},
+					},
+				},
+				{
+					Author: agentName,
+					LLMResponse: model.LLMResponse{
+						OutputTranscription: &genai.Transcription{Text: "How can I help?", Finished: true},
+					},
+				},
+			},
+			want: []*genai.Content{
+				genai.NewContentFromText("I need a refund", "user"),
+				{
+					Role: "user",
+					Parts: []*genai.Part{
+						{Text: "For context:"},
+						{Text: "[rootAgent] said: Let me transfer you"},
+					},
+				},
+				genai.NewContentFromText("How can I help?", "model"),
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,263 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/summarizer"
+	"google.golang.org/genai"
+)
+
+// contextSummaryPrefix introduces the summary of the dropped history in the
+// model request.
+const contextSummaryPrefix = summarizer.PreviousSummaryPrefix
+
+// defaultSummaryBatch is the default of ContextWindow.SummaryBatch.
+const defaultSummaryBatch = 10
+
+// ContextWindow limits the conversation history sent to the model.
+type ContextWindow struct {
+	// MaxEvents is the maximum number of history contents. Zero means no limit.
+	MaxEvents int
+	// MaxTokens is the maximum estimated size of the history. Zero means no
+	// limit.
+	MaxTokens int
+	// CountTokens estimates the size of a content.
+	// Optional: defaults to EstimateTokens.
+	CountTokens func(*genai.Content) int
+
+	// Summarizer, if set, summarizes the dropped history. The summary is
+	// stored in the session, see session.Compaction, and sent at the
+	// beginning of the history.
+	Summarizer summarizer.Summarizer
+	// SummaryBatch is the number of contents dropped beyond the window when
+	// the summary is extended.
+	// Optional: defaults to defaultSummaryBatch.
+	SummaryBatch int
+}
+
+// mediaTokens is the estimated size of an inline or file media part, the
+// size of an image for Gemini: the size of the media data doesn't tell
+// how many tokens the model counts for it.
+const mediaTokens = 258
+
+// EstimateTokens estimates the number of tokens of the content: a quarter
+// of the size of its text, or of the JSON encoding of its other parts,
+// e.g. the function calls, and a fixed size for its media parts.
+func EstimateTokens(c *genai.Content) int {
+	if c == nil {
+		return 0
+	}
+	n, size := 0, 0
+	for _, p := range c.Parts {
+		switch {
+		case p == nil:
+		case p.InlineData != nil || p.FileData != nil:
+			n += mediaTokens
+		case p.Text != "":
+			size += len(p.Text)
+		default:
+			if data, err := json.Marshal(p); err == nil {
+				size += len(data)
+			}
+		}
+	}
+	return n + (size+3)/4
+}
+
+// apply returns the part of the history which fits the window. If the
+// window summarizes, the history covered by the latest compaction of the
+// agent is replaced with its summary, see compactHistory.
+func (w *ContextWindow) apply(ctx agent.InvocationContext, contents []*genai.Content) []*genai.Content {
+	start := w.windowStart(contents, w.MaxEvents, w.MaxTokens)
+	if w.Summarizer == nil {
+		return contents[start:]
+	}
+	c := latestCompaction(ctx, len(contents))
+	if c == nil {
+		return contents[start:]
+	}
+	kept := contents[max(start, c.Contents):]
+	if c.Summary == "" {
+		return kept
+	}
+	return append([]*genai.Content{genai.NewContentFromText(contextSummaryPrefix+c.Summary, genai.RoleUser)}, kept...)
+}
+
+// compact returns the part of the request contents which fits maxTokens.
+// It is used when the request doesn't fit the context window of the model,
+// see checkRequestSize. The dropped contents aren't summarized, but the
+// summary of the history which starts the contents is kept if it fits.
+func (w *ContextWindow) compact(contents []*genai.Content, maxTokens int) []*genai.Content {
+	if len(contents) > 1 && isContextSummary(contents[0]) {
+		count := w.CountTokens
+		if count == nil {
+			count = EstimateTokens
+		}
+		if rest := maxTokens - count(contents[0]); rest > 0 {
+			kept := contents[1:]
+			return append(contents[:1:1], kept[w.windowStart(kept, 0, rest):]...)
+		}
+	}
+	return contents[w.windowStart(contents, 0, maxTokens):]
+}
+
+// windowStart returns the index of the first content which is kept. The
+// window never starts with a function response, since the model can't match
+// it with its call.
+func (w *ContextWindow) windowStart(contents []*genai.Content, maxEvents, maxTokens int) int {
+	n := len(contents)
+	start := 0
+	if maxEvents > 0 && n > maxEvents {
+		start = n - maxEvents
+	}
+	if maxTokens > 0 {
+		count := w.CountTokens
+		if count == nil {
+			count = EstimateTokens
+		}
+		total := 0
+		for i := n - 1; i >= start; i-- {
+			total += count(contents[i])
+			if total > maxTokens {
+				// Always keep the latest content.
+				start = min(i+1, n-1)
+				break
+			}
+		}
+	}
+
+	i := start
+	for i < n && hasFunctionResponsePart(contents[i]) {
+		i++
+	}
+	if i < n {
+		return i
+	}
+	// Only function responses are left: extend the window to their calls.
+	for start > 0 && hasFunctionResponsePart(contents[start]) {
+		start--
+	}
+	return start
+}
+
+// compaction returns the event recording the summary of the history
+// dropped by the window, or nil if the latest compaction of the agent still
+// covers it. The history is dropped in batches of SummaryBatch contents
+// beyond the window, so that the summary is extended once per batch rather
+// than on every step.
+func (w *ContextWindow) compaction(ctx agent.InvocationContext, contents []*genai.Content) (*session.Event, error) {
+	start := w.windowStart(contents, w.MaxEvents, w.MaxTokens)
+	prev := latestCompaction(ctx, len(contents))
+	covered := 0
+	if prev != nil {
+		covered = prev.Contents
+	}
+	if start <= covered {
+		return nil, nil
+	}
+	dropped := w.batchEnd(contents, start)
+
+	req := &summarizer.Request{Purpose: summarizer.PurposeCompaction, Contents: contents[covered:dropped]}
+	if prev != nil {
+		req.Previous = prev.Summary
+	}
+	summary, err := summarizerFor(ctx, w.Summarizer).Summarize(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize the conversation history: %w", err)
+	}
+
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.Actions.Compaction = &session.Compaction{Contents: dropped, Summary: summary}
+	return ev, nil
+}
+
+// batchEnd returns the number of contents dropped with the next summary:
+// the contents before start, and a batch more if the rest of the window
+// doesn't start with a function response and keeps the latest content.
+func (w *ContextWindow) batchEnd(contents []*genai.Content, start int) int {
+	batch := w.SummaryBatch
+	if batch <= 0 {
+		batch = defaultSummaryBatch
+	}
+	end := min(start+batch, len(contents)-1)
+	for end < len(contents) && hasFunctionResponsePart(contents[end]) {
+		end++
+	}
+	if end >= len(contents) {
+		return start
+	}
+	return max(start, end)
+}
+
+// latestCompaction returns the latest compaction of the history of the
+// agent, or nil if there is none or it doesn't match the history of n
+// contents.
+func latestCompaction(ctx agent.InvocationContext, n int) *session.Compaction {
+	if ctx.Session() == nil {
+		return nil
+	}
+	events := ctx.Session().Events()
+	for i := events.Len() - 1; i >= 0; i-- {
+		ev := events.At(i)
+		c := ev.Actions.Compaction
+		if c == nil || ev.Author != ctx.Agent().Name() || ev.Branch != ctx.Branch() {
+			continue
+		}
+		if c.Contents <= 0 || c.Contents >= n {
+			return nil
+		}
+		return c
+	}
+	return nil
+}
+
+// compactHistory returns the event recording the summary of the history
+// dropped by the context window of the agent, or nil if the agent doesn't
+// summarize its history or the history is already summarized. The event is
+// yielded before the model request is built, so that the summary is stored
+// in the session and reused by the next steps and invocations.
+func compactHistory(ctx agent.InvocationContext) (*session.Event, error) {
+	w := contextWindow(ctx)
+	if w == nil || w.Summarizer == nil {
+		return nil, nil
+	}
+	contents, err := historyContents(ctx, asLLMAgent(ctx.Agent()))
+	if err != nil {
+		return nil, err
+	}
+	return w.compaction(ctx, contents)
+}
+
+func isContextSummary(c *genai.Content) bool {
+	return c != nil && c.Role == genai.RoleUser && len(c.Parts) == 1 && c.Parts[0] != nil &&
+		strings.HasPrefix(c.Parts[0].Text, contextSummaryPrefix)
+}
+
+func hasFunctionResponsePart(c *genai.Content) bool {
+	for _, p := range c.Parts {
+		if p != nil && p.FunctionResponse != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal_test
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/featureflag"
+	"google.golang.org/adk/internal/agent/runconfig"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+func textEvent(author, text, role string) *session.Event {
+	return &session.Event{
+		Author:      author,
+		LLMResponse: model.LLMResponse{Content: genai.NewContentFromText(text, genai.Role(role))},
+	}
+}
+
+func TestContentsRequestProcessor_ContextWindow(t *testing.T) {
+	const agentName = "testAgent"
+	conversation := []*session.Event{
+		textEvent("user", "u1", "user"),
+		textEvent(agentName, "m1", "model"),
+		textEvent("user", "u2", "user"),
+		{Author: agentName, LLMResponse: model.LLMResponse{Content: genai.NewContentFromFunctionCall("f", nil, "model")}},
+		{Author: agentName, LLMResponse: model.LLMResponse{Content: genai.NewContentFromFunctionResponse("f", nil, "user")}},
+		textEvent(agentName, "m2", "model"),
+		textEvent("user", "u3", "user"),
+	}
+	contents := func(events ...*session.Event) []*genai.Content {
+		var c []*genai.Content
+		for _, ev := range events {
+			c = append(c, ev.Content)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		window *llmagent.ContextWindowConfig
+		want   []*genai.Content
+	}{
+		{
+			name:   "history fits",
+			window: &llmagent.ContextWindowConfig{MaxEvents: 10},
+			want:   contents(conversation...),
+		},
+		{
+			name:   "max events",
+			window: &llmagent.ContextWindowConfig{MaxEvents: 4},
+			want:   contents(conversation[3:]...),
+		},
+		{
+			name:   "window does not start with a function response",
+			window: &llmagent.ContextWindowConfig{MaxEvents: 3},
+			want:   contents(conversation[5:]...),
+		},
+		{
+			name: "max tokens",
+			window: &llmagent.ContextWindowConfig{
+				MaxTokens:   25,
+				CountTokens: func(*genai.Content) int { return 10 },
+			},
+			want: contents(conversation[5:]...),
+		},
+		{
+			name: "latest content is always kept",
+			window: &llmagent.ContextWindowConfig{
+				MaxTokens:   5,
+				CountTokens: func(*genai.Content) int { return 10 },
+			},
+			want: contents(conversation[6:]...),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testAgent := utils.Must(llmagent.New(llmagent.Config{
+				Name:          agentName,
+				Model:         &testModel{},
+				ContextWindow: tt.window,
+			}))
+			ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+				Agent:   testAgent,
+				Session: &fakeSession{events: conversation},
+			})
+
+			req := &model.LLMRequest{}
+			if err := llminternal.ContentsRequestProcessor(ctx, req); err != nil {
+				t.Fatalf("ContentsRequestProcessor() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, req.Contents); diff != "" {
+				t.Errorf("ContentsRequestProcessor() contents mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestContentsRequestProcessor_ContextWindowSummary(t *testing.T) {
+	const agentName = "testAgent"
+	testAgent := utils.Must(llmagent.New(llmagent.Config{
+		Name:  agentName,
+		Model: &testModel{},
+		ContextWindow: &llmagent.ContextWindowConfig{
+			MaxEvents:    3,
+			Summarize:    true,
+			SummaryModel: &summaryModel{},
+		},
+	}))
+	compaction := func(author, branch string, contents int, summary string) *session.Event {
+		return &session.Event{
+			Author:  author,
+			Branch:  branch,
+			Actions: session.EventActions{Compaction: &session.Compaction{Contents: contents, Summary: summary}},
+		}
+	}
+	history := []*session.Event{
+		textEvent("user", "u1", "user"),
+		textEvent(agentName, "m1", "model"),
+		textEvent("user", "u2", "user"),
+		textEvent(agentName, "m2", "model"),
+		textEvent("user", "u3", "user"),
+	}
+	summary := func(text string) *genai.Content {
+		return genai.NewContentFromText("Summary of the earlier conversation:\n"+text, "user")
+	}
+
+	tests := []struct {
+		name   string
+		events []*session.Event
+		want   []*genai.Content
+	}{
+		{
+			name:   "no compaction",
+			events: history,
+			want:   []*genai.Content{history[2].Content, history[3].Content, history[4].Content},
+		},
+		{
+			name:   "compaction covers the dropped history",
+			events: append([]*session.Event{compaction(agentName, "", 1, "old"), compaction(agentName, "", 2, "new")}, history...),
+			want:   []*genai.Content{summary("new"), history[2].Content, history[3].Content, history[4].Content},
+		},
+		{
+			name:   "compaction beyond the window",
+			events: append(slices.Clone(history), compaction(agentName, "", 4, "all")),
+			want:   []*genai.Content{summary("all"), history[4].Content},
+		},
+		{
+			name:   "compaction of another agent",
+			events: append(slices.Clone(history), compaction("otherAgent", "", 4, "all")),
+			want:   []*genai.Content{history[2].Content, history[3].Content, history[4].Content},
+		},
+		{
+			name:   "compaction of another branch",
+			events: append(slices.Clone(history), compaction(agentName, "parent.other", 4, "all")),
+			want:   []*genai.Content{history[2].Content, history[3].Content, history[4].Content},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+				Agent:   testAgent,
+				Session: &fakeSession{events: tt.events},
+			})
+			req := &model.LLMRequest{}
+			if err := llminternal.ContentsRequestProcessor(ctx, req); err != nil {
+				t.Fatalf("ContentsRequestProcessor() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, req.Contents); diff != "" {
+				t.Errorf("ContentsRequestProcessor() contents mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestContentsRequestProcessor_ContextWindowFeatureFlag(t *testing.T) {
+	const agentName = "testAgent"
+	events := []*session.Event{
+		textEvent("user", "u1", "user"),
+		textEvent(agentName, "m1", "model"),
+		textEvent("user", "u2", "user"),
+	}
+	testAgent := utils.Must(llmagent.New(llmagent.Config{
+		Name:          agentName,
+		Model:         &testModel{},
+		ContextWindow: &llmagent.ContextWindowConfig{MaxEvents: 1},
+	}))
+
+	for _, enabled := range []bool{true, false} {
+		flags := featureflag.Flags{featureflag.ContextWindow: {Enabled: &enabled}}
+		ctx := runconfig.ToContext(t.Context(), &runconfig.RunConfig{FeatureFlags: flags})
+		ictx := icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{
+			Agent:   testAgent,
+			Session: &fakeSession{events: events},
+		})
+		req := &model.LLMRequest{}
+		if err := llminternal.ContentsRequestProcessor(ictx, req); err != nil {
+			t.Fatalf("ContentsRequestProcessor() error = %v", err)
+		}
+		want := len(events)
+		if enabled {
+			want = 1
+		}
+		if len(req.Contents) != want {
+			t.Errorf("with flag %s=%v got %d contents, want %d", featureflag.ContextWindow, enabled, len(req.Contents), want)
+		}
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content *genai.Content
+		want    int
+	}{
+		{name: "nil"},
+		{name: "text", content: genai.NewContentFromText("12345678", genai.RoleUser), want: 2},
+		{name: "inline image", content: genai.NewContentFromBytes(make([]byte, 4<<20), "image/png", genai.RoleUser), want: 258},
+		{name: "file", content: genai.NewContentFromURI("gs://bucket/video.mp4", "video/mp4", genai.RoleUser), want: 258},
+		{name: "function call", content: genai.NewContentFromFunctionCall("f", nil, genai.RoleModel), want: 8},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := llminternal.EstimateTokens(tc.content); got != tc.want {
+				t.Errorf("EstimateTokens() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestContentsRequestProcessor_ContextWindowValidation(t *testing.T) {
+	_, err := llmagent.New(llmagent.Config{
+		Name:          "testAgent",
+		Model:         &testModel{},
+		ContextWindow: &llmagent.ContextWindowConfig{Summarize: true},
+	})
+	if err == nil {
+		t.Errorf("llmagent.New() with an unlimited context window succeeded, want error")
+	}
+}
+
+type summaryModel struct {
+	requests  []*model.LLMRequest
+	summaries []string
+}
+
+func (m *summaryModel) Name() string { return "summary-model" }
+
+func (m *summaryModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.requests = append(m.requests, req)
+		summary := m.summaries[0]
+		m.summaries = m.summaries[1:]
+		yield(&model.LLMResponse{Content: genai.NewContentFromText(summary, genai.RoleModel)}, nil)
+	}
+}
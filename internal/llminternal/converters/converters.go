@@ -21,6 +21,7 @@ import (
 
 func Genai2LLMResponse(res *genai.GenerateContentResponse) *model.LLMResponse {
 	usageMetadata := res.UsageMetadata
+	blocked := model.NewSafetyBlock(res)
 	if len(res.Candidates) > 0 && res.Candidates[0] != nil {
 		candidate := res.Candidates[0]
 		if candidate.Content != nil && len(candidate.Content.Parts) > 0 {
@@ -32,11 +33,17 @@ func Genai2LLMResponse(res *genai.GenerateContentResponse) *model.LLMResponse {
 				AvgLogprobs:       candidate.AvgLogprobs,
 				LogprobsResult:    candidate.LogprobsResult,
 				UsageMetadata:     usageMetadata,
+				Blocked:           blocked,
 			}
 		}
+		errorMessage := candidate.FinishMessage
+		if errorMessage == "" && blocked != nil {
+			errorMessage = blocked.Error()
+		}
 		return &model.LLMResponse{
 			ErrorCode:         string(candidate.FinishReason),
-			ErrorMessage:      candidate.FinishMessage,
+			ErrorMessage:      errorMessage,
+			Blocked:           blocked,
 			GroundingMetadata: candidate.GroundingMetadata,
 			FinishReason:      candidate.FinishReason,
 			CitationMetadata:  candidate.CitationMetadata,
@@ -47,9 +54,14 @@ func Genai2LLMResponse(res *genai.GenerateContentResponse) *model.LLMResponse {
 
 	}
 	if res.PromptFeedback != nil {
+		errorMessage := res.PromptFeedback.BlockReasonMessage
+		if errorMessage == "" && blocked != nil {
+			errorMessage = blocked.Error()
+		}
 		return &model.LLMResponse{
 			ErrorCode:     string(res.PromptFeedback.BlockReason),
-			ErrorMessage:  res.PromptFeedback.BlockReasonMessage,
+			ErrorMessage:  errorMessage,
+			Blocked:       blocked,
 			UsageMetadata: usageMetadata,
 		}
 	}
@@ -59,3 +71,49 @@ func Genai2LLMResponse(res *genai.GenerateContentResponse) *model.LLMResponse {
 		UsageMetadata: usageMetadata,
 	}
 }
+
+// LiveServerMessage2LLMResponse converts a message of a live (bidi) connection
+// into a model response. The content of the model turn is partial: the turn
+// ends with a response with TurnComplete set. A response with Interrupted set
+// reports that the user interrupted the model (barge-in), so the client should
+// stop playing the model output. It returns nil for the messages which don't
+// carry a response, e.g. the setup completion.
+func LiveServerMessage2LLMResponse(msg *genai.LiveServerMessage) *model.LLMResponse {
+	var resp *model.LLMResponse
+	switch {
+	case msg.ServerContent != nil:
+		sc := msg.ServerContent
+		resp = &model.LLMResponse{
+			GroundingMetadata:   sc.GroundingMetadata,
+			TurnComplete:        sc.TurnComplete,
+			Interrupted:         sc.Interrupted,
+			InputTranscription:  sc.InputTranscription,
+			OutputTranscription: sc.OutputTranscription,
+		}
+		if sc.ModelTurn != nil && len(sc.ModelTurn.Parts) > 0 {
+			resp.Content = sc.ModelTurn
+			resp.Partial = !sc.TurnComplete
+		}
+	case msg.ToolCall != nil && len(msg.ToolCall.FunctionCalls) > 0:
+		parts := make([]*genai.Part, 0, len(msg.ToolCall.FunctionCalls))
+		for _, fc := range msg.ToolCall.FunctionCalls {
+			parts = append(parts, &genai.Part{FunctionCall: fc})
+		}
+		resp = &model.LLMResponse{Content: genai.NewContentFromParts(parts, genai.RoleModel)}
+	case msg.UsageMetadata != nil:
+		resp = &model.LLMResponse{}
+	default:
+		return nil
+	}
+	if u := msg.UsageMetadata; u != nil {
+		resp.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:        u.PromptTokenCount,
+			CachedContentTokenCount: u.CachedContentTokenCount,
+			CandidatesTokenCount:    u.ResponseTokenCount,
+			ToolUsePromptTokenCount: u.ToolUsePromptTokenCount,
+			ThoughtsTokenCount:      u.ThoughtsTokenCount,
+			TotalTokenCount:         u.TotalTokenCount,
+		}
+	}
+	return resp
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/summarizer"
+	"google.golang.org/genai"
+)
+
+// handoffSummaryEvent asks the handoff summarizer to summarize the
+// conversation for the target agent. It returns nil if the summary is
+// empty.
+func (f *Flow) handoffSummaryEvent(ctx agent.InvocationContext, target agent.Agent) (*session.Event, error) {
+	var events []*session.Event
+	if ctx.Session() != nil {
+		for e := range ctx.Session().Events().All() {
+			events = append(events, e)
+		}
+	}
+	contents, err := buildContentsDefault(ctx.Agent().Name(), ctx.Branch(), events)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := fmt.Sprintf("Control is being transferred to the agent %q.", target.Name())
+	if target.Description() != "" {
+		prompt += fmt.Sprintf(" Its description: %s", target.Description())
+	}
+	summary, err := summarizerFor(ctx, f.HandoffSummarizer).Summarize(ctx, &summarizer.Request{
+		Purpose:  summarizer.PurposeHandoff,
+		Contents: contents,
+		Context:  prompt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate handoff summary: %w", err)
+	}
+	if summary == "" {
+		return nil, nil
+	}
+
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.Content = genai.NewContentFromText(summary, genai.RoleModel)
+	ev.Actions.HandoffSummaryFor = target.Name()
+	return ev, nil
+}
+
+// applyHandoffSummary moves the latest handoff summary for the agent to the
+// beginning of the events, and drops the events of other agents which
+// happened before the summary, as the summary replaces them.
+func applyHandoffSummary(agentName string, events []*session.Event) []*session.Event {
+	idx := -1
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Actions.HandoffSummaryFor == agentName {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return events
+	}
+
+	result := make([]*session.Event, 0, len(events))
+	result = append(result, events[idx])
+	for _, ev := range events[:idx] {
+		if !isOtherAgentReply(agentName, ev) {
+			result = append(result, ev)
+		}
+	}
+	return append(result, events[idx+1:]...)
+}
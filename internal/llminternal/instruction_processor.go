@@ -22,10 +22,12 @@ import (
 	"unicode"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/featureflag"
 	"google.golang.org/adk/internal/agent/parentmap"
 	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/model"
+	"google.golang.org/genai"
 )
 
 // TODO: Remove this once state keywords are implemented and replace with those consts
@@ -49,19 +51,80 @@ func instructionsRequestProcessor(ctx agent.InvocationContext, req *model.LLMReq
 		rootAgent = llmAgent
 	}
 
-	// Append global instructions.
-	if err := appendGlobalInstructions(ctx, req, rootAgent.internal()); err != nil {
-		return fmt.Errorf("failed to append global instructions: %w", err)
+	// Append the static instruction as is, it's never deferred.
+	if si := llmAgent.internal().StaticInstruction; si != nil {
+		appendSystemInstructionParts(req, si.Parts...)
+	}
+
+	// Append global instructions. Deferred instructions are sent after the
+	// conversation history by deferredInstructionsRequestProcessor.
+	if !deferGlobalInstruction(ctx, llmAgent.internal(), rootAgent.internal()) {
+		if err := appendGlobalInstructions(ctx, req, rootAgent.internal()); err != nil {
+			return fmt.Errorf("failed to append global instructions: %w", err)
+		}
 	}
 
 	// Append agent's instruction
-	if err := appendInstructions(ctx, req, llmAgent.internal()); err != nil {
-		return fmt.Errorf("failed to append instructions: %w", err)
+	if !deferInstruction(ctx, llmAgent.internal()) {
+		if err := appendInstructions(ctx, req, llmAgent.internal()); err != nil {
+			return fmt.Errorf("failed to append instructions: %w", err)
+		}
 	}
 
 	return nil
 }
 
+// isDynamicInstruction reports whether the instruction may change between
+// model calls, i.e. it is computed by a provider or has placeholders.
+func isDynamicInstruction(provider InstructionProvider, instruction string) bool {
+	return provider != nil || placeholderRegex.MatchString(instruction)
+}
+
+// deferInstruction reports whether the agent's instruction is sent as user
+// content instead of the system instruction. This is the case when the agent
+// has a static instruction, or when its instruction is dynamic and the agent
+// uses cache-aware ordering.
+func deferInstruction(ctx agent.InvocationContext, agentState *State) bool {
+	return agentState.StaticInstruction != nil ||
+		cacheAwareOrdering(ctx, agentState) && isDynamicInstruction(agentState.InstructionProvider, agentState.Instruction)
+}
+
+// deferGlobalInstruction reports whether the root agent's global instruction
+// is sent as user content instead of the system instruction.
+func deferGlobalInstruction(ctx agent.InvocationContext, agentState, rootState *State) bool {
+	return cacheAwareOrdering(ctx, agentState) && isDynamicInstruction(rootState.GlobalInstructionProvider, rootState.GlobalInstruction)
+}
+
+// cacheAwareOrdering reports whether the agent uses cache-aware ordering,
+// as configured or as set by the feature flags of the run.
+func cacheAwareOrdering(ctx agent.InvocationContext, agentState *State) bool {
+	return flagEnabled(ctx, featureflag.CacheAwareOrdering, agentState.CacheAwareOrdering)
+}
+
+// appendSystemInstructionParts appends the parts to the system instruction of
+// the request.
+func appendSystemInstructionParts(req *model.LLMRequest, parts ...*genai.Part) {
+	if req.Config == nil {
+		req.Config = &genai.GenerateContentConfig{}
+	}
+	if req.Config.SystemInstruction == nil {
+		req.Config.SystemInstruction = &genai.Content{Role: genai.RoleUser}
+	}
+	for _, p := range parts {
+		if p != nil && p.Text != "" {
+			text, sensitive := model.ExtractSensitive(p.Text)
+			if len(sensitive) > 0 {
+				// The parts are shared with the config of the agent.
+				clone := *p
+				clone.Text = text
+				p = &clone
+				req.Sensitive = append(req.Sensitive, sensitive...)
+			}
+		}
+		req.Config.SystemInstruction.Parts = append(req.Config.SystemInstruction.Parts, p)
+	}
+}
+
 // The regex to find placeholders like {variable} or {artifact.file_name}.
 var placeholderRegex = regexp.MustCompile(`{+[^{}]*}+`)
 
@@ -136,6 +199,11 @@ func replaceMatch(ctx agent.InvocationContext, match string) (string, error) {
 			}
 			return "", fmt.Errorf("failed to load artifact %s: %w", fileName, err)
 		}
+		if resp.Part.InlineData != nil || resp.Part.FileData != nil {
+			// The artifact is sent after the instructions, see
+			// artifactReferencesRequestProcessor.
+			return artifactRef(fileName), nil
+		}
 		return resp.Part.Text, nil
 	}
 
@@ -0,0 +1,137 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"iter"
+	"strings"
+
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// maxTokensContinuationPrompt asks the model to continue the response which
+// reached the output token limit.
+const maxTokensContinuationPrompt = "Your previous response reached the output length limit. " +
+	"Continue it exactly where it stopped, without repeating any of it."
+
+// Bounds of the text repeated at the seam of a continuation which is
+// dropped when the parts are stitched. Shorter repetitions are likely to be
+// coincidental, e.g. a space or a common word.
+const (
+	minStitchOverlap = 8
+	maxStitchOverlap = 2048
+)
+
+// LongOutput continues the text responses which stop at the output token
+// limit of the model and stitches the parts into one response.
+type LongOutput struct {
+	// MaxContinuations is the maximum number of continuations of a response.
+	MaxContinuations int
+}
+
+// generate yields the responses of generate, asking the model to continue
+// the final text responses with finish reason MAX_TOKENS. The truncated
+// responses aren't yielded; the final response of the last continuation
+// carries the stitched text and the usage of all the calls. Partial
+// responses are yielded as they are.
+func (o *LongOutput) generate(generate func(*model.LLMRequest) iter.Seq2[*model.LLMResponse, error], req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		prefix := ""
+		var usage *genai.GenerateContentResponseUsageMetadata
+		attemptReq := req
+		for attempt := 0; ; attempt++ {
+			var truncated *model.LLMResponse
+			// The stitched text and the usage go to the first final
+			// response with text only, as a stream can yield several final
+			// responses.
+			stitched := attempt == 0
+			for resp, err := range generate(attemptReq) {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if resp.Partial {
+					if !yield(resp, nil) {
+						return
+					}
+					continue
+				}
+				if attempt < o.MaxContinuations && isTruncatedText(resp) {
+					truncated = resp
+					break
+				}
+				if !stitched && responseText(resp) != "" {
+					resp = withTextPrefix(resp, trimOverlap(prefix, responseText(resp)), attempt)
+					resp.UsageMetadata = addUsage(usage, resp.UsageMetadata)
+					stitched = true
+				}
+				if !yield(resp, nil) {
+					return
+				}
+			}
+			if truncated == nil {
+				if !stitched {
+					// The continuation ended without text.
+					yield(&model.LLMResponse{
+						Content:        genai.NewContentFromText(prefix, genai.RoleModel),
+						UsageMetadata:  usage,
+						TurnComplete:   true,
+						CustomMetadata: map[string]any{ContinuationsMetadataKey: attempt},
+					}, nil)
+				}
+				return
+			}
+			prefix = trimOverlap(prefix, responseText(truncated)) + responseText(truncated)
+			usage = addUsage(usage, truncated.UsageMetadata)
+			attemptReq = continuationRequest(req, prefix, maxTokensContinuationPrompt)
+		}
+	}
+}
+
+// isTruncatedText reports whether the response is text cut off by the
+// output token limit. Responses with function calls aren't continued.
+func isTruncatedText(resp *model.LLMResponse) bool {
+	return resp.FinishReason == genai.FinishReasonMaxTokens &&
+		len(utils.FunctionCalls(resp.Content)) == 0 &&
+		responseText(resp) != ""
+}
+
+// trimOverlap returns text without its end repeated at the start of cont.
+func trimOverlap(text, cont string) string {
+	for n := min(len(text), len(cont), maxStitchOverlap); n >= minStitchOverlap; n-- {
+		if strings.HasSuffix(text, cont[:n]) {
+			return text[:len(text)-n]
+		}
+	}
+	return text
+}
+
+// addUsage returns the sum of the token usages, nil if both are nil.
+func addUsage(a, b *genai.GenerateContentResponseUsageMetadata) *genai.GenerateContentResponseUsageMetadata {
+	if a == nil || b == nil {
+		if a == nil {
+			return b
+		}
+		return a
+	}
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     a.PromptTokenCount + b.PromptTokenCount,
+		CandidatesTokenCount: a.CandidatesTokenCount + b.CandidatesTokenCount,
+		ThoughtsTokenCount:   a.ThoughtsTokenCount + b.ThoughtsTokenCount,
+		TotalTokenCount:      a.TotalTokenCount + b.TotalTokenCount,
+	}
+}
@@ -29,22 +29,8 @@ func nlPlanningRequestProcessor(ctx agent.InvocationContext, req *model.LLMReque
 	return nil
 }
 
-func codeExecutionRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
-	// TODO: implement (adk-python src/google/adk/flows/llm_flows/_code_execution.py)
-	return nil
-}
-
 func authPreprocessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
-	// TODO: implement (adk-python src/google/adk/auth/auth_preprocessor.py)
-	return nil
-}
-
-func nlPlanningResponseProcessor(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
-	// TODO: implement (adk-python src/google/adk/_nl_planning.py)
-	return nil
-}
-
-func codeExecutionResponseProcessor(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
-	// TODO: implement (adk-python src/google/adk_code_execution.py)
+	// The credential responses are handled by Flow.resumeAuthenticatedTools,
+	// since resuming the tools yields events.
 	return nil
 }
@@ -0,0 +1,194 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// PartialResponsePolicy is what the flow does with the text streamed by the
+// model when the stream terminates early.
+type PartialResponsePolicy string
+
+const (
+	// PartialResponseFinalize commits the streamed text as the response.
+	PartialResponseFinalize PartialResponsePolicy = "finalize"
+	// PartialResponseContinue asks the model to continue the streamed text.
+	// If the continuations fail too, the text is finalized.
+	PartialResponseContinue PartialResponsePolicy = "continue"
+)
+
+// continuationPrompt asks the model to continue its interrupted response.
+const continuationPrompt = "Your previous response was interrupted. " +
+	"Continue it exactly where it stopped, without repeating any of it."
+
+// PartialResponseRecovery recovers the text streamed by the model when the
+// stream terminates early, because of an error or finish reason OTHER.
+type PartialResponseRecovery struct {
+	Policy PartialResponsePolicy
+	// MaxContinuations is the number of continuations requested by
+	// PartialResponseContinue.
+	MaxContinuations int
+	// Annotation is appended to the text of finalized responses.
+	Annotation string
+}
+
+// Metadata keys set on the recovered responses, see
+// model.LLMResponse.CustomMetadata.
+const (
+	// PartialResponseMetadataKey is set on finalized responses. Its value is
+	// the reason the stream terminated.
+	PartialResponseMetadataKey = "partial_response"
+	// ContinuationsMetadataKey is the number of continuations of the
+	// response.
+	ContinuationsMetadataKey = "partial_response_continuations"
+)
+
+// generate streams the responses of the model, recovering the streamed text
+// according to the policy when the stream terminates early. The recovered
+// response is yielded as the final, non-partial response.
+func (r *PartialResponseRecovery) generate(ctx context.Context, llm model.LLM, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		prefix := ""
+		attemptReq := req
+		for attempt := 0; ; attempt++ {
+			var streamed strings.Builder
+			reason := ""
+			// The recovered text prefixes the first final response with
+			// text only, as a stream can yield several final responses.
+			prefixed := prefix == ""
+			for resp, err := range llm.GenerateContent(ctx, attemptReq, true) {
+				if err != nil {
+					if streamed.Len() == 0 && prefix == "" {
+						yield(nil, err)
+						return
+					}
+					reason = err.Error()
+					break
+				}
+				if resp.Partial {
+					streamed.WriteString(responseText(resp))
+					if !yield(resp, nil) {
+						return
+					}
+					continue
+				}
+				if resp.FinishReason == genai.FinishReasonOther {
+					if text := responseText(resp); text != "" {
+						// The aggregated response replaces the partial text.
+						streamed.Reset()
+						streamed.WriteString(text)
+					}
+					if streamed.Len() > 0 || prefix != "" {
+						reason = "finish reason " + string(resp.FinishReason)
+						break
+					}
+				}
+				if !prefixed && responseText(resp) != "" {
+					resp = withTextPrefix(resp, prefix, attempt)
+					prefixed = true
+				}
+				streamed.Reset()
+				if !yield(resp, nil) {
+					return
+				}
+			}
+			if reason == "" {
+				if !prefixed {
+					// The continuation ended without text.
+					yield(&model.LLMResponse{
+						Content:        genai.NewContentFromText(prefix, genai.RoleModel),
+						TurnComplete:   true,
+						CustomMetadata: map[string]any{ContinuationsMetadataKey: attempt},
+					}, nil)
+				}
+				return
+			}
+
+			text := prefix + streamed.String()
+			if r.Policy == PartialResponseContinue && attempt < r.MaxContinuations {
+				prefix = text
+				attemptReq = continuationRequest(req, text, continuationPrompt)
+				continue
+			}
+			yield(&model.LLMResponse{
+				Content:      genai.NewContentFromText(text+r.Annotation, genai.RoleModel),
+				TurnComplete: true,
+				CustomMetadata: map[string]any{
+					PartialResponseMetadataKey: reason,
+					ContinuationsMetadataKey:   attempt,
+				},
+			}, nil)
+			return
+		}
+	}
+}
+
+// continuationRequest returns the request asking the model, with prompt, to
+// continue the text of its interrupted response.
+func continuationRequest(req *model.LLMRequest, text, prompt string) *model.LLMRequest {
+	cont := *req
+	cont.Contents = append(slices.Clone(req.Contents),
+		genai.NewContentFromText(text, genai.RoleModel),
+		genai.NewContentFromText(prompt, genai.RoleUser),
+	)
+	return &cont
+}
+
+// withTextPrefix returns the response of a continuation with the text of the
+// interrupted responses prepended to its text.
+func withTextPrefix(resp *model.LLMResponse, prefix string, continuations int) *model.LLMResponse {
+	if resp.Content == nil {
+		return resp
+	}
+	out := *resp
+	out.Content = &genai.Content{Role: resp.Content.Role}
+	added := false
+	for _, p := range resp.Content.Parts {
+		if !added && p != nil && p.Text != "" && !p.Thought {
+			p = &genai.Part{Text: prefix + p.Text}
+			added = true
+		}
+		out.Content.Parts = append(out.Content.Parts, p)
+	}
+	if !added {
+		out.Content.Parts = append([]*genai.Part{{Text: prefix}}, out.Content.Parts...)
+	}
+	out.CustomMetadata = map[string]any{ContinuationsMetadataKey: continuations}
+	for k, v := range resp.CustomMetadata {
+		out.CustomMetadata[k] = v
+	}
+	return &out
+}
+
+// responseText returns the text of the response, without the thoughts.
+func responseText(resp *model.LLMResponse) string {
+	if resp.Content == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, p := range resp.Content.Parts {
+		if p != nil && !p.Thought {
+			sb.WriteString(p.Text)
+		}
+	}
+	return sb.String()
+}
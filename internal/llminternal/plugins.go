@@ -0,0 +1,56 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"context"
+
+	"google.golang.org/adk/internal/plugininternal"
+)
+
+// The callbacks of the plugins registered on the runner run before the
+// callbacks of the agent.
+
+func (f *Flow) beforeModelCallbacks(ctx context.Context) []BeforeModelCallback {
+	var callbacks []BeforeModelCallback
+	for _, cb := range plugininternal.FromContext(ctx).BeforeModelCallbacks() {
+		callbacks = append(callbacks, BeforeModelCallback(cb))
+	}
+	return append(callbacks, f.BeforeModelCallbacks...)
+}
+
+func (f *Flow) afterModelCallbacks(ctx context.Context) []AfterModelCallback {
+	var callbacks []AfterModelCallback
+	for _, cb := range plugininternal.FromContext(ctx).AfterModelCallbacks() {
+		callbacks = append(callbacks, AfterModelCallback(cb))
+	}
+	return append(callbacks, f.AfterModelCallbacks...)
+}
+
+func (f *Flow) beforeToolCallbacks(ctx context.Context) []BeforeToolCallback {
+	var callbacks []BeforeToolCallback
+	for _, cb := range plugininternal.FromContext(ctx).BeforeToolCallbacks() {
+		callbacks = append(callbacks, BeforeToolCallback(cb))
+	}
+	return append(callbacks, f.BeforeToolCallbacks...)
+}
+
+func (f *Flow) afterToolCallbacks(ctx context.Context) []AfterToolCallback {
+	var callbacks []AfterToolCallback
+	for _, cb := range plugininternal.FromContext(ctx).AfterToolCallbacks() {
+		callbacks = append(callbacks, AfterToolCallback(cb))
+	}
+	return append(callbacks, f.AfterToolCallbacks...)
+}
@@ -0,0 +1,185 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/agent/parentmap"
+	"google.golang.org/adk/internal/logging"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// deferredInstructionsRequestProcessor sends the instructions, which were
+// deferred by instructionsRequestProcessor, as user content placed after the
+// conversation history and right before the latest user input. This keeps
+// the system instruction stable between model calls.
+func deferredInstructionsRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil {
+		return nil
+	}
+
+	rootAgent := asLLMAgent(parentmap.FromContext(ctx).RootAgent(ctx.Agent()))
+	if rootAgent == nil {
+		rootAgent = llmAgent
+	}
+
+	var deferred model.LLMRequest
+	if deferGlobalInstruction(ctx, llmAgent.internal(), rootAgent.internal()) {
+		if err := appendGlobalInstructions(ctx, &deferred, rootAgent.internal()); err != nil {
+			return fmt.Errorf("failed to append global instructions: %w", err)
+		}
+	}
+	if deferInstruction(ctx, llmAgent.internal()) {
+		if err := appendInstructions(ctx, &deferred, llmAgent.internal()); err != nil {
+			return fmt.Errorf("failed to append instructions: %w", err)
+		}
+	}
+	if deferred.Config == nil || deferred.Config.SystemInstruction == nil {
+		return nil
+	}
+
+	content := &genai.Content{Role: genai.RoleUser, Parts: deferred.Config.SystemInstruction.Parts}
+	req.Contents = slices.Insert(req.Contents, latestUserInputIndex(req.Contents), content)
+	req.Sensitive = append(req.Sensitive, deferred.Sensitive...)
+	return nil
+}
+
+// latestUserInputIndex returns the index of the first content of the latest
+// run of user input contents, or len(contents) if there is no user input.
+// The function responses aren't user input: the index stays the same in the
+// steps of a turn, and never separates a function call from its response.
+func latestUserInputIndex(contents []*genai.Content) int {
+	i := len(contents)
+	for i > 0 && !isUserInput(contents[i-1]) {
+		i--
+	}
+	if i == 0 {
+		return len(contents)
+	}
+	for i > 0 && isUserInput(contents[i-1]) {
+		i--
+	}
+	return i
+}
+
+// isUserInput reports whether the content is text written by the user.
+func isUserInput(c *genai.Content) bool {
+	if c == nil || c.Role != genai.RoleUser {
+		return false
+	}
+	hasText := false
+	for _, p := range c.Parts {
+		switch {
+		case p == nil:
+		case p.FunctionResponse != nil || p.FunctionCall != nil:
+			return false
+		case p.Text != "" && !p.Thought:
+			hasText = true
+		}
+	}
+	return hasText
+}
+
+// stabilizePromptPrefix makes the request prefix (the system instruction and
+// the tools) deterministic: the tools collected from the agent tools and
+// toolsets may come in any order, so the function declarations are sorted by
+// name.
+func stabilizePromptPrefix(req *model.LLMRequest) {
+	if req.Config == nil {
+		return
+	}
+	for _, t := range req.Config.Tools {
+		if t == nil {
+			continue
+		}
+		slices.SortStableFunc(t.FunctionDeclarations, func(a, b *genai.FunctionDeclaration) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+}
+
+// reportPromptPrefixChange logs which bytes of the request prefix changed
+// since prev, the prefix of the previous model call of the agent in the
+// invocation, if the agent debugs the cache stability. It returns the prefix
+// of the request, to compare the next model call with.
+func reportPromptPrefixChange(ctx agent.InvocationContext, prev []byte, req *model.LLMRequest) []byte {
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil || !llmAgent.internal().DebugCacheStability {
+		return nil
+	}
+	prefix, err := promptPrefix(req)
+	if err != nil {
+		logging.ForInvocation(ctx).Error("prompt cache: failed to encode the request prefix", "error", err)
+		return nil
+	}
+	if prev == nil {
+		return prefix
+	}
+	if msg, changed := prefixChange(prev, prefix); changed {
+		logging.ForInvocation(ctx).Info("prompt cache: request prefix " + msg)
+	}
+	return prefix
+}
+
+// promptPrefix returns the serialized stable sections of the request, in the
+// order they are sent to the model.
+func promptPrefix(req *model.LLMRequest) ([]byte, error) {
+	var p struct {
+		SystemInstruction *genai.Content `json:"systemInstruction,omitempty"`
+		Tools             []*genai.Tool  `json:"tools,omitempty"`
+	}
+	if req.Config != nil {
+		p.SystemInstruction = req.Config.SystemInstruction
+		p.Tools = req.Config.Tools
+	}
+	return json.Marshal(p)
+}
+
+// prefixChange describes the first difference between the previous and the
+// current prefix. It returns false if both are equal.
+func prefixChange(prev, cur []byte) (string, bool) {
+	n := min(len(prev), len(cur))
+	i := 0
+	for i < n && prev[i] == cur[i] {
+		i++
+	}
+	if i == len(prev) && i == len(cur) {
+		return "", false
+	}
+	end := min(i+40, len(cur))
+	return fmt.Sprintf("changed at byte %d of %d (was %d bytes long), only the first %d bytes can be served from cache; new bytes: %q",
+		i, len(cur), len(prev), i, cur[i:end]), true
+}
+
+// reportRequestDiff logs and traces the changes of the request since the
+// previous step of the invocation, if enabled for the agent.
+func reportRequestDiff(ctx agent.InvocationContext, spans []trace.Span, prev, cur *model.LLMRequest) {
+	llmAgent := asLLMAgent(ctx.Agent())
+	if prev == nil || llmAgent == nil || !llmAgent.internal().DebugRequestDiff {
+		return
+	}
+	diff := model.DiffRequests(prev, cur)
+	telemetry.TraceLLMRequestDiff(spans, diff)
+	logging.ForInvocation(ctx).Info("request diff", "diff", diff.String(), "perturbed", diff.Perturbed())
+}
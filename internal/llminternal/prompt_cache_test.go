@@ -0,0 +1,71 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestLatestUserInputIndex(t *testing.T) {
+	user := genai.NewContentFromText("hi", genai.RoleUser)
+	model := genai.NewContentFromText("hello", genai.RoleModel)
+	call := genai.NewContentFromFunctionCall("f", nil, genai.RoleModel)
+	response := genai.NewContentFromFunctionResponse("f", nil, genai.RoleUser)
+
+	tests := []struct {
+		name     string
+		contents []*genai.Content
+		want     int
+	}{
+		{"empty", nil, 0},
+		{"only user", []*genai.Content{user, user}, 0},
+		{"user after model", []*genai.Content{user, model, user, user}, 2},
+		{"ends with model", []*genai.Content{user, model}, 0},
+		{"function response", []*genai.Content{user, model, user, call, response}, 2},
+		{"function responses of a turn", []*genai.Content{user, model, user, call, response, call, response}, 2},
+		{"no user input", []*genai.Content{model, call, response}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := latestUserInputIndex(tt.contents); got != tt.want {
+				t.Errorf("latestUserInputIndex() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefixChange(t *testing.T) {
+	tests := []struct {
+		name        string
+		prev, cur   string
+		wantChanged bool
+		wantMsg     string
+	}{
+		{"equal", "abcdef", "abcdef", false, ""},
+		{"changed", "abcdef", "abcXef", true, "changed at byte 3 of 6"},
+		{"extended", "abc", "abcdef", true, "changed at byte 3 of 6 (was 3 bytes long)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, changed := prefixChange([]byte(tt.prev), []byte(tt.cur))
+			if changed != tt.wantChanged || !strings.Contains(msg, tt.wantMsg) {
+				t.Errorf("prefixChange(%q, %q) = (%q, %v), want (%q, %v)", tt.prev, tt.cur, msg, changed, tt.wantMsg, tt.wantChanged)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"encoding/json"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/featureflag"
+	"google.golang.org/adk/model"
+)
+
+// checkRequestSize returns a *model.ContextWindowExceededError if the
+// request doesn't fit the context window of the model, as reported by
+// model.CapabilitiesOf, so that the invocation fails before the model
+// provider rejects the request. If the agent has a context window, the
+// request contents are compacted to fit first.
+func checkRequestSize(ctx agent.InvocationContext, llm model.LLM, req *model.LLMRequest) error {
+	if llm == nil {
+		return nil
+	}
+	caps, ok := model.CapabilitiesOf(llm)
+	if !ok || caps.InputTokenLimit <= 0 {
+		return nil
+	}
+	limit := caps.InputTokenLimit
+	tokens := estimateRequestSize(req)
+	if tokens <= limit {
+		return nil
+	}
+
+	if w := contextWindow(ctx); w != nil {
+		contentTokens := 0
+		for _, c := range req.Contents {
+			contentTokens += EstimateTokens(c)
+		}
+		if available := limit - (tokens - contentTokens); available > 0 {
+			req.Contents = w.compact(req.Contents, available)
+			if tokens = estimateRequestSize(req); tokens <= limit {
+				return nil
+			}
+		}
+	}
+	return &model.ContextWindowExceededError{Model: llm.Name(), Limit: limit, Tokens: tokens}
+}
+
+// contextWindow returns the context window of the agent of the invocation,
+// or nil if it has none or it is turned off.
+func contextWindow(ctx agent.InvocationContext) *ContextWindow {
+	a, ok := ctx.Agent().(Agent)
+	if !ok {
+		return nil
+	}
+	w := a.internal().ContextWindow
+	if w == nil || !flagEnabled(ctx, featureflag.ContextWindow, true) {
+		return nil
+	}
+	return w
+}
+
+// estimateRequestSize estimates the prompt tokens of the request including
+// its tool declarations.
+func estimateRequestSize(req *model.LLMRequest) int {
+	n := estimateRequestTokens(req)
+	if req.Config != nil && len(req.Config.Tools) > 0 {
+		if data, err := json.Marshal(req.Config.Tools); err == nil {
+			n += (len(data) + 3) / 4
+		}
+	}
+	return n
+}
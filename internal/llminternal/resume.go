@@ -0,0 +1,61 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// resumePendingToolCalls executes the function calls of the last event of
+// the session if the agent made them and they have no responses yet, i.e.
+// the invocation was interrupted before the tools ran, e.g. by a crash. It
+// returns the function response event, or nil if there are no such calls.
+//
+// The calls of the long-running tools and the credential requests aren't
+// executed: they wait for the responses of the client.
+func (f *Flow) resumePendingToolCalls(ctx agent.InvocationContext) (*session.Event, error) {
+	if ctx.Session() == nil {
+		return nil, nil
+	}
+	events := ctx.Session().Events()
+	if events.Len() == 0 {
+		return nil, nil
+	}
+	last := events.At(events.Len() - 1)
+	if last.Author != ctx.Agent().Name() || last.Branch != ctx.Branch() || last.Partial || len(last.LongRunningToolIDs) > 0 {
+		return nil, nil
+	}
+	content := &genai.Content{Role: genai.RoleModel}
+	for _, fnCall := range utils.FunctionCalls(last.LLMResponse.Content) {
+		if fnCall.Name == auth.RequestCredentialFunctionName {
+			return nil, nil
+		}
+		content.Parts = append(content.Parts, &genai.Part{FunctionCall: fnCall})
+	}
+	if len(content.Parts) == 0 {
+		return nil, nil
+	}
+
+	tools, err := f.toolsDict(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return f.handleFunctionCalls(ctx, tools, &model.LLMResponse{Content: content}, nil)
+}
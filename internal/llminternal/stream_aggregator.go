@@ -50,9 +50,12 @@ func (s *streamingResponseAggregator) ProcessResponse(ctx context.Context, genRe
 		}
 		candidate := genResp.Candidates[0]
 		resp := converters.Genai2LLMResponse(genResp)
-		resp.TurnComplete = candidate.FinishReason != ""
 		// Aggregate the response and check if an intermediate event to yield was created
-		if aggrResp := s.aggregateResponse(resp); aggrResp != nil {
+		aggrResp := s.aggregateResponse(resp)
+		// The turn is complete once the aggregated response is yielded, so
+		// the partial responses aren't marked.
+		resp.TurnComplete = candidate.FinishReason != "" && !resp.Partial
+		if aggrResp != nil {
 			if !yield(aggrResp, nil) {
 				return // Consumer stopped
 			}
@@ -97,10 +100,36 @@ func (s *streamingResponseAggregator) aggregateResponse(llmResponse *model.LLMRe
 	return nil
 }
 
+// addPartial aggregates the text of the partial response without generating
+// an aggregated response, which is generated by Close.
+func (s *streamingResponseAggregator) addPartial(llmResponse *model.LLMResponse) {
+	s.response = llmResponse
+	if llmResponse.Content == nil {
+		return
+	}
+	if llmResponse.Content.Role != "" {
+		s.role = llmResponse.Content.Role
+	}
+	for _, part := range llmResponse.Content.Parts {
+		switch {
+		case part == nil || part.Text == "":
+		case part.Thought:
+			s.thoughtText += part.Text
+		default:
+			s.text += part.Text
+		}
+	}
+}
+
 // Close generates an aggregated response at the end, if needed,
 // this should be called after all the model responses are processed.
+// The aggregated response completes the turn.
 func (s *streamingResponseAggregator) Close() *model.LLMResponse {
-	return s.createAggregateResponse()
+	resp := s.createAggregateResponse()
+	if resp != nil {
+		resp.TurnComplete = true
+	}
+	return resp
 }
 
 func (s *streamingResponseAggregator) createAggregateResponse() *model.LLMResponse {
@@ -117,6 +146,7 @@ func (s *streamingResponseAggregator) createAggregateResponse() *model.LLMRespon
 			Content:           &genai.Content{Parts: parts, Role: s.role},
 			ErrorCode:         s.response.ErrorCode,
 			ErrorMessage:      s.response.ErrorMessage,
+			Blocked:           s.response.Blocked,
 			UsageMetadata:     s.response.UsageMetadata,
 			GroundingMetadata: s.response.GroundingMetadata,
 			FinishReason:      s.response.FinishReason,
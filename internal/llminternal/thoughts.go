@@ -0,0 +1,73 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// nlPlanningResponseProcessor separates the thought parts of the model
+// response (the reasoning of the model) from its user-visible parts: the
+// thoughts are moved before the other parts and a response holding only
+// thoughts is marked with model.LLMResponse.Thought, so that the runner can
+// withhold them from the end user (see agent.RunConfig.IncludeThoughts).
+// If the agent strips the thoughts, they are removed from the response, so
+// they are not persisted in the session history either.
+func nlPlanningResponseProcessor(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
+	if resp == nil || resp.Content == nil {
+		return nil
+	}
+	thoughts, visible := SplitThoughts(resp.Content.Parts)
+	if len(thoughts) == 0 {
+		return nil
+	}
+	if stripThoughts(ctx) {
+		if len(visible) == 0 {
+			// Nothing is left to yield.
+			resp.Content = nil
+			return nil
+		}
+		resp.Content = &genai.Content{Role: resp.Content.Role, Parts: visible}
+		return nil
+	}
+	resp.Content = &genai.Content{Role: resp.Content.Role, Parts: append(thoughts, visible...)}
+	resp.Thought = len(visible) == 0
+	return nil
+}
+
+// SplitThoughts splits the parts into the thought parts and the other parts,
+// keeping their order.
+func SplitThoughts(parts []*genai.Part) (thoughts, visible []*genai.Part) {
+	for _, p := range parts {
+		if p == nil {
+			continue
+		}
+		if p.Thought {
+			thoughts = append(thoughts, p)
+		} else {
+			visible = append(visible, p)
+		}
+	}
+	return thoughts, visible
+}
+
+func stripThoughts(ctx agent.InvocationContext) bool {
+	if llmAgent := asLLMAgent(ctx.Agent()); llmAgent != nil {
+		return llmAgent.internal().StripThoughts
+	}
+	return false
+}
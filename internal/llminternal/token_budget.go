@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"errors"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+// checkTokenBudget returns a *agent.TokenBudgetExceededError if the model
+// call with the request would exceed the token budget of the invocation.
+func checkTokenBudget(ctx agent.InvocationContext, req *model.LLMRequest) error {
+	cfg, counter := ctx.RunConfig(), ctx.Tokens()
+	if cfg == nil || cfg.MaxTokensPerInvocation <= 0 || counter == nil {
+		return nil
+	}
+	used := counter.Usage().TotalTokens + estimateRequestTokens(req)
+	if used <= cfg.MaxTokensPerInvocation {
+		return nil
+	}
+	return &agent.TokenBudgetExceededError{
+		Agent:  ctx.Agent().Name(),
+		Budget: cfg.MaxTokensPerInvocation,
+		Used:   used,
+	}
+}
+
+// tokenBudgetExceededEvent returns the event which ends the invocation. It
+// tells the client that the result of the invocation is partial.
+func tokenBudgetExceededEvent(ctx agent.InvocationContext, err error) *session.Event {
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.ErrorCode = agent.TokenBudgetExceededCode
+	ev.ErrorMessage = err.Error()
+	var budgetErr *agent.TokenBudgetExceededError
+	if errors.As(err, &budgetErr) {
+		ev.CustomMetadata = map[string]any{
+			"token_budget": budgetErr.Budget,
+			"tokens_used":  budgetErr.Used,
+		}
+	}
+	return ev
+}
+
+// countTokens adds the usage of the model call to the token counter of the
+// invocation. The usage is estimated if the model didn't report it.
+func countTokens(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) {
+	counter := ctx.Tokens()
+	if counter == nil || resp == nil {
+		return
+	}
+	if u := resp.UsageMetadata; u != nil && u.TotalTokenCount > 0 {
+		counter.Add(agent.TokenUsage{
+			PromptTokens:     int(u.PromptTokenCount),
+			CandidatesTokens: int(u.CandidatesTokenCount),
+			TotalTokens:      int(u.TotalTokenCount),
+		})
+		return
+	}
+	prompt := estimateRequestTokens(req)
+	candidates := 0
+	if resp.Content != nil {
+		candidates = EstimateTokens(resp.Content)
+	}
+	counter.Add(agent.TokenUsage{
+		PromptTokens:     prompt,
+		CandidatesTokens: candidates,
+		TotalTokens:      prompt + candidates,
+	})
+}
+
+// estimateRequestTokens estimates the prompt tokens of the request: its
+// contents and its system instruction.
+func estimateRequestTokens(req *model.LLMRequest) int {
+	n := 0
+	for _, c := range req.Contents {
+		n += EstimateTokens(c)
+	}
+	if req.Config != nil && req.Config.SystemInstruction != nil {
+		n += EstimateTokens(req.Config.SystemInstruction)
+	}
+	return n
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"google.golang.org/adk/internal/logging"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/toolresult"
+)
+
+// ToolResultLimit limits the size of the function responses sent to the
+// model.
+type ToolResultLimit struct {
+	MaxBytes int
+	Strategy toolresult.Strategy
+}
+
+// apply returns the result of the call of the tool, reduced by the strategy
+// if its JSON encoding exceeds MaxBytes. The result is truncated if the
+// strategy fails or its result still exceeds MaxBytes.
+func (l *ToolResultLimit) apply(ctx tool.Context, toolName string, result map[string]any) map[string]any {
+	if l == nil || l.MaxBytes <= 0 || toolresult.Size(result) <= l.MaxBytes {
+		return result
+	}
+	reduced, err := l.Strategy.Reduce(ctx, toolName, result, l.MaxBytes)
+	if err != nil {
+		logging.FromContext(ctx).Warn("tool result limit: failed to reduce the result, truncating it", "tool", toolName, "error", err)
+		reduced = result
+	}
+	if toolresult.Size(reduced) <= l.MaxBytes {
+		return reduced
+	}
+	truncated, err := toolresult.Truncate().Reduce(ctx, toolName, reduced, l.MaxBytes)
+	if err != nil {
+		return result
+	}
+	return truncated
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging provides the structured logger of the invocations.
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"google.golang.org/adk/agent"
+)
+
+type loggerCtxKey struct{}
+
+// ToContext returns a copy of ctx holding the logger.
+func ToContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey{}, logger)
+}
+
+// FromContext returns the logger of ctx, or slog.Default() if it has none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerCtxKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// ForInvocation returns the logger of the invocation, which adds its IDs,
+// session and agent to the records.
+func ForInvocation(ctx agent.InvocationContext) *slog.Logger {
+	attrs := []any{slog.String("invocation_id", ctx.InvocationID())}
+	if a := ctx.Agent(); a != nil {
+		attrs = append(attrs, slog.String("agent", a.Name()))
+	}
+	if s := ctx.Session(); s != nil {
+		attrs = append(attrs, slog.String("app", s.AppName()), slog.String("user_id", s.UserID()), slog.String("session_id", s.ID()))
+	}
+	return FromContext(ctx).With(attrs...)
+}
@@ -0,0 +1,204 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugininternal runs the callbacks of the plugins registered on the
+// runner.
+package plugininternal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/plugininternal/plugincontext"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// Manager runs the callbacks of the plugins in order.
+type Manager struct {
+	plugins []*plugin.Plugin
+}
+
+// NewManager returns the manager of the plugins. The plugin names must be
+// unique.
+func NewManager(plugins []*plugin.Plugin) (*Manager, error) {
+	names := make(map[string]bool)
+	for _, p := range plugins {
+		if p == nil {
+			return nil, fmt.Errorf("plugin is nil")
+		}
+		if names[p.Name()] {
+			return nil, fmt.Errorf("plugin %q is registered more than once", p.Name())
+		}
+		names[p.Name()] = true
+	}
+	return &Manager{plugins: plugins}, nil
+}
+
+// ToContext returns a context holding the manager.
+func ToContext(ctx context.Context, m *Manager) context.Context {
+	return plugincontext.ToContext(ctx, m)
+}
+
+// FromContext returns the manager of the run, or nil if there are no
+// plugins.
+func FromContext(ctx context.Context) *Manager {
+	m, _ := plugincontext.FromContext(ctx).(*Manager)
+	return m
+}
+
+// RunOnUserMessage returns the user message as replaced by the plugins.
+func (m *Manager) RunOnUserMessage(ctx agent.InvocationContext, msg *genai.Content) (*genai.Content, error) {
+	for _, p := range m.all() {
+		if cb := p.OnUserMessageCallback(); cb != nil {
+			newMsg, err := cb(ctx, msg)
+			if err != nil {
+				return nil, fmt.Errorf("plugin %q: on user message callback failed: %w", p.Name(), err)
+			}
+			if newMsg != nil {
+				return newMsg, nil
+			}
+		}
+	}
+	return msg, nil
+}
+
+// RunBeforeRun returns the content ending the run, or nil if the run
+// proceeds.
+func (m *Manager) RunBeforeRun(ctx agent.InvocationContext) (*genai.Content, error) {
+	for _, p := range m.all() {
+		if cb := p.BeforeRunCallback(); cb != nil {
+			content, err := cb(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("plugin %q: before run callback failed: %w", p.Name(), err)
+			}
+			if content != nil {
+				return content, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// RunAfterRun runs the after run callbacks of all the plugins.
+func (m *Manager) RunAfterRun(ctx agent.InvocationContext) {
+	for _, p := range m.all() {
+		if cb := p.AfterRunCallback(); cb != nil {
+			cb(ctx)
+		}
+	}
+}
+
+// RunClose runs the close callbacks of all the plugins. All the callbacks are
+// run, even if some of them fail.
+func (m *Manager) RunClose(ctx context.Context) error {
+	var errs []error
+	for _, p := range m.all() {
+		if cb := p.CloseCallback(); cb != nil {
+			if err := cb(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("plugin %q: close callback failed: %w", p.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RunOnEvent returns the event as replaced by the plugins.
+func (m *Manager) RunOnEvent(ctx agent.InvocationContext, event *session.Event) (*session.Event, error) {
+	for _, p := range m.all() {
+		if cb := p.OnEventCallback(); cb != nil {
+			newEvent, err := cb(ctx, event)
+			if err != nil {
+				return nil, fmt.Errorf("plugin %q: on event callback failed: %w", p.Name(), err)
+			}
+			if newEvent != nil {
+				return newEvent, nil
+			}
+		}
+	}
+	return event, nil
+}
+
+// The callback lists below are run before the callbacks of the agents. They
+// are empty for a nil manager.
+
+// BeforeAgentCallbacks returns the before agent callbacks of the plugins.
+func (m *Manager) BeforeAgentCallbacks() (callbacks []agent.BeforeAgentCallback) {
+	for _, p := range m.all() {
+		if cb := p.BeforeAgentCallback(); cb != nil {
+			callbacks = append(callbacks, cb)
+		}
+	}
+	return callbacks
+}
+
+// AfterAgentCallbacks returns the after agent callbacks of the plugins.
+func (m *Manager) AfterAgentCallbacks() (callbacks []agent.AfterAgentCallback) {
+	for _, p := range m.all() {
+		if cb := p.AfterAgentCallback(); cb != nil {
+			callbacks = append(callbacks, cb)
+		}
+	}
+	return callbacks
+}
+
+// BeforeModelCallbacks returns the before model callbacks of the plugins.
+func (m *Manager) BeforeModelCallbacks() (callbacks []plugin.BeforeModelCallback) {
+	for _, p := range m.all() {
+		if cb := p.BeforeModelCallback(); cb != nil {
+			callbacks = append(callbacks, cb)
+		}
+	}
+	return callbacks
+}
+
+// AfterModelCallbacks returns the after model callbacks of the plugins.
+func (m *Manager) AfterModelCallbacks() (callbacks []plugin.AfterModelCallback) {
+	for _, p := range m.all() {
+		if cb := p.AfterModelCallback(); cb != nil {
+			callbacks = append(callbacks, cb)
+		}
+	}
+	return callbacks
+}
+
+// BeforeToolCallbacks returns the before tool callbacks of the plugins.
+func (m *Manager) BeforeToolCallbacks() (callbacks []plugin.BeforeToolCallback) {
+	for _, p := range m.all() {
+		if cb := p.BeforeToolCallback(); cb != nil {
+			callbacks = append(callbacks, cb)
+		}
+	}
+	return callbacks
+}
+
+// AfterToolCallbacks returns the after tool callbacks of the plugins.
+func (m *Manager) AfterToolCallbacks() (callbacks []plugin.AfterToolCallback) {
+	for _, p := range m.all() {
+		if cb := p.AfterToolCallback(); cb != nil {
+			callbacks = append(callbacks, cb)
+		}
+	}
+	return callbacks
+}
+
+func (m *Manager) all() []*plugin.Plugin {
+	if m == nil {
+		return nil
+	}
+	return m.plugins
+}
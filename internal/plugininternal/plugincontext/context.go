@@ -0,0 +1,32 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugincontext stores the plugin manager of the run in the context.
+// It has no dependencies, so the agent package can look the manager up
+// without an import cycle.
+package plugincontext
+
+import "context"
+
+type ctxKey struct{}
+
+// ToContext returns a context holding the plugin manager.
+func ToContext(ctx context.Context, manager any) context.Context {
+	return context.WithValue(ctx, ctxKey{}, manager)
+}
+
+// FromContext returns the plugin manager of the context, or nil.
+func FromContext(ctx context.Context) any {
+	return ctx.Value(ctxKey{})
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	sessionOperationDuration = "gcp.vertex.agent.session.operation.duration"
+	sessionOperation         = "gcp.vertex.agent.session.operation"
+	sessionOperationError    = "gcp.vertex.agent.session.operation.error"
+
+	invocationFailures        = "gcp.vertex.agent.invocation.failures"
+	invocationFailureCategory = "gcp.vertex.agent.failure.category"
+	invocationFailureAgent    = "gcp.vertex.agent.failure.agent"
+)
+
+// sessionOperationHistogram is created with the global meter provider. If the
+// global meter provider is not set, the measurements are dropped.
+var sessionOperationHistogram = sync.OnceValue(func() metric.Float64Histogram {
+	h, err := otel.Meter(systemName).Float64Histogram(sessionOperationDuration,
+		metric.WithDescription("Latency of the session service calls made by the runner."),
+		metric.WithUnit("ms"))
+	if err != nil {
+		otel.Handle(err)
+	}
+	return h
+})
+
+// RecordSessionOperation records the latency of a session service call, e.g.
+// "get" or "append_event".
+func RecordSessionOperation(ctx context.Context, operation string, d time.Duration, err error) {
+	h := sessionOperationHistogram()
+	if h == nil {
+		return
+	}
+	h.Record(ctx, float64(d)/float64(time.Millisecond), metric.WithAttributes(
+		attribute.String(sessionOperation, operation),
+		attribute.Bool(sessionOperationError, err != nil),
+	))
+}
+
+// invocationFailureCounter is created with the global meter provider. If the
+// global meter provider is not set, the measurements are dropped.
+var invocationFailureCounter = sync.OnceValue(func() metric.Int64Counter {
+	c, err := otel.Meter(systemName).Int64Counter(invocationFailures,
+		metric.WithDescription("Number of failed invocations, by failure category."))
+	if err != nil {
+		otel.Handle(err)
+	}
+	return c
+})
+
+// RecordInvocationFailure counts a failed invocation with its category, e.g.
+// "model_error", and the agent which failed.
+func RecordInvocationFailure(ctx context.Context, category, agentName string) {
+	c := invocationFailureCounter()
+	if c == nil {
+		return
+	}
+	c.Add(ctx, 1, metric.WithAttributes(
+		attribute.String(invocationFailureCategory, category),
+		attribute.String(invocationFailureAgent, agentName),
+	))
+}
@@ -18,6 +18,7 @@ package telemetry
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"sync"
 
 	"go.opentelemetry.io/otel"
@@ -80,6 +81,23 @@ func RegisterTelemetry() {
 	})
 }
 
+// Flush exports the spans which the local tracer and the global tracer
+// haven't exported yet, if the global tracer supports flushing.
+func Flush(ctx context.Context) error {
+	var errs []error
+	if tp, ok := localTracer.tp.(*sdktrace.TracerProvider); ok {
+		if err := tp.ForceFlush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if tp, ok := otel.GetTracerProvider().(interface{ ForceFlush(context.Context) error }); ok {
+		if err := tp.ForceFlush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // If the global tracer is not set, the default NoopTracerProvider will be used.
 // That means that the spans are NOT recording/exporting
 // If the local tracer is not set, we'll set up tracer with all registered span processors.
@@ -183,7 +201,7 @@ func TraceLLMCall(spans []trace.Span, agentCtx agent.InvocationContext, llmReque
 			attribute.String("gcp.vertex.agent.invocation_id", event.InvocationID),
 			attribute.String("gcp.vertex.agent.session_id", agentCtx.Session().ID()),
 			attribute.String("gcp.vertex.agent.event_id", event.ID),
-			attribute.String("gcp.vertex.agent.llm_request", safeSerialize(llmRequestToTrace(llmRequest))),
+			attribute.String("gcp.vertex.agent.llm_request", model.RedactSensitiveJSON(safeSerialize(llmRequestToTrace(llmRequest)), llmRequest.Sensitive)),
 			attribute.String("gcp.vertex.agent.llm_response", safeSerialize(event.LLMResponse)),
 		}
 
@@ -202,6 +220,17 @@ func TraceLLMCall(spans []trace.Span, agentCtx agent.InvocationContext, llmReque
 	}
 }
 
+// TraceLLMRequestDiff records the changes of the LLM request since the
+// previous LLM call of the invocation.
+func TraceLLMRequestDiff(spans []trace.Span, diff *model.RequestDiff) {
+	for _, span := range spans {
+		span.SetAttributes(
+			attribute.String("gcp.vertex.agent.llm_request_diff", diff.String()),
+			attribute.Bool("gcp.vertex.agent.llm_request_perturbed", diff.Perturbed()),
+		)
+	}
+}
+
 func safeSerialize(obj any) string {
 	dump, err := json.Marshal(obj)
 	if err != nil {
@@ -20,6 +20,7 @@ import (
 	"github.com/google/uuid"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/auth"
 	contextinternal "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/session"
@@ -79,7 +80,12 @@ type toolContext struct {
 	artifacts         *internalArtifacts
 }
 
+// Artifacts returns the artifacts of the session, or nil if the runner has
+// no artifact service.
 func (c *toolContext) Artifacts() agent.Artifacts {
+	if c.artifacts.Artifacts == nil {
+		return nil
+	}
 	return c.artifacts
 }
 
@@ -98,3 +104,27 @@ func (c *toolContext) AgentName() string {
 func (c *toolContext) SearchMemory(ctx context.Context, query string) (*memory.SearchResponse, error) {
 	return c.invocationContext.Memory().Search(ctx, query)
 }
+
+func (c *toolContext) RequestCredential(cfg *auth.Config) {
+	if c.eventActions.RequestedAuthConfigs == nil {
+		c.eventActions.RequestedAuthConfigs = make(map[string]*auth.Config)
+	}
+	c.eventActions.RequestedAuthConfigs[c.functionCallID] = cfg
+}
+
+func (c *toolContext) Credential(cfg *auth.Config) (*auth.Credential, error) {
+	// The credential exchanged when the client responded to the request.
+	if v, err := c.State().Get(CredentialStateKey(cfg)); err == nil {
+		if cred, ok := v.(*auth.Credential); ok {
+			return cred, nil
+		}
+	}
+	return LoadCredential(c.invocationContext, cfg)
+}
+
+// CredentialStateKey returns the state key holding the credential exchanged
+// for cfg. The key is temporary, so the credential isn't persisted in the
+// session.
+func CredentialStateKey(cfg *auth.Config) string {
+	return session.KeyPrefixTemp + cfg.Key()
+}
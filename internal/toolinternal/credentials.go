@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolinternal
+
+import (
+	"fmt"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/agent/runconfig"
+)
+
+// LoadCredential returns the credential for cfg stored in the credential
+// service of the run, refreshing it if needed. It returns nil if there is no
+// credential service or no stored credential.
+func LoadCredential(ctx agent.InvocationContext, cfg *auth.Config) (*auth.Credential, error) {
+	service := credentialService(ctx)
+	if service == nil {
+		return nil, nil
+	}
+	id := credentialID(ctx, cfg)
+	cred, err := service.Load(ctx, &auth.LoadCredentialRequest{ID: id})
+	if err != nil || cred == nil {
+		return nil, err
+	}
+	cred, refreshed, err := auth.Refresh(ctx, cfg, cred)
+	if err != nil {
+		return nil, err
+	}
+	if refreshed {
+		if err := service.Save(ctx, &auth.SaveCredentialRequest{ID: id, Credential: cred}); err != nil {
+			return nil, fmt.Errorf("failed to save refreshed credential: %w", err)
+		}
+	}
+	return cred, nil
+}
+
+// SaveCredential stores the credential exchanged for cfg in the credential
+// service of the run, if any.
+func SaveCredential(ctx agent.InvocationContext, cfg *auth.Config, cred *auth.Credential) error {
+	service := credentialService(ctx)
+	if service == nil {
+		return nil
+	}
+	return service.Save(ctx, &auth.SaveCredentialRequest{ID: credentialID(ctx, cfg), Credential: cred})
+}
+
+func credentialService(ctx agent.InvocationContext) auth.CredentialService {
+	if cfg := runconfig.FromContext(ctx); cfg != nil {
+		return cfg.CredentialService
+	}
+	return nil
+}
+
+func credentialID(ctx agent.InvocationContext, cfg *auth.Config) auth.CredentialID {
+	s := ctx.Session()
+	id := auth.CredentialID{AppName: s.AppName(), UserID: s.UserID(), Key: cfg.Key()}
+	if cfg.Scope == auth.ScopeSession {
+		id.SessionID = s.ID()
+	}
+	return id
+}
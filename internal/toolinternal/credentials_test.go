@@ -0,0 +1,71 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolinternal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/agent/runconfig"
+	contextinternal "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/session"
+)
+
+func TestToolContext_Credential(t *testing.T) {
+	ctx := runconfig.ToContext(t.Context(), &runconfig.RunConfig{CredentialService: auth.InMemoryCredentialService()})
+	sessionService := session.InMemoryService()
+	newToolContext := func(sessionID string) *toolContext {
+		resp, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: sessionID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		inv := contextinternal.NewInvocationContext(ctx, contextinternal.InvocationContextParams{Session: resp.Session})
+		return NewToolContext(inv, "fn1", nil).(*toolContext)
+	}
+	userConfig := &auth.Config{CredentialKey: "user_key"}
+	sessionConfig := &auth.Config{CredentialKey: "session_key", Scope: auth.ScopeSession}
+	cred := &auth.Credential{AuthType: auth.CredentialTypeAPIKey, APIKey: "secret"}
+
+	toolCtx := newToolContext("s1")
+	for _, cfg := range []*auth.Config{userConfig, sessionConfig} {
+		if got, err := toolCtx.Credential(cfg); err != nil || got != nil {
+			t.Errorf("Credential(%q) = (%v, %v), want no credential", cfg.Key(), got, err)
+		}
+		if err := SaveCredential(toolCtx.invocationContext, cfg, cred); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := toolCtx.Credential(cfg); err != nil || !cmp.Equal(got, cred) {
+			t.Errorf("Credential(%q) = (%v, %v), want the saved credential", cfg.Key(), got, err)
+		}
+	}
+
+	other := newToolContext("s2")
+	if got, err := other.Credential(userConfig); err != nil || !cmp.Equal(got, cred) {
+		t.Errorf("Credential(user scoped) in another session = (%v, %v), want the saved credential", got, err)
+	}
+	if got, err := other.Credential(sessionConfig); err != nil || got != nil {
+		t.Errorf("Credential(session scoped) in another session = (%v, %v), want no credential", got, err)
+	}
+
+	// The credential exchanged in the invocation takes precedence.
+	exchanged := &auth.Credential{AuthType: auth.CredentialTypeAPIKey, APIKey: "new"}
+	if err := other.State().Set(CredentialStateKey(userConfig), exchanged); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := other.Credential(userConfig); err != nil || got != exchanged {
+		t.Errorf("Credential(user scoped) = (%v, %v), want the exchanged credential", got, err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolinternal
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"google.golang.org/adk/tool"
+)
+
+// Detach returns a tool context for running the tool of tc in a goroutine
+// which may outlive the call, e.g. after a timeout. The returned context is
+// canceled with ctx and has its own copy of the actions of tc, so that the
+// late changes of an abandoned tool don't race with the event of the call.
+// commit copies the actions back to tc once the tool returned in time.
+func Detach(ctx context.Context, tc tool.Context) (detached tool.Context, commit func()) {
+	t, ok := tc.(*toolContext)
+	if !ok {
+		return &deadlineContext{Context: tc, ctx: ctx}, func() {}
+	}
+	actions := *t.eventActions
+	actions.StateDelta = maps.Clone(t.eventActions.StateDelta)
+	actions.ArtifactDelta = maps.Clone(t.eventActions.ArtifactDelta)
+	actions.RequestedAuthConfigs = maps.Clone(t.eventActions.RequestedAuthConfigs)
+	detachedCtx := NewToolContext(t.invocationContext, t.functionCallID, &actions)
+	return &deadlineContext{Context: detachedCtx, ctx: ctx}, func() {
+		// The state of tc writes to its StateDelta map, so the map is kept.
+		delta := t.eventActions.StateDelta
+		clear(delta)
+		maps.Copy(delta, actions.StateDelta)
+		*t.eventActions = actions
+		t.eventActions.StateDelta = delta
+	}
+}
+
+// deadlineContext is a tool.Context canceled with ctx.
+type deadlineContext struct {
+	tool.Context
+	ctx context.Context
+}
+
+func (c *deadlineContext) Deadline() (time.Time, bool) { return c.ctx.Deadline() }
+func (c *deadlineContext) Done() <-chan struct{}       { return c.ctx.Done() }
+func (c *deadlineContext) Err() error                  { return c.ctx.Err() }
+func (c *deadlineContext) Value(key any) any           { return c.ctx.Value(key) }
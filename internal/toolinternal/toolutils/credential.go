@@ -0,0 +1,32 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolutils
+
+import "google.golang.org/adk/auth"
+
+// AccessToken returns the OAuth2 access token or the bearer token of the
+// credential, or "" if it has neither, e.g. to call the Google Cloud APIs
+// on behalf of the user.
+func AccessToken(cred *auth.Credential) string {
+	switch {
+	case cred == nil:
+		return ""
+	case cred.OAuth2 != nil && cred.OAuth2.AccessToken != "":
+		return cred.OAuth2.AccessToken
+	case cred.HTTP != nil:
+		return cred.HTTP.Credentials.Token
+	}
+	return ""
+}
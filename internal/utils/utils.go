@@ -130,7 +130,8 @@ func AppendInstructions(r *model.LLMRequest, instructions ...string) {
 		return
 	}
 
-	inst := strings.Join(instructions, "\n\n")
+	inst, sensitive := model.ExtractSensitive(strings.Join(instructions, "\n\n"))
+	r.Sensitive = append(r.Sensitive, sensitive...)
 
 	if r.Config == nil {
 		r.Config = &genai.GenerateContentConfig{}
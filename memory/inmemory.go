@@ -15,20 +15,64 @@
 package memory
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"maps"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"google.golang.org/adk/embedding"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/summarizer"
 	"google.golang.org/genai"
 )
 
 // InMemoryService returns a new in-memory implementation of the memory service. Thread-safe.
 func InMemoryService() Service {
+	return NewInMemoryService(InMemoryConfig{})
+}
+
+// InMemoryConfig is the configuration of the service returned by
+// NewInMemoryService.
+type InMemoryConfig struct {
+	// Summarizer, if set, summarizes the sessions added to the memory, with
+	// the purpose summarizer.PurposeMemory. The summary is stored as the
+	// only memory of the session instead of its events.
+	// Optional.
+	Summarizer summarizer.Summarizer
+	// Embedder, if set, embeds the memories and the queries: the search
+	// returns the memories similar to the query, the most similar first,
+	// instead of the memories sharing a word with it.
+	// Optional.
+	Embedder embedding.Embedder
+	// MinSimilarity is the minimum cosine similarity between a memory and
+	// the query for the memory to be returned, with an Embedder.
+	// Optional: defaults to DefaultMinSimilarity.
+	MinSimilarity float64
+	// MaxResults is the maximum number of memories returned by a search,
+	// with an Embedder.
+	// Optional: if zero, all the similar memories are returned.
+	MaxResults int
+}
+
+// DefaultMinSimilarity is the default InMemoryConfig.MinSimilarity.
+const DefaultMinSimilarity = 0.6
+
+// NewInMemoryService returns a new in-memory implementation of the memory
+// service with the given configuration. Thread-safe.
+func NewInMemoryService(cfg InMemoryConfig) Service {
+	if cfg.MinSimilarity == 0 {
+		cfg.MinSimilarity = DefaultMinSimilarity
+	}
 	return &inMemoryService{
-		store: make(map[key]map[sessionID][]value),
+		store:         make(map[key]map[sessionID][]value),
+		summarizer:    cfg.Summarizer,
+		embedder:      cfg.Embedder,
+		minSimilarity: cfg.MinSimilarity,
+		maxResults:    cfg.MaxResults,
 	}
 }
 
@@ -45,17 +89,73 @@ type value struct {
 
 	// precomputed set of words in the content for simple keyword matching.
 	words map[string]struct{}
+	// embedding of the text of the content, if the service has an embedder.
+	embedding []float32
 }
 
 // inMemoryService is an in-memory implementation of Service.
 type inMemoryService struct {
 	mu    sync.RWMutex
 	store map[key]map[sessionID][]value
+
+	summarizer    summarizer.Summarizer
+	embedder      embedding.Embedder
+	minSimilarity float64
+	maxResults    int
 }
 
 func (s *inMemoryService) AddSession(ctx context.Context, curSession session.Session) error {
-	var values []value
+	values, err := s.sessionValues(ctx, curSession)
+	if err != nil {
+		return err
+	}
+	if err := s.embed(ctx, values); err != nil {
+		return fmt.Errorf("failed to embed the memories of session %q: %w", curSession.ID(), err)
+	}
 
+	k := key{
+		appName: curSession.AppName(),
+		userID:  curSession.UserID(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.store[k]
+	if !ok {
+		v = map[sessionID][]value{}
+		s.store[k] = v
+	}
+
+	sid := sessionID(curSession.ID())
+	v[sid] = values
+	return nil
+}
+
+// sessionValues returns the memories of the session: its events with text,
+// or its summary if the service has a summarizer.
+func (s *inMemoryService) sessionValues(ctx context.Context, curSession session.Session) ([]value, error) {
+	return sessionValues(ctx, s.summarizer, curSession)
+}
+
+func sessionValues(ctx context.Context, sum summarizer.Summarizer, curSession session.Session) ([]value, error) {
+	if sum != nil {
+		summary, err := sum.Summarize(ctx, summarizer.SessionRequest(curSession, summarizer.PurposeMemory))
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize session %q: %w", curSession.ID(), err)
+		}
+		if summary == "" {
+			return nil, nil
+		}
+		return []value{{
+			content:   genai.NewContentFromText(summary, genai.RoleModel),
+			author:    "memory",
+			timestamp: curSession.LastUpdateTime(),
+			words:     extractWords(summary),
+		}}, nil
+	}
+
+	var values []value
 	for event := range curSession.Events().All() {
 		if event.LLMResponse.Content == nil {
 			continue
@@ -81,27 +181,45 @@ func (s *inMemoryService) AddSession(ctx context.Context, curSession session.Ses
 			words:     words,
 		})
 	}
+	return values, nil
+}
 
-	k := key{
-		appName: curSession.AppName(),
-		userID:  curSession.UserID(),
+// embed sets the embeddings of the values, if the service has an embedder.
+func (s *inMemoryService) embed(ctx context.Context, values []value) error {
+	if s.embedder == nil || len(values) == 0 {
+		return nil
 	}
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	v, ok := s.store[k]
-	if !ok {
-		v = map[sessionID][]value{}
-		s.store[k] = v
+	texts := make([]string, len(values))
+	for i, v := range values {
+		texts[i] = contentText(v.content)
+	}
+	embeddings, err := s.embedder.Embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+	if len(embeddings) != len(values) {
+		return fmt.Errorf("got %d embeddings for %d memories", len(embeddings), len(values))
+	}
+	for i := range values {
+		values[i].embedding = embeddings[i]
 	}
-
-	sid := sessionID(curSession.ID())
-	v[sid] = values
 	return nil
 }
 
+func contentText(c *genai.Content) string {
+	var texts []string
+	for _, part := range c.Parts {
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
 func (s *inMemoryService) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	if s.embedder != nil {
+		return s.searchSimilar(ctx, req)
+	}
 	queryWords := extractWords(req.Query)
 
 	k := key{
@@ -164,3 +282,49 @@ func extractWords(text string) map[string]struct{} {
 
 	return res
 }
+
+// searchSimilar returns the memories similar to the query, the most similar
+// first.
+func (s *inMemoryService) searchSimilar(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	embeddings, err := s.embedder.Embed(ctx, []string{req.Query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed the query: %w", err)
+	}
+	if len(embeddings) != 1 {
+		return nil, fmt.Errorf("failed to embed the query: got %d embeddings", len(embeddings))
+	}
+	query := embeddings[0]
+
+	type match struct {
+		entry      Entry
+		similarity float64
+	}
+	var matches []match
+	s.mu.RLock()
+	for _, events := range s.store[key{appName: req.AppName, userID: req.UserID}] {
+		for _, e := range events {
+			if similarity := embedding.Cosine(e.embedding, query); similarity >= s.minSimilarity {
+				matches = append(matches, match{
+					entry:      Entry{Content: e.content, Author: e.author, Timestamp: e.timestamp},
+					similarity: similarity,
+				})
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	slices.SortStableFunc(matches, func(a, b match) int {
+		if c := cmp.Compare(b.similarity, a.similarity); c != 0 {
+			return c
+		}
+		return a.entry.Timestamp.Compare(b.entry.Timestamp)
+	})
+	if s.maxResults > 0 && len(matches) > s.maxResults {
+		matches = matches[:s.maxResults]
+	}
+	res := &SearchResponse{}
+	for _, m := range matches {
+		res.Memories = append(res.Memories, m.entry)
+	}
+	return res, nil
+}
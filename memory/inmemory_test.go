@@ -15,6 +15,7 @@
 package memory_test
 
 import (
+	"context"
 	"iter"
 	"slices"
 	"testing"
@@ -24,6 +25,7 @@ import (
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/summarizer"
 	"google.golang.org/genai"
 )
 
@@ -156,6 +158,91 @@ func Test_inMemoryService_SearchMemory(t *testing.T) {
 	}
 }
 
+// stubSummarizer returns its summary and records the requests.
+type stubSummarizer struct {
+	summary  string
+	requests []*summarizer.Request
+}
+
+func (s *stubSummarizer) Summarize(ctx context.Context, req *summarizer.Request) (string, error) {
+	s.requests = append(s.requests, req)
+	return s.summary, nil
+}
+
+func Test_inMemoryService_Summarizer(t *testing.T) {
+	sum := &stubSummarizer{summary: "The user flies blue kites."}
+	service := memory.NewInMemoryService(memory.InMemoryConfig{Summarizer: sum})
+	ts := must(time.Parse(time.RFC3339, "2023-10-01T10:00:00Z"))
+	sess := makeSession(t, "app1", "user1", "sess1", []*session.Event{
+		{Author: "user1", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("I fly kites", genai.RoleUser)}},
+		{Author: "bot", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("Which color?", genai.RoleModel)}, Timestamp: ts},
+	})
+	if err := service.AddSession(t.Context(), sess); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sum.requests) != 1 || sum.requests[0].Purpose != summarizer.PurposeMemory || len(sum.requests[0].Contents) != 2 {
+		t.Fatalf("summarizer requests = %+v, want one memory request with the 2 contents", sum.requests)
+	}
+	got, err := service.Search(t.Context(), &memory.SearchRequest{AppName: "app1", UserID: "user1", Query: "blue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &memory.SearchResponse{Memories: []memory.Entry{{
+		Content:   genai.NewContentFromText("The user flies blue kites.", genai.RoleModel),
+		Author:    "memory",
+		Timestamp: ts,
+	}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Search() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// stubEmbedder embeds the known texts to fixed vectors.
+type stubEmbedder map[string][]float32
+
+func (e stubEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var embeddings [][]float32
+	for _, text := range texts {
+		embeddings = append(embeddings, e[text])
+	}
+	return embeddings, nil
+}
+
+func Test_inMemoryService_Embedder(t *testing.T) {
+	service := memory.NewInMemoryService(memory.InMemoryConfig{
+		Embedder: stubEmbedder{
+			"I fly kites":           {1, 0, 0},
+			"I like red kites":      {0.8, 0.6, 0},
+			"The weather is sunny.": {0, 0, 1},
+			"hobbies":               {1, 0.1, 0},
+		},
+		MaxResults: 2,
+	})
+	kites := genai.NewContentFromText("I fly kites", genai.RoleUser)
+	red := genai.NewContentFromText("I like red kites", genai.RoleUser)
+	sess := makeSession(t, "app1", "user1", "sess1", []*session.Event{
+		{Author: "user1", LLMResponse: model.LLMResponse{Content: red}},
+		{Author: "user1", LLMResponse: model.LLMResponse{Content: kites}},
+		{Author: "bot", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("The weather is sunny.", genai.RoleModel)}},
+	})
+	if err := service.AddSession(t.Context(), sess); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := service.Search(t.Context(), &memory.SearchRequest{AppName: "app1", UserID: "user1", Query: "hobbies"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &memory.SearchResponse{Memories: []memory.Entry{
+		{Content: kites, Author: "user1"},
+		{Content: red, Author: "user1"},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Search() mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func makeSession(t *testing.T, appName, userID, sessionID string, events []*session.Event) session.Session {
 	t.Helper()
 
@@ -212,7 +299,10 @@ func (s *testSession) State() session.State {
 }
 
 func (s *testSession) LastUpdateTime() time.Time {
-	panic("not implemented")
+	if len(s.events) == 0 {
+		return time.Time{}
+	}
+	return s.events[len(s.events)-1].Timestamp
 }
 
 func must[V any](v V, err error) V {
@@ -0,0 +1,216 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pgvector provides a [memory.VectorStore] storing the memories in
+// PostgreSQL with the pgvector extension:
+//
+//	store, err := pgvector.NewStore(postgres.Open(dsn), pgvector.Config{Dimensions: 768})
+//	...
+//	svc, err := memory.NewVectorService(memory.VectorServiceConfig{Store: store, Embedder: embedder})
+//
+// The queries use the cosine distance operator, so an HNSW or IVFFlat index
+// with vector_cosine_ops speeds them up on large tables.
+package pgvector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/memory"
+	"google.golang.org/genai"
+	"gorm.io/gorm"
+)
+
+// DefaultTable is the default Config.Table.
+const DefaultTable = "adk_memories"
+
+// Config is used to create a store.
+type Config struct {
+	// Table stores the memories. It is created if it doesn't exist.
+	// Optional: defaults to [DefaultTable].
+	Table string
+	// Dimensions is the size of the embeddings, e.g. 768.
+	Dimensions int
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// NewStore returns a vector store using the PostgreSQL database of the
+// dialector, e.g. postgres.Open(dsn). It creates the vector extension and
+// the table if they don't exist.
+func NewStore(dialector gorm.Dialector, cfg Config, opts ...gorm.Option) (memory.VectorStore, error) {
+	if cfg.Table == "" {
+		cfg.Table = DefaultTable
+	}
+	if !identifier.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("invalid table name %q", cfg.Table)
+	}
+	if cfg.Dimensions <= 0 {
+		return nil, fmt.Errorf("dimensions must be positive, got %d", cfg.Dimensions)
+	}
+	db, err := gorm.Open(dialector, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database: %w", err)
+	}
+	if name := db.Dialector.Name(); name != "postgres" {
+		return nil, fmt.Errorf("pgvector requires a postgres database, got %q", name)
+	}
+	s := &store{db: db, table: cfg.Table}
+	if err := s.migrate(cfg.Dimensions); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+type store struct {
+	db    *gorm.DB
+	table string
+}
+
+func (s *store) migrate(dimensions int) error {
+	for _, stmt := range []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			app_name TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			session_id TEXT NOT NULL,
+			content JSONB NOT NULL,
+			author TEXT NOT NULL,
+			timestamp TIMESTAMPTZ NOT NULL,
+			embedding vector(%d) NOT NULL
+		)`, s.table, dimensions),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %[1]s_app_user ON %[1]s (app_name, user_id)`, s.table),
+	} {
+		if err := s.db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to create the table %s: %w", s.table, err)
+		}
+	}
+	return nil
+}
+
+func (s *store) Upsert(ctx context.Context, records []*memory.VectorRecord) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, r := range records {
+			content, err := json.Marshal(r.Entry.Content)
+			if err != nil {
+				return fmt.Errorf("failed to encode the content of %q: %w", r.ID, err)
+			}
+			err = tx.Exec(fmt.Sprintf(`INSERT INTO %s (id, app_name, user_id, session_id, content, author, timestamp, embedding)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?::vector)
+				ON CONFLICT (id) DO UPDATE SET content = EXCLUDED.content, author = EXCLUDED.author,
+					timestamp = EXCLUDED.timestamp, embedding = EXCLUDED.embedding`, s.table),
+				r.ID, r.AppName, r.UserID, r.SessionID, string(content), r.Entry.Author, r.Entry.Timestamp, formatVector(r.Embedding)).Error
+			if err != nil {
+				return fmt.Errorf("failed to upsert %q: %w", r.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+type row struct {
+	ID         string
+	AppName    string
+	UserID     string
+	SessionID  string
+	Content    string
+	Author     string
+	Timestamp  time.Time
+	Embedding  string
+	Similarity float64
+}
+
+func (s *store) Query(ctx context.Context, q *memory.VectorQuery) ([]*memory.VectorMatch, error) {
+	vector := formatVector(q.Embedding)
+	limit := q.Limit
+	if limit <= 0 {
+		limit = memory.DefaultMaxResults
+	}
+	var rows []row
+	err := s.db.WithContext(ctx).Raw(fmt.Sprintf(`SELECT id, app_name, user_id, session_id, content::text AS content, author, timestamp,
+			embedding::text AS embedding, 1 - (embedding <=> ?::vector) AS similarity
+		FROM %s WHERE app_name = ? AND user_id = ?
+		ORDER BY embedding <=> ?::vector LIMIT ?`, s.table),
+		vector, q.AppName, q.UserID, vector, limit).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the memories: %w", err)
+	}
+	matches := make([]*memory.VectorMatch, len(rows))
+	for i, r := range rows {
+		var content genai.Content
+		if err := json.Unmarshal([]byte(r.Content), &content); err != nil {
+			return nil, fmt.Errorf("failed to decode the content of %q: %w", r.ID, err)
+		}
+		embedding, err := parseVector(r.Embedding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the embedding of %q: %w", r.ID, err)
+		}
+		matches[i] = &memory.VectorMatch{
+			Record: &memory.VectorRecord{
+				ID:        r.ID,
+				AppName:   r.AppName,
+				UserID:    r.UserID,
+				SessionID: r.SessionID,
+				Embedding: embedding,
+				Entry:     memory.Entry{Content: &content, Author: r.Author, Timestamp: r.Timestamp},
+			},
+			Similarity: r.Similarity,
+		}
+	}
+	return matches, nil
+}
+
+// formatVector returns the text representation of a vector, e.g. "[1,2.5]".
+func formatVector(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// parseVector parses the text representation of a vector.
+func parseVector(s string) ([]float32, error) {
+	s, ok := strings.CutPrefix(strings.TrimSpace(s), "[")
+	if !ok {
+		return nil, fmt.Errorf("malformed vector %q", s)
+	}
+	if s, ok = strings.CutSuffix(s, "]"); !ok {
+		return nil, fmt.Errorf("malformed vector %q", s)
+	}
+	if s == "" {
+		return nil, nil
+	}
+	fields := strings.Split(s, ",")
+	v := make([]float32, len(fields))
+	for i, f := range fields {
+		x, err := strconv.ParseFloat(strings.TrimSpace(f), 32)
+		if err != nil {
+			return nil, fmt.Errorf("malformed vector: %w", err)
+		}
+		v[i] = float32(x)
+	}
+	return v, nil
+}
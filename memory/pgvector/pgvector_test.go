@@ -0,0 +1,58 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgvector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gorm.io/driver/sqlite"
+)
+
+func TestVector(t *testing.T) {
+	v := []float32{1, -2.5, 0.125, 3e-7}
+	s := formatVector(v)
+	if s != "[1,-2.5,0.125,3e-07]" {
+		t.Errorf("formatVector() = %q", s)
+	}
+	got, err := parseVector(s)
+	if err != nil {
+		t.Fatalf("parseVector() error = %v", err)
+	}
+	if diff := cmp.Diff(v, got); diff != "" {
+		t.Errorf("parseVector() mismatch (-want +got):\n%s", diff)
+	}
+	for _, bad := range []string{"1,2", "[1,x]", "[1"} {
+		if _, err := parseVector(bad); err == nil {
+			t.Errorf("parseVector(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestNewStore_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		cfg  Config
+		want string
+	}{
+		{cfg: Config{Table: "memories; DROP TABLE x", Dimensions: 3}, want: "invalid table name"},
+		{cfg: Config{}, want: "dimensions"},
+		{cfg: Config{Dimensions: 3}, want: "requires a postgres database"},
+	} {
+		if _, err := NewStore(sqlite.Open("file::memory:"), tt.cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("NewStore(%+v) error = %v, want %q", tt.cfg, err, tt.want)
+		}
+	}
+}
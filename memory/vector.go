@@ -0,0 +1,217 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"google.golang.org/adk/embedding"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/summarizer"
+)
+
+// VectorStore stores the embeddings of the memories, see NewVectorService.
+// The implementations must be safe for concurrent use.
+type VectorStore interface {
+	// Upsert adds the records, replacing the records with the same IDs.
+	Upsert(ctx context.Context, records []*VectorRecord) error
+	// Query returns the records of the app and the user of the query, the
+	// most similar to its embedding first.
+	Query(ctx context.Context, q *VectorQuery) ([]*VectorMatch, error)
+}
+
+// VectorRecord is a memory stored in a VectorStore.
+type VectorRecord struct {
+	// ID identifies the record in the store. The records of a session have
+	// the IDs "<app>/<user>/<session>/<index>".
+	ID                         string
+	AppName, UserID, SessionID string
+	Embedding                  []float32
+	Entry                      Entry
+}
+
+// VectorQuery is a query of a VectorStore.
+type VectorQuery struct {
+	AppName, UserID string
+	Embedding       []float32
+	// Limit is the maximum number of records returned.
+	Limit int
+}
+
+// VectorMatch is a record returned by a query of a VectorStore.
+type VectorMatch struct {
+	Record *VectorRecord
+	// Similarity is the cosine similarity between the embeddings of the
+	// record and of the query.
+	Similarity float64
+}
+
+// DefaultMaxResults is the default VectorServiceConfig.MaxResults.
+const DefaultMaxResults = 10
+
+// VectorServiceConfig is the configuration of the service returned by
+// NewVectorService.
+type VectorServiceConfig struct {
+	// Store stores the memories and their embeddings.
+	Store VectorStore
+	// Embedder embeds the memories and the queries.
+	Embedder embedding.Embedder
+	// Summarizer, if set, summarizes the sessions added to the memory, with
+	// the purpose summarizer.PurposeMemory. The summary is stored as the
+	// only memory of the session instead of its events.
+	// Optional.
+	Summarizer summarizer.Summarizer
+	// MinSimilarity is the minimum cosine similarity between a memory and
+	// the query for the memory to be returned.
+	// Optional: defaults to DefaultMinSimilarity.
+	MinSimilarity float64
+	// MaxResults is the maximum number of memories returned by a search.
+	// Optional: defaults to DefaultMaxResults.
+	MaxResults int
+}
+
+// NewVectorService returns a memory service storing the embeddings of the
+// memories in a vector store, e.g. pgvector or Vertex AI Vector Search. The
+// search returns the memories of the user similar to the query, the most
+// similar first.
+//
+// A session can be added multiple times: its memories replace the previous
+// ones with the same index.
+func NewVectorService(cfg VectorServiceConfig) (Service, error) {
+	if cfg.Store == nil {
+		return nil, fmt.Errorf("vector store is required")
+	}
+	if cfg.Embedder == nil {
+		return nil, fmt.Errorf("embedder is required")
+	}
+	if cfg.MinSimilarity == 0 {
+		cfg.MinSimilarity = DefaultMinSimilarity
+	}
+	if cfg.MaxResults <= 0 {
+		cfg.MaxResults = DefaultMaxResults
+	}
+	return &vectorService{cfg: cfg}, nil
+}
+
+type vectorService struct {
+	cfg VectorServiceConfig
+}
+
+func (s *vectorService) AddSession(ctx context.Context, curSession session.Session) error {
+	values, err := sessionValues(ctx, s.cfg.Summarizer, curSession)
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	texts := make([]string, len(values))
+	for i, v := range values {
+		texts[i] = contentText(v.content)
+	}
+	embeddings, err := s.cfg.Embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed the memories of session %q: %w", curSession.ID(), err)
+	}
+	if len(embeddings) != len(values) {
+		return fmt.Errorf("failed to embed the memories of session %q: got %d embeddings for %d memories", curSession.ID(), len(embeddings), len(values))
+	}
+
+	records := make([]*VectorRecord, len(values))
+	for i, v := range values {
+		records[i] = &VectorRecord{
+			ID:        fmt.Sprintf("%s/%s/%s/%d", curSession.AppName(), curSession.UserID(), curSession.ID(), i),
+			AppName:   curSession.AppName(),
+			UserID:    curSession.UserID(),
+			SessionID: curSession.ID(),
+			Embedding: embeddings[i],
+			Entry:     Entry{Content: v.content, Author: v.author, Timestamp: v.timestamp},
+		}
+	}
+	if err := s.cfg.Store.Upsert(ctx, records); err != nil {
+		return fmt.Errorf("failed to store the memories of session %q: %w", curSession.ID(), err)
+	}
+	return nil
+}
+
+func (s *vectorService) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	embeddings, err := s.cfg.Embedder.Embed(ctx, []string{req.Query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed the query: %w", err)
+	}
+	if len(embeddings) != 1 {
+		return nil, fmt.Errorf("failed to embed the query: got %d embeddings", len(embeddings))
+	}
+	matches, err := s.cfg.Store.Query(ctx, &VectorQuery{
+		AppName:   req.AppName,
+		UserID:    req.UserID,
+		Embedding: embeddings[0],
+		Limit:     s.cfg.MaxResults,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the memories: %w", err)
+	}
+	res := &SearchResponse{}
+	for _, m := range matches {
+		if m.Similarity >= s.cfg.MinSimilarity {
+			res.Memories = append(res.Memories, m.Record.Entry)
+		}
+	}
+	return res, nil
+}
+
+// NewInMemoryVectorStore returns a VectorStore keeping the records in
+// memory, e.g. for the tests. The queries scan the records of the user.
+func NewInMemoryVectorStore() VectorStore {
+	return &inMemoryVectorStore{records: make(map[key]map[string]*VectorRecord)}
+}
+
+type inMemoryVectorStore struct {
+	mu      sync.RWMutex
+	records map[key]map[string]*VectorRecord
+}
+
+func (s *inMemoryVectorStore) Upsert(ctx context.Context, records []*VectorRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range records {
+		k := key{appName: r.AppName, userID: r.UserID}
+		if s.records[k] == nil {
+			s.records[k] = make(map[string]*VectorRecord)
+		}
+		s.records[k][r.ID] = r
+	}
+	return nil
+}
+
+func (s *inMemoryVectorStore) Query(ctx context.Context, q *VectorQuery) ([]*VectorMatch, error) {
+	s.mu.RLock()
+	var matches []*VectorMatch
+	for _, r := range s.records[key{appName: q.AppName, userID: q.UserID}] {
+		matches = append(matches, &VectorMatch{Record: r, Similarity: embedding.Cosine(r.Embedding, q.Embedding)})
+	}
+	s.mu.RUnlock()
+	slices.SortFunc(matches, func(a, b *VectorMatch) int {
+		return cmp.Or(cmp.Compare(b.Similarity, a.Similarity), cmp.Compare(a.Record.ID, b.Record.ID))
+	})
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+	}
+	return matches, nil
+}
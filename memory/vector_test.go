@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+func TestVectorService(t *testing.T) {
+	service, err := memory.NewVectorService(memory.VectorServiceConfig{
+		Store: memory.NewInMemoryVectorStore(),
+		Embedder: stubEmbedder{
+			"I fly kites":           {1, 0, 0},
+			"I like red kites":      {0.8, 0.6, 0},
+			"The weather is sunny.": {0, 0, 1},
+			"hobbies":               {1, 0.1, 0},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kites := genai.NewContentFromText("I fly kites", genai.RoleUser)
+	red := genai.NewContentFromText("I like red kites", genai.RoleUser)
+	for _, sess := range []session.Session{
+		makeSession(t, "app1", "user1", "sess1", []*session.Event{
+			{Author: "user1", LLMResponse: model.LLMResponse{Content: red}},
+			{Author: "bot", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("The weather is sunny.", genai.RoleModel)}},
+		}),
+		makeSession(t, "app1", "user1", "sess2", []*session.Event{
+			{Author: "user1", LLMResponse: model.LLMResponse{Content: kites}},
+		}),
+		makeSession(t, "app1", "user2", "sess3", []*session.Event{
+			{Author: "user2", LLMResponse: model.LLMResponse{Content: kites}},
+		}),
+	} {
+		if err := service.AddSession(t.Context(), sess); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := service.Search(t.Context(), &memory.SearchRequest{AppName: "app1", UserID: "user1", Query: "hobbies"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &memory.SearchResponse{Memories: []memory.Entry{
+		{Content: kites, Author: "user1"},
+		{Content: red, Author: "user1"},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Search() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := memory.NewVectorService(memory.VectorServiceConfig{Embedder: stubEmbedder{}}); err == nil {
+		t.Error("NewVectorService() without store succeeded, want error")
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vertexvectorsearch provides a [memory.VectorStore] storing the
+// memories in a Vertex AI Vector Search index.
+//
+// The index must support the streaming updates and be deployed to an index
+// endpoint. The memories are stored in the embedding metadata of the
+// datapoints, and restricted by app and user with the "app_name" and
+// "user_id" namespaces.
+package vertexvectorsearch
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/adk/embedding"
+	"google.golang.org/adk/memory"
+	"google.golang.org/genai"
+)
+
+// Config is used to create a store.
+type Config struct {
+	// Index is the resource name of the index, e.g.
+	// "projects/my-project/locations/us-central1/indexes/123".
+	Index string
+	// IndexEndpoint is the resource name of the endpoint the index is
+	// deployed to, e.g.
+	// "projects/my-project/locations/us-central1/indexEndpoints/456".
+	IndexEndpoint string
+	// DeployedIndexID is the ID of the index deployed to the endpoint.
+	DeployedIndexID string
+	// PublicEndpointDomain is the domain of the public index endpoint, e.g.
+	// "1234.us-central1-5678.vdb.vertexai.goog", which serves the queries.
+	// Optional: if empty, the queries are sent to APIEndpoint.
+	PublicEndpointDomain string
+	// APIEndpoint is the URL of the Vertex AI API.
+	// Optional: defaults to the regional endpoint of the index, e.g.
+	// "https://us-central1-aiplatform.googleapis.com".
+	APIEndpoint string
+	// HTTPClient sends the authenticated requests.
+	// Optional: defaults to a client using the application default
+	// credentials.
+	HTTPClient *http.Client
+}
+
+// NewStore returns a vector store using the Vertex AI Vector Search index.
+func NewStore(ctx context.Context, cfg Config) (memory.VectorStore, error) {
+	if cfg.Index == "" || cfg.IndexEndpoint == "" || cfg.DeployedIndexID == "" {
+		return nil, fmt.Errorf("index, index endpoint and deployed index ID are required")
+	}
+	if cfg.APIEndpoint == "" {
+		location, ok := locationOf(cfg.Index)
+		if !ok {
+			return nil, fmt.Errorf("invalid index resource name %q", cfg.Index)
+		}
+		cfg.APIEndpoint = fmt.Sprintf("https://%s-aiplatform.googleapis.com", location)
+	}
+	queryEndpoint := cfg.APIEndpoint
+	if cfg.PublicEndpointDomain != "" {
+		queryEndpoint = "https://" + cfg.PublicEndpointDomain
+	}
+	if cfg.HTTPClient == nil {
+		client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/cloud-platform")
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the default credentials: %w", err)
+		}
+		cfg.HTTPClient = client
+	}
+	return &store{
+		cfg:       cfg,
+		upsertURL: fmt.Sprintf("%s/v1/%s:upsertDatapoints", strings.TrimSuffix(cfg.APIEndpoint, "/"), cfg.Index),
+		queryURL:  fmt.Sprintf("%s/v1/%s:findNeighbors", strings.TrimSuffix(queryEndpoint, "/"), cfg.IndexEndpoint),
+	}, nil
+}
+
+// locationOf returns the location of a resource name
+// "projects/<project>/locations/<location>/...".
+func locationOf(name string) (string, bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) < 4 || parts[0] != "projects" || parts[2] != "locations" || parts[3] == "" {
+		return "", false
+	}
+	return parts[3], true
+}
+
+type store struct {
+	cfg                 Config
+	upsertURL, queryURL string
+}
+
+type datapoint struct {
+	DatapointID       string      `json:"datapointId"`
+	FeatureVector     []float32   `json:"featureVector"`
+	Restricts         []restrict  `json:"restricts,omitempty"`
+	EmbeddingMetadata *recordMeta `json:"embeddingMetadata,omitempty"`
+}
+
+type restrict struct {
+	Namespace string   `json:"namespace"`
+	AllowList []string `json:"allowList"`
+}
+
+// recordMeta is the embedding metadata of a datapoint.
+type recordMeta struct {
+	AppName   string         `json:"app_name"`
+	UserID    string         `json:"user_id"`
+	SessionID string         `json:"session_id"`
+	Author    string         `json:"author"`
+	Timestamp time.Time      `json:"timestamp"`
+	Content   *genai.Content `json:"content"`
+}
+
+func restricts(appName, userID string) []restrict {
+	return []restrict{
+		{Namespace: "app_name", AllowList: []string{appName}},
+		{Namespace: "user_id", AllowList: []string{userID}},
+	}
+}
+
+func (s *store) Upsert(ctx context.Context, records []*memory.VectorRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	points := make([]datapoint, len(records))
+	for i, r := range records {
+		points[i] = datapoint{
+			DatapointID:   r.ID,
+			FeatureVector: r.Embedding,
+			Restricts:     restricts(r.AppName, r.UserID),
+			EmbeddingMetadata: &recordMeta{
+				AppName:   r.AppName,
+				UserID:    r.UserID,
+				SessionID: r.SessionID,
+				Author:    r.Entry.Author,
+				Timestamp: r.Entry.Timestamp,
+				Content:   r.Entry.Content,
+			},
+		}
+	}
+	if err := s.post(ctx, s.upsertURL, map[string]any{"datapoints": points}, nil); err != nil {
+		return fmt.Errorf("failed to upsert the datapoints: %w", err)
+	}
+	return nil
+}
+
+func (s *store) Query(ctx context.Context, q *memory.VectorQuery) ([]*memory.VectorMatch, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = memory.DefaultMaxResults
+	}
+	req := map[string]any{
+		"deployedIndexId": s.cfg.DeployedIndexID,
+		"queries": []map[string]any{{
+			"datapoint":     datapoint{DatapointID: "query", FeatureVector: q.Embedding, Restricts: restricts(q.AppName, q.UserID)},
+			"neighborCount": limit,
+		}},
+		"returnFullDatapoint": true,
+	}
+	var resp struct {
+		NearestNeighbors []struct {
+			Neighbors []struct {
+				Datapoint datapoint `json:"datapoint"`
+			} `json:"neighbors"`
+		} `json:"nearestNeighbors"`
+	}
+	if err := s.post(ctx, s.queryURL, req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to find the neighbors: %w", err)
+	}
+	var matches []*memory.VectorMatch
+	for _, nn := range resp.NearestNeighbors {
+		for _, n := range nn.Neighbors {
+			meta := n.Datapoint.EmbeddingMetadata
+			// The restricts already filter the datapoints, the metadata is
+			// checked in case the index has other datapoints.
+			if meta == nil || meta.AppName != q.AppName || meta.UserID != q.UserID {
+				continue
+			}
+			matches = append(matches, &memory.VectorMatch{
+				Record: &memory.VectorRecord{
+					ID:        n.Datapoint.DatapointID,
+					AppName:   meta.AppName,
+					UserID:    meta.UserID,
+					SessionID: meta.SessionID,
+					Embedding: n.Datapoint.FeatureVector,
+					Entry:     memory.Entry{Content: meta.Content, Author: meta.Author, Timestamp: meta.Timestamp},
+				},
+				Similarity: embedding.Cosine(n.Datapoint.FeatureVector, q.Embedding),
+			})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b *memory.VectorMatch) int {
+		return cmp.Compare(b.Similarity, a.Similarity)
+	})
+	return matches, nil
+}
+
+func (s *store) post(ctx context.Context, url string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(b))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
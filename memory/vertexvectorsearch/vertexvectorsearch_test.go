@@ -0,0 +1,145 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vertexvectorsearch_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/memory/vertexvectorsearch"
+	"google.golang.org/genai"
+)
+
+const (
+	index         = "projects/p/locations/us-central1/indexes/1"
+	indexEndpoint = "projects/p/locations/us-central1/indexEndpoints/2"
+)
+
+// fakeVectorSearch upserts the datapoints and returns all the datapoints
+// matching the restricts of the queries.
+type fakeVectorSearch struct {
+	mu     sync.Mutex
+	points map[string]map[string]any
+}
+
+func (f *fakeVectorSearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch r.URL.Path {
+	case "/v1/" + index + ":upsertDatapoints":
+		for _, p := range body["datapoints"].([]any) {
+			p := p.(map[string]any)
+			f.points[p["datapointId"].(string)] = p
+		}
+		w.Write([]byte("{}"))
+	case "/v1/" + indexEndpoint + ":findNeighbors":
+		if body["deployedIndexId"] != "deployed" || body["returnFullDatapoint"] != true {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		query := body["queries"].([]any)[0].(map[string]any)["datapoint"].(map[string]any)
+		var neighbors []map[string]any
+		for _, p := range f.points {
+			if allows(p, query) {
+				neighbors = append(neighbors, map[string]any{"datapoint": p, "distance": 0})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"nearestNeighbors": []any{map[string]any{"neighbors": neighbors}}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// allows reports whether the restricts of the datapoint match the ones of
+// the query.
+func allows(point, query map[string]any) bool {
+	for _, qr := range query["restricts"].([]any) {
+		qr := qr.(map[string]any)
+		ok := false
+		for _, pr := range point["restricts"].([]any) {
+			pr := pr.(map[string]any)
+			if pr["namespace"] == qr["namespace"] && slices.Equal(pr["allowList"].([]any), qr["allowList"].([]any)) {
+				ok = true
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStore(t *testing.T) {
+	srv := httptest.NewServer(&fakeVectorSearch{points: make(map[string]map[string]any)})
+	defer srv.Close()
+	store, err := vertexvectorsearch.NewStore(t.Context(), vertexvectorsearch.Config{
+		Index:           index,
+		IndexEndpoint:   indexEndpoint,
+		DeployedIndexID: "deployed",
+		APIEndpoint:     srv.URL,
+		HTTPClient:      srv.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	record := func(id, user string, text string, embedding ...float32) *memory.VectorRecord {
+		return &memory.VectorRecord{
+			ID: id, AppName: "app", UserID: user, SessionID: "s", Embedding: embedding,
+			Entry: memory.Entry{Content: genai.NewContentFromText(text, genai.RoleUser), Author: user, Timestamp: ts},
+		}
+	}
+	kites := record("app/u1/s/0", "u1", "I fly kites", 1, 0)
+	weather := record("app/u1/s/1", "u1", "It is sunny", 0, 1)
+	other := record("app/u2/s/0", "u2", "I fly kites too", 1, 0)
+	if err := store.Upsert(t.Context(), []*memory.VectorRecord{weather, kites, other}); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+
+	got, err := store.Query(t.Context(), &memory.VectorQuery{AppName: "app", UserID: "u1", Embedding: []float32{1, 0.1}, Limit: 5})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	var ids []string
+	for _, m := range got {
+		ids = append(ids, m.Record.ID)
+	}
+	if diff := cmp.Diff([]string{"app/u1/s/0", "app/u1/s/1"}, ids); diff != "" {
+		t.Errorf("Query() IDs mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(kites, got[0].Record); diff != "" {
+		t.Errorf("Query() record mismatch (-want +got):\n%s", diff)
+	}
+	if got[0].Similarity <= got[1].Similarity {
+		t.Errorf("Query() similarities = %v, %v, want the most similar first", got[0].Similarity, got[1].Similarity)
+	}
+
+	if _, err := vertexvectorsearch.NewStore(t.Context(), vertexvectorsearch.Config{Index: "bad", IndexEndpoint: "e", DeployedIndexID: "d"}); err == nil {
+		t.Error("NewStore() with an invalid index succeeded, want error")
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bandit provides a [model.LLM] which shifts the traffic between
+// model or prompt variants based on online rewards, e.g. user feedback or
+// the scores of an eval judge.
+//
+// The variants are selected with an epsilon-greedy strategy: every variant
+// is tried once, then the variant with the best mean reward receives the
+// traffic, except for a minimum share spread across all the variants to
+// keep exploring. A session keeps the variant it was assigned, so that a
+// conversation doesn't switch models midway and its reward can be
+// attributed to the variant.
+package bandit
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Default values of the [Config] fields.
+const (
+	DefaultMinExploration = 0.1
+	DefaultPinTTL         = 24 * time.Hour
+)
+
+// VariantMetadataKey is the key of the name of the selected variant in the
+// CustomMetadata of the responses.
+const VariantMetadataKey = "bandit_variant"
+
+// Variant is a model, optionally with additional instructions, competing
+// for the traffic.
+type Variant struct {
+	// Name identifies the variant in the rewards and stats.
+	Name string
+	// Model serves the requests of the variant.
+	Model model.LLM
+	// Instruction is appended to the system instruction of the requests.
+	// Optional.
+	Instruction string
+}
+
+// Config is used to create a [Model].
+type Config struct {
+	// Variants compete for the traffic.
+	Variants []Variant
+	// MinExploration is the share of the requests sent to a random variant,
+	// between 0 and 1.
+	// Optional: defaults to [DefaultMinExploration]. Negative disables the
+	// exploration once all the variants were tried.
+	MinExploration float64
+	// PinTTL is how long a session keeps its variant after its last request.
+	// Optional: defaults to [DefaultPinTTL].
+	PinTTL time.Duration
+	// Rand is the source of the random selections.
+	// Optional: defaults to the global source of math/rand/v2.
+	Rand *rand.Rand
+}
+
+// VariantStats describes the state of a variant.
+type VariantStats struct {
+	Name string
+	// Selections is the number of sessions, or sessionless requests, the
+	// variant was selected for.
+	Selections int
+	// Rewards is the number of rewards received.
+	Rewards int
+	// MeanReward is the mean of the rewards received, zero if none.
+	MeanReward float64
+}
+
+// Model is a [model.LLM] selecting a variant for each session. It is safe for
+// concurrent use.
+type Model struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	variants []*variantState
+	pins     map[string]*pin
+}
+
+type variantState struct {
+	Variant
+
+	selections int
+	rewards    int
+	rewardSum  float64
+}
+
+type pin struct {
+	variant *variantState
+	// fixed pins are set with Pin and never expire.
+	fixed    bool
+	lastUsed time.Time
+}
+
+// New returns a model selecting between the variants.
+func New(cfg Config) (*Model, error) {
+	if len(cfg.Variants) == 0 {
+		return nil, fmt.Errorf("at least one variant is required")
+	}
+	if cfg.MinExploration > 1 {
+		return nil, fmt.Errorf("min exploration must be at most 1, got %v", cfg.MinExploration)
+	}
+	if cfg.MinExploration == 0 {
+		cfg.MinExploration = DefaultMinExploration
+	}
+	if cfg.PinTTL <= 0 {
+		cfg.PinTTL = DefaultPinTTL
+	}
+	m := &Model{cfg: cfg, now: time.Now, pins: make(map[string]*pin)}
+	names := make(map[string]bool)
+	for i, v := range cfg.Variants {
+		if v.Model == nil {
+			return nil, fmt.Errorf("variant %d has no model", i)
+		}
+		if v.Name == "" {
+			return nil, fmt.Errorf("variant %d has no name", i)
+		}
+		if names[v.Name] {
+			return nil, fmt.Errorf("duplicate variant name %q", v.Name)
+		}
+		names[v.Name] = true
+		m.variants = append(m.variants, &variantState{Variant: v})
+	}
+	return m, nil
+}
+
+// Name returns the model name of the first variant.
+func (m *Model) Name() string {
+	return m.variants[0].Model.Name()
+}
+
+// GenerateContent sends the request to the variant of the session. The
+// name of the variant is set in the CustomMetadata of the responses, see
+// [VariantMetadataKey].
+func (m *Model) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	v := m.selectVariant(sessionID(ctx))
+	if v.Instruction != "" {
+		req = withInstruction(req, v.Instruction)
+	}
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for resp, err := range v.Model.GenerateContent(ctx, req, stream) {
+			if err != nil {
+				yield(nil, fmt.Errorf("variant %q: %w", v.Name, err))
+				return
+			}
+			if resp != nil {
+				if resp.CustomMetadata == nil {
+					resp.CustomMetadata = make(map[string]any)
+				}
+				resp.CustomMetadata[VariantMetadataKey] = v.Name
+			}
+			if !yield(resp, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Reward records the reward of the session, e.g. 1 for a positive user
+// feedback and 0 for a negative one, for the variant the session was
+// assigned. It returns an error if the session has no variant, e.g. because
+// its pin expired.
+func (m *Model) Reward(sessionID string, reward float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.pins[sessionID]
+	if !ok {
+		return fmt.Errorf("session %q has no variant", sessionID)
+	}
+	p.variant.reward(reward)
+	return nil
+}
+
+// RewardVariant records a reward for the variant, e.g. the score of an eval
+// judge for the responses of the variant.
+func (m *Model) RewardVariant(name string, reward float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v := m.variant(name)
+	if v == nil {
+		return fmt.Errorf("unknown variant %q", name)
+	}
+	v.reward(reward)
+	return nil
+}
+
+// Pin assigns the variant to the session, e.g. for the testers or for the
+// sessions which must not change of model. The pin doesn't expire.
+func (m *Model) Pin(sessionID, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v := m.variant(name)
+	if v == nil {
+		return fmt.Errorf("unknown variant %q", name)
+	}
+	m.pins[sessionID] = &pin{variant: v, fixed: true}
+	return nil
+}
+
+// Stats returns the state of the variants, in the order of the config.
+func (m *Model) Stats() []VariantStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make([]VariantStats, 0, len(m.variants))
+	for _, v := range m.variants {
+		stats = append(stats, VariantStats{
+			Name:       v.Name,
+			Selections: v.selections,
+			Rewards:    v.rewards,
+			MeanReward: v.mean(),
+		})
+	}
+	return stats
+}
+
+// selectVariant returns the variant pinned to the session, or selects one.
+// An empty session ID selects a variant for the request only.
+func (m *Model) selectVariant(sessionID string) *variantState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.prunePins(now)
+	if p, ok := m.pins[sessionID]; ok && sessionID != "" {
+		p.lastUsed = now
+		return p.variant
+	}
+	v := m.pick()
+	v.selections++
+	if sessionID != "" {
+		m.pins[sessionID] = &pin{variant: v, lastUsed: now}
+	}
+	return v
+}
+
+// pick returns an untried variant, a random variant with the probability
+// MinExploration, or else the variant with the best mean reward.
+func (m *Model) pick() *variantState {
+	for _, v := range m.variants {
+		if v.selections == 0 {
+			return v
+		}
+	}
+	if m.float64() < m.cfg.MinExploration {
+		return m.variants[m.intN(len(m.variants))]
+	}
+	best := m.variants[0]
+	for _, v := range m.variants[1:] {
+		if v.mean() > best.mean() {
+			best = v
+		}
+	}
+	return best
+}
+
+func (m *Model) prunePins(now time.Time) {
+	for id, p := range m.pins {
+		if !p.fixed && now.Sub(p.lastUsed) > m.cfg.PinTTL {
+			delete(m.pins, id)
+		}
+	}
+}
+
+func (m *Model) variant(name string) *variantState {
+	for _, v := range m.variants {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+func (m *Model) float64() float64 {
+	if m.cfg.Rand != nil {
+		return m.cfg.Rand.Float64()
+	}
+	return rand.Float64()
+}
+
+func (m *Model) intN(n int) int {
+	if m.cfg.Rand != nil {
+		return m.cfg.Rand.IntN(n)
+	}
+	return rand.IntN(n)
+}
+
+func (v *variantState) reward(r float64) {
+	v.rewards++
+	v.rewardSum += r
+}
+
+func (v *variantState) mean() float64 {
+	if v.rewards == 0 {
+		return 0
+	}
+	return v.rewardSum / float64(v.rewards)
+}
+
+// sessionID returns the ID of the session of the agent calling the model,
+// or "" if the model isn't called by an agent.
+func sessionID(ctx context.Context) string {
+	if ictx, ok := ctx.(agent.InvocationContext); ok && ictx.Session() != nil {
+		return ictx.Session().ID()
+	}
+	return ""
+}
+
+// withInstruction returns a copy of the request with the instruction
+// appended to the system instruction.
+func withInstruction(req *model.LLMRequest, instruction string) *model.LLMRequest {
+	r := *req
+	var cfg genai.GenerateContentConfig
+	if req.Config != nil {
+		cfg = *req.Config
+	}
+	si := &genai.Content{Role: genai.RoleUser}
+	if cfg.SystemInstruction != nil {
+		si.Role = cfg.SystemInstruction.Role
+		si.Parts = append(si.Parts, cfg.SystemInstruction.Parts...)
+	}
+	si.Parts = append(si.Parts, genai.NewPartFromText(instruction))
+	cfg.SystemInstruction = si
+	r.Config = &cfg
+	return &r
+}
+
+var _ model.LLM = (*Model)(nil)
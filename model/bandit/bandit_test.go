@@ -0,0 +1,124 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bandit
+
+import (
+	"context"
+	"iter"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+type fakeModel struct {
+	name     string
+	requests []*model.LLMRequest
+}
+
+func (m *fakeModel) Name() string { return "gemini-test" }
+
+func (m *fakeModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.requests = append(m.requests, req)
+		yield(&model.LLMResponse{Content: genai.NewContentFromText(m.name, genai.RoleModel)}, nil)
+	}
+}
+
+func TestModel_ShiftsTraffic(t *testing.T) {
+	m, err := New(Config{
+		Variants:       []Variant{{Name: "a", Model: &fakeModel{name: "a"}}, {Name: "b", Model: &fakeModel{name: "b"}}},
+		MinExploration: -1,
+		Rand:           rand.New(rand.NewPCG(1, 2)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every variant is tried first.
+	if got := m.selectVariant("s1").Name; got != "a" {
+		t.Errorf("variant of s1 = %q, want a", got)
+	}
+	if got := m.selectVariant("s2").Name; got != "b" {
+		t.Errorf("variant of s2 = %q, want b", got)
+	}
+	if err := m.Reward("s1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reward("s2", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reward("unknown", 1); err == nil {
+		t.Error("Reward() of a session without variant succeeded, want error")
+	}
+	for _, s := range []string{"s3", "s4", "s5"} {
+		if got := m.selectVariant(s).Name; got != "b" {
+			t.Errorf("variant of %s = %q, want b", s, got)
+		}
+	}
+	// The sessions keep their variant.
+	if got := m.selectVariant("s1").Name; got != "a" {
+		t.Errorf("variant of s1 = %q, want a", got)
+	}
+	if err := m.Pin("s6", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.selectVariant("s6").Name; got != "a" {
+		t.Errorf("variant of the pinned s6 = %q, want a", got)
+	}
+	if err := m.RewardVariant("b", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []VariantStats{
+		{Name: "a", Selections: 1, Rewards: 1, MeanReward: 0},
+		{Name: "b", Selections: 4, Rewards: 2, MeanReward: 0.5},
+	}
+	if diff := cmp.Diff(want, m.Stats()); diff != "" {
+		t.Errorf("Stats() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_GenerateContent(t *testing.T) {
+	a := &fakeModel{name: "a"}
+	m, err := New(Config{Variants: []Variant{{Name: "concise", Model: a, Instruction: "Be concise."}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &model.LLMRequest{Config: &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText("You are helpful.", genai.RoleUser),
+	}}
+	for resp, err := range m.GenerateContent(t.Context(), req, false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.CustomMetadata[VariantMetadataKey]; got != "concise" {
+			t.Errorf("variant metadata = %v, want concise", got)
+		}
+	}
+
+	if len(a.requests) != 1 {
+		t.Fatalf("model was called %d times, want 1", len(a.requests))
+	}
+	want := &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{{Text: "You are helpful."}, {Text: "Be concise."}}}
+	if diff := cmp.Diff(want, a.requests[0].Config.SystemInstruction); diff != "" {
+		t.Errorf("system instruction mismatch (-want +got):\n%s", diff)
+	}
+	if n := len(req.Config.SystemInstruction.Parts); n != 1 {
+		t.Errorf("the request of the agent was modified: %d instruction parts, want 1", n)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// ResponseCache stores the encoded responses of the models, see WithCache.
+// The implementations must be safe for concurrent use.
+type ResponseCache interface {
+	// Get returns the value stored under the key, or false if there is none
+	// or it expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores the value under the key.
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// WithCache returns a model returning the cached responses of m for the
+// requests identical to a previous one, see RequestKey. It speeds up the
+// evals and the repeated deterministic workflows; the responses of the
+// models sampling with a non-zero temperature are cached as well, so the
+// cached model always returns the first responses it got.
+//
+// Only the complete and successful responses are cached: the calls failing,
+// returning an error code or stopped early by the caller are not. The
+// errors of the cache are ignored, the requests are then sent to m.
+func WithCache(m LLM, cache ResponseCache) LLM {
+	return &cachedModel{llm: m, cache: cache}
+}
+
+type cachedModel struct {
+	llm   LLM
+	cache ResponseCache
+}
+
+// Name returns the name of the cached model.
+func (m *cachedModel) Name() string {
+	return m.llm.Name()
+}
+
+func (m *cachedModel) GenerateContent(ctx context.Context, req *LLMRequest, stream bool) iter.Seq2[*LLMResponse, error] {
+	return func(yield func(*LLMResponse, error) bool) {
+		key, err := RequestKey(m.llm.Name(), req, stream)
+		if err != nil {
+			for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+				if !yield(resp, err) {
+					return
+				}
+			}
+			return
+		}
+		if value, ok, err := m.cache.Get(ctx, key); err == nil && ok {
+			var resps []*LLMResponse
+			if err := json.Unmarshal(value, &resps); err == nil {
+				for _, resp := range resps {
+					if !yield(resp, nil) {
+						return
+					}
+				}
+				return
+			}
+		}
+
+		var (
+			resps     []json.RawMessage
+			cacheable = true
+		)
+		for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+			if err != nil || resp == nil || resp.ErrorCode != "" {
+				cacheable = false
+			} else if cacheable {
+				// The response is encoded before the caller may modify it.
+				b, err := json.Marshal(resp)
+				cacheable = err == nil
+				resps = append(resps, b)
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+		if !cacheable || len(resps) == 0 {
+			return
+		}
+		if value, err := json.Marshal(resps); err == nil {
+			_ = m.cache.Set(ctx, key, value)
+		}
+	}
+}
+
+// RequestKey returns the cache key of a request to the model with the given
+// name: the hex SHA-256 of the canonical JSON encoding of the model, the
+// contents, the config of the request and the streaming mode. The tools are
+// part of the key through their declarations in the config.
+func RequestKey(modelName string, req *LLMRequest, stream bool) (string, error) {
+	if req == nil {
+		req = &LLMRequest{}
+	}
+	if req.Model != "" {
+		modelName = req.Model
+	}
+	b, err := json.Marshal(struct {
+		Model    string                       `json:"model"`
+		Stream   bool                         `json:"stream"`
+		Contents []*genai.Content             `json:"contents"`
+		Config   *genai.GenerateContentConfig `json:"config"`
+	}{modelName, stream, req.Contents, req.Config})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the request: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// InMemoryCacheConfig is used to create an in-memory [ResponseCache].
+type InMemoryCacheConfig struct {
+	// TTL is how long the responses are cached.
+	// Optional: if zero, they don't expire.
+	TTL time.Duration
+	// MaxEntries is the maximum number of cached requests. The least
+	// recently used one is evicted beyond.
+	// Optional: if zero, the number of entries is unlimited.
+	MaxEntries int
+}
+
+// NewInMemoryCache returns a [ResponseCache] storing the responses in
+// memory, e.g. for the evals.
+func NewInMemoryCache(cfg InMemoryCacheConfig) ResponseCache {
+	return &inMemoryCache{cfg: cfg, now: time.Now, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+type inMemoryCache struct {
+	cfg InMemoryCacheConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // of *cacheEntry, the most recently used first
+}
+
+type cacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func (c *inMemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*cacheEntry)
+	if !e.expires.IsZero() && !c.now().Before(e.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	c.lru.MoveToFront(el)
+	return e.value, true, nil
+}
+
+func (c *inMemoryCache) Set(_ context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := &cacheEntry{key: key, value: value}
+	if c.cfg.TTL > 0 {
+		e.expires = c.now().Add(c.cfg.TTL)
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return nil
+	}
+	c.entries[key] = c.lru.PushFront(e)
+	for c.cfg.MaxEntries > 0 && c.lru.Len() > c.cfg.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+	return nil
+}
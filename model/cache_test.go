@@ -0,0 +1,116 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"context"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// countingModel returns its responses for each request and counts the calls.
+type countingModel struct {
+	responses []*model.LLMResponse
+	calls     int
+}
+
+func (m *countingModel) Name() string { return "counting" }
+
+func (m *countingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	m.calls++
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for _, resp := range m.responses {
+			if !yield(resp, nil) {
+				return
+			}
+		}
+	}
+}
+
+func collect(t *testing.T, llm model.LLM, req *model.LLMRequest, stream bool) []*model.LLMResponse {
+	t.Helper()
+	var resps []*model.LLMResponse
+	for resp, err := range llm.GenerateContent(t.Context(), req, stream) {
+		if err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+		resps = append(resps, resp)
+	}
+	return resps
+}
+
+func TestWithCache(t *testing.T) {
+	request := func(text string) *model.LLMRequest {
+		return &model.LLMRequest{
+			Contents: []*genai.Content{genai.NewContentFromText(text, genai.RoleUser)},
+			Config:   &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0)},
+		}
+	}
+	inner := &countingModel{responses: []*model.LLMResponse{
+		{Content: genai.NewContentFromText("hel", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("hello", genai.RoleModel), TurnComplete: true},
+	}}
+	llm := model.WithCache(inner, model.NewInMemoryCache(model.InMemoryCacheConfig{MaxEntries: 2}))
+
+	want := collect(t, llm, request("hi"), true)
+	got := collect(t, llm, request("hi"), true)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("cached responses mismatch (-want +got):\n%s", diff)
+	}
+	if inner.calls != 1 {
+		t.Errorf("model calls = %d, want 1: the identical request is cached", inner.calls)
+	}
+	got[1].Content.Parts[0].Text = "modified"
+	if got := collect(t, llm, request("hi"), true); got[1].Content.Parts[0].Text != "hello" {
+		t.Errorf("cached response = %q, want the responses unaffected by the caller", got[1].Content.Parts[0].Text)
+	}
+
+	collect(t, llm, request("hi"), false)
+	collect(t, llm, request("bye"), true)
+	if inner.calls != 3 {
+		t.Errorf("model calls = %d, want 3: the streaming mode and the contents are part of the key", inner.calls)
+	}
+	// "hi" in streaming mode is the least recently used entry.
+	collect(t, llm, request("hi"), true)
+	if inner.calls != 4 {
+		t.Errorf("model calls = %d, want 4: the least recently used entry is evicted", inner.calls)
+	}
+
+	inner.responses = []*model.LLMResponse{{ErrorCode: "MAX_TOKENS"}}
+	collect(t, llm, request("error"), false)
+	collect(t, llm, request("error"), false)
+	if inner.calls != 6 {
+		t.Errorf("model calls = %d, want 6: the responses with an error code aren't cached", inner.calls)
+	}
+}
+
+func TestInMemoryCache_TTL(t *testing.T) {
+	cache := model.NewInMemoryCache(model.InMemoryCacheConfig{TTL: 50 * time.Millisecond})
+	if err := cache.Set(t.Context(), "key", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := cache.Get(t.Context(), "key"); err != nil || !ok || string(got) != "value" {
+		t.Errorf("Get() = %q, %v, %v, want the value", got, ok, err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, ok, err := cache.Get(t.Context(), "key"); err != nil || ok {
+		t.Errorf("Get() after the TTL = %v, %v, want no value", ok, err)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// Capabilities describe what a model supports. The agents, the runner and
+// the flows check the capabilities of the models before using a feature,
+// e.g. the size of the requests or the tools, so that the misconfigurations
+// fail early with a clear error instead of a provider error.
+type Capabilities struct {
+	// InputTokenLimit is the maximum number of tokens of a request, i.e.
+	// the context window of the model. Zero means unknown.
+	InputTokenLimit int
+	// OutputTokenLimit is the maximum number of tokens of a response. Zero
+	// means unknown.
+	OutputTokenLimit int
+	// InputModalities are the kinds of content the model accepts.
+	InputModalities []genai.MediaModality
+	// OutputModalities are the kinds of content the model generates.
+	OutputModalities []genai.Modality
+	// FunctionCalling reports whether the model calls tools.
+	FunctionCalling bool
+	// Live reports whether the model supports the live (bidi) API, e.g. for
+	// the audio transcriptions.
+	Live bool
+	// ContextCaching reports whether the model supports the explicit
+	// context caching, see gemini.NewModelWithContextCache.
+	ContextCaching bool
+}
+
+// SupportsInput reports whether the model accepts the given kind of content.
+func (c Capabilities) SupportsInput(m genai.MediaModality) bool {
+	return slices.Contains(c.InputModalities, m)
+}
+
+// SupportsOutput reports whether the model generates the given kind of
+// content.
+func (c Capabilities) SupportsOutput(m genai.Modality) bool {
+	return slices.Contains(c.OutputModalities, m)
+}
+
+// CapabilitiesProvider is implemented by the models which declare their own
+// capabilities, e.g. custom models. The declared capabilities take
+// precedence over the registered ones, see CapabilitiesOf.
+type CapabilitiesProvider interface {
+	Capabilities() Capabilities
+}
+
+var (
+	geminiInputs = []genai.MediaModality{
+		genai.MediaModalityText, genai.MediaModalityImage, genai.MediaModalityVideo,
+		genai.MediaModalityAudio, genai.MediaModalityDocument,
+	}
+	textOutput  = []genai.Modality{genai.ModalityText}
+	audioOutput = []genai.Modality{genai.ModalityText, genai.ModalityAudio}
+
+	capabilitiesMu sync.RWMutex
+	capabilities   = map[string]Capabilities{
+		"gemini-1.5-flash":      {InputTokenLimit: 1_048_576, OutputTokenLimit: 8_192, InputModalities: geminiInputs, OutputModalities: textOutput, FunctionCalling: true, ContextCaching: true},
+		"gemini-1.5-pro":        {InputTokenLimit: 2_097_152, OutputTokenLimit: 8_192, InputModalities: geminiInputs, OutputModalities: textOutput, FunctionCalling: true, ContextCaching: true},
+		"gemini-2.0-flash":      {InputTokenLimit: 1_048_576, OutputTokenLimit: 8_192, InputModalities: geminiInputs, OutputModalities: textOutput, FunctionCalling: true, ContextCaching: true},
+		"gemini-2.0-flash-lite": {InputTokenLimit: 1_048_576, OutputTokenLimit: 8_192, InputModalities: geminiInputs, OutputModalities: textOutput, FunctionCalling: true},
+		"gemini-2.0-flash-live": {InputTokenLimit: 1_048_576, OutputTokenLimit: 8_192, InputModalities: geminiInputs, OutputModalities: audioOutput, FunctionCalling: true, Live: true},
+		"gemini-2.5-flash":      {InputTokenLimit: 1_048_576, OutputTokenLimit: 65_536, InputModalities: geminiInputs, OutputModalities: textOutput, FunctionCalling: true, ContextCaching: true},
+		"gemini-2.5-flash-lite": {InputTokenLimit: 1_048_576, OutputTokenLimit: 65_536, InputModalities: geminiInputs, OutputModalities: textOutput, FunctionCalling: true, ContextCaching: true},
+		// The native audio variants of gemini-2.5-flash only serve the live
+		// API: they need their own entries, since they would match the
+		// gemini-2.5-flash entry otherwise.
+		"gemini-2.5-flash-native-audio":                     {InputTokenLimit: 131_072, OutputTokenLimit: 8_192, InputModalities: geminiInputs, OutputModalities: audioOutput, FunctionCalling: true, Live: true},
+		"gemini-2.5-flash-preview-native-audio-dialog":      {InputTokenLimit: 131_072, OutputTokenLimit: 8_192, InputModalities: geminiInputs, OutputModalities: audioOutput, FunctionCalling: true, Live: true},
+		"gemini-2.5-flash-exp-native-audio-thinking-dialog": {InputTokenLimit: 131_072, OutputTokenLimit: 8_192, InputModalities: geminiInputs, OutputModalities: audioOutput, FunctionCalling: true, Live: true},
+		"gemini-2.5-pro":        {InputTokenLimit: 1_048_576, OutputTokenLimit: 65_536, InputModalities: geminiInputs, OutputModalities: textOutput, FunctionCalling: true, ContextCaching: true},
+		"gemini-live-2.5-flash": {InputTokenLimit: 131_072, OutputTokenLimit: 8_192, InputModalities: geminiInputs, OutputModalities: audioOutput, FunctionCalling: true, Live: true},
+	}
+)
+
+// RegisterCapabilities registers the capabilities of the model with the
+// given name and of its versions, e.g. "gemini-2.5-flash" also covers
+// "gemini-2.5-flash-preview-05-20". It replaces the capabilities
+// registered before for the name, including the built-in ones.
+func RegisterCapabilities(name string, c Capabilities) {
+	capabilitiesMu.Lock()
+	defer capabilitiesMu.Unlock()
+	capabilities[name] = c
+}
+
+// RegisteredModels returns the sorted names of the registered models whose
+// capabilities satisfy the filter, e.g. the models supporting the live API.
+// A nil filter returns all the registered models.
+func RegisteredModels(filter func(Capabilities) bool) []string {
+	capabilitiesMu.RLock()
+	defer capabilitiesMu.RUnlock()
+	var names []string
+	for name, c := range capabilities {
+		if filter == nil || filter(c) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// CapabilitiesOf returns the capabilities of the model: the ones it
+// declares if it implements CapabilitiesProvider, or else the ones
+// registered for its name, see LookupCapabilities. It reports false if the
+// capabilities are unknown, in which case the features aren't checked.
+func CapabilitiesOf(llm LLM) (Capabilities, bool) {
+	if p, ok := llm.(CapabilitiesProvider); ok {
+		return p.Capabilities(), true
+	}
+	return LookupCapabilities(llm.Name())
+}
+
+// LookupCapabilities returns the capabilities of the model with the given
+// name. Resource names, e.g. "models/gemini-2.5-pro", are looked up by
+// their last segment, and versioned names by the longest registered name
+// they start with.
+func LookupCapabilities(name string) (Capabilities, bool) {
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	capabilitiesMu.RLock()
+	defer capabilitiesMu.RUnlock()
+	if c, ok := capabilities[name]; ok {
+		return c, true
+	}
+	var found Capabilities
+	longest := 0
+	for prefix, c := range capabilities {
+		if len(prefix) > longest && strings.HasPrefix(name, prefix+"-") {
+			found, longest = c, len(prefix)
+		}
+	}
+	return found, longest > 0
+}
+
+// ContextWindowExceededError is returned when a request is larger than the
+// context window of the model, before the request is sent to the model.
+type ContextWindowExceededError struct {
+	Model string
+	// Limit is the input token limit of the model.
+	Limit int
+	// Tokens is the estimated size of the request.
+	Tokens int
+}
+
+func (e *ContextWindowExceededError) Error() string {
+	return fmt.Sprintf("request of about %d tokens exceeds the context window of %d tokens of model %q", e.Tokens, e.Limit, e.Model)
+}
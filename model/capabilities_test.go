@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"slices"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestLookupCapabilities(t *testing.T) {
+	model.RegisterCapabilities("test-model", model.Capabilities{InputTokenLimit: 100})
+	model.RegisterCapabilities("test-model-mini", model.Capabilities{InputTokenLimit: 10})
+
+	tests := []struct {
+		name      string
+		wantLimit int
+		wantOK    bool
+	}{
+		{name: "test-model", wantLimit: 100, wantOK: true},
+		{name: "models/test-model", wantLimit: 100, wantOK: true},
+		{name: "test-model-001", wantLimit: 100, wantOK: true},
+		{name: "test-model-mini-001", wantLimit: 10, wantOK: true},
+		{name: "test-modelx", wantOK: false},
+		{name: "unknown", wantOK: false},
+		{name: "projects/p/locations/l/publishers/google/models/gemini-2.5-flash-preview-05-20", wantLimit: 1_048_576, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := model.LookupCapabilities(tt.name)
+			if ok != tt.wantOK || got.InputTokenLimit != tt.wantLimit {
+				t.Errorf("LookupCapabilities(%q) = %+v, %v, want limit %d, %v", tt.name, got, ok, tt.wantLimit, tt.wantOK)
+			}
+		})
+	}
+}
+
+// declaredModel is a model which declares its capabilities.
+type declaredModel struct {
+	model.LLM
+	caps model.Capabilities
+}
+
+func (m declaredModel) Name() string                     { return "test-model" }
+func (m declaredModel) Capabilities() model.Capabilities { return m.caps }
+
+func TestCapabilitiesOf(t *testing.T) {
+	model.RegisterCapabilities("test-model", model.Capabilities{InputTokenLimit: 100})
+
+	got, ok := model.CapabilitiesOf(declaredModel{caps: model.Capabilities{InputTokenLimit: 5, Live: true}})
+	if !ok || got.InputTokenLimit != 5 || !got.Live {
+		t.Errorf("CapabilitiesOf(declared) = %+v, %v, want the declared capabilities", got, ok)
+	}
+}
+
+func TestRegisteredModels(t *testing.T) {
+	got := model.RegisteredModels(func(c model.Capabilities) bool { return c.Live })
+	want := []string{
+		"gemini-2.0-flash-live",
+		"gemini-2.5-flash-exp-native-audio-thinking-dialog",
+		"gemini-2.5-flash-native-audio",
+		"gemini-2.5-flash-preview-native-audio-dialog",
+		"gemini-live-2.5-flash",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("RegisteredModels(live) = %v, want %v", got, want)
+	}
+
+	for _, name := range []string{
+		"gemini-2.5-flash-native-audio-preview-09-2025",
+		"gemini-2.5-flash-preview-native-audio-dialog",
+		"gemini-live-2.5-flash-preview",
+	} {
+		if caps, ok := model.LookupCapabilities(name); !ok || !caps.Live || !caps.SupportsOutput(genai.ModalityAudio) {
+			t.Errorf("LookupCapabilities(%q) = %+v, %v, want a live audio model", name, caps, ok)
+		}
+	}
+
+	caps, ok := model.LookupCapabilities("gemini-2.5-flash")
+	if !ok || !caps.FunctionCalling || !caps.SupportsInput(genai.MediaModalityImage) || caps.SupportsOutput(genai.ModalityAudio) {
+		t.Errorf("LookupCapabilities(gemini-2.5-flash) = %+v, %v", caps, ok)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+
+	"google.golang.org/genai"
+)
+
+// DefaultMaxInlineBytes is the default size above which the data is uploaded
+// to the model provider instead of being inlined in the requests, for the
+// models which upload files, see [FileUploader].
+const DefaultMaxInlineBytes = 10 << 20
+
+// FileUploader is implemented by the models which accept files uploaded to
+// the model provider, e.g. the Gemini File API. The LLM agents upload the
+// large artifacts referenced in their requests instead of inlining them,
+// see llmagent.Config.MaxInlineArtifactBytes.
+type FileUploader interface {
+	// UploadFile uploads the blob and returns its reference to send in the
+	// requests. It returns an error wrapping errors.ErrUnsupported if the
+	// model provider doesn't support the file uploads, in which case the
+	// blob is inlined.
+	UploadFile(ctx context.Context, displayName string, blob *genai.Blob) (*genai.FileData, error)
+}
@@ -0,0 +1,364 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/internal/logging"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Default values of the [ContextCacheConfig] fields.
+const (
+	DefaultContextCacheTTL       = 30 * time.Minute
+	DefaultContextCacheMinTokens = 4096
+	DefaultContextCacheMaxUses   = 10
+)
+
+// ContextCacheConfig configures the explicit context caching of the Gemini
+// model: the stable prefix of the requests (the system instruction, the
+// tools and the conversation before the latest user input) is stored in a
+// Gemini CachedContent resource, which the next requests with the same
+// prefix reference instead of sending it again.
+type ContextCacheConfig struct {
+	// TTL of the cached contents.
+	// Optional: defaults to [DefaultContextCacheTTL].
+	TTL time.Duration
+	// MinTokens is the minimum size of the prompt, as reported by the
+	// previous response with the same system instruction and tools, for
+	// which a cache is created. Gemini rejects caches smaller than the
+	// minimum of the model.
+	// Optional: defaults to [DefaultContextCacheMinTokens].
+	MinTokens int
+	// MaxUses is the number of requests using a cache, after which the cache
+	// is recreated to include the newer conversation.
+	// Optional: defaults to [DefaultContextCacheMaxUses].
+	MaxUses int
+}
+
+// NewModelWithContextCache returns [model.LLM], backed by the Gemini API,
+// which caches the stable prefix of the requests as described in
+// [ContextCacheConfig]. It returns an error if the model is known not to
+// support the context caching, see [model.LookupCapabilities].
+func NewModelWithContextCache(ctx context.Context, modelName string, cfg *genai.ClientConfig, cacheCfg ContextCacheConfig) (model.LLM, error) {
+	if caps, ok := model.LookupCapabilities(modelName); ok && !caps.ContextCaching {
+		return nil, fmt.Errorf("model %q does not support context caching", modelName)
+	}
+	llm, err := NewModel(ctx, modelName, cfg)
+	if err != nil {
+		return nil, err
+	}
+	m := llm.(*geminiModel)
+	m.cache = newContextCache(cacheCfg, &genaiCacheClient{client: m.client})
+	return m, nil
+}
+
+// cacheClient creates and deletes the cached contents.
+type cacheClient interface {
+	create(ctx context.Context, modelName string, cfg *genai.CreateCachedContentConfig) (name string, err error)
+	delete(ctx context.Context, name string) error
+}
+
+type genaiCacheClient struct {
+	client *genai.Client
+}
+
+func (c *genaiCacheClient) create(ctx context.Context, modelName string, cfg *genai.CreateCachedContentConfig) (string, error) {
+	cached, err := c.client.Caches.Create(ctx, modelName, cfg)
+	if err != nil {
+		return "", err
+	}
+	return cached.Name, nil
+}
+
+func (c *genaiCacheClient) delete(ctx context.Context, name string) error {
+	_, err := c.client.Caches.Delete(ctx, name, nil)
+	return err
+}
+
+// contextCache tracks the cached contents, by the hash of the system
+// instruction and the tools they contain. It's safe for concurrent use.
+type contextCache struct {
+	cfg    ContextCacheConfig
+	client cacheClient
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string][]*cacheEntry
+	// promptTokens is the prompt size reported by the last response, by
+	// the hash of the system instruction and the tools.
+	promptTokens map[string]int32
+}
+
+type cacheEntry struct {
+	name string
+	// numContents is the number of cached contents, and contentsHash their
+	// hash.
+	numContents  int
+	contentsHash string
+	expireAt     time.Time
+	uses         int
+}
+
+// cachedRequest is a request prepared to use a cached content.
+type cachedRequest struct {
+	contents []*genai.Content
+	config   *genai.GenerateContentConfig
+	// prefixKey is the hash of the system instruction and the tools.
+	prefixKey string
+}
+
+// expiryMargin is the minimum remaining lifetime of a cache to be used, so
+// that it doesn't expire while the request is processed.
+const expiryMargin = time.Minute
+
+func newContextCache(cfg ContextCacheConfig, client cacheClient) *contextCache {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultContextCacheTTL
+	}
+	if cfg.MinTokens <= 0 {
+		cfg.MinTokens = DefaultContextCacheMinTokens
+	}
+	if cfg.MaxUses <= 0 {
+		cfg.MaxUses = DefaultContextCacheMaxUses
+	}
+	return &contextCache{
+		cfg:          cfg,
+		client:       client,
+		now:          time.Now,
+		entries:      make(map[string][]*cacheEntry),
+		promptTokens: make(map[string]int32),
+	}
+}
+
+// prepare returns the contents and the config to send for the request. If a
+// cache holds the prefix of the request, the prefix is replaced by the
+// reference to the cache. A cache is created if the prompt is large enough.
+// The request itself isn't modified.
+func (c *contextCache) prepare(ctx context.Context, modelName string, req *model.LLMRequest) cachedRequest {
+	out := cachedRequest{contents: req.Contents, config: req.Config}
+	if req.Config != nil && req.Config.CachedContent != "" {
+		// The caller manages the cache.
+		return out
+	}
+	prefixKey, err := prefixHash(req.Config)
+	if err != nil {
+		logging.FromContext(ctx).Error("context cache: failed to hash the request prefix", "error", err)
+		return out
+	}
+	out.prefixKey = prefixKey
+	n := stablePrefixLen(req.Contents)
+	hashes, err := contentsHashes(req.Contents[:n])
+	if err != nil {
+		logging.FromContext(ctx).Error("context cache: failed to hash the request contents", "error", err)
+		return out
+	}
+
+	entry := c.lookup(prefixKey, hashes)
+	if entry == nil && c.shouldCreate(prefixKey) {
+		entry = c.create(ctx, modelName, prefixKey, req.Config, req.Contents[:n], hashes[n])
+	}
+	if entry == nil {
+		return out
+	}
+
+	var cfg genai.GenerateContentConfig
+	if req.Config != nil {
+		cfg = *req.Config
+	}
+	cfg.CachedContent = entry.name
+	cfg.SystemInstruction = nil
+	cfg.Tools = nil
+	cfg.ToolConfig = nil
+	out.config = &cfg
+	out.contents = req.Contents[entry.numContents:]
+	return out
+}
+
+// lookup returns the usable cache with the longest prefix of the contents,
+// and counts the use. hashes[i] is the hash of the first i contents.
+func (c *contextCache) lookup(prefixKey string, hashes []string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	var best *cacheEntry
+	for _, e := range c.entries[prefixKey] {
+		if e.numContents >= len(hashes) || hashes[e.numContents] != e.contentsHash {
+			continue
+		}
+		if e.uses >= c.cfg.MaxUses || now.Add(expiryMargin).After(e.expireAt) {
+			continue
+		}
+		if best == nil || e.numContents > best.numContents {
+			best = e
+		}
+	}
+	if best != nil {
+		best.uses++
+	}
+	return best
+}
+
+func (c *contextCache) shouldCreate(prefixKey string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int(c.promptTokens[prefixKey]) >= c.cfg.MinTokens
+}
+
+// create creates a cache for the prefix, and deletes the caches of the same
+// system instruction and tools which can't be used anymore.
+func (c *contextCache) create(ctx context.Context, modelName, prefixKey string, cfg *genai.GenerateContentConfig, contents []*genai.Content, contentsHash string) *cacheEntry {
+	createCfg := &genai.CreateCachedContentConfig{
+		TTL:         c.cfg.TTL,
+		DisplayName: "adk-" + prefixKey[:16],
+		Contents:    contents,
+	}
+	if cfg != nil {
+		createCfg.SystemInstruction = cfg.SystemInstruction
+		createCfg.Tools = cfg.Tools
+		createCfg.ToolConfig = cfg.ToolConfig
+	}
+	start := c.now()
+	name, err := c.client.create(ctx, modelName, createCfg)
+	if err != nil {
+		// The request is sent without the cache.
+		logging.FromContext(ctx).Warn("context cache: failed to create the cached content", "error", err)
+		return nil
+	}
+	entry := &cacheEntry{
+		name:         name,
+		numContents:  len(contents),
+		contentsHash: contentsHash,
+		expireAt:     start.Add(c.cfg.TTL),
+		uses:         1,
+	}
+
+	c.mu.Lock()
+	now := c.now()
+	var stale []string
+	kept := []*cacheEntry{entry}
+	for _, e := range c.entries[prefixKey] {
+		if e.uses >= c.cfg.MaxUses || now.Add(expiryMargin).After(e.expireAt) || e.contentsHash == contentsHash {
+			stale = append(stale, e.name)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	c.entries[prefixKey] = kept
+	c.mu.Unlock()
+
+	for _, name := range stale {
+		if err := c.client.delete(ctx, name); err != nil {
+			logging.FromContext(ctx).Warn("context cache: failed to delete the cached content", "name", name, "error", err)
+		}
+	}
+	return entry
+}
+
+// record records the prompt size reported in the response.
+func (c *contextCache) record(prefixKey string, resp *model.LLMResponse) {
+	if prefixKey == "" || resp == nil || resp.UsageMetadata == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.promptTokens[prefixKey] = resp.UsageMetadata.PromptTokenCount
+}
+
+// invalidate forgets the cache, e.g. after the API reported it as missing.
+func (c *contextCache) invalidate(prefixKey, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := c.entries[prefixKey]
+	for i, e := range entries {
+		if e.name == name {
+			c.entries[prefixKey] = append(entries[:i:i], entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// isCacheMissError reports whether the error is the rejection of a cache
+// which was deleted or has expired, in which case the request is sent again
+// without the cache. The other errors, e.g. the quota errors, don't tell
+// anything about the cache.
+func isCacheMissError(err error) bool {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Code {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound:
+	default:
+		return false
+	}
+	msg := strings.ToLower(apiErr.Message)
+	return strings.Contains(msg, "cache") && (strings.Contains(msg, "not found") || strings.Contains(msg, "expired"))
+}
+
+// stablePrefixLen returns the number of contents before the latest user
+// input, which are stable between the requests of a conversation.
+func stablePrefixLen(contents []*genai.Content) int {
+	i := len(contents)
+	for i > 0 && contents[i-1] != nil && contents[i-1].Role == genai.RoleUser {
+		i--
+	}
+	return i
+}
+
+// prefixHash returns the hash of the system instruction and the tools.
+func prefixHash(cfg *genai.GenerateContentConfig) (string, error) {
+	var p struct {
+		SystemInstruction *genai.Content    `json:"systemInstruction,omitempty"`
+		Tools             []*genai.Tool     `json:"tools,omitempty"`
+		ToolConfig        *genai.ToolConfig `json:"toolConfig,omitempty"`
+	}
+	if cfg != nil {
+		p.SystemInstruction, p.Tools, p.ToolConfig = cfg.SystemInstruction, cfg.Tools, cfg.ToolConfig
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// contentsHashes returns the hashes of the prefixes of the contents: the
+// i-th hash covers the first i contents.
+func contentsHashes(contents []*genai.Content) ([]string, error) {
+	h := sha256.New()
+	hashes := make([]string, 0, len(contents)+1)
+	hashes = append(hashes, fmt.Sprintf("%x", h.Sum(nil)))
+	for _, c := range contents {
+		b, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		h.Write(b)
+		h.Write([]byte{'\n'})
+		hashes = append(hashes, fmt.Sprintf("%x", h.Sum(nil)))
+	}
+	return hashes, nil
+}
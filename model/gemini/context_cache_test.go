@@ -0,0 +1,180 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+type fakeCacheClient struct {
+	created []*genai.CreateCachedContentConfig
+	deleted []string
+}
+
+func (c *fakeCacheClient) create(ctx context.Context, modelName string, cfg *genai.CreateCachedContentConfig) (string, error) {
+	c.created = append(c.created, cfg)
+	return fmt.Sprintf("cachedContents/%d", len(c.created)), nil
+}
+
+func (c *fakeCacheClient) delete(ctx context.Context, name string) error {
+	c.deleted = append(c.deleted, name)
+	return nil
+}
+
+func conversation(turns int, instruction string) *model.LLMRequest {
+	req := &model.LLMRequest{Config: &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText(instruction, genai.RoleUser),
+		Tools:             []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "f"}}}},
+		Temperature:       new(float32),
+	}}
+	for i := range turns {
+		req.Contents = append(req.Contents,
+			genai.NewContentFromText(fmt.Sprintf("question %d", i), genai.RoleUser),
+			genai.NewContentFromText(fmt.Sprintf("answer %d", i), genai.RoleModel))
+	}
+	req.Contents = append(req.Contents, genai.NewContentFromText("latest question", genai.RoleUser))
+	return req
+}
+
+func usage(tokens int32) *model.LLMResponse {
+	return &model.LLMResponse{UsageMetadata: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: tokens}}
+}
+
+func TestContextCache(t *testing.T) {
+	ctx := t.Context()
+	client := &fakeCacheClient{}
+	c := newContextCache(ContextCacheConfig{MinTokens: 1000, MaxUses: 2, TTL: time.Hour}, client)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	// The prompt size isn't known yet.
+	req := conversation(1, "be nice")
+	got := c.prepare(ctx, "gemini", req)
+	if got.config != req.Config || len(client.created) != 0 {
+		t.Fatalf("prepare() used a cache for the first request")
+	}
+	c.record(got.prefixKey, usage(2000))
+
+	// The prefix is cached.
+	req = conversation(2, "be nice")
+	got = c.prepare(ctx, "gemini", req)
+	if len(client.created) != 1 {
+		t.Fatalf("created %d caches, want 1", len(client.created))
+	}
+	if diff := cmp.Diff(req.Contents[:4], client.created[0].Contents); diff != "" {
+		t.Errorf("cached contents mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(req.Config.SystemInstruction, client.created[0].SystemInstruction); diff != "" {
+		t.Errorf("cached system instruction mismatch (-want +got):\n%s", diff)
+	}
+	if got.config.CachedContent != "cachedContents/1" || got.config.SystemInstruction != nil || got.config.Tools != nil || *got.config.Temperature != 0 {
+		t.Errorf("prepare() config = %+v, want the cache reference instead of the prefix", got.config)
+	}
+	if diff := cmp.Diff(req.Contents[4:], got.contents); diff != "" {
+		t.Errorf("prepare() contents mismatch (-want +got):\n%s", diff)
+	}
+	if req.Config.CachedContent != "" || req.Config.SystemInstruction == nil {
+		t.Error("prepare() modified the request")
+	}
+
+	// The next request of the conversation reuses the cache.
+	req = conversation(3, "be nice")
+	got = c.prepare(ctx, "gemini", req)
+	if got.config.CachedContent != "cachedContents/1" || len(client.created) != 1 {
+		t.Errorf("prepare() didn't reuse the cache")
+	}
+	if diff := cmp.Diff(req.Contents[4:], got.contents); diff != "" {
+		t.Errorf("prepare() contents mismatch (-want +got):\n%s", diff)
+	}
+
+	// The cache was used MaxUses times: it's recreated with the longer
+	// conversation and the old one is deleted.
+	req = conversation(4, "be nice")
+	got = c.prepare(ctx, "gemini", req)
+	if got.config.CachedContent != "cachedContents/2" || len(client.created[1].Contents) != 8 {
+		t.Errorf("prepare() didn't recreate the cache")
+	}
+	if diff := cmp.Diff([]string{"cachedContents/1"}, client.deleted); diff != "" {
+		t.Errorf("deleted caches mismatch (-want +got):\n%s", diff)
+	}
+
+	// A changed system instruction doesn't match the cache.
+	req = conversation(4, "be very nice")
+	if got := c.prepare(ctx, "gemini", req); got.config != req.Config {
+		t.Error("prepare() used a cache of another system instruction")
+	}
+
+	// An expired cache isn't used.
+	now = now.Add(time.Hour)
+	req = conversation(4, "be nice")
+	got = c.prepare(ctx, "gemini", req)
+	if got.config.CachedContent != "cachedContents/3" {
+		t.Errorf("prepare() CachedContent = %q, want a new cache", got.config.CachedContent)
+	}
+
+	// The caller manages the cache.
+	req = conversation(4, "be nice")
+	req.Config.CachedContent = "mine"
+	if got := c.prepare(ctx, "gemini", req); got.config != req.Config {
+		t.Error("prepare() replaced the cache of the caller")
+	}
+}
+
+func TestContextCache_Invalidate(t *testing.T) {
+	ctx := t.Context()
+	client := &fakeCacheClient{}
+	c := newContextCache(ContextCacheConfig{MinTokens: 1}, client)
+
+	got := c.prepare(ctx, "gemini", conversation(1, "be nice"))
+	c.record(got.prefixKey, usage(10))
+	got = c.prepare(ctx, "gemini", conversation(1, "be nice"))
+	if got.config.CachedContent == "" {
+		t.Fatal("prepare() didn't use a cache")
+	}
+	c.invalidate(got.prefixKey, got.config.CachedContent)
+	got = c.prepare(ctx, "gemini", conversation(1, "be nice"))
+	if got.config.CachedContent != "cachedContents/2" {
+		t.Errorf("prepare() CachedContent = %q, want a new cache after invalidation", got.config.CachedContent)
+	}
+}
+
+func TestIsCacheMissError(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "not found", err: genai.APIError{Code: 404, Message: "CachedContent not found (or permission denied)"}, want: true},
+		{name: "permission denied", err: genai.APIError{Code: 403, Message: "CachedContent not found (or permission denied)"}, want: true},
+		{name: "expired", err: fmt.Errorf("call: %w", genai.APIError{Code: 400, Message: "Cache content 123 is expired."}), want: true},
+		{name: "quota", err: genai.APIError{Code: 429, Message: "Resource has been exhausted"}},
+		{name: "server error", err: genai.APIError{Code: 500, Message: "Internal error"}},
+		{name: "invalid request", err: genai.APIError{Code: 400, Message: "Request contains an invalid argument."}},
+		{name: "canceled", err: context.Canceled},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isCacheMissError(tc.err); got != tc.want {
+				t.Errorf("isCacheMissError(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// DefaultFileUploadThreshold is the default size of the inline data above
+// which the data is uploaded to the File API, see [FileUploadConfig]. It is
+// the same as the default size of the artifacts uploaded by the LLM agents,
+// see llmagent.Config.MaxInlineArtifactBytes.
+const DefaultFileUploadThreshold = model.DefaultMaxInlineBytes
+
+// FileUploadConfig configures the uploads of the large inline data of the
+// requests to the File API. The uploaded data is referenced by its URI in the
+// requests, so that large PDFs or images aren't sent again on every turn of
+// the conversation. The uploaded files are reused while the same data is
+// sent, and deleted when the model is closed, see runner.Runner.Close.
+//
+// Vertex AI doesn't support the File API, so the data is always sent inline
+// there.
+type FileUploadConfig struct {
+	// Threshold is the size in bytes of the inline data above which the data
+	// is uploaded. A negative threshold disables the uploads of the inline
+	// data, only the artifacts are uploaded, see [model.FileUploader].
+	// Optional: defaults to [DefaultFileUploadThreshold].
+	Threshold int
+}
+
+// NewModelWithFileUploads returns [model.LLM], backed by the Gemini API,
+// which uploads the large inline data of the requests to the File API as
+// described in [FileUploadConfig]. The model is a [model.FileUploader].
+// [NewModel] doesn't upload any data.
+func NewModelWithFileUploads(ctx context.Context, modelName string, cfg *genai.ClientConfig, uploadCfg FileUploadConfig) (model.LLM, error) {
+	llm, err := NewModel(ctx, modelName, cfg)
+	if err != nil {
+		return nil, err
+	}
+	m := llm.(*geminiModel)
+	m.fileUploads = true
+	m.uploadThreshold = uploadCfg.Threshold
+	if m.uploadThreshold == 0 {
+		m.uploadThreshold = DefaultFileUploadThreshold
+	}
+	return m, nil
+}
+
+// defaultFileTTL is how long the uploaded files are reused if the File API
+// doesn't report their expiration. The files are kept for 48 hours.
+const defaultFileTTL = 47 * time.Hour
+
+// uploadedFiles are the files uploaded to the File API, by the hash of
+// their content, so that the same artifact is uploaded once.
+type uploadedFiles struct {
+	mu    sync.Mutex
+	files map[[sha256.Size]byte]uploadedFile
+}
+
+type uploadedFile struct {
+	// name is the resource name of the file, used to delete it.
+	name    string
+	data    *genai.FileData
+	expires time.Time
+}
+
+var _ model.FileUploader = (*geminiModel)(nil)
+
+// UploadFile implements [model.FileUploader] with the Gemini File API. The
+// files uploaded before are reused until they expire. Vertex AI doesn't
+// support the File API, and only the models created with
+// [NewModelWithFileUploads] upload files.
+func (m *geminiModel) UploadFile(ctx context.Context, displayName string, blob *genai.Blob) (*genai.FileData, error) {
+	if !m.fileUploads {
+		return nil, fmt.Errorf("the file uploads are not enabled for the model: %w", errors.ErrUnsupported)
+	}
+	if m.client.ClientConfig().Backend == genai.BackendVertexAI {
+		return nil, fmt.Errorf("the File API is not supported by Vertex AI: %w", errors.ErrUnsupported)
+	}
+	key := sha256.Sum256(blob.Data)
+	now := time.Now()
+
+	m.uploads.mu.Lock()
+	f, ok := m.uploads.files[key]
+	m.uploads.mu.Unlock()
+	if ok && now.Before(f.expires) {
+		return f.data, nil
+	}
+
+	file, err := m.client.Files.Upload(ctx, bytes.NewReader(blob.Data), &genai.UploadFileConfig{
+		MIMEType:    blob.MIMEType,
+		DisplayName: displayName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file %q: %w", displayName, err)
+	}
+	f = uploadedFile{
+		name:    file.Name,
+		data:    &genai.FileData{FileURI: file.URI, MIMEType: file.MIMEType},
+		expires: now.Add(defaultFileTTL),
+	}
+	if !file.ExpirationTime.IsZero() {
+		// Leave a margin for the requests sent shortly before the expiration.
+		f.expires = file.ExpirationTime.Add(-time.Hour)
+	}
+
+	m.uploads.mu.Lock()
+	defer m.uploads.mu.Unlock()
+	if m.uploads.files == nil {
+		m.uploads.files = make(map[[sha256.Size]byte]uploadedFile)
+	}
+	// The File API deletes the expired files by itself.
+	maps.DeleteFunc(m.uploads.files, func(_ [sha256.Size]byte, f uploadedFile) bool {
+		return !now.Before(f.expires)
+	})
+	m.uploads.files[key] = f
+	return f.data, nil
+}
+
+// uploadInlineData replaces the inline data of the request larger than the
+// upload threshold with the data of the files they are uploaded to. The
+// contents of the request are copied before they are modified, since they may
+// be shared with the session events.
+func (m *geminiModel) uploadInlineData(ctx context.Context, req *model.LLMRequest) error {
+	if m.uploadThreshold <= 0 || m.client.ClientConfig().Backend == genai.BackendVertexAI {
+		return nil
+	}
+	for i, content := range req.Contents {
+		if content == nil {
+			continue
+		}
+		var parts []*genai.Part
+		for j, part := range content.Parts {
+			if part == nil || part.InlineData == nil || len(part.InlineData.Data) <= m.uploadThreshold {
+				continue
+			}
+			data, err := m.UploadFile(ctx, part.InlineData.DisplayName, part.InlineData)
+			if err != nil {
+				return err
+			}
+			if parts == nil {
+				parts = slices.Clone(content.Parts)
+			}
+			uploaded := *part
+			uploaded.InlineData = nil
+			uploaded.FileData = data
+			parts[j] = &uploaded
+		}
+		if parts != nil {
+			req.Contents[i] = &genai.Content{Role: content.Role, Parts: parts}
+		}
+	}
+	return nil
+}
+
+// Close deletes the files uploaded to the File API. The model can still be
+// used afterwards: the data is uploaded again if needed.
+func (m *geminiModel) Close(ctx context.Context) error {
+	m.uploads.mu.Lock()
+	files := m.uploads.files
+	m.uploads.files = nil
+	m.uploads.mu.Unlock()
+
+	var errs []error
+	for _, f := range files {
+		if f.name == "" {
+			continue
+		}
+		if _, err := m.client.Files.Delete(ctx, f.name, nil); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete file %q: %w", f.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// fakeFileAPI serves the uploads and deletions of files and the content
+// generation of the Gemini API.
+type fakeFileAPI struct {
+	mu       sync.Mutex
+	uploads  int
+	deleted  []string
+	requests []map[string]any
+}
+
+func (f *fakeFileAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/upload/v1beta/files":
+		w.Header().Set("X-Goog-Upload-Url", "http://"+r.Host+"/upload/session")
+		io.WriteString(w, "{}")
+	case r.URL.Path == "/upload/session":
+		f.uploads++
+		w.Header().Set("X-Goog-Upload-Status", "final")
+		io.WriteString(w, `{"file": {"name": "files/doc", "uri": "https://files/doc", "mimeType": "application/pdf"}}`)
+	case r.Method == http.MethodDelete:
+		f.deleted = append(f.deleted, strings.TrimPrefix(r.URL.Path, "/v1beta/"))
+		io.WriteString(w, "{}")
+	case strings.HasSuffix(r.URL.Path, ":generateContent"):
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		f.requests = append(f.requests, body)
+		io.WriteString(w, `{"candidates": [{"content": {"role": "model", "parts": [{"text": "ok"}]}}]}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestModel_UploadInlineData(t *testing.T) {
+	api := &fakeFileAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	llm, err := NewModelWithFileUploads(t.Context(), "gemini-2.5-flash", &genai.ClientConfig{
+		Backend:     genai.BackendGeminiAPI,
+		APIKey:      "key",
+		HTTPOptions: genai.HTTPOptions{BaseURL: srv.URL},
+	}, FileUploadConfig{Threshold: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pdf := genai.NewPartFromBytes([]byte(strings.Repeat("%PDF", 10)), "application/pdf")
+	small := genai.NewPartFromBytes([]byte("tiny"), "image/png")
+	content := genai.NewContentFromParts([]*genai.Part{pdf, small, genai.NewPartFromText("Summarize it.")}, genai.RoleUser)
+	for range 2 {
+		req := &model.LLMRequest{Contents: []*genai.Content{content}}
+		for _, err := range llm.GenerateContent(t.Context(), req, false) {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if content.Parts[0].InlineData == nil {
+		t.Error("GenerateContent() modified the contents of the request")
+	}
+	if api.uploads != 1 {
+		t.Errorf("got %d uploads, want 1", api.uploads)
+	}
+	for _, body := range api.requests {
+		parts := body["contents"].([]any)[0].(map[string]any)["parts"].([]any)
+		if _, ok := parts[0].(map[string]any)["fileData"]; !ok {
+			t.Errorf("large part sent as %v, want file data", parts[0])
+		}
+		if _, ok := parts[1].(map[string]any)["inlineData"]; !ok {
+			t.Errorf("small part sent as %v, want inline data", parts[1])
+		}
+	}
+
+	if err := llm.(*geminiModel).Close(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.deleted) != 1 || api.deleted[0] != "files/doc" {
+		t.Errorf("deleted files = %v, want [files/doc]", api.deleted)
+	}
+}
+
+func TestModel_NoFileUploadsByDefault(t *testing.T) {
+	api := &fakeFileAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	llm, err := NewModel(t.Context(), "gemini-2.5-flash", &genai.ClientConfig{
+		Backend:     genai.BackendGeminiAPI,
+		APIKey:      "key",
+		HTTPOptions: genai.HTTPOptions{BaseURL: srv.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	large := genai.NewPartFromBytes(make([]byte, DefaultFileUploadThreshold+1), "application/pdf")
+	req := &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromParts([]*genai.Part{large}, genai.RoleUser)}}
+	for _, err := range llm.GenerateContent(t.Context(), req, false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := llm.(model.FileUploader).UploadFile(t.Context(), "doc", large.InlineData); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("UploadFile() error = %v, want errors.ErrUnsupported", err)
+	}
+	if api.uploads != 0 {
+		t.Errorf("got %d uploads, want 0", api.uploads)
+	}
+	parts := api.requests[0]["contents"].([]any)[0].(map[string]any)["parts"].([]any)
+	if _, ok := parts[0].(map[string]any)["inlineData"]; !ok {
+		t.Errorf("large part sent as file data, want inline data")
+	}
+}
@@ -35,13 +35,25 @@ type geminiModel struct {
 	client             *genai.Client
 	name               string
 	versionHeaderValue string
+	// cache is set if the context caching is enabled.
+	cache *contextCache
+	// fileUploads is set if the model uploads files to the File API, see
+	// NewModelWithFileUploads.
+	fileUploads bool
+	// uploads are the files uploaded with UploadFile.
+	uploads uploadedFiles
+	// uploadThreshold is the size of the inline data above which the data is
+	// uploaded to the File API, the inline data isn't uploaded if not
+	// positive.
+	uploadThreshold int
 }
 
 // NewModel returns [model.LLM], backed by the Gemini API.
 //
 // It uses the provided context and configuration to initialize the underlying
 // [genai.Client]. The modelName specifies which Gemini model to target
-// (e.g., "gemini-2.5-flash").
+// (e.g., "gemini-2.5-flash"). The inline data of the requests is sent as is,
+// see [NewModelWithFileUploads] to upload the large data to the File API.
 //
 // An error is returned if the [genai.Client] fails to initialize.
 func NewModel(ctx context.Context, modelName string, cfg *genai.ClientConfig) (model.LLM, error) {
@@ -95,9 +107,28 @@ func (m *geminiModel) addHeaders(headers http.Header) {
 	headers.Set("user-agent", m.versionHeaderValue)
 }
 
+// prepare returns the contents and the config to send, using the context
+// cache if enabled.
+func (m *geminiModel) prepare(ctx context.Context, req *model.LLMRequest) cachedRequest {
+	if m.cache == nil {
+		return cachedRequest{contents: req.Contents, config: req.Config}
+	}
+	return m.cache.prepare(ctx, m.name, req)
+}
+
 // generate calls the model synchronously returning result from the first candidate.
 func (m *geminiModel) generate(ctx context.Context, req *model.LLMRequest) (*model.LLMResponse, error) {
-	resp, err := m.client.Models.GenerateContent(ctx, m.name, req.Contents, req.Config)
+	if err := m.uploadInlineData(ctx, req); err != nil {
+		return nil, err
+	}
+	cr := m.prepare(ctx, req)
+	resp, err := m.client.Models.GenerateContent(ctx, m.name, cr.contents, cr.config)
+	if err != nil && cr.config != req.Config && isCacheMissError(err) {
+		// The cache was deleted or has expired: retry without it.
+		m.cache.invalidate(cr.prefixKey, cr.config.CachedContent)
+		cr = cachedRequest{contents: req.Contents, config: req.Config, prefixKey: cr.prefixKey}
+		resp, err = m.client.Models.GenerateContent(ctx, m.name, cr.contents, cr.config)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to call model: %w", err)
 	}
@@ -105,7 +136,11 @@ func (m *geminiModel) generate(ctx context.Context, req *model.LLMRequest) (*mod
 		// shouldn't happen?
 		return nil, fmt.Errorf("empty response")
 	}
-	return converters.Genai2LLMResponse(resp), nil
+	llmResp := converters.Genai2LLMResponse(resp)
+	if m.cache != nil {
+		m.cache.record(cr.prefixKey, llmResp)
+	}
+	return llmResp, nil
 }
 
 // generateStream returns a stream of responses from the model.
@@ -113,12 +148,23 @@ func (m *geminiModel) generateStream(ctx context.Context, req *model.LLMRequest)
 	aggregator := llminternal.NewStreamingResponseAggregator()
 
 	return func(yield func(*model.LLMResponse, error) bool) {
-		for resp, err := range m.client.Models.GenerateContentStream(ctx, m.name, req.Contents, req.Config) {
+		if err := m.uploadInlineData(ctx, req); err != nil {
+			yield(nil, err)
+			return
+		}
+		cr := m.prepare(ctx, req)
+		for resp, err := range m.client.Models.GenerateContentStream(ctx, m.name, cr.contents, cr.config) {
 			if err != nil {
+				if cr.config != req.Config && isCacheMissError(err) {
+					m.cache.invalidate(cr.prefixKey, cr.config.CachedContent)
+				}
 				yield(nil, err)
 				return
 			}
 			for llmResponse, err := range aggregator.ProcessResponse(ctx, resp) {
+				if m.cache != nil && err == nil {
+					m.cache.record(cr.prefixKey, llmResponse)
+				}
 				if !yield(llmResponse, err) {
 					return // Consumer stopped
 				}
@@ -0,0 +1,370 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keypool provides a [model.LLM] which spreads the requests across
+// several backends, e.g. Gemini models using different API keys or projects,
+// to go beyond the quota of a single key.
+//
+// Each backend has its own quota, tracked locally. The requests are sent to
+// the available backends in turn. A backend is skipped while its local quota
+// is used up, after the API reported its quota as exhausted, or while it is
+// unhealthy. If a backend fails before any response was received, the
+// request transparently fails over to the next backend.
+package keypool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// ErrNoBackendAvailable is returned when all the backends are exhausted or
+// unhealthy.
+var ErrNoBackendAvailable = errors.New("no model backend available")
+
+// Default values of the [Config] fields.
+const (
+	DefaultCooldown         = time.Minute
+	DefaultMaxCooldown      = time.Hour
+	DefaultFailureThreshold = 3
+)
+
+// Backend is a model using one API key or project.
+type Backend struct {
+	// Name identifies the backend in the stats and errors, e.g. "project-a".
+	// It must not contain secrets.
+	Name string
+	// Model is the model using the API key or project of the backend.
+	Model model.LLM
+	// RequestsPerMinute, RequestsPerDay and TokensPerMinute are the quota of
+	// the backend. The daily quota resets at midnight UTC.
+	// Optional: zero means unlimited.
+	RequestsPerMinute int
+	RequestsPerDay    int
+	TokensPerMinute   int
+}
+
+// Config is used to create a [Model].
+type Config struct {
+	// Backends receive the requests in turn.
+	Backends []Backend
+	// Cooldown is how long a backend is skipped after the API reported its
+	// quota as exhausted, or after it became unhealthy. The cooldown doubles
+	// each time the backend fails again, up to MaxCooldown.
+	// Optional: defaults to [DefaultCooldown] and [DefaultMaxCooldown].
+	Cooldown    time.Duration
+	MaxCooldown time.Duration
+	// FailureThreshold is the number of consecutive failures, other than
+	// quota errors, after which a backend is unhealthy.
+	// Optional: defaults to [DefaultFailureThreshold].
+	FailureThreshold int
+	// HealthCheck checks whether a backend can serve requests. It is used by
+	// [Model.CheckHealth].
+	// Optional: if not set, the backend is sent a minimal request.
+	HealthCheck func(ctx context.Context, b Backend) error
+	// IsQuotaError reports whether an error means the quota of the backend
+	// is exhausted.
+	// Optional: defaults to [model.IsRateLimited].
+	IsQuotaError func(err error) bool
+}
+
+// BackendStats describes the state of a backend.
+type BackendStats struct {
+	Name               string
+	RequestsThisMinute int
+	RequestsToday      int
+	TokensThisMinute   int
+	// Healthy is false after FailureThreshold consecutive failures, until a
+	// request or a health check succeeds.
+	Healthy bool
+	// CooldownUntil is the time until which the backend is skipped. It is
+	// zero if the backend isn't cooling down.
+	CooldownUntil time.Time
+	// LastError is the last error returned by the backend.
+	LastError error
+}
+
+// Model is a [model.LLM] spreading the requests across several backends.
+// It is safe for concurrent use.
+type Model struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	backends []*backendState
+	next     int
+}
+
+type backendState struct {
+	Backend
+
+	minute, day   time.Time
+	minuteReqs    int
+	dayReqs       int
+	minuteTokens  int
+	failures      int
+	quotaFailures int
+	cooldownUntil time.Time
+	lastErr       error
+}
+
+// New returns a model spreading the requests across the backends.
+func New(cfg Config) (*Model, error) {
+	if len(cfg.Backends) == 0 {
+		return nil, fmt.Errorf("at least one backend is required")
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultCooldown
+	}
+	if cfg.MaxCooldown <= 0 {
+		cfg.MaxCooldown = DefaultMaxCooldown
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DefaultFailureThreshold
+	}
+	if cfg.IsQuotaError == nil {
+		cfg.IsQuotaError = model.IsRateLimited
+	}
+	m := &Model{cfg: cfg, now: time.Now}
+	names := make(map[string]bool)
+	for i, b := range cfg.Backends {
+		if b.Model == nil {
+			return nil, fmt.Errorf("backend %d has no model", i)
+		}
+		if b.Name == "" {
+			b.Name = fmt.Sprintf("backend-%d", i)
+		}
+		if names[b.Name] {
+			return nil, fmt.Errorf("duplicate backend name %q", b.Name)
+		}
+		names[b.Name] = true
+		m.backends = append(m.backends, &backendState{Backend: b})
+	}
+	return m, nil
+}
+
+// Name returns the model name of the first backend.
+func (m *Model) Name() string {
+	return m.backends[0].Model.Name()
+}
+
+// GenerateContent sends the request to the next available backend. If the
+// backend fails before returning any response, the request is sent to the
+// next one.
+func (m *Model) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		tried := make(map[*backendState]bool)
+		var errs []error
+		for {
+			b := m.acquire(tried)
+			if b == nil {
+				errs = append([]error{ErrNoBackendAvailable}, errs...)
+				yield(nil, errors.Join(errs...))
+				return
+			}
+			tried[b] = true
+
+			var (
+				yielded bool
+				tokens  int
+				err     error
+			)
+			for resp, respErr := range b.Model.GenerateContent(ctx, req, stream) {
+				if respErr != nil {
+					err = respErr
+					break
+				}
+				if resp.UsageMetadata != nil {
+					tokens = max(tokens, int(resp.UsageMetadata.TotalTokenCount))
+				}
+				yielded = true
+				if !yield(resp, nil) {
+					m.release(b, tokens, nil)
+					return
+				}
+			}
+			m.release(b, tokens, err)
+			if err == nil {
+				return
+			}
+			if yielded || ctx.Err() != nil {
+				yield(nil, fmt.Errorf("backend %q: %w", b.Name, err))
+				return
+			}
+			errs = append(errs, fmt.Errorf("backend %q: %w", b.Name, err))
+		}
+	}
+}
+
+// CheckHealth checks the health of all backends, e.g. to recover the
+// backends which became unhealthy before their cooldown ends. It returns the
+// errors of the unhealthy backends.
+func (m *Model) CheckHealth(ctx context.Context) error {
+	var errs []error
+	for _, b := range m.backends {
+		err := m.checkHealth(ctx, b.Backend)
+		m.mu.Lock()
+		if err == nil {
+			b.failures = 0
+			if !m.cfg.IsQuotaError(b.lastErr) {
+				b.cooldownUntil = time.Time{}
+			}
+		} else {
+			b.lastErr = err
+			b.failures = max(b.failures, m.cfg.FailureThreshold)
+			errs = append(errs, fmt.Errorf("backend %q: %w", b.Name, err))
+		}
+		m.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Model) checkHealth(ctx context.Context, b Backend) error {
+	if m.cfg.HealthCheck != nil {
+		return m.cfg.HealthCheck(ctx, b)
+	}
+	req := &model.LLMRequest{
+		Model:    b.Model.Name(),
+		Contents: []*genai.Content{genai.NewContentFromText("ping", genai.RoleUser)},
+		Config:   &genai.GenerateContentConfig{MaxOutputTokens: 1},
+	}
+	for _, err := range b.Model.GenerateContent(ctx, req, false) {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats returns the state of the backends, in the order of the config.
+func (m *Model) Stats() []BackendStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	stats := make([]BackendStats, 0, len(m.backends))
+	for _, b := range m.backends {
+		b.resetWindows(now)
+		s := BackendStats{
+			Name:               b.Name,
+			RequestsThisMinute: b.minuteReqs,
+			RequestsToday:      b.dayReqs,
+			TokensThisMinute:   b.minuteTokens,
+			Healthy:            b.failures < m.cfg.FailureThreshold,
+			LastError:          b.lastErr,
+		}
+		if now.Before(b.cooldownUntil) {
+			s.CooldownUntil = b.cooldownUntil
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// acquire returns the next available backend which wasn't tried yet, and
+// counts the request in its quota. It returns nil if there's none.
+func (m *Model) acquire(tried map[*backendState]bool) *backendState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for i := range m.backends {
+		b := m.backends[(m.next+i)%len(m.backends)]
+		if tried[b] || !b.available(now) {
+			continue
+		}
+		m.next = (m.next + i + 1) % len(m.backends)
+		b.minuteReqs++
+		b.dayReqs++
+		return b
+	}
+	return nil
+}
+
+// release records the outcome of a request sent to the backend.
+func (m *Model) release(b *backendState, tokens int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	b.resetWindows(now)
+	b.minuteTokens += tokens
+	if err == nil {
+		b.failures = 0
+		b.quotaFailures = 0
+		return
+	}
+	b.lastErr = err
+	switch {
+	case m.cfg.IsQuotaError(err):
+		b.cooldownUntil = now.Add(m.cooldown(b.quotaFailures))
+		b.quotaFailures++
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+	default:
+		b.failures++
+		if b.failures >= m.cfg.FailureThreshold {
+			b.cooldownUntil = now.Add(m.cooldown(b.failures - m.cfg.FailureThreshold))
+		}
+	}
+}
+
+// cooldown returns the cooldown after the given number of previous
+// failures.
+func (m *Model) cooldown(failures int) time.Duration {
+	d := m.cfg.Cooldown
+	for range failures {
+		if d >= m.cfg.MaxCooldown {
+			break
+		}
+		d *= 2
+	}
+	return min(d, m.cfg.MaxCooldown)
+}
+
+// available reports whether the backend can serve a request.
+func (b *backendState) available(now time.Time) bool {
+	b.resetWindows(now)
+	if now.Before(b.cooldownUntil) {
+		return false
+	}
+	if b.RequestsPerMinute > 0 && b.minuteReqs >= b.RequestsPerMinute {
+		return false
+	}
+	if b.RequestsPerDay > 0 && b.dayReqs >= b.RequestsPerDay {
+		return false
+	}
+	if b.TokensPerMinute > 0 && b.minuteTokens >= b.TokensPerMinute {
+		return false
+	}
+	return true
+}
+
+// resetWindows resets the counters of the quota windows which ended.
+func (b *backendState) resetWindows(now time.Time) {
+	now = now.UTC()
+	if minute := now.Truncate(time.Minute); !minute.Equal(b.minute) {
+		b.minute = minute
+		b.minuteReqs = 0
+		b.minuteTokens = 0
+	}
+	if day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC); !day.Equal(b.day) {
+		b.day = day
+		b.dayReqs = 0
+	}
+}
+
+var _ model.LLM = (*Model)(nil)
@@ -0,0 +1,204 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keypool
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+type fakeModel struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (m *fakeModel) Name() string { return "gemini-test" }
+
+func (m *fakeModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.calls++
+		if m.err != nil {
+			yield(nil, m.err)
+			return
+		}
+		yield(&model.LLMResponse{
+			Content:       genai.NewContentFromText(m.name, genai.RoleModel),
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{TotalTokenCount: 10},
+		}, nil)
+	}
+}
+
+func generate(t *testing.T, m *Model) (string, error) {
+	t.Helper()
+	var text string
+	for resp, err := range m.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+		if err != nil {
+			return "", err
+		}
+		text += resp.Content.Parts[0].Text
+	}
+	return text, nil
+}
+
+func newTestModel(t *testing.T, cfg Config) (*Model, *time.Time) {
+	t.Helper()
+	m, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestModel_RoundRobin(t *testing.T) {
+	a, b := &fakeModel{name: "a"}, &fakeModel{name: "b"}
+	m, _ := newTestModel(t, Config{Backends: []Backend{{Name: "a", Model: a}, {Name: "b", Model: b}}})
+
+	var got []string
+	for range 4 {
+		text, err := generate(t, m)
+		if err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+		got = append(got, text)
+	}
+	want := []string{"a", "b", "a", "b"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("GenerateContent() backends = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestModel_LocalQuota(t *testing.T) {
+	a, b := &fakeModel{name: "a"}, &fakeModel{name: "b"}
+	m, now := newTestModel(t, Config{Backends: []Backend{
+		{Name: "a", Model: a, RequestsPerMinute: 1},
+		{Name: "b", Model: b, RequestsPerDay: 2},
+	}})
+
+	for range 3 {
+		if _, err := generate(t, m); err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+	}
+	if _, err := generate(t, m); !errors.Is(err, ErrNoBackendAvailable) {
+		t.Fatalf("GenerateContent() error = %v, want %v", err, ErrNoBackendAvailable)
+	}
+	if a.calls != 1 || b.calls != 2 {
+		t.Errorf("calls = (%d, %d), want (1, 2)", a.calls, b.calls)
+	}
+
+	*now = now.Add(time.Minute)
+	if text, err := generate(t, m); err != nil || text != "a" {
+		t.Errorf("GenerateContent() after a minute = (%q, %v), want (\"a\", nil)", text, err)
+	}
+	stats := m.Stats()
+	if stats[1].RequestsToday != 2 || stats[0].RequestsThisMinute != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestModel_FailoverOnQuotaError(t *testing.T) {
+	quotaErr := genai.APIError{Code: 429, Status: "RESOURCE_EXHAUSTED"}
+	a, b := &fakeModel{name: "a", err: quotaErr}, &fakeModel{name: "b"}
+	m, now := newTestModel(t, Config{
+		Backends: []Backend{{Name: "a", Model: a}, {Name: "b", Model: b}},
+		Cooldown: 30 * time.Second,
+	})
+
+	for range 3 {
+		if text, err := generate(t, m); err != nil || text != "b" {
+			t.Fatalf("GenerateContent() = (%q, %v), want (\"b\", nil)", text, err)
+		}
+	}
+	if a.calls != 1 {
+		t.Errorf("exhausted backend calls = %d, want 1", a.calls)
+	}
+	if got := m.Stats()[0].CooldownUntil; !got.Equal(now.Add(30 * time.Second)) {
+		t.Errorf("CooldownUntil = %v, want %v", got, now.Add(30*time.Second))
+	}
+
+	// The cooldown doubles when the quota is still exhausted.
+	*now = now.Add(30 * time.Second)
+	generate(t, m)
+	generate(t, m)
+	if a.calls != 2 {
+		t.Errorf("exhausted backend calls = %d, want 2", a.calls)
+	}
+	if got := m.Stats()[0].CooldownUntil; !got.Equal(now.Add(time.Minute)) {
+		t.Errorf("CooldownUntil = %v, want %v", got, now.Add(time.Minute))
+	}
+}
+
+func TestModel_AllBackendsFail(t *testing.T) {
+	backendErr := errors.New("boom")
+	a, b := &fakeModel{name: "a", err: backendErr}, &fakeModel{name: "b", err: backendErr}
+	m, _ := newTestModel(t, Config{
+		Backends:         []Backend{{Name: "a", Model: a}, {Name: "b", Model: b}},
+		FailureThreshold: 2,
+	})
+
+	for range 2 {
+		if _, err := generate(t, m); !errors.Is(err, backendErr) {
+			t.Fatalf("GenerateContent() error = %v, want %v", err, backendErr)
+		}
+	}
+	for _, s := range m.Stats() {
+		if s.Healthy {
+			t.Errorf("backend %q is healthy after %d failures", s.Name, 2)
+		}
+	}
+	if _, err := generate(t, m); !errors.Is(err, ErrNoBackendAvailable) {
+		t.Fatalf("GenerateContent() error = %v, want %v", err, ErrNoBackendAvailable)
+	}
+
+	b.err = nil
+	if err := m.CheckHealth(t.Context()); !errors.Is(err, backendErr) {
+		t.Errorf("CheckHealth() error = %v, want %v", err, backendErr)
+	}
+	if text, err := generate(t, m); err != nil || text != "b" {
+		t.Errorf("GenerateContent() after health check = (%q, %v), want (\"b\", nil)", text, err)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "no backends", cfg: Config{}},
+		{name: "no model", cfg: Config{Backends: []Backend{{Name: "a"}}}},
+		{name: "duplicate name", cfg: Config{Backends: []Backend{
+			{Name: "a", Model: &fakeModel{}},
+			{Name: "a", Model: &fakeModel{}},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Error("New() error = nil, want error")
+			}
+		})
+	}
+}
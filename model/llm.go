@@ -17,7 +17,9 @@ package model
 
 import (
 	"context"
+	"errors"
 	"iter"
+	"net/http"
 
 	"google.golang.org/genai"
 )
@@ -33,8 +35,15 @@ type LLMRequest struct {
 	Model    string
 	Contents []*genai.Content
 	Config   *genai.GenerateContentConfig
+	// LiveConnectConfig is the config of the live (bidi) connection, for the
+	// models which support it.
+	LiveConnectConfig *genai.LiveConnectConfig
 
 	Tools map[string]any `json:"-"`
+	// Sensitive are the segments of the instructions marked with
+	// [Sensitive]. They are sent to the model, but redacted from the
+	// telemetry and the debug logs.
+	Sensitive []string `json:"-"`
 }
 
 // LLMResponse is the raw LLM response.
@@ -50,13 +59,71 @@ type LLMResponse struct {
 	// Only used for streaming mode and when the content is plain text.
 	Partial bool
 	// Indicates whether the response from the model is complete.
-	// Only used for streaming mode.
+	// Only used for streaming mode. In live (bidi) mode, it is set once the
+	// model finished speaking, possibly on a response without content.
 	TurnComplete bool
 	// Flag indicating that LLM was interrupted when generating the content.
-	// Usually it is due to user interruption during a bidi streaming.
+	// Usually it is due to user interruption during a bidi streaming: the
+	// client should stop playing the model output it buffered (barge-in).
 	Interrupted  bool
 	ErrorCode    string
 	ErrorMessage string
+	// Blocked is set when the prompt or the response was blocked by the
+	// safety filters of the model. The content, if any, is the part of the
+	// response generated before the block.
+	Blocked *SafetyBlock
+	// Thought indicates that the content only holds thought parts, i.e. the
+	// reasoning of the model (see genai.Part.Thought), and no text meant for
+	// the end user.
+	Thought      bool
 	FinishReason genai.FinishReason
 	AvgLogprobs  float64
+	// InputTranscription is the transcription of the user audio input, in
+	// live (bidi) mode.
+	InputTranscription *genai.Transcription
+	// OutputTranscription is the transcription of the model audio output,
+	// in live (bidi) mode.
+	OutputTranscription *genai.Transcription
+}
+
+// CallError is returned by the agents when a model call fails. Its message is
+// the message of the error of the model.
+type CallError struct {
+	Model string
+	Err   error
+}
+
+func (e *CallError) Error() string { return e.Err.Error() }
+
+func (e *CallError) Unwrap() error { return e.Err }
+
+// Is reports whether the call was rate limited, so that
+// errors.Is(err, ErrModelRateLimited) matches the errors of the rate-limited
+// calls of all the models.
+func (e *CallError) Is(target error) bool {
+	return target == ErrModelRateLimited && IsRateLimited(e.Err)
+}
+
+// ErrModelRateLimited is matched by the errors of the model calls rejected
+// because of the rate limits or quota of the model, e.g. the Gemini API
+// errors with the status 429 (RESOURCE_EXHAUSTED).
+var ErrModelRateLimited = errors.New("model rate limited")
+
+// IsRateLimited reports whether the error returned by a model means that the
+// call was rejected because of the rate limits or quota of the model: the
+// error matches ErrModelRateLimited, or is a Gemini API error with the status
+// 429 (RESOURCE_EXHAUSTED).
+func IsRateLimited(err error) bool {
+	if errors.Is(err, ErrModelRateLimited) {
+		return true
+	}
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests
+	}
+	var apiErrPtr *genai.APIError
+	if errors.As(err, &apiErrPtr) && apiErrPtr != nil {
+		return apiErrPtr.Code == http.StatusTooManyRequests
+	}
+	return false
 }
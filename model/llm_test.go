@@ -15,6 +15,9 @@
 package model_test
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"reflect"
 	"testing"
 
@@ -108,6 +111,11 @@ func TestCreateResponse(t *testing.T) {
 			want: model.LLMResponse{
 				ErrorCode:    string(FinishReasonSafety),
 				ErrorMessage: "Safety filter triggered",
+				Blocked: &model.SafetyBlock{
+					Source:  model.BlockedResponse,
+					Reason:  string(FinishReasonSafety),
+					Message: "Safety filter triggered",
+				},
 				AvgLogprobs:  -2.1,
 				FinishReason: FinishReasonSafety,
 			},
@@ -124,6 +132,37 @@ func TestCreateResponse(t *testing.T) {
 			want: model.LLMResponse{
 				ErrorCode:    string(BlockedReasonSafety),
 				ErrorMessage: "Prompt blocked for safety",
+				Blocked: &model.SafetyBlock{
+					Source:  model.BlockedPrompt,
+					Reason:  string(BlockedReasonSafety),
+					Message: "Prompt blocked for safety",
+				},
+			},
+		},
+		{
+			name: "CreateBlockedWithSafetyRatings",
+			input: genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{{
+					FinishReason: FinishReasonSafety,
+					SafetyRatings: []*genai.SafetyRating{
+						{Category: genai.HarmCategoryHarassment, Probability: genai.HarmProbabilityLow},
+						{Category: genai.HarmCategoryDangerousContent, Probability: genai.HarmProbabilityHigh, Blocked: true},
+					},
+				}},
+			},
+			want: model.LLMResponse{
+				ErrorCode:    string(FinishReasonSafety),
+				ErrorMessage: "response blocked by the safety filters of the model: SAFETY (HARM_CATEGORY_DANGEROUS_CONTENT)",
+				Blocked: &model.SafetyBlock{
+					Source:     model.BlockedResponse,
+					Reason:     string(FinishReasonSafety),
+					Categories: []genai.HarmCategory{genai.HarmCategoryDangerousContent},
+					Ratings: []*genai.SafetyRating{
+						{Category: genai.HarmCategoryHarassment, Probability: genai.HarmProbabilityLow},
+						{Category: genai.HarmCategoryDangerousContent, Probability: genai.HarmProbabilityHigh, Blocked: true},
+					},
+				},
+				FinishReason: FinishReasonSafety,
 			},
 		},
 		{
@@ -198,8 +237,13 @@ func TestCreateResponse(t *testing.T) {
 				}},
 			},
 			want: model.LLMResponse{
-				ErrorCode:        string(FinishReasonRecitation),
-				ErrorMessage:     "Response blocked due to recitation triggered",
+				ErrorCode:    string(FinishReasonRecitation),
+				ErrorMessage: "Response blocked due to recitation triggered",
+				Blocked: &model.SafetyBlock{
+					Source:  model.BlockedResponse,
+					Reason:  string(FinishReasonRecitation),
+					Message: "Response blocked due to recitation triggered",
+				},
 				CitationMetadata: citationMeta,
 				FinishReason:     FinishReasonRecitation,
 			},
@@ -235,9 +279,114 @@ func TestCreateResponse(t *testing.T) {
 				t.Errorf("*genai.LogprobsResult mismatch: want %+v, got %+v", tc.want.LogprobsResult, got.LogprobsResult)
 			}
 
+			if !reflect.DeepEqual(got.Blocked, tc.want.Blocked) {
+				t.Errorf("Blocked mismatch: want %+v, got %+v", tc.want.Blocked, got.Blocked)
+			}
+
 			if !reflect.DeepEqual(got.CitationMetadata, tc.want.CitationMetadata) {
 				t.Errorf("CitationMetadata mismatch: want %+v, got %+v", tc.want.CitationMetadata, got.CitationMetadata)
 			}
 		})
 	}
 }
+
+func TestLiveServerMessage2LLMResponse(t *testing.T) {
+	text := genai.NewContentFromText("Hello", genai.RoleModel)
+	call := &genai.FunctionCall{ID: "call-1", Name: "get_weather", Args: map[string]any{"city": "Paris"}}
+	for _, tc := range []struct {
+		name  string
+		input *genai.LiveServerMessage
+		want  *model.LLMResponse
+	}{
+		{
+			name:  "setup complete",
+			input: &genai.LiveServerMessage{SetupComplete: &genai.LiveServerSetupComplete{}},
+		},
+		{
+			name:  "model turn",
+			input: &genai.LiveServerMessage{ServerContent: &genai.LiveServerContent{ModelTurn: text}},
+			want:  &model.LLMResponse{Content: text, Partial: true},
+		},
+		{
+			name:  "last content of the turn",
+			input: &genai.LiveServerMessage{ServerContent: &genai.LiveServerContent{ModelTurn: text, TurnComplete: true}},
+			want:  &model.LLMResponse{Content: text, TurnComplete: true},
+		},
+		{
+			name:  "turn complete",
+			input: &genai.LiveServerMessage{ServerContent: &genai.LiveServerContent{TurnComplete: true}},
+			want:  &model.LLMResponse{TurnComplete: true},
+		},
+		{
+			name:  "interrupted",
+			input: &genai.LiveServerMessage{ServerContent: &genai.LiveServerContent{Interrupted: true}},
+			want:  &model.LLMResponse{Interrupted: true},
+		},
+		{
+			name: "transcription",
+			input: &genai.LiveServerMessage{ServerContent: &genai.LiveServerContent{
+				OutputTranscription: &genai.Transcription{Text: "Hello"},
+			}},
+			want: &model.LLMResponse{OutputTranscription: &genai.Transcription{Text: "Hello"}},
+		},
+		{
+			name: "tool call",
+			input: &genai.LiveServerMessage{
+				ToolCall:      &genai.LiveServerToolCall{FunctionCalls: []*genai.FunctionCall{call}},
+				UsageMetadata: &genai.UsageMetadata{PromptTokenCount: 10, ResponseTokenCount: 5, TotalTokenCount: 15},
+			},
+			want: &model.LLMResponse{
+				Content:       genai.NewContentFromParts([]*genai.Part{{FunctionCall: call}}, genai.RoleModel),
+				UsageMetadata: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 10, CandidatesTokenCount: 5, TotalTokenCount: 15},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := converters.LiveServerMessage2LLMResponse(tc.input)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("LiveServerMessage2LLMResponse() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsRateLimited(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "429", err: genai.APIError{Code: http.StatusTooManyRequests}, want: true},
+		{name: "wrapped 429", err: fmt.Errorf("backend: %w", &genai.APIError{Code: http.StatusTooManyRequests}), want: true},
+		{name: "rate limited call", err: &model.CallError{Err: genai.APIError{Code: http.StatusTooManyRequests}}, want: true},
+		{name: "500", err: genai.APIError{Code: http.StatusInternalServerError}},
+		{name: "other", err: errors.New("boom")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := model.IsRateLimited(tt.err); got != tt.want {
+				t.Errorf("IsRateLimited(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallError_RateLimited(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "429", err: &model.CallError{Err: genai.APIError{Code: http.StatusTooManyRequests}}, want: true},
+		{name: "wrapped 429", err: fmt.Errorf("agent: %w", &model.CallError{Err: &genai.APIError{Code: http.StatusTooManyRequests}}), want: true},
+		{name: "500", err: &model.CallError{Err: genai.APIError{Code: http.StatusInternalServerError}}},
+		{name: "not a model call", err: genai.APIError{Code: http.StatusTooManyRequests}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, model.ErrModelRateLimited); got != tt.want {
+				t.Errorf("errors.Is(%v, ErrModelRateLimited) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
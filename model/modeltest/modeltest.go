@@ -0,0 +1,192 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package modeltest provides a scripted [model.LLM] to unit test agents
+// without calling a real model.
+//
+// The model returns the scripted responses in order, one per call, and
+// records the requests, e.g.
+//
+//	m := modeltest.New(modeltest.Config{Responses: []modeltest.Response{
+//		modeltest.FunctionCall("get_weather", map[string]any{"city": "Paris"}),
+//		modeltest.Text("It's sunny in Paris."),
+//	}})
+//	a, _ := llmagent.New(llmagent.Config{Name: "weather", Model: m, Tools: tools})
+//	// Run the agent, then:
+//	m.ExpectExhausted(t)
+package modeltest
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Response is a scripted response of the model.
+type Response struct {
+	// Content of the response.
+	Content *genai.Content
+	// Chunks are the texts of the partial responses streamed before the
+	// complete response, in streaming mode.
+	// Optional: if empty, only the complete response is streamed.
+	Chunks []string
+	// Usage is reported with the complete response.
+	// Optional.
+	Usage *genai.GenerateContentResponseUsageMetadata
+	// Err fails the call, if set.
+	Err error
+}
+
+// Text returns the response with the text. In streaming mode, the text is
+// streamed word by word.
+func Text(text string) Response {
+	return Response{
+		Content: genai.NewContentFromText(text, genai.RoleModel),
+		Chunks:  strings.SplitAfter(text, " "),
+	}
+}
+
+// FunctionCall returns the response calling the function with the
+// arguments.
+func FunctionCall(name string, args map[string]any) Response {
+	return Response{Content: genai.NewContentFromFunctionCall(name, args, genai.RoleModel)}
+}
+
+// Error returns the response failing the call with err.
+func Error(err error) Response {
+	return Response{Err: err}
+}
+
+// Config configures a Model.
+type Config struct {
+	// Name of the model.
+	// Optional: defaults to "modeltest".
+	Name string
+	// Responses are returned in order, one per call. A call after the last
+	// response fails.
+	Responses []Response
+}
+
+// Model is a scripted [model.LLM]. It is safe for concurrent use.
+type Model struct {
+	name string
+
+	mu        sync.Mutex
+	responses []Response
+	requests  []*model.LLMRequest
+}
+
+// New creates a Model.
+func New(cfg Config) *Model {
+	name := cfg.Name
+	if name == "" {
+		name = "modeltest"
+	}
+	return &Model{name: name, responses: append([]Response(nil), cfg.Responses...)}
+}
+
+// Name implements model.LLM.
+func (m *Model) Name() string {
+	return m.name
+}
+
+// GenerateContent implements model.LLM. It returns the next scripted
+// response.
+func (m *Model) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		resp, err := m.next(req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if resp.Err != nil {
+			yield(nil, resp.Err)
+			return
+		}
+		if stream && len(resp.Chunks) > 1 {
+			for _, chunk := range resp.Chunks {
+				partial := &model.LLMResponse{Content: genai.NewContentFromText(chunk, genai.RoleModel), Partial: true}
+				if !yield(partial, nil) {
+					return
+				}
+			}
+		}
+		yield(&model.LLMResponse{Content: resp.Content, UsageMetadata: resp.Usage, TurnComplete: true}, nil)
+	}
+}
+
+func (m *Model) next(req *model.LLMRequest) (Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, req)
+	if len(m.responses) == 0 {
+		return Response{}, fmt.Errorf("modeltest: no scripted response left for call %d", len(m.requests))
+	}
+	resp := m.responses[0]
+	m.responses = m.responses[1:]
+	return resp, nil
+}
+
+// Add appends responses to the script.
+func (m *Model) Add(responses ...Response) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responses = append(m.responses, responses...)
+}
+
+// Requests returns the requests the model received, in order.
+func (m *Model) Requests() []*model.LLMRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*model.LLMRequest(nil), m.requests...)
+}
+
+// LastRequest returns the last request the model received, or nil if it
+// wasn't called.
+func (m *Model) LastRequest() *model.LLMRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.requests) == 0 {
+		return nil
+	}
+	return m.requests[len(m.requests)-1]
+}
+
+// ExpectCalls fails the test if the model wasn't called n times.
+func (m *Model) ExpectCalls(t testing.TB, n int) {
+	t.Helper()
+	if got := len(m.Requests()); got != n {
+		t.Errorf("model %q was called %d times, want %d", m.name, got, n)
+	}
+}
+
+// ExpectExhausted fails the test if some scripted responses weren't
+// returned.
+func (m *Model) ExpectExhausted(t testing.TB) {
+	t.Helper()
+	m.mu.Lock()
+	left := len(m.responses)
+	m.mu.Unlock()
+	if left > 0 {
+		t.Errorf("model %q has %d scripted responses left", m.name, left)
+	}
+}
+
+var _ model.LLM = (*Model)(nil)
@@ -0,0 +1,84 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modeltest_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+func TestModel(t *testing.T) {
+	weather, err := functiontool.New(functiontool.Config{Name: "get_weather", Description: "returns the weather"},
+		func(_ tool.Context, args map[string]any) (map[string]any, error) {
+			return map[string]any{"weather": "sunny"}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := modeltest.New(modeltest.Config{Responses: []modeltest.Response{
+		modeltest.FunctionCall("get_weather", map[string]any{"city": "Paris"}),
+		modeltest.Text("It's sunny."),
+	}})
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, Tools: []tool.Tool{weather}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	stream := testutil.NewTestAgentRunner(t, a).RunContentWithConfig(t, "session", genai.NewContentFromText("weather in Paris?", genai.RoleUser), agent.RunConfig{StreamingMode: agent.StreamingModeSSE})
+	for ev, err := range stream {
+		if err != nil {
+			t.Fatal(err)
+		}
+		part := ev.Content.Parts[0]
+		switch {
+		case part.FunctionCall != nil:
+			got = append(got, "call:"+part.FunctionCall.Name)
+		case part.FunctionResponse != nil:
+			got = append(got, "response:"+part.FunctionResponse.Name)
+		case ev.Partial:
+			got = append(got, "partial:"+part.Text)
+		default:
+			got = append(got, part.Text)
+		}
+	}
+	want := []string{"call:get_weather", "response:get_weather", "partial:It's ", "partial:sunny.", "It's sunny."}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("events mismatch (-want +got):\n%s", diff)
+	}
+	m.ExpectCalls(t, 2)
+	m.ExpectExhausted(t)
+	if n := len(m.LastRequest().Contents); n != 3 {
+		t.Errorf("last request has %d contents, want the user input, the call and the response", n)
+	}
+
+	// The calls after the script fail, and so do the scripted errors.
+	m.Add(modeltest.Error(errors.New("overloaded")))
+	for _, want := range []string{"overloaded", "modeltest: no scripted response left for call 4"} {
+		_, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "again"))
+		if err == nil || err.Error() != want {
+			t.Errorf("run error = %v, want %q", err, want)
+		}
+	}
+}
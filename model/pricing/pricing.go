@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pricing estimates the cost of the model calls from their usage
+// metadata and a table of the prices of the models.
+//
+// The cost function of a table plugs into the hooks computing the costs,
+// e.g. the invocation summary of the runner, which then reports the cost of
+// each invocation on its final event:
+//
+//	table := pricing.Table{
+//		"gemini-2.5-flash": {Input: 0.30, CachedInput: 0.03, Output: 2.50},
+//	}
+//	r, err := runner.New(runner.Config{
+//		...
+//		InvocationSummary: &runner.InvocationSummaryConfig{Cost: table.CostFunc(rootAgent)},
+//	})
+package pricing
+
+import (
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/genai"
+)
+
+// Rates are the prices of a model in USD per million tokens.
+type Rates struct {
+	// Input is the price of the prompt tokens, including the prompt tokens
+	// of the tool use.
+	Input float64 `json:"input" yaml:"input"`
+	// CachedInput is the price of the prompt tokens served from the context
+	// cache.
+	// Optional: if zero, they cost Input.
+	CachedInput float64 `json:"cached_input,omitempty" yaml:"cached_input"`
+	// Output is the price of the output tokens: the candidates and the
+	// thoughts tokens.
+	Output float64 `json:"output" yaml:"output"`
+
+	// LongContextThreshold is the number of prompt tokens above which the
+	// LongContext rates apply, for the models priced by tiers.
+	// Optional: if zero, the rates don't depend on the size of the prompt.
+	LongContextThreshold int32 `json:"long_context_threshold,omitempty" yaml:"long_context_threshold"`
+	// LongContext are the rates of the prompts above LongContextThreshold.
+	LongContext *Rates `json:"long_context,omitempty" yaml:"long_context"`
+}
+
+// Cost returns the cost in USD of a model call with the given usage.
+func (r Rates) Cost(usage *genai.GenerateContentResponseUsageMetadata) float64 {
+	if usage == nil {
+		return 0
+	}
+	prompt := int64(usage.PromptTokenCount) + int64(usage.ToolUsePromptTokenCount)
+	if r.LongContextThreshold > 0 && r.LongContext != nil && prompt > int64(r.LongContextThreshold) {
+		r = *r.LongContext
+	}
+	cached := min(int64(usage.CachedContentTokenCount), prompt)
+	output := int64(usage.CandidatesTokenCount) + int64(usage.ThoughtsTokenCount)
+	cachedRate := r.CachedInput
+	if cachedRate == 0 {
+		cachedRate = r.Input
+	}
+	return (float64(prompt-cached)*r.Input + float64(cached)*cachedRate + float64(output)*r.Output) / 1e6
+}
+
+// Table maps the names of the models to their rates. A name ending with
+// "*" matches the models whose name starts with the rest, e.g.
+// "gemini-2.5-flash*"; the exact names take precedence, then the longest
+// patterns.
+type Table map[string]Rates
+
+// Rates returns the rates of the model, ignoring the "models/" prefix of
+// its name.
+func (t Table) Rates(modelName string) (Rates, bool) {
+	modelName = strings.TrimPrefix(modelName, "models/")
+	if r, ok := t[modelName]; ok {
+		return r, true
+	}
+	var (
+		best   Rates
+		prefix = -1
+	)
+	for name, r := range t {
+		p, ok := strings.CutSuffix(name, "*")
+		if ok && strings.HasPrefix(modelName, p) && len(p) > prefix {
+			best, prefix = r, len(p)
+		}
+	}
+	return best, prefix >= 0
+}
+
+// Cost returns the cost in USD of a call of the model with the given usage,
+// or false if the table has no rates for the model.
+func (t Table) Cost(modelName string, usage *genai.GenerateContentResponseUsageMetadata) (float64, bool) {
+	r, ok := t.Rates(modelName)
+	if !ok {
+		return 0, false
+	}
+	return r.Cost(usage), true
+}
+
+// CostFunc returns a function computing the cost in USD of the model calls
+// of the agents of the tree of root from the rates of their models, e.g.
+// for runner.InvocationSummaryConfig.Cost or bigqueryanalytics.Config.Cost.
+// The calls of the agents without a model, or whose model isn't in the
+// table, cost zero.
+func (t Table) CostFunc(root agent.Agent) func(agentName string, usage *genai.GenerateContentResponseUsageMetadata) float64 {
+	models := make(map[string]string)
+	var walk func(a agent.Agent)
+	walk = func(a agent.Agent) {
+		if llmAgent, ok := a.(llminternal.Agent); ok {
+			if m := llminternal.Reveal(llmAgent).Model; m != nil {
+				models[a.Name()] = m.Name()
+			}
+		}
+		for _, sub := range a.SubAgents() {
+			walk(sub)
+		}
+	}
+	if root != nil {
+		walk(root)
+	}
+	return func(agentName string, usage *genai.GenerateContentResponseUsageMetadata) float64 {
+		modelName, ok := models[agentName]
+		if !ok {
+			return 0
+		}
+		cost, _ := t.Cost(modelName, usage)
+		return cost
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pricing_test
+
+import (
+	"math"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/model/pricing"
+	"google.golang.org/genai"
+)
+
+func TestTable(t *testing.T) {
+	table := pricing.Table{
+		"gemini-2.5-flash":  {Input: 1, CachedInput: 0.1, Output: 10},
+		"gemini-2.5-*":      {Input: 2, Output: 20},
+		"gemini-2.5-flash*": {Input: 3, Output: 30},
+		"gemini-2.5-pro": {
+			Input: 1, Output: 10,
+			LongContextThreshold: 200_000,
+			LongContext:          &pricing.Rates{Input: 2, Output: 15},
+		},
+	}
+	usage := &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        1_000_000,
+		CachedContentTokenCount: 500_000,
+		CandidatesTokenCount:    100_000,
+		ThoughtsTokenCount:      100_000,
+	}
+	tests := []struct {
+		model  string
+		want   float64
+		wantOK bool
+	}{
+		{model: "gemini-2.5-flash", want: 0.5 + 0.05 + 2, wantOK: true},
+		{model: "models/gemini-2.5-flash", want: 0.5 + 0.05 + 2, wantOK: true},
+		{model: "gemini-2.5-flash-lite", want: 3 + 6, wantOK: true},
+		{model: "gemini-2.5-other", want: 2 + 4, wantOK: true},
+		{model: "gemini-2.5-pro", want: 2 + 3, wantOK: true},
+		{model: "gemini-2.0-flash", want: 0, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := table.Cost(tt.model, usage)
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Cost() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	small := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 200_000, CandidatesTokenCount: 1000}
+	if got, _ := table.Cost("gemini-2.5-pro", small); math.Abs(got-0.21) > 1e-9 {
+		t.Errorf("Cost() below the long context threshold = %v, want 0.21", got)
+	}
+}
+
+func TestTable_CostFunc(t *testing.T) {
+	sub, err := llmagent.New(llmagent.Config{Name: "sub", Model: modeltest.New(modeltest.Config{Name: "cheap"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := llmagent.New(llmagent.Config{
+		Name:      "root",
+		Model:     modeltest.New(modeltest.Config{Name: "expensive"}),
+		SubAgents: []agent.Agent{sub},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cost := pricing.Table{
+		"cheap":     {Input: 1, Output: 1},
+		"expensive": {Input: 10, Output: 10},
+	}.CostFunc(root)
+
+	usage := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 100_000, CandidatesTokenCount: 100_000}
+	for agentName, want := range map[string]float64{"root": 2, "sub": 0.2, "unknown": 0} {
+		if got := cost(agentName, usage); math.Abs(got-want) > 1e-9 {
+			t.Errorf("cost(%q) = %v, want %v", agentName, got, want)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rediscache provides a [model.ResponseCache] storing the responses
+// of the models in Redis, so that they are shared by the replicas of an
+// application or across the runs of the evals:
+//
+//	cache, err := rediscache.New(rediscache.Config{Addr: "localhost:6379", TTL: time.Hour})
+//	...
+//	llm = model.WithCache(llm, cache)
+package rediscache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/model"
+)
+
+// Default values of the [Config] fields.
+const (
+	DefaultPrefix       = "adk:llm:"
+	DefaultMaxIdleConns = 4
+)
+
+// Config is used to create a [Cache].
+type Config struct {
+	// Addr is the address of the Redis server, e.g. "localhost:6379".
+	Addr string
+	// Username and Password authenticate the connections.
+	// Optional: if Password is empty, the connections aren't authenticated.
+	Username string
+	Password string
+	// DB is the number of the database.
+	// Optional: defaults to 0.
+	DB int
+	// TLSConfig enables TLS for the connections.
+	// Optional: if nil, the connections are not encrypted.
+	TLSConfig *tls.Config
+	// Prefix is prepended to the keys of the cache.
+	// Optional: defaults to [DefaultPrefix].
+	Prefix string
+	// TTL is how long the responses are cached.
+	// Optional: if zero, they don't expire.
+	TTL time.Duration
+	// MaxIdleConns is the number of connections kept open between the
+	// requests.
+	// Optional: defaults to [DefaultMaxIdleConns].
+	MaxIdleConns int
+}
+
+// Cache is a [model.ResponseCache] storing the responses in Redis. It is
+// safe for concurrent use.
+type Cache struct {
+	cfg Config
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+var _ model.ResponseCache = (*Cache)(nil)
+
+// New returns a cache storing the responses in the Redis server at
+// cfg.Addr. The connections are opened on demand.
+func New(cfg Config) (*Cache, error) {
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("redis address is required")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultPrefix
+	}
+	if cfg.MaxIdleConns <= 0 {
+		cfg.MaxIdleConns = DefaultMaxIdleConns
+	}
+	return &Cache{cfg: cfg}, nil
+}
+
+// Get implements model.ResponseCache.
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.do(ctx, "GET", c.cfg.Prefix+key)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get %q: %w", key, err)
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	b, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("failed to get %q: unexpected reply %v", key, reply)
+	}
+	return b, true, nil
+}
+
+// Set implements model.ResponseCache.
+func (c *Cache) Set(ctx context.Context, key string, value []byte) error {
+	args := []string{"SET", c.cfg.Prefix + key, string(value)}
+	if c.cfg.TTL > 0 {
+		args = append(args, "PX", strconv.FormatInt(max(c.cfg.TTL.Milliseconds(), 1), 10))
+	}
+	if _, err := c.do(ctx, args...); err != nil {
+		return fmt.Errorf("failed to set %q: %w", key, err)
+	}
+	return nil
+}
+
+// Close closes the idle connections. The cache must not be used after.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	idle := c.idle
+	c.idle, c.closed = nil, true
+	c.mu.Unlock()
+	var errs []error
+	for _, cn := range idle {
+		errs = append(errs, cn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// redisError is an error reply of the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// do sends a command and returns its reply: nil, a string, an int64, a
+// []byte or a []any. The connection is discarded after an I/O error.
+func (c *Cache) do(ctx context.Context, args ...string) (any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := cn.roundTrip(ctx, args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		cn.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+func (c *Cache) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("cache is closed")
+	}
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+	return c.dial(ctx)
+}
+
+func (c *Cache) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || len(c.idle) >= c.cfg.MaxIdleConns {
+		cn.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+func (c *Cache) dial(ctx context.Context) (*conn, error) {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", c.cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", c.cfg.Addr, err)
+	}
+	if c.cfg.TLSConfig != nil {
+		tc := tls.Client(nc, c.cfg.TLSConfig)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("failed to connect to %s: %w", c.cfg.Addr, err)
+		}
+		nc = tc
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	if c.cfg.Password != "" {
+		args := []string{"AUTH", c.cfg.Password}
+		if c.cfg.Username != "" {
+			args = []string{"AUTH", c.cfg.Username, c.cfg.Password}
+		}
+		if _, err := cn.roundTrip(ctx, args...); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+	if c.cfg.DB != 0 {
+		if _, err := cn.roundTrip(ctx, "SELECT", strconv.Itoa(c.cfg.DB)); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("failed to select the database %d: %w", c.cfg.DB, err)
+		}
+	}
+	return cn, nil
+}
+
+func (cn *conn) roundTrip(ctx context.Context, args ...string) (any, error) {
+	deadline, _ := ctx.Deadline()
+	if err := cn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(cn.w, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(cn.w, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if err := cn.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(cn.r)
+}
+
+// readReply reads a reply in the Redis serialization protocol (RESP2).
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, line := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return line, nil
+	case '-':
+		return nil, redisError(line)
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("malformed reply %q", line)
+}
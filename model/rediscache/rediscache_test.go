@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rediscache
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves GET, SET, AUTH and SELECT over the Redis protocol.
+type fakeRedis struct {
+	addr     string
+	password string
+
+	mu       sync.Mutex
+	data     map[string]string
+	commands []string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	s := &fakeRedis{addr: l.Addr().String(), password: password, data: make(map[string]string)}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+func (s *fakeRedis) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	authenticated := s.password == ""
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, a := range reply.([]any) {
+			args = append(args, string(a.([]byte)))
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, strings.Join(args, " "))
+		var resp string
+		switch {
+		case args[0] == "AUTH":
+			authenticated = args[len(args)-1] == s.password
+			resp = "+OK\r\n"
+			if !authenticated {
+				resp = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			resp = "-NOAUTH Authentication required\r\n"
+		case args[0] == "SELECT":
+			resp = "+OK\r\n"
+		case args[0] == "SET":
+			s.data[args[1]] = args[2]
+			resp = "+OK\r\n"
+		case args[0] == "GET":
+			v, ok := s.data[args[1]]
+			resp = "$-1\r\n"
+			if ok {
+				resp = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			}
+		default:
+			resp = "-ERR unknown command\r\n"
+		}
+		s.mu.Unlock()
+		if _, err := c.Write([]byte(resp)); err != nil {
+			return
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	s := newFakeRedis(t, "secret")
+	cache, err := New(Config{Addr: s.addr, Password: "secret", DB: 2, TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	if _, ok, err := cache.Get(t.Context(), "missing"); err != nil || ok {
+		t.Errorf("Get(missing) = %v, %v, want no value", ok, err)
+	}
+	value := "line1\r\nline2"
+	if err := cache.Set(t.Context(), "key", []byte(value)); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, ok, err := cache.Get(t.Context(), "key"); err != nil || !ok || string(got) != value {
+		t.Errorf("Get(key) = %q, %v, %v, want %q", got, ok, err, value)
+	}
+
+	s.mu.Lock()
+	commands := strings.Join(s.commands, "\n")
+	s.mu.Unlock()
+	want := "AUTH secret\nSELECT 2\nGET adk:llm:missing\nSET adk:llm:key " + value + " PX 60000\nGET adk:llm:key"
+	if commands != want {
+		t.Errorf("commands =\n%s\nwant\n%s", commands, want)
+	}
+
+	bad, err := New(Config{Addr: s.addr, Password: "wrong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bad.Close()
+	if _, _, err := bad.Get(t.Context(), "key"); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Get() with a wrong password error = %v, want WRONGPASS", err)
+	}
+
+	if _, err := New(Config{}); err == nil {
+		t.Error("New() without an address succeeded, want error")
+	}
+}
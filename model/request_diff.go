@@ -0,0 +1,197 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// RequestDiff describes the changes between two successive requests to the
+// model, e.g. two steps of an agent within an invocation. Between steps, the
+// contents are expected to only grow: removed contents and changes of the
+// system instruction or of the tools invalidate the prompt cache.
+type RequestDiff struct {
+	// KeptContents is the number of leading contents shared by both requests.
+	KeptContents int
+	// RemovedContents are the contents of the previous request after the
+	// shared ones.
+	RemovedContents []*genai.Content
+	// AddedContents are the contents of the current request after the shared
+	// ones.
+	AddedContents []*genai.Content
+
+	// SystemInstructionChanged reports whether the system instruction
+	// changed, from PrevSystemInstruction to SystemInstruction.
+	SystemInstructionChanged                 bool
+	PrevSystemInstruction, SystemInstruction string
+
+	// AddedTools, RemovedTools and ChangedTools are the names of the function
+	// declarations which were added, removed or changed.
+	AddedTools, RemovedTools, ChangedTools []string
+
+	// sensitive are the sensitive segments of both requests, redacted from
+	// the summary.
+	sensitive []string
+}
+
+// DiffRequests returns the changes from prev to cur.
+func DiffRequests(prev, cur *LLMRequest) *RequestDiff {
+	d := &RequestDiff{}
+
+	var prevContents, curContents []*genai.Content
+	if prev != nil {
+		prevContents = prev.Contents
+	}
+	if cur != nil {
+		curContents = cur.Contents
+	}
+	for d.KeptContents < min(len(prevContents), len(curContents)) &&
+		reflect.DeepEqual(prevContents[d.KeptContents], curContents[d.KeptContents]) {
+		d.KeptContents++
+	}
+	d.RemovedContents = prevContents[d.KeptContents:]
+	d.AddedContents = curContents[d.KeptContents:]
+
+	d.PrevSystemInstruction = systemInstruction(prev)
+	d.SystemInstruction = systemInstruction(cur)
+	d.SystemInstructionChanged = d.PrevSystemInstruction != d.SystemInstruction
+	if prev != nil {
+		d.sensitive = append(d.sensitive, prev.Sensitive...)
+	}
+	if cur != nil {
+		d.sensitive = append(d.sensitive, cur.Sensitive...)
+	}
+	d.PrevSystemInstruction = RedactSensitive(d.PrevSystemInstruction, d.sensitive)
+	d.SystemInstruction = RedactSensitive(d.SystemInstruction, d.sensitive)
+
+	prevTools, curTools := functionDeclarations(prev), functionDeclarations(cur)
+	for _, name := range slices.Sorted(maps.Keys(curTools)) {
+		prevDecl, ok := prevTools[name]
+		switch {
+		case !ok:
+			d.AddedTools = append(d.AddedTools, name)
+		case !reflect.DeepEqual(prevDecl, curTools[name]):
+			d.ChangedTools = append(d.ChangedTools, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(prevTools)) {
+		if _, ok := curTools[name]; !ok {
+			d.RemovedTools = append(d.RemovedTools, name)
+		}
+	}
+	return d
+}
+
+// Perturbed reports whether the previous request isn't a prefix of the
+// current one, i.e. contents were removed or the system instruction or the
+// tools changed.
+func (d *RequestDiff) Perturbed() bool {
+	return len(d.RemovedContents) > 0 || d.SystemInstructionChanged ||
+		len(d.AddedTools) > 0 || len(d.RemovedTools) > 0 || len(d.ChangedTools) > 0
+}
+
+// String returns a one-line summary of the diff.
+func (d *RequestDiff) String() string {
+	parts := []string{fmt.Sprintf("contents: %d kept, %d removed, %d added", d.KeptContents, len(d.RemovedContents), len(d.AddedContents))}
+	if len(d.RemovedContents) > 0 {
+		parts = append(parts, fmt.Sprintf("content %d changed: %s -> %s", d.KeptContents, summarize(d.RemovedContents[0], d.sensitive), summarizeAt(d.AddedContents, 0, d.sensitive)))
+	}
+	if d.SystemInstructionChanged {
+		parts = append(parts, fmt.Sprintf("system instruction changed: %q -> %q", truncate(d.PrevSystemInstruction), truncate(d.SystemInstruction)))
+	}
+	if len(d.AddedTools) > 0 {
+		parts = append(parts, fmt.Sprintf("tools added: %s", strings.Join(d.AddedTools, ", ")))
+	}
+	if len(d.RemovedTools) > 0 {
+		parts = append(parts, fmt.Sprintf("tools removed: %s", strings.Join(d.RemovedTools, ", ")))
+	}
+	if len(d.ChangedTools) > 0 {
+		parts = append(parts, fmt.Sprintf("tools changed: %s", strings.Join(d.ChangedTools, ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func systemInstruction(req *LLMRequest) string {
+	if req == nil || req.Config == nil || req.Config.SystemInstruction == nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range req.Config.SystemInstruction.Parts {
+		if p != nil && p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func functionDeclarations(req *LLMRequest) map[string]*genai.FunctionDeclaration {
+	decls := make(map[string]*genai.FunctionDeclaration)
+	if req == nil || req.Config == nil {
+		return decls
+	}
+	for _, t := range req.Config.Tools {
+		if t == nil {
+			continue
+		}
+		for _, decl := range t.FunctionDeclarations {
+			decls[decl.Name] = decl
+		}
+	}
+	return decls
+}
+
+func summarizeAt(contents []*genai.Content, i int, sensitive []string) string {
+	if i >= len(contents) {
+		return "<none>"
+	}
+	return summarize(contents[i], sensitive)
+}
+
+// summarize returns a short description of the content, e.g.
+// `user: "hello"` or `model: call get_weather`. The sensitive segments of the
+// text are redacted.
+func summarize(c *genai.Content, sensitive []string) string {
+	if c == nil {
+		return "<nil>"
+	}
+	var parts []string
+	for _, p := range c.Parts {
+		switch {
+		case p == nil:
+		case p.FunctionCall != nil:
+			parts = append(parts, "call "+p.FunctionCall.Name)
+		case p.FunctionResponse != nil:
+			parts = append(parts, "response "+p.FunctionResponse.Name)
+		default:
+			parts = append(parts, fmt.Sprintf("%q", truncate(RedactSensitive(p.Text, sensitive))))
+		}
+	}
+	return c.Role + ": " + strings.Join(parts, ", ")
+}
+
+func truncate(s string) string {
+	const maxLen = 60
+	r := []rune(s)
+	if len(r) <= maxLen {
+		return s
+	}
+	return string(r[:maxLen]) + "..."
+}
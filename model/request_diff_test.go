@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestDiffRequests(t *testing.T) {
+	request := func(instruction string, tools []*genai.FunctionDeclaration, contents ...*genai.Content) *model.LLMRequest {
+		return &model.LLMRequest{
+			Contents: contents,
+			Config: &genai.GenerateContentConfig{
+				SystemInstruction: genai.NewContentFromText(instruction, genai.RoleUser),
+				Tools:             []*genai.Tool{{FunctionDeclarations: tools}},
+			},
+		}
+	}
+	hello := genai.NewContentFromText("hello", genai.RoleUser)
+	call := genai.NewContentFromFunctionCall("search", map[string]any{"q": "adk"}, genai.RoleModel)
+	response := genai.NewContentFromFunctionResponse("search", map[string]any{"count": 3}, genai.RoleUser)
+	search := &genai.FunctionDeclaration{Name: "search", Description: "searches"}
+	fetch := &genai.FunctionDeclaration{Name: "fetch"}
+
+	tests := []struct {
+		name          string
+		prev, cur     *model.LLMRequest
+		wantPerturbed bool
+		wantString    string
+	}{
+		{
+			name:       "contents added",
+			prev:       request("be nice", []*genai.FunctionDeclaration{search}, hello),
+			cur:        request("be nice", []*genai.FunctionDeclaration{search}, hello, call, response),
+			wantString: "contents: 1 kept, 0 removed, 2 added",
+		},
+		{
+			name:          "content changed",
+			prev:          request("be nice", nil, hello, call),
+			cur:           request("be nice", nil, genai.NewContentFromText("hi", genai.RoleUser), call),
+			wantPerturbed: true,
+			wantString:    `contents: 0 kept, 2 removed, 2 added; content 0 changed: user: "hello" -> user: "hi"`,
+		},
+		{
+			name:          "instruction changed",
+			prev:          request("be nice", nil, hello),
+			cur:           request("be nicer", nil, hello),
+			wantPerturbed: true,
+			wantString:    `contents: 1 kept, 0 removed, 0 added; system instruction changed: "be nice" -> "be nicer"`,
+		},
+		{
+			name:          "tools changed",
+			prev:          request("", []*genai.FunctionDeclaration{search, fetch}, hello),
+			cur:           request("", []*genai.FunctionDeclaration{{Name: "search"}, {Name: "translate"}}, hello),
+			wantPerturbed: true,
+			wantString:    "contents: 1 kept, 0 removed, 0 added; tools added: translate; tools removed: fetch; tools changed: search",
+		},
+		{
+			name:       "no previous request",
+			cur:        request("", nil, hello),
+			wantString: "contents: 0 kept, 0 removed, 1 added",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := model.DiffRequests(tt.prev, tt.cur)
+			if diff.Perturbed() != tt.wantPerturbed {
+				t.Errorf("Perturbed() = %v, want %v", diff.Perturbed(), tt.wantPerturbed)
+			}
+			if diff := cmp.Diff(tt.wantString, diff.String()); diff != "" {
+				t.Errorf("String() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
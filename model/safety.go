@@ -0,0 +1,118 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// BlockSource is the part of the model call which was blocked by the safety
+// filters of the model.
+type BlockSource string
+
+const (
+	// BlockedPrompt is set when the request was blocked and the model didn't
+	// generate a response.
+	BlockedPrompt BlockSource = "prompt"
+	// BlockedResponse is set when the generation of the response was stopped.
+	BlockedResponse BlockSource = "response"
+)
+
+// SafetyBlock describes a model call blocked by the safety filters of the
+// model, see [LLMResponse.Blocked]. It implements error, so that the
+// applications can return it or match it with errors.As.
+type SafetyBlock struct {
+	Source BlockSource `json:"source"`
+	// Reason is the block reason of the prompt, or the finish reason of the
+	// response, e.g. "SAFETY" or "PROHIBITED_CONTENT".
+	Reason string `json:"reason"`
+	// Message is the explanation of the model, if any.
+	Message string `json:"message,omitempty"`
+	// Categories are the harm categories which triggered the block. Empty if
+	// the model didn't report them, e.g. for the blocklists.
+	Categories []genai.HarmCategory `json:"categories,omitempty"`
+	// Ratings are all the safety ratings reported by the model.
+	Ratings []*genai.SafetyRating `json:"ratings,omitempty"`
+}
+
+func (b *SafetyBlock) Error() string {
+	msg := fmt.Sprintf("%s blocked by the safety filters of the model: %s", b.Source, b.Reason)
+	if len(b.Categories) > 0 {
+		categories := make([]string, len(b.Categories))
+		for i, c := range b.Categories {
+			categories[i] = string(c)
+		}
+		msg += " (" + strings.Join(categories, ", ") + ")"
+	}
+	if b.Message != "" {
+		msg += ": " + b.Message
+	}
+	return msg
+}
+
+// IsBlockingFinishReason reports whether the finish reason means that the
+// response was stopped by the safety filters of the model.
+func IsBlockingFinishReason(r genai.FinishReason) bool {
+	switch r {
+	case genai.FinishReasonSafety, genai.FinishReasonRecitation, genai.FinishReasonBlocklist,
+		genai.FinishReasonProhibitedContent, genai.FinishReasonSPII, genai.FinishReasonImageSafety:
+		return true
+	}
+	return false
+}
+
+// NewSafetyBlock returns the safety block of the response, or nil if neither
+// the prompt nor the first candidate was blocked.
+func NewSafetyBlock(res *genai.GenerateContentResponse) *SafetyBlock {
+	if res == nil {
+		return nil
+	}
+	if len(res.Candidates) > 0 && res.Candidates[0] != nil {
+		c := res.Candidates[0]
+		if !IsBlockingFinishReason(c.FinishReason) {
+			return nil
+		}
+		return &SafetyBlock{
+			Source:     BlockedResponse,
+			Reason:     string(c.FinishReason),
+			Message:    c.FinishMessage,
+			Categories: blockedCategories(c.SafetyRatings),
+			Ratings:    c.SafetyRatings,
+		}
+	}
+	if f := res.PromptFeedback; f != nil && f.BlockReason != "" && f.BlockReason != genai.BlockedReasonUnspecified {
+		return &SafetyBlock{
+			Source:     BlockedPrompt,
+			Reason:     string(f.BlockReason),
+			Message:    f.BlockReasonMessage,
+			Categories: blockedCategories(f.SafetyRatings),
+			Ratings:    f.SafetyRatings,
+		}
+	}
+	return nil
+}
+
+func blockedCategories(ratings []*genai.SafetyRating) []genai.HarmCategory {
+	var categories []genai.HarmCategory
+	for _, r := range ratings {
+		if r != nil && r.Blocked {
+			categories = append(categories, r.Category)
+		}
+	}
+	return categories
+}
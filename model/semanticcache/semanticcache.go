@@ -0,0 +1,285 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package semanticcache provides a cache of the responses of the models
+// matching the requests by meaning rather than exactly, for the assistants
+// answering the same questions phrased differently, e.g. FAQs.
+//
+// The cache embeds the last user message of the requests and returns the
+// responses of a previous request whose last user message is similar enough.
+// By default, the responses are only reused for the same user, see
+// Config.Scope. The agents opt in by using a model wrapped by the cache; the other agents
+// can share the same underlying model:
+//
+//	cache, err := semanticcache.New(semanticcache.Config{Embedder: embedder, TTL: time.Hour})
+//	...
+//	faq, err := llmagent.New(llmagent.Config{Model: cache.Wrap(llm), ...})
+package semanticcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/embedding"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Default values of the [Config] fields.
+const (
+	DefaultThreshold  = 0.95
+	DefaultMaxEntries = 1000
+)
+
+// Config is used to create a [Cache].
+type Config struct {
+	// Embedder embeds the last user message of the requests.
+	Embedder embedding.Embedder
+	// Threshold is the minimum cosine similarity between the embeddings of
+	// the last user messages of two requests for the second one to reuse
+	// the responses of the first one.
+	// Optional: defaults to [DefaultThreshold].
+	Threshold float64
+	// TTL is how long the responses are cached.
+	// Optional: if zero, they don't expire.
+	TTL time.Duration
+	// MaxEntries is the maximum number of cached requests. The oldest one is
+	// evicted beyond.
+	// Optional: defaults to [DefaultMaxEntries].
+	MaxEntries int
+	// Scope returns the scope of the model call: the responses are only
+	// reused for the requests of the same scope, so that the responses,
+	// which may be personalized, aren't served to other users.
+	// Optional: defaults to [UserScope].
+	Scope func(ctx context.Context) string
+}
+
+// UserScope scopes the cached responses to the user of the invocation
+// calling the model: it returns the app name and the user ID of the session,
+// or "" if the model isn't called by an agent.
+func UserScope(ctx context.Context) string {
+	ictx, ok := ctx.(agent.InvocationContext)
+	if !ok || ictx.Session() == nil {
+		return ""
+	}
+	return strconv.Quote(ictx.Session().AppName()) + "/" + strconv.Quote(ictx.Session().UserID())
+}
+
+// Stats are the counters of a [Cache].
+type Stats struct {
+	Hits, Misses int
+	Entries      int
+}
+
+// Cache is a semantic cache of the responses of the models. It is safe for
+// concurrent use.
+type Cache struct {
+	cfg Config
+	now func() time.Time
+
+	mu           sync.Mutex
+	entries      []*entry // the oldest first
+	hits, misses int
+}
+
+type entry struct {
+	// scope identifies the scope of the call (see Config.Scope), the model,
+	// the config of the request and the streaming mode: only the requests
+	// with the same scope are compared.
+	scope     string
+	embedding []float32
+	responses []byte
+	expires   time.Time
+}
+
+// New returns a semantic cache.
+func New(cfg Config) (*Cache, error) {
+	if cfg.Embedder == nil {
+		return nil, fmt.Errorf("embedder is required")
+	}
+	if cfg.Threshold == 0 {
+		cfg.Threshold = DefaultThreshold
+	}
+	if cfg.Threshold < -1 || cfg.Threshold > 1 {
+		return nil, fmt.Errorf("threshold %v is not a cosine similarity between -1 and 1", cfg.Threshold)
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultMaxEntries
+	}
+	if cfg.Scope == nil {
+		cfg.Scope = UserScope
+	}
+	return &Cache{cfg: cfg, now: time.Now}, nil
+}
+
+// Stats returns the counters of the cache.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries)}
+}
+
+// Wrap returns a model returning the cached responses of m for the requests
+// whose last user message is similar to the one of a previous request with
+// the same config, e.g. the same system instruction and tools, and the same
+// scope, see Config.Scope. The earlier contents of the conversation are
+// ignored.
+//
+// The requests whose last content isn't a user text message, e.g. the
+// requests sending the results of the tools, are not cached. Only the
+// complete and successful responses are cached, and the errors of the
+// embedder are ignored: the requests are then sent to m.
+func (c *Cache) Wrap(m model.LLM) model.LLM {
+	return &cachedModel{llm: m, cache: c}
+}
+
+type cachedModel struct {
+	llm   model.LLM
+	cache *Cache
+}
+
+// Name returns the name of the wrapped model.
+func (m *cachedModel) Name() string {
+	return m.llm.Name()
+}
+
+func (m *cachedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		e := m.cache.entryOf(ctx, m.llm.Name(), req, stream)
+		if e != nil {
+			if resps, ok := m.cache.lookup(e); ok {
+				for _, resp := range resps {
+					if !yield(resp, nil) {
+						return
+					}
+				}
+				return
+			}
+		}
+
+		var (
+			resps     []json.RawMessage
+			cacheable = e != nil
+		)
+		for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+			if err != nil || resp == nil || resp.ErrorCode != "" {
+				cacheable = false
+			} else if cacheable {
+				// The response is encoded before the caller may modify it.
+				b, err := json.Marshal(resp)
+				cacheable = err == nil
+				resps = append(resps, b)
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+		if !cacheable || len(resps) == 0 {
+			return
+		}
+		if b, err := json.Marshal(resps); err == nil {
+			e.responses = b
+			m.cache.add(e)
+		}
+	}
+}
+
+// entryOf returns the entry of the request, without responses, or nil if
+// the request can't be cached.
+func (c *Cache) entryOf(ctx context.Context, modelName string, req *model.LLMRequest, stream bool) *entry {
+	if len(req.Contents) == 0 {
+		return nil
+	}
+	last := req.Contents[len(req.Contents)-1]
+	if last == nil || last.Role != genai.RoleUser || len(last.Parts) == 0 {
+		return nil
+	}
+	var text strings.Builder
+	for _, p := range last.Parts {
+		if p == nil || p.Text == "" || p.Thought {
+			return nil
+		}
+		text.WriteString(p.Text)
+	}
+	key, err := model.RequestKey(modelName, &model.LLMRequest{Model: req.Model, Config: req.Config}, stream)
+	if err != nil {
+		return nil
+	}
+	scope := c.cfg.Scope(ctx) + "\x00" + key
+	embeddings, err := c.cfg.Embedder.Embed(ctx, []string{text.String()})
+	if err != nil || len(embeddings) != 1 {
+		return nil
+	}
+	return &entry{scope: scope, embedding: embeddings[0]}
+}
+
+// lookup returns the responses of the most similar entry of the scope of e,
+// if it is above the threshold.
+func (c *Cache) lookup(e *entry) ([]*model.LLMResponse, bool) {
+	c.mu.Lock()
+	now := c.now()
+	var (
+		best       *entry
+		similarity = c.cfg.Threshold
+	)
+	live := c.entries[:0]
+	for _, cached := range c.entries {
+		if !cached.expires.IsZero() && !now.Before(cached.expires) {
+			continue
+		}
+		live = append(live, cached)
+		if cached.scope != e.scope {
+			continue
+		}
+		if s := embedding.Cosine(cached.embedding, e.embedding); s >= similarity {
+			best, similarity = cached, s
+		}
+	}
+	clear(c.entries[len(live):])
+	c.entries = live
+	if best == nil {
+		c.misses++
+		c.mu.Unlock()
+		return nil, false
+	}
+	c.hits++
+	b := best.responses
+	c.mu.Unlock()
+
+	var resps []*model.LLMResponse
+	if err := json.Unmarshal(b, &resps); err != nil {
+		return nil, false
+	}
+	return resps, true
+}
+
+func (c *Cache) add(e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cfg.TTL > 0 {
+		e.expires = c.now().Add(c.cfg.TTL)
+	}
+	c.entries = append(c.entries, e)
+	if n := len(c.entries) - c.cfg.MaxEntries; n > 0 {
+		clear(c.entries[:n])
+		c.entries = c.entries[n:]
+	}
+}
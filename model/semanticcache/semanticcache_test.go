@@ -0,0 +1,172 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semanticcache_test
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/semanticcache"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// fakeEmbedder embeds the known texts to fixed vectors.
+type fakeEmbedder map[string][]float32
+
+func (e fakeEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	var embeddings [][]float32
+	for _, text := range texts {
+		v, ok := e[text]
+		if !ok {
+			return nil, fmt.Errorf("unknown text %q", text)
+		}
+		embeddings = append(embeddings, v)
+	}
+	return embeddings, nil
+}
+
+// echoModel answers with the last user message and counts the calls.
+type echoModel struct {
+	calls int
+}
+
+func (m *echoModel) Name() string { return "echo" }
+
+func (m *echoModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	m.calls++
+	return func(yield func(*model.LLMResponse, error) bool) {
+		text := req.Contents[len(req.Contents)-1].Parts[0].Text
+		yield(&model.LLMResponse{Content: genai.NewContentFromText("answer to "+text, genai.RoleModel)}, nil)
+	}
+}
+
+func TestCache(t *testing.T) {
+	cache, err := semanticcache.New(semanticcache.Config{
+		Embedder: fakeEmbedder{
+			"What are your opening hours?": {1, 0, 0},
+			"When are you open?":           {0.99, 0.1, 0},
+			"Where are you?":               {0, 1, 0},
+			"unknown":                      nil,
+		},
+		Threshold: 0.9,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := &echoModel{}
+	llm := cache.Wrap(inner)
+
+	ask := func(instruction string, contents ...*genai.Content) string {
+		t.Helper()
+		req := &model.LLMRequest{
+			Contents: contents,
+			Config:   &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText(instruction, genai.RoleUser)},
+		}
+		var text string
+		for resp, err := range llm.GenerateContent(t.Context(), req, false) {
+			if err != nil {
+				t.Fatalf("GenerateContent() error = %v", err)
+			}
+			text = resp.Content.Parts[0].Text
+		}
+		return text
+	}
+	user := func(text string) *genai.Content { return genai.NewContentFromText(text, genai.RoleUser) }
+
+	ask("faq", user("What are your opening hours?"))
+	if got := ask("faq", user("When are you open?")); got != "answer to What are your opening hours?" {
+		t.Errorf("similar question answer = %q, want the cached answer", got)
+	}
+	if inner.calls != 1 {
+		t.Errorf("model calls = %d, want 1", inner.calls)
+	}
+
+	if got := ask("faq", user("Where are you?")); got != "answer to Where are you?" {
+		t.Errorf("different question answer = %q, want a new answer", got)
+	}
+	if got := ask("other agent", user("When are you open?")); got != "answer to When are you open?" {
+		t.Errorf("answer with another instruction = %q, want a new answer", got)
+	}
+	response := genai.NewContentFromFunctionResponse("lookup", map[string]any{"open": "9-17"}, genai.RoleUser)
+	ask("faq", user("What are your opening hours?"), response)
+	ask("faq", user("What are your opening hours?"), response)
+	if inner.calls != 5 {
+		t.Errorf("model calls = %d, want 5: the tool results aren't cached", inner.calls)
+	}
+
+	if got, want := cache.Stats(), (semanticcache.Stats{Hits: 1, Misses: 3, Entries: 3}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	if _, err := semanticcache.New(semanticcache.Config{}); err == nil {
+		t.Error("New() without embedder succeeded, want error")
+	}
+}
+
+func TestCache_UserScope(t *testing.T) {
+	cache, err := semanticcache.New(semanticcache.Config{
+		Embedder: fakeEmbedder{
+			"What is my balance?": {1, 0, 0},
+			"How much do I have?": {0.99, 0.1, 0},
+		},
+		Threshold: 0.9,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := &echoModel{}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: cache.Wrap(inner)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "bank", Agent: a, SessionService: sessions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ask := func(userID, text string) string {
+		t.Helper()
+		created, err := sessions.Create(t.Context(), &session.CreateRequest{AppName: "bank", UserID: userID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var answer string
+		for ev, err := range r.Run(t.Context(), userID, created.Session.ID(), genai.NewContentFromText(text, genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			answer = ev.Content.Parts[0].Text
+		}
+		return answer
+	}
+
+	ask("alice", "What is my balance?")
+	if got := ask("bob", "How much do I have?"); got != "answer to How much do I have?" {
+		t.Errorf("answer to another user = %q, want a new answer", got)
+	}
+	if got := ask("alice", "How much do I have?"); got != "answer to What is my balance?" {
+		t.Errorf("answer to the same user = %q, want the cached answer", got)
+	}
+	if inner.calls != 2 {
+		t.Errorf("model calls = %d, want 2", inner.calls)
+	}
+}
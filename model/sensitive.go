@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// The markers of the sensitive segments of the instructions, see
+// [Sensitive].
+const (
+	SensitiveStart = "<sensitive>"
+	SensitiveEnd   = "</sensitive>"
+)
+
+// Sensitive marks a segment of an instruction as sensitive, e.g. proprietary
+// prompt content:
+//
+//	Instruction: "You are a support agent. " + model.Sensitive(pricingRules),
+//
+// The markers can also be written in the instructions loaded from files.
+// The agents remove the markers and send the full instruction to the model,
+// but the segment is replaced with its hash, see [RedactSensitive], in the
+// telemetry and the debug logs.
+func Sensitive(text string) string {
+	return SensitiveStart + text + SensitiveEnd
+}
+
+// ExtractSensitive removes the markers of the sensitive segments from the
+// text. It returns the text and the segments. An unterminated segment
+// extends to the end of the text.
+func ExtractSensitive(text string) (string, []string) {
+	if !strings.Contains(text, SensitiveStart) {
+		return text, nil
+	}
+	var b strings.Builder
+	var segments []string
+	for {
+		before, after, found := strings.Cut(text, SensitiveStart)
+		b.WriteString(before)
+		if !found {
+			break
+		}
+		segment, rest, _ := strings.Cut(after, SensitiveEnd)
+		b.WriteString(segment)
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+		text = rest
+	}
+	return b.String(), segments
+}
+
+// RedactSensitive replaces the sensitive segments in s with their hashes,
+// e.g. "[REDACTED sha256:2c26b46b68ff]", so that the logs show where and
+// whether a segment changed without revealing it.
+func RedactSensitive(s string, segments []string) string {
+	for _, segment := range segments {
+		s = strings.ReplaceAll(s, segment, redactedSegment(segment))
+	}
+	return s
+}
+
+// RedactSensitiveJSON is like [RedactSensitive] for the JSON encoding of a
+// value containing the segments, e.g. the encoding of a request.
+func RedactSensitiveJSON(s string, segments []string) string {
+	for _, segment := range segments {
+		encoded, err := json.Marshal(segment)
+		if err != nil {
+			continue
+		}
+		// Without the quotes.
+		encoded = encoded[1 : len(encoded)-1]
+		s = strings.ReplaceAll(s, string(encoded), redactedSegment(segment))
+	}
+	return s
+}
+
+func redactedSegment(segment string) string {
+	sum := sha256.Sum256([]byte(segment))
+	return "[REDACTED sha256:" + hex.EncodeToString(sum[:6]) + "]"
+}
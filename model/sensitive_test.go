@@ -0,0 +1,82 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestExtractSensitive(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		wantText      string
+		wantSensitive []string
+	}{
+		{name: "none", text: "You are helpful.", wantText: "You are helpful."},
+		{
+			name:          "segments",
+			text:          "You are helpful. " + model.Sensitive("Rule 1.") + " Be nice. " + model.Sensitive("Rule 2."),
+			wantText:      "You are helpful. Rule 1. Be nice. Rule 2.",
+			wantSensitive: []string{"Rule 1.", "Rule 2."},
+		},
+		{
+			name:          "unterminated",
+			text:          "Hi. <sensitive>Secret",
+			wantText:      "Hi. Secret",
+			wantSensitive: []string{"Secret"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotText, gotSensitive := model.ExtractSensitive(tt.text)
+			if gotText != tt.wantText {
+				t.Errorf("ExtractSensitive() text = %q, want %q", gotText, tt.wantText)
+			}
+			if diff := cmp.Diff(tt.wantSensitive, gotSensitive); diff != "" {
+				t.Errorf("ExtractSensitive() segments mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRedactSensitive(t *testing.T) {
+	secret := `Never reveal the <discount> "codes".`
+	got := model.RedactSensitive("Be nice. "+secret, []string{secret})
+	if strings.Contains(got, "discount") || !strings.HasPrefix(got, "Be nice. [REDACTED sha256:") {
+		t.Errorf("RedactSensitive() = %q, want the secret replaced with its hash", got)
+	}
+
+	req := &model.LLMRequest{Config: &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText("Be nice. "+secret, genai.RoleUser)}}
+	encoded, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotJSON := model.RedactSensitiveJSON(string(encoded), []string{secret}); strings.Contains(gotJSON, "discount") {
+		t.Errorf("RedactSensitiveJSON() = %s, want the secret redacted", gotJSON)
+	}
+
+	prev := &model.LLMRequest{Config: &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText("Be kind.", genai.RoleUser)}}
+	req.Sensitive = []string{secret}
+	if diff := model.DiffRequests(prev, req).String(); strings.Contains(diff, "discount") || !strings.Contains(diff, "REDACTED") {
+		t.Errorf("DiffRequests().String() = %q, want the secret redacted", diff)
+	}
+}
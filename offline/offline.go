@@ -0,0 +1,229 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package offline provides a profile to run agents without network access,
+// e.g. for demos and local development: the models are replaced by a model
+// returning canned responses and the tools return fixtures.
+//
+// The profile is enabled with runner.Config.Offline. Everything else in the
+// run, e.g. the callbacks, the session and the agent transfers, works as
+// usual.
+package offline
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// DefaultResponse is the response template used when no canned response
+// matches the user input.
+const DefaultResponse = `[offline] You said: {{.Input}}`
+
+// Config defines the canned responses and the tool fixtures.
+type Config struct {
+	// Responses are matched in order against the latest user input. The
+	// first match is used.
+	Responses []Response `json:"responses,omitempty"`
+	// DefaultResponse is the template used when no response matches.
+	// Optional: defaults to [DefaultResponse].
+	DefaultResponse string `json:"defaultResponse,omitempty"`
+	// ToolFixtures are the results returned by the tools, by tool name.
+	ToolFixtures map[string]map[string]any `json:"toolFixtures,omitempty"`
+	// DefaultToolResult is returned by the tools without a fixture.
+	// Optional: defaults to a result naming the tool.
+	DefaultToolResult map[string]any `json:"defaultToolResult,omitempty"`
+	// LocalTools are the names of the tools which run as usual, because they
+	// don't need network access, e.g. "exit_loop". The agent transfer tool
+	// always runs.
+	LocalTools []string `json:"localTools,omitempty"`
+}
+
+// Response is a canned model response.
+type Response struct {
+	// Match is a regular expression matched against the latest user input.
+	// Optional: if empty, the response matches any input.
+	Match string `json:"match,omitempty"`
+	// ToolCalls are the tools the model calls before responding. Once the
+	// tools have returned, the model responds with Text.
+	// Optional.
+	ToolCalls []ToolCall `json:"toolCalls,omitempty"`
+	// Text is a text/template of the response. The template data has the
+	// fields Input, the latest user input, and ToolResults, the results of
+	// the tools called by the model, by tool name.
+	Text string `json:"text,omitempty"`
+}
+
+// ToolCall is a tool call made by the offline model.
+type ToolCall struct {
+	Name string         `json:"name"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// Profile is a compiled offline [Config].
+type Profile struct {
+	responses         []response
+	defaultResponse   *template.Template
+	toolFixtures      map[string]map[string]any
+	defaultToolResult map[string]any
+	localTools        []string
+}
+
+type response struct {
+	match     *regexp.Regexp
+	toolCalls []ToolCall
+	text      *template.Template
+}
+
+// New compiles the offline profile.
+func New(cfg Config) (*Profile, error) {
+	p := &Profile{
+		toolFixtures:      cfg.ToolFixtures,
+		defaultToolResult: cfg.DefaultToolResult,
+		localTools:        cfg.LocalTools,
+	}
+	for i, r := range cfg.Responses {
+		var resp response
+		if r.Match != "" {
+			re, err := regexp.Compile(r.Match)
+			if err != nil {
+				return nil, fmt.Errorf("invalid match of response %d: %w", i, err)
+			}
+			resp.match = re
+		}
+		text, err := template.New(fmt.Sprintf("response %d", i)).Parse(r.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid text of response %d: %w", i, err)
+		}
+		resp.text = text
+		resp.toolCalls = r.ToolCalls
+		p.responses = append(p.responses, resp)
+	}
+	if cfg.DefaultResponse == "" {
+		cfg.DefaultResponse = DefaultResponse
+	}
+	defaultResponse, err := template.New("default response").Parse(cfg.DefaultResponse)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default response: %w", err)
+	}
+	p.defaultResponse = defaultResponse
+	return p, nil
+}
+
+// Model returns the model responding with the canned responses.
+func (p *Profile) Model() model.LLM {
+	return &cannedModel{profile: p}
+}
+
+// ToolResult returns the fixture returned by the named tool. It returns false
+// if the tool is a local tool, which runs as usual.
+func (p *Profile) ToolResult(name string) (map[string]any, bool) {
+	if slices.Contains(p.localTools, name) {
+		return nil, false
+	}
+	if fixture, ok := p.toolFixtures[name]; ok {
+		return fixture, true
+	}
+	if p.defaultToolResult != nil {
+		return p.defaultToolResult, true
+	}
+	return map[string]any{"result": fmt.Sprintf("[offline] %s was called", name)}, true
+}
+
+type cannedModel struct {
+	profile *Profile
+}
+
+func (m *cannedModel) Name() string {
+	return "offline"
+}
+
+func (m *cannedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		content, err := m.profile.respond(req.Contents)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		yield(&model.LLMResponse{Content: content, TurnComplete: true}, nil)
+	}
+}
+
+// templateData is the data of the response templates.
+type templateData struct {
+	Input       string
+	ToolResults map[string]any
+}
+
+func (p *Profile) respond(contents []*genai.Content) (*genai.Content, error) {
+	data := lastTurn(contents)
+
+	tmpl := p.defaultResponse
+	for _, r := range p.responses {
+		if r.match != nil && !r.match.MatchString(data.Input) {
+			continue
+		}
+		if len(r.toolCalls) > 0 && len(data.ToolResults) == 0 {
+			content := &genai.Content{Role: genai.RoleModel}
+			for _, call := range r.toolCalls {
+				content.Parts = append(content.Parts, &genai.Part{
+					FunctionCall: &genai.FunctionCall{Name: call.Name, Args: call.Args},
+				})
+			}
+			return content, nil
+		}
+		tmpl = r.text
+		break
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return nil, fmt.Errorf("failed to render offline response: %w", err)
+	}
+	return genai.NewContentFromText(b.String(), genai.RoleModel), nil
+}
+
+// lastTurn returns the latest user input and the results of the tools called
+// since.
+func lastTurn(contents []*genai.Content) templateData {
+	data := templateData{ToolResults: make(map[string]any)}
+	for _, c := range slices.Backward(contents) {
+		if c == nil || c.Role != genai.RoleUser {
+			continue
+		}
+		var text []string
+		for _, p := range c.Parts {
+			if p.FunctionResponse != nil {
+				if _, ok := data.ToolResults[p.FunctionResponse.Name]; !ok {
+					data.ToolResults[p.FunctionResponse.Name] = p.FunctionResponse.Response
+				}
+			}
+			if p.Text != "" {
+				text = append(text, p.Text)
+			}
+		}
+		if len(text) > 0 {
+			data.Input = strings.Join(text, "\n")
+			break
+		}
+	}
+	return data
+}
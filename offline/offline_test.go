@@ -0,0 +1,117 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offline
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+)
+
+func TestProfile_Respond(t *testing.T) {
+	p, err := New(Config{
+		Responses: []Response{
+			{
+				Match:     "^search (.*)",
+				ToolCalls: []ToolCall{{Name: "search", Args: map[string]any{"q": "adk"}}},
+				Text:      "Found {{.ToolResults.search.count}} results for {{.Input}}.",
+			},
+			{Match: "(?i)hello", Text: "Hi!"},
+		},
+		DefaultResponse: "No canned response for {{printf \"%q\" .Input}}.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		contents []*genai.Content
+		want     *genai.Content
+	}{
+		{
+			name:     "text response",
+			contents: []*genai.Content{genai.NewContentFromText("Hello there", genai.RoleUser)},
+			want:     genai.NewContentFromText("Hi!", genai.RoleModel),
+		},
+		{
+			name:     "tool calls",
+			contents: []*genai.Content{genai.NewContentFromText("search adk", genai.RoleUser)},
+			want:     genai.NewContentFromFunctionCall("search", map[string]any{"q": "adk"}, genai.RoleModel),
+		},
+		{
+			name: "response after tool calls",
+			contents: []*genai.Content{
+				genai.NewContentFromText("search adk", genai.RoleUser),
+				genai.NewContentFromFunctionCall("search", map[string]any{"q": "adk"}, genai.RoleModel),
+				genai.NewContentFromFunctionResponse("search", map[string]any{"count": 3}, genai.RoleUser),
+			},
+			want: genai.NewContentFromText("Found 3 results for search adk.", genai.RoleModel),
+		},
+		{
+			name: "default response",
+			contents: []*genai.Content{
+				genai.NewContentFromText("Hello", genai.RoleUser),
+				genai.NewContentFromText("Hi!", genai.RoleModel),
+				genai.NewContentFromText("bye", genai.RoleUser),
+			},
+			want: genai.NewContentFromText(`No canned response for "bye".`, genai.RoleModel),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.respond(tt.contents)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("respond() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProfile_ToolResult(t *testing.T) {
+	p, err := New(Config{
+		ToolFixtures: map[string]map[string]any{"search": {"count": 3}},
+		LocalTools:   []string{"exit_loop"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok := p.ToolResult("search"); !ok || !cmp.Equal(got, map[string]any{"count": 3}) {
+		t.Errorf("ToolResult(search) = (%v, %v), want the fixture", got, ok)
+	}
+	if got, ok := p.ToolResult("exit_loop"); ok {
+		t.Errorf("ToolResult(exit_loop) = (%v, %v), want the local tool to run", got, ok)
+	}
+	if got, ok := p.ToolResult("unknown"); !ok || got["result"] == nil {
+		t.Errorf("ToolResult(unknown) = (%v, %v), want the default result", got, ok)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Responses: []Response{{Match: "("}}},
+		{Responses: []Response{{Text: "{{"}}},
+		{DefaultResponse: "{{.Input"},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) succeeded, want error", cfg)
+		}
+	}
+}
@@ -0,0 +1,516 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bigqueryanalytics provides a plugin which writes one analytics row
+// per invocation to a BigQuery table: who ran it, which agents handled it,
+// the tokens and cost of the model calls, the latency breakdown, the tool
+// usage and the outcome. See [Row] for the table schema.
+//
+// Usage:
+//
+//	a, err := bigqueryanalytics.New(ctx, bigqueryanalytics.Config{
+//		ProjectID: "my-project",
+//		DatasetID: "agents",
+//		TableID:   "invocations",
+//	})
+//	...
+//	defer a.Close(ctx)
+//	r, err := runner.New(runner.Config{..., Plugins: []*plugin.Plugin{a.Plugin()}})
+//
+// The rows are written in batches in the background. Close writes the
+// remaining rows. The rows whose write failed are written again with the
+// next batch, a bounded number of times: the rows are lost if the table
+// stays unavailable, see [Config.MaxAttempts] and [Config.MaxPendingRows].
+package bigqueryanalytics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/api/option"
+	"google.golang.org/genai"
+)
+
+// PluginName is the name of the analytics plugin.
+const PluginName = "bigquery_analytics"
+
+// Outcomes of the invocations.
+const (
+	// OutcomeSuccess means that the agents gave a final response.
+	OutcomeSuccess = "SUCCESS"
+	// OutcomeError means that a model call failed or returned an error.
+	OutcomeError = "ERROR"
+	// OutcomeIncomplete means that the invocation ended without a final
+	// response, e.g. it's waiting for a long-running tool or the caller
+	// stopped reading the events.
+	OutcomeIncomplete = "INCOMPLETE"
+)
+
+const (
+	defaultBatchSize      = 100
+	defaultFlushInterval  = 5 * time.Second
+	defaultMaxAttempts    = 3
+	defaultMaxPendingRows = 10_000
+)
+
+// Writer writes analytics rows.
+type Writer interface {
+	Write(ctx context.Context, rows []*Row) error
+}
+
+// CostFunc returns the cost in USD of a model call of the agent with the
+// given usage. The agent identifies the model, e.g. to look up its price.
+type CostFunc func(agentName string, usage *genai.GenerateContentResponseUsageMetadata) float64
+
+// Config is used to create [Analytics].
+type Config struct {
+	// ProjectID, DatasetID and TableID identify the table the rows are
+	// written to. The table must exist, see [CreateTable].
+	ProjectID string
+	DatasetID string
+	TableID   string
+	// ClientOptions are used to create the BigQuery client.
+	// Optional.
+	ClientOptions []option.ClientOption
+
+	// Writer writes the rows instead of the BigQuery table, e.g. to write
+	// them to another warehouse.
+	// Optional: if set, ProjectID, DatasetID and TableID are ignored.
+	Writer Writer
+
+	// Cost computes the cost of each model call.
+	// Optional: if nil, the cost is not reported.
+	Cost CostFunc
+	// BatchSize is the number of rows which triggers a write.
+	// Optional: defaults to 100.
+	BatchSize int
+	// FlushInterval is the maximum time a row waits to be written.
+	// Optional: defaults to 5s.
+	FlushInterval time.Duration
+	// MaxAttempts is the number of writes of a row before it is dropped.
+	// Optional: defaults to 3.
+	MaxAttempts int
+	// MaxPendingRows is the maximum number of rows waiting to be written,
+	// including the rows whose write failed. The oldest rows are dropped
+	// beyond it.
+	// Optional: defaults to 10000.
+	MaxPendingRows int
+	// OnError is called when rows can't be written, and when rows are
+	// dropped.
+	// Optional: defaults to logging the error.
+	OnError func(error)
+}
+
+// Row is the analytics row of an invocation. The JSON names are the columns
+// of the table, see [Schema].
+type Row struct {
+	InvocationID string `json:"invocation_id"`
+	AppName      string `json:"app_name"`
+	UserID       string `json:"user_id"`
+	SessionID    string `json:"session_id"`
+
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	// LatencyMS is the duration of the invocation. ModelLatencyMS and
+	// ToolLatencyMS are the time spent in the model and tool calls.
+	LatencyMS      int64 `json:"latency_ms"`
+	ModelLatencyMS int64 `json:"model_latency_ms"`
+	ToolLatencyMS  int64 `json:"tool_latency_ms"`
+
+	// AgentPath are the agents which produced events, in order of their
+	// first event.
+	AgentPath []string `json:"agent_path"`
+
+	ModelCalls       int64   `json:"model_calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CachedTokens     int64   `json:"cached_tokens"`
+	CandidatesTokens int64   `json:"candidates_tokens"`
+	ThoughtsTokens   int64   `json:"thoughts_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+
+	ToolCalls int64        `json:"tool_calls"`
+	Tools     []*ToolUsage `json:"tools"`
+
+	// Outcome is one of OutcomeSuccess, OutcomeError and OutcomeIncomplete.
+	Outcome string `json:"outcome"`
+	// Error is the first error of the invocation.
+	Error string `json:"error"`
+}
+
+// ToolUsage is the usage of a tool in an invocation.
+type ToolUsage struct {
+	Name      string `json:"name"`
+	Calls     int64  `json:"calls"`
+	Errors    int64  `json:"errors"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// Analytics collects the invocation rows and writes them.
+type Analytics struct {
+	cfg    Config
+	writer Writer
+	plugin *plugin.Plugin
+	now    func() time.Time
+
+	mu sync.Mutex
+	// runs are the invocations in progress, by session.
+	runs    map[string]*runState
+	pending []pendingRow
+
+	flushc    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// pendingRow is a row waiting to be written.
+type pendingRow struct {
+	row *Row
+	// attempts is the number of failed writes of the row.
+	attempts int
+}
+
+type runState struct {
+	row   *Row
+	tools map[string]*ToolUsage
+	// modelCalls are the start times of the calls in progress, by agent
+	// invocation.
+	modelCalls map[string]time.Time
+	// toolCalls are the start times of the calls in progress, by call ID.
+	toolCalls map[string]time.Time
+}
+
+// New creates the analytics plugin and starts writing the rows in the
+// background. Call Close to write the remaining rows.
+func New(ctx context.Context, cfg Config) (*Analytics, error) {
+	w := cfg.Writer
+	if w == nil {
+		if cfg.ProjectID == "" || cfg.DatasetID == "" || cfg.TableID == "" {
+			return nil, fmt.Errorf("project, dataset and table IDs are required")
+		}
+		var err error
+		w, err = NewTableWriter(ctx, cfg.ProjectID, cfg.DatasetID, cfg.TableID, cfg.ClientOptions...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.MaxPendingRows <= 0 {
+		cfg.MaxPendingRows = defaultMaxPendingRows
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(err error) { slog.Error("bigquery analytics: failed to write the events", "error", err) }
+	}
+
+	a := &Analytics{
+		cfg:    cfg,
+		writer: w,
+		now:    time.Now,
+		runs:   make(map[string]*runState),
+		flushc: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	p, err := plugin.New(plugin.Config{
+		Name:                PluginName,
+		BeforeRunCallback:   a.beforeRun,
+		AfterRunCallback:    a.afterRun,
+		OnEventCallback:     a.onEvent,
+		BeforeModelCallback: a.beforeModel,
+		AfterModelCallback:  a.afterModel,
+		BeforeToolCallback:  a.beforeTool,
+		AfterToolCallback:   a.afterTool,
+		CloseCallback:       a.Close,
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.plugin = p
+
+	a.wg.Add(1)
+	go a.loop()
+	return a, nil
+}
+
+// Plugin returns the plugin to register with runner.Config.Plugins.
+func (a *Analytics) Plugin() *plugin.Plugin { return a.plugin }
+
+// Close stops the background writes and writes the remaining rows. The
+// runner calls it when it is closed. The rows are lost if the write fails.
+func (a *Analytics) Close(ctx context.Context) error {
+	a.closeOnce.Do(func() {
+		close(a.done)
+		a.wg.Wait()
+	})
+	return a.flush(ctx)
+}
+
+func (a *Analytics) loop() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(a.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+		case <-a.flushc:
+		}
+		if err := a.flush(context.Background()); err != nil {
+			a.cfg.OnError(err)
+		}
+	}
+}
+
+// flush writes the pending rows. If the write fails, the rows are kept to
+// be written with the next batch, unless they were written MaxAttempts
+// times.
+func (a *Analytics) flush(ctx context.Context) error {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = nil
+	a.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	rows := make([]*Row, len(pending))
+	for i, p := range pending {
+		rows[i] = p.row
+	}
+	err := a.writer.Write(ctx, rows)
+	if err == nil {
+		return nil
+	}
+
+	var retried []pendingRow
+	for _, p := range pending {
+		if p.attempts++; p.attempts < a.cfg.MaxAttempts {
+			retried = append(retried, p)
+		}
+	}
+	dropped := len(pending) - len(retried)
+	a.mu.Lock()
+	a.pending = append(retried, a.pending...)
+	dropped += a.trimPending()
+	a.mu.Unlock()
+	if dropped > 0 {
+		return fmt.Errorf("failed to write %d rows, dropped %d rows: %w", len(rows), dropped, err)
+	}
+	return fmt.Errorf("failed to write %d rows, retrying: %w", len(rows), err)
+}
+
+// trimPending drops the oldest pending rows beyond MaxPendingRows and
+// returns their number. It must be called with a.mu held.
+func (a *Analytics) trimPending() int {
+	n := len(a.pending) - a.cfg.MaxPendingRows
+	if n <= 0 {
+		return 0
+	}
+	a.pending = slices.Delete(a.pending, 0, n)
+	return n
+}
+
+// runKey identifies the invocation of the runner in the session. The agents
+// called as tools or transferred to run in sub-invocations of the same run.
+func runKey(appName, userID, sessionID string) string {
+	return appName + "/" + userID + "/" + sessionID
+}
+
+// run returns the state of the invocation in progress. It must be called
+// with a.mu held.
+func (a *Analytics) run(ctx agent.ReadonlyContext) *runState {
+	return a.runs[runKey(ctx.AppName(), ctx.UserID(), ctx.SessionID())]
+}
+
+func (a *Analytics) beforeRun(ctx agent.InvocationContext) (*genai.Content, error) {
+	s := ctx.Session()
+	row := &Row{
+		InvocationID: ctx.InvocationID(),
+		AppName:      s.AppName(),
+		UserID:       s.UserID(),
+		SessionID:    s.ID(),
+		StartTime:    a.now(),
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.runs[runKey(row.AppName, row.UserID, row.SessionID)] = &runState{
+		row:        row,
+		tools:      make(map[string]*ToolUsage),
+		modelCalls: make(map[string]time.Time),
+		toolCalls:  make(map[string]time.Time),
+	}
+	return nil, nil
+}
+
+func (a *Analytics) afterRun(ctx agent.InvocationContext) {
+	s := ctx.Session()
+	key := runKey(s.AppName(), s.UserID(), s.ID())
+
+	a.mu.Lock()
+	r := a.runs[key]
+	delete(a.runs, key)
+	if r == nil {
+		a.mu.Unlock()
+		return
+	}
+	row := r.row
+	row.EndTime = a.now()
+	row.LatencyMS = row.EndTime.Sub(row.StartTime).Milliseconds()
+	switch {
+	case row.Error != "":
+		row.Outcome = OutcomeError
+	case row.Outcome == "":
+		row.Outcome = OutcomeIncomplete
+	}
+	a.pending = append(a.pending, pendingRow{row: row})
+	dropped := a.trimPending()
+	full := len(a.pending) >= a.cfg.BatchSize
+	a.mu.Unlock()
+
+	if dropped > 0 {
+		a.cfg.OnError(fmt.Errorf("dropped %d rows: too many rows waiting to be written", dropped))
+	}
+	if full {
+		select {
+		case a.flushc <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (a *Analytics) onEvent(ctx agent.InvocationContext, ev *session.Event) (*session.Event, error) {
+	s := ctx.Session()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	r := a.runs[runKey(s.AppName(), s.UserID(), s.ID())]
+	if r == nil || ev == nil || ev.Partial {
+		return nil, nil
+	}
+	if ev.Author != "" && ev.Author != genai.RoleUser && !slices.Contains(r.row.AgentPath, ev.Author) {
+		r.row.AgentPath = append(r.row.AgentPath, ev.Author)
+	}
+	if ev.ErrorCode != "" {
+		r.setError(fmt.Sprintf("%s: %s", ev.ErrorCode, ev.ErrorMessage))
+	}
+	if ev.Author != genai.RoleUser && ev.IsFinalResponse() {
+		r.row.Outcome = OutcomeSuccess
+	}
+	return nil, nil
+}
+
+// modelCallKey identifies the model calls of an agent. The calls of an
+// agent in an invocation are sequential.
+func modelCallKey(ctx agent.CallbackContext) string {
+	return ctx.InvocationID() + "/" + ctx.Branch() + "/" + ctx.AgentName()
+}
+
+func (a *Analytics) beforeModel(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if r := a.run(ctx); r != nil {
+		r.row.ModelCalls++
+		r.modelCalls[modelCallKey(ctx)] = a.now()
+	}
+	return nil, nil
+}
+
+func (a *Analytics) afterModel(ctx agent.CallbackContext, resp *model.LLMResponse, respErr error) (*model.LLMResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	r := a.run(ctx)
+	if r == nil || (respErr == nil && resp != nil && resp.Partial) {
+		return nil, nil
+	}
+	key := modelCallKey(ctx)
+	if start, ok := r.modelCalls[key]; ok {
+		delete(r.modelCalls, key)
+		r.row.ModelLatencyMS += a.now().Sub(start).Milliseconds()
+	}
+	if respErr != nil {
+		r.setError(respErr.Error())
+		return nil, nil
+	}
+	if resp == nil || resp.UsageMetadata == nil {
+		return nil, nil
+	}
+	u := resp.UsageMetadata
+	r.row.PromptTokens += int64(u.PromptTokenCount)
+	r.row.CachedTokens += int64(u.CachedContentTokenCount)
+	r.row.CandidatesTokens += int64(u.CandidatesTokenCount)
+	r.row.ThoughtsTokens += int64(u.ThoughtsTokenCount)
+	r.row.TotalTokens += int64(u.TotalTokenCount)
+	if a.cfg.Cost != nil {
+		r.row.CostUSD += a.cfg.Cost(ctx.AgentName(), u)
+	}
+	return nil, nil
+}
+
+func (a *Analytics) beforeTool(ctx tool.Context, t tool.Tool, args map[string]any) (map[string]any, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if r := a.run(ctx); r != nil {
+		r.toolCalls[ctx.FunctionCallID()] = a.now()
+	}
+	return nil, nil
+}
+
+func (a *Analytics) afterTool(ctx tool.Context, t tool.Tool, args, result map[string]any, err error) (map[string]any, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	r := a.run(ctx)
+	if r == nil {
+		return nil, nil
+	}
+	usage, ok := r.tools[t.Name()]
+	if !ok {
+		usage = &ToolUsage{Name: t.Name()}
+		r.tools[t.Name()] = usage
+		r.row.Tools = append(r.row.Tools, usage)
+	}
+	usage.Calls++
+	r.row.ToolCalls++
+	if err != nil {
+		usage.Errors++
+	}
+	if start, ok := r.toolCalls[ctx.FunctionCallID()]; ok {
+		delete(r.toolCalls, ctx.FunctionCallID())
+		d := a.now().Sub(start).Milliseconds()
+		usage.LatencyMS += d
+		r.row.ToolLatencyMS += d
+	}
+	return nil, nil
+}
+
+func (r *runState) setError(msg string) {
+	if r.row.Error == "" {
+		r.row.Error = msg
+	}
+}
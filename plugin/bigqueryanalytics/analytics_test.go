@@ -0,0 +1,330 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigqueryanalytics
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+type fakeWriter struct {
+	mu   sync.Mutex
+	rows []*Row
+	err  error
+}
+
+func (w *fakeWriter) Write(ctx context.Context, rows []*Row) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.rows = append(w.rows, rows...)
+	return nil
+}
+
+// scriptedModel returns the responses in order, one per call.
+type scriptedModel struct {
+	responses []*model.LLMResponse
+	err       error
+}
+
+func (m *scriptedModel) Name() string { return "scripted-model" }
+
+func (m *scriptedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if m.err != nil {
+			yield(nil, m.err)
+			return
+		}
+		resp := m.responses[0]
+		m.responses = m.responses[1:]
+		yield(resp, nil)
+	}
+}
+
+func usage(prompt, candidates int32) *genai.GenerateContentResponseUsageMetadata {
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     prompt,
+		CandidatesTokenCount: candidates,
+		TotalTokenCount:      prompt + candidates,
+	}
+}
+
+func runAgent(t *testing.T, a *Analytics, llm model.LLM) {
+	t.Helper()
+	weatherTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather in a city",
+	}, func(ctx tool.Context, args struct {
+		City string `json:"city"`
+	}) (map[string]any, error) {
+		return map[string]any{"weather": "sunny"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ag, err := llmagent.New(llmagent.Config{
+		Name:  "weather_agent",
+		Model: llm,
+		Tools: []tool.Tool{weatherTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := session.InMemoryService()
+	r, err := runner.New(runner.Config{
+		AppName:        "weather_app",
+		Agent:          ag,
+		SessionService: sessions,
+		Plugins:        []*plugin.Plugin{a.Plugin()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Create(t.Context(), &session.CreateRequest{AppName: "weather_app", UserID: "u1", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range r.Run(t.Context(), "u1", "s1", genai.NewContentFromText("weather in Paris?", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			break
+		}
+	}
+}
+
+func TestAnalytics(t *testing.T) {
+	tests := []struct {
+		name string
+		llm  *scriptedModel
+		want *Row
+	}{
+		{
+			name: "success",
+			llm: &scriptedModel{responses: []*model.LLMResponse{
+				{
+					Content:       genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel),
+					UsageMetadata: usage(100, 10),
+				},
+				{
+					Content:       genai.NewContentFromText("It is sunny.", genai.RoleModel),
+					UsageMetadata: usage(120, 5),
+				},
+			}},
+			want: &Row{
+				AppName:          "weather_app",
+				UserID:           "u1",
+				SessionID:        "s1",
+				AgentPath:        []string{"weather_agent"},
+				ModelCalls:       2,
+				PromptTokens:     220,
+				CandidatesTokens: 15,
+				TotalTokens:      235,
+				CostUSD:          2.35,
+				ToolCalls:        1,
+				Tools:            []*ToolUsage{{Name: "get_weather", Calls: 1}},
+				Outcome:          OutcomeSuccess,
+			},
+		},
+		{
+			name: "model error",
+			llm:  &scriptedModel{err: errors.New("quota exceeded")},
+			want: &Row{
+				AppName:    "weather_app",
+				UserID:     "u1",
+				SessionID:  "s1",
+				ModelCalls: 1,
+				Outcome:    OutcomeError,
+				Error:      "quota exceeded",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &fakeWriter{}
+			a, err := New(t.Context(), Config{
+				Writer:        w,
+				FlushInterval: time.Hour,
+				Cost: func(agentName string, u *genai.GenerateContentResponseUsageMetadata) float64 {
+					if agentName != "weather_agent" {
+						t.Errorf("cost of agent %q, want weather_agent", agentName)
+					}
+					return float64(u.TotalTokenCount) / 100
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			runAgent(t, a, tt.llm)
+			if err := a.Close(t.Context()); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			if len(w.rows) != 1 {
+				t.Fatalf("got %d rows, want 1", len(w.rows))
+			}
+			got := w.rows[0]
+			if got.InvocationID == "" || got.StartTime.IsZero() || got.EndTime.Before(got.StartTime) {
+				t.Errorf("row has invocation ID %q, start %v, end %v", got.InvocationID, got.StartTime, got.EndTime)
+			}
+			ignore := cmpopts.IgnoreFields(Row{}, "InvocationID", "StartTime", "EndTime", "LatencyMS", "ModelLatencyMS", "ToolLatencyMS")
+			ignoreTool := cmpopts.IgnoreFields(ToolUsage{}, "LatencyMS")
+			if diff := cmp.Diff(tt.want, got, ignore, ignoreTool, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("row mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAnalyticsBatching(t *testing.T) {
+	w := &fakeWriter{}
+	a, err := New(t.Context(), Config{Writer: w, BatchSize: 1, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(t.Context())
+
+	runAgent(t, a, &scriptedModel{responses: []*model.LLMResponse{{Content: genai.NewContentFromText("hi", genai.RoleModel)}}})
+
+	// A full batch is written without waiting for the flush interval.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w.mu.Lock()
+		n := len(w.rows)
+		w.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d rows after a full batch, want 1", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAnalyticsWriteError(t *testing.T) {
+	w := &fakeWriter{err: errors.New("table not found")}
+	a, err := New(t.Context(), Config{Writer: w, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runAgent(t, a, &scriptedModel{responses: []*model.LLMResponse{{Content: genai.NewContentFromText("hi", genai.RoleModel)}}})
+	if err := a.Close(t.Context()); err == nil {
+		t.Errorf("Close() succeeded, want the write error")
+	}
+}
+
+func TestAnalyticsWriteRetry(t *testing.T) {
+	w := &fakeWriter{err: errors.New("backend unavailable")}
+	var dropErrs []error
+	a, err := New(t.Context(), Config{
+		Writer:         w,
+		FlushInterval:  time.Hour,
+		MaxAttempts:    2,
+		MaxPendingRows: 2,
+		OnError:        func(err error) { dropErrs = append(dropErrs, err) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(t.Context())
+	hi := func() model.LLM {
+		return &scriptedModel{responses: []*model.LLMResponse{{Content: genai.NewContentFromText("hi", genai.RoleModel)}}}
+	}
+	setErr := func(err error) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.err = err
+	}
+
+	// A failed row is written with the next batch.
+	runAgent(t, a, hi())
+	if err := a.flush(t.Context()); err == nil {
+		t.Fatal("flush() succeeded, want the write error")
+	}
+	setErr(nil)
+	runAgent(t, a, hi())
+	if err := a.flush(t.Context()); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+	if len(w.rows) != 2 {
+		t.Errorf("got %d rows after the retry, want 2", len(w.rows))
+	}
+
+	// A row is dropped after MaxAttempts writes.
+	setErr(errors.New("backend unavailable"))
+	runAgent(t, a, hi())
+	a.flush(t.Context())
+	if err := a.flush(t.Context()); err == nil || !strings.Contains(err.Error(), "dropped 1 rows") {
+		t.Errorf("flush() error = %v, want the dropped rows", err)
+	}
+
+	// The oldest rows are dropped beyond MaxPendingRows.
+	for range 3 {
+		runAgent(t, a, hi())
+	}
+	if len(dropErrs) != 1 || len(a.pending) != 2 {
+		t.Errorf("got %d drop errors and %d pending rows, want 1 and 2", len(dropErrs), len(a.pending))
+	}
+	setErr(nil)
+}
+
+func TestNewRequiresTable(t *testing.T) {
+	if _, err := New(t.Context(), Config{ProjectID: "p"}); err == nil {
+		t.Errorf("New() without dataset and table succeeded, want error")
+	}
+}
+
+func TestRowValuesMatchSchema(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 123456789, time.FixedZone("CET", 3600))
+	r := &Row{InvocationID: "e-1", StartTime: start, Tools: []*ToolUsage{{Name: "t", Calls: 2}}}
+	values := r.values()
+
+	var columns []string
+	for _, f := range Schema().Fields {
+		columns = append(columns, f.Name)
+		if _, ok := values[f.Name]; !ok {
+			t.Errorf("row has no value for column %q", f.Name)
+		}
+	}
+	for name := range values {
+		if !slices.Contains(columns, name) {
+			t.Errorf("row value %q is not a column of the schema", name)
+		}
+	}
+	if got, want := values["start_time"], "2025-03-01T11:00:00.123456Z"; got != want {
+		t.Errorf("start_time = %v, want %v", got, want)
+	}
+	if got := values["agent_path"]; got == nil {
+		t.Errorf("agent_path = nil, want an empty list")
+	}
+}
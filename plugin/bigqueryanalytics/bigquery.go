@@ -0,0 +1,204 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigqueryanalytics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+)
+
+// timestampFormat is the format of the TIMESTAMP values accepted by the
+// BigQuery streaming inserts, which have microsecond precision.
+const timestampFormat = "2006-01-02T15:04:05.999999Z07:00"
+
+// Schema returns the schema of the analytics table:
+//
+//	invocation_id      STRING    ID of the invocation started by the runner
+//	app_name           STRING
+//	user_id            STRING
+//	session_id         STRING
+//	start_time         TIMESTAMP the table is partitioned by day of start_time
+//	end_time           TIMESTAMP
+//	latency_ms         INTEGER   duration of the invocation
+//	model_latency_ms   INTEGER   time spent in model calls
+//	tool_latency_ms    INTEGER   time spent in tool calls
+//	agent_path         STRING    REPEATED, agents in order of their first event
+//	model_calls        INTEGER
+//	prompt_tokens      INTEGER
+//	cached_tokens      INTEGER
+//	candidates_tokens  INTEGER
+//	thoughts_tokens    INTEGER
+//	total_tokens       INTEGER
+//	cost_usd           FLOAT     only if Config.Cost is set
+//	tool_calls         INTEGER
+//	tools              RECORD    REPEATED, name, calls, errors and latency_ms of each tool
+//	outcome            STRING    SUCCESS, ERROR or INCOMPLETE
+//	error              STRING    first error of the invocation
+func Schema() *bigquery.TableSchema {
+	field := func(name, typ, description string) *bigquery.TableFieldSchema {
+		return &bigquery.TableFieldSchema{Name: name, Type: typ, Mode: "NULLABLE", Description: description}
+	}
+	repeated := func(f *bigquery.TableFieldSchema) *bigquery.TableFieldSchema {
+		f.Mode = "REPEATED"
+		return f
+	}
+	required := func(f *bigquery.TableFieldSchema) *bigquery.TableFieldSchema {
+		f.Mode = "REQUIRED"
+		return f
+	}
+	tools := repeated(field("tools", "RECORD", "Usage of each tool."))
+	tools.Fields = []*bigquery.TableFieldSchema{
+		field("name", "STRING", "Name of the tool."),
+		field("calls", "INTEGER", "Number of calls."),
+		field("errors", "INTEGER", "Number of failed calls."),
+		field("latency_ms", "INTEGER", "Time spent in the calls."),
+	}
+	return &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+		required(field("invocation_id", "STRING", "ID of the invocation started by the runner.")),
+		field("app_name", "STRING", "Name of the app."),
+		field("user_id", "STRING", "ID of the user."),
+		field("session_id", "STRING", "ID of the session."),
+		required(field("start_time", "TIMESTAMP", "Start of the invocation.")),
+		field("end_time", "TIMESTAMP", "End of the invocation."),
+		field("latency_ms", "INTEGER", "Duration of the invocation."),
+		field("model_latency_ms", "INTEGER", "Time spent in model calls."),
+		field("tool_latency_ms", "INTEGER", "Time spent in tool calls."),
+		repeated(field("agent_path", "STRING", "Agents which produced events, in order of their first event.")),
+		field("model_calls", "INTEGER", "Number of model calls."),
+		field("prompt_tokens", "INTEGER", "Prompt tokens of all model calls."),
+		field("cached_tokens", "INTEGER", "Cached prompt tokens of all model calls."),
+		field("candidates_tokens", "INTEGER", "Response tokens of all model calls."),
+		field("thoughts_tokens", "INTEGER", "Thinking tokens of all model calls."),
+		field("total_tokens", "INTEGER", "Total tokens of all model calls."),
+		field("cost_usd", "FLOAT", "Cost of all model calls in USD."),
+		field("tool_calls", "INTEGER", "Number of tool calls."),
+		tools,
+		field("outcome", "STRING", "SUCCESS, ERROR or INCOMPLETE."),
+		field("error", "STRING", "First error of the invocation."),
+	}}
+}
+
+// CreateTable creates the analytics table with [Schema], partitioned by day
+// of start_time.
+func CreateTable(ctx context.Context, projectID, datasetID, tableID string, opts ...option.ClientOption) error {
+	svc, err := bigquery.NewService(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create BigQuery client: %w", err)
+	}
+	table := &bigquery.Table{
+		TableReference: &bigquery.TableReference{
+			ProjectId: projectID,
+			DatasetId: datasetID,
+			TableId:   tableID,
+		},
+		Schema:           Schema(),
+		TimePartitioning: &bigquery.TimePartitioning{Type: "DAY", Field: "start_time"},
+		Description:      "Agent invocation analytics written by the ADK BigQuery analytics plugin.",
+	}
+	if _, err := svc.Tables.Insert(projectID, datasetID, table).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("failed to create table %s.%s.%s: %w", projectID, datasetID, tableID, err)
+	}
+	return nil
+}
+
+// NewTableWriter returns a [Writer] which streams the rows to the BigQuery
+// table.
+func NewTableWriter(ctx context.Context, projectID, datasetID, tableID string, opts ...option.ClientOption) (Writer, error) {
+	svc, err := bigquery.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BigQuery client: %w", err)
+	}
+	return &tableWriter{svc: svc, projectID: projectID, datasetID: datasetID, tableID: tableID}, nil
+}
+
+type tableWriter struct {
+	svc                           *bigquery.Service
+	projectID, datasetID, tableID string
+}
+
+func (w *tableWriter) Write(ctx context.Context, rows []*Row) error {
+	req := &bigquery.TableDataInsertAllRequest{}
+	for _, r := range rows {
+		req.Rows = append(req.Rows, &bigquery.TableDataInsertAllRequestRows{
+			// The invocation ID deduplicates the inserts retried after a
+			// failure, see Config.MaxAttempts.
+			InsertId: r.InvocationID,
+			Json:     r.values(),
+		})
+	}
+	resp, err := w.svc.Tabledata.InsertAll(w.projectID, w.datasetID, w.tableID, req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to insert rows: %w", err)
+	}
+	if len(resp.InsertErrors) > 0 {
+		var msgs []string
+		for _, ie := range resp.InsertErrors {
+			for _, e := range ie.Errors {
+				msgs = append(msgs, fmt.Sprintf("row %d: %s", ie.Index, e.Message))
+			}
+		}
+		return fmt.Errorf("failed to insert %d rows: %s", len(resp.InsertErrors), strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// values returns the column values of the row.
+func (r *Row) values() map[string]bigquery.JsonValue {
+	tools := make([]map[string]bigquery.JsonValue, len(r.Tools))
+	for i, t := range r.Tools {
+		tools[i] = map[string]bigquery.JsonValue{
+			"name":       t.Name,
+			"calls":      t.Calls,
+			"errors":     t.Errors,
+			"latency_ms": t.LatencyMS,
+		}
+	}
+	agentPath := r.AgentPath
+	if agentPath == nil {
+		agentPath = []string{}
+	}
+	return map[string]bigquery.JsonValue{
+		"invocation_id":     r.InvocationID,
+		"app_name":          r.AppName,
+		"user_id":           r.UserID,
+		"session_id":        r.SessionID,
+		"start_time":        formatTimestamp(r.StartTime),
+		"end_time":          formatTimestamp(r.EndTime),
+		"latency_ms":        r.LatencyMS,
+		"model_latency_ms":  r.ModelLatencyMS,
+		"tool_latency_ms":   r.ToolLatencyMS,
+		"agent_path":        agentPath,
+		"model_calls":       r.ModelCalls,
+		"prompt_tokens":     r.PromptTokens,
+		"cached_tokens":     r.CachedTokens,
+		"candidates_tokens": r.CandidatesTokens,
+		"thoughts_tokens":   r.ThoughtsTokens,
+		"total_tokens":      r.TotalTokens,
+		"cost_usd":          r.CostUSD,
+		"tool_calls":        r.ToolCalls,
+		"tools":             tools,
+		"outcome":           r.Outcome,
+		"error":             r.Error,
+	}
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampFormat)
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugin provides plugins: sets of callbacks registered on the runner
+// which apply to the whole run, i.e. to every agent, model call and tool call,
+// without adding them to the callbacks of each agent. Plugins suit
+// cross-cutting concerns, e.g. logging, policy enforcement or caching.
+//
+// The plugins run in the order they are registered, and before the callbacks
+// of the agents. As with the agent callbacks, the first callback returning a
+// non-nil result short-circuits the remaining ones, including the agent
+// callbacks.
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// Config is used to create a [Plugin]. All callbacks are optional.
+type Config struct {
+	// Name must be unique among the plugins of a runner.
+	Name string
+
+	// OnUserMessageCallback is called with the user message, before it's
+	// added to the session. A non-nil result replaces the message.
+	OnUserMessageCallback OnUserMessageCallback
+	// BeforeRunCallback is called before the agent runs. A non-nil result
+	// ends the run with an event containing the result.
+	BeforeRunCallback BeforeRunCallback
+	// AfterRunCallback is called once all the events of the run have been
+	// yielded.
+	AfterRunCallback AfterRunCallback
+	// OnEventCallback is called with each event, after it's added to the
+	// session. A non-nil result replaces the event returned to the caller.
+	OnEventCallback OnEventCallback
+
+	BeforeAgentCallback agent.BeforeAgentCallback
+	AfterAgentCallback  agent.AfterAgentCallback
+	BeforeModelCallback BeforeModelCallback
+	AfterModelCallback  AfterModelCallback
+	BeforeToolCallback  BeforeToolCallback
+	AfterToolCallback   AfterToolCallback
+
+	// CloseCallback is called when the runner is closed, to flush and
+	// release the resources of the plugin, see runner.Runner.Close.
+	CloseCallback CloseCallback
+}
+
+type OnUserMessageCallback func(ctx agent.InvocationContext, msg *genai.Content) (*genai.Content, error)
+
+type BeforeRunCallback func(ctx agent.InvocationContext) (*genai.Content, error)
+
+type AfterRunCallback func(ctx agent.InvocationContext)
+
+type OnEventCallback func(ctx agent.InvocationContext, event *session.Event) (*session.Event, error)
+
+type CloseCallback func(ctx context.Context) error
+
+// BeforeModelCallback has the semantics of llmagent.BeforeModelCallback.
+type BeforeModelCallback func(ctx agent.CallbackContext, llmRequest *model.LLMRequest) (*model.LLMResponse, error)
+
+// AfterModelCallback has the semantics of llmagent.AfterModelCallback.
+type AfterModelCallback func(ctx agent.CallbackContext, llmResponse *model.LLMResponse, llmResponseError error) (*model.LLMResponse, error)
+
+// BeforeToolCallback has the semantics of llmagent.BeforeToolCallback.
+type BeforeToolCallback func(ctx tool.Context, tool tool.Tool, args map[string]any) (map[string]any, error)
+
+// AfterToolCallback has the semantics of llmagent.AfterToolCallback.
+type AfterToolCallback func(ctx tool.Context, tool tool.Tool, args, result map[string]any, err error) (map[string]any, error)
+
+// Plugin is a set of callbacks applied to the whole run. Register plugins
+// with runner.Config.Plugins.
+type Plugin struct {
+	cfg Config
+}
+
+// New creates a plugin.
+func New(cfg Config) (*Plugin, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("plugin name is required")
+	}
+	return &Plugin{cfg: cfg}, nil
+}
+
+// Name returns the name of the plugin.
+func (p *Plugin) Name() string { return p.cfg.Name }
+
+func (p *Plugin) OnUserMessageCallback() OnUserMessageCallback {
+	return p.cfg.OnUserMessageCallback
+}
+
+func (p *Plugin) BeforeRunCallback() BeforeRunCallback { return p.cfg.BeforeRunCallback }
+
+func (p *Plugin) AfterRunCallback() AfterRunCallback { return p.cfg.AfterRunCallback }
+
+func (p *Plugin) OnEventCallback() OnEventCallback { return p.cfg.OnEventCallback }
+
+func (p *Plugin) BeforeAgentCallback() agent.BeforeAgentCallback {
+	return p.cfg.BeforeAgentCallback
+}
+
+func (p *Plugin) AfterAgentCallback() agent.AfterAgentCallback {
+	return p.cfg.AfterAgentCallback
+}
+
+func (p *Plugin) BeforeModelCallback() BeforeModelCallback { return p.cfg.BeforeModelCallback }
+
+func (p *Plugin) AfterModelCallback() AfterModelCallback { return p.cfg.AfterModelCallback }
+
+func (p *Plugin) BeforeToolCallback() BeforeToolCallback { return p.cfg.BeforeToolCallback }
+
+func (p *Plugin) AfterToolCallback() AfterToolCallback { return p.cfg.AfterToolCallback }
+
+func (p *Plugin) CloseCallback() CloseCallback { return p.cfg.CloseCallback }
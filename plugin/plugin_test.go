@@ -0,0 +1,45 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin_test
+
+import (
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/genai"
+)
+
+func TestNew(t *testing.T) {
+	if _, err := plugin.New(plugin.Config{}); err == nil {
+		t.Error("New() without a name succeeded, want error")
+	}
+
+	p, err := plugin.New(plugin.Config{
+		Name: "test",
+		BeforeAgentCallback: func(agent.CallbackContext) (*genai.Content, error) {
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "test" {
+		t.Errorf("Name() = %q, want %q", p.Name(), "test")
+	}
+	if p.BeforeAgentCallback() == nil || p.AfterAgentCallback() != nil {
+		t.Error("callbacks don't match the config")
+	}
+}
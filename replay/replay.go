@@ -0,0 +1,256 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package replay records the model responses and the tool results of
+// invocations into a trace, and replays them, so that the invocations can
+// be re-run deterministically, e.g. for debugging or golden tests.
+//
+// A [Recorder] records the invocations of the runner it's registered with,
+// see runner.Config.Plugins. A runner configured with runner.Config.Replay
+// serves the recorded responses and results instead of calling the models
+// and the tools, while the callbacks, the session and the agent transfers
+// work as usual. Use runner.Config.NewID and runner.Config.Now to make the
+// IDs and the timestamps of the events deterministic too.
+//
+// The responses and the results are recorded as returned by the models and
+// the tools. The partial responses aren't recorded: a replayed invocation
+// doesn't stream. The failed tool calls and the model calls of the
+// summarizers aren't recorded either.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"sync"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/tool"
+)
+
+// Trace holds the recorded model responses and tool results.
+type Trace struct {
+	ModelCalls []ModelCall `json:"modelCalls,omitempty"`
+	ToolCalls  []ToolCall  `json:"toolCalls,omitempty"`
+}
+
+// ModelCall is a recorded model response. The model calls of an agent are
+// replayed in the order they were recorded.
+type ModelCall struct {
+	Agent    string             `json:"agent"`
+	Response *model.LLMResponse `json:"response,omitempty"`
+	// Error is the message of the error of the model call, if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+// ToolCall is a recorded tool result. It is replayed for a call of the tool
+// by the same agent with the same arguments.
+type ToolCall struct {
+	Agent  string         `json:"agent"`
+	Name   string         `json:"name"`
+	Args   map[string]any `json:"args,omitempty"`
+	Result map[string]any `json:"result"`
+}
+
+// ReadFile reads a trace written by [Trace.WriteFile].
+func ReadFile(name string) (*Trace, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var t Trace
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to decode trace %q: %w", name, err)
+	}
+	return &t, nil
+}
+
+// WriteFile writes the trace as JSON.
+func (t *Trace) WriteFile(name string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trace: %w", err)
+	}
+	return os.WriteFile(name, data, 0o644)
+}
+
+// Recorder records the model responses and the tool results of the runs.
+type Recorder struct {
+	mu    sync.Mutex
+	trace Trace
+}
+
+// NewRecorder creates a Recorder with an empty trace.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Plugin returns the plugin recording the runs, to register with
+// runner.Config.Plugins.
+func (r *Recorder) Plugin() *plugin.Plugin {
+	p, _ := plugin.New(plugin.Config{
+		Name: "replay_recorder",
+		AfterModelCallback: func(ctx agent.CallbackContext, resp *model.LLMResponse, err error) (*model.LLMResponse, error) {
+			call := ModelCall{Agent: ctx.AgentName()}
+			switch {
+			case err != nil:
+				call.Error = err.Error()
+			case resp == nil || resp.Partial:
+				return nil, nil
+			default:
+				// The later response processors may modify the response in
+				// place, e.g. to separate the thoughts, so a copy of what the
+				// model returned is recorded.
+				call.Response = clone(resp)
+			}
+			r.mu.Lock()
+			r.trace.ModelCalls = append(r.trace.ModelCalls, call)
+			r.mu.Unlock()
+			return nil, nil
+		},
+		AfterToolCallback: func(ctx tool.Context, t tool.Tool, args, result map[string]any, err error) (map[string]any, error) {
+			if err != nil {
+				return nil, nil
+			}
+			r.mu.Lock()
+			r.trace.ToolCalls = append(r.trace.ToolCalls, ToolCall{Agent: ctx.AgentName(), Name: t.Name(), Args: clone(args), Result: clone(result)})
+			r.mu.Unlock()
+			return nil, nil
+		},
+	})
+	return p
+}
+
+// Trace returns the trace recorded so far.
+func (r *Recorder) Trace() *Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Trace{
+		ModelCalls: append([]ModelCall(nil), r.trace.ModelCalls...),
+		ToolCalls:  append([]ToolCall(nil), r.trace.ToolCalls...),
+	}
+}
+
+// Replayer serves the model responses and the tool results of a trace. The
+// runner replays the trace of runner.Config.Replay with a Replayer.
+type Replayer struct {
+	mu sync.Mutex
+	// modelCalls are the recorded model calls of each agent, in order.
+	modelCalls map[string][]ModelCall
+	toolCalls  []recordedToolCall
+}
+
+type recordedToolCall struct {
+	ToolCall
+	// args is the JSON encoding of the arguments.
+	args string
+	used bool
+}
+
+// NewReplayer creates a Replayer of the trace.
+func NewReplayer(t *Trace) (*Replayer, error) {
+	r := &Replayer{modelCalls: make(map[string][]ModelCall)}
+	for _, call := range t.ModelCalls {
+		r.modelCalls[call.Agent] = append(r.modelCalls[call.Agent], call)
+	}
+	for _, call := range t.ToolCalls {
+		args, err := json.Marshal(call.Args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode the arguments of the recorded call of tool %q: %w", call.Name, err)
+		}
+		r.toolCalls = append(r.toolCalls, recordedToolCall{ToolCall: call, args: string(args)})
+	}
+	return r, nil
+}
+
+// Model returns the model replaying the recorded responses of the agent, in
+// order. A call beyond the recorded ones fails.
+func (r *Replayer) Model(agentName string) model.LLM {
+	return &replayModel{replayer: r, agent: agentName}
+}
+
+// ToolResult returns the result of the first unused recorded call of the
+// tool by the agent with the same arguments. It returns false if there's
+// none.
+func (r *Replayer) ToolResult(agentName, toolName string, args map[string]any) (map[string]any, bool) {
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return nil, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.toolCalls {
+		call := &r.toolCalls[i]
+		if call.used || call.Agent != agentName || call.Name != toolName || call.args != string(encoded) {
+			continue
+		}
+		call.used = true
+		return clone(call.Result), true
+	}
+	return nil, false
+}
+
+// nextModelCall returns the next recorded model call of the agent.
+func (r *Replayer) nextModelCall(agentName string) (ModelCall, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := r.modelCalls[agentName]
+	if len(calls) == 0 {
+		return ModelCall{}, false
+	}
+	r.modelCalls[agentName] = calls[1:]
+	return calls[0], true
+}
+
+type replayModel struct {
+	replayer *Replayer
+	agent    string
+}
+
+func (m *replayModel) Name() string {
+	return "replay"
+}
+
+func (m *replayModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		call, ok := m.replayer.nextModelCall(m.agent)
+		switch {
+		case !ok:
+			yield(nil, fmt.Errorf("replay: no recorded model call left for agent %q", m.agent))
+		case call.Error != "":
+			yield(nil, errors.New(call.Error))
+		default:
+			yield(clone(call.Response), nil)
+		}
+	}
+}
+
+// clone returns a deep copy of v, so that the agents can't modify the
+// recorded or replayed values.
+func clone[T any](v T) T {
+	var out T
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
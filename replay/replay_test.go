@@ -0,0 +1,187 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/replay"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+// failingModel fails the test if it's called.
+type failingModel struct{ t *testing.T }
+
+func (m failingModel) Name() string { return "failing" }
+
+func (m failingModel) GenerateContent(context.Context, *model.LLMRequest, bool) iter.Seq2[*model.LLMResponse, error] {
+	m.t.Error("model called during the replay")
+	return func(yield func(*model.LLMResponse, error) bool) {
+		yield(nil, errors.New("unexpected call"))
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	counter, err := functiontool.New(functiontool.Config{Name: "next_ticket", Description: "returns the next ticket number"},
+		func(tool.Context, map[string]any) (map[string]any, error) {
+			calls++
+			return map[string]any{"ticket": calls}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(t *testing.T, m model.LLM, cfg runner.Config) []string {
+		t.Helper()
+		a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, Tools: []tool.Tool{counter}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := t.Context()
+		cfg.AppName, cfg.Agent, cfg.SessionService = "app", a, session.InMemoryService()
+		if _, err := cfg.SessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s"}); err != nil {
+			t.Fatal(err)
+		}
+		r, err := runner.New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for ev, err := range r.Run(ctx, "user", "s", genai.NewContentFromText("open a ticket", genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			for _, p := range ev.Content.Parts {
+				switch {
+				case p.FunctionCall != nil:
+					got = append(got, "call:"+p.FunctionCall.Name)
+				case p.FunctionResponse != nil:
+					got = append(got, fmt.Sprintf("response:%v", p.FunctionResponse.Response["ticket"]))
+				default:
+					got = append(got, p.Text)
+				}
+			}
+		}
+		return got
+	}
+
+	recorder := replay.NewRecorder()
+	recorded := run(t, &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromFunctionCall("next_ticket", map[string]any{}, genai.RoleModel),
+		genai.NewContentFromText("Your ticket is open.", genai.RoleModel),
+	}}, runner.Config{Plugins: []*plugin.Plugin{recorder.Plugin()}})
+
+	name := filepath.Join(t.TempDir(), "trace.json")
+	if err := recorder.Trace().WriteFile(name); err != nil {
+		t.Fatal(err)
+	}
+	trace, err := replay.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trace.ModelCalls) != 2 || len(trace.ToolCalls) != 1 {
+		t.Fatalf("trace has %d model calls and %d tool calls, want 2 and 1", len(trace.ModelCalls), len(trace.ToolCalls))
+	}
+
+	replayed := run(t, failingModel{t}, runner.Config{Replay: trace})
+	if diff := cmp.Diff(recorded, replayed); diff != "" {
+		t.Errorf("replayed events mismatch (-recorded +replayed):\n%s", diff)
+	}
+	if calls != 1 {
+		t.Errorf("tool called %d times, want once, during the recording", calls)
+	}
+}
+
+func TestRecorder_CopiesResponses(t *testing.T) {
+	recorder := replay.NewRecorder()
+	// The plugin registered after the recorder modifies the response in place.
+	rewrite, err := plugin.New(plugin.Config{
+		Name: "rewrite",
+		AfterModelCallback: func(ctx agent.CallbackContext, resp *model.LLMResponse, err error) (*model.LLMResponse, error) {
+			if resp != nil && resp.Content != nil {
+				resp.Content.Parts[0].Text = "rewritten"
+			}
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromText("original", genai.RoleModel),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService, Plugins: []*plugin.Plugin{recorder.Plugin(), rewrite}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range r.Run(t.Context(), "user", "s", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	calls := recorder.Trace().ModelCalls
+	if len(calls) != 1 {
+		t.Fatalf("trace has %d model calls, want 1", len(calls))
+	}
+	if got := calls[0].Response.Content.Parts[0].Text; got != "original" {
+		t.Errorf("recorded response text = %q, want the text returned by the model", got)
+	}
+}
+
+func TestReplayer_Unrecorded(t *testing.T) {
+	r, err := replay.NewReplayer(&replay.Trace{
+		ToolCalls: []replay.ToolCall{{Agent: "agent", Name: "search", Args: map[string]any{"q": "go"}, Result: map[string]any{"n": 1}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.ToolResult("agent", "search", map[string]any{"q": "rust"}); ok {
+		t.Error("ToolResult() with other arguments = true, want false")
+	}
+	if _, ok := r.ToolResult("agent", "search", map[string]any{"q": "go"}); !ok {
+		t.Error("ToolResult() = false, want the recorded result")
+	}
+	if _, ok := r.ToolResult("agent", "search", map[string]any{"q": "go"}); ok {
+		t.Error("ToolResult() of an already replayed call = true, want false")
+	}
+	for _, err := range r.Model("agent").GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+		if err == nil {
+			t.Error("GenerateContent() of an unrecorded model call succeeded, want an error")
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"fmt"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+)
+
+// UnknownAuthorPolicy decides how the runner handles the session events of
+// agents which are not in the agent tree, e.g. agents removed since the
+// events were appended, when it picks the agent to continue the
+// conversation. The events of renamed agents are routed with
+// Config.AgentAliases instead.
+type UnknownAuthorPolicy string
+
+const (
+	// UnknownAuthorIgnore logs and skips the events of unknown agents: the
+	// conversation continues with the agent of an earlier event, or else
+	// with the root agent.
+	UnknownAuthorIgnore UnknownAuthorPolicy = "ignore"
+	// UnknownAuthorFail fails the run with an [*UnknownAuthorError].
+	UnknownAuthorFail UnknownAuthorPolicy = "fail"
+)
+
+// UnknownAuthorError is returned by the runs of a runner with the policy
+// UnknownAuthorFail, if the agent to continue the conversation is decided
+// by an event of an agent which is not in the agent tree.
+type UnknownAuthorError struct {
+	Author  string
+	EventID string
+}
+
+func (e *UnknownAuthorError) Error() string {
+	return fmt.Sprintf("event %s is from the unknown agent %q, see runner.Config.AgentAliases", e.EventID, e.Author)
+}
+
+// validateAuthorConfig checks that the aliases name agents of the tree and
+// aren't names of agents themselves.
+func validateAuthorConfig(root agent.Agent, aliases map[string]string, policy UnknownAuthorPolicy) error {
+	switch policy {
+	case "", UnknownAuthorIgnore, UnknownAuthorFail:
+	default:
+		return fmt.Errorf("unknown policy for unknown authors %q", policy)
+	}
+	for alias, name := range aliases {
+		if findAgent(root, alias) != nil {
+			return fmt.Errorf("agent alias %q is the name of an agent of the tree", alias)
+		}
+		if findAgent(root, name) == nil {
+			return fmt.Errorf("agent alias %q refers to the unknown agent %q", alias, name)
+		}
+	}
+	return nil
+}
+
+// agentByAuthor returns the agent of the tree which authored the event,
+// following the agent aliases, or nil if it's unknown.
+func (r *Runner) agentByAuthor(author string) agent.Agent {
+	if name, ok := r.agentAliases[author]; ok {
+		author = name
+	}
+	return findAgent(r.rootAgent, author)
+}
+
+// unknownAuthor applies the policy for unknown authors to the event: it
+// returns an error if the run fails, or nil if the event is skipped.
+func (r *Runner) unknownAuthor(event *session.Event) error {
+	if r.unknownAuthorPolicy == UnknownAuthorFail {
+		return &UnknownAuthorError{Author: event.Author, EventID: event.ID}
+	}
+	r.logger().Warn("event from an unknown agent", "author", event.Author, "event_id", event.ID)
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// FailureConfig configures the classification of the failed invocations,
+// see [Config.Failures].
+type FailureConfig struct {
+	// Classify returns the category of the failure of an invocation, given
+	// the error, or the error event, which ended it.
+	// Optional: defaults to ClassifyFailure.
+	Classify func(err error, event *session.Event) session.FailureCategory
+}
+
+// ClassifyFailure is the default classification of the failures: the budget
+// errors and events, the errors of the model and tool calls, the responses
+// blocked by the model are classified as such, the other error events as
+// model errors and the other errors as bugs.
+func ClassifyFailure(err error, event *session.Event) session.FailureCategory {
+	if err != nil {
+		var budgetErr *agent.TokenBudgetExceededError
+		var windowErr *model.ContextWindowExceededError
+		var toolErr *tool.CallError
+		var modelErr *model.CallError
+		switch {
+		case errors.As(err, &budgetErr), errors.As(err, &windowErr):
+			return session.FailureBudget
+		case errors.As(err, &toolErr):
+			return session.FailureTool
+		case errors.As(err, &modelErr):
+			return session.FailureModel
+		}
+		return session.FailureBug
+	}
+	switch {
+	case event == nil:
+		return session.FailureBug
+	case event.ErrorCode == agent.TokenBudgetExceededCode:
+		return session.FailureBudget
+	case event.Blocked != nil, model.IsBlockingFinishReason(genai.FinishReason(event.ErrorCode)):
+		return session.FailureGuardrail
+	}
+	return session.FailureModel
+}
+
+// failureTracker keeps the first failure of an invocation.
+type failureTracker struct {
+	cfg     *FailureConfig
+	failure *session.Failure
+}
+
+// observe records the failure of the error or of the error event, unless a
+// failure was recorded before.
+func (t *failureTracker) observe(agentName string, err error, event *session.Event) {
+	if t == nil || t.failure != nil {
+		return
+	}
+	f := &session.Failure{Agent: agentName}
+	switch {
+	case err != nil:
+		f.Message = err.Error()
+	case event != nil && !event.Partial && event.ErrorCode != "":
+		f.Agent, f.Code, f.Message = event.Author, event.ErrorCode, event.ErrorMessage
+	default:
+		return
+	}
+	classify := ClassifyFailure
+	if t.cfg.Classify != nil {
+		classify = t.cfg.Classify
+	}
+	f.Category = classify(err, event)
+	t.failure = f
+}
+
+// event returns the terminal event of the failed invocation, nil if the
+// invocation didn't fail or was canceled. The failure is counted in the
+// metrics.
+func (t *failureTracker) event(ctx agent.InvocationContext) *session.Event {
+	if t == nil || t.failure == nil || ctx.Err() != nil {
+		return nil
+	}
+	telemetry.RecordInvocationFailure(ctx, string(t.failure.Category), t.failure.Agent)
+	event := session.NewEvent(ctx.InvocationID())
+	event.Author = ctx.Agent().Name()
+	event.Branch = ctx.Branch()
+	event.TurnComplete = true
+	event.Actions.Failure = t.failure
+	return event
+}
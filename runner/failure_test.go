@@ -0,0 +1,137 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// blockingModel responds as a model whose safety filters blocked the
+// response.
+type blockingModel struct{}
+
+func (blockingModel) Name() string { return "blocking" }
+
+func (blockingModel) GenerateContent(context.Context, *model.LLMRequest, bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		yield(&model.LLMResponse{ErrorCode: "SAFETY", ErrorMessage: "blocked", FinishReason: genai.FinishReasonSafety}, nil)
+	}
+}
+
+func TestRunner_Failures(t *testing.T) {
+	tests := []struct {
+		name  string
+		agent agent.Agent
+		want  *session.Failure
+	}{
+		{
+			name:  "success",
+			agent: must(llmagent.New(llmagent.Config{Name: "ok_agent", Model: &scriptedModel{responses: []*genai.Content{genai.NewContentFromText("hi", genai.RoleModel)}}})),
+		},
+		{
+			name:  "model error",
+			agent: must(llmagent.New(llmagent.Config{Name: "model_agent", Model: &scriptedModel{}})),
+			want:  &session.Failure{Category: session.FailureModel, Agent: "model_agent", Message: "no more responses"},
+		},
+		{
+			name: "unknown tool",
+			agent: must(llmagent.New(llmagent.Config{Name: "tool_agent", Model: &scriptedModel{responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("missing", nil, genai.RoleModel),
+			}}})),
+			want: &session.Failure{Category: session.FailureTool, Agent: "tool_agent", Message: `unknown tool: "missing"`},
+		},
+		{
+			name:  "guardrail",
+			agent: must(llmagent.New(llmagent.Config{Name: "safe_agent", Model: blockingModel{}})),
+			want:  &session.Failure{Category: session.FailureGuardrail, Agent: "safe_agent", Code: "SAFETY", Message: "blocked"},
+		},
+		{
+			name: "bug",
+			agent: must(agent.New(agent.Config{
+				Name: "buggy_agent",
+				Run: func(agent.InvocationContext) iter.Seq2[*session.Event, error] {
+					return func(yield func(*session.Event, error) bool) {
+						yield(nil, fmt.Errorf("nil map"))
+					}
+				},
+			})),
+			want: &session.Failure{Category: session.FailureBug, Agent: "buggy_agent", Message: "nil map"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := t.Context()
+			sessionService := session.InMemoryService()
+			if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+				t.Fatal(err)
+			}
+			r, err := New(Config{AppName: "app", Agent: tt.agent, SessionService: sessionService, Failures: &FailureConfig{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got *session.Failure
+			var last *session.Event
+			for ev := range r.Run(ctx, "user", "session", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+				if ev != nil {
+					last = ev
+					if ev.Actions.Failure != nil {
+						got = ev.Actions.Failure
+					}
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("failure mismatch (-want +got):\n%s", diff)
+			}
+			if got != nil && last.Actions.Failure == nil {
+				t.Errorf("the failure event is not the last event")
+			}
+		})
+	}
+}
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		event *session.Event
+		want  session.FailureCategory
+	}{
+		{name: "token budget", err: fmt.Errorf("run: %w", &agent.TokenBudgetExceededError{}), want: session.FailureBudget},
+		{name: "context window", err: &model.ContextWindowExceededError{}, want: session.FailureBudget},
+		{name: "model call", err: &model.CallError{Err: errors.New("503")}, want: session.FailureModel},
+		{name: "other error", err: errors.New("oops"), want: session.FailureBug},
+		{name: "budget event", event: &session.Event{LLMResponse: model.LLMResponse{ErrorCode: agent.TokenBudgetExceededCode}}, want: session.FailureBudget},
+		{name: "prohibited content", event: &session.Event{LLMResponse: model.LLMResponse{ErrorCode: "PROHIBITED_CONTENT"}}, want: session.FailureGuardrail},
+		{name: "max tokens", event: &session.Event{LLMResponse: model.LLMResponse{ErrorCode: "MAX_TOKENS"}}, want: session.FailureModel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyFailure(tt.err, tt.event); got != tt.want {
+				t.Errorf("ClassifyFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
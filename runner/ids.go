@@ -0,0 +1,60 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"time"
+
+	"google.golang.org/adk/session"
+)
+
+// eventStamper sets the IDs and timestamps of the events of an invocation
+// with the ID generator and clock of the runner, see Config.NewID and
+// Config.Now. The events are created with random IDs by the agents.
+type eventStamper struct {
+	newID func() string
+	now   func() time.Time
+	// ids maps the IDs set by the agents to the generated ones, so that the
+	// partial events and the final event of a streamed response keep sharing
+	// an ID.
+	ids map[string]string
+}
+
+// newEventStamper returns the stamper using the ID generator newID and the
+// clock now, or nil if both are nil: the events keep their random IDs and
+// their timestamps from time.Now.
+func newEventStamper(newID func() string, now func() time.Time) *eventStamper {
+	if newID == nil && now == nil {
+		return nil
+	}
+	return &eventStamper{newID: newID, now: now, ids: make(map[string]string)}
+}
+
+func (s *eventStamper) stamp(event *session.Event) {
+	if s == nil || event == nil {
+		return
+	}
+	if s.newID != nil {
+		id, ok := s.ids[event.ID]
+		if !ok {
+			id = s.newID()
+			s.ids[event.ID] = id
+		}
+		event.ID = id
+	}
+	if s.now != nil {
+		event.Timestamp = s.now()
+	}
+}
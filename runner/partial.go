@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/internal/logging"
+	"google.golang.org/adk/session"
+)
+
+// partialPruneInterval is the minimum interval between two deletions of the
+// partial events past their retention period.
+const partialPruneInterval = time.Minute
+
+// PartialEventsConfig configures the persistence of the partial events, see
+// [Config.PartialEvents].
+type PartialEventsConfig struct {
+	// Store keeps the partial events.
+	Store session.PartialEventStore
+	// Retention is how long the partial events are kept. The older events
+	// are deleted from the store by the runner, at most once a minute.
+	// Optional: if zero, the events are kept until they are deleted from
+	// the store otherwise.
+	Retention time.Duration
+}
+
+// partialEvents persists the partial events of the runs.
+type partialEvents struct {
+	cfg PartialEventsConfig
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+func newPartialEvents(cfg *PartialEventsConfig) (*partialEvents, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	if cfg.Store == nil {
+		return nil, fmt.Errorf("partial events store is required")
+	}
+	if cfg.Retention < 0 {
+		return nil, fmt.Errorf("partial events retention must not be negative, got %v", cfg.Retention)
+	}
+	return &partialEvents{cfg: *cfg}, nil
+}
+
+// append stores the partial event, deleting the events past their retention
+// period first if they weren't deleted recently.
+func (p *partialEvents) append(ctx context.Context, s session.Session, event *session.Event) error {
+	p.prune(ctx)
+	if err := p.cfg.Store.AppendPartial(ctx, s, event); err != nil {
+		return fmt.Errorf("failed to store partial event: %w", err)
+	}
+	return nil
+}
+
+func (p *partialEvents) prune(ctx context.Context) {
+	if p.cfg.Retention == 0 {
+		return
+	}
+	now := time.Now()
+	p.mu.Lock()
+	if now.Sub(p.lastPrune) < partialPruneInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastPrune = now
+	p.mu.Unlock()
+
+	// A failed deletion is retried later, it doesn't fail the run.
+	if err := p.cfg.Store.DeletePartials(ctx, &session.DeletePartialsRequest{Before: now.Add(-p.cfg.Retention)}); err != nil {
+		logging.FromContext(ctx).Error("failed to delete the old partial events", "retention", p.cfg.Retention, "error", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimited is matched by the [*RateLimitError] of the runs rejected by
+// the rate limiter.
+var ErrRateLimited = errors.New("rate limited")
+
+// RateLimitError is returned by the runs rejected by the rate limiter of
+// the runner, see [Config.RateLimiter].
+type RateLimitError struct {
+	AppName, UserID string
+	// RetryAfter is the delay after which the run would be allowed.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("the invocations of user %q of app %q are rate limited, retry after %v", e.UserID, e.AppName, e.RetryAfter)
+}
+
+func (e *RateLimitError) Is(target error) bool { return target == ErrRateLimited }
+
+// RateLimiter limits the rate of the invocations of the runner, so that a
+// noisy user can't exhaust the model quota of everyone.
+type RateLimiter interface {
+	// Wait returns when an invocation of the user of the app is allowed,
+	// or an error, e.g. a *RateLimitError, if it's rejected.
+	Wait(ctx context.Context, appName, userID string) error
+}
+
+// RateLimitConfig configures the limiter returned by [NewRateLimiter].
+type RateLimitConfig struct {
+	// QPS is the sustained number of invocations per second allowed to each
+	// user of each app.
+	QPS float64
+	// Burst is the number of invocations a user can make at once, above the
+	// sustained rate.
+	// Optional: defaults to 1.
+	Burst int
+	// Queue makes the invocations over the limit wait for their turn, until
+	// their context is done, rather than be rejected.
+	// Optional: if false, they fail with a *RateLimitError.
+	Queue bool
+}
+
+// idleLimiterSweepInterval is the minimum interval between two removals of
+// the limiters of the idle users.
+const idleLimiterSweepInterval = time.Minute
+
+// NewRateLimiter returns a rate limiter with a token bucket per user of
+// each app.
+func NewRateLimiter(cfg RateLimitConfig) (RateLimiter, error) {
+	if cfg.QPS <= 0 {
+		return nil, fmt.Errorf("rate limit QPS must be positive, got %v", cfg.QPS)
+	}
+	if cfg.Burst < 0 {
+		return nil, fmt.Errorf("rate limit burst must not be negative, got %d", cfg.Burst)
+	}
+	if cfg.Burst == 0 {
+		cfg.Burst = 1
+	}
+	return &userRateLimiter{cfg: cfg, limiters: make(map[userKey]*rate.Limiter), now: time.Now}, nil
+}
+
+type userKey struct {
+	appName, userID string
+}
+
+// userRateLimiter keeps a token bucket per user.
+type userRateLimiter struct {
+	cfg RateLimitConfig
+	now func() time.Time
+
+	mu        sync.Mutex
+	limiters  map[userKey]*rate.Limiter
+	lastSweep time.Time
+}
+
+func (l *userRateLimiter) Wait(ctx context.Context, appName, userID string) error {
+	limiter := l.limiter(appName, userID)
+	if l.cfg.Queue {
+		if err := limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("failed to wait for the rate limiter: %w", err)
+		}
+		return nil
+	}
+	now := l.now()
+	r := limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return &RateLimitError{AppName: appName, UserID: userID, RetryAfter: delay}
+	}
+	return nil
+}
+
+// limiter returns the limiter of the user. The limiters of the users whose
+// bucket is full again are removed periodically, as they behave like new
+// ones.
+func (l *userRateLimiter) limiter(appName, userID string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if now.Sub(l.lastSweep) >= idleLimiterSweepInterval {
+		l.lastSweep = now
+		for k, limiter := range l.limiters {
+			if limiter.TokensAt(now) >= float64(l.cfg.Burst) {
+				delete(l.limiters, k)
+			}
+		}
+	}
+	key := userKey{appName: appName, userID: userID}
+	limiter, ok := l.limiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(l.cfg.QPS), l.cfg.Burst)
+		l.limiters[key] = limiter
+	}
+	return limiter
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+
+	"google.golang.org/adk/agent"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// invocationState is the state of an interrupted invocation, restored from
+// its events.
+type invocationState struct {
+	// id is the ID of the invocation started by the runner.
+	id string
+	// agent is the author of the last event of an agent, empty if no agent
+	// made an event.
+	agent  string
+	branch string
+	// userContent is the user input which started the invocation.
+	userContent *genai.Content
+}
+
+// findInvocation returns the state of the invocation started by the runner
+// which the invocation with the given ID belongs to: either that invocation
+// or one of its sub-invocations, e.g. the invocation of an agent. It returns
+// an error if the session has no such invocation or if it completed.
+func findInvocation(events session.Events, invocationID string) (*invocationState, error) {
+	parents := make(map[string]string)
+	for ev := range events.All() {
+		if ev.ParentInvocationID != "" {
+			parents[ev.InvocationID] = ev.ParentInvocationID
+		}
+	}
+	root := func(id string) string {
+		// The bound protects from cycles in malformed sessions.
+		for range len(parents) {
+			parent, ok := parents[id]
+			if !ok {
+				break
+			}
+			id = parent
+		}
+		return id
+	}
+
+	id := root(invocationID)
+	var state *invocationState
+	for ev := range events.All() {
+		if root(ev.InvocationID) != id {
+			continue
+		}
+		if state == nil {
+			state = &invocationState{id: id}
+		}
+		switch {
+		case ev.Actions.EndOfInvocation:
+			return nil, fmt.Errorf("invocation %q has already completed", invocationID)
+		case ev.Actions.InvocationSummary != nil:
+		case ev.Author == "user":
+			if state.userContent == nil {
+				state.userContent = ev.Content
+			}
+		default:
+			state.agent, state.branch = ev.Author, ev.Branch
+		}
+	}
+	if state == nil {
+		return nil, fmt.Errorf("invocation %q not found in the session", invocationID)
+	}
+	return state, nil
+}
+
+// UnmarshalInvocation rehydrates an invocation context serialized with
+// json.Marshal, e.g. by another process of a distributed deployment. The
+// session, the agent and the services of the invocation are restored from
+// the runner, which must run the same agent tree as the one which
+// serialized the invocation.
+func (r *Runner) UnmarshalInvocation(ctx context.Context, data []byte, cfg agent.RunConfig) (agent.InvocationContext, error) {
+	ictx, _, err := r.rehydrate(ctx, data, &cfg)
+	return ictx, err
+}
+
+// rehydrate returns the invocation context serialized in data and its
+// session.
+func (r *Runner) rehydrate(ctx context.Context, data []byte, cfg *agent.RunConfig) (agent.InvocationContext, session.Session, error) {
+	var snapshot icontext.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal invocation: %w", err)
+	}
+	if snapshot.InvocationID == "" {
+		return nil, nil, fmt.Errorf("invocation ID is required")
+	}
+	if snapshot.AppName != r.appName {
+		return nil, nil, fmt.Errorf("invocation of app %q can't be rehydrated by the runner of app %q", snapshot.AppName, r.appName)
+	}
+	agentToRun := r.agentByAuthor(snapshot.Agent)
+	if agentToRun == nil {
+		return nil, nil, fmt.Errorf("agent %q of invocation %q not found in the agent tree", snapshot.Agent, snapshot.InvocationID)
+	}
+	if err := validateRunConfig(r.rootAgent, cfg); err != nil {
+		return nil, nil, err
+	}
+	resp, err := r.getSession(ctx, &session.GetRequest{
+		AppName:   snapshot.AppName,
+		UserID:    snapshot.UserID,
+		SessionID: snapshot.SessionID,
+		// The session must include the events of the invocation.
+		Consistency: session.ConsistencyStrong,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.newInvocationContext(ctx, resp.Session, cfg, icontext.InvocationContextParams{
+		InvocationID:       snapshot.InvocationID,
+		ParentInvocationID: snapshot.ParentInvocationID,
+		Agent:              agentToRun,
+		Branch:             snapshot.Branch,
+		UserContent:        snapshot.UserContent,
+		EndInvocation:      snapshot.Ended,
+	}), resp.Session, nil
+}
+
+// ResumeInvocation continues an invocation serialized with json.Marshal,
+// e.g. by another process, yielding the events of the rest of the
+// invocation. Its agent runs again in the rehydrated invocation: the
+// function calls of its last event which have no responses yet are
+// executed first. See UnmarshalInvocation.
+func (r *Runner) ResumeInvocation(ctx context.Context, data []byte, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	return func(yield func(*session.Event, error) bool) {
+		ictx, storedSession, err := r.rehydrate(ctx, data, &cfg)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if ictx.Ended() {
+			yield(nil, fmt.Errorf("invocation %q has already ended", ictx.InvocationID()))
+			return
+		}
+		unlock, err := r.lockSession(ctx, storedSession.UserID(), storedSession.ID())
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer unlock()
+		r.runAgent(ictx, storedSession, cfg, yield)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"fmt"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/model"
+)
+
+// validateRunConfig returns an error if the run config uses a feature which
+// a model of the agent tree doesn't support, as reported by
+// model.CapabilitiesOf. Models with unknown capabilities aren't checked.
+func validateRunConfig(root agent.Agent, cfg *agent.RunConfig) error {
+	transcription := cfg.InputAudioTranscription != nil || cfg.OutputAudioTranscription != nil
+	live := transcription || cfg.RealtimeInputConfig != nil || cfg.Proactivity != nil || cfg.SupportCFC
+	if !live {
+		return nil
+	}
+	var validate func(a agent.Agent) error
+	validate = func(a agent.Agent) error {
+		if llmAgent, ok := a.(llminternal.Agent); ok {
+			if m := llminternal.Reveal(llmAgent).Model; m != nil {
+				if caps, ok := model.CapabilitiesOf(m); ok && !caps.Live {
+					feature := "audio transcription"
+					switch {
+					case cfg.SupportCFC:
+						feature = "compositional function calling"
+					case !transcription:
+						feature = "live connect config"
+					}
+					return fmt.Errorf("invalid run config: %s requires a live model, but agent %q uses model %q", feature, a.Name(), m.Name())
+				}
+			}
+		}
+		for _, sub := range a.SubAgents() {
+			if err := validate(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return validate(root)
+}
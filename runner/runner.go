@@ -17,21 +17,35 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"iter"
-	"log"
+	"log/slog"
+	"reflect"
+	"slices"
+	"time"
 
+	"github.com/google/uuid"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/featureflag"
 	"google.golang.org/adk/internal/agent/parentmap"
 	"google.golang.org/adk/internal/agent/runconfig"
 	artifactinternal "google.golang.org/adk/internal/artifact"
 	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/internal/logging"
 	imemory "google.golang.org/adk/internal/memory"
+	"google.golang.org/adk/internal/plugininternal"
 	"google.golang.org/adk/internal/sessioninternal"
+	"google.golang.org/adk/internal/telemetry"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/offline"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/replay"
 	"google.golang.org/adk/session"
 	"google.golang.org/genai"
 )
@@ -47,6 +61,106 @@ type Config struct {
 	ArtifactService artifact.Service
 	// optional
 	MemoryService memory.Service
+	// CredentialService stores the credentials the tools obtain with the
+	// auth flow, see tool.Context.RequestCredential.
+	// Optional: if nil, a credential is only available to the tool call which
+	// requested it.
+	CredentialService auth.CredentialService
+
+	// SlowSessionOperationThreshold is the latency above which the session
+	// service calls (Get and AppendEvent) are logged together with the session
+	// size. The latency of all calls is recorded as an OpenTelemetry metric.
+	// Optional: if zero, slow calls are not logged.
+	SlowSessionOperationThreshold time.Duration
+
+	// Logger logs the runs. Its records carry the IDs of the invocation, the
+	// agent and the session, and the model and tool calls are logged at the
+	// debug level.
+	// Optional: defaults to slog.Default().
+	Logger *slog.Logger
+
+	// Plugins are callbacks applied to every agent, model and tool call of
+	// the runs, see package plugin.
+	// Optional.
+	Plugins []*plugin.Plugin
+
+	// Offline runs the agents without network access: the models return
+	// canned responses and the tools return fixtures, see package offline.
+	// Optional: if nil, the agents run as usual.
+	Offline *offline.Config
+	// Replay replays an invocation recorded with a replay.Recorder: the
+	// models return the recorded responses and the tools the recorded
+	// results, see package replay. A model or tool call which wasn't
+	// recorded fails.
+	// Optional: if nil, the agents run as usual.
+	Replay *replay.Trace
+
+	// FeatureFlags turn the optional behaviors of the agents, e.g. the
+	// cache-aware ordering or the context window, on and off per app and
+	// user at runtime, see package featureflag.
+	// Optional: if nil, the agents behave as configured.
+	FeatureFlags featureflag.Provider
+
+	// InvocationSummary makes the runner emit, after the agents finished, an
+	// event with the model usage and cost of the invocation per agent, see
+	// session.InvocationSummary.
+	// Optional: if nil, no summary is emitted.
+	InvocationSummary *InvocationSummaryConfig
+
+	// Resumable makes the runner record the end of each completed
+	// invocation in the session, so that the interrupted invocations can be
+	// resumed with Runner.Resume.
+	// Optional: if false, the invocations can't be resumed.
+	Resumable bool
+
+	// AgentAliases maps former names of agents to their current names, so
+	// that the sessions with events of renamed agents continue with the
+	// renamed agents.
+	// Optional.
+	AgentAliases map[string]string
+	// UnknownAuthor decides how the events of agents which are neither in
+	// the agent tree nor aliased are handled when picking the agent to
+	// continue the conversation.
+	// Optional: defaults to UnknownAuthorIgnore.
+	UnknownAuthor UnknownAuthorPolicy
+
+	// PartialEvents persists the partial events, which aren't committed to
+	// the sessions, into a separate store, for the deployments which must
+	// audit exactly what was streamed to the users. The events are stored as
+	// they are streamed, after the plugins, the thought filtering and
+	// agent.RunConfig.EventFilter. A run fails if a partial event can't be
+	// stored.
+	// Optional: if nil, the partial events are only streamed.
+	PartialEvents *PartialEventsConfig
+
+	// RateLimiter limits the rate of the invocations of Run per user, e.g.
+	// a limiter returned by NewRateLimiter.
+	// Optional: if nil, the invocations aren't limited.
+	RateLimiter RateLimiter
+	// SessionLocker serializes the invocations of Run, Resume and
+	// ResumeInvocation per session, so that simultaneous runs of a session
+	// queue or fail with a *SessionBusyError rather than interleave their
+	// events, e.g. a locker returned by NewSessionLocker.
+	// Optional: if nil, the runs of a session aren't serialized.
+	SessionLocker SessionLocker
+
+	// Failures makes the runner classify the failure of the failed
+	// invocations, e.g. as a model error or a guardrail block, emit it in a
+	// terminal event, see session.Failure, and count it in the
+	// "gcp.vertex.agent.invocation.failures" metric.
+	// Optional: if nil, the failures are only reported as errors and error
+	// events.
+	Failures *FailureConfig
+
+	// NewID generates the IDs of the invocations and of the events, e.g.
+	// sortable IDs like ULIDs to order the events across replicas, or
+	// sequential IDs for deterministic tests. The invocation IDs are
+	// prefixed with "e-". The events of a streamed response share an ID.
+	// Optional: defaults to random UUIDs.
+	NewID func() string
+	// Now is the clock of the timestamps of the events.
+	// Optional: defaults to time.Now.
+	Now func() time.Time
 }
 
 // New creates a new [Runner].
@@ -63,6 +177,34 @@ func New(cfg Config) (*Runner, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create agent tree: %w", err)
 	}
+	if err := validateAuthorConfig(cfg.Agent, cfg.AgentAliases, cfg.UnknownAuthor); err != nil {
+		return nil, err
+	}
+
+	plugins, err := plugininternal.NewManager(cfg.Plugins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register plugins: %w", err)
+	}
+
+	var offlineProfile *offline.Profile
+	if cfg.Offline != nil {
+		offlineProfile, err = offline.New(*cfg.Offline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create offline profile: %w", err)
+		}
+	}
+
+	var replayer *replay.Replayer
+	if cfg.Replay != nil {
+		if replayer, err = replay.NewReplayer(cfg.Replay); err != nil {
+			return nil, fmt.Errorf("failed to create replayer: %w", err)
+		}
+	}
+
+	partials, err := newPartialEvents(cfg.PartialEvents)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Runner{
 		appName:         cfg.AppName,
@@ -70,7 +212,25 @@ func New(cfg Config) (*Runner, error) {
 		sessionService:  cfg.SessionService,
 		artifactService: cfg.ArtifactService,
 		memoryService:   cfg.MemoryService,
+		credentials:     cfg.CredentialService,
 		parents:         parents,
+
+		slowSessionOperationThreshold: cfg.SlowSessionOperationThreshold,
+		log:                           cfg.Logger,
+		offline:                       offlineProfile,
+		replay:                        replayer,
+		plugins:                       plugins,
+		featureFlags:                  cfg.FeatureFlags,
+		invocationSummary:             cfg.InvocationSummary,
+		resumable:                     cfg.Resumable,
+		agentAliases:                  cfg.AgentAliases,
+		unknownAuthorPolicy:           cfg.UnknownAuthor,
+		partialEvents:                 partials,
+		rateLimiter:                   cfg.RateLimiter,
+		sessionLocker:                 cfg.SessionLocker,
+		failures:                      cfg.Failures,
+		newID:                         cfg.NewID,
+		now:                           cfg.Now,
 	}, nil
 }
 
@@ -83,22 +243,57 @@ type Runner struct {
 	sessionService  session.Service
 	artifactService artifact.Service
 	memoryService   memory.Service
+	credentials     auth.CredentialService
 
 	parents parentmap.Map
+
+	slowSessionOperationThreshold time.Duration
+	log                           *slog.Logger
+	offline                       *offline.Profile
+	replay                        *replay.Replayer
+	plugins                       *plugininternal.Manager
+	featureFlags                  featureflag.Provider
+	invocationSummary             *InvocationSummaryConfig
+	resumable                     bool
+	agentAliases                  map[string]string
+	unknownAuthorPolicy           UnknownAuthorPolicy
+	partialEvents                 *partialEvents
+	rateLimiter                   RateLimiter
+	sessionLocker                 SessionLocker
+	failures                      *FailureConfig
+	newID                         func() string
+	now                           func() time.Time
 }
 
 // Run runs the agent for the given user input, yielding events from agents.
 // For each user message it finds the proper agent within an agent tree to
 // continue the conversation within the session.
 func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.Content, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
-	// TODO(hakim): we need to validate whether cfg is compatible with the Agent.
-	//   see adk-python/src/google/adk/runners.py Runner._new_invocation_context.
 	// TODO: setup tracer.
 	return func(yield func(*session.Event, error) bool) {
-		resp, err := r.sessionService.Get(ctx, &session.GetRequest{
+		if err := validateRunConfig(r.rootAgent, &cfg); err != nil {
+			yield(nil, err)
+			return
+		}
+		if r.rateLimiter != nil {
+			if err := r.rateLimiter.Wait(ctx, r.appName, userID); err != nil {
+				r.logger().InfoContext(ctx, "invocation rate limited", "user_id", userID, "session_id", sessionID, "error", err)
+				yield(nil, err)
+				return
+			}
+		}
+		unlock, err := r.lockSession(ctx, userID, sessionID)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer unlock()
+		resp, err := r.getSession(ctx, &session.GetRequest{
 			AppName:   r.appName,
 			UserID:    userID,
 			SessionID: sessionID,
+			// The session must include the events of the previous turns.
+			Consistency: session.ConsistencyStrong,
 		})
 		if err != nil {
 			yield(nil, err)
@@ -107,72 +302,302 @@ func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.C
 
 		session := resp.Session
 
-		agentToRun, err := r.findAgentToRun(session)
+		agentToRun, err := r.findAgentToRun(session, msg)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
 
-		ctx = parentmap.ToContext(ctx, r.parents)
-		ctx = runconfig.ToContext(ctx, &runconfig.RunConfig{
-			StreamingMode: runconfig.StreamingMode(cfg.StreamingMode),
+		// An invocation resuming a function call continues in the branch of
+		// the call, so that the response follows the call in the history of
+		// the agent.
+		var branch string
+		if call, _ := findMatchingFunctionCall(session.Events(), msg); call != nil {
+			branch = call.Branch
+		}
+
+		ctx := r.newInvocationContext(ctx, session, &cfg, icontext.InvocationContextParams{
+			Agent:       agentToRun,
+			Branch:      branch,
+			UserContent: msg,
 		})
 
-		var artifacts agent.Artifacts
-		if r.artifactService != nil {
-			artifacts = &artifactinternal.Artifacts{
-				Service:   r.artifactService,
-				SessionID: session.ID(),
-				AppName:   session.AppName(),
-				UserID:    session.UserID(),
-			}
+		newMsg, err := r.plugins.RunOnUserMessage(ctx, msg)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if newMsg != msg {
+			msg = newMsg
+			ctx.(*icontext.InvocationContext).SetUserContent(msg)
 		}
 
-		var memoryImpl agent.Memory = nil
-		if r.memoryService != nil {
-			memoryImpl = &imemory.Memory{
-				Service:   r.memoryService,
-				SessionID: session.ID(),
-				UserID:    session.UserID(),
-				AppName:   session.AppName(),
-			}
+		if err := r.appendMessageToSession(ctx, session, msg, cfg.SaveInputBlobsAsArtifacts); err != nil {
+			yield(nil, err)
+			return
 		}
 
-		ctx := icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{
-			Artifacts:   artifacts,
-			Memory:      memoryImpl,
-			Session:     sessioninternal.NewMutableSession(r.sessionService, session),
-			Agent:       agentToRun,
-			UserContent: msg,
-			RunConfig:   &cfg,
+		r.runAgent(ctx, session, cfg, yield)
+	}
+}
+
+// Resume resumes an invocation which didn't complete, e.g. because the
+// process crashed, the caller stopped consuming the events or the context
+// was canceled, yielding the events of the rest of the invocation. It
+// requires a resumable runner, see Config.Resumable.
+//
+// The state of the invocation is restored from its events in the session:
+// the agent which made the last event continues in its branch with the
+// original user input. Its function calls which have no responses yet are
+// executed first. The invocations waiting for the responses of long-running
+// tools or for credentials are complete: the client continues them with Run
+// and the function responses.
+//
+// Only the agent of the last event is resumed: the workflow agents, e.g.
+// the sequential agent, which ran it don't continue with their other
+// sub-agents.
+func (r *Runner) Resume(ctx context.Context, userID, sessionID, invocationID string, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	return func(yield func(*session.Event, error) bool) {
+		if !r.resumable {
+			yield(nil, fmt.Errorf("runner is not resumable, see Config.Resumable"))
+			return
+		}
+		if err := validateRunConfig(r.rootAgent, &cfg); err != nil {
+			yield(nil, err)
+			return
+		}
+		unlock, err := r.lockSession(ctx, userID, sessionID)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer unlock()
+		resp, err := r.getSession(ctx, &session.GetRequest{
+			AppName:   r.appName,
+			UserID:    userID,
+			SessionID: sessionID,
+			// The session must include the events of the previous turns.
+			Consistency: session.ConsistencyStrong,
 		})
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		session := resp.Session
 
-		if err := r.appendMessageToSession(ctx, session, msg, cfg.SaveInputBlobsAsArtifacts); err != nil {
+		state, err := findInvocation(session.Events(), invocationID)
+		if err != nil {
 			yield(nil, err)
 			return
 		}
+		agentToRun := r.agentByAuthor(state.agent)
+		if agentToRun == nil {
+			// The invocation was interrupted before any agent made an
+			// event: start it as a new one.
+			if agentToRun, err = r.findAgentToRun(session, nil); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
 
-		for event, err := range agentToRun.Run(ctx) {
-			if err != nil {
-				if !yield(event, err) {
-					return
-				}
-				continue
+		ctx := r.newInvocationContext(ctx, session, &cfg, icontext.InvocationContextParams{
+			InvocationID: state.id,
+			Agent:        agentToRun,
+			Branch:       state.branch,
+			UserContent:  state.userContent,
+		})
+		r.runAgent(ctx, session, cfg, yield)
+	}
+}
+
+// newInvocationContext returns the context of an invocation in the session,
+// completing params with the services of the runner.
+func (r *Runner) newInvocationContext(ctx context.Context, storedSession session.Session, cfg *agent.RunConfig, params icontext.InvocationContextParams) agent.InvocationContext {
+	ctx = parentmap.ToContext(ctx, r.parents)
+	ctx = runconfig.ToContext(ctx, &runconfig.RunConfig{
+		StreamingMode:     runconfig.StreamingMode(cfg.StreamingMode),
+		Offline:           r.offline,
+		Replay:            r.replay,
+		CredentialService: r.credentials,
+		FeatureFlags:      r.featureFlags,
+
+		InputAudioTranscription:  cfg.InputAudioTranscription,
+		OutputAudioTranscription: cfg.OutputAudioTranscription,
+		RealtimeInputConfig:      cfg.RealtimeInputConfig,
+		Proactivity:              cfg.Proactivity,
+		SupportCFC:               cfg.SupportCFC,
+	})
+	ctx = plugininternal.ToContext(ctx, r.plugins)
+	ctx = logging.ToContext(ctx, r.logger())
+
+	if r.artifactService != nil {
+		params.Artifacts = &artifactinternal.Artifacts{
+			Service:   r.artifactService,
+			SessionID: storedSession.ID(),
+			AppName:   storedSession.AppName(),
+			UserID:    storedSession.UserID(),
+		}
+	}
+	if r.memoryService != nil {
+		params.Memory = &imemory.Memory{
+			Service:   r.memoryService,
+			SessionID: storedSession.ID(),
+			UserID:    storedSession.UserID(),
+			AppName:   storedSession.AppName(),
+		}
+	}
+	params.Session = sessioninternal.NewMutableSession(r.sessionService, storedSession)
+	params.RunConfig = cfg
+	params.NewID = r.newID
+	params.Now = r.now
+	return icontext.NewInvocationContext(ctx, params)
+}
+
+// runAgent runs the agent of the invocation, committing its events to the
+// session and yielding them.
+func (r *Runner) runAgent(ctx agent.InvocationContext, storedSession session.Session, cfg agent.RunConfig, yield func(*session.Event, error) bool) {
+	agentToRun := ctx.Agent()
+	stamper := newEventStamper(icontext.IDGenerator(ctx), icontext.Clock(ctx))
+
+	defer r.plugins.RunAfterRun(ctx)
+	content, err := r.plugins.RunBeforeRun(ctx)
+	if err != nil {
+		yield(nil, err)
+		return
+	}
+	if content != nil {
+		// A plugin ended the run before the agent.
+		event := newEvent(ctx, agentToRun.Name(), content)
+		event.Actions.EndOfInvocation = r.resumable
+		stamper.stamp(event)
+		if err := r.appendEvent(ctx, storedSession, event); err != nil {
+			yield(nil, fmt.Errorf("failed to add event to session: %w", err))
+			return
+		}
+		yield(event, nil)
+		return
+	}
+
+	var usage *usageAggregator
+	if r.invocationSummary != nil {
+		usage = newUsageAggregator(r.invocationSummary)
+	}
+
+	var failures *failureTracker
+	if r.failures != nil {
+		failures = &failureTracker{cfg: r.failures}
+	}
+	lastAuthor := agentToRun.Name()
+
+	failed := false
+	for event, err := range agentToRun.Run(ctx) {
+		if err != nil {
+			failed = true
+			if ctx.Err() != nil && !errors.Is(err, agent.ErrInvocationCancelled) {
+				err = fmt.Errorf("%w: %w", agent.ErrInvocationCancelled, err)
+			}
+			failures.observe(lastAuthor, err, nil)
+			if !yield(event, err) {
+				return
+			}
+			continue
+		}
+		stamper.stamp(event)
+		if usage != nil {
+			usage.add(event)
+		}
+		lastAuthor = event.Author
+		failures.observe(event.Author, nil, event)
+
+		// only commit non-partial event to a session service
+		partial := event.LLMResponse.Partial
+		if !partial {
+			if err := r.appendEvent(ctx, storedSession, event); err != nil {
+				yield(nil, fmt.Errorf("failed to add event to session: %w", err))
+				return
 			}
+		}
 
-			// only commit non-partial event to a session service
-			if !event.LLMResponse.Partial {
-				if err := r.sessionService.AppendEvent(ctx, session, event); err != nil {
-					yield(nil, fmt.Errorf("failed to add event to session: %w", err))
-					return
-				}
+		event, err = r.plugins.RunOnEvent(ctx, event)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if !cfg.IncludeThoughts {
+			if event = withoutThoughts(event); event == nil {
+				continue
 			}
+		}
+		if cfg.EventFilter != nil && !cfg.EventFilter(event) {
+			continue
+		}
+		// The partial events are stored as they are streamed.
+		if partial && r.partialEvents != nil {
+			if err := r.partialEvents.append(ctx, storedSession, event); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+		if !yield(event, nil) {
+			return
+		}
+	}
+
+	// The agents may stop without an error when the context is canceled.
+	if !failed && ctx.Err() != nil {
+		if !yield(nil, fmt.Errorf("%w: %w", agent.ErrInvocationCancelled, ctx.Err())) {
+			return
+		}
+	}
 
+	// The canceled invocations didn't fail.
+	if event := failures.event(ctx); event != nil {
+		stamper.stamp(event)
+		if err := r.appendEvent(ctx, storedSession, event); err != nil {
+			yield(nil, fmt.Errorf("failed to add event to session: %w", err))
+			return
+		}
+		if cfg.EventFilter == nil || cfg.EventFilter(event) {
 			if !yield(event, nil) {
 				return
 			}
 		}
 	}
+
+	// The failed and canceled invocations can be resumed.
+	end := r.resumable && !failed && ctx.Err() == nil
+	if usage != nil {
+		event := newSummaryEvent(ctx, agentToRun.Name(), usage.summary())
+		event.Actions.EndOfInvocation = end
+		stamper.stamp(event)
+		if err := r.appendEvent(ctx, storedSession, event); err != nil {
+			yield(nil, fmt.Errorf("failed to add event to session: %w", err))
+			return
+		}
+		if cfg.EventFilter != nil && !cfg.EventFilter(event) {
+			return
+		}
+		yield(event, nil)
+		return
+	}
+	if end {
+		// The end of the invocation is only recorded in the session.
+		event := session.NewEvent(ctx.InvocationID())
+		event.Author = agentToRun.Name()
+		event.Branch = ctx.Branch()
+		event.Actions.EndOfInvocation = true
+		stamper.stamp(event)
+		if err := r.appendEvent(ctx, storedSession, event); err != nil {
+			yield(nil, fmt.Errorf("failed to add event to session: %w", err))
+		}
+	}
+}
+
+func newEvent(ctx agent.InvocationContext, author string, content *genai.Content) *session.Event {
+	event := session.NewEvent(ctx.InvocationID())
+	event.Author = author
+	event.LLMResponse = model.LLMResponse{Content: content}
+	return event
 }
 
 func (r *Runner) appendMessageToSession(ctx agent.InvocationContext, storedSession session.Session, msg *genai.Content, saveInputBlobsAsArtifacts bool) error {
@@ -198,35 +623,213 @@ func (r *Runner) appendMessageToSession(ctx agent.InvocationContext, storedSessi
 	}
 
 	event := session.NewEvent(ctx.InvocationID())
+	event.ParentInvocationID = ctx.ParentInvocationID()
+	event.Branch = ctx.Branch()
 
 	event.Author = "user"
 	event.LLMResponse = model.LLMResponse{
 		Content: msg,
 	}
+	newEventStamper(icontext.IDGenerator(ctx), icontext.Clock(ctx)).stamp(event)
 
-	if err := r.sessionService.AppendEvent(ctx, storedSession, event); err != nil {
+	if err := r.appendEvent(ctx, storedSession, event); err != nil {
 		return fmt.Errorf("failed to append event to sessionService: %w", err)
 	}
 	return nil
 }
 
+// AddDeveloperMessage appends a developer message to the session, e.g. "the
+// user's subscription was upgraded", to be called between turns. The agents
+// see it as context in their next turns, distinct from the user input. It
+// doesn't run the agents. Like the runs, it takes the lock of the session,
+// see Config.SessionLocker.
+func (r *Runner) AddDeveloperMessage(ctx context.Context, userID, sessionID string, msg *genai.Content) error {
+	if msg == nil || len(msg.Parts) == 0 {
+		return fmt.Errorf("developer message is empty")
+	}
+	unlock, err := r.lockSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	resp, err := r.getSession(ctx, &session.GetRequest{
+		AppName:     r.appName,
+		UserID:      userID,
+		SessionID:   sessionID,
+		Consistency: session.ConsistencyStrong,
+	})
+	if err != nil {
+		return err
+	}
+
+	content := *msg
+	content.Role = genai.RoleUser
+	invocationID := "e-" + uuid.NewString()
+	if r.newID != nil {
+		invocationID = "e-" + r.newID()
+	}
+	event := session.NewEvent(invocationID)
+	event.Author = session.DeveloperAuthor
+	event.LLMResponse = model.LLMResponse{Content: &content}
+	newEventStamper(r.newID, r.now).stamp(event)
+	if err := r.appendEvent(ctx, resp.Session, event); err != nil {
+		return fmt.Errorf("failed to add developer message to session: %w", err)
+	}
+	return nil
+}
+
+// getSession gets the session from the session service, recording the
+// latency of the call.
+func (r *Runner) getSession(ctx context.Context, req *session.GetRequest) (*session.GetResponse, error) {
+	start := time.Now()
+	resp, err := r.sessionService.Get(ctx, req)
+	numEvents := 0
+	if err == nil && resp != nil && resp.Session != nil {
+		numEvents = resp.Session.Events().Len()
+	}
+	r.recordSessionOperation(ctx, "get", req.SessionID, numEvents, time.Since(start), err)
+	return resp, err
+}
+
+// withoutThoughts returns the event without its thought parts, or nil if the
+// event only holds thoughts. The event itself, which may already be appended
+// to the session, is not changed.
+func withoutThoughts(event *session.Event) *session.Event {
+	if event.Thought {
+		return nil
+	}
+	if event.Content == nil {
+		return event
+	}
+	thoughts, visible := llminternal.SplitThoughts(event.Content.Parts)
+	if len(thoughts) == 0 {
+		return event
+	}
+	if len(visible) == 0 {
+		return nil
+	}
+	stripped := *event
+	stripped.Content = &genai.Content{Role: event.Content.Role, Parts: visible}
+	return &stripped
+}
+
+// appendEvent appends the event to the session, recording the latency of the
+// call.
+func (r *Runner) appendEvent(ctx context.Context, s session.Session, event *session.Event) error {
+	start := time.Now()
+	err := r.sessionService.AppendEvent(ctx, s, event)
+	r.recordSessionOperation(ctx, "append_event", s.ID(), s.Events().Len(), time.Since(start), err)
+	return err
+}
+
+func (r *Runner) recordSessionOperation(ctx context.Context, operation, sessionID string, numEvents int, d time.Duration, err error) {
+	telemetry.RecordSessionOperation(ctx, operation, d, err)
+	if r.slowSessionOperationThreshold > 0 && d > r.slowSessionOperationThreshold {
+		r.logger().Warn("slow session operation", "operation", operation, "session_id", sessionID, "events", numEvents, "duration", d, "threshold", r.slowSessionOperationThreshold)
+	}
+}
+
+// logger returns the logger of the runner.
+func (r *Runner) logger() *slog.Logger {
+	if r.log != nil {
+		return r.log
+	}
+	return slog.Default()
+}
+
+// Close releases the resources held by the runner, e.g. the MCP connections
+// and the subprocesses of the MCP servers:
+//   - the toolsets and the models of the LLM agents in the agent tree which
+//     implement io.Closer or have a Close(context.Context) error method are
+//     closed;
+//   - the close callbacks of the plugins are called;
+//   - the telemetry spans which haven't been exported yet are flushed.
+//
+// All the resources are released even if some of them fail. The runner must
+// not be used after Close.
+func (r *Runner) Close(ctx context.Context) error {
+	var errs []error
+	closed := make(map[any]bool)
+	closeResource := func(kind, name string, res any) {
+		// A toolset or a model may be shared by several agents.
+		if reflect.TypeOf(res).Comparable() {
+			if closed[res] {
+				return
+			}
+			closed[res] = true
+		}
+		var err error
+		switch c := res.(type) {
+		case interface{ Close(context.Context) error }:
+			err = c.Close(ctx)
+		case io.Closer:
+			err = c.Close()
+		default:
+			return
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s %q: %w", kind, name, err))
+		}
+	}
+	var closeAgent func(a agent.Agent)
+	closeAgent = func(a agent.Agent) {
+		if llmAgent, ok := a.(llminternal.Agent); ok {
+			state := llminternal.Reveal(llmAgent)
+			for _, ts := range state.Toolsets {
+				closeResource("toolset", ts.Name(), ts)
+			}
+			if state.Model != nil {
+				closeResource("model", state.Model.Name(), state.Model)
+			}
+		}
+		for _, sub := range a.SubAgents() {
+			closeAgent(sub)
+		}
+	}
+	closeAgent(r.rootAgent)
+
+	if err := r.plugins.RunClose(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if err := telemetry.Flush(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to flush telemetry: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
 // findAgentToRun returns the agent that should handle the next request based on
 // session history.
-func (r *Runner) findAgentToRun(session session.Session) (agent.Agent, error) {
-	events := session.Events()
+func (r *Runner) findAgentToRun(storedSession session.Session, msg *genai.Content) (agent.Agent, error) {
+	events := storedSession.Events()
+
+	// A function response, e.g. to a credential request or of a long-running
+	// tool, resumes the agent which made the function call.
+	event, err := findMatchingFunctionCall(events, msg)
+	if err != nil {
+		return nil, err
+	}
+	if event != nil {
+		if subAgent := r.agentByAuthor(event.Author); subAgent != nil {
+			return subAgent, nil
+		}
+		if err := r.unknownAuthor(event); err != nil {
+			return nil, err
+		}
+	}
+
 	for i := events.Len() - 1; i >= 0; i-- {
 		event := events.At(i)
 
-		// TODO: findMatchingFunctionCall.
-
-		if event.Author == "user" {
+		if event.Author == "user" || event.Author == session.DeveloperAuthor || event.Actions.InvocationSummary != nil || event.Actions.EndOfInvocation {
 			continue
 		}
 
-		subAgent := findAgent(r.rootAgent, event.Author)
+		subAgent := r.agentByAuthor(event.Author)
 		// Agent not found, continue looking for the other event.
 		if subAgent == nil {
-			log.Printf("Event from an unknown agent: %s, event id: %s", event.Author, event.ID)
+			if err := r.unknownAuthor(event); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
@@ -239,6 +842,37 @@ func (r *Runner) findAgentToRun(session session.Session) (agent.Agent, error) {
 	return r.rootAgent, nil
 }
 
+// findMatchingFunctionCall returns the latest event with a function call
+// answered by the function responses in msg, or nil if msg doesn't contain
+// any. It returns an error if the responses don't match any function call of
+// the session.
+func findMatchingFunctionCall(events session.Events, msg *genai.Content) (*session.Event, error) {
+	if msg == nil {
+		return nil, nil
+	}
+	var ids []string
+	for _, p := range msg.Parts {
+		if p.FunctionResponse != nil && p.FunctionResponse.ID != "" {
+			ids = append(ids, p.FunctionResponse.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	for i := events.Len() - 1; i >= 0; i-- {
+		event := events.At(i)
+		if event.LLMResponse.Content == nil {
+			continue
+		}
+		for _, p := range event.LLMResponse.Content.Parts {
+			if p.FunctionCall != nil && slices.Contains(ids, p.FunctionCall.ID) {
+				return event, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("function responses %v don't match any function call in the session", ids)
+}
+
 // checks if the agent and its parent chain allow transfer up the tree.
 func (r *Runner) isTransferableAcrossAgentTree(agentToRun agent.Agent) bool {
 	for curAgent := agentToRun; curAgent != nil; curAgent = r.parents[curAgent.Name()] {
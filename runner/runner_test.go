@@ -17,15 +17,25 @@ package runner
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"iter"
+	"log/slog"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/offline"
+	"google.golang.org/adk/plugin"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
 	"google.golang.org/genai"
 )
 
@@ -40,6 +50,9 @@ func TestRunner_findAgentToRun(t *testing.T) {
 		name      string
 		rootAgent agent.Agent
 		session   session.Session
+		msg       *genai.Content
+		aliases   map[string]string
+		policy    UnknownAuthorPolicy
 		wantAgent agent.Agent
 		wantErr   bool
 	}{
@@ -79,14 +92,98 @@ func TestRunner_findAgentToRun(t *testing.T) {
 			rootAgent: agentTree.root,
 			wantAgent: agentTree.root,
 		},
+		{
+			name: "function response resumes the agent which made the call",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				{
+					Author: "no_transfer_agent",
+					LLMResponse: model.LLMResponse{
+						Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+							{FunctionCall: &genai.FunctionCall{ID: "call-1", Name: "adk_request_credential"}},
+						}},
+					},
+				},
+			}),
+			msg: &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+				{FunctionResponse: &genai.FunctionResponse{ID: "call-1", Name: "adk_request_credential"}},
+			}},
+			rootAgent: agentTree.root,
+			wantAgent: agentTree.noTransferAgent,
+		},
+		{
+			name: "any of the function responses matches the call",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				{
+					Author: "no_transfer_agent",
+					LLMResponse: model.LLMResponse{
+						Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+							{FunctionCall: &genai.FunctionCall{ID: "call-2", Name: "long_running"}},
+						}},
+					},
+				},
+				{
+					Author: "allows_transfer_agent",
+				},
+			}),
+			msg: &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+				{FunctionResponse: &genai.FunctionResponse{Name: "client_tool"}},
+				{FunctionResponse: &genai.FunctionResponse{ID: "call-2", Name: "long_running"}},
+			}},
+			rootAgent: agentTree.root,
+			wantAgent: agentTree.noTransferAgent,
+		},
+		{
+			name: "function response without matching call",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				{
+					Author: "allows_transfer_agent",
+				},
+			}),
+			msg: &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+				{FunctionResponse: &genai.FunctionResponse{ID: "unknown", Name: "long_running"}},
+			}},
+			rootAgent: agentTree.root,
+			wantErr:   true,
+		},
+		{
+			name: "unknown agent is skipped",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				{Author: "allows_transfer_agent"},
+				{Author: "removed_agent"},
+			}),
+			rootAgent: agentTree.root,
+			wantAgent: agentTree.allowsTransferAgent,
+		},
+		{
+			name: "renamed agent is found by alias",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				{Author: "no_transfer_agent"},
+				{Author: "old_agent_name"},
+			}),
+			aliases:   map[string]string{"old_agent_name": "allows_transfer_agent"},
+			rootAgent: agentTree.root,
+			wantAgent: agentTree.allowsTransferAgent,
+		},
+		{
+			name: "unknown agent fails the run",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				{Author: "allows_transfer_agent"},
+				{Author: "removed_agent"},
+			}),
+			policy:    UnknownAuthorFail,
+			rootAgent: agentTree.root,
+			wantErr:   true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := &Runner{
-				rootAgent: tt.rootAgent,
+				rootAgent:           tt.rootAgent,
+				agentAliases:        tt.aliases,
+				unknownAuthorPolicy: tt.policy,
 			}
-			gotAgent, err := r.findAgentToRun(tt.session)
+			gotAgent, err := r.findAgentToRun(tt.session, tt.msg)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Runner.findAgentToRun() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -98,6 +195,34 @@ func TestRunner_findAgentToRun(t *testing.T) {
 	}
 }
 
+func TestNew_AgentAliases(t *testing.T) {
+	agentTree := agentTree(t)
+	for _, tc := range []struct {
+		name    string
+		aliases map[string]string
+		policy  UnknownAuthorPolicy
+		wantErr bool
+	}{
+		{name: "valid", aliases: map[string]string{"old": "no_transfer_agent"}, policy: UnknownAuthorFail},
+		{name: "unknown target", aliases: map[string]string{"old": "missing"}, wantErr: true},
+		{name: "alias of existing agent", aliases: map[string]string{"allows_transfer_agent": "no_transfer_agent"}, wantErr: true},
+		{name: "unknown policy", policy: "route", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(Config{
+				AppName:        "testApp",
+				Agent:          agentTree.root,
+				SessionService: session.InMemoryService(),
+				AgentAliases:   tc.aliases,
+				UnknownAuthor:  tc.policy,
+			})
+			if (err != nil) != tc.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
 func Test_findAgent(t *testing.T) {
 	agentTree := agentTree(t)
 
@@ -336,6 +461,342 @@ func agentTree(t *testing.T) agentTreeStruct {
 	}
 }
 
+func TestRunner_EventFilter(t *testing.T) {
+	newEvent := func(ctx agent.InvocationContext, content *genai.Content, partial bool) *session.Event {
+		ev := session.NewEvent(ctx.InvocationID())
+		ev.Author = ctx.Agent().Name()
+		ev.LLMResponse = model.LLMResponse{Content: content, Partial: partial}
+		return ev
+	}
+	testAgent := must(agent.New(agent.Config{
+		Name: "test_agent",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				for _, ev := range []*session.Event{
+					newEvent(ctx, genai.NewContentFromText("Look", genai.RoleModel), true),
+					newEvent(ctx, genai.NewContentFromFunctionCall("search", nil, genai.RoleModel), false),
+					newEvent(ctx, genai.NewContentFromFunctionResponse("search", nil, genai.RoleUser), false),
+					newEvent(ctx, genai.NewContentFromText("Found it.", genai.RoleModel), false),
+				} {
+					if !yield(ev, nil) {
+						return
+					}
+				}
+			}
+		},
+	}))
+
+	tests := []struct {
+		name   string
+		filter agent.EventFilter
+		want   []string
+	}{
+		{name: "no filter", want: []string{"Look", "call:search", "response:search", "Found it."}},
+		{name: "no partials", filter: agent.NoPartials, want: []string{"call:search", "response:search", "Found it."}},
+		{name: "no tool events", filter: agent.NoToolEvents, want: []string{"Look", "Found it."}},
+		{name: "final only", filter: agent.FinalResponsesOnly, want: []string{"Found it."}},
+		{name: "all of", filter: agent.AllOf(agent.NoPartials, agent.NoToolEvents), want: []string{"Found it."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := t.Context()
+			sessionService := session.InMemoryService()
+			if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+				t.Fatal(err)
+			}
+			r, err := New(Config{AppName: "testApp", Agent: testAgent, SessionService: sessionService})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for ev, err := range r.Run(ctx, "testUser", "testSession", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{EventFilter: tt.filter}) {
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+				part := ev.Content.Parts[0]
+				switch {
+				case part.FunctionCall != nil:
+					got = append(got, "call:"+part.FunctionCall.Name)
+				case part.FunctionResponse != nil:
+					got = append(got, "response:"+part.FunctionResponse.Name)
+				default:
+					got = append(got, part.Text)
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Run() events mismatch (-want +got):\n%s", diff)
+			}
+
+			resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The user message and all non-partial events are stored.
+			if got := resp.Session.Events().Len(); got != 4 {
+				t.Errorf("session has %d events, want 4", got)
+			}
+		})
+	}
+}
+
+type closableToolset struct {
+	name   string
+	closed int
+}
+
+func (ts *closableToolset) Name() string { return ts.name }
+
+func (ts *closableToolset) Tools(agent.ReadonlyContext) ([]tool.Tool, error) { return nil, nil }
+
+func (ts *closableToolset) Close() error {
+	ts.closed++
+	return nil
+}
+
+// closableModel is a model holding a client which must be closed.
+type closableModel struct {
+	scriptedModel
+	closed int
+	err    error
+}
+
+func (m *closableModel) Close(ctx context.Context) error {
+	m.closed++
+	return m.err
+}
+
+func TestRunner_Close(t *testing.T) {
+	shared := &closableToolset{name: "shared"}
+	filtered := &closableToolset{name: "filtered"}
+	llm := &closableModel{}
+	sub := must(llmagent.New(llmagent.Config{
+		Name:     "sub",
+		Model:    llm,
+		Toolsets: []tool.Toolset{shared, tool.FilterToolset(filtered, tool.StringPredicate(nil))},
+	}))
+	root := must(llmagent.New(llmagent.Config{
+		Name:      "root",
+		Model:     llm,
+		Toolsets:  []tool.Toolset{shared},
+		SubAgents: []agent.Agent{sub},
+	}))
+	pluginClosed := 0
+	p, err := plugin.New(plugin.Config{
+		Name: "closable",
+		CloseCallback: func(context.Context) error {
+			pluginClosed++
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{AppName: "testApp", Agent: root, SessionService: session.InMemoryService(), Plugins: []*plugin.Plugin{p}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Close(t.Context()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if shared.closed != 1 {
+		t.Errorf("shared toolset closed %d times, want 1", shared.closed)
+	}
+	if filtered.closed != 1 {
+		t.Errorf("filtered toolset closed %d times, want 1", filtered.closed)
+	}
+	if llm.closed != 1 {
+		t.Errorf("shared model closed %d times, want 1", llm.closed)
+	}
+	if pluginClosed != 1 {
+		t.Errorf("plugin closed %d times, want 1", pluginClosed)
+	}
+}
+
+func TestRunner_CloseError(t *testing.T) {
+	ts := &closableToolset{name: "toolset"}
+	llm := &closableModel{err: errors.New("connection reset")}
+	a := must(llmagent.New(llmagent.Config{Name: "agent", Model: llm, Toolsets: []tool.Toolset{ts}}))
+	r, err := New(Config{AppName: "testApp", Agent: a, SessionService: session.InMemoryService()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Close(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("Close() error = %v, want the model close error", err)
+	}
+	// The other resources are released despite the error.
+	if ts.closed != 1 {
+		t.Errorf("toolset closed %d times, want 1", ts.closed)
+	}
+}
+
+// capableModel is a scriptedModel which declares its capabilities.
+type capableModel struct {
+	scriptedModel
+	caps model.Capabilities
+}
+
+func (m *capableModel) Capabilities() model.Capabilities { return m.caps }
+
+func TestRunner_ValidateRunConfig(t *testing.T) {
+	transcription := agent.RunConfig{InputAudioTranscription: &genai.AudioTranscriptionConfig{}}
+	for _, tc := range []struct {
+		name    string
+		llm     model.LLM
+		cfg     agent.RunConfig
+		wantErr bool
+	}{
+		{name: "no transcription", llm: &capableModel{}, cfg: agent.RunConfig{}},
+		{name: "live model", llm: &capableModel{caps: model.Capabilities{Live: true}}, cfg: transcription},
+		{name: "unknown capabilities", llm: &scriptedModel{}, cfg: transcription},
+		{name: "not a live model", llm: &capableModel{}, cfg: transcription, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sub := must(llmagent.New(llmagent.Config{Name: "sub", Model: tc.llm}))
+			root := must(llmagent.New(llmagent.Config{
+				Name:      "root",
+				Model:     &scriptedModel{responses: []*genai.Content{genai.NewContentFromText("hello", genai.RoleModel)}},
+				SubAgents: []agent.Agent{sub},
+			}))
+			ctx := t.Context()
+			sessionService := session.InMemoryService()
+			if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+				t.Fatal(err)
+			}
+			r, err := New(Config{AppName: "testApp", Agent: root, SessionService: sessionService})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var runErr error
+			for _, err := range r.Run(ctx, "testUser", "testSession", genai.NewContentFromText("hi", genai.RoleUser), tc.cfg) {
+				if err != nil {
+					runErr = err
+					break
+				}
+			}
+			if gotErr := runErr != nil && strings.Contains(runErr.Error(), "invalid run config"); gotErr != tc.wantErr {
+				t.Errorf("Run() error = %v, want invalid run config error: %v", runErr, tc.wantErr)
+			}
+		})
+	}
+}
+
+// slowSessionService delays the AppendEvent calls.
+type slowSessionService struct {
+	session.Service
+	delay time.Duration
+}
+
+func (s *slowSessionService) AppendEvent(ctx context.Context, sess session.Session, event *session.Event) error {
+	time.Sleep(s.delay)
+	return s.Service.AppendEvent(ctx, sess, event)
+}
+
+func TestRunner_SlowSessionOperationLog(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := t.Context()
+	sessionService := &slowSessionService{Service: session.InMemoryService(), delay: 20 * time.Millisecond}
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+		t.Fatal(err)
+	}
+
+	testAgent := must(agent.New(agent.Config{
+		Name: "test_agent",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {}
+		},
+	}))
+	r, err := New(Config{
+		AppName:                       "testApp",
+		Agent:                         testAgent,
+		SessionService:                sessionService,
+		SlowSessionOperationThreshold: 10 * time.Millisecond,
+		Logger:                        slog.New(slog.NewTextHandler(&buf, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, err := range r.Run(ctx, "testUser", "testSession", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, `msg="slow session operation" operation=append_event session_id=testSession events=1`) {
+		t.Errorf("log = %q, want slow append_event to be logged", got)
+	}
+	if strings.Contains(got, "operation=get") {
+		t.Errorf("log = %q, want fast get not to be logged", got)
+	}
+}
+
+func TestRunner_Logger(t *testing.T) {
+	echo, err := functiontool.New(functiontool.Config{Name: "echo", Description: "echoes the args"},
+		func(_ tool.Context, args map[string]any) (map[string]any, error) { return args, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := must(llmagent.New(llmagent.Config{Name: "agent", Model: &scriptedModel{responses: []*genai.Content{
+		genai.NewContentFromFunctionCall("echo", map[string]any{"text": "hi"}, genai.RoleModel),
+		genai.NewContentFromText("done", genai.RoleModel),
+	}}, Tools: []tool.Tool{echo}}))
+
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	r, err := New(Config{
+		AppName:        "testApp",
+		Agent:          a,
+		SessionService: sessionService,
+		Logger:         slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range r.Run(ctx, "testUser", "testSession", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	var got []string
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var record struct {
+			Level, Msg, Agent, Tool string
+			InvocationID            string `json:"invocation_id"`
+			SessionID               string `json:"session_id"`
+		}
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		if record.InvocationID == "" || record.Agent != "agent" || record.SessionID != "testSession" {
+			t.Errorf("log record %+v, want the invocation, agent and session", record)
+		}
+		got = append(got, record.Level+" "+record.Msg+" "+record.Tool)
+	}
+	want := []string{
+		"DEBUG model request ",
+		"DEBUG model response ",
+		"DEBUG tool call echo",
+		"DEBUG tool response echo",
+		"DEBUG model request ",
+		"DEBUG model response ",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("log records mismatch (-want +got):\n%s", diff)
+	}
+}
+
 type agentTreeStruct struct {
 	root, noTransferAgent, allowsTransferAgent agent.Agent
 }
@@ -369,3 +830,983 @@ func createSession(t *testing.T, ctx context.Context, sessionID, appName, userID
 
 	return resp.Session
 }
+
+func TestRunner_Offline(t *testing.T) {
+	type Args struct {
+		City string `json:"city"`
+	}
+	getWeather, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather forecast",
+	}, func(tool.Context, Args) (map[string]any, error) {
+		t.Error("get_weather was called in offline mode")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The agent has no model: the offline model is used instead.
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "weather_agent",
+		Tools: []tool.Tool{getWeather},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{
+		AppName:        "testApp",
+		Agent:          a,
+		SessionService: sessionService,
+		Offline: &offline.Config{
+			Responses: []offline.Response{{
+				Match:     "(?i)weather",
+				ToolCalls: []offline.ToolCall{{Name: "get_weather", Args: map[string]any{"city": "Paris"}}},
+				Text:      "It is {{.ToolResults.get_weather.forecast}} in Paris.",
+			}},
+			ToolFixtures: map[string]map[string]any{"get_weather": {"forecast": "sunny"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(text string) []string {
+		var got []string
+		for ev, err := range r.Run(ctx, "testUser", "testSession", genai.NewContentFromText(text, genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			part := ev.Content.Parts[0]
+			switch {
+			case part.FunctionCall != nil:
+				got = append(got, "call:"+part.FunctionCall.Name)
+			case part.FunctionResponse != nil:
+				got = append(got, fmt.Sprintf("response:%v", part.FunctionResponse.Response))
+			default:
+				got = append(got, part.Text)
+			}
+		}
+		return got
+	}
+
+	if diff := cmp.Diff([]string{"call:get_weather", "response:map[forecast:sunny]", "It is sunny in Paris."}, run("What's the weather?")); diff != "" {
+		t.Errorf("Run() events mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"[offline] You said: hello"}, run("hello")); diff != "" {
+		t.Errorf("Run() events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// scriptedModel returns the responses in order.
+type scriptedModel struct {
+	responses []*genai.Content
+	requests  []*model.LLMRequest
+	// usage is reported with each response, if set.
+	usage *genai.GenerateContentResponseUsageMetadata
+}
+
+func (m *scriptedModel) Name() string { return "scripted" }
+
+func (m *scriptedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.requests = append(m.requests, req)
+		if len(m.responses) == 0 {
+			yield(nil, fmt.Errorf("no more responses"))
+			return
+		}
+		resp := m.responses[0]
+		m.responses = m.responses[1:]
+		yield(&model.LLMResponse{Content: resp, UsageMetadata: m.usage}, nil)
+	}
+}
+
+func TestRunner_Plugins(t *testing.T) {
+	var calls []string
+	record := func(name string) { calls = append(calls, name) }
+
+	p, err := plugin.New(plugin.Config{
+		Name: "recorder",
+		OnUserMessageCallback: func(ctx agent.InvocationContext, msg *genai.Content) (*genai.Content, error) {
+			record("on user message")
+			return genai.NewContentFromText(strings.ToUpper(msg.Parts[0].Text), genai.RoleUser), nil
+		},
+		BeforeRunCallback: func(agent.InvocationContext) (*genai.Content, error) {
+			record("before run")
+			return nil, nil
+		},
+		AfterRunCallback: func(agent.InvocationContext) { record("after run") },
+		OnEventCallback: func(ctx agent.InvocationContext, event *session.Event) (*session.Event, error) {
+			record("on event")
+			if event.IsFinalResponse() {
+				redacted := *event
+				redacted.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("[redacted]", genai.RoleModel)}
+				return &redacted, nil
+			}
+			return nil, nil
+		},
+		BeforeAgentCallback: func(agent.CallbackContext) (*genai.Content, error) {
+			record("before agent")
+			return nil, nil
+		},
+		AfterAgentCallback: func(agent.CallbackContext) (*genai.Content, error) {
+			record("after agent")
+			return nil, nil
+		},
+		BeforeModelCallback: func(agent.CallbackContext, *model.LLMRequest) (*model.LLMResponse, error) {
+			record("before model")
+			return nil, nil
+		},
+		AfterModelCallback: func(agent.CallbackContext, *model.LLMResponse, error) (*model.LLMResponse, error) {
+			record("after model")
+			return nil, nil
+		},
+		BeforeToolCallback: func(tool.Context, tool.Tool, map[string]any) (map[string]any, error) {
+			record("before tool")
+			return nil, nil
+		},
+		AfterToolCallback: func(tool.Context, tool.Tool, map[string]any, map[string]any, error) (map[string]any, error) {
+			record("after tool")
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	echo, err := functiontool.New(functiontool.Config{Name: "echo", Description: "echoes the args"},
+		func(_ tool.Context, args map[string]any) (map[string]any, error) { return args, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &scriptedModel{responses: []*genai.Content{
+		genai.NewContentFromFunctionCall("echo", map[string]any{"text": "hi"}, genai.RoleModel),
+		genai.NewContentFromText("done", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "agent",
+		Model: m,
+		Tools: []tool.Tool{echo},
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{
+			func(agent.CallbackContext, *model.LLMRequest) (*model.LLMResponse, error) {
+				record("agent before model")
+				return nil, nil
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{AppName: "testApp", Agent: a, SessionService: sessionService, Plugins: []*plugin.Plugin{p}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last *session.Event
+	for ev, err := range r.Run(ctx, "testUser", "testSession", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		last = ev
+	}
+
+	want := []string{
+		"on user message", "before run", "before agent",
+		"before model", "agent before model", "after model", "on event",
+		"before tool", "after tool", "on event",
+		"before model", "agent before model", "after model", "on event",
+		"after agent", "after run",
+	}
+	if diff := cmp.Diff(want, calls); diff != "" {
+		t.Errorf("callbacks mismatch (-want +got):\n%s", diff)
+	}
+	if got := m.requests[0].Contents[0].Parts[0].Text; got != "HI" {
+		t.Errorf("model received %q, want the message rewritten by the plugin", got)
+	}
+	if got := last.Content.Parts[0].Text; got != "[redacted]" {
+		t.Errorf("last event text = %q, want the event replaced by the plugin", got)
+	}
+}
+
+func TestRunner_PluginEndsRun(t *testing.T) {
+	afterRun := false
+	p, err := plugin.New(plugin.Config{
+		Name: "policy",
+		BeforeRunCallback: func(agent.InvocationContext) (*genai.Content, error) {
+			return genai.NewContentFromText("Not allowed.", genai.RoleModel), nil
+		},
+		AfterRunCallback: func(agent.InvocationContext) { afterRun = true },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &scriptedModel{}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{AppName: "testApp", Agent: a, SessionService: sessionService, Plugins: []*plugin.Plugin{p}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for ev, err := range r.Run(ctx, "testUser", "testSession", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		got = append(got, ev.Author+": "+ev.Content.Parts[0].Text)
+	}
+	if diff := cmp.Diff([]string{"agent: Not allowed."}, got); diff != "" {
+		t.Errorf("Run() events mismatch (-want +got):\n%s", diff)
+	}
+	if len(m.requests) != 0 {
+		t.Errorf("model was called %d times, want 0", len(m.requests))
+	}
+	if !afterRun {
+		t.Error("after run callback wasn't called")
+	}
+
+	if _, err := New(Config{AppName: "testApp", Agent: a, SessionService: sessionService, Plugins: []*plugin.Plugin{p, p}}); err == nil {
+		t.Error("New() with duplicate plugins succeeded, want error")
+	}
+}
+
+func TestRunner_AddDeveloperMessage(t *testing.T) {
+	m := &scriptedModel{responses: []*genai.Content{
+		genai.NewContentFromText("You're on the premium plan.", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{AppName: "testApp", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.AddDeveloperMessage(ctx, "testUser", "testSession", genai.NewContentFromText("The subscription was upgraded to premium.", genai.RoleModel)); err != nil {
+		t.Fatalf("AddDeveloperMessage() error = %v", err)
+	}
+	if err := r.AddDeveloperMessage(ctx, "testUser", "testSession", &genai.Content{}); err == nil {
+		t.Error("AddDeveloperMessage() with empty message succeeded")
+	}
+	for _, err := range r.Run(ctx, "testUser", "testSession", genai.NewContentFromText("What's my plan?", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	want := []*genai.Content{
+		{
+			Role: genai.RoleUser,
+			Parts: []*genai.Part{
+				{Text: "Developer message (not from the user), for context:"},
+				{Text: "The subscription was upgraded to premium."},
+			},
+		},
+		genai.NewContentFromText("What's my plan?", genai.RoleUser),
+	}
+	if diff := cmp.Diff(want, m.requests[0].Contents); diff != "" {
+		t.Errorf("model request contents mismatch (-want +got):\n%s", diff)
+	}
+
+	resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Session.Events().At(0).Author; got != session.DeveloperAuthor {
+		t.Errorf("first event author = %q, want %q", got, session.DeveloperAuthor)
+	}
+}
+
+func TestRunner_InvocationSummary(t *testing.T) {
+	echo, err := functiontool.New(functiontool.Config{Name: "echo", Description: "echoes the args"},
+		func(_ tool.Context, args map[string]any) (map[string]any, error) { return args, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &scriptedModel{
+		responses: []*genai.Content{
+			genai.NewContentFromFunctionCall("echo", map[string]any{"text": "hi"}, genai.RoleModel),
+			genai.NewContentFromText("done", genai.RoleModel),
+			genai.NewContentFromText("again", genai.RoleModel),
+		},
+		usage: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 10, CandidatesTokenCount: 5, TotalTokenCount: 15},
+	}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, Tools: []tool.Tool{echo}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{
+		AppName:        "testApp",
+		Agent:          a,
+		SessionService: sessionService,
+		InvocationSummary: &InvocationSummaryConfig{
+			Cost: func(agentName string, usage *genai.GenerateContentResponseUsageMetadata) float64 {
+				return float64(usage.TotalTokenCount) / 1000
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(text string) []*session.Event {
+		var events []*session.Event
+		for ev, err := range r.Run(ctx, "testUser", "testSession", genai.NewContentFromText(text, genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			events = append(events, ev)
+		}
+		return events
+	}
+
+	events := run("hi")
+	for _, ev := range events[:len(events)-1] {
+		if ev.Author == "agent" && ev.Content.Parts[0].FunctionResponse == nil && ev.UsageMetadata == nil {
+			t.Errorf("model event %q has no usage metadata", ev.ID)
+		}
+	}
+	last := events[len(events)-1]
+	want := &session.InvocationSummary{
+		Agents: []session.AgentUsage{{Agent: "agent", ModelCalls: 2, PromptTokens: 20, CandidatesTokens: 10, TotalTokens: 30, Cost: 0.03}},
+		Total:  session.AgentUsage{ModelCalls: 2, PromptTokens: 20, CandidatesTokens: 10, TotalTokens: 30, Cost: 0.03},
+	}
+	if diff := cmp.Diff(want, last.Actions.InvocationSummary); diff != "" {
+		t.Errorf("InvocationSummary mismatch (-want +got):\n%s", diff)
+	}
+	if last.Content != nil {
+		t.Errorf("summary event content = %v, want nil", last.Content)
+	}
+
+	resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := resp.Session.Events().At(resp.Session.Events().Len() - 1)
+	if diff := cmp.Diff(want, stored.Actions.InvocationSummary); diff != "" {
+		t.Errorf("stored InvocationSummary mismatch (-want +got):\n%s", diff)
+	}
+
+	// The summary of the previous invocation isn't sent to the model.
+	run("again")
+	for _, c := range m.requests[len(m.requests)-1].Contents {
+		if len(c.Parts) == 0 {
+			t.Errorf("model request has an empty content: %v", c)
+		}
+	}
+}
+
+func TestRunner_FunctionResponseResumesCallBranch(t *testing.T) {
+	m := &scriptedModel{responses: []*genai.Content{genai.NewContentFromText("done", genai.RoleModel)}}
+	sub, err := llmagent.New(llmagent.Config{Name: "sub", Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := llmagent.New(llmagent.Config{Name: "root", Model: &scriptedModel{}, SubAgents: []agent.Agent{sub}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	resp, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+		{FunctionCall: &genai.FunctionCall{ID: "call-1", Name: "long_running", Args: map[string]any{}}},
+	}}
+	for _, ev := range []*session.Event{
+		{Author: "sub", Branch: "par.sub", LLMResponse: model.LLMResponse{Content: call}},
+		{Author: "other", Branch: "par.other", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("other branch", genai.RoleModel)}},
+	} {
+		if err := sessionService.AppendEvent(ctx, resp.Session, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := New(Config{AppName: "testApp", Agent: root, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+		{FunctionResponse: &genai.FunctionResponse{ID: "call-1", Name: "long_running", Response: map[string]any{"status": "finished"}}},
+	}}
+	for ev, err := range r.Run(ctx, "testUser", "testSession", response, agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if ev.Author != "sub" || ev.Branch != "par.sub" {
+			t.Errorf("event author, branch = %q, %q, want %q, %q", ev.Author, ev.Branch, "sub", "par.sub")
+		}
+	}
+
+	if len(m.requests) != 1 {
+		t.Fatalf("sub model was called %d times, want 1", len(m.requests))
+	}
+	want := []*genai.Content{call, response}
+	if diff := cmp.Diff(want, m.requests[0].Contents); diff != "" {
+		t.Errorf("model request contents mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := sessionService.Get(ctx, &session.GetRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userEvent := got.Session.Events().At(2); userEvent.Author != "user" || userEvent.Branch != "par.sub" {
+		t.Errorf("function response event author, branch = %q, %q, want %q, %q", userEvent.Author, userEvent.Branch, "user", "par.sub")
+	}
+}
+
+func TestRunner_Resume(t *testing.T) {
+	echo, err := functiontool.New(functiontool.Config{Name: "echo", Description: "echoes the args"},
+		func(_ tool.Context, args map[string]any) (map[string]any, error) { return args, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &scriptedModel{responses: []*genai.Content{
+		genai.NewContentFromFunctionCall("echo", map[string]any{"text": "hi"}, genai.RoleModel),
+		genai.NewContentFromText("done", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, Tools: []tool.Tool{echo}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{AppName: "testApp", Agent: a, SessionService: sessionService, Resumable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The caller stops consuming the events after the function call, before
+	// the tool runs.
+	var invocationID, runInvocationID string
+	for ev, err := range r.Run(ctx, "testUser", "testSession", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		// The agent's invocation is a sub-invocation of the one started by
+		// the runner.
+		invocationID, runInvocationID = ev.InvocationID, ev.ParentInvocationID
+		break
+	}
+
+	describe := func(ev *session.Event) string {
+		part := ev.Content.Parts[0]
+		switch {
+		case part.FunctionCall != nil:
+			return "call:" + part.FunctionCall.Name
+		case part.FunctionResponse != nil:
+			return fmt.Sprintf("response:%v", part.FunctionResponse.Response)
+		}
+		return part.Text
+	}
+	var got []string
+	for ev, err := range r.Resume(ctx, "testUser", "testSession", invocationID, agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Resume() error = %v", err)
+		}
+		if ev.ParentInvocationID != runInvocationID {
+			t.Errorf("event parent invocation ID = %q, want %q", ev.ParentInvocationID, runInvocationID)
+		}
+		got = append(got, describe(ev))
+	}
+	if diff := cmp.Diff([]string{"response:map[text:hi]", "done"}, got); diff != "" {
+		t.Errorf("Resume() events mismatch (-want +got):\n%s", diff)
+	}
+	if len(m.requests) != 2 {
+		t.Fatalf("model was called %d times, want 2", len(m.requests))
+	}
+	if n := len(m.requests[1].Contents); n != 3 {
+		t.Errorf("resumed model request has %d contents, want user input, call and response", n)
+	}
+
+	resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := resp.Session.Events()
+	if last := events.At(events.Len() - 1); !last.Actions.EndOfInvocation || last.InvocationID != runInvocationID {
+		t.Errorf("last session event = %+v, want the end of invocation %q", last, runInvocationID)
+	}
+
+	for _, tc := range []struct {
+		name         string
+		runner       *Runner
+		invocationID string
+	}{
+		{name: "completed invocation", runner: r, invocationID: invocationID},
+		{name: "unknown invocation", runner: r, invocationID: "unknown"},
+		{name: "runner not resumable", runner: &Runner{}, invocationID: invocationID},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotErr error
+			for _, err := range tc.runner.Resume(ctx, "testUser", "testSession", tc.invocationID, agent.RunConfig{}) {
+				gotErr = err
+			}
+			if gotErr == nil {
+				t.Error("Resume() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestRunner_ResumeInvocation(t *testing.T) {
+	echo, err := functiontool.New(functiontool.Config{Name: "echo", Description: "echoes the args"},
+		func(_ tool.Context, args map[string]any) (map[string]any, error) { return args, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	newAgent := func(m model.LLM) agent.Agent {
+		return must(llmagent.New(llmagent.Config{Name: "agent", Model: m, Tools: []tool.Tool{echo}}))
+	}
+
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The invocation is serialized once the caller stops consuming the
+	// events after the function call, before the tool runs.
+	var data []byte
+	p, err := plugin.New(plugin.Config{
+		Name: "serializer",
+		AfterRunCallback: func(ctx agent.InvocationContext) {
+			if data, err = json.Marshal(ctx); err != nil {
+				t.Errorf("json.Marshal() error = %v", err)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := New(Config{
+		AppName:        "testApp",
+		Agent:          newAgent(&scriptedModel{responses: []*genai.Content{genai.NewContentFromFunctionCall("echo", map[string]any{"text": "hi"}, genai.RoleModel)}}),
+		SessionService: sessionService,
+		Plugins:        []*plugin.Plugin{p},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var invocationID string
+	for ev, err := range first.Run(ctx, "testUser", "testSession", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		invocationID = ev.ParentInvocationID
+		break
+	}
+
+	var snapshot struct {
+		InvocationID         string                `json:"invocationId"`
+		Agent                string                `json:"agent"`
+		PendingFunctionCalls []*genai.FunctionCall `json:"pendingFunctionCalls"`
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.InvocationID != invocationID || snapshot.Agent != "agent" || len(snapshot.PendingFunctionCalls) != 1 || snapshot.PendingFunctionCalls[0].Name != "echo" {
+		t.Errorf("serialized invocation = %s, want invocation %q of agent with a pending echo call", data, invocationID)
+	}
+
+	// Another runner, e.g. of another process, continues the invocation.
+	second, err := New(Config{
+		AppName:        "testApp",
+		Agent:          newAgent(&scriptedModel{responses: []*genai.Content{genai.NewContentFromText("done", genai.RoleModel)}}),
+		SessionService: sessionService,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for ev, err := range second.ResumeInvocation(ctx, data, agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("ResumeInvocation() error = %v", err)
+		}
+		if ev.ParentInvocationID != invocationID {
+			t.Errorf("event parent invocation ID = %q, want %q", ev.ParentInvocationID, invocationID)
+		}
+		part := ev.Content.Parts[0]
+		if part.FunctionResponse != nil {
+			got = append(got, "response:"+part.FunctionResponse.Name)
+		} else {
+			got = append(got, part.Text)
+		}
+	}
+	if diff := cmp.Diff([]string{"response:echo", "done"}, got); diff != "" {
+		t.Errorf("ResumeInvocation() events mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := second.UnmarshalInvocation(ctx, []byte(`{"invocationId":"e-1","appName":"otherApp"}`), agent.RunConfig{}); err == nil {
+		t.Error("UnmarshalInvocation() of another app succeeded, want an error")
+	}
+}
+
+func TestRunner_PartialEvents(t *testing.T) {
+	ctx := t.Context()
+	testAgent := must(agent.New(agent.Config{
+		Name: "test_agent",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				for i, text := range []string{"It is", "It is sunny.", "It is sunny."} {
+					ev := session.NewEvent(ctx.InvocationID())
+					ev.Author = ctx.Agent().Name()
+					ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel), Partial: i < 2}
+					if !yield(ev, nil) {
+						return
+					}
+				}
+			}
+		},
+	}))
+	sessionService := session.InMemoryService()
+	created, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := session.InMemoryPartialEventStore()
+	expired := session.NewEvent("old_invocation")
+	expired.Timestamp = time.Now().Add(-2 * time.Hour)
+	if err := store.AppendPartial(ctx, created.Session, expired); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stored partial events are the streamed ones: redacted by the
+	// plugins and filtered.
+	redact, err := plugin.New(plugin.Config{
+		Name: "redact",
+		OnEventCallback: func(ctx agent.InvocationContext, ev *session.Event) (*session.Event, error) {
+			redacted := *ev
+			redacted.Content = genai.NewContentFromText(strings.ReplaceAll(ev.Content.Parts[0].Text, "sunny", "***"), genai.RoleModel)
+			return &redacted, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{
+		AppName:        "testApp",
+		Agent:          testAgent,
+		SessionService: sessionService,
+		PartialEvents:  &PartialEventsConfig{Store: store, Retention: time.Hour},
+		Plugins:        []*plugin.Plugin{redact},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := agent.RunConfig{EventFilter: func(ev *session.Event) bool { return ev.Content.Parts[0].Text != "It is" }}
+	for _, err := range r.Run(ctx, "testUser", "testSession", genai.NewContentFromText("hi", genai.RoleUser), cfg) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	resp, err := store.ListPartials(ctx, &session.ListPartialsRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ev := range resp.Events {
+		got = append(got, ev.Content.Parts[0].Text)
+	}
+	if diff := cmp.Diff([]string{"It is ***."}, got); diff != "" {
+		t.Errorf("partial events mismatch (-want +got):\n%s", diff)
+	}
+	stored, err := sessionService.Get(ctx, &session.GetRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ev := range stored.Session.Events().All() {
+		if ev.Partial {
+			t.Errorf("partial event %q committed to the session", ev.Content.Parts[0].Text)
+		}
+	}
+
+	if _, err := New(Config{Agent: testAgent, SessionService: sessionService, PartialEvents: &PartialEventsConfig{}}); err == nil {
+		t.Error("New() without a partial events store succeeded, want an error")
+	}
+}
+
+func TestRunner_Errors(t *testing.T) {
+	echo, err := functiontool.New(functiontool.Config{Name: "echo", Description: "echoes the args"},
+		func(_ tool.Context, args map[string]any) (map[string]any, error) { return args, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := genai.NewContentFromFunctionCall("echo", map[string]any{"text": "hi"}, genai.RoleModel)
+
+	tests := []struct {
+		name      string
+		responses []*genai.Content
+		sessionID string
+		cfg       agent.RunConfig
+		cancel    bool
+		want      error
+	}{
+		{
+			name:      "session not found",
+			sessionID: "missing",
+			want:      session.ErrSessionNotFound,
+		},
+		{
+			name:      "tool not found",
+			responses: []*genai.Content{genai.NewContentFromFunctionCall("missing", nil, genai.RoleModel)},
+			want:      tool.ErrToolNotFound,
+		},
+		{
+			name:      "max iterations",
+			responses: []*genai.Content{call, call, call},
+			cfg:       agent.RunConfig{MaxIterations: 2},
+			want:      agent.ErrMaxIterations,
+		},
+		{
+			name:      "cancelled",
+			responses: []*genai.Content{genai.NewContentFromText("hi", genai.RoleModel)},
+			cancel:    true,
+			want:      agent.ErrInvocationCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+			a := must(llmagent.New(llmagent.Config{Name: "agent", Model: &scriptedModel{responses: tt.responses}, Tools: []tool.Tool{echo}}))
+			sessionService := session.InMemoryService()
+			if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+				t.Fatal(err)
+			}
+			r, err := New(Config{AppName: "app", Agent: a, SessionService: sessionService})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.sessionID == "" {
+				tt.sessionID = "session"
+			}
+			if tt.cancel {
+				cancel()
+			}
+			var got error
+			for _, err := range r.Run(ctx, "user", tt.sessionID, genai.NewContentFromText("hi", genai.RoleUser), tt.cfg) {
+				if err != nil {
+					got = err
+				}
+			}
+			if !errors.Is(got, tt.want) {
+				t.Errorf("Run() error = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunner_IDsAndClock(t *testing.T) {
+	ctx := t.Context()
+	next := 0
+	newID := func() string {
+		next++
+		return fmt.Sprintf("id-%d", next)
+	}
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	a := must(llmagent.New(llmagent.Config{Name: "agent", Model: &scriptedModel{responses: []*genai.Content{genai.NewContentFromText("hi", genai.RoleModel)}}}))
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{
+		AppName:        "app",
+		Agent:          a,
+		SessionService: sessionService,
+		NewID:          newID,
+		Now:            func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range r.Run(ctx, "user", "session", genai.NewContentFromText("hello", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "session"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type stamp struct {
+		ID, InvocationID string
+		Timestamp        time.Time
+	}
+	var got []stamp
+	for ev := range resp.Session.Events().All() {
+		got = append(got, stamp{ev.ID, ev.InvocationID, ev.Timestamp})
+	}
+	// The LLM agent runs in a sub-invocation, with its own ID.
+	want := []stamp{
+		{ID: "id-2", InvocationID: "e-id-1", Timestamp: now},
+		{ID: "id-4", InvocationID: "e-id-3", Timestamp: now},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_RateLimiter(t *testing.T) {
+	ctx := t.Context()
+	limiter, err := NewRateLimiter(RateLimitConfig{QPS: 0.001, Burst: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := must(llmagent.New(llmagent.Config{Name: "agent", Model: &scriptedModel{responses: []*genai.Content{
+		genai.NewContentFromText("one", genai.RoleModel),
+		genai.NewContentFromText("two", genai.RoleModel),
+		genai.NewContentFromText("three", genai.RoleModel),
+	}}}))
+	sessionService := session.InMemoryService()
+	for _, userID := range []string{"noisy", "quiet"} {
+		if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: userID, SessionID: "s"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := New(Config{AppName: "testApp", Agent: a, SessionService: sessionService, RateLimiter: limiter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(userID string) error {
+		for _, err := range r.Run(ctx, userID, "s", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The burst allows two invocations at once.
+	for i := range 2 {
+		if err := run("noisy"); err != nil {
+			t.Fatalf("Run() #%d error = %v", i, err)
+		}
+	}
+	err = run("noisy")
+	var rateErr *RateLimitError
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &rateErr) || rateErr.UserID != "noisy" || rateErr.RetryAfter <= 0 {
+		t.Errorf("Run() over the limit error = %v, want a *RateLimitError of the noisy user", err)
+	}
+	// The other users have their own bucket.
+	if err := run("quiet"); err != nil {
+		t.Errorf("Run() of another user error = %v", err)
+	}
+
+	// Queued invocations wait until their context is done.
+	queued, err := NewRateLimiter(RateLimitConfig{QPS: 0.001, Queue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queued.Wait(ctx, "testApp", "noisy"); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := queued.Wait(waitCtx, "testApp", "noisy"); err == nil {
+		t.Error("Wait() over the limit succeeded, want an error when the context is done")
+	}
+}
+
+func TestRunner_SessionLocker(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		queue   bool
+		wantErr error
+	}{
+		{"fail fast", false, ErrSessionBusy},
+		{"queue", true, context.DeadlineExceeded},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := t.Context()
+			a := must(llmagent.New(llmagent.Config{Name: "agent", Model: &scriptedModel{responses: []*genai.Content{
+				genai.NewContentFromText("one", genai.RoleModel),
+				genai.NewContentFromText("two", genai.RoleModel),
+				genai.NewContentFromText("three", genai.RoleModel),
+			}}}))
+			sessionService := session.InMemoryService()
+			for _, sessionID := range []string{"s1", "s2"} {
+				if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "user", SessionID: sessionID}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r, err := New(Config{AppName: "testApp", Agent: a, SessionService: sessionService, SessionLocker: NewSessionLocker(SessionLockConfig{Queue: tc.queue})})
+			if err != nil {
+				t.Fatal(err)
+			}
+			run := func(ctx context.Context, sessionID string) error {
+				for _, err := range r.Run(ctx, "user", sessionID, genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			// The first run holds the lock of s1 while its events are consumed.
+			next, stop := iter.Pull2(r.Run(ctx, "user", "s1", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}))
+			if _, err, ok := next(); err != nil || !ok {
+				t.Fatalf("Run() first event = %v, %v", err, ok)
+			}
+			waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			defer cancel()
+			if err := run(waitCtx, "s1"); !errors.Is(err, tc.wantErr) {
+				t.Errorf("concurrent Run() error = %v, want %v", err, tc.wantErr)
+			}
+			msgCtx, cancelMsg := context.WithTimeout(ctx, 10*time.Millisecond)
+			defer cancelMsg()
+			if err := r.AddDeveloperMessage(msgCtx, "user", "s1", genai.NewContentFromText("note", genai.RoleUser)); !errors.Is(err, tc.wantErr) {
+				t.Errorf("concurrent AddDeveloperMessage() error = %v, want %v", err, tc.wantErr)
+			}
+			if err := run(ctx, "s2"); err != nil {
+				t.Errorf("Run() of another session error = %v", err)
+			}
+			stop()
+			if err := run(ctx, "s1"); err != nil {
+				t.Errorf("Run() after the first run ended error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrSessionBusy is matched by the [*SessionBusyError] of the runs rejected
+// by the session locker.
+var ErrSessionBusy = errors.New("session busy")
+
+// SessionBusyError is returned by the runs of a session which another run
+// of the runner is driving, see [Config.SessionLocker].
+type SessionBusyError struct {
+	AppName, UserID, SessionID string
+}
+
+func (e *SessionBusyError) Error() string {
+	return fmt.Sprintf("session %q of user %q of app %q is used by another invocation", e.SessionID, e.UserID, e.AppName)
+}
+
+func (e *SessionBusyError) Is(target error) bool { return target == ErrSessionBusy }
+
+// SessionLocker serializes the invocations of each session, so that two
+// simultaneous runs of a session don't interleave their events.
+type SessionLocker interface {
+	// Lock returns when the session is locked for an invocation, with the
+	// function unlocking it, or an error, e.g. a *SessionBusyError, if the
+	// invocation is rejected.
+	Lock(ctx context.Context, appName, userID, sessionID string) (unlock func(), err error)
+}
+
+// SessionLockConfig configures the locker returned by [NewSessionLocker].
+type SessionLockConfig struct {
+	// Queue makes the invocations of a locked session wait for their turn,
+	// until their context is done, rather than be rejected.
+	// Optional: if false, they fail with a *SessionBusyError.
+	Queue bool
+}
+
+// NewSessionLocker returns a session locker serializing the invocations of
+// the runners of the process which share it. The runners of other
+// processes, e.g. of other replicas of a server, need a distributed
+// SessionLocker.
+func NewSessionLocker(cfg SessionLockConfig) SessionLocker {
+	return &localSessionLocker{cfg: cfg, locks: make(map[sessionKey]*sessionLock)}
+}
+
+type sessionKey struct {
+	appName, userID, sessionID string
+}
+
+// sessionLock is the lock of a session, removed when no invocation holds or
+// waits for it.
+type sessionLock struct {
+	sem  chan struct{}
+	refs int
+}
+
+type localSessionLocker struct {
+	cfg SessionLockConfig
+
+	mu    sync.Mutex
+	locks map[sessionKey]*sessionLock
+}
+
+func (l *localSessionLocker) Lock(ctx context.Context, appName, userID, sessionID string) (func(), error) {
+	key := sessionKey{appName: appName, userID: userID, sessionID: sessionID}
+	l.mu.Lock()
+	lock, ok := l.locks[key]
+	if !ok {
+		lock = &sessionLock{sem: make(chan struct{}, 1)}
+		l.locks[key] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	release := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if lock.refs--; lock.refs == 0 {
+			delete(l.locks, key)
+		}
+	}
+	if l.cfg.Queue {
+		select {
+		case lock.sem <- struct{}{}:
+		case <-ctx.Done():
+			release()
+			return nil, fmt.Errorf("failed to wait for the lock of session %q: %w", sessionID, ctx.Err())
+		}
+	} else {
+		select {
+		case lock.sem <- struct{}{}:
+		default:
+			release()
+			return nil, &SessionBusyError{AppName: appName, UserID: userID, SessionID: sessionID}
+		}
+	}
+	return sync.OnceFunc(func() {
+		<-lock.sem
+		release()
+	}), nil
+}
+
+// lockSession locks the session for an invocation with the session locker
+// of the runner, if any.
+func (r *Runner) lockSession(ctx context.Context, userID, sessionID string) (unlock func(), err error) {
+	if r.sessionLocker == nil {
+		return func() {}, nil
+	}
+	return r.sessionLocker.Lock(ctx, r.appName, userID, sessionID)
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// InvocationSummaryConfig configures the invocation summary event, see
+// [Config.InvocationSummary].
+type InvocationSummaryConfig struct {
+	// Cost returns the cost in USD of a model call of the agent with the
+	// given usage, e.g. the CostFunc of a pricing.Table.
+	// Optional: if nil, the costs are zero.
+	Cost func(agentName string, usage *genai.GenerateContentResponseUsageMetadata) float64
+}
+
+// usageAggregator aggregates the usage metadata of the events of an
+// invocation per agent.
+type usageAggregator struct {
+	cfg    *InvocationSummaryConfig
+	agents []session.AgentUsage
+	index  map[string]int
+}
+
+func newUsageAggregator(cfg *InvocationSummaryConfig) *usageAggregator {
+	return &usageAggregator{cfg: cfg, index: make(map[string]int)}
+}
+
+func (a *usageAggregator) add(event *session.Event) {
+	usage := event.UsageMetadata
+	if usage == nil || event.Partial {
+		return
+	}
+	i, ok := a.index[event.Author]
+	if !ok {
+		i = len(a.agents)
+		a.index[event.Author] = i
+		a.agents = append(a.agents, session.AgentUsage{Agent: event.Author})
+	}
+	u := &a.agents[i]
+	u.ModelCalls++
+	u.PromptTokens += int64(usage.PromptTokenCount)
+	u.CandidatesTokens += int64(usage.CandidatesTokenCount)
+	u.TotalTokens += int64(usage.TotalTokenCount)
+	if a.cfg.Cost != nil {
+		u.Cost += a.cfg.Cost(event.Author, usage)
+	}
+}
+
+func (a *usageAggregator) summary() *session.InvocationSummary {
+	s := &session.InvocationSummary{Agents: a.agents}
+	for _, u := range a.agents {
+		s.Total.ModelCalls += u.ModelCalls
+		s.Total.PromptTokens += u.PromptTokens
+		s.Total.CandidatesTokens += u.CandidatesTokens
+		s.Total.TotalTokens += u.TotalTokens
+		s.Total.Cost += u.Cost
+	}
+	return s
+}
+
+// newSummaryEvent returns the event carrying the invocation summary.
+func newSummaryEvent(ctx agent.InvocationContext, author string, summary *session.InvocationSummary) *session.Event {
+	event := session.NewEvent(ctx.InvocationID())
+	event.Author = author
+	event.Branch = ctx.Branch()
+	event.TurnComplete = true
+	event.Actions.InvocationSummary = summary
+	return event
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	agentinternal "google.golang.org/adk/internal/agent"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// ToolInfo describes a tool of the agent tree, see Runner.Tools.
+type ToolInfo struct {
+	// Agent is the name of the agent owning the tool.
+	Agent string
+	// Toolset is the name of the toolset providing the tool, empty if the tool
+	// is given to the agent directly.
+	Toolset string
+	// Name and Description of the tool.
+	Name        string
+	Description string
+	// Declaration is the function declaration sent to the model, nil for the
+	// tools which aren't functions, e.g. the built-in tools of the model.
+	Declaration *genai.FunctionDeclaration
+	// LongRunning reports whether the tool is a long-running operation.
+	LongRunning bool
+	// Auth describes the credential the tool requests, nil if the tool
+	// requests none or doesn't document it, see tool.Documented.
+	Auth *auth.Config
+	// Timeout bounds the duration of a call of the tool, zero if none.
+	Timeout time.Duration
+	// Reachable reports whether the owning agent can be reached from the
+	// root agent, i.e. transferred to by an LLM agent or run by a workflow
+	// agent. Tools of unreachable agents are never offered to a model.
+	Reachable bool
+	// Unreachable explains why the owning agent can't be reached, empty if it
+	// can.
+	Unreachable string
+}
+
+// Tools lists the tools of all the LLM agents of the agent tree, in the order
+// of the tree, with their declarations and requirements.
+//
+// The tools of toolsets are listed as they are offered in a new session of
+// the user, so toolsets selecting tools based on the session state may offer
+// other tools later on. The session is not stored in the session service.
+func (r *Runner) Tools(ctx context.Context, userID string) ([]ToolInfo, error) {
+	created, err := session.InMemoryService().Create(ctx, &session.CreateRequest{
+		AppName: r.appName,
+		UserID:  userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the catalog session: %w", err)
+	}
+	reachable := r.reachableAgents()
+
+	var infos []ToolInfo
+	var walk func(a agent.Agent) error
+	walk = func(a agent.Agent) error {
+		if llmAgent, ok := a.(llminternal.Agent); ok {
+			unreachable := ""
+			if !reachable[a.Name()] {
+				unreachable = r.unreachableReason(a)
+			}
+			ictx := icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{
+				Session: created.Session,
+				Agent:   a,
+			})
+			add := func(toolset string, t tool.Tool) {
+				info := ToolInfo{
+					Agent:       a.Name(),
+					Toolset:     toolset,
+					Name:        t.Name(),
+					Description: t.Description(),
+					LongRunning: t.IsLongRunning(),
+					Reachable:   unreachable == "",
+					Unreachable: unreachable,
+				}
+				if fn, ok := t.(toolinternal.FunctionTool); ok {
+					info.Declaration = fn.Declaration()
+				}
+				if d, ok := t.(tool.Documented); ok {
+					req := d.Requirements()
+					info.Auth, info.Timeout = req.Auth, req.Timeout
+				}
+				infos = append(infos, info)
+			}
+			state := llminternal.Reveal(llmAgent)
+			for _, t := range state.Tools {
+				add("", t)
+			}
+			for _, ts := range state.Toolsets {
+				tools, err := ts.Tools(icontext.NewReadonlyContext(ictx))
+				if err != nil {
+					return fmt.Errorf("failed to list the tools of the toolset %q of agent %q: %w", ts.Name(), a.Name(), err)
+				}
+				for _, t := range tools {
+					add(ts.Name(), t)
+				}
+			}
+		}
+		for _, sub := range a.SubAgents() {
+			if err := walk(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(r.rootAgent); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// reachableAgents returns the names of the agents which can be reached from
+// the root agent. LLM agents reach the agents they can transfer to, workflow
+// agents reach all their sub-agents. Custom agents decide by themselves which
+// sub-agents they run, so they aren't assumed to reach any.
+func (r *Runner) reachableAgents() map[string]bool {
+	reachable := map[string]bool{}
+	queue := []agent.Agent{r.rootAgent}
+	for len(queue) > 0 {
+		a := queue[0]
+		queue = queue[1:]
+		if reachable[a.Name()] {
+			continue
+		}
+		reachable[a.Name()] = true
+		switch agentType(a) {
+		case agentinternal.TypeLLMAgent:
+			queue = append(queue, llminternal.TransferTargets(a, r.parents[a.Name()])...)
+		case agentinternal.TypeLoopAgent, agentinternal.TypeSequentialAgent, agentinternal.TypeParallelAgent:
+			queue = append(queue, a.SubAgents()...)
+		}
+	}
+	return reachable
+}
+
+// unreachableReason explains why the agent a can't be reached from the root
+// agent.
+func (r *Runner) unreachableReason(a agent.Agent) string {
+	parent := r.parents[a.Name()]
+	if agentType(parent) == agentinternal.TypeCustomAgent {
+		return fmt.Sprintf("agent %q is a sub-agent of the custom agent %q, which may run it on its own", a.Name(), parent.Name())
+	}
+	return fmt.Sprintf("no agent can transfer to agent %q or run it", a.Name())
+}
+
+// agentType returns the type of the agent, TypeCustomAgent for the agents
+// implemented outside of this module.
+func agentType(a agent.Agent) agentinternal.Type {
+	if internal, ok := a.(agentinternal.Agent); ok {
+		return agentinternal.Reveal(internal).AgentType
+	}
+	return agentinternal.TypeCustomAgent
+}
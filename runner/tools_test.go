@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"iter"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+type staticToolset struct {
+	name  string
+	tools []tool.Tool
+}
+
+func (s *staticToolset) Name() string { return s.name }
+
+func (s *staticToolset) Tools(agent.ReadonlyContext) ([]tool.Tool, error) { return s.tools, nil }
+
+func TestRunner_Tools(t *testing.T) {
+	newTool := func(name string, timeout time.Duration) tool.Tool {
+		t.Helper()
+		tl, err := functiontool.New(functiontool.Config{Name: name, Description: name + " tool", Timeout: timeout},
+			func(tool.Context, struct{}) (map[string]any, error) { return nil, nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tl
+	}
+
+	hidden := must(llmagent.New(llmagent.Config{
+		Name:  "hidden",
+		Tools: []tool.Tool{newTool("secret", 0)},
+	}))
+	custom := must(agent.New(agent.Config{
+		Name:      "custom",
+		SubAgents: []agent.Agent{hidden},
+		Run: func(agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(func(*session.Event, error) bool) {}
+		},
+	}))
+	helper := must(llmagent.New(llmagent.Config{
+		Name:     "helper",
+		Toolsets: []tool.Toolset{&staticToolset{name: "search", tools: []tool.Tool{newTool("find", 0)}}},
+	}))
+	root := must(llmagent.New(llmagent.Config{
+		Name:      "root",
+		Tools:     []tool.Tool{newTool("lookup", time.Minute)},
+		SubAgents: []agent.Agent{helper, custom},
+	}))
+	r, err := New(Config{AppName: "app", Agent: root, SessionService: session.InMemoryService()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.Tools(t.Context(), "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ToolInfo{
+		{Agent: "root", Name: "lookup", Description: "lookup tool", Timeout: time.Minute, Reachable: true},
+		{Agent: "helper", Toolset: "search", Name: "find", Description: "find tool", Reachable: true},
+		{Agent: "hidden", Name: "secret", Description: "secret tool"},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(ToolInfo{}, "Declaration", "Unreachable")); diff != "" {
+		t.Errorf("Tools() mismatch (-want +got):\n%s", diff)
+	}
+	for _, info := range got {
+		if info.Declaration == nil || info.Declaration.Name != info.Name {
+			t.Errorf("Tools() declaration of %q = %+v", info.Name, info.Declaration)
+		}
+	}
+	if !strings.Contains(got[2].Unreachable, `custom agent "custom"`) {
+		t.Errorf("Tools() unreachable reason = %q, want it to name the custom agent", got[2].Unreachable)
+	}
+}
@@ -13,4 +13,19 @@
 // limitations under the License.
 
 // Package adka2a allows to expose ADK agents via A2A.
+//
+// The simplest way to serve an agent is [NewServeMux], which serves the agent
+// card and handles the A2A JSON-RPC requests:
+//
+//	mux := adka2a.NewServeMux(adka2a.ExecutorConfig{
+//		RunnerConfig: runner.Config{
+//			AppName:        agent.Name(),
+//			Agent:          agent,
+//			SessionService: session.InMemoryService(),
+//		},
+//	}, "http://localhost:8080", "/invoke")
+//	http.ListenAndServe(":8080", mux)
+//
+// [NewAgentCard] and [NewJSONRPCHandler] can be used to mount the endpoints on
+// an existing router.
 package adka2a
@@ -152,6 +152,8 @@ func (e *Executor) prepareSession(ctx context.Context, meta invocationMeta) erro
 		AppName:   e.config.RunnerConfig.AppName,
 		UserID:    meta.userID,
 		SessionID: meta.sessionID,
+		// A session created by a previous request may not be replicated yet.
+		Consistency: session.ConsistencyStrong,
 	})
 	if err == nil && resp != nil {
 		return nil
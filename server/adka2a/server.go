@@ -0,0 +1,59 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adka2a
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2asrv"
+	"google.golang.org/adk/agent"
+)
+
+// NewAgentCard builds the public agent card of the agent. The invokeURL is
+// the URL of the JSON-RPC endpoint A2A clients connect to.
+func NewAgentCard(agent agent.Agent, invokeURL string) *a2a.AgentCard {
+	return &a2a.AgentCard{
+		Name:                              agent.Name(),
+		Description:                       agent.Description(),
+		DefaultInputModes:                 []string{"text/plain"},
+		DefaultOutputModes:                []string{"text/plain"},
+		URL:                               invokeURL,
+		PreferredTransport:                a2a.TransportProtocolJSONRPC,
+		Skills:                            BuildAgentSkills(agent),
+		Capabilities:                      a2a.AgentCapabilities{Streaming: true},
+		SupportsAuthenticatedExtendedCard: false,
+	}
+}
+
+// NewJSONRPCHandler returns an http.Handler which serves the A2A JSON-RPC
+// protocol (message/send, message/stream, tasks/get, etc.) by running the
+// agent with an [Executor].
+func NewJSONRPCHandler(config ExecutorConfig, opts ...a2asrv.RequestHandlerOption) http.Handler {
+	return a2asrv.NewJSONRPCHandler(a2asrv.NewHandler(NewExecutor(config), opts...))
+}
+
+// NewServeMux returns an http.ServeMux which exposes the agent as an A2A
+// endpoint: the agent card is served on [a2asrv.WellKnownAgentCardPath] and
+// the JSON-RPC requests are handled on invokePath. The card advertises
+// baseURL+invokePath as the agent URL.
+func NewServeMux(config ExecutorConfig, baseURL, invokePath string, opts ...a2asrv.RequestHandlerOption) *http.ServeMux {
+	mux := http.NewServeMux()
+	card := NewAgentCard(config.RunnerConfig.Agent, strings.TrimSuffix(baseURL, "/")+invokePath)
+	mux.Handle(a2asrv.WellKnownAgentCardPath, a2asrv.NewStaticAgentCardHandler(card))
+	mux.Handle(invokePath, NewJSONRPCHandler(config, opts...))
+	return mux
+}
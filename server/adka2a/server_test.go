@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adka2a
+
+import (
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2aclient"
+	"github.com/a2aproject/a2a-go/a2aclient/agentcard"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+func TestNewServeMux(t *testing.T) {
+	ctx := t.Context()
+
+	agnt, err := agent.New(agent.Config{
+		Name:        "greeter",
+		Description: "greets the user",
+		Run: func(ic agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				for _, text := range []string{"Hello", "world"} {
+					event := session.NewEvent(ic.InvocationID())
+					event.Content = genai.NewContentFromText(text, genai.RoleModel)
+					if !yield(event, nil) {
+						return
+					}
+				}
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("agent.New() error = %v", err)
+	}
+
+	var handler http.Handler
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	handler = NewServeMux(ExecutorConfig{
+		RunnerConfig: runner.Config{
+			AppName:        agnt.Name(),
+			Agent:          agnt,
+			SessionService: session.InMemoryService(),
+		},
+	}, server.URL, "/invoke")
+
+	card, err := agentcard.DefaultResolver.Resolve(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("agentcard.Resolve() error = %v", err)
+	}
+	if card.Name != "greeter" || card.URL != server.URL+"/invoke" || !card.Capabilities.Streaming {
+		t.Errorf("agent card = %+v, want streaming agent greeter served on %s/invoke", card, server.URL)
+	}
+
+	client, err := a2aclient.NewFromCard(ctx, card)
+	if err != nil {
+		t.Fatalf("a2aclient.NewFromCard() error = %v", err)
+	}
+
+	var texts []string
+	var lastState a2a.TaskState
+	for event, err := range client.SendStreamingMessage(ctx, &a2a.MessageSendParams{
+		Message: a2a.NewMessage(a2a.MessageRoleUser, a2a.TextPart{Text: "Hi!"}),
+	}) {
+		if err != nil {
+			t.Fatalf("client.SendStreamingMessage() error = %v", err)
+		}
+		switch e := event.(type) {
+		case *a2a.TaskArtifactUpdateEvent:
+			for _, p := range e.Artifact.Parts {
+				if tp, ok := p.(a2a.TextPart); ok {
+					texts = append(texts, tp.Text)
+				}
+			}
+		case *a2a.TaskStatusUpdateEvent:
+			lastState = e.Status.State
+		}
+	}
+	if len(texts) != 2 || texts[0] != "Hello" || texts[1] != "world" {
+		t.Errorf("streamed texts = %v, want [Hello world]", texts)
+	}
+	if lastState != a2a.TaskStateCompleted {
+		t.Errorf("final task state = %v, want %v", lastState, a2a.TaskStateCompleted)
+	}
+}
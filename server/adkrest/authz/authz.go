@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package authz authorizes the requests to the ADK REST API.
+//
+// The operations of the API are either user operations, e.g. running the
+// agents or reading the user's own sessions, or admin operations, e.g.
+// deleting sessions or the debug and eval endpoints. An [Authorizer] decides
+// whether a request may perform an operation; [JWT] is an example
+// implementation verifying the JSON Web Tokens of the requests.
+package authz
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Scope is the kind of an operation of the REST API.
+type Scope string
+
+const (
+	// ScopeUser operations act on the resources of the user of the request:
+	// running the agents and reading and creating the user's sessions and
+	// artifacts.
+	ScopeUser Scope = "user"
+	// ScopeAdmin operations act on the resources of any user or on the
+	// server: deleting sessions and artifacts, the debug and eval endpoints.
+	ScopeAdmin Scope = "admin"
+)
+
+// Operation is an operation of the REST API to authorize.
+type Operation struct {
+	// Name of the operation, e.g. "RunAgent" or "DeleteSession".
+	Name  string
+	Scope Scope
+	// The app, user and session the operation acts on, empty if the
+	// operation doesn't have them, e.g. listing the apps.
+	AppName   string
+	UserID    string
+	SessionID string
+}
+
+// Errors returned by the authorizers. The REST API responds with the status
+// 401 Unauthorized and 403 Forbidden respectively.
+var (
+	ErrUnauthenticated  = errors.New("unauthenticated")
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// Authorizer authorizes the requests to the REST API.
+type Authorizer interface {
+	// Authorize returns nil if the request may perform the operation. It
+	// returns an error wrapping ErrUnauthenticated if the request has no
+	// valid credentials and ErrPermissionDenied if the caller isn't allowed
+	// to perform the operation.
+	Authorize(r *http.Request, op Operation) error
+}
+
+// AuthorizerFunc is an adapter to use a function as an [Authorizer].
+type AuthorizerFunc func(r *http.Request, op Operation) error
+
+// Authorize implements [Authorizer].
+func (f AuthorizerFunc) Authorize(r *http.Request, op Operation) error {
+	return f(r, op)
+}
+
+// Principal is the authenticated caller of the REST API.
+type Principal struct {
+	// Subject is the user ID of the caller.
+	Subject string
+	// Admin callers may perform all the operations.
+	Admin bool
+}
+
+// Allow returns nil if the principal may perform the operation: admins may
+// perform all the operations, the other callers the user operations on
+// their own user ID.
+func (p Principal) Allow(op Operation) error {
+	if p.Admin {
+		return nil
+	}
+	if op.Scope != ScopeUser {
+		return fmt.Errorf("%w: %s requires admin access", ErrPermissionDenied, op.Name)
+	}
+	if op.UserID != "" && op.UserID != p.Subject {
+		return fmt.Errorf("%w: %s of user %q by %q", ErrPermissionDenied, op.Name, op.UserID, p.Subject)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/server/adkrest/authz"
+)
+
+func TestPrincipalAllow(t *testing.T) {
+	run := authz.Operation{Name: "RunAgent", Scope: authz.ScopeUser, UserID: "alice"}
+	listApps := authz.Operation{Name: "ListApps", Scope: authz.ScopeUser}
+	deleteSession := authz.Operation{Name: "DeleteSession", Scope: authz.ScopeAdmin, UserID: "alice"}
+
+	tests := []struct {
+		name      string
+		principal authz.Principal
+		op        authz.Operation
+		wantErr   error
+	}{
+		{"own user operation", authz.Principal{Subject: "alice"}, run, nil},
+		{"operation without user", authz.Principal{Subject: "alice"}, listApps, nil},
+		{"other user operation", authz.Principal{Subject: "bob"}, run, authz.ErrPermissionDenied},
+		{"admin operation", authz.Principal{Subject: "alice"}, deleteSession, authz.ErrPermissionDenied},
+		{"admin", authz.Principal{Subject: "root", Admin: true}, deleteSession, nil},
+		{"admin other user", authz.Principal{Subject: "root", Admin: true}, run, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.principal.Allow(tt.op); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Allow() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWT(t *testing.T) {
+	secret := []byte("secret")
+	j, err := authz.NewJWT(authz.JWTConfig{Secret: secret, Issuer: "issuer", Audience: "adk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+	token := func(t *testing.T, secret []byte, claims map[string]any) string {
+		t.Helper()
+		tok, err := authz.NewToken(secret, claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	run := authz.Operation{Name: "RunAgent", Scope: authz.ScopeUser, UserID: "alice"}
+
+	tests := []struct {
+		name          string
+		authorization string
+		op            authz.Operation
+		wantPrincipal authz.Principal
+		wantErr       error
+	}{
+		{
+			name:          "user",
+			authorization: "Bearer " + token(t, secret, map[string]any{"sub": "alice", "iss": "issuer", "aud": "adk", "exp": exp}),
+			op:            run,
+			wantPrincipal: authz.Principal{Subject: "alice"},
+		},
+		{
+			name:          "admin with audience list",
+			authorization: "Bearer " + token(t, secret, map[string]any{"sub": "root", "iss": "issuer", "aud": []string{"other", "adk"}, "admin": true}),
+			op:            authz.Operation{Name: "DeleteSession", Scope: authz.ScopeAdmin},
+			wantPrincipal: authz.Principal{Subject: "root", Admin: true},
+		},
+		{
+			name:          "other user",
+			authorization: "Bearer " + token(t, secret, map[string]any{"sub": "bob", "iss": "issuer", "aud": "adk"}),
+			op:            run,
+			wantPrincipal: authz.Principal{Subject: "bob"},
+			wantErr:       authz.ErrPermissionDenied,
+		},
+		{
+			name:    "missing token",
+			op:      run,
+			wantErr: authz.ErrUnauthenticated,
+		},
+		{
+			name:          "wrong secret",
+			authorization: "Bearer " + token(t, []byte("other"), map[string]any{"sub": "alice", "iss": "issuer", "aud": "adk"}),
+			op:            run,
+			wantErr:       authz.ErrUnauthenticated,
+		},
+		{
+			name:          "expired",
+			authorization: "Bearer " + token(t, secret, map[string]any{"sub": "alice", "iss": "issuer", "aud": "adk", "exp": time.Now().Add(-time.Minute).Unix()}),
+			op:            run,
+			wantErr:       authz.ErrUnauthenticated,
+		},
+		{
+			name:          "wrong issuer",
+			authorization: "Bearer " + token(t, secret, map[string]any{"sub": "alice", "iss": "other", "aud": "adk"}),
+			op:            run,
+			wantErr:       authz.ErrUnauthenticated,
+		},
+		{
+			name:          "wrong audience",
+			authorization: "Bearer " + token(t, secret, map[string]any{"sub": "alice", "iss": "issuer", "aud": "other"}),
+			op:            run,
+			wantErr:       authz.ErrUnauthenticated,
+		},
+		{
+			name:          "no subject",
+			authorization: "Bearer " + token(t, secret, map[string]any{"iss": "issuer", "aud": "adk"}),
+			op:            run,
+			wantErr:       authz.ErrUnauthenticated,
+		},
+		{
+			name:          "malformed",
+			authorization: "Bearer abc",
+			op:            run,
+			wantErr:       authz.ErrUnauthenticated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/run", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			if err := j.Authorize(r, tt.op); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Authorize() error = %v, want %v", err, tt.wantErr)
+			}
+			p, err := j.Principal(r)
+			if err == nil && p != tt.wantPrincipal {
+				t.Errorf("Principal() = %+v, want %+v", p, tt.wantPrincipal)
+			}
+		})
+	}
+}
+
+func TestNewJWT_RequiresSecret(t *testing.T) {
+	if _, err := authz.NewJWT(authz.JWTConfig{}); err == nil {
+		t.Error("NewJWT() error = nil, want error")
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JWTConfig is used to create a [JWT] authorizer.
+type JWTConfig struct {
+	// Secret is the key of the HS256 signature of the tokens.
+	Secret []byte
+	// Issuer is the required "iss" claim.
+	// Optional: if empty, the issuer isn't checked.
+	Issuer string
+	// Audience is the required "aud" claim.
+	// Optional: if empty, the audience isn't checked.
+	Audience string
+	// AdminClaim is the name of the boolean claim granting the admin access.
+	// Optional: defaults to "admin".
+	AdminClaim string
+}
+
+// JWT authorizes the requests with the JSON Web Token in their
+// "Authorization: Bearer" header. The "sub" claim of the token is the user
+// ID of the caller, see [Principal].
+//
+// JWT only supports the tokens signed with HS256 and is meant as an example:
+// production deployments usually verify the tokens of their identity
+// provider with its public keys.
+type JWT struct {
+	cfg JWTConfig
+	now func() time.Time
+}
+
+// NewJWT creates a [JWT] authorizer.
+func NewJWT(cfg JWTConfig) (*JWT, error) {
+	if len(cfg.Secret) == 0 {
+		return nil, fmt.Errorf("secret is required")
+	}
+	if cfg.AdminClaim == "" {
+		cfg.AdminClaim = "admin"
+	}
+	return &JWT{cfg: cfg, now: time.Now}, nil
+}
+
+// Authorize implements [Authorizer].
+func (j *JWT) Authorize(r *http.Request, op Operation) error {
+	p, err := j.Principal(r)
+	if err != nil {
+		return err
+	}
+	return p.Allow(op)
+}
+
+// Principal returns the caller identified by the token of the request.
+func (j *JWT) Principal(r *http.Request) (Principal, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: missing bearer token", ErrUnauthenticated)
+	}
+	claims, err := j.verify(strings.TrimSpace(token))
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return Principal{}, fmt.Errorf("%w: token has no subject", ErrUnauthenticated)
+	}
+	admin, _ := claims[j.cfg.AdminClaim].(bool)
+	return Principal{Subject: sub, Admin: admin}, nil
+}
+
+// verify checks the signature and the registered claims of the token and
+// returns its claims.
+func (j *JWT) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	if !hmac.Equal(signature, sign(j.cfg.Secret, parts[0]+"."+parts[1])) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid claims: %w", err)
+	}
+	now := j.now()
+	if exp, ok := claims["exp"].(float64); ok && !now.Before(time.Unix(int64(exp), 0)) {
+		return nil, fmt.Errorf("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0)) {
+		return nil, fmt.Errorf("token not valid yet")
+	}
+	if j.cfg.Issuer != "" && claims["iss"] != j.cfg.Issuer {
+		return nil, fmt.Errorf("unexpected issuer %v", claims["iss"])
+	}
+	if j.cfg.Audience != "" && !hasAudience(claims["aud"], j.cfg.Audience) {
+		return nil, fmt.Errorf("unexpected audience %v", claims["aud"])
+	}
+	return claims, nil
+}
+
+// NewToken returns a token with the given claims signed with HS256, to be
+// verified by a [JWT] authorizer with the same secret, e.g. in tests.
+func NewToken(secret []byte, claims map[string]any) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode claims: %w", err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(secret, signed)), nil
+}
+
+func sign(secret []byte, signed string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func hasAudience(aud any, want string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == want
+	case []any:
+		return slices.Contains(aud, any(want))
+	}
+	return false
+}
@@ -15,9 +15,14 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
 
+	"github.com/gorilla/mux"
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/server/adkrest/internal/models"
+	"google.golang.org/adk/session"
 )
 
 // AppsAPIController is the controller for the Apps API.
@@ -34,3 +39,36 @@ func (c *AppsAPIController) ListAppsHandler(rw http.ResponseWriter, req *http.Re
 	apps := c.agentLoader.ListAgents()
 	EncodeJSONResponse(apps, http.StatusOK, rw)
 }
+
+// ListToolsHandler lists the tools of the agent tree of an app, with their
+// declarations, requirements and reachability, see runner.Runner.Tools.
+func (c *AppsAPIController) ListToolsHandler(rw http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	appName, userID := vars["app_name"], vars["user_id"]
+	if appName == "" || userID == "" {
+		return newStatusError(fmt.Errorf("app_name and user_id parameters are required"), http.StatusBadRequest)
+	}
+	rootAgent, err := c.agentLoader.LoadAgent(appName)
+	if err != nil {
+		return newStatusError(fmt.Errorf("load agent: %w", err), http.StatusNotFound)
+	}
+	// The catalog never reads or stores sessions.
+	r, err := runner.New(runner.Config{
+		AppName:        appName,
+		Agent:          rootAgent,
+		SessionService: session.InMemoryService(),
+	})
+	if err != nil {
+		return newStatusError(fmt.Errorf("create runner: %w", err), http.StatusInternalServerError)
+	}
+	infos, err := r.Tools(req.Context(), userID)
+	if err != nil {
+		return newStatusError(fmt.Errorf("list tools: %w", err), http.StatusInternalServerError)
+	}
+	tools := []models.Tool{}
+	for _, info := range infos {
+		tools = append(tools, models.FromToolInfo(info))
+	}
+	EncodeJSONResponse(tools, http.StatusOK, rw)
+	return nil
+}
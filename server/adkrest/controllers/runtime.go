@@ -145,9 +145,10 @@ func flashEvent(flusher http.Flusher, rw http.ResponseWriter, event session.Even
 
 func (c *RuntimeAPIController) validateSessionExists(ctx context.Context, appName, userID, sessionID string) error {
 	_, err := c.sessionService.Get(ctx, &session.GetRequest{
-		AppName:   appName,
-		UserID:    userID,
-		SessionID: sessionID,
+		AppName:     appName,
+		UserID:      userID,
+		SessionID:   sessionID,
+		Consistency: session.ConsistencyStrong,
 	})
 	if err != nil {
 		return newStatusError(fmt.Errorf("get session: %w", err), http.StatusNotFound)
@@ -176,11 +177,37 @@ func (c *RuntimeAPIController) getRunner(req models.RunAgentRequest) (*runner.Ru
 	if req.Streaming {
 		streamingMode = agent.StreamingModeSSE
 	}
+	eventFilter, err := toEventFilter(req.EventFilter)
+	if err != nil {
+		return nil, nil, newStatusError(err, http.StatusBadRequest)
+	}
 	return r, &agent.RunConfig{
 		StreamingMode: streamingMode,
+		EventFilter:   eventFilter,
 	}, nil
 }
 
+// toEventFilter combines the event filters set in the request.
+func toEventFilter(names []string) (agent.EventFilter, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	var filters []agent.EventFilter
+	for _, name := range names {
+		switch name {
+		case models.EventFilterFinalOnly:
+			filters = append(filters, agent.FinalResponsesOnly)
+		case models.EventFilterNoPartials:
+			filters = append(filters, agent.NoPartials)
+		case models.EventFilterNoToolEvents:
+			filters = append(filters, agent.NoToolEvents)
+		default:
+			return nil, fmt.Errorf("unknown event filter %q", name)
+		}
+	}
+	return agent.AllOf(filters...), nil
+}
+
 func decodeRequestBody(req *http.Request) (decodedReq models.RunAgentRequest, err error) {
 	var runAgentRequest models.RunAgentRequest
 	defer func() {
@@ -20,6 +20,7 @@ import (
 	"github.com/gorilla/mux"
 	"google.golang.org/adk/cmd/launcher"
 	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/server/adkrest/authz"
 	"google.golang.org/adk/server/adkrest/controllers"
 	"google.golang.org/adk/server/adkrest/internal/routers"
 	"google.golang.org/adk/server/adkrest/internal/services"
@@ -35,7 +36,7 @@ func NewHandler(config *launcher.Config) http.Handler {
 	router := mux.NewRouter().StrictSlash(true)
 	// TODO: Allow taking a prefix to allow customizing the path
 	// where the ADK REST API will be served.
-	setupRouter(router,
+	setupRouter(router, config.APIAuthorizer,
 		routers.NewSessionsAPIRouter(controllers.NewSessionsAPIController(config.SessionService)),
 		routers.NewRuntimeAPIRouter(controllers.NewRuntimeAPIRouter(config.SessionService, config.AgentLoader, config.ArtifactService)),
 		routers.NewAppsAPIRouter(controllers.NewAppsAPIController(config.AgentLoader)),
@@ -46,7 +47,7 @@ func NewHandler(config *launcher.Config) http.Handler {
 	return router
 }
 
-func setupRouter(router *mux.Router, subrouters ...routers.Router) *mux.Router {
-	routers.SetupSubRouters(router, subrouters...)
+func setupRouter(router *mux.Router, authorizer authz.Authorizer, subrouters ...routers.Router) *mux.Router {
+	routers.SetupSubRouters(router, authorizer, subrouters...)
 	return router
 }
@@ -24,26 +24,35 @@ import (
 
 // EventActions represent a data model for session.EventActions
 type EventActions struct {
-	StateDelta    map[string]any   `json:"stateDelta"`
-	ArtifactDelta map[string]int64 `json:"artifactDelta"`
+	StateDelta        map[string]any             `json:"stateDelta"`
+	ArtifactDelta     map[string]int64           `json:"artifactDelta"`
+	SkipSummarization bool                       `json:"skipSummarization,omitempty"`
+	InvocationSummary *session.InvocationSummary `json:"invocationSummary,omitempty"`
+	Failure           *session.Failure           `json:"failure,omitempty"`
 }
 
 // Event represents a single event in a session.
 type Event struct {
-	ID                 string                   `json:"id"`
-	Time               int64                    `json:"time"`
-	InvocationID       string                   `json:"invocationId"`
-	Branch             string                   `json:"branch"`
-	Author             string                   `json:"author"`
-	Partial            bool                     `json:"partial"`
-	LongRunningToolIDs []string                 `json:"longRunningToolIds"`
-	Content            *genai.Content           `json:"content"`
-	GroundingMetadata  *genai.GroundingMetadata `json:"groundingMetadata"`
-	TurnComplete       bool                     `json:"turnComplete"`
-	Interrupted        bool                     `json:"interrupted"`
-	ErrorCode          string                   `json:"errorCode"`
-	ErrorMessage       string                   `json:"errorMessage"`
-	Actions            EventActions             `json:"actions"`
+	ID                  string                                      `json:"id"`
+	Time                int64                                       `json:"time"`
+	InvocationID        string                                      `json:"invocationId"`
+	ParentInvocationID  string                                      `json:"parentInvocationId,omitempty"`
+	Branch              string                                      `json:"branch"`
+	Author              string                                      `json:"author"`
+	Partial             bool                                        `json:"partial"`
+	LongRunningToolIDs  []string                                    `json:"longRunningToolIds"`
+	Content             *genai.Content                              `json:"content"`
+	GroundingMetadata   *genai.GroundingMetadata                    `json:"groundingMetadata"`
+	UsageMetadata       *genai.GenerateContentResponseUsageMetadata `json:"usageMetadata,omitempty"`
+	TurnComplete        bool                                        `json:"turnComplete"`
+	Interrupted         bool                                        `json:"interrupted"`
+	ErrorCode           string                                      `json:"errorCode"`
+	ErrorMessage        string                                      `json:"errorMessage"`
+	Blocked             *model.SafetyBlock                          `json:"blocked,omitempty"`
+	Thought             bool                                        `json:"thought,omitempty"`
+	InputTranscription  *genai.Transcription                        `json:"inputTranscription,omitempty"`
+	OutputTranscription *genai.Transcription                        `json:"outputTranscription,omitempty"`
+	Actions             EventActions                                `json:"actions"`
 }
 
 // ToSessionEvent maps Event data struct to session.Event
@@ -52,21 +61,31 @@ func ToSessionEvent(event Event) *session.Event {
 		ID:                 event.ID,
 		Timestamp:          time.Unix(event.Time, 0),
 		InvocationID:       event.InvocationID,
+		ParentInvocationID: event.ParentInvocationID,
 		Branch:             event.Branch,
 		Author:             event.Author,
 		LongRunningToolIDs: event.LongRunningToolIDs,
 		LLMResponse: model.LLMResponse{
 			Content:           event.Content,
 			GroundingMetadata: event.GroundingMetadata,
+			UsageMetadata:     event.UsageMetadata,
 			Partial:           event.Partial,
 			TurnComplete:      event.TurnComplete,
 			Interrupted:       event.Interrupted,
 			ErrorCode:         event.ErrorCode,
 			ErrorMessage:      event.ErrorMessage,
+			Blocked:           event.Blocked,
+			Thought:           event.Thought,
+
+			InputTranscription:  event.InputTranscription,
+			OutputTranscription: event.OutputTranscription,
 		},
 		Actions: session.EventActions{
-			StateDelta:    event.Actions.StateDelta,
-			ArtifactDelta: event.Actions.ArtifactDelta,
+			StateDelta:        event.Actions.StateDelta,
+			ArtifactDelta:     event.Actions.ArtifactDelta,
+			SkipSummarization: event.Actions.SkipSummarization,
+			InvocationSummary: event.Actions.InvocationSummary,
+			Failure:           event.Actions.Failure,
 		},
 	}
 }
@@ -74,22 +93,31 @@ func ToSessionEvent(event Event) *session.Event {
 // FromSessionEvent maps session.Event to Event data struct
 func FromSessionEvent(event session.Event) Event {
 	return Event{
-		ID:                 event.ID,
-		Time:               event.Timestamp.Unix(),
-		InvocationID:       event.InvocationID,
-		Branch:             event.Branch,
-		Author:             event.Author,
-		Partial:            event.Partial,
-		LongRunningToolIDs: event.LongRunningToolIDs,
-		Content:            event.LLMResponse.Content,
-		GroundingMetadata:  event.LLMResponse.GroundingMetadata,
-		TurnComplete:       event.LLMResponse.TurnComplete,
-		Interrupted:        event.LLMResponse.Interrupted,
-		ErrorCode:          event.LLMResponse.ErrorCode,
-		ErrorMessage:       event.LLMResponse.ErrorMessage,
+		ID:                  event.ID,
+		Time:                event.Timestamp.Unix(),
+		InvocationID:        event.InvocationID,
+		ParentInvocationID:  event.ParentInvocationID,
+		Branch:              event.Branch,
+		Author:              event.Author,
+		Partial:             event.Partial,
+		LongRunningToolIDs:  event.LongRunningToolIDs,
+		Content:             event.LLMResponse.Content,
+		GroundingMetadata:   event.LLMResponse.GroundingMetadata,
+		UsageMetadata:       event.LLMResponse.UsageMetadata,
+		TurnComplete:        event.LLMResponse.TurnComplete,
+		Interrupted:         event.LLMResponse.Interrupted,
+		ErrorCode:           event.LLMResponse.ErrorCode,
+		ErrorMessage:        event.LLMResponse.ErrorMessage,
+		Blocked:             event.LLMResponse.Blocked,
+		Thought:             event.LLMResponse.Thought,
+		InputTranscription:  event.LLMResponse.InputTranscription,
+		OutputTranscription: event.LLMResponse.OutputTranscription,
 		Actions: EventActions{
-			StateDelta:    event.Actions.StateDelta,
-			ArtifactDelta: event.Actions.ArtifactDelta,
+			StateDelta:        event.Actions.StateDelta,
+			ArtifactDelta:     event.Actions.ArtifactDelta,
+			SkipSummarization: event.Actions.SkipSummarization,
+			InvocationSummary: event.Actions.InvocationSummary,
+			Failure:           event.Actions.Failure,
 		},
 	}
 }
@@ -32,8 +32,19 @@ type RunAgentRequest struct {
 	Streaming bool `json:"streaming,omitempty"`
 
 	StateDelta *map[string]any `json:"stateDelta,omitempty"`
+
+	// EventFilter selects the returned events, see the EventFilter* constants.
+	// An event is returned if it is accepted by all the filters.
+	EventFilter []string `json:"eventFilter,omitempty"`
 }
 
+// Values of RunAgentRequest.EventFilter.
+const (
+	EventFilterFinalOnly    = "finalOnly"
+	EventFilterNoPartials   = "noPartials"
+	EventFilterNoToolEvents = "noToolEvents"
+)
+
 // AssertRunAgentRequestRequired checks if the required fields are not zero-ed
 func (req RunAgentRequest) AssertRunAgentRequestRequired() error {
 	elements := map[string]any{
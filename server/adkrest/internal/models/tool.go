@@ -0,0 +1,52 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/runner"
+	"google.golang.org/genai"
+)
+
+// Tool describes a tool of the agent tree of an app.
+type Tool struct {
+	Agent       string                     `json:"agent"`
+	Toolset     string                     `json:"toolset,omitempty"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	Declaration *genai.FunctionDeclaration `json:"declaration,omitempty"`
+	LongRunning bool                       `json:"longRunning,omitempty"`
+	Auth        *auth.Config               `json:"auth,omitempty"`
+	// TimeoutSeconds is the timeout of a call of the tool, zero if none.
+	TimeoutSeconds float64 `json:"timeoutSeconds,omitempty"`
+	Reachable      bool    `json:"reachable"`
+	Unreachable    string  `json:"unreachable,omitempty"`
+}
+
+// FromToolInfo converts the description of a tool by the runner.
+func FromToolInfo(info runner.ToolInfo) Tool {
+	return Tool{
+		Agent:          info.Agent,
+		Toolset:        info.Toolset,
+		Name:           info.Name,
+		Description:    info.Description,
+		Declaration:    info.Declaration,
+		LongRunning:    info.LongRunning,
+		Auth:           info.Auth,
+		TimeoutSeconds: info.Timeout.Seconds(),
+		Reachable:      info.Reachable,
+		Unreachable:    info.Unreachable,
+	}
+}
@@ -17,6 +17,7 @@ package routers
 import (
 	"net/http"
 
+	"google.golang.org/adk/server/adkrest/authz"
 	"google.golang.org/adk/server/adkrest/controllers"
 )
 
@@ -39,6 +40,14 @@ func (r *AppsAPIRouter) Routes() Routes {
 			Methods:     []string{http.MethodGet},
 			Pattern:     "/list-apps",
 			HandlerFunc: r.appsController.ListAppsHandler,
+			Scope:       authz.ScopeUser,
+		},
+		Route{
+			Name:        "ListTools",
+			Methods:     []string{http.MethodGet},
+			Pattern:     "/apps/{app_name}/users/{user_id}/tools",
+			HandlerFunc: controllers.NewErrorHandler(r.appsController.ListToolsHandler),
+			Scope:       authz.ScopeUser,
 		},
 	}
 }
@@ -17,6 +17,7 @@ package routers
 import (
 	"net/http"
 
+	"google.golang.org/adk/server/adkrest/authz"
 	"google.golang.org/adk/server/adkrest/controllers"
 )
 
@@ -38,24 +39,28 @@ func (r *ArtifactsAPIRouter) Routes() Routes {
 			Methods:     []string{http.MethodGet},
 			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}/artifacts",
 			HandlerFunc: r.artifactsController.ListArtifactsHandler,
+			Scope:       authz.ScopeUser,
 		},
 		Route{
 			Name:        "LoadArtifact",
 			Methods:     []string{http.MethodGet},
 			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}/artifacts/{artifact_name}",
 			HandlerFunc: r.artifactsController.LoadArtifactHandler,
+			Scope:       authz.ScopeUser,
 		},
 		Route{
 			Name:        "LoadArtifact",
 			Methods:     []string{http.MethodGet},
 			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}/artifacts/{artifact_name}/versions/{version}",
 			HandlerFunc: r.artifactsController.LoadArtifactVersionHandler,
+			Scope:       authz.ScopeUser,
 		},
 		Route{
 			Name:        "DeleteArtifact",
 			Methods:     []string{http.MethodDelete, http.MethodOptions},
 			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}/artifacts/{artifact_name}",
 			HandlerFunc: r.artifactsController.DeleteArtifactHandler,
+			Scope:       authz.ScopeAdmin,
 		},
 	}
 }
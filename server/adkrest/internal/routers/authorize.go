@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/adk/server/adkrest/authz"
+)
+
+// authorize wraps the handler of the route with the authorization of its
+// operation. The CORS preflight requests aren't authorized.
+func authorize(authorizer authz.Authorizer, route Route, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		op, err := operation(route, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := authorizer.Authorize(r, op); err != nil {
+			switch {
+			case errors.Is(err, authz.ErrUnauthenticated):
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+			case errors.Is(err, authz.ErrPermissionDenied):
+				http.Error(w, err.Error(), http.StatusForbidden)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// operation returns the operation of the request. The app, user and session
+// are read from the path or, for the run requests, from the JSON body.
+func operation(route Route, r *http.Request) (authz.Operation, error) {
+	scope := route.Scope
+	if scope != authz.ScopeUser {
+		scope = authz.ScopeAdmin
+	}
+	vars := mux.Vars(r)
+	op := authz.Operation{
+		Name:      route.Name,
+		Scope:     scope,
+		AppName:   vars["app_name"],
+		UserID:    vars["user_id"],
+		SessionID: vars["session_id"],
+	}
+	if op.UserID != "" || r.Method != http.MethodPost || r.Body == nil {
+		return op, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return authz.Operation{}, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	var ids struct {
+		AppName   string `json:"appName"`
+		UserID    string `json:"userId"`
+		SessionID string `json:"sessionId"`
+	}
+	// The handler reports the malformed bodies.
+	if json.Unmarshal(body, &ids) == nil {
+		op.AppName, op.UserID, op.SessionID = ids.AppName, ids.UserID, ids.SessionID
+	}
+	return op, nil
+}
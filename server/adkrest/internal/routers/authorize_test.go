@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/mux"
+	"google.golang.org/adk/server/adkrest/authz"
+)
+
+type testRouter Routes
+
+func (r testRouter) Routes() Routes { return Routes(r) }
+
+func TestAuthorize(t *testing.T) {
+	var gotBody string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}
+	var gotOps []authz.Operation
+	authorizer := authz.AuthorizerFunc(func(r *http.Request, op authz.Operation) error {
+		gotOps = append(gotOps, op)
+		switch r.Header.Get("Authorization") {
+		case "":
+			return authz.ErrUnauthenticated
+		case "alice":
+			return authz.Principal{Subject: "alice"}.Allow(op)
+		case "fail":
+			return fmt.Errorf("backend unavailable")
+		}
+		return authz.Principal{Subject: "root", Admin: true}.Allow(op)
+	})
+
+	router := mux.NewRouter()
+	SetupSubRouters(router, authorizer, testRouter{
+		{Name: "GetSession", Methods: []string{http.MethodGet}, Pattern: "/apps/{app_name}/users/{user_id}/sessions/{session_id}", HandlerFunc: handler, Scope: authz.ScopeUser},
+		{Name: "DeleteSession", Methods: []string{http.MethodDelete, http.MethodOptions}, Pattern: "/apps/{app_name}/users/{user_id}/sessions/{session_id}", HandlerFunc: handler, Scope: authz.ScopeAdmin},
+		{Name: "RunAgent", Methods: []string{http.MethodPost}, Pattern: "/run", HandlerFunc: handler, Scope: authz.ScopeUser},
+		{Name: "Debug", Methods: []string{http.MethodGet}, Pattern: "/debug", HandlerFunc: handler},
+	})
+
+	runBody := `{"appName":"app","userId":"alice","sessionId":"s1"}`
+	tests := []struct {
+		name          string
+		method, path  string
+		body          string
+		authorization string
+		wantStatus    int
+	}{
+		{"own session", http.MethodGet, "/apps/app/users/alice/sessions/s1", "", "alice", http.StatusOK},
+		{"other user session", http.MethodGet, "/apps/app/users/bob/sessions/s1", "", "alice", http.StatusForbidden},
+		{"unauthenticated", http.MethodGet, "/apps/app/users/alice/sessions/s1", "", "", http.StatusUnauthorized},
+		{"user delete", http.MethodDelete, "/apps/app/users/alice/sessions/s1", "", "alice", http.StatusForbidden},
+		{"admin delete", http.MethodDelete, "/apps/app/users/alice/sessions/s1", "", "root", http.StatusOK},
+		{"preflight", http.MethodOptions, "/apps/app/users/alice/sessions/s1", "", "", http.StatusOK},
+		{"run", http.MethodPost, "/run", runBody, "alice", http.StatusOK},
+		{"route without scope", http.MethodGet, "/debug", "", "alice", http.StatusForbidden},
+		{"authorizer failure", http.MethodGet, "/debug", "", "fail", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBody = ""
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code == http.StatusOK && gotBody != tt.body {
+				t.Errorf("handler body = %q, want %q", gotBody, tt.body)
+			}
+		})
+	}
+
+	// The ids of the run request are read from the body.
+	want := authz.Operation{Name: "RunAgent", Scope: authz.ScopeUser, AppName: "app", UserID: "alice", SessionID: "s1"}
+	var got authz.Operation
+	for _, op := range gotOps {
+		if op.Name == "RunAgent" {
+			got = op
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RunAgent operation mismatch (-want +got):\n%s", diff)
+	}
+}
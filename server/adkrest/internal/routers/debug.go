@@ -17,6 +17,7 @@ package routers
 import (
 	"net/http"
 
+	"google.golang.org/adk/server/adkrest/authz"
 	"google.golang.org/adk/server/adkrest/controllers"
 )
 
@@ -39,18 +40,21 @@ func (r *DebugAPIRouter) Routes() Routes {
 			Methods:     []string{http.MethodGet},
 			Pattern:     "/debug/trace/{event_id}",
 			HandlerFunc: r.runtimeController.TraceDictHandler,
+			Scope:       authz.ScopeAdmin,
 		},
 		Route{
 			Name:        "GetEventGraph",
 			Methods:     []string{http.MethodGet},
 			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}/events/{event_id}/graph",
 			HandlerFunc: r.runtimeController.EventGraphHandler,
+			Scope:       authz.ScopeAdmin,
 		},
 		Route{
 			Name:        "GetSessionTrace",
 			Methods:     []string{http.MethodGet},
 			Pattern:     "/debug/trace/session/{session_id}",
 			HandlerFunc: controllers.Unimplemented,
+			Scope:       authz.ScopeAdmin,
 		},
 	}
 }
@@ -17,6 +17,7 @@ package routers
 import (
 	"net/http"
 
+	"google.golang.org/adk/server/adkrest/authz"
 	"google.golang.org/adk/server/adkrest/controllers"
 )
 
@@ -31,18 +32,21 @@ func (r *EvalAPIRouter) Routes() Routes {
 			Methods:     []string{http.MethodGet},
 			Pattern:     "/apps/{app_name}/eval_sets",
 			HandlerFunc: controllers.Unimplemented,
+			Scope:       authz.ScopeAdmin,
 		},
 		Route{
 			Name:        "ListEvalSets",
 			Methods:     []string{http.MethodPost, http.MethodOptions},
 			Pattern:     "/apps/{app_name}/eval_sets/{eval_set_name}",
 			HandlerFunc: controllers.Unimplemented,
+			Scope:       authz.ScopeAdmin,
 		},
 		Route{
 			Name:        "ListEvalResults",
 			Methods:     []string{http.MethodGet},
 			Pattern:     "/apps/{app_name}/eval_results",
 			HandlerFunc: controllers.Unimplemented,
+			Scope:       authz.ScopeAdmin,
 		},
 	}
 }
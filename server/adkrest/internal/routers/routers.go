@@ -19,6 +19,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"google.golang.org/adk/server/adkrest/authz"
 )
 
 // A Route defines the parameters for an api endpoint
@@ -27,6 +28,8 @@ type Route struct {
 	Methods     []string
 	Pattern     string
 	HandlerFunc http.HandlerFunc
+	// Scope of the route, routes without a user scope require admin access.
+	Scope authz.Scope
 }
 
 // Routes is a list of defined api endpoints
@@ -40,15 +43,18 @@ type Router interface {
 // NewRouter creates a new router for any number of api routers
 func NewRouter(routers ...Router) *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
-	SetupSubRouters(router)
+	SetupSubRouters(router, nil)
 	return router
 }
 
 // SetupSubRouters adds routes from subrouter to the naub router
-func SetupSubRouters(router *mux.Router, subrouters ...Router) {
+func SetupSubRouters(router *mux.Router, authorizer authz.Authorizer, subrouters ...Router) {
 	for _, api := range subrouters {
 		for _, route := range api.Routes() {
 			var handler http.Handler = route.HandlerFunc
+			if authorizer != nil {
+				handler = authorize(authorizer, route, handler)
+			}
 
 			router.
 				Methods(route.Methods...).
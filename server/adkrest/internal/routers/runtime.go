@@ -17,6 +17,7 @@ package routers
 import (
 	"net/http"
 
+	"google.golang.org/adk/server/adkrest/authz"
 	"google.golang.org/adk/server/adkrest/controllers"
 )
 
@@ -39,12 +40,14 @@ func (r *RuntimeAPIRouter) Routes() Routes {
 			Methods:     []string{http.MethodPost, http.MethodOptions},
 			Pattern:     "/run",
 			HandlerFunc: controllers.NewErrorHandler(r.runtimeController.RunHandler),
+			Scope:       authz.ScopeUser,
 		},
 		Route{
 			Name:        "RunAgentSse",
 			Methods:     []string{http.MethodPost, http.MethodOptions},
 			Pattern:     "/run_sse",
 			HandlerFunc: controllers.NewErrorHandler(r.runtimeController.RunSSEHandler),
+			Scope:       authz.ScopeUser,
 		},
 	}
 }
@@ -17,6 +17,7 @@ package routers
 import (
 	"net/http"
 
+	"google.golang.org/adk/server/adkrest/authz"
 	"google.golang.org/adk/server/adkrest/controllers"
 )
 
@@ -38,30 +39,35 @@ func (r *SessionsAPIRouter) Routes() Routes {
 			Methods:     []string{http.MethodGet},
 			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}",
 			HandlerFunc: r.sessionController.GetSessionHandler,
+			Scope:       authz.ScopeUser,
 		},
 		Route{
 			Name:        "CreateSession",
 			Methods:     []string{http.MethodPost},
 			Pattern:     "/apps/{app_name}/users/{user_id}/sessions",
 			HandlerFunc: r.sessionController.CreateSessionHandler,
+			Scope:       authz.ScopeUser,
 		},
 		Route{
 			Name:        "CreateSessionWithId",
 			Methods:     []string{http.MethodPost},
 			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}",
 			HandlerFunc: r.sessionController.CreateSessionHandler,
+			Scope:       authz.ScopeUser,
 		},
 		Route{
 			Name:        "DeleteSession",
 			Methods:     []string{http.MethodDelete, http.MethodOptions},
 			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}",
 			HandlerFunc: r.sessionController.DeleteSessionHandler,
+			Scope:       authz.ScopeAdmin,
 		},
 		Route{
 			Name:        "ListSessions",
 			Methods:     []string{http.MethodGet},
 			Pattern:     "/apps/{app_name}/users/{user_id}/sessions",
 			HandlerFunc: r.sessionController.ListSessionsHandler,
+			Scope:       authz.ScopeUser,
 		},
 	}
 }
@@ -0,0 +1,313 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package archive moves idle sessions from the session service to cold
+// storage, e.g. a GCS bucket, to reduce the cost of keeping stale sessions
+// in the hot backend.
+//
+// [Service] wraps the hot session service. [Service.ArchiveIdle] exports the
+// sessions idle beyond a threshold in the JSON export format (see [Export])
+// to the [Store], and deletes them from the hot service. When an archived
+// session is accessed with Get, it is transparently rehydrated into the hot
+// service and removed from the store.
+//
+// ArchiveIdle is typically run periodically, e.g. by a cron job:
+//
+//	svc := archive.New(archive.Config{
+//		Hot:   sessionService,
+//		Store: archive.GCSStore(client.Bucket("my-archive"), "sessions/"),
+//	})
+//	n, err := svc.ArchiveIdle(ctx, &archive.ArchiveRequest{AppName: "my_app", IdleFor: 30 * 24 * time.Hour})
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/session"
+)
+
+// Config is used to create a [Service].
+type Config struct {
+	// Hot is the session service holding the active sessions.
+	Hot session.Service
+	// Store holds the archived sessions.
+	Store Store
+}
+
+// Service is a [session.Service] which rehydrates the archived sessions on
+// access.
+type Service struct {
+	hot   session.Service
+	store Store
+}
+
+// New returns a session service archiving the sessions of cfg.Hot to
+// cfg.Store.
+func New(cfg Config) (*Service, error) {
+	if cfg.Hot == nil {
+		return nil, fmt.Errorf("hot session service is required")
+	}
+	if cfg.Store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	return &Service{hot: cfg.Hot, store: cfg.Store}, nil
+}
+
+// ArchiveRequest selects the sessions to archive.
+type ArchiveRequest struct {
+	AppName string
+	// UserID limits the archival to the sessions of the user.
+	// Optional: if empty, the sessions of all users are archived.
+	UserID string
+	// IdleFor is the minimum time since the last update of the sessions.
+	IdleFor time.Duration
+}
+
+// ArchiveIdle exports the sessions idle for longer than req.IdleFor to the
+// store, and deletes them from the hot service. It returns the number of
+// archived sessions.
+func (s *Service) ArchiveIdle(ctx context.Context, req *ArchiveRequest) (int, error) {
+	if req.IdleFor <= 0 {
+		return 0, fmt.Errorf("idle duration must be positive, got %v", req.IdleFor)
+	}
+	resp, err := s.hot.List(ctx, &session.ListRequest{AppName: req.AppName, UserID: req.UserID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	cutoff := time.Now().Add(-req.IdleFor)
+	archived := 0
+	for _, sess := range resp.Sessions {
+		if err := ctx.Err(); err != nil {
+			return archived, err
+		}
+		if !sess.LastUpdateTime().Before(cutoff) {
+			continue
+		}
+		if err := s.archive(ctx, sess.AppName(), sess.UserID(), sess.ID()); err != nil {
+			return archived, err
+		}
+		archived++
+	}
+	return archived, nil
+}
+
+func (s *Service) archive(ctx context.Context, appName, userID, sessionID string) error {
+	// List doesn't return the events, so the full session is read first.
+	resp, err := s.hot.Get(ctx, &session.GetRequest{AppName: appName, UserID: userID, SessionID: sessionID, Consistency: session.ConsistencyStrong})
+	if err != nil {
+		return fmt.Errorf("failed to get session %q: %w", sessionID, err)
+	}
+	data, err := Export(resp.Session)
+	if err != nil {
+		return err
+	}
+	// The session is deleted from the hot service only once it's safely
+	// stored.
+	if err := s.store.Put(ctx, objectName(appName, userID, sessionID), data); err != nil {
+		return fmt.Errorf("failed to archive session %q: %w", sessionID, err)
+	}
+	if err := s.hot.Delete(ctx, &session.DeleteRequest{AppName: appName, UserID: userID, SessionID: sessionID}); err != nil {
+		return fmt.Errorf("failed to delete archived session %q: %w", sessionID, err)
+	}
+	return nil
+}
+
+// Create creates a session in the hot service. It fails if the session ID
+// is used by an archived session.
+func (s *Service) Create(ctx context.Context, req *session.CreateRequest) (*session.CreateResponse, error) {
+	if req.SessionID != "" {
+		_, err := s.store.Get(ctx, objectName(req.AppName, req.UserID, req.SessionID))
+		if err == nil {
+			return nil, fmt.Errorf("session %s already exists in the archive", req.SessionID)
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+	return s.hot.Create(ctx, req)
+}
+
+// Get returns the session from the hot service. If it isn't there, but is
+// archived, it's rehydrated into the hot service first.
+//
+// The rehydrated events keep their timestamps, so the rehydrated session ends
+// with an event without content, of the author "archive", stamped with the
+// time of the rehydration: the session isn't idle, or expired, right away.
+func (s *Service) Get(ctx context.Context, req *session.GetRequest) (*session.GetResponse, error) {
+	resp, hotErr := s.hot.Get(ctx, req)
+	if hotErr == nil || !errors.Is(hotErr, session.ErrSessionNotFound) {
+		return resp, hotErr
+	}
+	name := objectName(req.AppName, req.UserID, req.SessionID)
+	data, err := s.store.Get(ctx, name)
+	if errors.Is(err, ErrNotFound) {
+		return nil, hotErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rehydrate(ctx, data); err != nil {
+		return nil, err
+	}
+	if err := s.store.Delete(ctx, name); err != nil {
+		return nil, err
+	}
+	return s.hot.Get(ctx, req)
+}
+
+// rehydrate recreates the exported session in the hot service.
+func (s *Service) rehydrate(ctx context.Context, data []byte) error {
+	exported, err := Import(data)
+	if err != nil {
+		return err
+	}
+	created, err := s.hot.Create(ctx, &session.CreateRequest{
+		AppName:   exported.AppName,
+		UserID:    exported.UserID,
+		SessionID: exported.ID,
+		State:     exported.State,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rehydrate session %q: %w", exported.ID, err)
+	}
+	for _, ev := range exported.SessionEvents() {
+		// The app and user state may have changed since the session was
+		// archived, so the stale deltas aren't applied again.
+		for k := range ev.Actions.StateDelta {
+			if strings.HasPrefix(k, session.KeyPrefixApp) || strings.HasPrefix(k, session.KeyPrefixUser) {
+				delete(ev.Actions.StateDelta, k)
+			}
+		}
+		if err := s.hot.AppendEvent(ctx, created.Session, ev); err != nil {
+			return fmt.Errorf("failed to rehydrate session %q: %w", exported.ID, err)
+		}
+	}
+	rehydrated := session.NewEvent("")
+	rehydrated.Author = rehydrationAuthor
+	if err := s.hot.AppendEvent(ctx, created.Session, rehydrated); err != nil {
+		return fmt.Errorf("failed to rehydrate session %q: %w", exported.ID, err)
+	}
+	return nil
+}
+
+// rehydrationAuthor is the author of the event stamping the rehydrated
+// sessions with the time of their rehydration.
+const rehydrationAuthor = "archive"
+
+// List returns the sessions of the hot service followed by the archived
+// sessions, which aren't rehydrated.
+func (s *Service) List(ctx context.Context, req *session.ListRequest) (*session.ListResponse, error) {
+	resp, err := s.hot.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	prefix := url.PathEscape(req.AppName) + "/"
+	if req.UserID != "" {
+		prefix += url.PathEscape(req.UserID) + "/"
+	}
+	names, err := s.store.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+	sessions := slices.Clone(resp.Sessions)
+	for _, name := range names {
+		data, err := s.store.Get(ctx, name)
+		if errors.Is(err, ErrNotFound) {
+			// Rehydrated concurrently.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		exported, err := Import(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		sessions = append(sessions, &archivedSession{exported: exported})
+	}
+	return &session.ListResponse{Sessions: sessions}, nil
+}
+
+// Delete deletes the session from the hot service and from the store.
+func (s *Service) Delete(ctx context.Context, req *session.DeleteRequest) error {
+	if err := s.hot.Delete(ctx, req); err != nil {
+		return err
+	}
+	return s.store.Delete(ctx, objectName(req.AppName, req.UserID, req.SessionID))
+}
+
+// AppendEvent appends the event to the session in the hot service.
+func (s *Service) AppendEvent(ctx context.Context, sess session.Session, ev *session.Event) error {
+	return s.hot.AppendEvent(ctx, sess, ev)
+}
+
+// objectName returns the name of the archived session in the store.
+func objectName(appName, userID, sessionID string) string {
+	return url.PathEscape(appName) + "/" + url.PathEscape(userID) + "/" + url.PathEscape(sessionID) + ".json"
+}
+
+// archivedSession is a read-only view of an archived session, returned by
+// List. Its events aren't loaded.
+type archivedSession struct {
+	exported *Exported
+}
+
+func (s *archivedSession) ID() string                { return s.exported.ID }
+func (s *archivedSession) AppName() string           { return s.exported.AppName }
+func (s *archivedSession) UserID() string            { return s.exported.UserID }
+func (s *archivedSession) LastUpdateTime() time.Time { return s.exported.LastUpdateTime }
+func (s *archivedSession) Events() session.Events    { return noEvents{} }
+func (s *archivedSession) State() session.State {
+	return archivedState(s.exported.State)
+}
+
+type archivedState map[string]any
+
+func (s archivedState) Get(key string) (any, error) {
+	v, ok := s[key]
+	if !ok {
+		return nil, session.ErrStateKeyNotExist
+	}
+	return v, nil
+}
+
+func (s archivedState) Set(string, any) error {
+	return fmt.Errorf("archived session is read-only")
+}
+
+func (s archivedState) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for k, v := range s {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+type noEvents struct{}
+
+func (noEvents) All() iter.Seq[*session.Event] { return func(func(*session.Event) bool) {} }
+func (noEvents) Len() int                      { return 0 }
+func (noEvents) At(int) *session.Event         { return nil }
+
+var _ session.Service = (*Service)(nil)
@@ -0,0 +1,258 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+func createSession(t *testing.T, svc session.Service, id string, updated time.Time, events ...*session.Event) {
+	t.Helper()
+	ctx := t.Context()
+	resp, err := svc.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: id})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for _, ev := range events {
+		if err := svc.AppendEvent(ctx, resp.Session, ev); err != nil {
+			t.Fatalf("AppendEvent() error = %v", err)
+		}
+	}
+	// The last update time is the timestamp of the last event.
+	last := &session.Event{ID: id + "-last", Timestamp: updated, Author: "user", Actions: session.EventActions{StateDelta: map[string]any{}}}
+	if err := svc.AppendEvent(ctx, resp.Session, last); err != nil {
+		t.Fatalf("AppendEvent() error = %v", err)
+	}
+}
+
+func TestService_ArchiveAndRehydrate(t *testing.T) {
+	ctx := t.Context()
+	hot := session.InMemoryService()
+	store := DirStore(t.TempDir())
+	svc, err := New(Config{Hot: hot, Store: store})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour).Round(0)
+	createSession(t, hot, "old", old,
+		&session.Event{
+			ID:           "e1",
+			Timestamp:    old,
+			InvocationID: "inv1",
+			Author:       "user",
+			LLMResponse:  model.LLMResponse{Content: genai.NewContentFromText("hi", genai.RoleUser)},
+			Actions:      session.EventActions{StateDelta: map[string]any{"k": "v", "user:name": "old name"}},
+		},
+		&session.Event{
+			ID:           "e2",
+			Timestamp:    old,
+			InvocationID: "inv1",
+			Author:       "agent",
+			LLMResponse: model.LLMResponse{
+				Content:       genai.NewContentFromText("hello", genai.RoleModel),
+				UsageMetadata: &genai.GenerateContentResponseUsageMetadata{TotalTokenCount: 3},
+			},
+			Actions: session.EventActions{StateDelta: map[string]any{}, TransferToAgent: "other"},
+		},
+	)
+	createSession(t, hot, "recent", time.Now())
+
+	before, err := hot.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "old"})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	n, err := svc.ArchiveIdle(ctx, &ArchiveRequest{AppName: "app", IdleFor: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("ArchiveIdle() error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("ArchiveIdle() = %d, want 1", n)
+	}
+	if _, err := hot.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "old"}); err == nil {
+		t.Error("archived session is still in the hot service")
+	}
+
+	list, err := svc.List(ctx, &session.ListRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var ids []string
+	for _, s := range list.Sessions {
+		ids = append(ids, s.ID())
+	}
+	slices.Sort(ids)
+	if diff := cmp.Diff([]string{"old", "recent"}, ids); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := svc.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "old"}); err == nil {
+		t.Error("Create() with the ID of an archived session succeeded")
+	}
+
+	// The user state changed after the session was archived.
+	if _, err := hot.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", State: map[string]any{"user:name": "new name"}}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	after, err := svc.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "old"})
+	if err != nil {
+		t.Fatalf("Get() of archived session error = %v", err)
+	}
+	// The stale user state delta isn't rehydrated.
+	wantEvents := slices.Collect(before.Session.Events().All())
+	delete(wantEvents[0].Actions.StateDelta, "user:name")
+	gotEvents := slices.Collect(after.Session.Events().All())
+	if len(gotEvents) == 0 {
+		t.Fatal("rehydrated session has no events")
+	}
+	last := gotEvents[len(gotEvents)-1]
+	if diff := cmp.Diff(wantEvents, gotEvents[:len(gotEvents)-1]); diff != "" {
+		t.Errorf("rehydrated events mismatch (-want +got):\n%s", diff)
+	}
+	// The rehydrated session is stamped with the time of the rehydration.
+	if last.Author != rehydrationAuthor || last.Content != nil || time.Since(last.Timestamp) > time.Minute {
+		t.Errorf("last rehydrated event = %+v, want the rehydration event", last)
+	}
+	if n, err := svc.ArchiveIdle(ctx, &ArchiveRequest{AppName: "app", IdleFor: 24 * time.Hour}); err != nil || n != 0 {
+		t.Errorf("ArchiveIdle() after the rehydration = %d, %v, want 0", n, err)
+	}
+	wantState := map[string]any{"k": "v", "user:name": "new name"}
+	if diff := cmp.Diff(wantState, maps.Collect(after.Session.State().All())); diff != "" {
+		t.Errorf("rehydrated state mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := store.Get(ctx, objectName("app", "user", "old")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("store.Get() after rehydration error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestService_GetUnknownSession(t *testing.T) {
+	svc, err := New(Config{Hot: session.InMemoryService(), Store: DirStore(t.TempDir())})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := svc.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "missing"}); err == nil {
+		t.Error("Get() of unknown session succeeded")
+	}
+}
+
+// failingService fails the calls to Get.
+type failingService struct {
+	session.Service
+}
+
+func (failingService) Get(context.Context, *session.GetRequest) (*session.GetResponse, error) {
+	return nil, errors.New("backend unavailable")
+}
+
+func TestService_GetHotError(t *testing.T) {
+	store := DirStore(t.TempDir())
+	if err := store.Put(t.Context(), objectName("app", "user", "s"), []byte(`{"version": 1, "id": "s", "appName": "app", "userId": "user"}`)); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := New(Config{Hot: failingService{session.InMemoryService()}, Store: store})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := svc.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s"}); err == nil || errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("Get() error = %v, want the error of the hot service", err)
+	}
+	if _, err := store.Get(t.Context(), objectName("app", "user", "s")); err != nil {
+		t.Errorf("store.Get() error = %v, want the session to stay archived", err)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	hot := session.InMemoryService()
+	ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	createSession(t, hot, "s", ts, &session.Event{
+		ID:        "e1",
+		Timestamp: ts,
+		Author:    "agent",
+		Actions:   session.EventActions{StateDelta: map[string]any{"k": "v", "app:x": 1.0}, Escalate: true},
+	}, &session.Event{
+		ID:        "e2",
+		Timestamp: ts,
+		Author:    "agent",
+		LLMResponse: model.LLMResponse{
+			Blocked: &model.SafetyBlock{Source: model.BlockedResponse, Reason: "SAFETY", Categories: []genai.HarmCategory{genai.HarmCategoryHarassment}},
+			Thought: true,
+		},
+		Actions: session.EventActions{StateDelta: map[string]any{}},
+	})
+	resp, err := hot.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s"})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	data, err := Export(resp.Session)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	got, err := Import(data)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if got.ID != "s" || got.AppName != "app" || got.UserID != "user" || !got.LastUpdateTime.Equal(ts) {
+		t.Errorf("Import() = %+v", got)
+	}
+	if diff := cmp.Diff(map[string]any{"k": "v"}, got.State); diff != "" {
+		t.Errorf("Import() state mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(slices.Collect(resp.Session.Events().All()), got.SessionEvents()); diff != "" {
+		t.Errorf("Import() events mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := Import([]byte(`{"version": 2, "id": "s", "appName": "app", "userId": "user"}`)); err == nil {
+		t.Error("Import() of unsupported version succeeded")
+	}
+}
+
+func TestDirStore(t *testing.T) {
+	ctx := context.Background()
+	store := DirStore(t.TempDir())
+	if err := store.Put(ctx, "a/b/c.json", []byte("data")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if got, err := store.Get(ctx, "a/b/c.json"); err != nil || string(got) != "data" {
+		t.Errorf("Get() = (%q, %v), want (\"data\", nil)", got, err)
+	}
+	if names, err := store.List(ctx, "a/"); err != nil || !slices.Equal(names, []string{"a/b/c.json"}) {
+		t.Errorf("List() = (%v, %v)", names, err)
+	}
+	if err := store.Put(ctx, "../escape.json", nil); err == nil {
+		t.Error("Put() outside of the directory succeeded")
+	}
+	if err := store.Delete(ctx, "a/b/c.json"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get(ctx, "a/b/c.json"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want %v", err, ErrNotFound)
+	}
+	if err := store.Delete(ctx, "a/b/c.json"); err != nil {
+		t.Errorf("Delete() of missing object error = %v", err)
+	}
+}
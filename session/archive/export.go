@@ -0,0 +1,230 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// FormatVersion is the version of the export format written by [Export].
+const FormatVersion = 1
+
+// Exported is the JSON export format of a session. It uses the field names
+// of the REST API, and keeps all the fields of the events.
+type Exported struct {
+	Version        int             `json:"version"`
+	ID             string          `json:"id"`
+	AppName        string          `json:"appName"`
+	UserID         string          `json:"userId"`
+	LastUpdateTime time.Time       `json:"lastUpdateTime"`
+	State          map[string]any  `json:"state"`
+	Events         []ExportedEvent `json:"events"`
+}
+
+// ExportedEvent is the JSON export format of a [session.Event].
+type ExportedEvent struct {
+	ID                  string                                      `json:"id"`
+	Timestamp           time.Time                                   `json:"timestamp"`
+	InvocationID        string                                      `json:"invocationId"`
+	ParentInvocationID  string                                      `json:"parentInvocationId,omitempty"`
+	Branch              string                                      `json:"branch,omitempty"`
+	Author              string                                      `json:"author"`
+	LongRunningToolIDs  []string                                    `json:"longRunningToolIds,omitempty"`
+	Content             *genai.Content                              `json:"content,omitempty"`
+	CitationMetadata    *genai.CitationMetadata                     `json:"citationMetadata,omitempty"`
+	GroundingMetadata   *genai.GroundingMetadata                    `json:"groundingMetadata,omitempty"`
+	UsageMetadata       *genai.GenerateContentResponseUsageMetadata `json:"usageMetadata,omitempty"`
+	CustomMetadata      map[string]any                              `json:"customMetadata,omitempty"`
+	LogprobsResult      *genai.LogprobsResult                       `json:"logprobsResult,omitempty"`
+	TurnComplete        bool                                        `json:"turnComplete,omitempty"`
+	Interrupted         bool                                        `json:"interrupted,omitempty"`
+	Thought             bool                                        `json:"thought,omitempty"`
+	ErrorCode           string                                      `json:"errorCode,omitempty"`
+	ErrorMessage        string                                      `json:"errorMessage,omitempty"`
+	Blocked             *model.SafetyBlock                          `json:"blocked,omitempty"`
+	FinishReason        genai.FinishReason                          `json:"finishReason,omitempty"`
+	AvgLogprobs         float64                                     `json:"avgLogprobs,omitempty"`
+	InputTranscription  *genai.Transcription                        `json:"inputTranscription,omitempty"`
+	OutputTranscription *genai.Transcription                        `json:"outputTranscription,omitempty"`
+	Actions             ExportedEventActions                        `json:"actions"`
+}
+
+// ExportedEventActions is the JSON export format of a
+// [session.EventActions].
+type ExportedEventActions struct {
+	StateDelta           map[string]any             `json:"stateDelta,omitempty"`
+	ArtifactDelta        map[string]int64           `json:"artifactDelta,omitempty"`
+	SkipSummarization    bool                       `json:"skipSummarization,omitempty"`
+	TransferToAgent      string                     `json:"transferToAgent,omitempty"`
+	Escalate             bool                       `json:"escalate,omitempty"`
+	HandoffSummaryFor    string                     `json:"handoffSummaryFor,omitempty"`
+	Compaction           *session.Compaction        `json:"compaction,omitempty"`
+	InvocationSummary    *session.InvocationSummary `json:"invocationSummary,omitempty"`
+	EndOfInvocation      bool                       `json:"endOfInvocation,omitempty"`
+	RequestedAuthConfigs map[string]*auth.Config    `json:"requestedAuthConfigs,omitempty"`
+}
+
+// Export encodes the session in the JSON export format. The app and user
+// state, which are shared with other sessions, aren't exported.
+func Export(s session.Session) ([]byte, error) {
+	exported := Exported{
+		Version:        FormatVersion,
+		ID:             s.ID(),
+		AppName:        s.AppName(),
+		UserID:         s.UserID(),
+		LastUpdateTime: s.LastUpdateTime(),
+		State:          sessionState(s.State().All()),
+		Events:         []ExportedEvent{},
+	}
+	for ev := range s.Events().All() {
+		exported.Events = append(exported.Events, exportEvent(ev))
+	}
+	data, err := json.Marshal(exported)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode session %q: %w", s.ID(), err)
+	}
+	return data, nil
+}
+
+// Import decodes a session exported with [Export].
+func Import(data []byte) (*Exported, error) {
+	var exported Exported
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	if exported.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported session export format version %d", exported.Version)
+	}
+	if exported.AppName == "" || exported.UserID == "" || exported.ID == "" {
+		return nil, fmt.Errorf("exported session has no app name, user ID or session ID")
+	}
+	return &exported, nil
+}
+
+// SessionEvents returns the events of the exported session.
+func (e *Exported) SessionEvents() []*session.Event {
+	events := make([]*session.Event, 0, len(e.Events))
+	for _, ev := range e.Events {
+		events = append(events, ev.toSessionEvent())
+	}
+	return events
+}
+
+// sessionState returns the state without the app, user and temp keys.
+func sessionState(all iter.Seq2[string, any]) map[string]any {
+	state := make(map[string]any)
+	for k, v := range all {
+		if strings.HasPrefix(k, session.KeyPrefixApp) || strings.HasPrefix(k, session.KeyPrefixUser) || strings.HasPrefix(k, session.KeyPrefixTemp) {
+			continue
+		}
+		state[k] = v
+	}
+	return state
+}
+
+func exportEvent(ev *session.Event) ExportedEvent {
+	return ExportedEvent{
+		ID:                  ev.ID,
+		Timestamp:           ev.Timestamp,
+		InvocationID:        ev.InvocationID,
+		ParentInvocationID:  ev.ParentInvocationID,
+		Branch:              ev.Branch,
+		Author:              ev.Author,
+		LongRunningToolIDs:  ev.LongRunningToolIDs,
+		Content:             ev.Content,
+		CitationMetadata:    ev.CitationMetadata,
+		GroundingMetadata:   ev.GroundingMetadata,
+		UsageMetadata:       ev.UsageMetadata,
+		CustomMetadata:      ev.CustomMetadata,
+		LogprobsResult:      ev.LogprobsResult,
+		TurnComplete:        ev.TurnComplete,
+		Interrupted:         ev.Interrupted,
+		Thought:             ev.Thought,
+		ErrorCode:           ev.ErrorCode,
+		ErrorMessage:        ev.ErrorMessage,
+		Blocked:             ev.Blocked,
+		FinishReason:        ev.FinishReason,
+		AvgLogprobs:         ev.AvgLogprobs,
+		InputTranscription:  ev.InputTranscription,
+		OutputTranscription: ev.OutputTranscription,
+		Actions: ExportedEventActions{
+			StateDelta:           ev.Actions.StateDelta,
+			ArtifactDelta:        ev.Actions.ArtifactDelta,
+			SkipSummarization:    ev.Actions.SkipSummarization,
+			TransferToAgent:      ev.Actions.TransferToAgent,
+			Escalate:             ev.Actions.Escalate,
+			HandoffSummaryFor:    ev.Actions.HandoffSummaryFor,
+			Compaction:           ev.Actions.Compaction,
+			InvocationSummary:    ev.Actions.InvocationSummary,
+			EndOfInvocation:      ev.Actions.EndOfInvocation,
+			RequestedAuthConfigs: ev.Actions.RequestedAuthConfigs,
+		},
+	}
+}
+
+func (ev ExportedEvent) toSessionEvent() *session.Event {
+	stateDelta := make(map[string]any)
+	maps.Copy(stateDelta, ev.Actions.StateDelta)
+	return &session.Event{
+		LLMResponse: model.LLMResponse{
+			Content:           ev.Content,
+			CitationMetadata:  ev.CitationMetadata,
+			GroundingMetadata: ev.GroundingMetadata,
+			UsageMetadata:     ev.UsageMetadata,
+			CustomMetadata:    ev.CustomMetadata,
+			LogprobsResult:    ev.LogprobsResult,
+			TurnComplete:      ev.TurnComplete,
+			Interrupted:       ev.Interrupted,
+			Thought:           ev.Thought,
+			ErrorCode:         ev.ErrorCode,
+			ErrorMessage:      ev.ErrorMessage,
+			Blocked:           ev.Blocked,
+			FinishReason:      ev.FinishReason,
+			AvgLogprobs:       ev.AvgLogprobs,
+
+			InputTranscription:  ev.InputTranscription,
+			OutputTranscription: ev.OutputTranscription,
+		},
+		ID:                 ev.ID,
+		Timestamp:          ev.Timestamp,
+		InvocationID:       ev.InvocationID,
+		ParentInvocationID: ev.ParentInvocationID,
+		Branch:             ev.Branch,
+		Author:             ev.Author,
+		LongRunningToolIDs: ev.LongRunningToolIDs,
+		Actions: session.EventActions{
+			StateDelta:           stateDelta,
+			ArtifactDelta:        ev.Actions.ArtifactDelta,
+			SkipSummarization:    ev.Actions.SkipSummarization,
+			TransferToAgent:      ev.Actions.TransferToAgent,
+			Escalate:             ev.Actions.Escalate,
+			HandoffSummaryFor:    ev.Actions.HandoffSummaryFor,
+			Compaction:           ev.Actions.Compaction,
+			InvocationSummary:    ev.Actions.InvocationSummary,
+			EndOfInvocation:      ev.Actions.EndOfInvocation,
+			RequestedAuthConfigs: ev.Actions.RequestedAuthConfigs,
+		},
+	}
+}
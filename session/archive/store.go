@@ -0,0 +1,215 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// ErrNotFound is returned by [Store.Get] when there's no object with the
+// given name.
+var ErrNotFound = errors.New("archived session not found")
+
+// Store is the cold storage of the archived sessions, e.g. a GCS or S3
+// bucket. The object names are slash-separated paths.
+type Store interface {
+	// Put writes the object, replacing the existing one.
+	Put(ctx context.Context, name string, data []byte) error
+	// Get reads the object. It returns [ErrNotFound] if it doesn't exist.
+	Get(ctx context.Context, name string) ([]byte, error)
+	// Delete deletes the object. It doesn't fail if it doesn't exist.
+	Delete(ctx context.Context, name string) error
+	// List returns the names of the objects starting with the prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// DirStore returns a [Store] keeping the objects as files in the directory,
+// e.g. a mounted bucket or a network file system.
+func DirStore(dir string) Store {
+	return &dirStore{dir: dir}
+}
+
+type dirStore struct {
+	dir string
+}
+
+func (s *dirStore) path(name string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", fmt.Errorf("invalid object name %q", name)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(name)), nil
+}
+
+func (s *dirStore) Put(ctx context.Context, name string, data []byte) error {
+	p, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	// Write to a temporary file first, so that a failed write doesn't leave a
+	// truncated object.
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %q: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %q: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("failed to write %q: %w", name, err)
+	}
+	return nil
+}
+
+func (s *dirStore) Get(ctx context.Context, name string) ([]byte, error) {
+	p, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", name, err)
+	}
+	return data, nil
+}
+
+func (s *dirStore) Delete(ctx context.Context, name string) error {
+	p, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %q: %w", name, err)
+	}
+	return nil
+}
+
+func (s *dirStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && p == s.dir {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived sessions: %w", err)
+	}
+	return names, nil
+}
+
+// GCSStore returns a [Store] keeping the objects in the GCS bucket, under
+// the prefix, e.g. "sessions/". Use a bucket with a cold storage class, e.g.
+// Coldline or Archive.
+func GCSStore(bucket *storage.BucketHandle, prefix string) Store {
+	return &gcsStore{bucket: bucket, prefix: prefix}
+}
+
+type gcsStore struct {
+	bucket *storage.BucketHandle
+	prefix string
+}
+
+func (s *gcsStore) Put(ctx context.Context, name string, data []byte) error {
+	w := s.bucket.Object(path.Join(s.prefix, name)).NewWriter(ctx)
+	w.ContentType = "application/json"
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write %q: %w", name, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write %q: %w", name, err)
+	}
+	return nil
+}
+
+func (s *gcsStore) Get(ctx context.Context, name string) ([]byte, error) {
+	r, err := s.bucket.Object(path.Join(s.prefix, name)).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", name, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", name, err)
+	}
+	return data, nil
+}
+
+func (s *gcsStore) Delete(ctx context.Context, name string) error {
+	err := s.bucket.Object(path.Join(s.prefix, name)).Delete(ctx)
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete %q: %w", name, err)
+	}
+	return nil
+}
+
+func (s *gcsStore) List(ctx context.Context, prefix string) ([]string, error) {
+	full := path.Join(s.prefix, prefix)
+	if strings.HasSuffix(prefix, "/") {
+		full += "/"
+	}
+	var names []string
+	it := s.bucket.Objects(ctx, &storage.Query{Prefix: full})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list archived sessions: %w", err)
+		}
+		name := strings.TrimPrefix(attrs.Name, s.prefix)
+		names = append(names, strings.TrimPrefix(name, "/"))
+	}
+	return names, nil
+}
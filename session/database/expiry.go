@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/adk/session"
+	"gorm.io/gorm"
+)
+
+// SetExpiryPolicy implements session.Expirer. The policies are not stored
+// in the database, so every service using the database must set them.
+func (s *databaseService) SetExpiryPolicy(appName string, policy session.ExpiryPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.policies == nil {
+		s.policies = make(map[string]session.ExpiryPolicy)
+	}
+	s.policies[appName] = policy
+}
+
+// ExpireBefore implements session.Expirer. The expired sessions are deleted
+// with their events.
+func (s *databaseService) ExpireBefore(ctx context.Context, req *session.ExpireBeforeRequest) (*session.ExpireBeforeResponse, error) {
+	if req.AppName == "" {
+		return nil, fmt.Errorf("app_name is required, got app_name: %q", req.AppName)
+	}
+	var deleted int
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		deleted, err = s.deleteExpired(tx, &storageSession{AppName: req.AppName}, req.Before)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error expiring sessions: %w", err)
+	}
+	return &session.ExpireBeforeResponse{Deleted: deleted}, nil
+}
+
+// policy returns the expiry policy of the app.
+func (s *databaseService) policy(appName string) session.ExpiryPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.policies[appName]
+}
+
+// expired reports whether the stored session is expired at now.
+func (s *databaseService) expired(sess *storageSession, now time.Time) bool {
+	return s.policy(sess.AppName).Expired(sess.CreateTime, sess.UpdateTime, now)
+}
+
+// deleteExpired deletes the sessions matching where which are expired at
+// now, with their events, and returns their number. where.AppName must be
+// set.
+func (s *databaseService) deleteExpired(tx *gorm.DB, where *storageSession, now time.Time) (int, error) {
+	policy := s.policy(where.AppName)
+	if policy.TTL <= 0 {
+		return 0, nil
+	}
+	column := "update_time"
+	if policy.Mode == session.ExpiryAbsolute {
+		column = "create_time"
+	}
+	var expired []storageSession
+	if err := tx.Select("app_name", "user_id", "id").
+		Where(where).
+		Where(column+" <= ?", now.Add(-policy.TTL)).
+		Find(&expired).Error; err != nil {
+		return 0, fmt.Errorf("failed to find the expired sessions: %w", err)
+	}
+	for _, e := range expired {
+		if err := tx.Where("app_name = ? AND user_id = ? AND session_id = ?", e.AppName, e.UserID, e.ID).Delete(&storageEvent{}).Error; err != nil {
+			return 0, fmt.Errorf("failed to delete the events of session %q: %w", e.ID, err)
+		}
+		if err := tx.Where(&storageSession{AppName: e.AppName, UserID: e.UserID, ID: e.ID}).Delete(&storageSession{}).Error; err != nil {
+			return 0, fmt.Errorf("failed to delete session %q: %w", e.ID, err)
+		}
+	}
+	return len(expired), nil
+}
+
+var _ session.Expirer = (*databaseService)(nil)
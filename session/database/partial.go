@@ -0,0 +1,125 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/adk/session"
+	"gorm.io/gorm"
+)
+
+// storagePartialEvent is a partial event, kept as JSON since the partial
+// events are only read back for audits.
+type storagePartialEvent struct {
+	Seq          uint64 `gorm:"primaryKey;autoIncrement"`
+	AppName      string `gorm:"index:idx_partial_events_session"`
+	UserID       string `gorm:"index:idx_partial_events_session"`
+	SessionID    string `gorm:"index:idx_partial_events_session"`
+	InvocationID string
+	Timestamp    time.Time `gorm:"index"`
+	Event        dynamicJSON
+}
+
+// TableName explicitly sets the table name for the storagePartialEvent struct.
+func (storagePartialEvent) TableName() string {
+	return "partial_events"
+}
+
+type partialEventStore struct {
+	db *gorm.DB
+}
+
+// NewPartialEventStore creates a [session.PartialEventStore] storing the
+// partial events in the "partial_events" table of a relational database via
+// the GORM library. The table is created by [AutoMigratePartialEventStore].
+func NewPartialEventStore(dialector gorm.Dialector, opts ...gorm.Option) (session.PartialEventStore, error) {
+	db, err := gorm.Open(dialector, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating database partial event store: %w", err)
+	}
+	return &partialEventStore{db: db}, nil
+}
+
+// AutoMigratePartialEventStore runs the GORM auto-migration tool for the
+// table of a store created by [NewPartialEventStore].
+func AutoMigratePartialEventStore(store session.PartialEventStore) error {
+	s, ok := store.(*partialEventStore)
+	if !ok {
+		return fmt.Errorf("invalid partial event store type")
+	}
+	if err := s.db.AutoMigrate(&storagePartialEvent{}); err != nil {
+		return fmt.Errorf("auto migrate failed: %w", err)
+	}
+	return nil
+}
+
+func (s *partialEventStore) AppendPartial(ctx context.Context, sess session.Session, event *session.Event) error {
+	if sess == nil || event == nil {
+		return fmt.Errorf("session and event are required")
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal partial event: %w", err)
+	}
+	row := &storagePartialEvent{
+		AppName:      sess.AppName(),
+		UserID:       sess.UserID(),
+		SessionID:    sess.ID(),
+		InvocationID: event.InvocationID,
+		Timestamp:    event.Timestamp,
+		Event:        dynamicJSON(data),
+	}
+	if err := s.db.WithContext(ctx).Create(row).Error; err != nil {
+		return fmt.Errorf("database error while inserting partial event: %w", err)
+	}
+	return nil
+}
+
+func (s *partialEventStore) ListPartials(ctx context.Context, req *session.ListPartialsRequest) (*session.ListPartialsResponse, error) {
+	if req.AppName == "" || req.UserID == "" || req.SessionID == "" {
+		return nil, fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", req.AppName, req.UserID, req.SessionID)
+	}
+	query := s.db.WithContext(ctx).Where(&storagePartialEvent{
+		AppName:      req.AppName,
+		UserID:       req.UserID,
+		SessionID:    req.SessionID,
+		InvocationID: req.InvocationID,
+	})
+	var rows []storagePartialEvent
+	if err := query.Order("seq").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("database error while fetching partial events: %w", err)
+	}
+	resp := &session.ListPartialsResponse{}
+	for _, row := range rows {
+		var event session.Event
+		if err := json.Unmarshal(row.Event, &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal partial event: %w", err)
+		}
+		resp.Events = append(resp.Events, &event)
+	}
+	return resp, nil
+}
+
+func (s *partialEventStore) DeletePartials(ctx context.Context, req *session.DeletePartialsRequest) error {
+	err := s.db.WithContext(ctx).Where("timestamp < ?", req.Before).Delete(&storagePartialEvent{}).Error
+	if err != nil {
+		return fmt.Errorf("database error while deleting partial events: %w", err)
+	}
+	return nil
+}
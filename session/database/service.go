@@ -16,10 +16,13 @@ package database
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"maps"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -30,6 +33,13 @@ import (
 // databaseService is an database implementation of sessionService.Service.
 type databaseService struct {
 	db *gorm.DB
+	// replica, if set, serves the reads of sessions which don't require
+	// strong consistency.
+	replica *gorm.DB
+
+	mu sync.RWMutex
+	// policies are the expiry policies of the apps.
+	policies map[string]session.ExpiryPolicy
 }
 
 // NewSessionService creates a new [session.Service] implementation that uses a
@@ -48,6 +58,31 @@ func NewSessionService(dialector gorm.Dialector, opts ...gorm.Option) (session.S
 	return &databaseService{db: db}, nil
 }
 
+// NewSessionServiceWithReplica is like [NewSessionService], but serves the
+// [session.Service.Get] calls which don't request
+// [session.ConsistencyStrong] from a read replica. The replica may lag
+// behind the primary database, which serves all the other calls.
+func NewSessionServiceWithReplica(primary, replica gorm.Dialector, opts ...gorm.Option) (session.Service, error) {
+	db, err := gorm.Open(primary, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating database session service: %w", err)
+	}
+	replicaDB, err := gorm.Open(replica, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error opening the read replica of the database session service: %w", err)
+	}
+	return &databaseService{db: db, replica: replicaDB}, nil
+}
+
+// reader returns the database serving the reads with the given
+// consistency.
+func (s *databaseService) reader(c session.Consistency) *gorm.DB {
+	if s.replica != nil && c != session.ConsistencyStrong {
+		return s.replica
+	}
+	return s.db
+}
+
 // AutoMigrate runs the GORM auto-migration tool to ensure the database schema
 // matches the internal storage models (e.g., storageSession, storageEvent).
 //
@@ -120,6 +155,10 @@ func (s *databaseService) Create(ctx context.Context, req *session.CreateRequest
 		}
 		createdSession.State = sessionState
 
+		// An expired session is treated as deleted, so its ID can be reused.
+		if _, err := s.deleteExpired(tx, &storageSession{AppName: req.AppName, UserID: req.UserID, ID: sessionID}, time.Now()); err != nil {
+			return fmt.Errorf("error creating session on database: %w", err)
+		}
 		if err := tx.Create(createdSession).Error; err != nil {
 			return fmt.Errorf("error creating session on database: %w", err)
 		}
@@ -145,8 +184,9 @@ func (s *databaseService) Get(ctx context.Context, req *session.GetRequest) (*se
 		return nil, fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", appName, userID, sessionID)
 	}
 
+	db := s.reader(req.Consistency)
 	var foundSession storageSession
-	err := s.db.WithContext(ctx).
+	err := db.WithContext(ctx).
 		Where(&storageSession{
 			AppName: appName,
 			UserID:  userID,
@@ -154,12 +194,17 @@ func (s *databaseService) Get(ctx context.Context, req *session.GetRequest) (*se
 		}).
 		First(&foundSession).Error
 	if err != nil {
-		// For any error including ErrRecordNotFound, return it as a system error.
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("session %q: %w", sessionID, session.ErrSessionNotFound)
+		}
 		return nil, fmt.Errorf("database error while fetching session: %w", err)
 	}
+	if s.expired(&foundSession, time.Now()) {
+		return nil, fmt.Errorf("session %q: %w", sessionID, session.ErrSessionNotFound)
+	}
 
 	// Fetch events
-	eventQuery := s.db.WithContext(ctx).
+	eventQuery := db.WithContext(ctx).
 		Model(&storageEvent{}).
 		Where("app_name = ?", appName).
 		Where("user_id = ?", userID).
@@ -184,11 +229,11 @@ func (s *databaseService) Get(ctx context.Context, req *session.GetRequest) (*se
 	}
 
 	// fetch app and user states
-	storageApp, err := fetchStorageAppState(s.db.WithContext(ctx), appName)
+	storageApp, err := fetchStorageAppState(db.WithContext(ctx), appName)
 	if err != nil {
 		return nil, fmt.Errorf("error on get session: %w", err)
 	}
-	storageUser, err := fetchStorageUserState(s.db.WithContext(ctx), appName, userID)
+	storageUser, err := fetchStorageUserState(db.WithContext(ctx), appName, userID)
 	if err != nil {
 		return nil, fmt.Errorf("error on get session: %w", err)
 	}
@@ -218,6 +263,77 @@ func (s *databaseService) Get(ctx context.Context, req *session.GetRequest) (*se
 }
 
 // List retrieves sessions from the database using its appName and optional UserId
+// eventCursor is the position of the last event of a page, encoded in the
+// page tokens of ListEvents.
+type eventCursor struct {
+	Timestamp time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// ListEvents implements session.EventLister. The events are ordered by
+// timestamp and ID, and the page token is the position of the last event of
+// the previous page, so that the pages aren't shifted by appended events.
+func (s *databaseService) ListEvents(ctx context.Context, req *session.ListEventsRequest) (*session.ListEventsResponse, error) {
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || sessionID == "" {
+		return nil, fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", appName, userID, sessionID)
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = session.DefaultEventPageSize
+	}
+
+	db := s.db.WithContext(ctx)
+	var sessions []storageSession
+	if err := db.Where(&storageSession{AppName: appName, UserID: userID, ID: sessionID}).Limit(1).Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("database error while fetching session: %w", err)
+	}
+	if len(sessions) == 0 || s.expired(&sessions[0], time.Now()) {
+		return nil, fmt.Errorf("session %q: %w", sessionID, session.ErrSessionNotFound)
+	}
+
+	query := db.Model(&storageEvent{}).
+		Where("app_name = ?", appName).
+		Where("user_id = ?", userID).
+		Where("session_id = ?", sessionID)
+	if req.PageToken != "" {
+		b, err := base64.RawURLEncoding.DecodeString(req.PageToken)
+		var cursor eventCursor
+		if err == nil {
+			err = json.Unmarshal(b, &cursor)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid page token %q", req.PageToken)
+		}
+		query = query.Where("(timestamp > ? OR (timestamp = ? AND id > ?))", cursor.Timestamp, cursor.Timestamp, cursor.ID)
+	}
+	// One more event is fetched to know whether there is a next page.
+	var storageEvents []storageEvent
+	if err := query.Order("timestamp ASC").Order("id ASC").Limit(pageSize + 1).Find(&storageEvents).Error; err != nil {
+		return nil, fmt.Errorf("database error while fetching events: %w", err)
+	}
+
+	resp := &session.ListEventsResponse{}
+	if len(storageEvents) > pageSize {
+		storageEvents = storageEvents[:pageSize]
+		last := storageEvents[pageSize-1]
+		b, err := json.Marshal(eventCursor{Timestamp: last.Timestamp, ID: last.ID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode page token: %w", err)
+		}
+		resp.NextPageToken = base64.RawURLEncoding.EncodeToString(b)
+	}
+	resp.Events = make([]*session.Event, 0, len(storageEvents))
+	for i := range storageEvents {
+		evt, err := createEventFromStorageEvent(&storageEvents[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to map storage event: %w", err)
+		}
+		resp.Events = append(resp.Events, evt)
+	}
+	return resp, nil
+}
+
 func (s *databaseService) List(ctx context.Context, req *session.ListRequest) (*session.ListResponse, error) {
 	appName, userID := req.AppName, req.UserID
 	if appName == "" {
@@ -269,8 +385,12 @@ func (s *databaseService) List(ctx context.Context, req *session.ListRequest) (*
 	}
 
 	// Create response sessions, transform the storageSessions into
+	now := time.Now()
 	responseSessions := make([]session.Session, 0, len(foundSessions))
 	for _, storage := range foundSessions {
+		if s.expired(&storage, now) {
+			continue
+		}
 		s := storage
 		sess, err := createSessionFromStorageSession(&s)
 		if err != nil {
@@ -347,38 +467,43 @@ func (s *databaseService) AppendEvent(ctx context.Context, curSession session.Se
 
 // applyEvent fetches the session, validates it, applies state changes from an
 // event, and saves the event atomically.
-func (s *databaseService) applyEvent(ctx context.Context, session *localSession, event *session.Event) error {
+func (s *databaseService) applyEvent(ctx context.Context, sess *localSession, event *session.Event) error {
 	// Wrap database operations in a single transaction.
 	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Fetch the session object from storage.
 		var storageSess storageSession
-		err := tx.Where(&storageSession{AppName: session.AppName(), UserID: session.UserID(), ID: session.ID()}).
+		err := tx.Where(&storageSession{AppName: sess.AppName(), UserID: sess.UserID(), ID: sess.ID()}).
 			First(&storageSess).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return fmt.Errorf("session not found, cannot apply event")
+				return fmt.Errorf("%w, cannot apply event", session.ErrSessionNotFound)
 			}
 			return fmt.Errorf("failed to get session: %w", err)
 		}
+		if s.expired(&storageSess, time.Now()) {
+			return fmt.Errorf("%w, cannot apply event", session.ErrSessionNotFound)
+		}
 
 		// Ensure the session object is not stale.
 		// We use UnixNano() for microsecond-level precision, matching the Python code.
 		storageUpdateTime := storageSess.UpdateTime.UnixNano()
-		sessionUpdateTime := session.updatedAt.UnixNano()
+		sessionUpdateTime := sess.updatedAt.UnixNano()
 		if storageUpdateTime > sessionUpdateTime {
-			return fmt.Errorf(
-				"stale session error: last update time from request (%s) is older than in database (%s)",
-				time.Unix(0, sessionUpdateTime).Format(time.RFC3339Nano),
-				time.Unix(0, storageUpdateTime).Format(time.RFC3339Nano),
-			)
+			return &session.ConflictError{
+				AppName:   sess.AppName(),
+				UserID:    sess.UserID(),
+				SessionID: sess.ID(),
+				Version:   version(sess.updatedAt),
+				Current:   version(storageSess.UpdateTime),
+			}
 		}
 
 		// Fetch App and User states.
-		storageApp, err := fetchStorageAppState(tx, session.AppName())
+		storageApp, err := fetchStorageAppState(tx, sess.AppName())
 		if err != nil {
 			return err
 		}
-		storageUser, err := fetchStorageUserState(tx, session.AppName(), session.UserID())
+		storageUser, err := fetchStorageUserState(tx, sess.AppName(), sess.UserID())
 		if err != nil {
 			return err
 		}
@@ -405,7 +530,7 @@ func (s *databaseService) applyEvent(ctx context.Context, session *localSession,
 		}
 
 		// Create the new event record in the database.
-		storageEv, err := createStorageEvent(session, event)
+		storageEv, err := createStorageEvent(sess, event)
 		if err != nil {
 			return fmt.Errorf("failed to map event to storage model: %w", err)
 		}
@@ -419,7 +544,7 @@ func (s *databaseService) applyEvent(ctx context.Context, session *localSession,
 			return fmt.Errorf("failed to save session state: %w", err)
 		}
 
-		session.updatedAt = storageSess.UpdateTime
+		sess.updatedAt = storageSess.UpdateTime
 
 		return nil // Returning nil commits the transaction.
 	})
@@ -15,6 +15,7 @@
 package database
 
 import (
+	"errors"
 	"maps"
 	"strconv"
 	"testing"
@@ -24,6 +25,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/session/sessiontest"
 	"google.golang.org/genai"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -883,6 +885,164 @@ func Test_databaseService_StateManagement(t *testing.T) {
 	})
 }
 
+func Test_databaseService_Transcriptions(t *testing.T) {
+	ctx := t.Context()
+	service := emptyService(t)
+	created, err := service.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "voice"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	event := &session.Event{
+		ID:        "ev1",
+		Author:    "agent",
+		Timestamp: time.Now(),
+		LLMResponse: model.LLMResponse{
+			InputTranscription:  &genai.Transcription{Text: "what's the weather?", Finished: true},
+			OutputTranscription: &genai.Transcription{Text: "It's sunny."},
+		},
+	}
+	if err := service.AppendEvent(ctx, created.Session, event); err != nil {
+		t.Fatalf("AppendEvent() error = %v", err)
+	}
+
+	got, err := service.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "voice"})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	gotEvent := got.Session.Events().At(0)
+	if diff := cmp.Diff(event.InputTranscription, gotEvent.InputTranscription); diff != "" {
+		t.Errorf("InputTranscription mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(event.OutputTranscription, gotEvent.OutputTranscription); diff != "" {
+		t.Errorf("OutputTranscription mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_databaseService_Thought(t *testing.T) {
+	ctx := t.Context()
+	service := emptyService(t)
+	created, err := service.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "thinking"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	event := &session.Event{
+		ID:        "ev1",
+		Author:    "agent",
+		Timestamp: time.Now(),
+		LLMResponse: model.LLMResponse{
+			Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{Text: "Let me think.", Thought: true}}},
+			Thought: true,
+		},
+	}
+	if err := service.AppendEvent(ctx, created.Session, event); err != nil {
+		t.Fatalf("AppendEvent() error = %v", err)
+	}
+
+	got, err := service.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "thinking"})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if gotEvent := got.Session.Events().At(0); !gotEvent.Thought {
+		t.Errorf("Thought = false after reload, want true")
+	}
+}
+
+func Test_databaseService_Blocked(t *testing.T) {
+	ctx := t.Context()
+	service := emptyService(t)
+	created, err := service.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "blocked"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	event := &session.Event{
+		ID:        "ev1",
+		Author:    "agent",
+		Timestamp: time.Now(),
+		LLMResponse: model.LLMResponse{
+			ErrorCode: "SAFETY",
+			Blocked: &model.SafetyBlock{
+				Source:     model.BlockedResponse,
+				Reason:     "SAFETY",
+				Categories: []genai.HarmCategory{genai.HarmCategoryHarassment},
+			},
+		},
+	}
+	if err := service.AppendEvent(ctx, created.Session, event); err != nil {
+		t.Fatalf("AppendEvent() error = %v", err)
+	}
+
+	got, err := service.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "blocked"})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if diff := cmp.Diff(event.Blocked, got.Session.Events().At(0).Blocked); diff != "" {
+		t.Errorf("Blocked mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_databaseService_Conformance(t *testing.T) {
+	sessiontest.Run(t, func(t *testing.T) session.Service {
+		service := emptyService(t)
+		// The shared in-memory SQLite database locks its tables on concurrent
+		// writes from several connections.
+		sqlDB, err := service.db.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB.SetMaxOpenConns(1)
+		return service
+	})
+}
+
+func Test_databaseService_ListEvents(t *testing.T) {
+	ctx := t.Context()
+	service := emptyService(t)
+	created, err := service.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "long"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var want []string
+	for i := range 5 {
+		// Events 1 and 2 share their timestamp: they are ordered by ID.
+		ts := start.Add(time.Duration(i) * time.Second)
+		if i == 2 {
+			ts = start.Add(time.Second)
+		}
+		event := &session.Event{ID: "ev" + strconv.Itoa(i), Author: "agent", Timestamp: ts}
+		if err := service.AppendEvent(ctx, created.Session, event); err != nil {
+			t.Fatalf("AppendEvent() error = %v", err)
+		}
+		want = append(want, event.ID)
+	}
+
+	first, err := service.ListEvents(ctx, &session.ListEventsRequest{AppName: "app", UserID: "user", SessionID: "long", PageSize: 2})
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	if len(first.Events) != 2 || first.NextPageToken == "" {
+		t.Fatalf("ListEvents() = %d events with next page token %q, want 2 events and a token", len(first.Events), first.NextPageToken)
+	}
+
+	var got []string
+	for ev, err := range session.AllEvents(ctx, service, "app", "user", "long", 2) {
+		if err != nil {
+			t.Fatalf("AllEvents() error = %v", err)
+		}
+		got = append(got, ev.ID)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("AllEvents() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := service.ListEvents(ctx, &session.ListEventsRequest{AppName: "app", UserID: "user", SessionID: "long", PageToken: "not a token"}); err == nil {
+		t.Error("ListEvents() with an invalid page token succeeded, want an error")
+	}
+	if _, err := service.ListEvents(ctx, &session.ListEventsRequest{AppName: "app", UserID: "user", SessionID: "missing"}); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("ListEvents() of a missing session error = %v, want %v", err, session.ErrSessionNotFound)
+	}
+}
+
 func serviceDbWithData(t *testing.T) *databaseService {
 	t.Helper()
 
@@ -952,6 +1112,125 @@ func serviceDbWithData(t *testing.T) *databaseService {
 	return service
 }
 
+func Test_databaseService_GetConsistency(t *testing.T) {
+	ctx := t.Context()
+	service, err := NewSessionServiceWithReplica(
+		sqlite.Open("file:consistency_primary?mode=memory&cache=shared"),
+		sqlite.Open("file:consistency_replica?mode=memory&cache=shared"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbservice := service.(*databaseService)
+	for _, s := range []session.Service{service, &databaseService{db: dbservice.replica}} {
+		if err := AutoMigrate(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The session is written to the primary database and not replicated yet.
+	if _, err := service.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		consistency session.Consistency
+		wantErr     bool
+	}{
+		{consistency: "", wantErr: true},
+		{consistency: session.ConsistencyEventual, wantErr: true},
+		{consistency: session.ConsistencyStrong},
+	} {
+		_, err := service.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "session", Consistency: tc.consistency})
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Get(consistency %q) error = %v, wantErr %v", tc.consistency, err, tc.wantErr)
+		}
+	}
+}
+
+func Test_partialEventStore(t *testing.T) {
+	ctx := t.Context()
+	store, err := NewPartialEventStore(sqlite.Open("file:partial_events?mode=memory&cache=shared"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AutoMigratePartialEventStore(store); err != nil {
+		t.Fatal(err)
+	}
+	created, err := session.InMemoryService().Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	var events []*session.Event
+	for i, text := range []string{"It", "It is", "It is sunny"} {
+		ev := session.NewEvent("invocation" + strconv.Itoa(i/2))
+		ev.Author = "agent"
+		ev.Timestamp = now.Add(time.Duration(i-2) * time.Hour)
+		ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel), Partial: true}
+		if err := store.AppendPartial(ctx, created.Session, ev); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, ev)
+	}
+
+	list := func(invocationID string) []*session.Event {
+		t.Helper()
+		resp, err := store.ListPartials(ctx, &session.ListPartialsRequest{AppName: "app", UserID: "user", SessionID: "session", InvocationID: invocationID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Events
+	}
+	opts := cmpopts.EquateApproxTime(time.Millisecond)
+	if diff := cmp.Diff(events, list(""), opts); diff != "" {
+		t.Errorf("ListPartials() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(events[2:], list("invocation1"), opts); diff != "" {
+		t.Errorf("ListPartials(invocation1) mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := store.DeletePartials(ctx, &session.DeletePartialsRequest{Before: now.Add(-30 * time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(events[2:], list(""), opts); diff != "" {
+		t.Errorf("ListPartials() after DeletePartials() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_databaseService_AppendEventConflict(t *testing.T) {
+	ctx := t.Context()
+	service := emptyService(t)
+	if _, err := service.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	get := func() session.Session {
+		resp, err := service.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Session
+	}
+	a, b := get(), get()
+
+	now := time.Now()
+	if err := service.AppendEvent(ctx, a, &session.Event{ID: "a1", Timestamp: now.Add(time.Second)}); err != nil {
+		t.Fatalf("AppendEvent() error = %v", err)
+	}
+	err := service.AppendEvent(ctx, b, &session.Event{ID: "b1", Timestamp: now.Add(2 * time.Second)})
+	if !errors.Is(err, session.ErrConflict) {
+		t.Fatalf("AppendEvent() to the stale session error = %v, want ErrConflict", err)
+	}
+
+	b = get()
+	if got, want := b.(session.Versioned).Version(), a.(session.Versioned).Version(); got != want {
+		t.Errorf("Version() = %s, want %s", got, want)
+	}
+	if err := service.AppendEvent(ctx, b, &session.Event{ID: "b1", Timestamp: now.Add(2 * time.Second)}); err != nil {
+		t.Fatalf("AppendEvent() after reading the session again error = %v", err)
+	}
+}
+
 func emptyService(t *testing.T) *databaseService {
 	t.Helper()
 	gormConfig := &gorm.Config{
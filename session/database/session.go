@@ -67,6 +67,19 @@ func (s *localSession) LastUpdateTime() time.Time {
 	return s.updatedAt
 }
 
+// Version implements session.Versioned. The version is the update time of
+// the stored session.
+func (s *localSession) Version() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return version(s.updatedAt)
+}
+
+func version(updatedAt time.Time) string {
+	return updatedAt.UTC().Format(time.RFC3339Nano)
+}
+
 func (s *localSession) appendEvent(event *session.Event) error {
 	if event.Partial {
 		return nil
@@ -108,6 +121,10 @@ func (e events) At(i int) *session.Event {
 	return nil
 }
 
+func (e events) Query(q session.EventQuery) iter.Seq[*session.Event] {
+	return q.Select(e)
+}
+
 type state struct {
 	mu    *sync.RWMutex
 	state map[string]any
@@ -72,8 +72,9 @@ type storageEvent struct {
 	UserID    string `gorm:"primaryKey;"`
 	SessionID string `gorm:"primaryKey;"`
 
-	InvocationID string
-	Author       string
+	InvocationID       string
+	ParentInvocationID string
+	Author             string
 	// In Python, this is a pickled object. In Go, the raw bytes are the closest
 	// equivalent. Unpickling would require a custom library or service.
 	Actions                []byte
@@ -87,12 +88,19 @@ type storageEvent struct {
 	CustomMetadata    dynamicJSON
 	UsageMetadata     dynamicJSON
 	CitationMetadata  dynamicJSON
+	// Transcriptions of the audio in live mode.
+	InputTranscription  dynamicJSON
+	OutputTranscription dynamicJSON
+	// Blocked is the safety block of the response, if any.
+	Blocked dynamicJSON
 
 	Partial      *bool
 	TurnComplete *bool
 	ErrorCode    *string
 	ErrorMessage *string
 	Interrupted  *bool
+	// Thought is set if the content only holds thought parts.
+	Thought *bool
 
 	// Belongs-To relationship: An event belongs to a session.
 	Session storageSession `gorm:"foreignKey:AppName,UserID,SessionID;references:AppName,UserID,ID"`
@@ -108,13 +116,14 @@ func (storageEvent) TableName() string {
 func createStorageEvent(session session.Session, event *session.Event) (*storageEvent, error) {
 	// Initialize the base storageEvent with direct field mappings.
 	storageEv := &storageEvent{
-		ID:           event.ID,
-		InvocationID: event.InvocationID,
-		Author:       event.Author,
-		SessionID:    session.ID(),
-		AppName:      session.AppName(),
-		UserID:       session.UserID(),
-		Timestamp:    event.Timestamp,
+		ID:                 event.ID,
+		InvocationID:       event.InvocationID,
+		ParentInvocationID: event.ParentInvocationID,
+		Author:             event.Author,
+		SessionID:          session.ID(),
+		AppName:            session.AppName(),
+		UserID:             session.UserID(),
+		Timestamp:          event.Timestamp,
 	}
 
 	// --- Handle complex or nullable fields ---
@@ -150,6 +159,7 @@ func createStorageEvent(session session.Session, event *session.Event) (*storage
 	storageEv.Partial = &event.Partial
 	storageEv.TurnComplete = &event.TurnComplete
 	storageEv.Interrupted = &event.Interrupted
+	storageEv.Thought = &event.Thought
 
 	// --- Handle JSON content fields ---
 	if event.Content != nil {
@@ -182,6 +192,24 @@ func createStorageEvent(session session.Session, event *session.Event) (*storage
 			return nil, fmt.Errorf("failed to marshal citation metadata: %w", err)
 		}
 	}
+	if event.InputTranscription != nil {
+		storageEv.InputTranscription, err = json.Marshal(event.InputTranscription)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal input transcription: %w", err)
+		}
+	}
+	if event.OutputTranscription != nil {
+		storageEv.OutputTranscription, err = json.Marshal(event.OutputTranscription)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal output transcription: %w", err)
+		}
+	}
+	if event.Blocked != nil {
+		storageEv.Blocked, err = json.Marshal(event.Blocked)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal safety block: %w", err)
+		}
+	}
 
 	return storageEv, nil
 }
@@ -241,6 +269,25 @@ func createEventFromStorageEvent(se *storageEvent) (*session.Event, error) {
 		}
 	}
 
+	var inputTranscription, outputTranscription *genai.Transcription
+	if len(se.InputTranscription) > 0 {
+		if err := json.Unmarshal(se.InputTranscription, &inputTranscription); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal input transcription: %w", err)
+		}
+	}
+	if len(se.OutputTranscription) > 0 {
+		if err := json.Unmarshal(se.OutputTranscription, &outputTranscription); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal output transcription: %w", err)
+		}
+	}
+
+	var blocked *model.SafetyBlock
+	if len(se.Blocked) > 0 {
+		if err := json.Unmarshal(se.Blocked, &blocked); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal safety block: %w", err)
+		}
+	}
+
 	// --- Handle JSON-encoded *string field ---
 	var toolIDs []string
 	if se.LongRunningToolIDsJSON != nil {
@@ -257,27 +304,33 @@ func createEventFromStorageEvent(se *storageEvent) (*session.Event, error) {
 	partial := derefOrZero(se.Partial)
 	turnComplete := derefOrZero(se.TurnComplete)
 	interrupted := derefOrZero(se.Interrupted)
+	thought := derefOrZero(se.Thought)
 
 	// --- Assemble the final Event struct ---
 	event := &session.Event{
 		ID:                 se.ID,
 		InvocationID:       se.InvocationID,
+		ParentInvocationID: se.ParentInvocationID,
 		Author:             se.Author,
 		Timestamp:          se.Timestamp,
 		Actions:            actions,
 		LongRunningToolIDs: toolIDs,
 		Branch:             branch,
 		LLMResponse: model.LLMResponse{
-			Content:           content,
-			GroundingMetadata: groundingMetadata,
-			CustomMetadata:    customMetadata,
-			UsageMetadata:     usageMetadata,
-			CitationMetadata:  citationMetadata,
-			ErrorCode:         errorCode,
-			ErrorMessage:      errorMessage,
-			Partial:           partial,
-			TurnComplete:      turnComplete,
-			Interrupted:       interrupted,
+			Content:             content,
+			GroundingMetadata:   groundingMetadata,
+			CustomMetadata:      customMetadata,
+			UsageMetadata:       usageMetadata,
+			CitationMetadata:    citationMetadata,
+			ErrorCode:           errorCode,
+			ErrorMessage:        errorMessage,
+			Blocked:             blocked,
+			Partial:             partial,
+			TurnComplete:        turnComplete,
+			Interrupted:         interrupted,
+			Thought:             thought,
+			InputTranscription:  inputTranscription,
+			OutputTranscription: outputTranscription,
 		},
 	}
 
@@ -0,0 +1,92 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// ExpiryMode selects the time the lifetime of a session starts from.
+type ExpiryMode string
+
+const (
+	// ExpirySliding expires the sessions TTL after their last update, so
+	// active conversations never expire.
+	ExpirySliding ExpiryMode = "sliding"
+	// ExpiryAbsolute expires the sessions TTL after their creation, however
+	// active they are.
+	ExpiryAbsolute ExpiryMode = "absolute"
+)
+
+// ExpiryPolicy is the expiry policy of the sessions of an app.
+type ExpiryPolicy struct {
+	// TTL is the lifetime of the sessions.
+	// Optional: if zero, the sessions never expire.
+	TTL time.Duration
+	// Mode selects the time the lifetime starts from.
+	// Optional: defaults to ExpirySliding.
+	Mode ExpiryMode
+}
+
+// ExpiresAt returns the time a session created at created and last updated
+// at updated expires at, or the zero time if it never expires.
+func (p ExpiryPolicy) ExpiresAt(created, updated time.Time) time.Time {
+	if p.TTL <= 0 {
+		return time.Time{}
+	}
+	if p.Mode == ExpiryAbsolute {
+		return created.Add(p.TTL)
+	}
+	return updated.Add(p.TTL)
+}
+
+// Expired reports whether a session created at created and last updated at
+// updated is expired at now.
+func (p ExpiryPolicy) Expired(created, updated, now time.Time) bool {
+	expiresAt := p.ExpiresAt(created, updated)
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
+
+// Expirer is implemented by the services which expire the sessions, so that
+// stale conversations don't accumulate forever. The services of this module
+// implement it.
+//
+// The expired sessions are treated as deleted: Get and AppendEvent return
+// [ErrSessionNotFound], List skips them, and Create can reuse their IDs.
+// Their storage is reclaimed by ExpireBefore, typically run periodically.
+type Expirer interface {
+	// SetExpiryPolicy sets the expiry policy of the sessions of the app,
+	// including the existing ones.
+	SetExpiryPolicy(appName string, policy ExpiryPolicy)
+	// ExpireBefore deletes the sessions of the app which expire before
+	// req.Before according to the policy of the app.
+	ExpireBefore(context.Context, *ExpireBeforeRequest) (*ExpireBeforeResponse, error)
+}
+
+// ExpireBeforeRequest represents a request to delete the expired sessions of
+// an app.
+type ExpireBeforeRequest struct {
+	AppName string
+	// Before is the time the sessions expire before, e.g. time.Now() to
+	// delete the sessions which are already expired.
+	Before time.Time
+}
+
+// ExpireBeforeResponse represents a response from [Expirer.ExpireBefore].
+type ExpireBeforeResponse struct {
+	// Deleted is the number of deleted sessions.
+	Deleted int
+}
@@ -21,6 +21,7 @@ import (
 	"maps"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -40,6 +41,8 @@ type inMemoryService struct {
 	sessions  omap.Map[string, *session] // session.ID) -> storedSession
 	userState map[string]map[string]stateMap
 	appState  map[string]stateMap
+	// policies are the expiry policies of the apps.
+	policies map[string]ExpiryPolicy
 }
 
 func (s *inMemoryService) Create(ctx context.Context, req *CreateRequest) (*CreateResponse, error) {
@@ -59,9 +62,16 @@ func (s *inMemoryService) Create(ctx context.Context, req *CreateRequest) (*Crea
 	}
 
 	encodedKey := key.Encode()
-	_, ok := s.sessions.Get(encodedKey)
-	if ok {
-		return nil, fmt.Errorf("session %s already exists", req.SessionID)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.sessions.Get(encodedKey); ok {
+		if !s.expired(existing, now) {
+			return nil, fmt.Errorf("session %s already exists", req.SessionID)
+		}
+		existing.notifyWatchers()
 	}
 
 	state := req.State
@@ -71,12 +81,10 @@ func (s *inMemoryService) Create(ctx context.Context, req *CreateRequest) (*Crea
 	val := &session{
 		id:        key,
 		state:     state,
-		updatedAt: time.Now(),
+		createdAt: now,
+		updatedAt: now,
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	s.sessions.Set(encodedKey, val)
 	appDelta, userDelta, _ := sessionutils.ExtractStateDeltas(req.State)
 	appState := s.updateAppState(appDelta, req.AppName)
@@ -92,6 +100,44 @@ func (s *inMemoryService) Create(ctx context.Context, req *CreateRequest) (*Crea
 	}, nil
 }
 
+// ListEvents implements EventLister. The page token is the offset of the
+// first event of the page.
+func (s *inMemoryService) ListEvents(ctx context.Context, req *ListEventsRequest) (*ListEventsResponse, error) {
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || sessionID == "" {
+		return nil, fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", appName, userID, sessionID)
+	}
+	offset := 0
+	if req.PageToken != "" {
+		var err error
+		if offset, err = strconv.Atoi(req.PageToken); err != nil || offset < 0 {
+			return nil, fmt.Errorf("invalid page token %q", req.PageToken)
+		}
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultEventPageSize
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res, ok := s.sessions.Get(id{appName: appName, userID: userID, sessionID: sessionID}.Encode())
+	if !ok || s.expired(res, time.Now()) {
+		return nil, fmt.Errorf("session %+v: %w", sessionID, ErrSessionNotFound)
+	}
+	res.mu.RLock()
+	defer res.mu.RUnlock()
+	if offset > len(res.events) {
+		return nil, fmt.Errorf("invalid page token %q", req.PageToken)
+	}
+	end := min(offset+pageSize, len(res.events))
+	resp := &ListEventsResponse{Events: slices.Clone(res.events[offset:end])}
+	if end < len(res.events) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return resp, nil
+}
+
 func (s *inMemoryService) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
 	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
 	if appName == "" || userID == "" || sessionID == "" {
@@ -108,8 +154,8 @@ func (s *inMemoryService) Get(ctx context.Context, req *GetRequest) (*GetRespons
 	}
 
 	res, ok := s.sessions.Get(id.Encode())
-	if !ok {
-		return nil, fmt.Errorf("session %+v not found", req.SessionID)
+	if !ok || s.expired(res, time.Now()) {
+		return nil, fmt.Errorf("session %+v: %w", req.SessionID, ErrSessionNotFound)
 	}
 
 	copiedSession := copySessionWithoutStateAndEvents(res)
@@ -156,6 +202,7 @@ func (s *inMemoryService) List(ctx context.Context, req *ListRequest) (*ListResp
 		hi = id{appName: appName, userID: userID + "\x00"}.Encode()
 	}
 
+	now := time.Now()
 	sessions := make([]Session, 0)
 	for k, storedSession := range s.sessions.Scan(lo, hi) {
 		var key id
@@ -166,6 +213,9 @@ func (s *inMemoryService) List(ctx context.Context, req *ListRequest) (*ListResp
 		if key.appName != appName && key.userID != userID {
 			break
 		}
+		if s.expired(storedSession, now) {
+			continue
+		}
 		copiedSession := copySessionWithoutStateAndEvents(storedSession)
 		copiedSession.state = s.mergeStates(storedSession.state, appName, storedSession.UserID())
 		sessions = append(sessions, copiedSession)
@@ -190,7 +240,10 @@ func (s *inMemoryService) Delete(ctx context.Context, req *DeleteRequest) error
 		sessionID: sessionID,
 	}
 
-	s.sessions.Delete(id.Encode())
+	if stored, ok := s.sessions.Get(id.Encode()); ok {
+		stored.notifyWatchers()
+		s.sessions.Delete(id.Encode())
+	}
 	return nil
 }
 
@@ -214,8 +267,17 @@ func (s *inMemoryService) AppendEvent(ctx context.Context, curSession Session, e
 	defer s.mu.Unlock()
 
 	stored_session, ok := s.sessions.Get(sess.id.Encode())
-	if !ok {
-		return fmt.Errorf("session not found, cannot apply event")
+	if !ok || s.expired(stored_session, time.Now()) {
+		return fmt.Errorf("%w, cannot apply event", ErrSessionNotFound)
+	}
+	if sess != stored_session && sess.version != stored_session.version {
+		return &ConflictError{
+			AppName:   sess.id.appName,
+			UserID:    sess.id.userID,
+			SessionID: sess.id.sessionID,
+			Version:   strconv.Itoa(sess.version),
+			Current:   strconv.Itoa(stored_session.version),
+		}
 	}
 
 	// update the in-memory session
@@ -226,6 +288,11 @@ func (s *inMemoryService) AppendEvent(ctx context.Context, curSession Session, e
 	// update the in-memory session service
 	stored_session.events = append(stored_session.events, event)
 	stored_session.updatedAt = event.Timestamp
+	stored_session.version++
+	stored_session.notifyWatchers()
+	sess.mu.Lock()
+	sess.version = stored_session.version
+	sess.mu.Unlock()
 	if len(event.Actions.StateDelta) > 0 {
 		appDelta, userDelta, sessionDelta := sessionutils.ExtractStateDeltas(event.Actions.StateDelta)
 		s.updateAppState(appDelta, curSession.AppName())
@@ -235,6 +302,108 @@ func (s *inMemoryService) AppendEvent(ctx context.Context, curSession Session, e
 	return nil
 }
 
+// WatchEvents implements EventWatcher.
+func (s *inMemoryService) WatchEvents(ctx context.Context, req *WatchRequest) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+		if appName == "" || userID == "" || sessionID == "" {
+			yield(nil, fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", appName, userID, sessionID))
+			return
+		}
+		key := id{appName: appName, userID: userID, sessionID: sessionID}.Encode()
+
+		var watched *session
+		offset := 0
+		for {
+			s.mu.Lock()
+			stored, ok := s.sessions.Get(key)
+			if !ok || s.expired(stored, time.Now()) || (watched != nil && stored != watched) {
+				s.mu.Unlock()
+				yield(nil, fmt.Errorf("session %+v: %w", sessionID, ErrSessionNotFound))
+				return
+			}
+			if watched == nil {
+				watched, offset = stored, len(stored.events)
+				if !req.After.IsZero() {
+					offset = sort.Search(len(stored.events), func(i int) bool {
+						return !stored.events[i].Timestamp.Before(req.After)
+					})
+				}
+			}
+			events := slices.Clone(stored.events[offset:])
+			offset = len(stored.events)
+			if stored.changed == nil {
+				stored.changed = make(chan struct{})
+			}
+			changed := stored.changed
+			// The watch ends when the session expires, even if no event is
+			// appended to it.
+			var expiry <-chan time.Time
+			if expiresAt := s.policies[appName].ExpiresAt(stored.createdAt, stored.updatedAt); !expiresAt.IsZero() {
+				expiry = time.After(time.Until(expiresAt))
+			}
+			s.mu.Unlock()
+
+			for _, event := range events {
+				if !yield(event, nil) {
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			case <-expiry:
+			}
+		}
+	}
+}
+
+// SetExpiryPolicy implements Expirer.
+func (s *inMemoryService) SetExpiryPolicy(appName string, policy ExpiryPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies[appName] = policy
+	// The watchers re-check the expiry of their sessions with the new policy.
+	lo := id{appName: appName}.Encode()
+	hi := id{appName: appName + "\x00"}.Encode()
+	for _, storedSession := range s.sessions.Scan(lo, hi) {
+		storedSession.notifyWatchers()
+	}
+}
+
+// ExpireBefore implements Expirer.
+func (s *inMemoryService) ExpireBefore(ctx context.Context, req *ExpireBeforeRequest) (*ExpireBeforeResponse, error) {
+	if req.AppName == "" {
+		return nil, fmt.Errorf("app_name is required, got app_name: %q", req.AppName)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []string
+	lo := id{appName: req.AppName}.Encode()
+	hi := id{appName: req.AppName + "\x00"}.Encode()
+	for k, storedSession := range s.sessions.Scan(lo, hi) {
+		if storedSession.id.appName == req.AppName && s.expired(storedSession, req.Before) {
+			expired = append(expired, k)
+		}
+	}
+	for _, k := range expired {
+		if stored, ok := s.sessions.Get(k); ok {
+			stored.notifyWatchers()
+		}
+		s.sessions.Delete(k)
+	}
+	return &ExpireBeforeResponse{Deleted: len(expired)}, nil
+}
+
+// expired reports whether the stored session is expired at now. The caller
+// must hold s.mu.
+func (s *inMemoryService) expired(sess *session, now time.Time) bool {
+	return s.policies[sess.id.appName].Expired(sess.createdAt, sess.updatedAt, now)
+}
+
 func (s *inMemoryService) updateAppState(appDelta stateMap, appName string) stateMap {
 	innerMap, ok := s.appState[appName]
 	if !ok {
@@ -291,7 +460,21 @@ type session struct {
 	mu        sync.RWMutex
 	events    []*Event
 	state     map[string]any
+	createdAt time.Time
 	updatedAt time.Time
+	// version is the number of events appended to the stored session.
+	version int
+	// changed, if the stored session is watched, is closed when an event is
+	// appended to it or when it is deleted. Guarded by the service's mu.
+	changed chan struct{}
+}
+
+// notifyWatchers wakes up the watchers of the stored session.
+func (s *session) notifyWatchers() {
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
 }
 
 func (s *session) ID() string {
@@ -314,6 +497,8 @@ func (s *session) State() State {
 }
 
 func (s *session) Events() Events {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return events(s.events)
 }
 
@@ -324,6 +509,13 @@ func (s *session) LastUpdateTime() time.Time {
 	return s.updatedAt
 }
 
+// Version implements Versioned.
+func (s *session) Version() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return strconv.Itoa(s.version)
+}
+
 func (s *session) appendEvent(event *Event) error {
 	if event.Partial {
 		return nil
@@ -334,6 +526,10 @@ func (s *session) appendEvent(event *Event) error {
 		return fmt.Errorf("error on appendEvent: %w", err)
 	}
 
+	// The events are read concurrently, e.g. by the branches of a parallel
+	// agent.
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.events = append(s.events, event)
 	s.updatedAt = event.Timestamp
 	return nil
@@ -362,6 +558,10 @@ func (e events) At(i int) *Event {
 	return nil
 }
 
+func (e events) Query(q EventQuery) iter.Seq[*Event] {
+	return q.Select(e)
+}
+
 type state struct {
 	mu    *sync.RWMutex
 	state map[string]any
@@ -454,8 +654,14 @@ func copySessionWithoutStateAndEvents(sess *session) *session {
 			userID:    sess.id.userID,
 			sessionID: sess.id.sessionID,
 		},
+		createdAt: sess.createdAt,
 		updatedAt: sess.updatedAt,
+		version:   sess.version,
 	}
 }
 
-var _ Service = (*inMemoryService)(nil)
+var (
+	_ Service      = (*inMemoryService)(nil)
+	_ Expirer      = (*inMemoryService)(nil)
+	_ EventWatcher = (*inMemoryService)(nil)
+)
@@ -15,6 +15,7 @@
 package session
 
 import (
+	"errors"
 	"maps"
 	"strconv"
 	"testing"
@@ -812,7 +813,7 @@ func Test_databaseService_AppendEvent(t *testing.T) {
 			opts := []cmp.Option{
 				cmp.AllowUnexported(session{}),
 				cmp.AllowUnexported(id{}),
-				cmpopts.IgnoreFields(session{}, "mu", "updatedAt"),
+				cmpopts.IgnoreFields(session{}, "mu", "updatedAt", "version"),
 				cmpopts.IgnoreFields(Event{}, "Timestamp"),
 				// Add sorters if event order is not guaranteed
 				cmpopts.SortSlices(func(a, b *Event) bool {
@@ -1000,3 +1001,46 @@ func emptyService(t *testing.T) Service {
 }
 
 // TODO: test concurrency
+
+func Test_inMemoryService_AppendEventConflict(t *testing.T) {
+	ctx := t.Context()
+	s := emptyService(t)
+	if _, err := s.Create(ctx, &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	get := func() Session {
+		resp, err := s.Get(ctx, &GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Session
+	}
+	// Two replicas read the session.
+	a, b := get(), get()
+
+	if err := s.AppendEvent(ctx, a, &Event{ID: "a1", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("AppendEvent() error = %v", err)
+	}
+	if err := s.AppendEvent(ctx, a, &Event{ID: "a2", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("AppendEvent() to the up to date session error = %v", err)
+	}
+	err := s.AppendEvent(ctx, b, &Event{ID: "b1", Timestamp: time.Now()})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, ErrConflict) {
+		t.Fatalf("AppendEvent() to the stale session error = %v, want a ConflictError", err)
+	}
+	if conflict.Version != "0" || conflict.Current != "2" {
+		t.Errorf("ConflictError versions = %s, %s, want 0, 2", conflict.Version, conflict.Current)
+	}
+
+	b = get()
+	if got := b.(Versioned).Version(); got != "2" {
+		t.Errorf("Version() = %s, want 2", got)
+	}
+	if err := s.AppendEvent(ctx, b, &Event{ID: "b1", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("AppendEvent() after reading the session again error = %v", err)
+	}
+	if got := get().Events().Len(); got != 3 {
+		t.Errorf("session has %d events, want 3", got)
+	}
+}
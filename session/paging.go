@@ -0,0 +1,96 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// DefaultEventPageSize is the number of events of a page when
+// ListEventsRequest.PageSize is not set.
+const DefaultEventPageSize = 100
+
+// ListEventsRequest represents a request to list the events of a session by
+// pages, oldest first.
+type ListEventsRequest struct {
+	AppName   string
+	UserID    string
+	SessionID string
+
+	// PageSize is the maximum number of events of the page.
+	// Optional: if zero, DefaultEventPageSize is used.
+	PageSize int
+	// PageToken is the NextPageToken of the previous page.
+	// Optional: if empty, the first page is listed.
+	PageToken string
+}
+
+// ListEventsResponse represents a response from [EventLister.ListEvents].
+type ListEventsResponse struct {
+	Events []*Event
+	// NextPageToken is the cursor of the next page, or empty if this is the
+	// last page.
+	NextPageToken string
+}
+
+// EventLister is implemented by the services which list the events of a
+// session by pages, so that very long sessions can be read without loading
+// all their events at once. The services of this module implement it.
+type EventLister interface {
+	ListEvents(context.Context, *ListEventsRequest) (*ListEventsResponse, error)
+}
+
+// AllEvents returns the events of the session, oldest first. The events are
+// listed by pages of pageSize events if the service implements
+// [EventLister], so only one page is held in memory at a time; otherwise,
+// the session is read with Get. A pageSize of zero uses
+// DefaultEventPageSize.
+func AllEvents(ctx context.Context, svc Service, appName, userID, sessionID string, pageSize int) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		lister, ok := svc.(EventLister)
+		if !ok {
+			resp, err := svc.Get(ctx, &GetRequest{AppName: appName, UserID: userID, SessionID: sessionID})
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for e := range resp.Session.Events().All() {
+				if !yield(e, nil) {
+					return
+				}
+			}
+			return
+		}
+		req := &ListEventsRequest{AppName: appName, UserID: userID, SessionID: sessionID, PageSize: pageSize}
+		for {
+			resp, err := lister.ListEvents(ctx, req)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to list events: %w", err))
+				return
+			}
+			for _, e := range resp.Events {
+				if !yield(e, nil) {
+					return
+				}
+			}
+			if resp.NextPageToken == "" {
+				return
+			}
+			req.PageToken = resp.NextPageToken
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// unpagedService hides the ListEvents method of the service.
+type unpagedService struct{ Service }
+
+func TestAllEvents(t *testing.T) {
+	ctx := t.Context()
+	svc := InMemoryService()
+	created, err := svc.Create(ctx, &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for i := range 5 {
+		ev := &Event{ID: "e" + strconv.Itoa(i), Author: "user"}
+		if err := svc.AppendEvent(ctx, created.Session, ev); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, ev.ID)
+	}
+
+	for name, svc := range map[string]Service{"paged": svc, "unpaged": unpagedService{svc}} {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for ev, err := range AllEvents(ctx, svc, "app", "user", "s1", 2) {
+				if err != nil {
+					t.Fatalf("AllEvents() error = %v", err)
+				}
+				got = append(got, ev.ID)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("AllEvents() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	lister := svc.(EventLister)
+	resp, err := lister.ListEvents(ctx, &ListEventsRequest{AppName: "app", UserID: "user", SessionID: "s1", PageSize: 2, PageToken: "4"})
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	if len(resp.Events) != 1 || resp.NextPageToken != "" {
+		t.Errorf("ListEvents() of the last page = %d events with next page token %q, want 1 event and no token", len(resp.Events), resp.NextPageToken)
+	}
+	if _, err := lister.ListEvents(ctx, &ListEventsRequest{AppName: "app", UserID: "user", SessionID: "s1", PageToken: "x"}); err == nil {
+		t.Error("ListEvents() with an invalid page token succeeded, want an error")
+	}
+	if _, err := lister.ListEvents(ctx, &ListEventsRequest{AppName: "app", UserID: "user", SessionID: "missing"}); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("ListEvents() of a missing session error = %v, want %v", err, ErrSessionNotFound)
+	}
+}
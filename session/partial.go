@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// PartialEventStore keeps the partial events, which the session services
+// don't store, for the deployments which must audit exactly what was
+// streamed to the users, see runner.Config.PartialEvents. The volume of the
+// partial events is high, so they are kept apart from the sessions and
+// deleted once their retention period is over.
+type PartialEventStore interface {
+	// AppendPartial stores a partial event of the session.
+	AppendPartial(ctx context.Context, s Session, event *Event) error
+	// ListPartials returns the partial events of a session, oldest first.
+	ListPartials(ctx context.Context, req *ListPartialsRequest) (*ListPartialsResponse, error)
+	// DeletePartials deletes the partial events of all the sessions older
+	// than req.Before.
+	DeletePartials(ctx context.Context, req *DeletePartialsRequest) error
+}
+
+// ListPartialsRequest represents a request to list the partial events of a
+// session.
+type ListPartialsRequest struct {
+	AppName, UserID, SessionID string
+	// InvocationID selects the partial events of an invocation.
+	// Optional: if empty, the events of all the invocations are returned.
+	InvocationID string
+}
+
+// ListPartialsResponse holds the partial events of a session.
+type ListPartialsResponse struct {
+	Events []*Event
+}
+
+// DeletePartialsRequest represents a request to delete the partial events
+// past their retention period.
+type DeletePartialsRequest struct {
+	Before time.Time
+}
+
+// InMemoryPartialEventStore returns an in-memory implementation of
+// [PartialEventStore], for tests and local development.
+func InMemoryPartialEventStore() PartialEventStore {
+	return &inMemoryPartialEventStore{events: make(map[id][]*Event)}
+}
+
+type inMemoryPartialEventStore struct {
+	mu     sync.RWMutex
+	events map[id][]*Event
+}
+
+func (s *inMemoryPartialEventStore) AppendPartial(ctx context.Context, sess Session, event *Event) error {
+	if sess == nil || event == nil {
+		return fmt.Errorf("session and event are required")
+	}
+	key := id{appName: sess.AppName(), userID: sess.UserID(), sessionID: sess.ID()}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events[key] = append(s.events[key], event)
+	return nil
+}
+
+func (s *inMemoryPartialEventStore) ListPartials(ctx context.Context, req *ListPartialsRequest) (*ListPartialsResponse, error) {
+	if req.AppName == "" || req.UserID == "" || req.SessionID == "" {
+		return nil, fmt.Errorf("app_name, user_id and session_id are required, got app_name: %q, user_id: %q, session_id: %q", req.AppName, req.UserID, req.SessionID)
+	}
+	key := id{appName: req.AppName, userID: req.UserID, sessionID: req.SessionID}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var events []*Event
+	for _, e := range s.events[key] {
+		if req.InvocationID == "" || e.InvocationID == req.InvocationID {
+			events = append(events, e)
+		}
+	}
+	return &ListPartialsResponse{Events: events}, nil
+}
+
+func (s *inMemoryPartialEventStore) DeletePartials(ctx context.Context, req *DeletePartialsRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, events := range s.events {
+		events = slices.DeleteFunc(events, func(e *Event) bool { return e.Timestamp.Before(req.Before) })
+		if len(events) == 0 {
+			delete(s.events, key)
+		} else {
+			s.events[key] = events
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"iter"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventQuery selects the events of a session. The zero fields select all the
+// events.
+type EventQuery struct {
+	// Author selects the events of the author, e.g. an agent or "user".
+	Author string
+	// Branch selects the events of the branch and of its sub-branches, see
+	// Event.Branch.
+	Branch string
+	// Since selects the events with a timestamp at or after it.
+	Since time.Time
+	// Until selects the events with a timestamp before it.
+	Until time.Time
+}
+
+// Match reports whether the event is selected by the query.
+func (q EventQuery) Match(e *Event) bool {
+	if e == nil {
+		return false
+	}
+	if q.Author != "" && e.Author != q.Author {
+		return false
+	}
+	if q.Branch != "" && e.Branch != q.Branch && !strings.HasPrefix(e.Branch, q.Branch+".") {
+		return false
+	}
+	if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Timestamp.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Select returns the events matching the query among the events sorted by
+// timestamp, like the events of the sessions of the services. The time range
+// is found by binary search, so only the events in the range are scanned.
+// It is meant for the implementations of [QueryableEvents].
+func (q EventQuery) Select(events []*Event) iter.Seq[*Event] {
+	return func(yield func(*Event) bool) {
+		start, end := 0, len(events)
+		if !q.Since.IsZero() {
+			start = sort.Search(len(events), func(i int) bool {
+				return !events[i].Timestamp.Before(q.Since)
+			})
+		}
+		if !q.Until.IsZero() {
+			end = start + sort.Search(end-start, func(i int) bool {
+				return !events[start+i].Timestamp.Before(q.Until)
+			})
+		}
+		for _, e := range events[start:end] {
+			if q.Match(e) && !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// QueryableEvents is implemented by the events of the sessions which select
+// events without scanning all of them. The events of the sessions of the
+// services of this module implement it.
+type QueryableEvents interface {
+	Events
+	// Query returns the events matching q, preserving their order.
+	Query(q EventQuery) iter.Seq[*Event]
+}
+
+// Query returns the events matching q, preserving their order. It uses the
+// Query method of the events if they implement [QueryableEvents], and scans
+// them otherwise.
+func Query(events Events, q EventQuery) iter.Seq[*Event] {
+	if events == nil {
+		return func(func(*Event) bool) {}
+	}
+	if qe, ok := events.(QueryableEvents); ok {
+		return qe.Query(q)
+	}
+	return func(yield func(*Event) bool) {
+		for e := range events.All() {
+			if q.Match(e) && !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// ByAuthor returns the events of the author, preserving their order.
+func ByAuthor(events Events, author string) iter.Seq[*Event] {
+	return Query(events, EventQuery{Author: author})
+}
+
+// Since returns the events with a timestamp at or after t, preserving their
+// order.
+func Since(events Events, t time.Time) iter.Seq[*Event] {
+	return Query(events, EventQuery{Since: t})
+}
+
+// InBranch returns the events of the branch and of its sub-branches,
+// preserving their order.
+func InBranch(events Events, branch string) iter.Seq[*Event] {
+	return Query(events, EventQuery{Branch: branch})
+}
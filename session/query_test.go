@@ -0,0 +1,100 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// scannedEvents hides the Query method of the events.
+type scannedEvents struct{ Events }
+
+func TestQuery(t *testing.T) {
+	ctx := t.Context()
+	svc := InMemoryService()
+	created, err := svc.Create(ctx, &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, ev := range []struct{ id, author, branch string }{
+		{"e0", "user", ""},
+		{"e1", "root", "root"},
+		{"e2", "helper", "root.helper"},
+		{"e3", "user", ""},
+		{"e4", "helper", "root.helperx"},
+		{"e5", "root", "root"},
+	} {
+		event := NewEvent("inv")
+		event.ID, event.Author, event.Branch = ev.id, ev.author, ev.branch
+		event.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		if err := svc.AppendEvent(ctx, created.Session, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := svc.Get(ctx, &GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := resp.Session.Events()
+	if _, ok := events.(QueryableEvents); !ok {
+		t.Fatalf("in-memory session events %T do not implement QueryableEvents", events)
+	}
+
+	ids := func(seq iter.Seq[*Event]) []string {
+		var ids []string
+		for e := range seq {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+	for _, tc := range []struct {
+		name string
+		q    EventQuery
+		want []string
+	}{
+		{name: "all", want: []string{"e0", "e1", "e2", "e3", "e4", "e5"}},
+		{name: "author", q: EventQuery{Author: "user"}, want: []string{"e0", "e3"}},
+		{name: "branch", q: EventQuery{Branch: "root.helper"}, want: []string{"e2"}},
+		{name: "parent branch", q: EventQuery{Branch: "root"}, want: []string{"e1", "e2", "e4", "e5"}},
+		{name: "since", q: EventQuery{Since: start.Add(3 * time.Minute)}, want: []string{"e3", "e4", "e5"}},
+		{name: "time range", q: EventQuery{Since: start.Add(time.Minute), Until: start.Add(3 * time.Minute)}, want: []string{"e1", "e2"}},
+		{name: "combined", q: EventQuery{Author: "root", Since: start.Add(30 * time.Second)}, want: []string{"e1", "e5"}},
+		{name: "empty range", q: EventQuery{Since: start.Add(time.Hour)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, ids(Query(events, tc.q))); diff != "" {
+				t.Errorf("Query() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want, ids(Query(scannedEvents{events}, tc.q))); diff != "" {
+				t.Errorf("Query() of scanned events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if diff := cmp.Diff([]string{"e2", "e4"}, ids(ByAuthor(events, "helper"))); diff != "" {
+		t.Errorf("ByAuthor() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"e4", "e5"}, ids(Since(events, start.Add(4*time.Minute)))); diff != "" {
+		t.Errorf("Since() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"e2"}, ids(InBranch(events, "root.helper"))); diff != "" {
+		t.Errorf("InBranch() mismatch (-want +got):\n%s", diff)
+	}
+}
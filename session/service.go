@@ -36,6 +36,7 @@ func InMemoryService() Service {
 	return &inMemoryService{
 		appState:  make(map[string]stateMap),
 		userState: make(map[string]map[string]stateMap),
+		policies:  make(map[string]ExpiryPolicy),
 	}
 }
 
@@ -67,8 +68,25 @@ type GetRequest struct {
 	// After returns events with timestamp >= the given time.
 	// Optional: if zero, the filter is not applied.
 	After time.Time
+	// Consistency of the read, honored by the distributed services, e.g.
+	// the ones reading from replicas.
+	// Optional: if empty, the service uses its default consistency.
+	Consistency Consistency
 }
 
+// Consistency is the consistency of the reads of the sessions.
+type Consistency string
+
+const (
+	// ConsistencyEventual allows the read to miss the latest writes, e.g.
+	// when it is served by a replica which lags behind.
+	ConsistencyEventual Consistency = "eventual"
+	// ConsistencyStrong makes the read reflect all the writes which
+	// completed before it, e.g. the events appended by the previous turn
+	// (read-your-writes).
+	ConsistencyStrong Consistency = "strong"
+)
+
 // GetResponse represents a response from [Service.Get].
 type GetResponse struct {
 	Session Session
@@ -16,10 +16,12 @@ package session
 
 import (
 	"errors"
+	"fmt"
 	"iter"
 	"time"
 
 	"github.com/google/uuid"
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/model"
 )
 
@@ -96,6 +98,11 @@ type Event struct {
 
 	// Set by agent.Context implementation.
 	InvocationID string
+	// ParentInvocationID is the ID of the invocation which started the
+	// invocation of this event, e.g. the invocation of an agent which called
+	// this agent as a tool. The agent transfers don't start an invocation.
+	// Empty for root invocations.
+	ParentInvocationID string
 	// The branch of the event.
 	//
 	// The format is like agent_1.agent_2.agent_3, where agent_1 is
@@ -146,15 +153,113 @@ type EventActions struct {
 	// value is the version.
 	ArtifactDelta map[string]int64
 
-	// If true, it won't call model to summarize function response.
+	// If true, it won't call model to summarize function response: the
+	// function response event is the final response of the agent, and the
+	// raw response is returned to the user. Tools and after tool callbacks
+	// set it through tool.Context.Actions, e.g. for large structured
+	// results.
 	// Only valid for function response event.
 	SkipSummarization bool
 	// If set, the event transfers to the specified agent.
 	TransferToAgent string
 	// The agent is escalating to a higher level agent.
 	Escalate bool
+	// If set, the event content is a summary of the conversation generated
+	// for the specified agent when the control was transferred to it. The
+	// summary replaces the earlier events of other agents in the history of
+	// that agent.
+	HandoffSummaryFor string
+	// If set, the event records the summary of the history which the
+	// context window of the agent that authored it dropped. The event has no
+	// content.
+	Compaction *Compaction
+	// If set, the event is the summary of the model usage of the invocation,
+	// emitted by the runner after the agents finished. The event has no
+	// content.
+	InvocationSummary *InvocationSummary
+	// If set, the invocation failed and the event, emitted by the runner
+	// after the agents, classifies the failure. The event has no content.
+	Failure *Failure
+	// If true, the invocation completed with this event. Resumable runners
+	// record the end of the invocations, so that the interrupted ones can be
+	// told apart and resumed.
+	EndOfInvocation bool
+	// The credentials requested by the tools, keyed by the function call ID
+	// of the tool. Only valid for function response event.
+	RequestedAuthConfigs map[string]*auth.Config
 }
 
+// FailureCategory is the category of the failure of an invocation, see
+// [Failure].
+type FailureCategory string
+
+const (
+	// FailureModel: the model call failed or the model returned no usable
+	// response.
+	FailureModel FailureCategory = "model_error"
+	// FailureTool: a tool or a toolset couldn't be called.
+	FailureTool FailureCategory = "tool_error"
+	// FailureGuardrail: the prompt or the response was blocked, e.g. by the
+	// safety filters of the model.
+	FailureGuardrail FailureCategory = "guardrail_block"
+	// FailureBudget: the invocation ran out of its token budget or of the
+	// context window of the model.
+	FailureBudget FailureCategory = "budget"
+	// FailureBug: any other failure, e.g. a misconfigured agent.
+	FailureBug FailureCategory = "bug"
+)
+
+// Failure describes why an invocation failed, so that the incidents can be
+// triaged from dashboards.
+type Failure struct {
+	Category FailureCategory
+	// Agent is the agent which failed.
+	Agent string
+	// Code is the error code of the event which ended the invocation, e.g.
+	// "SAFETY", empty if the invocation ended with an error.
+	Code string
+	// Message is the error message.
+	Message string
+}
+
+// Compaction is the summary of the history dropped by the context window of
+// an agent, see [EventActions.Compaction].
+type Compaction struct {
+	// Contents is the number of history contents of the agent, from the
+	// beginning of the session, which the summary covers.
+	Contents int
+	// Summary is the text of the summary.
+	Summary string
+}
+
+// InvocationSummary aggregates the model usage of an invocation per agent.
+type InvocationSummary struct {
+	// Usage of the agents which called a model, in the order of their first
+	// model call.
+	Agents []AgentUsage
+	// Total usage of all the agents.
+	Total AgentUsage
+}
+
+// AgentUsage is the model usage of an agent within an invocation.
+type AgentUsage struct {
+	// Name of the agent, empty for the total.
+	Agent string
+	// Number of model responses with usage metadata.
+	ModelCalls       int
+	PromptTokens     int64
+	CandidatesTokens int64
+	TotalTokens      int64
+	// Cost in USD, zero if the runner has no cost function.
+	Cost float64
+}
+
+// DeveloperAuthor is the author of the developer messages: messages
+// injected by the application between turns, e.g. "the user's subscription
+// was upgraded". The model sees them as context, distinct from the user
+// input.
+const DeveloperAuthor = "developer"
+
 // Prefixes for defining session's state scopes
 const (
 	// KeyPrefixApp is the prefix for app-level state keys.
@@ -174,6 +279,44 @@ const (
 // ErrStateKeyNotExist is the error thrown when key does not exist.
 var ErrStateKeyNotExist = errors.New("state key does not exist")
 
+// ErrSessionNotFound is wrapped by the errors of the services when the
+// session doesn't exist.
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrConflict is matched by the [*ConflictError] of AppendEvent.
+var ErrConflict = errors.New("session conflict")
+
+// Versioned is implemented by the sessions of the services which check the
+// version of the session on AppendEvent: an event can only be appended to a
+// session which is up to date with the stored session. If another runner,
+// e.g. another replica of the server, appended an event since the session
+// was read, AppendEvent returns a [*ConflictError] instead of interleaving
+// the events of the invocations. The session must then be read again.
+type Versioned interface {
+	// Version identifies the stored state of the session the session was
+	// read at, or last appended to, e.g. an event count or an update time.
+	Version() string
+}
+
+// ConflictError is returned by AppendEvent when the session was modified
+// since it was read, see [Versioned].
+type ConflictError struct {
+	AppName   string
+	UserID    string
+	SessionID string
+	// Version is the version of the session the event was appended to, and
+	// Current the version of the stored session.
+	Version string
+	Current string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("session %q was modified concurrently: appending to version %s, stored version is %s", e.SessionID, e.Version, e.Current)
+}
+
+// Is makes errors.Is(err, ErrConflict) match the conflict errors.
+func (e *ConflictError) Is(target error) bool { return target == ErrConflict }
+
 func hasFunctionCalls(resp *model.LLMResponse) bool {
 	if resp == nil || resp.Content == nil {
 		return false
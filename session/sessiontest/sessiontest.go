@@ -0,0 +1,423 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sessiontest provides a conformance test suite for the
+// implementations of [session.Service], e.g. the services backed by other
+// databases, so that they can check that they behave like the services of
+// this module.
+//
+// Run the suite from a test of the implementation:
+//
+//	func TestConformance(t *testing.T) {
+//		sessiontest.Run(t, func(t *testing.T) session.Service {
+//			return newTestService(t)
+//		})
+//	}
+package sessiontest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// Run runs the conformance suite against the services created by
+// newService, one per subtest. The subtests use distinct app names, so the
+// services may share their storage.
+func Run(t *testing.T, newService func(t *testing.T) session.Service) {
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, s *suite)
+	}{
+		{"Create", testCreate},
+		{"CreateGeneratedID", testCreateGeneratedID},
+		{"CreateDuplicate", testCreateDuplicate},
+		{"GetNotFound", testGetNotFound},
+		{"AppendEvent", testAppendEvent},
+		{"StateDelta", testStateDelta},
+		{"GetFilters", testGetFilters},
+		{"List", testList},
+		{"Delete", testDelete},
+		{"StaleSession", testStaleSession},
+		{"Expiry", testExpiry},
+		{"ExpireBefore", testExpireBefore},
+		{"ConcurrentSessions", testConcurrentSessions},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, &suite{
+				svc:     newService(t),
+				appName: "sessiontest_" + strings.ToLower(tc.name),
+			})
+		})
+	}
+}
+
+type suite struct {
+	svc     session.Service
+	appName string
+}
+
+func (s *suite) create(t *testing.T, userID, sessionID string, state map[string]any) session.Session {
+	t.Helper()
+	resp, err := s.svc.Create(t.Context(), &session.CreateRequest{AppName: s.appName, UserID: userID, SessionID: sessionID, State: state})
+	if err != nil {
+		t.Fatalf("Create(%q, %q) error = %v", userID, sessionID, err)
+	}
+	return resp.Session
+}
+
+func (s *suite) get(t *testing.T, userID, sessionID string) session.Session {
+	t.Helper()
+	resp, err := s.svc.Get(t.Context(), &session.GetRequest{AppName: s.appName, UserID: userID, SessionID: sessionID, Consistency: session.ConsistencyStrong})
+	if err != nil {
+		t.Fatalf("Get(%q, %q) error = %v", userID, sessionID, err)
+	}
+	return resp.Session
+}
+
+func (s *suite) append(t *testing.T, sess session.Session, event *session.Event) {
+	t.Helper()
+	if err := s.svc.AppendEvent(t.Context(), sess, event); err != nil {
+		t.Fatalf("AppendEvent(%q) error = %v", event.ID, err)
+	}
+}
+
+// start is the timestamp of the first event of the sessions. The services
+// may compare the timestamps of the events with the update times of the
+// sessions, so it's after the creation of the sessions.
+var start = time.Now().Add(time.Minute).Truncate(time.Second)
+
+// newEvent returns an event with the text, timestamped i seconds after
+// start.
+func newEvent(i int, text string) *session.Event {
+	ev := session.NewEvent("invocation")
+	ev.ID = fmt.Sprintf("event_%d", i)
+	ev.Author = "agent"
+	ev.Timestamp = start.Add(time.Duration(i) * time.Second)
+	ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel)}
+	return ev
+}
+
+// eventIDs returns the IDs of the events of the session, in order.
+func eventIDs(sess session.Session) []string {
+	var ids []string
+	for ev := range sess.Events().All() {
+		ids = append(ids, ev.ID)
+	}
+	return ids
+}
+
+func testCreate(t *testing.T, s *suite) {
+	created := s.create(t, "user", "session", map[string]any{"key": "value"})
+	if created.ID() != "session" || created.AppName() != s.appName || created.UserID() != "user" {
+		t.Errorf("Create() = session %q of app %q and user %q, want the requested IDs", created.ID(), created.AppName(), created.UserID())
+	}
+	got := s.get(t, "user", "session")
+	if v, err := got.State().Get("key"); err != nil || v != "value" {
+		t.Errorf("State().Get(key) = %v, %v, want the initial state", v, err)
+	}
+	if n := got.Events().Len(); n != 0 {
+		t.Errorf("new session has %d events, want 0", n)
+	}
+}
+
+func testCreateGeneratedID(t *testing.T, s *suite) {
+	first := s.create(t, "user", "", nil)
+	second := s.create(t, "user", "", nil)
+	if first.ID() == "" || first.ID() == second.ID() {
+		t.Fatalf("Create() generated IDs %q and %q, want distinct non-empty IDs", first.ID(), second.ID())
+	}
+	s.get(t, "user", first.ID())
+}
+
+func testCreateDuplicate(t *testing.T, s *suite) {
+	s.create(t, "user", "session", nil)
+	if _, err := s.svc.Create(t.Context(), &session.CreateRequest{AppName: s.appName, UserID: "user", SessionID: "session"}); err == nil {
+		t.Error("Create() of an existing session succeeded, want an error")
+	}
+}
+
+func testGetNotFound(t *testing.T, s *suite) {
+	_, err := s.svc.Get(t.Context(), &session.GetRequest{AppName: s.appName, UserID: "user", SessionID: "missing"})
+	if !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("Get() of a missing session error = %v, want %v", err, session.ErrSessionNotFound)
+	}
+}
+
+func testAppendEvent(t *testing.T, s *suite) {
+	sess := s.create(t, "user", "session", nil)
+	for i, text := range []string{"one", "two"} {
+		s.append(t, sess, newEvent(i, text))
+	}
+	partial := newEvent(2, "partial")
+	partial.Partial = true
+	s.append(t, sess, partial)
+	s.append(t, sess, newEvent(3, "three"))
+
+	if got, want := eventIDs(sess), []string{"event_0", "event_1", "event_3"}; !slices.Equal(got, want) {
+		t.Errorf("events of the appended session = %v, want %v without the partial event", got, want)
+	}
+	got := s.get(t, "user", "session")
+	if ids, want := eventIDs(got), []string{"event_0", "event_1", "event_3"}; !slices.Equal(ids, want) {
+		t.Fatalf("events of the stored session = %v, want %v without the partial event", ids, want)
+	}
+	ev := got.Events().At(1)
+	if ev.Author != "agent" || ev.InvocationID != "invocation" || !ev.Timestamp.Equal(start.Add(time.Second)) {
+		t.Errorf("stored event = author %q, invocation %q, timestamp %v, want the appended ones", ev.Author, ev.InvocationID, ev.Timestamp)
+	}
+	if ev.Content == nil || len(ev.Content.Parts) != 1 || ev.Content.Parts[0].Text != "two" {
+		t.Errorf("stored event content = %+v, want the text %q", ev.Content, "two")
+	}
+}
+
+func testStateDelta(t *testing.T, s *suite) {
+	sess := s.create(t, "user", "session", nil)
+	other := s.create(t, "user", "other", nil)
+	otherUser := s.create(t, "other_user", "session", nil)
+
+	ev := newEvent(0, "hi")
+	ev.Actions.StateDelta = map[string]any{
+		"session_key":                 "session",
+		session.KeyPrefixApp + "key":  "app",
+		session.KeyPrefixUser + "key": "user",
+		session.KeyPrefixTemp + "key": "temp",
+		"overwritten":                 "first",
+	}
+	s.append(t, sess, ev)
+	ev = newEvent(1, "again")
+	ev.Actions.StateDelta = map[string]any{"overwritten": "second"}
+	s.append(t, sess, ev)
+
+	for _, tc := range []struct {
+		userID, sessionID string
+		want              map[string]any
+	}{
+		{"user", "session", map[string]any{"session_key": "session", "app:key": "app", "user:key": "user", "overwritten": "second"}},
+		{"user", other.ID(), map[string]any{"app:key": "app", "user:key": "user"}},
+		{"other_user", otherUser.ID(), map[string]any{"app:key": "app"}},
+	} {
+		got := make(map[string]any)
+		for k, v := range s.get(t, tc.userID, tc.sessionID).State().All() {
+			got[k] = v
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("state of session %q of user %q = %v, want %v", tc.sessionID, tc.userID, got, tc.want)
+		}
+	}
+
+	stored := s.get(t, "user", "session").Events().At(0)
+	if _, ok := stored.Actions.StateDelta[session.KeyPrefixTemp+"key"]; ok {
+		t.Errorf("stored state delta = %v, want the temporary keys removed", stored.Actions.StateDelta)
+	}
+}
+
+func testGetFilters(t *testing.T, s *suite) {
+	s.create(t, "user", "session", nil)
+	sess := s.get(t, "user", "session")
+	for i := range 4 {
+		s.append(t, sess, newEvent(i, "text"))
+	}
+	for _, tc := range []struct {
+		name string
+		req  session.GetRequest
+		want []string
+	}{
+		{"NumRecentEvents", session.GetRequest{NumRecentEvents: 2}, []string{"event_2", "event_3"}},
+		{"After", session.GetRequest{After: start.Add(time.Second)}, []string{"event_1", "event_2", "event_3"}},
+	} {
+		req := tc.req
+		req.AppName, req.UserID, req.SessionID = s.appName, "user", "session"
+		resp, err := s.svc.Get(t.Context(), &req)
+		if err != nil {
+			t.Fatalf("Get() with %s error = %v", tc.name, err)
+		}
+		if got := eventIDs(resp.Session); !slices.Equal(got, tc.want) {
+			t.Errorf("Get() with %s events = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func testList(t *testing.T, s *suite) {
+	s.create(t, "user", "b", nil)
+	s.create(t, "user", "a", nil)
+	s.create(t, "other_user", "c", nil)
+
+	resp, err := s.svc.List(t.Context(), &session.ListRequest{AppName: s.appName, UserID: "user"})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var got []string
+	for _, sess := range resp.Sessions {
+		got = append(got, sess.ID())
+	}
+	slices.Sort(got)
+	if want := []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("List() = sessions %v, want the sessions %v of the user", got, want)
+	}
+}
+
+func testDelete(t *testing.T, s *suite) {
+	s.create(t, "user", "session", nil)
+	req := &session.DeleteRequest{AppName: s.appName, UserID: "user", SessionID: "session"}
+	if err := s.svc.Delete(t.Context(), req); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.svc.Get(t.Context(), &session.GetRequest{AppName: s.appName, UserID: "user", SessionID: "session"}); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("Get() of a deleted session error = %v, want %v", err, session.ErrSessionNotFound)
+	}
+	if err := s.svc.Delete(t.Context(), req); err != nil {
+		t.Errorf("Delete() of a missing session error = %v, want nil", err)
+	}
+}
+
+// testStaleSession checks that the services with versioned sessions reject
+// the events appended to a session read before another append.
+func testStaleSession(t *testing.T, s *suite) {
+	s.create(t, "user", "session", nil)
+	first := s.get(t, "user", "session")
+	if _, ok := first.(session.Versioned); !ok {
+		t.Skip("the sessions of the service aren't versioned")
+	}
+	second := s.get(t, "user", "session")
+	s.append(t, first, newEvent(0, "first"))
+	if err := s.svc.AppendEvent(t.Context(), second, newEvent(1, "second")); !errors.Is(err, session.ErrConflict) {
+		t.Errorf("AppendEvent() to a stale session error = %v, want %v", err, session.ErrConflict)
+	}
+}
+
+// testConcurrentSessions appends events to several sessions concurrently,
+// each of them updating the app state.
+func testConcurrentSessions(t *testing.T, s *suite) {
+	const sessions, events = 4, 5
+	var wg sync.WaitGroup
+	errs := make(chan error, sessions*events)
+	for i := range sessions {
+		sess := s.create(t, "user", fmt.Sprintf("session_%d", i), nil)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range events {
+				ev := newEvent(j, "text")
+				ev.Actions.StateDelta = map[string]any{fmt.Sprintf("%sfrom_%d", session.KeyPrefixApp, i): "done"}
+				if err := s.svc.AppendEvent(t.Context(), sess, ev); err != nil {
+					errs <- fmt.Errorf("AppendEvent() to session %d error = %w", i, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for i := range sessions {
+		sess := s.get(t, "user", fmt.Sprintf("session_%d", i))
+		if n := sess.Events().Len(); n != events {
+			t.Errorf("session %d has %d events, want %d", i, n, events)
+		}
+		for j := range sessions {
+			if v, err := sess.State().Get(fmt.Sprintf("%sfrom_%d", session.KeyPrefixApp, j)); err != nil || v != "done" {
+				t.Errorf("app state of session %d from session %d = %v, %v, want %q", i, j, v, err, "done")
+			}
+		}
+	}
+}
+
+func testExpiry(t *testing.T, s *suite) {
+	exp, ok := s.svc.(session.Expirer)
+	if !ok {
+		t.Skip("the service doesn't expire sessions")
+	}
+	s.create(t, "user", "session", nil)
+	sess := s.get(t, "user", "session")
+
+	exp.SetExpiryPolicy(s.appName, session.ExpiryPolicy{TTL: time.Nanosecond, Mode: session.ExpiryAbsolute})
+	if _, err := s.svc.Get(t.Context(), &session.GetRequest{AppName: s.appName, UserID: "user", SessionID: "session"}); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("Get() of an expired session error = %v, want %v", err, session.ErrSessionNotFound)
+	}
+	resp, err := s.svc.List(t.Context(), &session.ListRequest{AppName: s.appName, UserID: "user"})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(resp.Sessions) != 0 {
+		t.Errorf("List() returned %d sessions, want the expired session to be skipped", len(resp.Sessions))
+	}
+	if err := s.svc.AppendEvent(t.Context(), sess, newEvent(0, "late")); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("AppendEvent() to an expired session error = %v, want %v", err, session.ErrSessionNotFound)
+	}
+
+	// The ID of an expired session can be reused.
+	s.create(t, "user", "session", map[string]any{"key": "new"})
+	exp.SetExpiryPolicy(s.appName, session.ExpiryPolicy{})
+	got := s.get(t, "user", "session")
+	if v, err := got.State().Get("key"); err != nil || v != "new" || got.Events().Len() != 0 {
+		t.Errorf("Get() after recreating the expired session = state %v (%v) with %d events, want the new session", v, err, got.Events().Len())
+	}
+}
+
+func testExpireBefore(t *testing.T, s *suite) {
+	exp, ok := s.svc.(session.Expirer)
+	if !ok {
+		t.Skip("the service doesn't expire sessions")
+	}
+	now := time.Now()
+	s.create(t, "user", "idle", nil)
+	s.create(t, "user", "active", nil)
+	ev := newEvent(0, "recent")
+	ev.Timestamp = now.Add(30 * time.Minute)
+	s.append(t, s.get(t, "user", "active"), ev)
+
+	expireBefore := func(before time.Time, want int) {
+		t.Helper()
+		resp, err := exp.ExpireBefore(t.Context(), &session.ExpireBeforeRequest{AppName: s.appName, Before: before})
+		if err != nil {
+			t.Fatalf("ExpireBefore() error = %v", err)
+		}
+		if resp.Deleted != want {
+			t.Errorf("ExpireBefore(now%+v) deleted %d sessions, want %d", before.Sub(now).Round(time.Minute), resp.Deleted, want)
+		}
+	}
+	expireBefore(now.Add(80*time.Minute), 0)
+
+	// idle expires after 60 minutes and active after 90 minutes.
+	exp.SetExpiryPolicy(s.appName, session.ExpiryPolicy{TTL: time.Hour, Mode: session.ExpirySliding})
+	expireBefore(now, 0)
+	expireBefore(now.Add(80*time.Minute), 1)
+	if _, err := s.svc.Get(t.Context(), &session.GetRequest{AppName: s.appName, UserID: "user", SessionID: "idle"}); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("Get() of the deleted session error = %v, want %v", err, session.ErrSessionNotFound)
+	}
+
+	// active expires after 60 minutes, however recent its last update.
+	exp.SetExpiryPolicy(s.appName, session.ExpiryPolicy{TTL: time.Hour, Mode: session.ExpiryAbsolute})
+	expireBefore(now.Add(80*time.Minute), 1)
+
+	exp.SetExpiryPolicy(s.appName, session.ExpiryPolicy{})
+	resp, err := s.svc.List(t.Context(), &session.ListRequest{AppName: s.appName})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(resp.Sessions) != 0 {
+		t.Errorf("List() after ExpireBefore returned %d sessions, want 0", len(resp.Sessions))
+	}
+}
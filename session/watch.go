@@ -0,0 +1,243 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StateChange is a change of a state key made by an event appended to a
+// session.
+type StateChange struct {
+	AppName, UserID, SessionID string
+	// Key is the changed state key, including its scope prefix, e.g.
+	// "user:tier".
+	Key string
+	// Value is the new value of the key.
+	Value any
+	// Event is the event which made the change.
+	Event *Event
+}
+
+// StateWatcher notifies its subscribers of the state changes of the
+// sessions, so that they can react to the changes of particular keys
+// without diffing the state after every event. Use [WatchState] to make a
+// session service report the changes to the watcher.
+//
+// The zero value is ready to use.
+type StateWatcher struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   map[int]*stateSubscription
+}
+
+type stateSubscription struct {
+	prefixes []string
+	fn       func(StateChange)
+}
+
+// Watch subscribes fn to the changes of the keys which start with any of
+// the prefixes, or of all the keys if no prefix is given. fn is called
+// synchronously, once per changed key, after the event is appended and
+// before AppendEvent returns, so it must not block. The changes of an event
+// are reported in the order of their keys, to the subscribers in the order
+// of their subscription.
+//
+// The returned function cancels the subscription.
+func (w *StateWatcher) Watch(fn func(StateChange), prefixes ...string) (cancel func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.subs == nil {
+		w.subs = make(map[int]*stateSubscription)
+	}
+	id := w.nextID
+	w.nextID++
+	w.subs[id] = &stateSubscription{prefixes: slices.Clone(prefixes), fn: fn}
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.subs, id)
+	}
+}
+
+// Notify reports the state changes of the event appended to the session to
+// the subscribers.
+func (w *StateWatcher) Notify(s Session, event *Event) {
+	if s == nil || event == nil || len(event.Actions.StateDelta) == 0 {
+		return
+	}
+	w.mu.RLock()
+	subs := make([]*stateSubscription, 0, len(w.subs))
+	for _, id := range slices.Sorted(maps.Keys(w.subs)) {
+		subs = append(subs, w.subs[id])
+	}
+	w.mu.RUnlock()
+	if len(subs) == 0 {
+		return
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(event.Actions.StateDelta)) {
+		change := StateChange{
+			AppName:   s.AppName(),
+			UserID:    s.UserID(),
+			SessionID: s.ID(),
+			Key:       key,
+			Value:     event.Actions.StateDelta[key],
+			Event:     event,
+		}
+		for _, sub := range subs {
+			if sub.matches(key) {
+				sub.fn(change)
+			}
+		}
+	}
+}
+
+func (s *stateSubscription) matches(key string) bool {
+	if len(s.prefixes) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(s.prefixes, func(p string) bool { return strings.HasPrefix(key, p) })
+}
+
+// WatchState returns the session service which reports the state changes
+// of the events appended by svc to the watcher.
+func WatchState(svc Service, w *StateWatcher) Service {
+	return &watchedService{Service: svc, watcher: w}
+}
+
+type watchedService struct {
+	Service
+	watcher *StateWatcher
+}
+
+func (s *watchedService) AppendEvent(ctx context.Context, sess Session, event *Event) error {
+	if err := s.Service.AppendEvent(ctx, sess, event); err != nil {
+		return err
+	}
+	if !event.Partial {
+		s.watcher.Notify(sess, event)
+	}
+	return nil
+}
+
+// DefaultWatchPollInterval is the interval between two reads of the
+// session by [Watch] when WatchRequest.PollInterval is not set.
+const DefaultWatchPollInterval = time.Second
+
+// WatchRequest represents a request to follow the events appended to a
+// session.
+type WatchRequest struct {
+	AppName   string
+	UserID    string
+	SessionID string
+
+	// After streams the stored events with timestamp >= After first.
+	// Optional: if zero, only the events appended after the call are
+	// streamed.
+	After time.Time
+	// PollInterval is the interval between two reads of the session when
+	// the service is polled.
+	// Optional: if zero, DefaultWatchPollInterval is used.
+	PollInterval time.Duration
+}
+
+// EventWatcher is implemented by the services which notify the watchers of
+// a session of its appended events, rather than being polled by [Watch].
+// The in-memory service implements it.
+type EventWatcher interface {
+	WatchEvents(context.Context, *WatchRequest) iter.Seq2[*Event, error]
+}
+
+// Watch returns the events appended to the session, e.g. by the runner of
+// another process, as they are committed, so that a UI can follow the
+// session. The iteration ends when ctx is done, and with an error wrapping
+// [ErrSessionNotFound] when the session is deleted or expires.
+//
+// If the service implements [EventWatcher], it notifies the iteration of
+// the appended events; otherwise, the session is read with Get every
+// req.PollInterval.
+func Watch(ctx context.Context, svc Service, req *WatchRequest) iter.Seq2[*Event, error] {
+	if w, ok := svc.(EventWatcher); ok {
+		return w.WatchEvents(ctx, req)
+	}
+	return func(yield func(*Event, error) bool) {
+		interval := req.PollInterval
+		if interval <= 0 {
+			interval = DefaultWatchPollInterval
+		}
+		get := func(after time.Time) ([]*Event, error) {
+			resp, err := svc.Get(ctx, &GetRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, After: after})
+			if err != nil {
+				return nil, err
+			}
+			return slices.Collect(resp.Session.Events().All()), nil
+		}
+
+		// after is the timestamp of the last streamed event, and seen the IDs
+		// of the streamed events with that timestamp, which Get returns
+		// again.
+		after, seen := req.After, map[string]bool{}
+		skip := func(events []*Event) {
+			for _, ev := range events {
+				if !ev.Timestamp.Equal(after) {
+					after, seen = ev.Timestamp, map[string]bool{}
+				}
+				seen[ev.ID] = true
+			}
+		}
+		if after.IsZero() {
+			events, err := get(time.Time{})
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to watch session %q: %w", req.SessionID, err))
+				return
+			}
+			skip(events)
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			events, err := get(after)
+			if err != nil {
+				if ctx.Err() == nil {
+					yield(nil, fmt.Errorf("failed to watch session %q: %w", req.SessionID, err))
+				}
+				return
+			}
+			for _, ev := range events {
+				if ev.Timestamp.Equal(after) && seen[ev.ID] {
+					continue
+				}
+				skip([]*Event{ev})
+				if !yield(ev, nil) {
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWatchState(t *testing.T) {
+	ctx := t.Context()
+	var watcher StateWatcher
+	svc := WatchState(InMemoryService(), &watcher)
+
+	var orders, all []string
+	stopOrders := watcher.Watch(func(c StateChange) {
+		orders = append(orders, c.SessionID+" "+c.Key+"="+c.Value.(string))
+	}, "order_", "user:order_")
+	watcher.Watch(func(c StateChange) { all = append(all, c.Key) })
+
+	created, err := svc.Create(ctx, &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := created.Session
+	appendDelta := func(delta map[string]any) {
+		t.Helper()
+		ev := NewEvent("inv")
+		ev.Actions.StateDelta = delta
+		if err := svc.AppendEvent(ctx, sess, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appendDelta(map[string]any{"order_status": "shipped", "user:order_count": "3", "note": "hi"})
+	appendDelta(nil)
+	stopOrders()
+	appendDelta(map[string]any{"order_status": "delivered"})
+
+	if diff := cmp.Diff([]string{"s1 order_status=shipped", "s1 user:order_count=3"}, orders); diff != "" {
+		t.Errorf("order changes mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"note", "order_status", "user:order_count", "order_status"}, all); diff != "" {
+		t.Errorf("all changes mismatch (-want +got):\n%s", diff)
+	}
+
+	// Failed appends are not reported.
+	if err := svc.AppendEvent(ctx, sess, nil); err == nil {
+		t.Error("AppendEvent(nil) succeeded, want error")
+	}
+	if len(all) != 4 {
+		t.Errorf("got %d changes after a failed append, want 4", len(all))
+	}
+}
+
+func TestWatch(t *testing.T) {
+	for name, newService := range map[string]func() Service{
+		"notified": InMemoryService,
+		"polled":   func() Service { return unpagedService{InMemoryService()} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := t.Context()
+			svc := newService()
+			created, err := svc.Create(ctx, &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sess := created.Session
+			start := time.Now()
+			appendEvent := func(i int) {
+				t.Helper()
+				ev := NewEvent("inv")
+				ev.ID = fmt.Sprintf("e%d", i)
+				ev.Timestamp = start.Add(time.Duration(i) * time.Second)
+				if err := svc.AppendEvent(ctx, sess, ev); err != nil {
+					t.Fatal(err)
+				}
+			}
+			appendEvent(0)
+
+			type result struct {
+				id  string
+				err error
+			}
+			results := make(chan result)
+			go func() {
+				defer close(results)
+				req := &WatchRequest{AppName: "app", UserID: "user", SessionID: "s1", After: start.Add(time.Second), PollInterval: 10 * time.Millisecond}
+				for ev, err := range Watch(ctx, svc, req) {
+					r := result{err: err}
+					if ev != nil {
+						r.id = ev.ID
+					}
+					results <- r
+				}
+			}()
+			next := func() result {
+				t.Helper()
+				select {
+				case r := <-results:
+					return r
+				case <-time.After(5 * time.Second):
+					t.Fatal("timed out waiting for the watched event")
+					return result{}
+				}
+			}
+
+			appendEvent(1)
+			appendEvent(2)
+			for _, want := range []string{"e1", "e2"} {
+				if got := next(); got.id != want || got.err != nil {
+					t.Errorf("Watch() = %+v, want event %s", got, want)
+				}
+			}
+			appendEvent(3)
+			if got := next(); got.id != "e3" || got.err != nil {
+				t.Errorf("Watch() = %+v, want event e3", got)
+			}
+
+			if err := svc.Delete(ctx, &DeleteRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+				t.Fatal(err)
+			}
+			if got := next(); !errors.Is(got.err, ErrSessionNotFound) {
+				t.Errorf("Watch() after Delete = %+v, want %v", got, ErrSessionNotFound)
+			}
+			if r, ok := <-results; ok {
+				t.Errorf("Watch() continued after the error with %+v", r)
+			}
+		})
+	}
+}
+
+func TestWatch_Expiry(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		ttl    time.Duration
+		expire bool
+	}{
+		{name: "swept", ttl: time.Hour, expire: true},
+		{name: "lapsed", ttl: 50 * time.Millisecond},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := t.Context()
+			svc := InMemoryService()
+			exp := svc.(Expirer)
+			exp.SetExpiryPolicy("app", ExpiryPolicy{TTL: tc.ttl})
+			if _, err := svc.Create(ctx, &CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+				t.Fatal(err)
+			}
+
+			errs := make(chan error, 1)
+			go func() {
+				for _, err := range Watch(ctx, svc, &WatchRequest{AppName: "app", UserID: "user", SessionID: "s1"}) {
+					if err != nil {
+						errs <- err
+						return
+					}
+				}
+				errs <- nil
+			}()
+			if tc.expire {
+				// Let the watch start before the sweep.
+				time.Sleep(10 * time.Millisecond)
+				resp, err := exp.ExpireBefore(ctx, &ExpireBeforeRequest{AppName: "app", Before: time.Now().Add(2 * tc.ttl)})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.Deleted != 1 {
+					t.Fatalf("ExpireBefore() deleted %d sessions, want 1", resp.Deleted)
+				}
+			}
+			select {
+			case err := <-errs:
+				if !errors.Is(err, ErrSessionNotFound) {
+					t.Errorf("Watch() ended with %v, want %v", err, ErrSessionNotFound)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Watch() didn't end after the session expired")
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package summarizer defines the summarizer of the conversations, shared by
+// the compaction of the history which no longer fits the context window,
+// the handoff summaries on agent transfer, the ingestion of the sessions
+// into memory and the session titles, so that the summaries are configured
+// in one place.
+package summarizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// Purpose is what a summary is used for. The summarizers may adapt the
+// summary to it, e.g. the default summarizer picks the instruction for the
+// model by purpose.
+type Purpose string
+
+const (
+	// PurposeCompaction summarizes the history which no longer fits the
+	// context window of an agent.
+	PurposeCompaction Purpose = "compaction"
+	// PurposeHandoff summarizes the conversation for the target agent of a
+	// transfer.
+	PurposeHandoff Purpose = "handoff"
+	// PurposeMemory summarizes a session for the memory service.
+	PurposeMemory Purpose = "memory"
+	// PurposeTitle summarizes a session as a short title.
+	PurposeTitle Purpose = "title"
+	// PurposeToolResult summarizes a function response too large to be
+	// sent to the model.
+	PurposeToolResult Purpose = "tool_result"
+)
+
+// Default instructions of the summarizer returned by New, by purpose.
+const (
+	DefaultCompactionInstruction = "Write a compact summary of the conversation above, " +
+		"keeping the facts, decisions, tool results and open questions needed to continue it. " +
+		"Reply with the summary only."
+	DefaultHandoffInstruction = "Write a compact summary of the conversation so far, " +
+		"keeping only the facts, decisions and open questions relevant to that agent. " +
+		"Reply with the summary only."
+	DefaultMemoryInstruction = "Write a compact summary of the conversation above, " +
+		"keeping the facts about the user, their preferences and the outcomes worth remembering in later conversations. " +
+		"Reply with the summary only."
+	DefaultTitleInstruction = "Write a title of at most eight words for the conversation above. " +
+		"Reply with the title only."
+	DefaultToolResultInstruction = "Write a compact summary of the tool result above, " +
+		"keeping the values, identifiers and errors needed to use it. " +
+		"Reply with the summary only."
+)
+
+// PreviousSummaryPrefix introduces the previous summary in the contents
+// sent to the model.
+const PreviousSummaryPrefix = "Summary of the earlier conversation:\n"
+
+// Request is a request for a summary of contents.
+type Request struct {
+	Purpose Purpose
+	// Contents to summarize.
+	Contents []*genai.Content
+	// Previous is the summary of the conversation which preceded the
+	// contents. The summary of the contents extends it.
+	// Optional.
+	Previous string
+	// Context describes the circumstances of the summary, e.g. the target
+	// agent of a handoff.
+	// Optional.
+	Context string
+}
+
+// SessionRequest returns the request for the summary of the events of the
+// session with the given purpose, e.g. PurposeMemory or PurposeTitle.
+func SessionRequest(s session.Session, purpose Purpose) *Request {
+	req := &Request{Purpose: purpose}
+	for event := range s.Events().All() {
+		if event.Content != nil && len(event.Content.Parts) > 0 {
+			req.Contents = append(req.Contents, event.Content)
+		}
+	}
+	return req
+}
+
+// Summarizer summarizes conversations. Implementations must be safe for
+// concurrent use.
+type Summarizer interface {
+	// Summarize returns the summary of the request contents. An empty
+	// summary means there is nothing to summarize.
+	Summarize(ctx context.Context, req *Request) (string, error)
+}
+
+// Config is the configuration of the summarizer returned by New.
+type Config struct {
+	// Model generates the summaries.
+	Model model.LLM
+	// Instructions tell the model what to keep in the summaries, by
+	// purpose.
+	// Optional: the purposes without instruction use the default ones, e.g.
+	// DefaultCompactionInstruction.
+	Instructions map[Purpose]string
+}
+
+// New returns a Summarizer which asks the model for the summaries.
+func New(cfg Config) (Summarizer, error) {
+	if cfg.Model == nil {
+		return nil, errors.New("summarizer model is required")
+	}
+	return &modelSummarizer{model: cfg.Model, instructions: cfg.Instructions}, nil
+}
+
+type modelSummarizer struct {
+	model        model.LLM
+	instructions map[Purpose]string
+}
+
+func (s *modelSummarizer) Summarize(ctx context.Context, req *Request) (string, error) {
+	var contents []*genai.Content
+	if req.Previous != "" {
+		contents = append(contents, genai.NewContentFromText(PreviousSummaryPrefix+req.Previous, genai.RoleUser))
+	}
+	contents = append(contents, req.Contents...)
+	prompt := s.instruction(req.Purpose)
+	if req.Context != "" {
+		prompt = req.Context + "\n\n" + prompt
+	}
+	contents = append(contents, genai.NewContentFromText(prompt, genai.RoleUser))
+
+	var sb strings.Builder
+	for resp, err := range s.model.GenerateContent(ctx, &model.LLMRequest{Model: s.model.Name(), Contents: contents}, false) {
+		if err != nil {
+			return "", fmt.Errorf("failed to generate %s summary: %w", req.Purpose, err)
+		}
+		if resp.Content == nil {
+			continue
+		}
+		for _, p := range resp.Content.Parts {
+			if !p.Thought {
+				sb.WriteString(p.Text)
+			}
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func (s *modelSummarizer) instruction(p Purpose) string {
+	if instruction := s.instructions[p]; instruction != "" {
+		return instruction
+	}
+	switch p {
+	case PurposeHandoff:
+		return DefaultHandoffInstruction
+	case PurposeMemory:
+		return DefaultMemoryInstruction
+	case PurposeTitle:
+		return DefaultTitleInstruction
+	case PurposeToolResult:
+		return DefaultToolResultInstruction
+	default:
+		return DefaultCompactionInstruction
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summarizer_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/summarizer"
+	"google.golang.org/genai"
+)
+
+func TestNew(t *testing.T) {
+	if _, err := summarizer.New(summarizer.Config{}); err == nil {
+		t.Error("New() without model succeeded, want error")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	contents := []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}
+	tests := []struct {
+		name         string
+		instructions map[summarizer.Purpose]string
+		req          *summarizer.Request
+		want         []*genai.Content
+	}{
+		{
+			name: "default instruction",
+			req:  &summarizer.Request{Purpose: summarizer.PurposeTitle, Contents: contents},
+			want: []*genai.Content{
+				genai.NewContentFromText("hi", genai.RoleUser),
+				genai.NewContentFromText(summarizer.DefaultTitleInstruction, genai.RoleUser),
+			},
+		},
+		{
+			name:         "configured instruction",
+			instructions: map[summarizer.Purpose]string{summarizer.PurposeCompaction: "summarize"},
+			req:          &summarizer.Request{Purpose: summarizer.PurposeCompaction, Contents: contents, Previous: "earlier"},
+			want: []*genai.Content{
+				genai.NewContentFromText(summarizer.PreviousSummaryPrefix+"earlier", genai.RoleUser),
+				genai.NewContentFromText("hi", genai.RoleUser),
+				genai.NewContentFromText("summarize", genai.RoleUser),
+			},
+		},
+		{
+			name: "context",
+			req:  &summarizer.Request{Purpose: summarizer.PurposeHandoff, Contents: contents, Context: "For agent b."},
+			want: []*genai.Content{
+				genai.NewContentFromText("hi", genai.RoleUser),
+				genai.NewContentFromText("For agent b.\n\n"+summarizer.DefaultHandoffInstruction, genai.RoleUser),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText(" the summary\n", genai.RoleModel)}}
+			s, err := summarizer.New(summarizer.Config{Model: llm, Instructions: tt.instructions})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Summarize(t.Context(), tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != "the summary" {
+				t.Errorf("Summarize() = %q, want %q", got, "the summary")
+			}
+			if diff := cmp.Diff(tt.want, llm.Requests[0].Contents); diff != "" {
+				t.Errorf("model request contents mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
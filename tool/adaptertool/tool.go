@@ -0,0 +1,160 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adaptertool adapts the tools of other ecosystems, e.g. the
+// langchaingo tools, to tool.Tool, so that existing tool libraries can be
+// given to the agents without rewriting them.
+package adaptertool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/internal/toolinternal/toolutils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// Tool is the contract of the tools of other ecosystems: a named function
+// called with a text input, e.g. the langchaingo tools.Tool interface.
+type Tool interface {
+	Name() string
+	Description() string
+	Call(ctx context.Context, input string) (string, error)
+}
+
+// Schema is implemented by the tools which take JSON input described by a
+// JSON schema. The arguments of the model are then passed to Call as a JSON
+// object. The tools without a schema take a single "input" string argument,
+// passed to Call as it is.
+type Schema interface {
+	// InputSchema returns the JSON schema of the input, e.g. a
+	// *jsonschema.Schema or a map[string]any.
+	InputSchema() any
+}
+
+// inputArg is the argument of the tools without schema.
+const inputArg = "input"
+
+// New returns a tool calling t. The result of the call is returned to the
+// model as {"output": <result>}; when the result is a JSON object or array,
+// it is decoded, so that the model receives structured data.
+func New(t Tool) (tool.Tool, error) {
+	if t == nil {
+		return nil, errors.New("adaptertool: tool is nil")
+	}
+	if t.Name() == "" {
+		return nil, errors.New("adaptertool: tool has no name")
+	}
+	a := &adapter{tool: t}
+	decl := &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+	}
+	if s, ok := t.(Schema); ok && s.InputSchema() != nil {
+		decl.ParametersJsonSchema = s.InputSchema()
+		a.jsonInput = true
+	} else {
+		decl.ParametersJsonSchema = map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				inputArg: map[string]any{"type": "string", "description": "The input of the tool."},
+			},
+			"required": []string{inputArg},
+		}
+	}
+	a.decl = decl
+	return a, nil
+}
+
+type adapter struct {
+	tool      Tool
+	decl      *genai.FunctionDeclaration
+	jsonInput bool
+}
+
+// Name implements the tool.Tool.
+func (a *adapter) Name() string {
+	return a.tool.Name()
+}
+
+// Description implements the tool.Tool.
+func (a *adapter) Description() string {
+	return a.tool.Description()
+}
+
+// IsLongRunning implements the tool.Tool.
+func (a *adapter) IsLongRunning() bool {
+	return false
+}
+
+func (a *adapter) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	return toolutils.PackTool(req, a)
+}
+
+func (a *adapter) Declaration() *genai.FunctionDeclaration {
+	return a.decl
+}
+
+func (a *adapter) Run(ctx tool.Context, args any) (map[string]any, error) {
+	input, err := a.input(args)
+	if err != nil {
+		return nil, err
+	}
+	output, err := a.tool.Call(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := strings.TrimSpace(output); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var decoded any
+		if err := json.Unmarshal([]byte(trimmed), &decoded); err == nil {
+			return map[string]any{"output": decoded}, nil
+		}
+	}
+	return map[string]any{"output": output}, nil
+}
+
+// input returns the input of the call of the tool with the arguments of the
+// model.
+func (a *adapter) input(args any) (string, error) {
+	if a.jsonInput {
+		if args == nil {
+			args = map[string]any{}
+		}
+		b, err := json.Marshal(args)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode the arguments of tool %q: %w", a.Name(), err)
+		}
+		return string(b), nil
+	}
+	m, ok := args.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("unexpected arguments of tool %q: %T", a.Name(), args)
+	}
+	input, ok := m[inputArg].(string)
+	if !ok {
+		return "", fmt.Errorf("tool %q requires the string argument %q", a.Name(), inputArg)
+	}
+	return input, nil
+}
+
+var (
+	_ toolinternal.FunctionTool     = (*adapter)(nil)
+	_ toolinternal.RequestProcessor = (*adapter)(nil)
+)
@@ -0,0 +1,141 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptertool_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/adaptertool"
+	"google.golang.org/genai"
+)
+
+// calculator is a langchaingo-style tool taking a text input.
+type calculator struct{}
+
+func (calculator) Name() string        { return "calculator" }
+func (calculator) Description() string { return "Evaluates an arithmetic expression." }
+func (calculator) Call(ctx context.Context, input string) (string, error) {
+	if input != "2 + 2" {
+		return "", errors.New("unsupported expression")
+	}
+	return "4", nil
+}
+
+// weather takes JSON input described by a schema.
+type weather struct{}
+
+func (weather) Name() string        { return "weather" }
+func (weather) Description() string { return "Returns the weather of a city." }
+func (weather) InputSchema() any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"city": map[string]any{"type": "string"}},
+	}
+}
+func (weather) Call(ctx context.Context, input string) (string, error) {
+	var args struct{ City string }
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		return "", err
+	}
+	return `{"city": "` + args.City + `", "forecast": "sunny"}`, nil
+}
+
+func TestAdapter(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		tool       adaptertool.Tool
+		args       map[string]any
+		wantSchema any
+		want       map[string]any
+	}{
+		{
+			name: "text input",
+			tool: calculator{},
+			args: map[string]any{"input": "2 + 2"},
+			wantSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"input": map[string]any{"type": "string", "description": "The input of the tool."}},
+				"required":   []string{"input"},
+			},
+			want: map[string]any{"output": "4"},
+		},
+		{
+			name:       "JSON input",
+			tool:       weather{},
+			args:       map[string]any{"city": "Paris"},
+			wantSchema: weather{}.InputSchema(),
+			want:       map[string]any{"output": map[string]any{"city": "Paris", "forecast": "sunny"}},
+		},
+		{
+			name: "error",
+			tool: calculator{},
+			args: map[string]any{"input": "2 / 0"},
+			wantSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"input": map[string]any{"type": "string", "description": "The input of the tool."}},
+				"required":   []string{"input"},
+			},
+			want: map[string]any{"error": `tool "calculator" failed: unsupported expression`},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			adapted, err := adaptertool.New(tc.tool)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := &testutil.MockModel{Responses: []*genai.Content{
+				genai.NewContentFromFunctionCall(tc.tool.Name(), tc.args, genai.RoleModel),
+				genai.NewContentFromText("done", genai.RoleModel),
+			}}
+			a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, Tools: []tool.Tool{adapted}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session_id", "hi")); err != nil {
+				t.Fatal(err)
+			}
+
+			decls := m.Requests[0].Config.Tools[0].FunctionDeclarations
+			if len(decls) != 1 || decls[0].Name != tc.tool.Name() || !strings.HasPrefix(decls[0].Description, tc.tool.Description()) {
+				t.Fatalf("function declarations = %+v, want the declaration of %q", decls, tc.tool.Name())
+			}
+			if diff := cmp.Diff(tc.wantSchema, decls[0].ParametersJsonSchema); diff != "" {
+				t.Errorf("parameters schema mismatch (-want +got):\n%s", diff)
+			}
+			contents := m.Requests[1].Contents
+			resp := contents[len(contents)-1].Parts[0].FunctionResponse
+			if err, ok := resp.Response["error"].(error); ok {
+				resp.Response["error"] = err.Error()
+			}
+			if diff := cmp.Diff(tc.want, resp.Response); diff != "" {
+				t.Errorf("function response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	if _, err := adaptertool.New(nil); err == nil {
+		t.Error("New(nil) succeeded, want an error")
+	}
+}
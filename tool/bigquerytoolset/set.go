@@ -0,0 +1,170 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bigquerytoolset provides a toolset with which the agents explore
+// and query BigQuery: list the datasets and the tables, get their metadata
+// and schema, and execute SQL queries.
+//
+// The queries are dry-run first: they are rejected if they would process
+// more than Config.MaxBytesBilled, or, unless Config.AllowWrites is set, if
+// they aren't SELECT statements. The results are returned as columns and
+// rows, at most Config.MaxRows of them.
+//
+// By default the toolset uses the credentials of the application. With
+// Config.Auth, each user authorizes the access with OAuth2 and the queries
+// run with their credential, see tool.Context.RequestCredential.
+package bigquerytoolset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/toolinternal/toolutils"
+	"google.golang.org/adk/tool"
+	bigquery "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+)
+
+// Default values of the [Config] fields.
+const (
+	DefaultMaxRows = 50
+)
+
+// Names of the tools.
+const (
+	ListDatasetIDsTool = "list_dataset_ids"
+	GetDatasetInfoTool = "get_dataset_info"
+	ListTableIDsTool   = "list_table_ids"
+	GetTableInfoTool   = "get_table_info"
+	ExecuteSQLTool     = "execute_sql"
+)
+
+// Config is used to create the toolset.
+type Config struct {
+	// ProjectID is the project of the queries, billed for them, and the
+	// default project of the tools.
+	ProjectID string
+	// Location is the location of the query jobs, e.g. "US".
+	// Optional: BigQuery infers it from the tables.
+	Location string
+	// MaxRows is the maximum number of rows returned by a query.
+	// Optional: defaults to [DefaultMaxRows].
+	MaxRows int
+	// MaxBytesBilled is the maximum number of bytes a query may process,
+	// according to its dry run.
+	// Optional: if zero, the queries are not limited.
+	MaxBytesBilled int64
+	// AllowWrites allows the statements other than SELECT, e.g. INSERT or
+	// CREATE TABLE.
+	// Optional: by default the queries are read-only.
+	AllowWrites bool
+	// Auth is the OAuth2 credential the tools request from each user, e.g.
+	// with the scope "https://www.googleapis.com/auth/bigquery". The tools
+	// call BigQuery with the access token of the user.
+	// Optional: if nil, the tools use the application credentials.
+	Auth *auth.Config
+	// ClientOptions configure the BigQuery client, e.g. the credentials of
+	// the application or the endpoint.
+	// Optional.
+	ClientOptions []option.ClientOption
+	// ToolFilter selects the tools exposed to the model.
+	// Optional: if nil, all the tools are exposed.
+	ToolFilter tool.Predicate
+}
+
+// New returns the BigQuery toolset.
+func New(cfg Config) (tool.Toolset, error) {
+	if cfg.ProjectID == "" {
+		return nil, errors.New("project ID is required")
+	}
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = DefaultMaxRows
+	}
+	if cfg.MaxBytesBilled < 0 {
+		return nil, fmt.Errorf("max bytes billed must not be negative, got %d", cfg.MaxBytesBilled)
+	}
+	s := &set{cfg: cfg}
+	tools, err := s.newTools()
+	if err != nil {
+		return nil, err
+	}
+	s.tools = tools
+	return s, nil
+}
+
+type set struct {
+	cfg   Config
+	tools []tool.Tool
+
+	// appService is the client using the application credentials, created
+	// on first use.
+	appOnce    sync.Once
+	appService *bigquery.Service
+	appErr     error
+}
+
+func (s *set) Name() string {
+	return "bigquery_toolset"
+}
+
+func (s *set) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
+	if s.cfg.ToolFilter == nil {
+		return s.tools, nil
+	}
+	var tools []tool.Tool
+	for _, t := range s.tools {
+		if s.cfg.ToolFilter(ctx, t) {
+			tools = append(tools, t)
+		}
+	}
+	return tools, nil
+}
+
+// errPendingAuth is returned by service when the user was asked for their
+// credential.
+var errPendingAuth = errors.New("pending authorization")
+
+// service returns the BigQuery client of the user of the call.
+func (s *set) service(ctx tool.Context) (*bigquery.Service, error) {
+	if s.cfg.Auth == nil {
+		s.appOnce.Do(func() {
+			s.appService, s.appErr = bigquery.NewService(context.Background(), s.cfg.ClientOptions...)
+		})
+		if s.appErr != nil {
+			return nil, fmt.Errorf("failed to create BigQuery client: %w", s.appErr)
+		}
+		return s.appService, nil
+	}
+	cred, err := ctx.Credential(s.cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	token := toolutils.AccessToken(cred)
+	if token == "" {
+		ctx.RequestCredential(s.cfg.Auth)
+		return nil, errPendingAuth
+	}
+	opts := append(append([]option.ClientOption(nil), s.cfg.ClientOptions...),
+		option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})))
+	svc, err := bigquery.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BigQuery client: %w", err)
+	}
+	return svc, nil
+}
@@ -0,0 +1,243 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquerytoolset_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/auth"
+	contextinternal "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/bigquerytoolset"
+	"google.golang.org/api/option"
+)
+
+// fakeBigQuery serves the BigQuery REST API calls of the toolset.
+func fakeBigQuery(t *testing.T, wantToken string) *httptest.Server {
+	t.Helper()
+	reply := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wantToken != "" && r.Header.Get("Authorization") != "Bearer "+wantToken {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/projects/proj/datasets":
+			reply(w, map[string]any{"datasets": []any{
+				map[string]any{"datasetReference": map[string]any{"projectId": "proj", "datasetId": "sales"}},
+			}})
+		case r.Method == http.MethodGet && r.URL.Path == "/projects/proj/datasets/sales/tables/orders":
+			reply(w, map[string]any{
+				"tableReference": map[string]any{"projectId": "proj", "datasetId": "sales", "tableId": "orders"},
+				"type":           "TABLE",
+				"numRows":        "1000",
+				"schema": map[string]any{"fields": []any{
+					map[string]any{"name": "id", "type": "INTEGER", "mode": "REQUIRED"},
+					map[string]any{"name": "items", "type": "RECORD", "mode": "REPEATED", "fields": []any{
+						map[string]any{"name": "sku", "type": "STRING"},
+					}},
+				}},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/projects/proj/jobs":
+			var job struct {
+				Configuration struct {
+					DryRun bool `json:"dryRun"`
+					Query  struct {
+						Query string `json:"query"`
+					} `json:"query"`
+				} `json:"configuration"`
+			}
+			json.NewDecoder(r.Body).Decode(&job)
+			if !job.Configuration.DryRun {
+				http.Error(w, "want a dry run", http.StatusBadRequest)
+				return
+			}
+			statementType, bytes := "SELECT", "100"
+			if strings.HasPrefix(job.Configuration.Query.Query, "DELETE") {
+				statementType = "DELETE"
+			}
+			if strings.Contains(job.Configuration.Query.Query, "big_table") {
+				bytes = "1000000"
+			}
+			reply(w, map[string]any{"statistics": map[string]any{
+				"totalBytesProcessed": bytes,
+				"query":               map[string]any{"statementType": statementType, "totalBytesProcessed": bytes},
+			}})
+		case r.Method == http.MethodPost && r.URL.Path == "/projects/proj/queries":
+			reply(w, map[string]any{
+				"jobComplete":  false,
+				"jobReference": map[string]any{"projectId": "proj", "jobId": "job1", "location": "US"},
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/projects/proj/queries/job1":
+			if r.URL.Query().Get("maxResults") != "2" {
+				http.Error(w, "want maxResults=2", http.StatusBadRequest)
+				return
+			}
+			reply(w, map[string]any{
+				"jobComplete":         true,
+				"totalRows":           "3",
+				"totalBytesProcessed": "100",
+				"schema": map[string]any{"fields": []any{
+					map[string]any{"name": "region", "type": "STRING"},
+					map[string]any{"name": "total", "type": "FLOAT"},
+					map[string]any{"name": "orders", "type": "INTEGER"},
+				}},
+				"rows": []any{
+					map[string]any{"f": []any{map[string]any{"v": "EMEA"}, map[string]any{"v": "10.5"}, map[string]any{"v": "3"}}},
+					map[string]any{"f": []any{map[string]any{"v": "APAC"}, map[string]any{"v": nil}, map[string]any{"v": "1"}}},
+				},
+			})
+		default:
+			http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newToolContext(t *testing.T) tool.Context {
+	t.Helper()
+	resp, err := session.InMemoryService().Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv := contextinternal.NewInvocationContext(t.Context(), contextinternal.InvocationContextParams{Session: resp.Session})
+	return toolinternal.NewToolContext(inv, "", nil)
+}
+
+func run(t *testing.T, ts tool.Toolset, ctx tool.Context, name string, args map[string]any) (map[string]any, error) {
+	t.Helper()
+	tools, err := ts.Tools(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tl := range tools {
+		if tl.Name() == name {
+			return tl.(toolinternal.FunctionTool).Run(ctx, args)
+		}
+	}
+	t.Fatalf("no tool %q", name)
+	return nil, nil
+}
+
+func TestToolset(t *testing.T) {
+	srv := fakeBigQuery(t, "")
+	ts, err := bigquerytoolset.New(bigquerytoolset.Config{
+		ProjectID:      "proj",
+		MaxRows:        2,
+		MaxBytesBilled: 1000,
+		ClientOptions:  []option.ClientOption{option.WithEndpoint(srv.URL), option.WithoutAuthentication()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := newToolContext(t)
+	toJSON := func(v any) string {
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+
+	got, err := run(t, ts, ctx, bigquerytoolset.ListDatasetIDsTool, map[string]any{})
+	if err != nil || toJSON(got) != `{"dataset_ids":["sales"]}` {
+		t.Errorf("list_dataset_ids = %s, %v", toJSON(got), err)
+	}
+
+	got, err = run(t, ts, ctx, bigquerytoolset.GetTableInfoTool, map[string]any{"dataset_id": "sales", "table_id": "orders"})
+	if err != nil {
+		t.Fatalf("get_table_info error = %v", err)
+	}
+	wantSchema := `[{"mode":"REQUIRED","name":"id","type":"INTEGER"},{"fields":[{"mode":"","name":"sku","type":"STRING"}],"mode":"REPEATED","name":"items","type":"RECORD"}]`
+	if s := toJSON(got["schema"]); s != wantSchema || got["num_rows"] != float64(1000) {
+		t.Errorf("get_table_info = %s, want the schema %s and 1000 rows", toJSON(got), wantSchema)
+	}
+
+	got, err = run(t, ts, ctx, bigquerytoolset.ExecuteSQLTool, map[string]any{"query": "SELECT region, SUM(amount) AS total, COUNT(*) AS orders FROM sales.orders GROUP BY region"})
+	if err != nil {
+		t.Fatalf("execute_sql error = %v", err)
+	}
+	want := map[string]any{
+		"statement_type":        "SELECT",
+		"total_bytes_processed": float64(100),
+		"columns":               []any{"region", "total", "orders"},
+		"rows":                  []any{[]any{"EMEA", 10.5, float64(3)}, []any{"APAC", nil, float64(1)}},
+		"total_rows":            float64(3),
+		"truncated":             true,
+	}
+	var gotJSON map[string]any
+	json.Unmarshal([]byte(toJSON(got)), &gotJSON)
+	if diff := cmp.Diff(want, gotJSON); diff != "" {
+		t.Errorf("execute_sql mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = run(t, ts, ctx, bigquerytoolset.ExecuteSQLTool, map[string]any{"query": "SELECT * FROM sales.orders", "dry_run": true})
+	if err != nil || toJSON(got) != `{"statement_type":"SELECT","total_bytes_processed":100}` {
+		t.Errorf("execute_sql dry run = %s, %v", toJSON(got), err)
+	}
+	for query, wantErr := range map[string]string{
+		"DELETE FROM sales.orders WHERE true": "only SELECT statements",
+		"SELECT * FROM sales.big_table":       "more than the limit",
+	} {
+		if _, err := run(t, ts, ctx, bigquerytoolset.ExecuteSQLTool, map[string]any{"query": query}); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("execute_sql(%q) error = %v, want %q", query, err, wantErr)
+		}
+	}
+}
+
+func TestToolset_UserCredential(t *testing.T) {
+	srv := fakeBigQuery(t, "user-token")
+	authConfig := &auth.Config{
+		AuthScheme:        &auth.Scheme{Type: "oauth2"},
+		RawAuthCredential: &auth.Credential{AuthType: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Auth{ClientID: "client"}},
+	}
+	ts, err := bigquerytoolset.New(bigquerytoolset.Config{
+		ProjectID:     "proj",
+		Auth:          authConfig,
+		ClientOptions: []option.ClientOption{option.WithEndpoint(srv.URL)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := newToolContext(t)
+
+	got, err := run(t, ts, ctx, bigquerytoolset.ListDatasetIDsTool, map[string]any{})
+	if err != nil || got["status"] != "pending authorization" {
+		t.Errorf("list_dataset_ids without credential = %v, %v, want pending authorization", got, err)
+	}
+	if len(ctx.Actions().RequestedAuthConfigs) != 1 {
+		t.Errorf("requested auth configs = %v, want the credential requested", ctx.Actions().RequestedAuthConfigs)
+	}
+
+	cred := &auth.Credential{AuthType: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Auth{AccessToken: "user-token"}}
+	if err := ctx.State().Set(toolinternal.CredentialStateKey(authConfig), cred); err != nil {
+		t.Fatal(err)
+	}
+	got, err = run(t, ts, ctx, bigquerytoolset.ListDatasetIDsTool, map[string]any{})
+	if err != nil {
+		t.Fatalf("list_dataset_ids with the user credential error = %v", err)
+	}
+	if ids, _ := got["dataset_ids"].([]any); len(ids) != 1 || ids[0] != "sales" {
+		t.Errorf("list_dataset_ids = %v, want the datasets of the user", got)
+	}
+}
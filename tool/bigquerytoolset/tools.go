@@ -0,0 +1,399 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquerytoolset
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// queryTimeout is how long a query call waits for the job to complete,
+// before polling its results.
+const queryTimeout = 10 * time.Second
+
+// DatasetArgs are the arguments of the dataset tools.
+type DatasetArgs struct {
+	ProjectID string `json:"project_id,omitempty" jsonschema:"the Google Cloud project, defaults to the project of the agent"`
+	DatasetID string `json:"dataset_id,omitempty" jsonschema:"the BigQuery dataset"`
+}
+
+// TableArgs are the arguments of get_table_info.
+type TableArgs struct {
+	ProjectID string `json:"project_id,omitempty" jsonschema:"the Google Cloud project, defaults to the project of the agent"`
+	DatasetID string `json:"dataset_id" jsonschema:"the BigQuery dataset"`
+	TableID   string `json:"table_id" jsonschema:"the BigQuery table"`
+}
+
+// SQLArgs are the arguments of execute_sql.
+type SQLArgs struct {
+	ProjectID string `json:"project_id,omitempty" jsonschema:"the Google Cloud project running the query, defaults to the project of the agent"`
+	Query     string `json:"query" jsonschema:"the GoogleSQL query, with fully qualified table names: project.dataset.table"`
+	DryRun    bool   `json:"dry_run,omitempty" jsonschema:"only validate the query and estimate the bytes it processes"`
+}
+
+func (s *set) newTools() ([]tool.Tool, error) {
+	var tools []tool.Tool
+	add := func(t tool.Tool, err error) error {
+		if err != nil {
+			return fmt.Errorf("error creating BigQuery tool: %w", err)
+		}
+		tools = append(tools, t)
+		return nil
+	}
+	if err := errors.Join(
+		add(functiontool.New(functiontool.Config{
+			Name:        ListDatasetIDsTool,
+			Description: "Lists the IDs of the BigQuery datasets of a Google Cloud project.",
+			Auth:        s.cfg.Auth,
+		}, s.listDatasetIDs)),
+		add(functiontool.New(functiontool.Config{
+			Name:        GetDatasetInfoTool,
+			Description: "Returns the metadata of a BigQuery dataset, e.g. its location and description.",
+			Auth:        s.cfg.Auth,
+		}, s.getDatasetInfo)),
+		add(functiontool.New(functiontool.Config{
+			Name:        ListTableIDsTool,
+			Description: "Lists the IDs of the tables and views of a BigQuery dataset.",
+			Auth:        s.cfg.Auth,
+		}, s.listTableIDs)),
+		add(functiontool.New(functiontool.Config{
+			Name:        GetTableInfoTool,
+			Description: "Returns the metadata of a BigQuery table, e.g. its schema, description and number of rows.",
+			Auth:        s.cfg.Auth,
+		}, s.getTableInfo)),
+		add(functiontool.New(functiontool.Config{
+			Name: ExecuteSQLTool,
+			Description: fmt.Sprintf("Executes a GoogleSQL query in BigQuery and returns the columns and the rows of the result, at most %d rows.\n"+
+				"Get the schema of the tables first. Aggregate or filter in SQL rather than fetching many rows.\n", s.cfg.MaxRows),
+			Auth: s.cfg.Auth,
+		}, s.executeSQL)),
+	); err != nil {
+		return nil, err
+	}
+	return tools, nil
+}
+
+// pendingAuth is the result of the tools while the user authorizes the
+// access.
+var pendingAuth = map[string]any{"status": "pending authorization"}
+
+func (s *set) project(id string) string {
+	if id == "" {
+		return s.cfg.ProjectID
+	}
+	return id
+}
+
+func (s *set) listDatasetIDs(ctx tool.Context, args DatasetArgs) (map[string]any, error) {
+	svc, err := s.service(ctx)
+	if errors.Is(err, errPendingAuth) {
+		return pendingAuth, nil
+	} else if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	err = svc.Datasets.List(s.project(args.ProjectID)).Pages(ctx, func(page *bigquery.DatasetList) error {
+		for _, d := range page.Datasets {
+			ids = append(ids, d.DatasetReference.DatasetId)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the datasets: %w", err)
+	}
+	return map[string]any{"dataset_ids": ids}, nil
+}
+
+func (s *set) getDatasetInfo(ctx tool.Context, args DatasetArgs) (map[string]any, error) {
+	if args.DatasetID == "" {
+		return nil, errors.New("dataset_id is required")
+	}
+	svc, err := s.service(ctx)
+	if errors.Is(err, errPendingAuth) {
+		return pendingAuth, nil
+	} else if err != nil {
+		return nil, err
+	}
+	d, err := svc.Datasets.Get(s.project(args.ProjectID), args.DatasetID).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the dataset: %w", err)
+	}
+	return map[string]any{
+		"dataset_id":    d.DatasetReference.DatasetId,
+		"location":      d.Location,
+		"description":   d.Description,
+		"labels":        d.Labels,
+		"creation_time": millisToTime(d.CreationTime),
+	}, nil
+}
+
+func (s *set) listTableIDs(ctx tool.Context, args DatasetArgs) (map[string]any, error) {
+	if args.DatasetID == "" {
+		return nil, errors.New("dataset_id is required")
+	}
+	svc, err := s.service(ctx)
+	if errors.Is(err, errPendingAuth) {
+		return pendingAuth, nil
+	} else if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	err = svc.Tables.List(s.project(args.ProjectID), args.DatasetID).Pages(ctx, func(page *bigquery.TableList) error {
+		for _, t := range page.Tables {
+			ids = append(ids, t.TableReference.TableId)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tables: %w", err)
+	}
+	return map[string]any{"table_ids": ids}, nil
+}
+
+func (s *set) getTableInfo(ctx tool.Context, args TableArgs) (map[string]any, error) {
+	if args.DatasetID == "" || args.TableID == "" {
+		return nil, errors.New("dataset_id and table_id are required")
+	}
+	svc, err := s.service(ctx)
+	if errors.Is(err, errPendingAuth) {
+		return pendingAuth, nil
+	} else if err != nil {
+		return nil, err
+	}
+	t, err := svc.Tables.Get(s.project(args.ProjectID), args.DatasetID, args.TableID).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the table: %w", err)
+	}
+	info := map[string]any{
+		"table_id":      t.TableReference.TableId,
+		"type":          t.Type,
+		"description":   t.Description,
+		"num_rows":      t.NumRows,
+		"num_bytes":     t.NumBytes,
+		"creation_time": millisToTime(t.CreationTime),
+		"schema":        []map[string]any{},
+	}
+	if t.Schema != nil {
+		info["schema"] = schemaFields(t.Schema.Fields)
+	}
+	if t.TimePartitioning != nil {
+		info["partitioning"] = map[string]any{"type": t.TimePartitioning.Type, "field": t.TimePartitioning.Field}
+	}
+	if t.Clustering != nil {
+		info["clustering_fields"] = t.Clustering.Fields
+	}
+	if t.View != nil {
+		info["view_query"] = t.View.Query
+	}
+	return info, nil
+}
+
+func schemaFields(fields []*bigquery.TableFieldSchema) []map[string]any {
+	out := make([]map[string]any, len(fields))
+	for i, f := range fields {
+		out[i] = map[string]any{"name": f.Name, "type": f.Type, "mode": f.Mode}
+		if f.Description != "" {
+			out[i]["description"] = f.Description
+		}
+		if len(f.Fields) > 0 {
+			out[i]["fields"] = schemaFields(f.Fields)
+		}
+	}
+	return out
+}
+
+func (s *set) executeSQL(ctx tool.Context, args SQLArgs) (map[string]any, error) {
+	if args.Query == "" {
+		return nil, errors.New("query is required")
+	}
+	svc, err := s.service(ctx)
+	if errors.Is(err, errPendingAuth) {
+		return pendingAuth, nil
+	} else if err != nil {
+		return nil, err
+	}
+	project := s.project(args.ProjectID)
+	useLegacySQL := false
+
+	// The dry run validates the query, and estimates the bytes it processes.
+	dryRun, err := svc.Jobs.Insert(project, &bigquery.Job{
+		JobReference: &bigquery.JobReference{ProjectId: project, Location: s.cfg.Location},
+		Configuration: &bigquery.JobConfiguration{
+			DryRun: true,
+			Query:  &bigquery.JobConfigurationQuery{Query: args.Query, UseLegacySql: &useLegacySQL},
+		},
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	var (
+		statementType string
+		bytes         int64
+	)
+	if st := dryRun.Statistics; st != nil {
+		bytes = st.TotalBytesProcessed
+		if st.Query != nil {
+			statementType = st.Query.StatementType
+			bytes = max(bytes, st.Query.TotalBytesProcessed)
+		}
+	}
+	if !s.cfg.AllowWrites && statementType != "SELECT" {
+		return nil, fmt.Errorf("only SELECT statements are allowed, the query is a %s statement", statementType)
+	}
+	if s.cfg.MaxBytesBilled > 0 && bytes > s.cfg.MaxBytesBilled {
+		return nil, fmt.Errorf("the query would process %d bytes, more than the limit of %d bytes: filter on the partitions or select fewer columns", bytes, s.cfg.MaxBytesBilled)
+	}
+	if args.DryRun {
+		return map[string]any{"statement_type": statementType, "total_bytes_processed": bytes}, nil
+	}
+
+	resp, err := svc.Jobs.Query(project, &bigquery.QueryRequest{
+		Query:              args.Query,
+		UseLegacySql:       &useLegacySQL,
+		Location:           s.cfg.Location,
+		MaxResults:         int64(s.cfg.MaxRows),
+		MaximumBytesBilled: s.cfg.MaxBytesBilled,
+		TimeoutMs:          queryTimeout.Milliseconds(),
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute the query: %w", err)
+	}
+	result := &bigquery.GetQueryResultsResponse{
+		JobComplete:         resp.JobComplete,
+		JobReference:        resp.JobReference,
+		Rows:                resp.Rows,
+		Schema:              resp.Schema,
+		TotalRows:           resp.TotalRows,
+		TotalBytesProcessed: resp.TotalBytesProcessed,
+		NumDmlAffectedRows:  resp.NumDmlAffectedRows,
+	}
+	for !result.JobComplete {
+		if result.JobReference == nil {
+			return nil, errors.New("failed to execute the query: incomplete job without reference")
+		}
+		result, err = svc.Jobs.GetQueryResults(project, result.JobReference.JobId).
+			Location(result.JobReference.Location).
+			MaxResults(int64(s.cfg.MaxRows)).
+			TimeoutMs(queryTimeout.Milliseconds()).
+			Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the query results: %w", err)
+		}
+	}
+	return tabular(result, s.cfg.MaxRows, statementType), nil
+}
+
+// tabular returns the result of a query as columns and rows.
+func tabular(r *bigquery.GetQueryResultsResponse, maxRows int, statementType string) map[string]any {
+	out := map[string]any{
+		"statement_type":        statementType,
+		"total_bytes_processed": r.TotalBytesProcessed,
+	}
+	if statementType != "SELECT" {
+		out["num_dml_affected_rows"] = r.NumDmlAffectedRows
+	}
+	var fields []*bigquery.TableFieldSchema
+	if r.Schema != nil {
+		fields = r.Schema.Fields
+	}
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Name
+	}
+	rows := make([][]any, 0, min(len(r.Rows), maxRows))
+	for _, row := range r.Rows[:min(len(r.Rows), maxRows)] {
+		values := make([]any, len(fields))
+		for i, f := range fields {
+			if i < len(row.F) {
+				values[i] = convertValue(f, row.F[i].V, true)
+			}
+		}
+		rows = append(rows, values)
+	}
+	out["columns"] = columns
+	out["rows"] = rows
+	out["total_rows"] = max(r.TotalRows, uint64(len(rows)))
+	out["truncated"] = r.TotalRows > uint64(len(rows))
+	return out
+}
+
+// convertValue converts a cell value of the REST API, where the scalars
+// are strings, to the JSON value of its type.
+func convertValue(f *bigquery.TableFieldSchema, v any, checkRepeated bool) any {
+	if v == nil {
+		return nil
+	}
+	if checkRepeated && f.Mode == "REPEATED" {
+		items, _ := v.([]any)
+		out := make([]any, len(items))
+		for i, item := range items {
+			if cell, ok := item.(map[string]any); ok {
+				out[i] = convertValue(f, cell["v"], false)
+			}
+		}
+		return out
+	}
+	if f.Type == "RECORD" || f.Type == "STRUCT" {
+		record, _ := v.(map[string]any)
+		cells, _ := record["f"].([]any)
+		out := make(map[string]any, len(f.Fields))
+		for i, sub := range f.Fields {
+			if i < len(cells) {
+				if cell, ok := cells[i].(map[string]any); ok {
+					out[sub.Name] = convertValue(sub, cell["v"], true)
+				}
+			}
+		}
+		return out
+	}
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+	switch f.Type {
+	case "INTEGER", "INT64":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case "FLOAT", "FLOAT64":
+		if x, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(x, 0) && !math.IsNaN(x) {
+			return x
+		}
+	case "BOOLEAN", "BOOL":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case "TIMESTAMP":
+		if x, err := strconv.ParseFloat(s, 64); err == nil {
+			sec, frac := math.Modf(x)
+			return time.Unix(int64(sec), int64(math.Round(frac*1e6))*1000).UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return s
+}
+
+func millisToTime(ms int64) string {
+	if ms == 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+}
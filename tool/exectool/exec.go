@@ -0,0 +1,366 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package exectool provides a tool with which the agents run commands, e.g.
+// kubectl or git, under a sandbox policy.
+//
+// Only the commands of the allowlist run, without a shell: their arguments
+// are passed as is, so that the model can't chain commands or expand
+// variables. The commands run in a working directory confined to
+// Config.Dir, the arguments naming paths outside of it, also through a
+// symbolic link or as the value of a flag, are rejected, and the commands
+// are killed after Config.Timeout. Their output is returned up to
+// Config.MaxOutput bytes, and can be saved to an artifact.
+//
+// The policy limits what the model can ask for. It isn't an isolation
+// boundary: the commands run with the permissions of the process, so run it
+// in a container or with a dedicated user for untrusted inputs.
+package exectool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+// Default values of the [Config] fields.
+const (
+	DefaultName            = "run_command"
+	DefaultTimeout         = 30 * time.Second
+	DefaultMaxOutput       = 16 << 10
+	DefaultMaxArtifactSize = 10 << 20
+)
+
+// Command is a command of the allowlist.
+type Command struct {
+	// Name is the name of the command for the model, e.g. "kubectl".
+	Name string
+	// Path is the path of the executable.
+	// Optional: defaults to Name looked up in the PATH when the tool is
+	// created.
+	Path string
+	// Subcommands are the allowed values of the first argument, e.g.
+	// "get" and "describe".
+	// Optional: if empty, any first argument is allowed.
+	Subcommands []string
+	// Validate checks the arguments of the command, after the policy of
+	// the tool, e.g. to reject some flags.
+	// Optional.
+	Validate func(args []string) error
+}
+
+// Config is used to create the tool.
+type Config struct {
+	// Name is the name of the tool.
+	// Optional: defaults to [DefaultName].
+	Name string
+	// Description tells the model what the commands are for.
+	// Optional: defaults to a description listing the commands.
+	Description string
+	// Commands is the allowlist of the commands.
+	Commands []Command
+	// Dir is the directory the commands are confined to. The model can
+	// choose a working directory under it.
+	Dir string
+	// Env is the environment of the commands, as "key=value" strings.
+	// Optional: defaults to the PATH and the HOME of the process.
+	Env []string
+	// Timeout is the maximum duration of a command.
+	// Optional: defaults to [DefaultTimeout].
+	Timeout time.Duration
+	// MaxOutput is the maximum number of bytes of the standard output and
+	// of the standard error returned to the model.
+	// Optional: defaults to [DefaultMaxOutput].
+	MaxOutput int
+	// MaxArtifactSize is the maximum number of bytes of the standard
+	// output saved to an artifact. The output is written to a temporary
+	// file while the command runs.
+	// Optional: defaults to [DefaultMaxArtifactSize].
+	MaxArtifactSize int64
+}
+
+// Args are the arguments of the tool.
+type Args struct {
+	Command        string   `json:"command" jsonschema:"the name of the command"`
+	Args           []string `json:"args,omitempty" jsonschema:"the arguments of the command, each a separate item, without shell quoting"`
+	Dir            string   `json:"dir,omitempty" jsonschema:"the working directory, relative to the root directory"`
+	OutputArtifact string   `json:"output_artifact,omitempty" jsonschema:"if set, the standard output is saved to the artifact with this name, in full up to a limit"`
+}
+
+// Result is the result of the tool.
+type Result struct {
+	ExitCode          int    `json:"exit_code"`
+	Stdout            string `json:"stdout"`
+	Stderr            string `json:"stderr,omitempty"`
+	Truncated         bool   `json:"truncated,omitempty"`
+	TimedOut          bool   `json:"timed_out,omitempty"`
+	ArtifactVersion   int64  `json:"artifact_version,omitempty"`
+	ArtifactTruncated bool   `json:"artifact_truncated,omitempty"`
+}
+
+// New creates the tool running the commands of the allowlist.
+func New(cfg Config) (tool.Tool, error) {
+	if len(cfg.Commands) == 0 {
+		return nil, errors.New("at least one command is required")
+	}
+	if cfg.Dir == "" {
+		return nil, errors.New("directory is required")
+	}
+	root, err := filepath.Abs(cfg.Dir)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid directory %q: %w", cfg.Dir, err)
+	}
+	commands := make(map[string]Command, len(cfg.Commands))
+	for _, c := range cfg.Commands {
+		if c.Name == "" {
+			return nil, errors.New("command name is required")
+		}
+		if _, ok := commands[c.Name]; ok {
+			return nil, fmt.Errorf("duplicate command %q", c.Name)
+		}
+		if c.Path == "" {
+			path, err := exec.LookPath(c.Name)
+			if err != nil {
+				return nil, fmt.Errorf("command %q not found: %w", c.Name, err)
+			}
+			c.Path = path
+		}
+		commands[c.Name] = c
+	}
+	if cfg.Name == "" {
+		cfg.Name = DefaultName
+	}
+	if cfg.Description == "" {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		cfg.Description = fmt.Sprintf("Runs a command, without a shell, and returns its exit code and output. The allowed commands are: %s.\n"+
+			"The paths in the arguments must be under the root directory.\n", strings.Join(names, ", "))
+	}
+	if cfg.Env == nil {
+		for _, key := range []string{"PATH", "HOME"} {
+			if v, ok := os.LookupEnv(key); ok {
+				cfg.Env = append(cfg.Env, key+"="+v)
+			}
+		}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxOutput <= 0 {
+		cfg.MaxOutput = DefaultMaxOutput
+	}
+	if cfg.MaxArtifactSize <= 0 {
+		cfg.MaxArtifactSize = DefaultMaxArtifactSize
+	}
+	r := &runner{cfg: cfg, root: root, commands: commands}
+	return functiontool.New(functiontool.Config{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+	}, r.run)
+}
+
+type runner struct {
+	cfg      Config
+	root     string
+	commands map[string]Command
+}
+
+func (r *runner) run(ctx tool.Context, args Args) (Result, error) {
+	c, ok := r.commands[args.Command]
+	if !ok {
+		return Result{}, fmt.Errorf("command %q is not allowed", args.Command)
+	}
+	if len(c.Subcommands) > 0 && (len(args.Args) == 0 || !slices.Contains(c.Subcommands, args.Args[0])) {
+		return Result{}, fmt.Errorf("command %q only allows the subcommands %s", c.Name, strings.Join(c.Subcommands, ", "))
+	}
+	dir, err := r.confine(r.root, args.Dir)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid directory: %w", err)
+	}
+	for _, arg := range args.Args {
+		if err := r.checkArg(dir, arg); err != nil {
+			return Result{}, err
+		}
+	}
+	if c.Validate != nil {
+		if err := c.Validate(args.Args); err != nil {
+			return Result{}, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(cctx, c.Path, args.Args...)
+	cmd.Dir = dir
+	cmd.Env = r.cfg.Env
+	cmd.WaitDelay = time.Second
+	// The output is captured up to the limits, and the rest is discarded,
+	// so that a verbose command doesn't exhaust the memory.
+	stdout := &cappedWriter{w: new(bytes.Buffer), max: int64(r.cfg.MaxOutput)}
+	stderr := &cappedWriter{w: new(bytes.Buffer), max: int64(r.cfg.MaxOutput)}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	var artifact *cappedWriter
+	if args.OutputArtifact != "" {
+		f, err := os.CreateTemp("", "exectool-*")
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to create the output file: %w", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		artifact = &cappedWriter{w: f, max: r.cfg.MaxArtifactSize}
+		cmd.Stdout = io.MultiWriter(stdout, artifact)
+	}
+	err = cmd.Run()
+
+	result := Result{}
+	var exitErr *exec.ExitError
+	switch {
+	case cctx.Err() == context.DeadlineExceeded:
+		result.TimedOut = true
+		result.ExitCode = -1
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return Result{}, fmt.Errorf("failed to run %q: %w", c.Name, err)
+	}
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.Truncated = stdout.truncated || stderr.truncated
+
+	if artifact != nil {
+		f := artifact.w.(*os.File)
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return Result{}, fmt.Errorf("failed to read the output file: %w", err)
+		}
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to read the output file: %w", err)
+		}
+		saved, err := ctx.Artifacts().Save(ctx, args.OutputArtifact, genai.NewPartFromBytes(data, "text/plain"))
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to save the output: %w", err)
+		}
+		result.ArtifactVersion = saved.Version
+		result.ArtifactTruncated = artifact.truncated
+	}
+	return result, nil
+}
+
+// confine returns the path joined to dir, if it is under the root
+// directory once the symbolic links are resolved.
+func (r *runner) confine(dir, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+	// The path may not exist yet, e.g. an output file: the symbolic links
+	// of its longest existing parent are resolved.
+	resolved, rest := path, ""
+	for {
+		if p, err := filepath.EvalSymlinks(resolved); err == nil {
+			resolved = filepath.Join(p, rest)
+			break
+		}
+		parent := filepath.Dir(resolved)
+		if parent == resolved {
+			break
+		}
+		rest = filepath.Join(filepath.Base(resolved), rest)
+		resolved = parent
+	}
+	rel, err := filepath.Rel(r.root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%q is outside of the root directory", path)
+	}
+	return resolved, nil
+}
+
+// checkArg rejects the argument if it names a path outside of the root
+// directory, once the symbolic links are resolved. Every argument that
+// isn't a flag is checked as a path relative to the working directory, and
+// so are the values of the flags: "--file=/etc/passwd", and the values
+// joined to the short flags, "-f/etc/passwd". Arguments like "/" are
+// therefore rejected even where the command doesn't read them as paths.
+func (r *runner) checkArg(dir, arg string) error {
+	v := arg
+	switch {
+	case strings.HasPrefix(arg, "--"):
+		_, v, _ = strings.Cut(arg, "=")
+	case strings.HasPrefix(arg, "-"):
+		v = arg[min(len(arg), 2):]
+		if _, after, ok := strings.Cut(v, "="); ok {
+			v = after
+		}
+	}
+	if v == "" {
+		return nil
+	}
+	if _, err := r.confine(dir, v); err != nil {
+		return fmt.Errorf("invalid argument %q: %w", arg, err)
+	}
+	return nil
+}
+
+// cappedWriter writes the first max bytes to w, and discards the rest
+// without failing, so that the command isn't interrupted.
+type cappedWriter struct {
+	w         io.Writer
+	max       int64
+	n         int64
+	truncated bool
+}
+
+func (c *cappedWriter) Write(p []byte) (int, error) {
+	if room := c.max - c.n; int64(len(p)) > room {
+		c.truncated = true
+		if room > 0 {
+			if _, err := c.w.Write(p[:room]); err != nil {
+				return 0, err
+			}
+			c.n += room
+		}
+		return len(p), nil
+	}
+	if _, err := c.w.Write(p); err != nil {
+		return 0, err
+	}
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// String returns the output written to the buffer, without a rune cut by
+// the limit.
+func (c *cappedWriter) String() string {
+	return strings.ToValidUTF8(c.w.(*bytes.Buffer).String(), "")
+}
@@ -0,0 +1,216 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exectool_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	artifactinternal "google.golang.org/adk/internal/artifact"
+	contextinternal "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/exectool"
+)
+
+func newToolContext(t *testing.T) tool.Context {
+	t.Helper()
+	inv := contextinternal.NewInvocationContext(t.Context(), contextinternal.InvocationContextParams{
+		Artifacts: &artifactinternal.Artifacts{
+			Service:   artifact.InMemoryService(),
+			AppName:   "app",
+			UserID:    "user",
+			SessionID: "session",
+		},
+	})
+	return toolinternal.NewToolContext(inv, "", nil)
+}
+
+func TestTool(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test uses Unix commands")
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "logs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "logs", "app.log"), []byte("line 1\nline 2\nline 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc", filepath.Join(root, "etc")); err != nil {
+		t.Fatal(err)
+	}
+	tl, err := exectool.New(exectool.Config{
+		Commands: []exectool.Command{
+			{Name: "cat"},
+			{Name: "sleep"},
+			{Name: "ls"},
+			{Name: "git", Path: "/bin/true", Subcommands: []string{"status", "log"}},
+			{Name: "head", Validate: func(args []string) error {
+				if slices.Contains(args, "-c") {
+					return errors.New("-c is not allowed")
+				}
+				return nil
+			}},
+		},
+		Dir:       root,
+		Timeout:   200 * time.Millisecond,
+		MaxOutput: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := newToolContext(t)
+	run := func(args map[string]any) (map[string]any, error) {
+		return tl.(toolinternal.FunctionTool).Run(ctx, args)
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "output",
+			args: map[string]any{"command": "cat", "args": []any{"app.log"}, "dir": "logs"},
+			want: map[string]any{"exit_code": float64(0), "stdout": "line 1\nlin", "truncated": true},
+		},
+		{
+			name: "working directory",
+			args: map[string]any{"command": "ls", "dir": "logs/../logs"},
+			want: map[string]any{"exit_code": float64(0), "stdout": "app.log\n"},
+		},
+		{
+			name: "exit code",
+			args: map[string]any{"command": "ls", "args": []any{"missing"}},
+			want: map[string]any{"exit_code": float64(2)},
+		},
+		{
+			name: "timeout",
+			args: map[string]any{"command": "sleep", "args": []any{"5"}},
+			want: map[string]any{"exit_code": float64(-1), "stdout": "", "timed_out": true},
+		},
+		{name: "not allowed", args: map[string]any{"command": "rm", "args": []any{"-rf", "logs"}}, wantErr: "not allowed"},
+		{name: "shell", args: map[string]any{"command": "cat logs/app.log; rm -rf /"}, wantErr: "not allowed"},
+		{name: "subcommand", args: map[string]any{"command": "git", "args": []any{"push"}}, wantErr: "only allows the subcommands"},
+		{name: "absolute path", args: map[string]any{"command": "cat", "args": []any{"/etc/passwd"}}, wantErr: "outside of the root directory"},
+		{name: "parent path", args: map[string]any{"command": "cat", "args": []any{"../../etc/passwd"}}, wantErr: "outside of the root directory"},
+		{name: "flag path", args: map[string]any{"command": "ls", "args": []any{"--directory=/"}}, wantErr: "outside of the root directory"},
+		{name: "short flag path", args: map[string]any{"command": "ls", "args": []any{"-I/etc"}}, wantErr: "outside of the root directory"},
+		{name: "symbolic link path", args: map[string]any{"command": "cat", "args": []any{"etc/passwd"}}, wantErr: "outside of the root directory"},
+		{name: "directory", args: map[string]any{"command": "ls", "dir": ".."}, wantErr: "outside of the root directory"},
+		{name: "symbolic link", args: map[string]any{"command": "ls", "dir": "etc"}, wantErr: "outside of the root directory"},
+		{name: "validate", args: map[string]any{"command": "head", "args": []any{"-c", "1", "logs/app.log"}}, wantErr: "-c is not allowed"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := run(tc.args)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("Run() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			for k, v := range tc.want {
+				if got[k] != v {
+					t.Errorf("Run()[%q] = %#v, want %#v (result %v)", k, got[k], v, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTool_OutputArtifact(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test uses Unix commands")
+	}
+	root := t.TempDir()
+	content := strings.Repeat("0123456789", 10)
+	if err := os.WriteFile(filepath.Join(root, "data.txt"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tl, err := exectool.New(exectool.Config{Commands: []exectool.Command{{Name: "cat"}}, Dir: root, MaxOutput: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := newToolContext(t)
+	got, err := tl.(toolinternal.FunctionTool).Run(ctx, map[string]any{"command": "cat", "args": []any{"data.txt"}, "output_artifact": "data"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["stdout"] != "01234" || got["artifact_version"] != float64(1) {
+		t.Errorf("Run() = %v, want the truncated output and the artifact version", got)
+	}
+	loaded, err := ctx.Artifacts().Load(t.Context(), "data")
+	if err != nil || loaded.Part.InlineData == nil || string(loaded.Part.InlineData.Data) != content {
+		t.Errorf("artifact = %v, %v, want the full output", loaded, err)
+	}
+}
+
+func TestTool_OutputLimits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test uses Unix commands")
+	}
+	tl, err := exectool.New(exectool.Config{
+		Commands:        []exectool.Command{{Name: "yes"}},
+		Dir:             t.TempDir(),
+		Timeout:         500 * time.Millisecond,
+		MaxOutput:       10,
+		MaxArtifactSize: 1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := newToolContext(t)
+	// yes writes until it is killed: the output is discarded after the
+	// limits.
+	got, err := tl.(toolinternal.FunctionTool).Run(ctx, map[string]any{"command": "yes", "output_artifact": "yes.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["stdout"] != "y\ny\ny\ny\ny\n" || got["truncated"] != true || got["artifact_truncated"] != true {
+		t.Errorf("Run() = %v, want the truncated output", got)
+	}
+	loaded, err := ctx.Artifacts().Load(t.Context(), "yes.txt")
+	if err != nil || loaded.Part.InlineData == nil || len(loaded.Part.InlineData.Data) != 1000 {
+		t.Errorf("artifact = %v, %v, want the first 1000 bytes of the output", loaded, err)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for name, cfg := range map[string]exectool.Config{
+		"no commands":       {Dir: dir},
+		"no directory":      {Commands: []exectool.Command{{Name: "ls"}}},
+		"missing directory": {Commands: []exectool.Command{{Name: "ls"}}, Dir: filepath.Join(dir, "missing")},
+		"unknown command":   {Commands: []exectool.Command{{Name: "no-such-command-adk"}}, Dir: dir},
+		"duplicate command": {Commands: []exectool.Command{{Name: "ls"}, {Name: "ls"}}, Dir: dir},
+	} {
+		if _, err := exectool.New(cfg); err == nil {
+			t.Errorf("New(%s) succeeded, want error", name)
+		}
+	}
+}
@@ -16,9 +16,13 @@
 package functiontool
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/internal/toolinternal/toolutils"
 	"google.golang.org/adk/internal/typeutil"
 	"google.golang.org/adk/model"
@@ -42,6 +46,13 @@ type Config struct {
 	OutputSchema *jsonschema.Schema
 	// IsLongRunning makes a FunctionTool a long-running operation.
 	IsLongRunning bool
+	// Auth documents the credential the handler requests with
+	// tool.Context.RequestCredential, if any. It is reported by
+	// tool.Documented and doesn't change how the tool is called.
+	Auth *auth.Config
+	// Timeout bounds the duration of a call of the handler: the context of the
+	// handler is canceled once it elapses. Zero means no timeout.
+	Timeout time.Duration
 }
 
 // Func represents a Go function that can be wrapped in a tool.
@@ -98,6 +109,11 @@ func (f *functionTool[TArgs, TResults]) IsLongRunning() bool {
 	return f.cfg.IsLongRunning
 }
 
+// Requirements implements tool.Documented.
+func (f *functionTool[TArgs, TResults]) Requirements() tool.Requirements {
+	return tool.Requirements{Auth: f.cfg.Auth, Timeout: f.cfg.Timeout}
+}
+
 // ProcessRequest packs the function tool's declaration into the LLM request.
 func (f *functionTool[TArgs, TResults]) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
 	return toolutils.PackTool(req, f)
@@ -140,7 +156,7 @@ func (f *functionTool[TArgs, TResults]) Run(ctx tool.Context, args any) (map[str
 	if err != nil {
 		return nil, err
 	}
-	output, err := f.handler(ctx, input)
+	output, err := f.call(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -162,6 +178,31 @@ func (f *functionTool[TArgs, TResults]) Run(ctx tool.Context, args any) (map[str
 	return wrappedOutput, nil
 }
 
+// call calls the handler within the timeout of the tool, if any.
+func (f *functionTool[TArgs, TResults]) call(ctx tool.Context, input TArgs) (TResults, error) {
+	if f.cfg.Timeout <= 0 {
+		return f.handler(ctx, input)
+	}
+	tctx, cancel := context.WithTimeout(ctx, f.cfg.Timeout)
+	defer cancel()
+	output, err := f.handler(&timeoutContext{Context: ctx, ctx: tctx}, input)
+	if err != nil && errors.Is(err, context.DeadlineExceeded) && errors.Is(tctx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("tool %q timed out after %v: %w", f.Name(), f.cfg.Timeout, err)
+	}
+	return output, err
+}
+
+// timeoutContext is a tool.Context whose deadline is the timeout of the tool.
+type timeoutContext struct {
+	tool.Context
+	ctx context.Context
+}
+
+func (c *timeoutContext) Deadline() (time.Time, bool) { return c.ctx.Deadline() }
+func (c *timeoutContext) Done() <-chan struct{}       { return c.ctx.Done() }
+func (c *timeoutContext) Err() error                  { return c.ctx.Err() }
+func (c *timeoutContext) Value(key any) any           { return c.ctx.Value(key) }
+
 // ** NOTE FOR REVIEWERS **
 // Initially I started to borrow the design of the MCP ServerTool and
 // ToolHandlerFor/ToolHandler [1], but got diverged.
@@ -15,22 +15,28 @@
 package functiontool_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"iter"
 	"net/http"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/adk/auth"
+	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/httprr"
 	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/internal/typeutil"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/model/gemini"
+	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
 	"google.golang.org/genai"
@@ -507,3 +513,34 @@ func stringify(v any) string {
 	}
 	return string(x)
 }
+
+func TestFunctionTool_Timeout(t *testing.T) {
+	type Args struct{}
+	handler := func(ctx tool.Context, _ Args) (map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	authConfig := &auth.Config{AuthScheme: &auth.Scheme{Type: "oauth2"}}
+	slowTool, err := functiontool.New(functiontool.Config{
+		Name:    "slow",
+		Auth:    authConfig,
+		Timeout: 10 * time.Millisecond,
+	}, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := slowTool.(tool.Documented).Requirements()
+	if want := (tool.Requirements{Auth: authConfig, Timeout: 10 * time.Millisecond}); got != want {
+		t.Errorf("Requirements() = %+v, want %+v", got, want)
+	}
+
+	ictx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{})
+	toolCtx := toolinternal.NewToolContext(ictx, "call_1", &session.EventActions{})
+	_, err = slowTool.(toolinternal.FunctionTool).Run(toolCtx, map[string]any{})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Run() error = %v, want a timeout", err)
+	}
+	if toolCtx.Err() != nil {
+		t.Errorf("the context of the call was canceled: %v", toolCtx.Err())
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gcstoolset provides a toolset with which the agents manage Google
+// Cloud Storage: list the buckets and the objects, read an object into an
+// artifact of the session, and write an artifact to an object.
+//
+// By default the toolset uses the credentials of the application. With
+// Config.Auth, each user authorizes the access with OAuth2 and the tools
+// call Cloud Storage with their credential, so that their IAM permissions
+// apply, see tool.Context.RequestCredential.
+package gcstoolset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/toolinternal/toolutils"
+	"google.golang.org/adk/tool"
+	"google.golang.org/api/option"
+	storage "google.golang.org/api/storage/v1"
+)
+
+// Default values of the [Config] fields.
+const (
+	DefaultMaxObjectSize = 10 << 20
+	DefaultMaxResults    = 100
+)
+
+// Names of the tools.
+const (
+	ListBucketsTool = "list_buckets"
+	ListObjectsTool = "list_objects"
+	ReadObjectTool  = "read_object"
+	WriteObjectTool = "write_object"
+)
+
+// Config is used to create the toolset.
+type Config struct {
+	// ProjectID is the default project of list_buckets.
+	ProjectID string
+	// AllowWrites exposes the write_object tool.
+	// Optional: by default the toolset is read-only.
+	AllowWrites bool
+	// MaxObjectSize is the maximum size in bytes of the objects read into
+	// artifacts.
+	// Optional: defaults to [DefaultMaxObjectSize].
+	MaxObjectSize int64
+	// MaxResults is the maximum number of buckets or objects listed by a
+	// call.
+	// Optional: defaults to [DefaultMaxResults].
+	MaxResults int
+	// Auth is the OAuth2 credential the tools request from each user, e.g.
+	// with the scope "https://www.googleapis.com/auth/devstorage.read_write".
+	// The tools call Cloud Storage with the access token of the user.
+	// Optional: if nil, the tools use the application credentials.
+	Auth *auth.Config
+	// ClientOptions configure the Cloud Storage client, e.g. the
+	// credentials of the application or the endpoint.
+	// Optional.
+	ClientOptions []option.ClientOption
+	// ToolFilter selects the tools exposed to the model.
+	// Optional: if nil, all the tools are exposed.
+	ToolFilter tool.Predicate
+}
+
+// New returns the Cloud Storage toolset.
+func New(cfg Config) (tool.Toolset, error) {
+	if cfg.MaxObjectSize <= 0 {
+		cfg.MaxObjectSize = DefaultMaxObjectSize
+	}
+	if cfg.MaxResults <= 0 {
+		cfg.MaxResults = DefaultMaxResults
+	}
+	s := &set{cfg: cfg}
+	tools, err := s.newTools()
+	if err != nil {
+		return nil, err
+	}
+	s.tools = tools
+	return s, nil
+}
+
+type set struct {
+	cfg   Config
+	tools []tool.Tool
+
+	// appService is the client using the application credentials, created
+	// on first use.
+	appOnce    sync.Once
+	appService *storage.Service
+	appErr     error
+}
+
+func (s *set) Name() string {
+	return "gcs_toolset"
+}
+
+func (s *set) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
+	if s.cfg.ToolFilter == nil {
+		return s.tools, nil
+	}
+	var tools []tool.Tool
+	for _, t := range s.tools {
+		if s.cfg.ToolFilter(ctx, t) {
+			tools = append(tools, t)
+		}
+	}
+	return tools, nil
+}
+
+// errPendingAuth is returned by service when the user was asked for their
+// credential.
+var errPendingAuth = errors.New("pending authorization")
+
+// service returns the Cloud Storage client of the user of the call.
+func (s *set) service(ctx tool.Context) (*storage.Service, error) {
+	if s.cfg.Auth == nil {
+		s.appOnce.Do(func() {
+			s.appService, s.appErr = storage.NewService(context.Background(), s.cfg.ClientOptions...)
+		})
+		if s.appErr != nil {
+			return nil, fmt.Errorf("failed to create Cloud Storage client: %w", s.appErr)
+		}
+		return s.appService, nil
+	}
+	cred, err := ctx.Credential(s.cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	token := toolutils.AccessToken(cred)
+	if token == "" {
+		ctx.RequestCredential(s.cfg.Auth)
+		return nil, errPendingAuth
+	}
+	opts := append(append([]option.ClientOption(nil), s.cfg.ClientOptions...),
+		option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})))
+	svc, err := storage.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Storage client: %w", err)
+	}
+	return svc, nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcstoolset_test
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/auth"
+	artifactinternal "google.golang.org/adk/internal/artifact"
+	contextinternal "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/gcstoolset"
+	"google.golang.org/api/option"
+	"google.golang.org/genai"
+)
+
+type object struct {
+	contentType string
+	data        []byte
+}
+
+// fakeGCS serves the Cloud Storage JSON API calls of the toolset.
+func fakeGCS(t *testing.T, wantToken string, objects map[string]object) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wantToken != "" && r.Header.Get("Authorization") != "Bearer "+wantToken {
+			http.Error(w, `{"error":{"code":401,"message":"unauthenticated"}}`, http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		reply := func(v any) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(v)
+		}
+		meta := func(name string, o object) map[string]any {
+			return map[string]any{"bucket": "ops", "name": name, "size": strconv.Itoa(len(o.data)), "contentType": o.contentType, "generation": "1", "updated": "2025-01-02T03:04:05Z"}
+		}
+		p := r.URL.Path
+		switch {
+		case r.Method == http.MethodGet && p == "/b":
+			reply(map[string]any{"items": []any{map[string]any{"name": "ops", "location": "EU", "storageClass": "STANDARD"}}})
+		case r.Method == http.MethodGet && p == "/b/ops/o":
+			var items []any
+			for name, o := range objects {
+				if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+					items = append(items, meta(name, o))
+				}
+			}
+			reply(map[string]any{"items": items})
+		case r.Method == http.MethodGet && strings.HasPrefix(p, "/b/ops/o/"):
+			name := strings.TrimPrefix(p, "/b/ops/o/")
+			o, ok := objects[name]
+			if !ok {
+				http.Error(w, `{"error":{"code":404,"message":"not found"}}`, http.StatusNotFound)
+				return
+			}
+			if r.URL.Query().Get("alt") == "media" {
+				w.Header().Set("Content-Type", o.contentType)
+				w.Write(o.data)
+				return
+			}
+			reply(meta(name, o))
+		case r.Method == http.MethodPost && p == "/upload/storage/v1/b/ops/o":
+			_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil {
+				t.Errorf("upload content type: %v", err)
+				return
+			}
+			mr := multipart.NewReader(r.Body, params["boundary"])
+			var m struct {
+				Name        string `json:"name"`
+				ContentType string `json:"contentType"`
+			}
+			part, _ := mr.NextPart()
+			json.NewDecoder(part).Decode(&m)
+			part, _ = mr.NextPart()
+			data, _ := io.ReadAll(part)
+			objects[m.Name] = object{contentType: m.ContentType, data: data}
+			reply(meta(m.Name, objects[m.Name]))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newToolContext(t *testing.T) tool.Context {
+	t.Helper()
+	resp, err := session.InMemoryService().Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv := contextinternal.NewInvocationContext(t.Context(), contextinternal.InvocationContextParams{
+		Session: resp.Session,
+		Artifacts: &artifactinternal.Artifacts{
+			Service:   artifact.InMemoryService(),
+			AppName:   "app",
+			UserID:    "user",
+			SessionID: resp.Session.ID(),
+		},
+	})
+	return toolinternal.NewToolContext(inv, "", nil)
+}
+
+func run(t *testing.T, ts tool.Toolset, ctx tool.Context, name string, args map[string]any) (map[string]any, error) {
+	t.Helper()
+	tools, err := ts.Tools(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tl := range tools {
+		if tl.Name() == name {
+			return tl.(toolinternal.FunctionTool).Run(ctx, args)
+		}
+	}
+	t.Fatalf("no tool %q", name)
+	return nil, nil
+}
+
+func TestToolset(t *testing.T) {
+	objects := map[string]object{
+		"logs/app.log":  {contentType: "text/plain", data: []byte("started")},
+		"dumps/big.bin": {contentType: "application/octet-stream", data: make([]byte, 100)},
+	}
+	srv := fakeGCS(t, "", objects)
+	ts, err := gcstoolset.New(gcstoolset.Config{
+		ProjectID:     "proj",
+		AllowWrites:   true,
+		MaxObjectSize: 50,
+		ClientOptions: []option.ClientOption{option.WithEndpoint(srv.URL), option.WithoutAuthentication()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := newToolContext(t)
+	toJSON := func(v any) string {
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+
+	got, err := run(t, ts, ctx, gcstoolset.ListBucketsTool, map[string]any{})
+	if err != nil || toJSON(got) != `{"buckets":[{"location":"EU","name":"ops","storage_class":"STANDARD"}],"truncated":false}` {
+		t.Errorf("list_buckets = %s, %v", toJSON(got), err)
+	}
+
+	got, err = run(t, ts, ctx, gcstoolset.ListObjectsTool, map[string]any{"bucket": "ops", "prefix": "logs/"})
+	if err != nil || toJSON(got) != `{"objects":[{"content_type":"text/plain","name":"logs/app.log","size":7,"updated":"2025-01-02T03:04:05Z"}],"truncated":false}` {
+		t.Errorf("list_objects = %s, %v", toJSON(got), err)
+	}
+
+	got, err = run(t, ts, ctx, gcstoolset.ReadObjectTool, map[string]any{"bucket": "ops", "object": "logs/app.log"})
+	if err != nil || toJSON(got) != `{"artifact_name":"app.log","content_type":"text/plain","size":7,"version":1}` {
+		t.Errorf("read_object = %s, %v", toJSON(got), err)
+	}
+	loaded, err := ctx.Artifacts().Load(t.Context(), "app.log")
+	if err != nil || loaded.Part.InlineData == nil || string(loaded.Part.InlineData.Data) != "started" {
+		t.Errorf("artifact app.log = %v, %v, want the object content", loaded, err)
+	}
+	if _, err := run(t, ts, ctx, gcstoolset.ReadObjectTool, map[string]any{"bucket": "ops", "object": "dumps/big.bin"}); err == nil || !strings.Contains(err.Error(), "more than the limit") {
+		t.Errorf("read_object of a large object error = %v, want the size limit", err)
+	}
+
+	if _, err := ctx.Artifacts().Save(t.Context(), "report.csv", genai.NewPartFromBytes([]byte("a,b\n1,2\n"), "text/csv")); err != nil {
+		t.Fatal(err)
+	}
+	got, err = run(t, ts, ctx, gcstoolset.WriteObjectTool, map[string]any{"artifact_name": "report.csv", "bucket": "ops", "object": "reports/report.csv"})
+	if err != nil || got["uri"] != "gs://ops/reports/report.csv" {
+		t.Errorf("write_object = %s, %v", toJSON(got), err)
+	}
+	if o := objects["reports/report.csv"]; o.contentType != "text/csv" || string(o.data) != "a,b\n1,2\n" {
+		t.Errorf("written object = %+v, want the artifact", o)
+	}
+}
+
+func TestToolset_ReadOnly(t *testing.T) {
+	ts, err := gcstoolset.New(gcstoolset.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := ts.Tools(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tl := range tools {
+		if tl.Name() == gcstoolset.WriteObjectTool {
+			t.Errorf("tools contain %s without AllowWrites", tl.Name())
+		}
+	}
+}
+
+func TestToolset_UserCredential(t *testing.T) {
+	srv := fakeGCS(t, "user-token", map[string]object{})
+	authConfig := &auth.Config{
+		AuthScheme:        &auth.Scheme{Type: "oauth2"},
+		RawAuthCredential: &auth.Credential{AuthType: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Auth{ClientID: "client"}},
+	}
+	ts, err := gcstoolset.New(gcstoolset.Config{
+		ProjectID:     "proj",
+		Auth:          authConfig,
+		ClientOptions: []option.ClientOption{option.WithEndpoint(srv.URL)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := newToolContext(t)
+
+	got, err := run(t, ts, ctx, gcstoolset.ListBucketsTool, map[string]any{})
+	if err != nil || got["status"] != "pending authorization" {
+		t.Errorf("list_buckets without credential = %v, %v, want pending authorization", got, err)
+	}
+	if len(ctx.Actions().RequestedAuthConfigs) != 1 {
+		t.Errorf("requested auth configs = %v, want the credential requested", ctx.Actions().RequestedAuthConfigs)
+	}
+
+	cred := &auth.Credential{AuthType: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Auth{AccessToken: "user-token"}}
+	if err := ctx.State().Set(toolinternal.CredentialStateKey(authConfig), cred); err != nil {
+		t.Fatal(err)
+	}
+	got, err = run(t, ts, ctx, gcstoolset.ListBucketsTool, map[string]any{})
+	if err != nil {
+		t.Fatalf("list_buckets with the user credential error = %v", err)
+	}
+	if buckets, _ := got["buckets"].([]any); len(buckets) != 1 {
+		t.Errorf("list_buckets = %v, want the buckets of the user", got)
+	}
+}
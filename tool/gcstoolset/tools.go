@@ -0,0 +1,237 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcstoolset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	storage "google.golang.org/api/storage/v1"
+	"google.golang.org/genai"
+)
+
+// ListBucketsArgs are the arguments of list_buckets.
+type ListBucketsArgs struct {
+	ProjectID string `json:"project_id,omitempty" jsonschema:"the Google Cloud project, defaults to the project of the agent"`
+}
+
+// ListObjectsArgs are the arguments of list_objects.
+type ListObjectsArgs struct {
+	Bucket string `json:"bucket" jsonschema:"the bucket name, without gs://"`
+	Prefix string `json:"prefix,omitempty" jsonschema:"only list the objects whose name starts with the prefix"`
+}
+
+// ReadObjectArgs are the arguments of read_object.
+type ReadObjectArgs struct {
+	Bucket       string `json:"bucket" jsonschema:"the bucket name, without gs://"`
+	Object       string `json:"object" jsonschema:"the object name"`
+	ArtifactName string `json:"artifact_name,omitempty" jsonschema:"the name of the artifact, defaults to the base name of the object"`
+}
+
+// WriteObjectArgs are the arguments of write_object.
+type WriteObjectArgs struct {
+	ArtifactName string `json:"artifact_name" jsonschema:"the name of the artifact to write"`
+	Bucket       string `json:"bucket" jsonschema:"the bucket name, without gs://"`
+	Object       string `json:"object" jsonschema:"the object name"`
+}
+
+func (s *set) newTools() ([]tool.Tool, error) {
+	cfg := s.cfg
+	var tools []tool.Tool
+	add := func(t tool.Tool, err error) error {
+		if err != nil {
+			return fmt.Errorf("error creating Cloud Storage tool: %w", err)
+		}
+		tools = append(tools, t)
+		return nil
+	}
+	errs := []error{
+		add(functiontool.New(functiontool.Config{
+			Name:        ListBucketsTool,
+			Description: "Lists the Cloud Storage buckets of a Google Cloud project.",
+			Auth:        cfg.Auth,
+		}, s.listBuckets)),
+		add(functiontool.New(functiontool.Config{
+			Name:        ListObjectsTool,
+			Description: "Lists the objects of a Cloud Storage bucket, optionally under a prefix, with their size, content type and update time.",
+			Auth:        cfg.Auth,
+		}, s.listObjects)),
+		add(functiontool.New(functiontool.Config{
+			Name: ReadObjectTool,
+			Description: "Reads a Cloud Storage object into an artifact of the session, so that it can be loaded or processed.\n" +
+				"Returns the name and the version of the artifact.\n",
+			Auth: cfg.Auth,
+		}, s.readObject)),
+	}
+	if cfg.AllowWrites {
+		errs = append(errs, add(functiontool.New(functiontool.Config{
+			Name:        WriteObjectTool,
+			Description: "Writes an artifact of the session to a Cloud Storage object, replacing the object if it exists.",
+			Auth:        cfg.Auth,
+		}, s.writeObject)))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return tools, nil
+}
+
+// pendingAuth is the result of the tools while the user authorizes the
+// access.
+var pendingAuth = map[string]any{"status": "pending authorization"}
+
+func (s *set) listBuckets(ctx tool.Context, args ListBucketsArgs) (map[string]any, error) {
+	project := args.ProjectID
+	if project == "" {
+		project = s.cfg.ProjectID
+	}
+	if project == "" {
+		return nil, errors.New("project_id is required")
+	}
+	svc, err := s.service(ctx)
+	if errors.Is(err, errPendingAuth) {
+		return pendingAuth, nil
+	} else if err != nil {
+		return nil, err
+	}
+	resp, err := svc.Buckets.List(project).MaxResults(int64(s.cfg.MaxResults)).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the buckets: %w", err)
+	}
+	buckets := []map[string]any{}
+	for _, b := range resp.Items {
+		buckets = append(buckets, map[string]any{"name": b.Name, "location": b.Location, "storage_class": b.StorageClass})
+	}
+	return map[string]any{"buckets": buckets, "truncated": resp.NextPageToken != ""}, nil
+}
+
+func (s *set) listObjects(ctx tool.Context, args ListObjectsArgs) (map[string]any, error) {
+	if args.Bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+	svc, err := s.service(ctx)
+	if errors.Is(err, errPendingAuth) {
+		return pendingAuth, nil
+	} else if err != nil {
+		return nil, err
+	}
+	resp, err := svc.Objects.List(args.Bucket).Prefix(args.Prefix).MaxResults(int64(s.cfg.MaxResults)).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the objects: %w", err)
+	}
+	objects := []map[string]any{}
+	for _, o := range resp.Items {
+		objects = append(objects, map[string]any{
+			"name":         o.Name,
+			"size":         o.Size,
+			"content_type": o.ContentType,
+			"updated":      o.Updated,
+		})
+	}
+	return map[string]any{"objects": objects, "truncated": resp.NextPageToken != ""}, nil
+}
+
+func (s *set) readObject(ctx tool.Context, args ReadObjectArgs) (map[string]any, error) {
+	if args.Bucket == "" || args.Object == "" {
+		return nil, errors.New("bucket and object are required")
+	}
+	name := args.ArtifactName
+	if name == "" {
+		name = path.Base(args.Object)
+	}
+	svc, err := s.service(ctx)
+	if errors.Is(err, errPendingAuth) {
+		return pendingAuth, nil
+	} else if err != nil {
+		return nil, err
+	}
+	obj, err := svc.Objects.Get(args.Bucket, args.Object).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the object: %w", err)
+	}
+	if int64(obj.Size) > s.cfg.MaxObjectSize {
+		return nil, fmt.Errorf("the object has %d bytes, more than the limit of %d bytes", obj.Size, s.cfg.MaxObjectSize)
+	}
+	resp, err := svc.Objects.Get(args.Bucket, args.Object).Generation(obj.Generation).Context(ctx).Download()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the object: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.cfg.MaxObjectSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the object: %w", err)
+	}
+	if int64(len(data)) > s.cfg.MaxObjectSize {
+		return nil, fmt.Errorf("the object has more than the limit of %d bytes", s.cfg.MaxObjectSize)
+	}
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	saved, err := ctx.Artifacts().Save(ctx, name, genai.NewPartFromBytes(data, contentType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save the artifact: %w", err)
+	}
+	return map[string]any{
+		"artifact_name": name,
+		"version":       saved.Version,
+		"size":          len(data),
+		"content_type":  contentType,
+	}, nil
+}
+
+func (s *set) writeObject(ctx tool.Context, args WriteObjectArgs) (map[string]any, error) {
+	if args.ArtifactName == "" || args.Bucket == "" || args.Object == "" {
+		return nil, errors.New("artifact_name, bucket and object are required")
+	}
+	loaded, err := ctx.Artifacts().Load(ctx, args.ArtifactName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the artifact: %w", err)
+	}
+	var (
+		data        []byte
+		contentType string
+	)
+	switch p := loaded.Part; {
+	case p == nil:
+		return nil, fmt.Errorf("artifact %q is empty", args.ArtifactName)
+	case p.InlineData != nil:
+		data, contentType = p.InlineData.Data, p.InlineData.MIMEType
+	default:
+		data, contentType = []byte(p.Text), "text/plain; charset=utf-8"
+	}
+	svc, err := s.service(ctx)
+	if errors.Is(err, errPendingAuth) {
+		return pendingAuth, nil
+	} else if err != nil {
+		return nil, err
+	}
+	obj, err := svc.Objects.Insert(args.Bucket, &storage.Object{Name: args.Object, ContentType: contentType}).
+		Media(bytes.NewReader(data)).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write the object: %w", err)
+	}
+	return map[string]any{
+		"uri":        fmt.Sprintf("gs://%s/%s", obj.Bucket, obj.Name),
+		"size":       obj.Size,
+		"generation": obj.Generation,
+		"updated":    obj.Updated,
+	}, nil
+}
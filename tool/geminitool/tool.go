@@ -26,7 +26,7 @@
 //		},
 //	})
 //
-// Package also provides default tools like GoogleSearch.
+// Package also provides default tools like GoogleSearch and VertexAISearch.
 package geminitool
 
 import (
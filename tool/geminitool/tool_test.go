@@ -90,3 +90,54 @@ func TestGeminiTool_ProcessRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestVertexAISearch_ProcessRequest(t *testing.T) {
+	const (
+		dataStore = "projects/p/locations/global/collections/default_collection/dataStores/faq"
+		engine    = "projects/p/locations/global/collections/default_collection/engines/support"
+	)
+	testCases := []struct {
+		name     string
+		tool     geminitool.VertexAISearch
+		wantTool *genai.Tool
+		wantErr  bool
+	}{
+		{
+			name: "data store",
+			tool: geminitool.VertexAISearch{DataStoreID: dataStore, Filter: `lang: ANY("en")`, MaxResults: 5},
+			wantTool: &genai.Tool{Retrieval: &genai.Retrieval{VertexAISearch: &genai.VertexAISearch{
+				Datastore: dataStore, Filter: `lang: ANY("en")`, MaxResults: genai.Ptr[int32](5),
+			}}},
+		},
+		{
+			name: "engine with data store specs",
+			tool: geminitool.VertexAISearch{
+				SearchEngineID: engine,
+				DataStoreSpecs: []*genai.VertexAISearchDataStoreSpec{{DataStore: dataStore}},
+			},
+			wantTool: &genai.Tool{Retrieval: &genai.Retrieval{VertexAISearch: &genai.VertexAISearch{
+				Engine: engine, DataStoreSpecs: []*genai.VertexAISearchDataStoreSpec{{DataStore: dataStore}},
+			}}},
+		},
+		{name: "no data store", tool: geminitool.VertexAISearch{}, wantErr: true},
+		{name: "data store and engine", tool: geminitool.VertexAISearch{DataStoreID: dataStore, SearchEngineID: engine}, wantErr: true},
+		{name: "data store ID only", tool: geminitool.VertexAISearch{DataStoreID: "faq"}, wantErr: true},
+		{name: "specs without engine", tool: geminitool.VertexAISearch{DataStoreID: dataStore, DataStoreSpecs: []*genai.VertexAISearchDataStoreSpec{{DataStore: dataStore}}}, wantErr: true},
+		{name: "too many results", tool: geminitool.VertexAISearch{DataStoreID: dataStore, MaxResults: 11}, wantErr: true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &model.LLMRequest{}
+			err := tt.tool.ProcessRequest(nil, req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff([]*genai.Tool{tt.wantTool}, req.Config.Tools); diff != "" {
+				t.Errorf("tools mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
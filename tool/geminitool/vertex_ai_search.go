@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geminitool
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// VertexAISearch is a built-in tool with which Gemini models ground their
+// responses in the documents of a Vertex AI Search (Discovery Engine) data
+// store or engine. The search runs within the model; it requires the
+// Vertex AI backend.
+type VertexAISearch struct {
+	// DataStoreID is the resource name of the data store, e.g.
+	// "projects/my-project/locations/global/collections/default_collection/dataStores/my-store".
+	// Exactly one of DataStoreID and SearchEngineID is required.
+	DataStoreID string
+	// SearchEngineID is the resource name of the engine, e.g.
+	// "projects/my-project/locations/global/collections/default_collection/engines/my-engine".
+	SearchEngineID string
+	// DataStoreSpecs select and filter the data stores of the engine.
+	// Optional: only with SearchEngineID.
+	DataStoreSpecs []*genai.VertexAISearchDataStoreSpec
+	// Filter filters the searched documents, e.g. `category: ANY("faq")`.
+	// Optional.
+	Filter string
+	// MaxResults is the number of search results, at most 10.
+	// Optional: defaults to 10.
+	MaxResults int32
+}
+
+// Name implements tool.Tool.
+func (s VertexAISearch) Name() string {
+	return "vertex_ai_search"
+}
+
+// Description implements tool.Tool.
+func (s VertexAISearch) Description() string {
+	return "Searches a Vertex AI Search data store to ground the responses in its documents."
+}
+
+// ProcessRequest adds the Vertex AI Search retrieval tool to the LLM
+// request.
+func (s VertexAISearch) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	search := &genai.VertexAISearch{
+		Datastore:      s.DataStoreID,
+		Engine:         s.SearchEngineID,
+		DataStoreSpecs: s.DataStoreSpecs,
+		Filter:         s.Filter,
+	}
+	if s.MaxResults > 0 {
+		search.MaxResults = genai.Ptr(s.MaxResults)
+	}
+	return setTool(req, &genai.Tool{
+		Retrieval: &genai.Retrieval{VertexAISearch: search},
+	})
+}
+
+func (s VertexAISearch) validate() error {
+	switch {
+	case (s.DataStoreID == "") == (s.SearchEngineID == ""):
+		return fmt.Errorf("vertex_ai_search: exactly one of DataStoreID and SearchEngineID is required")
+	case s.DataStoreID != "" && !isResourceName(s.DataStoreID, "dataStores"):
+		return fmt.Errorf("vertex_ai_search: DataStoreID %q is not a data store resource name", s.DataStoreID)
+	case s.SearchEngineID != "" && !isResourceName(s.SearchEngineID, "engines"):
+		return fmt.Errorf("vertex_ai_search: SearchEngineID %q is not an engine resource name", s.SearchEngineID)
+	case len(s.DataStoreSpecs) > 0 && s.SearchEngineID == "":
+		return fmt.Errorf("vertex_ai_search: DataStoreSpecs require SearchEngineID")
+	case s.MaxResults < 0 || s.MaxResults > 10:
+		return fmt.Errorf("vertex_ai_search: MaxResults %d is not between 1 and 10", s.MaxResults)
+	}
+	return nil
+}
+
+// isResourceName reports whether name is a resource name
+// "projects/<project>/locations/<location>/.../<collection>/<id>".
+func isResourceName(name, collection string) bool {
+	parts := strings.Split(name, "/")
+	return len(parts) >= 6 && parts[0] == "projects" && parts[2] == "locations" &&
+		parts[len(parts)-2] == collection && parts[len(parts)-1] != ""
+}
+
+// IsLongRunning implements tool.Tool.
+func (s VertexAISearch) IsLongRunning() bool {
+	return false
+}
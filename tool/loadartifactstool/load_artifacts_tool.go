@@ -21,6 +21,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/adk/agent"
@@ -127,6 +128,9 @@ func (t *artifactsTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest)
 }
 
 func (t *artifactsTool) appendInitialInstructions(ctx tool.Context, req *model.LLMRequest) error {
+	if ctx.Artifacts() == nil {
+		return nil
+	}
 	resp, err := ctx.Artifacts().List(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to list artifacts: %w", err)
@@ -150,31 +154,31 @@ func (t *artifactsTool) appendInitialInstructions(ctx tool.Context, req *model.L
 	return nil
 }
 
+// processLoadArtifactsFunctionCall appends the artifacts requested by the
+// load_artifacts function responses of the latest content, which may hold
+// the responses of parallel function calls, to the request.
 func (t *artifactsTool) processLoadArtifactsFunctionCall(ctx tool.Context, req *model.LLMRequest) error {
-	if len(req.Contents) == 0 {
+	if len(req.Contents) == 0 || ctx.Artifacts() == nil {
 		return nil
 	}
 	lastContent := req.Contents[len(req.Contents)-1]
-	if lastContent == nil || len(lastContent.Parts) == 0 {
-		return nil
-	}
-	firstPart := lastContent.Parts[0]
-	if firstPart.FunctionResponse == nil {
-		return nil
-	}
-
-	functionResponse := firstPart.FunctionResponse
-
-	if functionResponse.Name != "load_artifacts" {
-		return nil
-	}
-	artifactNamesRaw, ok := functionResponse.Response["artifact_names"]
-	if !ok {
+	if lastContent == nil {
 		return nil
 	}
-	artifactNames, ok := artifactNamesRaw.([]string)
-	if !ok {
-		return fmt.Errorf("invalid artifact names type: %T, expected []string", artifactNamesRaw)
+	var artifactNames []string
+	for _, part := range lastContent.Parts {
+		if part == nil || part.FunctionResponse == nil || part.FunctionResponse.Name != t.name {
+			continue
+		}
+		names, err := responseArtifactNames(part.FunctionResponse.Response)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if !slices.Contains(artifactNames, name) {
+				artifactNames = append(artifactNames, name)
+			}
+		}
 	}
 	if len(artifactNames) == 0 {
 		return nil
@@ -204,6 +208,30 @@ func (t *artifactsTool) processLoadArtifactsFunctionCall(ctx tool.Context, req *
 	return nil
 }
 
+// responseArtifactNames returns the artifact names of a load_artifacts
+// function response. The names are a []any once the response went through
+// the JSON encoding of a persistent session service.
+func responseArtifactNames(response map[string]any) ([]string, error) {
+	switch names := response["artifact_names"].(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		return names, nil
+	case []any:
+		out := make([]string, 0, len(names))
+		for _, n := range names {
+			name, ok := n.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid artifact name type: %T, expected string", n)
+			}
+			out = append(out, name)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("invalid artifact names type: %T, expected []string", names)
+	}
+}
+
 func (t *artifactsTool) loadIndividualArtifact(ctx context.Context, artifactsService agent.Artifacts, artifactName string) (*genai.Content, error) {
 	resp, err := artifactsService.Load(ctx, artifactName)
 	if err != nil {
@@ -294,3 +294,49 @@ func createToolContext(t *testing.T) tool.Context {
 
 	return toolinternal.NewToolContext(ctx, "", nil)
 }
+
+func TestLoadArtifactsTool_ProcessRequest_ParallelPersistedResponses(t *testing.T) {
+	tc := createToolContext(t)
+	for name, text := range map[string]string{"doc1.txt": "content1", "doc2.txt": "content2"} {
+		if _, err := tc.Artifacts().Save(t.Context(), name, genai.NewPartFromText(text)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The responses of parallel calls, with the names decoded from JSON as
+	// by the persistent session services.
+	llmRequest := &model.LLMRequest{
+		Contents: []*genai.Content{{
+			Role: "user",
+			Parts: []*genai.Part{
+				genai.NewPartFromFunctionResponse("other_function", map[string]any{"ok": true}),
+				genai.NewPartFromFunctionResponse("load_artifacts", map[string]any{"artifact_names": []any{"doc2.txt", "doc1.txt"}}),
+				genai.NewPartFromFunctionResponse("load_artifacts", map[string]any{"artifact_names": []any{"doc1.txt"}}),
+			},
+		}},
+	}
+	if err := loadartifactstool.New().(toolinternal.RequestProcessor).ProcessRequest(tc, llmRequest); err != nil {
+		t.Fatalf("ProcessRequest failed: %v", err)
+	}
+
+	var got []string
+	for _, c := range llmRequest.Contents[1:] {
+		got = append(got, c.Parts[0].Text+" "+c.Parts[1].Text)
+	}
+	want := []string{"Artifact doc2.txt is: content2", "Artifact doc1.txt is: content1"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("appended contents mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadArtifactsTool_ProcessRequest_NoArtifactService(t *testing.T) {
+	tc := toolinternal.NewToolContext(icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{}), "", nil)
+	llmRequest := &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromFunctionResponse("load_artifacts", map[string]any{"artifact_names": []string{"doc1.txt"}}, "user")},
+	}
+	if err := loadartifactstool.New().(toolinternal.RequestProcessor).ProcessRequest(tc, llmRequest); err != nil {
+		t.Fatalf("ProcessRequest failed: %v", err)
+	}
+	if len(llmRequest.Contents) != 1 {
+		t.Errorf("got %d contents, want the function response only", len(llmRequest.Contents))
+	}
+}
@@ -30,7 +30,8 @@ import (
 // MCP ToolSet connects to a MCP Server, retrieves MCP Tools into ADK Tools and
 // passes them to the LLM.
 // It uses https://github.com/modelcontextprotocol/go-sdk for MCP communication.
-// MCP session is created lazily on the first request to LLM.
+// MCP session is created lazily on the first request to LLM, and is closed
+// by the Close method of the returned toolset (it implements io.Closer).
 //
 // Usage: create MCP ToolSet with mcptoolset.New() and provide it to the
 // LLMAgent in the llmagent.Config.
@@ -149,3 +150,20 @@ func (s *set) getSession(ctx context.Context) (*mcp.ClientSession, error) {
 	s.session = session
 	return s.session, nil
 }
+
+// Close closes the MCP session, if any. The session is created again on the
+// next request.
+func (s *set) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.session == nil {
+		return nil
+	}
+	err := s.session.Close()
+	s.session = nil
+	if err != nil {
+		return fmt.Errorf("failed to close MCP session: %w", err)
+	}
+	return nil
+}
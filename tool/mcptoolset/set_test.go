@@ -157,7 +157,7 @@ func TestMCPToolSet(t *testing.T) {
 	}
 
 	if diff := cmp.Diff(wantEvents, gotEvents,
-		cmpopts.IgnoreFields(session.Event{}, "ID", "Timestamp", "InvocationID"),
+		cmpopts.IgnoreFields(session.Event{}, "ID", "Timestamp", "InvocationID", "ParentInvocationID"),
 		cmpopts.IgnoreFields(session.EventActions{}, "StateDelta"),
 		cmpopts.IgnoreFields(model.LLMResponse{}, "UsageMetadata", "AvgLogprobs", "FinishReason"),
 		cmpopts.IgnoreFields(genai.FunctionCall{}, "ID"),
@@ -0,0 +1,303 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package retrievaltool provides a retrieval-augmented generation (RAG)
+// building block: a knowledge base which splits documents into chunks and
+// embeds them, and a tool with which the agents search it.
+//
+//	kb, err := retrievaltool.NewKnowledgeBase(retrievaltool.Config{Embedder: embedder})
+//	...
+//	err = kb.AddURIs(ctx, "docs/faq.md", "gs://my-bucket/handbook.txt")
+//	...
+//	search, err := retrievaltool.New(kb, retrievaltool.ToolConfig{})
+//	agent, err := llmagent.New(llmagent.Config{Tools: []tool.Tool{search}, ...})
+package retrievaltool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/adk/embedding"
+	"google.golang.org/adk/memory"
+	"google.golang.org/api/option"
+	"google.golang.org/genai"
+)
+
+// Default values of the [Config] fields.
+const (
+	DefaultName         = "knowledge"
+	DefaultChunkSize    = 1000
+	DefaultChunkOverlap = 100
+)
+
+// Config is used to create a [KnowledgeBase].
+type Config struct {
+	// Embedder embeds the chunks and the queries.
+	Embedder embedding.Embedder
+	// Store stores the chunks and their embeddings, e.g. a pgvector store.
+	// The chunks are stored with the AppName Name and an empty UserID.
+	// Optional: defaults to memory.NewInMemoryVectorStore.
+	Store memory.VectorStore
+	// Name identifies the knowledge base in Store.
+	// Optional: defaults to [DefaultName].
+	Name string
+	// ChunkSize is the maximum number of characters of a chunk. The chunks
+	// end at a paragraph, line, sentence or word boundary when possible.
+	// Optional: defaults to [DefaultChunkSize].
+	ChunkSize int
+	// ChunkOverlap is the number of characters shared by successive
+	// chunks, so that the text around the boundaries is found.
+	// Optional: defaults to [DefaultChunkOverlap]; negative means none.
+	ChunkOverlap int
+	// Chunker splits the text of the documents into chunks, replacing the
+	// splitting by size.
+	// Optional.
+	Chunker func(text string) []string
+	// StorageOptions configure the Cloud Storage client reading the gs://
+	// URIs.
+	// Optional.
+	StorageOptions []option.ClientOption
+}
+
+// Document is a text to add to a knowledge base.
+type Document struct {
+	// Source identifies the document, e.g. its URI. It is returned with
+	// the chunks of the document.
+	Source string
+	Text   string
+}
+
+// Result is a chunk found by a search.
+type Result struct {
+	Source string
+	Text   string
+	// Score is the cosine similarity between the chunk and the query.
+	Score float64
+}
+
+// KnowledgeBase is a searchable collection of chunked and embedded
+// documents. It is safe for concurrent use.
+type KnowledgeBase struct {
+	cfg Config
+
+	storageOnce   sync.Once
+	storageClient *storage.Client
+	storageErr    error
+}
+
+// NewKnowledgeBase returns an empty knowledge base.
+func NewKnowledgeBase(cfg Config) (*KnowledgeBase, error) {
+	if cfg.Embedder == nil {
+		return nil, fmt.Errorf("embedder is required")
+	}
+	if cfg.Store == nil {
+		cfg.Store = memory.NewInMemoryVectorStore()
+	}
+	if cfg.Name == "" {
+		cfg.Name = DefaultName
+	}
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = DefaultChunkSize
+	}
+	if cfg.ChunkOverlap == 0 {
+		cfg.ChunkOverlap = DefaultChunkOverlap
+	}
+	if cfg.ChunkOverlap < 0 {
+		cfg.ChunkOverlap = 0
+	}
+	if cfg.ChunkOverlap >= cfg.ChunkSize {
+		return nil, fmt.Errorf("chunk overlap %d must be smaller than the chunk size %d", cfg.ChunkOverlap, cfg.ChunkSize)
+	}
+	return &KnowledgeBase{cfg: cfg}, nil
+}
+
+// Add splits the documents into chunks, embeds them and stores them. The
+// chunks of a document replace the ones previously added with the same
+// source and index; a document which shrank keeps its extra chunks.
+func (kb *KnowledgeBase) Add(ctx context.Context, docs ...Document) error {
+	for _, doc := range docs {
+		var chunks []string
+		if kb.cfg.Chunker != nil {
+			chunks = kb.cfg.Chunker(doc.Text)
+		} else {
+			chunks = split(doc.Text, kb.cfg.ChunkSize, kb.cfg.ChunkOverlap)
+		}
+		if len(chunks) == 0 {
+			continue
+		}
+		embeddings, err := kb.cfg.Embedder.Embed(ctx, chunks)
+		if err != nil {
+			return fmt.Errorf("failed to embed %q: %w", doc.Source, err)
+		}
+		if len(embeddings) != len(chunks) {
+			return fmt.Errorf("failed to embed %q: got %d embeddings for %d chunks", doc.Source, len(embeddings), len(chunks))
+		}
+		now := time.Now()
+		records := make([]*memory.VectorRecord, len(chunks))
+		for i, chunk := range chunks {
+			records[i] = &memory.VectorRecord{
+				ID:        fmt.Sprintf("%s/%s/%d", kb.cfg.Name, doc.Source, i),
+				AppName:   kb.cfg.Name,
+				SessionID: doc.Source,
+				Embedding: embeddings[i],
+				Entry: memory.Entry{
+					Content:   genai.NewContentFromText(chunk, genai.RoleUser),
+					Author:    doc.Source,
+					Timestamp: now,
+				},
+			}
+		}
+		if err := kb.cfg.Store.Upsert(ctx, records); err != nil {
+			return fmt.Errorf("failed to store %q: %w", doc.Source, err)
+		}
+	}
+	return nil
+}
+
+// AddURIs reads the text documents at the URIs and adds them: local paths,
+// file:// URIs or Cloud Storage gs:// URIs.
+func (kb *KnowledgeBase) AddURIs(ctx context.Context, uris ...string) error {
+	for _, uri := range uris {
+		text, err := kb.read(ctx, uri)
+		if err != nil {
+			return fmt.Errorf("failed to read %q: %w", uri, err)
+		}
+		if err := kb.Add(ctx, Document{Source: uri, Text: text}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (kb *KnowledgeBase) read(ctx context.Context, uri string) (string, error) {
+	var r io.ReadCloser
+	switch {
+	case strings.HasPrefix(uri, "gs://"):
+		bucket, object, ok := strings.Cut(strings.TrimPrefix(uri, "gs://"), "/")
+		if !ok || bucket == "" || object == "" {
+			return "", fmt.Errorf("invalid Cloud Storage URI")
+		}
+		client, err := kb.storage(ctx)
+		if err != nil {
+			return "", err
+		}
+		if r, err = client.Bucket(bucket).Object(object).NewReader(ctx); err != nil {
+			return "", err
+		}
+	case strings.HasPrefix(uri, "file://"):
+		u, err := url.Parse(uri)
+		if err != nil {
+			return "", err
+		}
+		if r, err = os.Open(u.Path); err != nil {
+			return "", err
+		}
+	default:
+		var err error
+		if r, err = os.Open(uri); err != nil {
+			return "", err
+		}
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(b) {
+		return "", fmt.Errorf("not a text document")
+	}
+	return string(b), nil
+}
+
+func (kb *KnowledgeBase) storage(ctx context.Context) (*storage.Client, error) {
+	kb.storageOnce.Do(func() {
+		kb.storageClient, kb.storageErr = storage.NewClient(ctx, kb.cfg.StorageOptions...)
+	})
+	return kb.storageClient, kb.storageErr
+}
+
+// Search returns the chunks the most similar to the query, the most similar
+// first.
+func (kb *KnowledgeBase) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	embeddings, err := kb.cfg.Embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed the query: %w", err)
+	}
+	if len(embeddings) != 1 {
+		return nil, fmt.Errorf("failed to embed the query: got %d embeddings", len(embeddings))
+	}
+	matches, err := kb.cfg.Store.Query(ctx, &memory.VectorQuery{AppName: kb.cfg.Name, Embedding: embeddings[0], Limit: limit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the knowledge base: %w", err)
+	}
+	results := make([]Result, 0, len(matches))
+	for _, m := range matches {
+		var text strings.Builder
+		if c := m.Record.Entry.Content; c != nil {
+			for _, p := range c.Parts {
+				text.WriteString(p.Text)
+			}
+		}
+		results = append(results, Result{Source: m.Record.SessionID, Text: text.String(), Score: m.Similarity})
+	}
+	return results, nil
+}
+
+// split splits the text into chunks of at most size runes, sharing overlap
+// runes. The chunks end at the last paragraph, line, sentence or word
+// boundary of their second half, if any.
+func split(text string, size, overlap int) []string {
+	runes := []rune(text)
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			end = boundary(runes, start+size/2, end)
+		}
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+		start = max(end-overlap, start+1)
+	}
+	return chunks
+}
+
+// boundary returns the end of the last paragraph, line, sentence or word in
+// runes[from:to], or to if there's none.
+func boundary(runes []rune, from, to int) int {
+	window := string(runes[from:to])
+	for _, seps := range [][]string{{"\n\n"}, {"\n"}, {". ", "? ", "! "}, {" "}} {
+		end := -1
+		for _, sep := range seps {
+			if i := strings.LastIndex(window, sep); i >= 0 {
+				end = max(end, i+len(sep))
+			}
+		}
+		if end >= 0 {
+			return from + utf8.RuneCountInString(window[:end])
+		}
+	}
+	return to
+}
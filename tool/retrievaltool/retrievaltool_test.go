@@ -0,0 +1,140 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retrievaltool_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/retrievaltool"
+	"google.golang.org/genai"
+)
+
+// vocabularyEmbedder embeds the texts as the counts of the words of its
+// vocabulary, and records the texts.
+type vocabularyEmbedder struct {
+	vocabulary []string
+	texts      []string
+}
+
+func (e *vocabularyEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.texts = append(e.texts, texts...)
+	var embeddings [][]float32
+	for _, text := range texts {
+		v := make([]float32, len(e.vocabulary))
+		for i, word := range e.vocabulary {
+			v[i] = float32(strings.Count(strings.ToLower(text), word))
+		}
+		embeddings = append(embeddings, v)
+	}
+	return embeddings, nil
+}
+
+func TestKnowledgeBase(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "handbook.md")
+	handbook := "Vacation policy. Employees get 25 days of vacation per year.\n\n" +
+		"Expense policy. Expenses are reimbursed within 30 days."
+	if err := os.WriteFile(path, []byte(handbook), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	embedder := &vocabularyEmbedder{vocabulary: []string{"vacation", "expense", "parking"}}
+	kb, err := retrievaltool.NewKnowledgeBase(retrievaltool.Config{Embedder: embedder, ChunkSize: 70, ChunkOverlap: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.AddURIs(t.Context(), path); err != nil {
+		t.Fatalf("AddURIs() error = %v", err)
+	}
+	if err := kb.Add(t.Context(), retrievaltool.Document{Source: "wiki/parking", Text: "Parking is free."}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	wantChunks := []string{
+		"Vacation policy. Employees get 25 days of vacation per year.",
+		"Expense policy. Expenses are reimbursed within 30 days.",
+		"Parking is free.",
+	}
+	if got := strings.Join(embedder.texts, "|"); got != strings.Join(wantChunks, "|") {
+		t.Errorf("embedded chunks = %q, want %q", embedder.texts, wantChunks)
+	}
+
+	results, err := kb.Search(t.Context(), "how many vacation days?", 1)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Source != path || results[0].Text != wantChunks[0] {
+		t.Errorf("Search() = %+v, want the vacation chunk of the handbook", results)
+	}
+
+	if err := kb.AddURIs(t.Context(), filepath.Join(dir, "missing.md")); err == nil {
+		t.Error("AddURIs() with a missing file succeeded, want error")
+	}
+	if _, err := retrievaltool.NewKnowledgeBase(retrievaltool.Config{Embedder: embedder, ChunkSize: 10, ChunkOverlap: 10}); err == nil {
+		t.Error("NewKnowledgeBase() with an overlap as large as the chunks succeeded, want error")
+	}
+}
+
+func TestTool(t *testing.T) {
+	kb, err := retrievaltool.NewKnowledgeBase(retrievaltool.Config{
+		Embedder: &vocabularyEmbedder{vocabulary: []string{"vacation", "parking"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.Add(t.Context(),
+		retrievaltool.Document{Source: "vacation.md", Text: "Employees get 25 days of vacation."},
+		retrievaltool.Document{Source: "parking.md", Text: "Parking is free."},
+	); err != nil {
+		t.Fatal(err)
+	}
+	search, err := retrievaltool.New(kb, retrievaltool.ToolConfig{MaxResults: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromFunctionCall(retrievaltool.DefaultToolName, map[string]any{"query": "vacation days"}, genai.RoleModel),
+		genai.NewContentFromText("You get 25 days.", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, Tools: []tool.Tool{search}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+	if _, err := testutil.CollectTextParts(runner.Run(t, "session", "How many vacation days do I get?")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Requests) != 2 {
+		t.Fatalf("model called %d times, want 2", len(m.Requests))
+	}
+	contents := m.Requests[1].Contents
+	resp := contents[len(contents)-1].Parts[0].FunctionResponse
+	if resp == nil {
+		t.Fatalf("last content = %+v, want the function response", contents[len(contents)-1])
+	}
+	passages, _ := resp.Response["passages"].([]any)
+	if len(passages) != 1 {
+		t.Fatalf("passages = %v, want 1 passage", resp.Response)
+	}
+	if p, _ := passages[0].(map[string]any); p["source"] != "vacation.md" {
+		t.Errorf("passage = %v, want the vacation passage", p)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retrievaltool
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// Default values of the [ToolConfig] fields.
+const (
+	DefaultToolName    = "search_knowledge_base"
+	DefaultMaxResults  = 5
+	defaultDescription = "Searches the knowledge base for the passages relevant to the query and returns them with their source.\n" +
+		"Call it to answer the questions about the documents of the knowledge base, and cite the sources of the passages you use.\n"
+)
+
+// ToolConfig configures the search tool of a knowledge base.
+type ToolConfig struct {
+	// Name is the name of the tool.
+	// Optional: defaults to [DefaultToolName].
+	Name string
+	// Description tells the model what the knowledge base is about.
+	// Optional: defaults to a generic description.
+	Description string
+	// MaxResults is the number of passages returned by a search.
+	// Optional: defaults to [DefaultMaxResults].
+	MaxResults int
+	// MinScore is the minimum similarity between a passage and the query
+	// for the passage to be returned.
+	// Optional: if zero, the MaxResults most similar passages are returned.
+	MinScore float64
+}
+
+// Args are the arguments of the tool.
+type Args struct {
+	Query string `json:"query" jsonschema:"the search query, e.g. a question or keywords"`
+}
+
+// Passage is a passage returned by the tool.
+type Passage struct {
+	Source string  `json:"source"`
+	Text   string  `json:"text"`
+	Score  float64 `json:"score"`
+}
+
+// Results are the results of the tool.
+type Results struct {
+	Passages []Passage `json:"passages"`
+}
+
+// New creates a tool searching the knowledge base.
+func New(kb *KnowledgeBase, cfg ToolConfig) (tool.Tool, error) {
+	if kb == nil {
+		return nil, errors.New("knowledge base is required")
+	}
+	if cfg.Name == "" {
+		cfg.Name = DefaultToolName
+	}
+	if cfg.Description == "" {
+		cfg.Description = defaultDescription
+	}
+	if cfg.MaxResults <= 0 {
+		cfg.MaxResults = DefaultMaxResults
+	}
+	search := func(ctx tool.Context, args Args) (Results, error) {
+		if args.Query == "" {
+			return Results{}, errors.New("query is required")
+		}
+		found, err := kb.Search(ctx, args.Query, cfg.MaxResults)
+		if err != nil {
+			return Results{}, err
+		}
+		res := Results{Passages: []Passage{}}
+		for _, r := range found {
+			if cfg.MinScore == 0 || r.Score >= cfg.MinScore {
+				res.Passages = append(res.Passages, Passage(r))
+			}
+		}
+		return res, nil
+	}
+	t, err := functiontool.New(functiontool.Config{Name: cfg.Name, Description: cfg.Description}, search)
+	if err != nil {
+		return nil, fmt.Errorf("error creating retrieval tool: %w", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqltoolset provides a toolset with which the agents explore and
+// query a SQL database through database/sql: list the tables, get their
+// columns, and execute SQL statements with parameters.
+//
+// Unless Config.AllowWrites is set, the toolset is read-only: only single
+// queries, e.g. SELECT or WITH statements, are accepted, and they run in a
+// read-only transaction that is rolled back. The results are returned as
+// columns and rows, at most Config.MaxRows of them.
+package sqltoolset
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/tool"
+)
+
+// Dialect is the SQL dialect of the database, used to introspect its
+// schema and to write the query parameters.
+type Dialect string
+
+// Supported dialects.
+const (
+	// SQLite is the dialect of SQLite, with ? parameters.
+	SQLite Dialect = "sqlite"
+	// Postgres is the dialect of PostgreSQL, with $1 parameters.
+	Postgres Dialect = "postgres"
+	// MySQL is the dialect of MySQL, with ? parameters.
+	MySQL Dialect = "mysql"
+)
+
+// Default values of the [Config] fields.
+const (
+	DefaultMaxRows = 50
+	DefaultTimeout = 30 * time.Second
+)
+
+// Names of the tools.
+const (
+	ListTablesTool     = "list_tables"
+	GetTableSchemaTool = "get_table_schema"
+	ExecuteSQLTool     = "execute_sql"
+)
+
+// Config is used to create the toolset.
+type Config struct {
+	// DB is the database. The toolset doesn't close it.
+	DB *sql.DB
+	// Dialect is the SQL dialect of DB.
+	Dialect Dialect
+	// AllowWrites allows execute_sql to run any statement, e.g. INSERT or
+	// CREATE TABLE, outside of a read-only transaction.
+	// Optional: by default only the queries are allowed.
+	AllowWrites bool
+	// MaxRows is the maximum number of rows returned by a query.
+	// Optional: defaults to [DefaultMaxRows].
+	MaxRows int
+	// Timeout is the maximum duration of a statement.
+	// Optional: defaults to [DefaultTimeout].
+	Timeout time.Duration
+	// ToolFilter selects the tools exposed to the model.
+	// Optional: if nil, all the tools are exposed.
+	ToolFilter tool.Predicate
+}
+
+// New returns the SQL toolset.
+func New(cfg Config) (tool.Toolset, error) {
+	if cfg.DB == nil {
+		return nil, errors.New("database is required")
+	}
+	switch cfg.Dialect {
+	case SQLite, Postgres, MySQL:
+	default:
+		return nil, fmt.Errorf("unsupported dialect %q", cfg.Dialect)
+	}
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = DefaultMaxRows
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	s := &set{cfg: cfg}
+	tools, err := s.newTools()
+	if err != nil {
+		return nil, err
+	}
+	s.tools = tools
+	return s, nil
+}
+
+type set struct {
+	cfg   Config
+	tools []tool.Tool
+}
+
+func (s *set) Name() string {
+	return "sql_toolset"
+}
+
+func (s *set) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
+	if s.cfg.ToolFilter == nil {
+		return s.tools, nil
+	}
+	var tools []tool.Tool
+	for _, t := range s.tools {
+		if s.cfg.ToolFilter(ctx, t) {
+			tools = append(tools, t)
+		}
+	}
+	return tools, nil
+}
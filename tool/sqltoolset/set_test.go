@@ -0,0 +1,162 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqltoolset_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	contextinternal "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/sqltoolset"
+)
+
+func newDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(`
+		CREATE TABLE orders (id INTEGER PRIMARY KEY, region TEXT NOT NULL, amount REAL);
+		INSERT INTO orders (region, amount) VALUES ('EMEA', 10.5), ('APAC', NULL), ('EMEA', 2);
+		CREATE VIEW totals AS SELECT region, SUM(amount) AS total FROM orders GROUP BY region;
+	`); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func run(t *testing.T, ts tool.Toolset, name string, args map[string]any) (string, error) {
+	t.Helper()
+	tools, err := ts.Tools(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := toolinternal.NewToolContext(contextinternal.NewInvocationContext(t.Context(), contextinternal.InvocationContextParams{}), "", nil)
+	for _, tl := range tools {
+		if tl.Name() == name {
+			got, err := tl.(toolinternal.FunctionTool).Run(ctx, args)
+			b, _ := json.Marshal(got)
+			return string(b), err
+		}
+	}
+	t.Fatalf("no tool %q", name)
+	return "", nil
+}
+
+func TestToolset(t *testing.T) {
+	db := newDB(t)
+	ts, err := sqltoolset.New(sqltoolset.Config{DB: db, Dialect: sqltoolset.SQLite, MaxRows: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]any
+		want string
+	}{
+		{
+			name: "list tables",
+			tool: sqltoolset.ListTablesTool,
+			args: map[string]any{},
+			want: `{"tables":[{"name":"orders","type":"table"},{"name":"totals","type":"view"}]}`,
+		},
+		{
+			name: "table schema",
+			tool: sqltoolset.GetTableSchemaTool,
+			args: map[string]any{"table": "orders"},
+			want: `{"columns":[{"name":"id","nullable":true,"type":"INTEGER"},{"name":"region","nullable":false,"type":"TEXT"},{"name":"amount","nullable":true,"type":"REAL"}],"table":"orders"}`,
+		},
+		{
+			name: "truncated query",
+			tool: sqltoolset.ExecuteSQLTool,
+			args: map[string]any{"query": "SELECT id, region, amount FROM orders ORDER BY id"},
+			want: `{"columns":["id","region","amount"],"rows":[[1,"EMEA",10.5],[2,"APAC",null]],"truncated":true}`,
+		},
+		{
+			name: "parameters",
+			tool: sqltoolset.ExecuteSQLTool,
+			args: map[string]any{"query": "-- totals\nSELECT total FROM totals WHERE region = ?;", "params": []any{"EMEA"}},
+			want: `{"columns":["total"],"rows":[[12.5]],"truncated":false}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := run(t, ts, tc.tool, tc.args)
+			if err != nil || got != tc.want {
+				t.Errorf("%s = %s, %v, want %s", tc.tool, got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestToolset_ReadOnly(t *testing.T) {
+	db := newDB(t)
+	ts, err := sqltoolset.New(sqltoolset.Config{DB: db, Dialect: sqltoolset.SQLite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for query, wantErr := range map[string]string{
+		"DELETE FROM orders":                          "read-only",
+		"/* cleanup */ drop table orders":             "read-only",
+		"SELECT 1; DELETE FROM orders":                "single statement",
+		"WITH x AS (SELECT 1) DELETE FROM orders":     "readonly",
+		"SELECT ';' FROM orders; -- DELETE (ignored)": "",
+	} {
+		_, err := run(t, ts, sqltoolset.ExecuteSQLTool, map[string]any{"query": query})
+		if wantErr == "" && err != nil || wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("execute_sql(%q) error = %v, want %q", query, err, wantErr)
+		}
+	}
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM orders").Scan(&n); err != nil || n != 3 {
+		t.Errorf("orders = %d, %v, want the 3 orders unchanged", n, err)
+	}
+	// The connections are writable again after the read-only statements.
+	if _, err := db.Exec("INSERT INTO orders (region) VALUES ('AMER')"); err != nil {
+		t.Errorf("insert after read-only statements error = %v", err)
+	}
+}
+
+func TestToolset_AllowWrites(t *testing.T) {
+	db := newDB(t)
+	ts, err := sqltoolset.New(sqltoolset.Config{DB: db, Dialect: sqltoolset.SQLite, AllowWrites: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := run(t, ts, sqltoolset.ExecuteSQLTool, map[string]any{"query": "UPDATE orders SET amount = ? WHERE region = ?", "params": []any{1, "EMEA"}})
+	if err != nil || got != `{"rows_affected":2}` {
+		t.Errorf("execute_sql = %s, %v, want 2 rows affected", got, err)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	if _, err := sqltoolset.New(sqltoolset.Config{Dialect: sqltoolset.SQLite}); err == nil {
+		t.Error("New without database succeeded, want error")
+	}
+	if _, err := sqltoolset.New(sqltoolset.Config{DB: &sql.DB{}, Dialect: "oracle"}); err == nil {
+		t.Error("New with an unsupported dialect succeeded, want error")
+	}
+}
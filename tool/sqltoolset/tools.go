@@ -0,0 +1,350 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqltoolset
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// TableArgs are the arguments of get_table_schema.
+type TableArgs struct {
+	Table string `json:"table" jsonschema:"the table name"`
+}
+
+// SQLArgs are the arguments of execute_sql.
+type SQLArgs struct {
+	Query  string `json:"query" jsonschema:"a single SQL statement"`
+	Params []any  `json:"params,omitempty" jsonschema:"the values of the parameters of the statement, in order"`
+}
+
+func (s *set) newTools() ([]tool.Tool, error) {
+	var tools []tool.Tool
+	add := func(t tool.Tool, err error) error {
+		if err != nil {
+			return fmt.Errorf("error creating SQL tool: %w", err)
+		}
+		tools = append(tools, t)
+		return nil
+	}
+	placeholder := "?"
+	if s.cfg.Dialect == Postgres {
+		placeholder = "$1, $2, ..."
+	}
+	execDescription := fmt.Sprintf("Executes a single %s SQL statement and returns the columns and at most %d rows of its result.\n"+
+		"Pass the values in params and refer to them with %s placeholders, instead of writing them in the statement.\n", s.cfg.Dialect, s.cfg.MaxRows, placeholder)
+	if !s.cfg.AllowWrites {
+		execDescription += "The database is read-only: only queries are allowed.\n"
+	}
+	if err := errors.Join(
+		add(functiontool.New(functiontool.Config{
+			Name:        ListTablesTool,
+			Description: "Lists the tables and the views of the database.",
+		}, s.listTables)),
+		add(functiontool.New(functiontool.Config{
+			Name:        GetTableSchemaTool,
+			Description: "Returns the columns of a table or a view, with their type and whether they are nullable.",
+		}, s.getTableSchema)),
+		add(functiontool.New(functiontool.Config{
+			Name:        ExecuteSQLTool,
+			Description: execDescription,
+		}, s.executeSQL)),
+	); err != nil {
+		return nil, err
+	}
+	return tools, nil
+}
+
+// introspection are the queries listing the tables, and the columns of the
+// table in their parameter, in each dialect. They return the name and the
+// type of the tables, and the name, the type and the nullability of the
+// columns.
+var introspection = map[Dialect]struct{ tables, columns string }{
+	SQLite: {
+		tables:  `SELECT name, type FROM sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name`,
+		columns: `SELECT name, type, "notnull" = 0 FROM pragma_table_info(?) ORDER BY cid`,
+	},
+	Postgres: {
+		tables:  `SELECT table_name, table_type FROM information_schema.tables WHERE table_schema = current_schema() ORDER BY table_name`,
+		columns: `SELECT column_name, data_type, is_nullable = 'YES' FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position`,
+	},
+	MySQL: {
+		tables:  `SELECT table_name, table_type FROM information_schema.tables WHERE table_schema = DATABASE() ORDER BY table_name`,
+		columns: `SELECT column_name, column_type, is_nullable = 'YES' FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position`,
+	},
+}
+
+func (s *set) listTables(ctx tool.Context, _ struct{}) (map[string]any, error) {
+	qctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	rows, err := s.cfg.DB.QueryContext(qctx, introspection[s.cfg.Dialect].tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tables: %w", err)
+	}
+	defer rows.Close()
+	tables := []map[string]any{}
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			return nil, fmt.Errorf("failed to list the tables: %w", err)
+		}
+		tables = append(tables, map[string]any{"name": name, "type": strings.ToLower(typ)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list the tables: %w", err)
+	}
+	return map[string]any{"tables": tables}, nil
+}
+
+func (s *set) getTableSchema(ctx tool.Context, args TableArgs) (map[string]any, error) {
+	if args.Table == "" {
+		return nil, errors.New("table is required")
+	}
+	qctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	rows, err := s.cfg.DB.QueryContext(qctx, introspection[s.cfg.Dialect].columns, args.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the columns: %w", err)
+	}
+	defer rows.Close()
+	columns := []map[string]any{}
+	for rows.Next() {
+		var (
+			name, typ string
+			nullable  bool
+		)
+		if err := rows.Scan(&name, &typ, &nullable); err != nil {
+			return nil, fmt.Errorf("failed to get the columns: %w", err)
+		}
+		columns = append(columns, map[string]any{"name": name, "type": typ, "nullable": nullable})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get the columns: %w", err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %q not found", args.Table)
+	}
+	return map[string]any{"table": args.Table, "columns": columns}, nil
+}
+
+// queryKeywords are the first keywords of the statements returning rows.
+var queryKeywords = map[string]bool{
+	"SELECT": true, "WITH": true, "VALUES": true, "EXPLAIN": true, "SHOW": true, "DESCRIBE": true, "TABLE": true,
+}
+
+func (s *set) executeSQL(ctx tool.Context, args SQLArgs) (map[string]any, error) {
+	keyword, multiple := parseStatement(args.Query)
+	if keyword == "" {
+		return nil, errors.New("query is required")
+	}
+	if multiple {
+		return nil, errors.New("only a single statement is allowed")
+	}
+	isQuery := queryKeywords[keyword]
+	if !s.cfg.AllowWrites && !isQuery {
+		return nil, fmt.Errorf("the database is read-only, the statement is a %s statement", keyword)
+	}
+	qctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	if s.cfg.AllowWrites {
+		if isQuery {
+			return s.query(qctx, s.cfg.DB, args)
+		}
+		res, err := s.cfg.DB.ExecContext(qctx, args.Query, args.Params...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute the statement: %w", err)
+		}
+		out := map[string]any{}
+		if n, err := res.RowsAffected(); err == nil {
+			out["rows_affected"] = n
+		}
+		return out, nil
+	}
+
+	// The statement runs in a read-only transaction on a dedicated
+	// connection, so that statements modifying the data, e.g. in a WITH
+	// clause, fail. SQLite ignores the read-only transactions: its
+	// connection is made read-only with a pragma instead.
+	conn, err := s.cfg.DB.Conn(qctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+	defer conn.Close()
+	if s.cfg.Dialect == SQLite {
+		if _, err := conn.ExecContext(qctx, "PRAGMA query_only = ON"); err != nil {
+			return nil, fmt.Errorf("failed to make the connection read-only: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "PRAGMA query_only = OFF")
+	}
+	tx, err := conn.BeginTx(qctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin a read-only transaction: %w", err)
+	}
+	defer tx.Rollback()
+	return s.query(qctx, tx, args)
+}
+
+// queryer is implemented by *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// query runs the query and returns its result as columns and rows.
+func (s *set) query(ctx context.Context, db queryer, args SQLArgs) (map[string]any, error) {
+	rows, err := db.QueryContext(ctx, args.Query, args.Params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute the query: %w", err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute the query: %w", err)
+	}
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	result := [][]any{}
+	truncated := false
+	for rows.Next() {
+		if len(result) == s.cfg.MaxRows {
+			truncated = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("failed to read the rows: %w", err)
+		}
+		row := make([]any, len(values))
+		for i, v := range values {
+			row[i] = convertValue(v)
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the rows: %w", err)
+	}
+	return map[string]any{"columns": columns, "rows": result, "truncated": truncated}, nil
+}
+
+// convertValue converts a value scanned by the driver to a JSON value.
+func convertValue(v any) any {
+	switch v := v.(type) {
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return base64.StdEncoding.EncodeToString(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+// parseStatement returns the first keyword of the SQL statement, in upper
+// case, and whether the query has more than one statement. It skips the
+// comments, and the semicolons in the quoted strings and identifiers, and in
+// the Postgres dollar-quoted strings.
+func parseStatement(query string) (keyword string, multiple bool) {
+	ended := false
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case strings.HasPrefix(query[i:], "--"):
+			if j := strings.IndexByte(query[i:], '\n'); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(query)
+			}
+			continue
+		case strings.HasPrefix(query[i:], "/*"):
+			if j := strings.Index(query[i+2:], "*/"); j >= 0 {
+				i += j + 4
+			} else {
+				i = len(query)
+			}
+			continue
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == ';':
+			ended = true
+			i++
+			continue
+		}
+		if ended {
+			return keyword, true
+		}
+		switch c {
+		case '\'', '"', '`':
+			j := strings.IndexByte(query[i+1:], c)
+			if j < 0 {
+				return keyword, false
+			}
+			i += j + 2
+		case '$':
+			tag := dollarTag(query[i:])
+			if tag == "" || i > 0 && isWordByte(query[i-1]) {
+				i++
+				break
+			}
+			j := strings.Index(query[i+len(tag):], tag)
+			if j < 0 {
+				return keyword, false
+			}
+			i += j + 2*len(tag)
+		default:
+			j := i
+			for j < len(query) && isWordByte(query[j]) {
+				j++
+			}
+			if j == i {
+				j++
+			} else if keyword == "" {
+				keyword = strings.ToUpper(query[i:j])
+			}
+			i = j
+		}
+	}
+	return keyword, false
+}
+
+// dollarTag returns the opening tag of the dollar-quoted string at the start
+// of s, such as "$$" or "$body$", or "" if there's none.
+func dollarTag(s string) string {
+	for j := 1; j < len(s); j++ {
+		switch c := s[j]; {
+		case c == '$':
+			return s[:j+1]
+		case !isWordByte(c) || j == 1 && '0' <= c && c <= '9':
+			return ""
+		}
+	}
+	return ""
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
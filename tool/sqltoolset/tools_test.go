@@ -0,0 +1,38 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqltoolset
+
+import "testing"
+
+func TestParseStatement(t *testing.T) {
+	for _, tc := range []struct {
+		query        string
+		wantKeyword  string
+		wantMultiple bool
+	}{
+		{query: "SELECT 1;", wantKeyword: "SELECT"},
+		{query: "SELECT 1; DELETE FROM orders", wantKeyword: "SELECT", wantMultiple: true},
+		{query: "SELECT $$a; DELETE FROM orders$$", wantKeyword: "SELECT"},
+		{query: "DO $body$ BEGIN; DELETE FROM orders; END $body$;", wantKeyword: "DO"},
+		{query: "SELECT $body$ $$; $body$; DELETE FROM orders", wantKeyword: "SELECT", wantMultiple: true},
+		{query: "SELECT * FROM orders WHERE id = $1; DELETE FROM orders WHERE id = $1", wantKeyword: "SELECT", wantMultiple: true},
+		{query: "SELECT a$b FROM t; DELETE FROM t$", wantKeyword: "SELECT", wantMultiple: true},
+	} {
+		keyword, multiple := parseStatement(tc.query)
+		if keyword != tc.wantKeyword || multiple != tc.wantMultiple {
+			t.Errorf("parseStatement(%q) = %q, %v, want %q, %v", tc.query, keyword, multiple, tc.wantKeyword, tc.wantMultiple)
+		}
+	}
+}
@@ -19,13 +19,21 @@ package tool
 
 import (
 	"context"
+	"errors"
+	"io"
+	"time"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/session"
 )
 
 // Tool defines the interface for a callable tool.
+//
+// The agents call their tools sequentially by default. Tools used by agents
+// running the calls concurrently, e.g. with
+// llmagent.Config.MaxConcurrentToolCalls, must be safe for concurrent use.
 type Tool interface {
 	// Name returns the name of the tool.
 	Name() string
@@ -36,6 +44,39 @@ type Tool interface {
 	IsLongRunning() bool
 }
 
+// CallError is returned by the agents when a tool or a toolset can't be
+// called, e.g. the model called an unknown tool. The errors of the tools
+// themselves are reported to the model instead. Its message is the message of
+// the underlying error.
+type CallError struct {
+	// Tool is the name of the tool or of the toolset.
+	Tool string
+	Err  error
+}
+
+func (e *CallError) Error() string { return e.Err.Error() }
+
+func (e *CallError) Unwrap() error { return e.Err }
+
+// ErrToolNotFound is wrapped by the errors of the agents when the model calls
+// a tool which the agent doesn't have.
+var ErrToolNotFound = errors.New("unknown tool")
+
+// Requirements documents what a tool needs to be called, so that catalogs of
+// tools can show them to developers, see runner.Runner.Tools.
+type Requirements struct {
+	// Auth describes the credential the tool requests with
+	// Context.RequestCredential, if any.
+	Auth *auth.Config
+	// Timeout bounds the duration of a call of the tool. Zero means no timeout.
+	Timeout time.Duration
+}
+
+// Documented is implemented by the tools documenting their requirements.
+type Documented interface {
+	Requirements() Requirements
+}
+
 // Context defines the interface for the context passed to a tool when it's
 // called. It provides access to invocation-specific information and allows
 // the tool to interact with the agent's state and memory.
@@ -51,10 +92,27 @@ type Context interface {
 	Actions() *session.EventActions
 	// SearchMemory performs a semantic search on the agent's memory.
 	SearchMemory(context.Context, string) (*memory.SearchResponse, error)
+
+	// RequestCredential asks the client for the credential described by cfg.
+	// Once the tool returns, the agent emits an auth.RequestCredentialFunctionName
+	// function call and waits for the client to respond with the credential.
+	// The tool is then called again with the same arguments, and Credential
+	// returns the exchanged credential.
+	RequestCredential(cfg *auth.Config)
+	// Credential returns the credential provided by the client for cfg, or nil
+	// if the client hasn't provided one yet. Credentials are kept in the
+	// credential service of the runner, if any, and expired OAuth2 access
+	// tokens are refreshed, so toolsets can authenticate their calls without
+	// asking the user again.
+	Credential(cfg *auth.Config) (*auth.Credential, error)
 }
 
 // Toolset is an interface for a collection of tools. It allows grouping
 // related tools together and providing them to an agent.
+//
+// Toolsets holding resources, e.g. connections to a tool server, can
+// implement io.Closer to release them when the runner is closed, see
+// runner.Runner.Close.
 type Toolset interface {
 	// Name returns the name of the toolset.
 	Name() string
@@ -64,6 +122,40 @@ type Toolset interface {
 	Tools(ctx agent.ReadonlyContext) ([]Tool, error)
 }
 
+// FilterToolset returns a toolset which exposes only the tools of ts for which
+// the predicate returns true. The predicate is evaluated on every request, so
+// it can select tools based on the invocation, e.g. the role of the user kept
+// in the session state. Closing the returned toolset closes ts.
+func FilterToolset(ts Toolset, predicate Predicate) Toolset {
+	return &filteredToolset{Toolset: ts, predicate: predicate}
+}
+
+type filteredToolset struct {
+	Toolset
+	predicate Predicate
+}
+
+func (s *filteredToolset) Tools(ctx agent.ReadonlyContext) ([]Tool, error) {
+	tools, err := s.Toolset.Tools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var filtered []Tool
+	for _, t := range tools {
+		if s.predicate(ctx, t) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered, nil
+}
+
+func (s *filteredToolset) Close() error {
+	if c, ok := s.Toolset.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 // Predicate is a function which decides whether a tool should be exposed to LLM.
 type Predicate func(ctx agent.ReadonlyContext, tool Tool) bool
 
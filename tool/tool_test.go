@@ -17,6 +17,7 @@ package tool_test
 import (
 	"testing"
 
+	"google.golang.org/adk/agent"
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/agenttool"
@@ -95,3 +96,31 @@ func TestTypes(t *testing.T) {
 	}
 
 }
+
+type staticToolset []tool.Tool
+
+func (staticToolset) Name() string { return "static" }
+
+func (ts staticToolset) Tools(agent.ReadonlyContext) ([]tool.Tool, error) { return ts, nil }
+
+func TestFilterToolset(t *testing.T) {
+	newTool := func(name string) tool.Tool {
+		tl, err := functiontool.New(functiontool.Config{Name: name}, func(tool.Context, int) (int, error) { return 0, nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tl
+	}
+	ts := tool.FilterToolset(staticToolset{newTool("read"), newTool("write")}, tool.StringPredicate([]string{"read"}))
+
+	tools, err := ts.Tools(nil)
+	if err != nil {
+		t.Fatalf("Tools() error = %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name() != "read" {
+		t.Errorf("Tools() = %v, want only the read tool", tools)
+	}
+	if got := ts.Name(); got != "static" {
+		t.Errorf("Name() = %q, want %q", got, "static")
+	}
+}
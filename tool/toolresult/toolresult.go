@@ -0,0 +1,145 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toolresult defines the strategies reducing the function responses
+// which are too large to be sent to the model, see
+// llmagent.Config.ToolResultLimit.
+package toolresult
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"google.golang.org/adk/summarizer"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// Strategy reduces a function response larger than the limit.
+type Strategy interface {
+	// Reduce returns the function response of the call of the tool which
+	// replaces result, whose JSON encoding exceeds maxBytes. The agent
+	// truncates the returned response if it still exceeds maxBytes.
+	Reduce(ctx tool.Context, toolName string, result map[string]any, maxBytes int) (map[string]any, error)
+}
+
+// Size returns the size of the JSON encoding of the function response.
+func Size(result map[string]any) int {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// Truncate returns the strategy keeping the beginning of the JSON encoding
+// of the function responses, as a string. The response tells the model that
+// the result was truncated and its original size.
+func Truncate() Strategy {
+	return truncate{}
+}
+
+type truncate struct{}
+
+func (truncate) Reduce(_ tool.Context, _ string, result map[string]any, maxBytes int) (map[string]any, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the tool result: %w", err)
+	}
+	out := map[string]any{"truncated": true, "original_bytes": len(data)}
+	withHead(out, "truncated_result", string(data), maxBytes)
+	return out, nil
+}
+
+// withHead sets the key of out to the longest beginning of text with which
+// out fits maxBytes, possibly empty.
+func withHead(out map[string]any, key, text string, maxBytes int) {
+	keep := min(len(text), maxBytes)
+	for {
+		head := text[:keep]
+		for !utf8.ValidString(head) && len(head) > 0 {
+			head = head[:len(head)-1]
+		}
+		out[key] = head
+		over := Size(out) - maxBytes
+		if over <= 0 || len(head) == 0 {
+			return
+		}
+		keep = max(len(head)-over, 0)
+	}
+}
+
+// Summarize returns the strategy replacing the function responses with
+// their summary by s, with the purpose summarizer.PurposeToolResult.
+func Summarize(s summarizer.Summarizer) Strategy {
+	return &summarize{summarizer: s}
+}
+
+type summarize struct {
+	summarizer summarizer.Summarizer
+}
+
+func (s *summarize) Reduce(ctx tool.Context, toolName string, result map[string]any, maxBytes int) (map[string]any, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the tool result: %w", err)
+	}
+	summary, err := s.summarizer.Summarize(ctx, &summarizer.Request{
+		Purpose:  summarizer.PurposeToolResult,
+		Contents: []*genai.Content{genai.NewContentFromText(string(data), genai.RoleUser)},
+		Context:  fmt.Sprintf("The text above is the result of the tool %q.", toolName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"summarized_result": summary,
+		"summarized":        true,
+		"original_bytes":    len(data),
+	}, nil
+}
+
+// SaveAsArtifact returns the strategy saving the function responses as JSON
+// artifacts, named after the tool and the function call, and replacing them
+// with the name of the artifact and as much of the beginning of the result
+// as fits the limit. The model can load the artifacts with the
+// loadartifactstool.
+func SaveAsArtifact() Strategy {
+	return saveAsArtifact{}
+}
+
+type saveAsArtifact struct{}
+
+func (saveAsArtifact) Reduce(ctx tool.Context, toolName string, result map[string]any, maxBytes int) (map[string]any, error) {
+	if ctx.Artifacts() == nil {
+		return nil, errors.New("no artifact service")
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the tool result: %w", err)
+	}
+	name := fmt.Sprintf("%s_%s.json", toolName, ctx.FunctionCallID())
+	if _, err := ctx.Artifacts().Save(ctx, name, genai.NewPartFromText(string(data))); err != nil {
+		return nil, fmt.Errorf("failed to save the tool result: %w", err)
+	}
+	out := map[string]any{
+		"artifact":       name,
+		"note":           "The result is too large; load the artifact to see all of it.",
+		"original_bytes": len(data),
+	}
+	withHead(out, "result_preview", string(data), maxBytes)
+	return out, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package userchoicetool provides a long-running tool with which the model
+// asks the user to choose among options, e.g. to clarify a request.
+//
+// The invocation pauses once the model called the tool. The client shows
+// the question and the options of the call, see [Requests], and sends the
+// choice of the user in the next run, see [Choice].
+package userchoicetool
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+// Name is the name of the tool.
+const Name = "get_user_choice"
+
+// Args are the arguments of the tool.
+type Args struct {
+	Question string   `json:"question,omitempty" jsonschema:"the question asked to the user"`
+	Options  []string `json:"options" jsonschema:"the options the user chooses from"`
+}
+
+func getUserChoice(ctx tool.Context, args Args) (map[string]any, error) {
+	if len(args.Options) == 0 {
+		return nil, errors.New("at least one option is required")
+	}
+	// The model isn't called with the pending response: the invocation
+	// ends, and the next one continues with the choice of the user.
+	ctx.Actions().SkipSummarization = true
+	return map[string]any{"status": "waiting for the choice of the user"}, nil
+}
+
+// New creates an instance of the get_user_choice tool.
+func New() (tool.Tool, error) {
+	t, err := functiontool.New(functiontool.Config{
+		Name: Name,
+		Description: "Asks the user to choose one of the options and returns the choice.\n" +
+			"Call it when the request of the user is ambiguous and a few options resolve it.\n",
+		IsLongRunning: true,
+	}, getUserChoice)
+	if err != nil {
+		return nil, fmt.Errorf("error creating user choice tool: %w", err)
+	}
+	return t, nil
+}
+
+// Request is a pending request of the model for the choice of the user.
+type Request struct {
+	// FunctionCallID is the ID of the call of the tool, which the choice
+	// responds to.
+	FunctionCallID string
+	Question       string
+	Options        []string
+}
+
+// Requests returns the requests for the choice of the user made in the
+// event by the model.
+func Requests(ev *session.Event) []Request {
+	if ev == nil || ev.Content == nil {
+		return nil
+	}
+	var reqs []Request
+	for _, p := range ev.Content.Parts {
+		if p == nil || p.FunctionCall == nil || p.FunctionCall.Name != Name {
+			continue
+		}
+		req := Request{FunctionCallID: p.FunctionCall.ID}
+		req.Question, _ = p.FunctionCall.Args["question"].(string)
+		switch options := p.FunctionCall.Args["options"].(type) {
+		case []string:
+			req.Options = options
+		case []any:
+			for _, o := range options {
+				if s, ok := o.(string); ok {
+					req.Options = append(req.Options, s)
+				}
+			}
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+// Choice returns the user content responding to the request with the given
+// function call ID with the choice of the user, to be sent in the next run.
+func Choice(functionCallID, choice string) *genai.Content {
+	content := genai.NewContentFromFunctionResponse(Name, map[string]any{"choice": choice}, genai.RoleUser)
+	content.Parts[0].FunctionResponse.ID = functionCallID
+	return content
+}
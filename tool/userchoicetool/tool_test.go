@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package userchoicetool_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/userchoicetool"
+	"google.golang.org/genai"
+)
+
+func TestUserChoice(t *testing.T) {
+	choiceTool, err := userchoicetool.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromFunctionCall(userchoicetool.Name, map[string]any{
+			"question": "Which size?",
+			"options":  []any{"small", "large"},
+		}, genai.RoleModel),
+		genai.NewContentFromText("One large pizza coming.", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: m, Tools: []tool.Tool{choiceTool}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	var reqs []userchoicetool.Request
+	for ev, err := range runner.Run(t, "session", "a pizza please") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		reqs = append(reqs, userchoicetool.Requests(ev)...)
+	}
+	if len(m.Requests) != 1 {
+		t.Errorf("model called %d times before the choice, want 1", len(m.Requests))
+	}
+	if len(reqs) != 1 || reqs[0].FunctionCallID == "" {
+		t.Fatalf("Requests() = %+v, want a request with the function call ID", reqs)
+	}
+	want := userchoicetool.Request{FunctionCallID: reqs[0].FunctionCallID, Question: "Which size?", Options: []string{"small", "large"}}
+	if diff := cmp.Diff(want, reqs[0]); diff != "" {
+		t.Errorf("Requests() mismatch (-want +got):\n%s", diff)
+	}
+
+	text, err := testutil.CollectTextParts(runner.RunContent(t, "session", userchoicetool.Choice(reqs[0].FunctionCallID, "large")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"One large pizza coming."}, text); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+	if len(m.Requests) != 2 {
+		t.Fatalf("model called %d times, want 2", len(m.Requests))
+	}
+	contents := m.Requests[1].Contents
+	got := contents[len(contents)-1].Parts[0].FunctionResponse
+	if got == nil || got.Response["choice"] != "large" {
+		t.Errorf("last content of the request = %+v, want the choice of the user", contents[len(contents)-1])
+	}
+}
@@ -0,0 +1,384 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webtool
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipped are the elements removed from the pages: the scripts, the
+// navigation and the other boilerplate around the content.
+var skipped = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Canvas: true, atom.Iframe: true, atom.Object: true,
+	atom.Nav: true, atom.Aside: true, atom.Footer: true, atom.Form: true,
+	atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true, atom.Dialog: true,
+}
+
+// blocks are the block elements, rendered as separate paragraphs.
+var blocks = map[atom.Atom]bool{
+	atom.Html: true, atom.Body: true, atom.Main: true, atom.Article: true, atom.Section: true,
+	atom.Header: true, atom.Div: true, atom.P: true, atom.Address: true, atom.Details: true,
+	atom.Summary: true, atom.Figure: true, atom.Figcaption: true, atom.Fieldset: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Pre: true, atom.Blockquote: true, atom.Table: true, atom.Hr: true,
+}
+
+// page is the content extracted from an HTML page.
+type page struct {
+	title    string
+	markdown string
+}
+
+// extract returns the title and the main content of the HTML document as
+// markdown. The content is the first main or article element if there is
+// one, else the body without its header. The links are resolved against
+// base.
+func extract(doc *html.Node, base *url.URL) page {
+	var title string
+	if n := find(doc, atom.Title); n != nil {
+		title = collapse(textContent(n))
+	}
+	c := &converter{base: base}
+	root := find(doc, atom.Main)
+	if root == nil {
+		root = find(doc, atom.Article)
+	}
+	if root == nil {
+		root = doc
+		c.skipHeader = true
+	}
+	c.blocks(root)
+	return page{title: strings.TrimSpace(title), markdown: c.out.String()}
+}
+
+// converter writes the markdown of the block elements.
+type converter struct {
+	base       *url.URL
+	skipHeader bool
+	out        strings.Builder
+}
+
+func (c *converter) skip(n *html.Node) bool {
+	return n.Type == html.CommentNode || n.Type == html.ElementNode && (skipped[n.DataAtom] || c.skipHeader && n.DataAtom == atom.Header)
+}
+
+// blocks writes the children of n, the consecutive inline nodes as a
+// paragraph.
+func (c *converter) blocks(n *html.Node) {
+	var inline strings.Builder
+	flush := func() {
+		c.paragraph(inline.String())
+		inline.Reset()
+	}
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		if c.skip(ch) {
+			continue
+		}
+		if ch.Type != html.ElementNode || !blocks[ch.DataAtom] {
+			inline.WriteString(c.inline(ch))
+			continue
+		}
+		flush()
+		c.block(ch)
+	}
+	flush()
+}
+
+func (c *converter) block(n *html.Node) {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		if text := c.inlineChildren(n); strings.TrimSpace(text) != "" {
+			level := int(n.Data[1] - '0')
+			c.paragraph(strings.Repeat("#", level) + " " + strings.ReplaceAll(text, "\n", " "))
+		}
+	case atom.P, atom.Dt, atom.Summary, atom.Figcaption:
+		c.paragraph(c.inlineChildren(n))
+	case atom.Pre:
+		if code := strings.Trim(textContent(n), "\n"); code != "" {
+			c.raw("```\n" + code + "\n```")
+		}
+	case atom.Hr:
+		c.raw("---")
+	case atom.Ul, atom.Ol:
+		c.list(n)
+	case atom.Blockquote:
+		sub := c.sub()
+		sub.blocks(n)
+		lines := strings.Split(sub.out.String(), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		c.raw(strings.Join(lines, "\n"))
+	case atom.Table:
+		c.table(n)
+	default:
+		c.blocks(n)
+	}
+}
+
+// sub returns a converter writing the content of a nested block.
+func (c *converter) sub() *converter {
+	return &converter{base: c.base, skipHeader: c.skipHeader}
+}
+
+// paragraph writes the inline text as a paragraph.
+func (c *converter) paragraph(text string) {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	c.raw(strings.Join(lines, "\n"))
+}
+
+// raw writes the markdown as a paragraph, as is.
+func (c *converter) raw(md string) {
+	if strings.TrimSpace(md) == "" {
+		return
+	}
+	if c.out.Len() > 0 {
+		c.out.WriteString("\n\n")
+	}
+	c.out.WriteString(strings.Trim(md, "\n"))
+}
+
+func (c *converter) list(n *html.Node) {
+	var items []string
+	i := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		i = start
+	}
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		sub := c.sub()
+		sub.blocks(li)
+		text := strings.ReplaceAll(sub.out.String(), "\n\n", "\n")
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(i) + ". "
+			i++
+		}
+		items = append(items, prefixLines(text, marker, strings.Repeat(" ", len(marker))))
+	}
+	c.raw(strings.Join(items, "\n"))
+}
+
+func (c *converter) table(n *html.Node) {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			if ch.Type != html.ElementNode {
+				continue
+			}
+			if ch.DataAtom != atom.Tr {
+				if ch.DataAtom != atom.Table {
+					walk(ch)
+				}
+				continue
+			}
+			var row []string
+			for cell := ch.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+					text := strings.Join(strings.Fields(c.inlineChildren(cell)), " ")
+					row = append(row, strings.ReplaceAll(text, "|", `\|`))
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return
+	}
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	var lines []string
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	c.raw(strings.Join(lines, "\n"))
+}
+
+func (c *converter) inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		b.WriteString(c.inline(ch))
+	}
+	return b.String()
+}
+
+// inline returns the markdown of the inline node, with the whitespace of
+// the text collapsed.
+func (c *converter) inline(n *html.Node) string {
+	if c.skip(n) {
+		return ""
+	}
+	switch n.Type {
+	case html.TextNode:
+		return collapse(n.Data)
+	case html.ElementNode:
+	default:
+		return c.inlineChildren(n)
+	}
+	switch n.DataAtom {
+	case atom.Br:
+		return "\n"
+	case atom.A:
+		text := strings.TrimSpace(c.inlineChildren(n))
+		if text == "" {
+			return ""
+		}
+		if href := c.resolve(attr(n, "href")); href != "" {
+			return "[" + text + "](" + href + ")"
+		}
+		return text
+	case atom.Img:
+		alt := collapse(attr(n, "alt"))
+		if src := c.resolve(attr(n, "src")); strings.TrimSpace(alt) != "" && src != "" {
+			return "![" + strings.TrimSpace(alt) + "](" + src + ")"
+		}
+		return ""
+	case atom.Strong, atom.B:
+		return emphasis(c.inlineChildren(n), "**")
+	case atom.Em, atom.I:
+		return emphasis(c.inlineChildren(n), "*")
+	case atom.Code, atom.Kbd, atom.Samp:
+		if code := strings.TrimSpace(textContent(n)); code != "" {
+			return "`" + code + "`"
+		}
+		return ""
+	}
+	text := c.inlineChildren(n)
+	if blocks[n.DataAtom] {
+		// A block inside an inline element, e.g. a div in a link.
+		return " " + text + " "
+	}
+	return text
+}
+
+// resolve returns the absolute http or https URL of the reference, or ""
+// if it isn't one, e.g. a javascript: URL.
+func (c *converter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if c.base != nil {
+		u = c.base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return u.String()
+}
+
+// emphasis wraps the text in the marker, outside of its surrounding
+// spaces.
+func emphasis(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	lead := text[:strings.Index(text, trimmed)]
+	trail := text[len(lead)+len(trimmed):]
+	return lead + marker + trimmed + marker + trail
+}
+
+// collapse replaces the runs of whitespace of the text with a space.
+func collapse(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" {
+			return " "
+		}
+		return ""
+	}
+	out := strings.Join(fields, " ")
+	if strings.TrimLeft(s[:1], " \t\n\r\f") == "" {
+		out = " " + out
+	}
+	if strings.TrimRight(s[len(s)-1:], " \t\n\r\f") == "" {
+		out += " "
+	}
+	return out
+}
+
+// prefixLines prefixes the first line of the text with first, and the
+// other non-empty lines with rest.
+func prefixLines(text, first, rest string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + line
+		case line != "":
+			lines[i] = rest + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func find(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		if found := find(ch, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		b.WriteString(textContent(ch))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,347 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webtool provides a tool with which the agents read web pages.
+//
+// The tool fetches the page of a URL, removes the boilerplate around its
+// content, e.g. the scripts, the navigation and the footer, and returns the
+// content as markdown, at most Config.MaxLength characters of it at a time.
+// The pages built with JavaScript can be rendered by a headless browser
+// service, see Config.RenderEndpoint.
+package webtool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// Default values of the [Config] fields.
+const (
+	DefaultName        = "fetch_web_page"
+	DefaultMaxLength   = 20000
+	DefaultMaxBodySize = 5 << 20
+	DefaultTimeout     = 30 * time.Second
+	DefaultUserAgent   = "adk-go-webtool/1.0"
+	defaultDescription = "Fetches a web page and returns its title and its main content as markdown.\n" +
+		"If the content is truncated, call it again with the returned next_offset to read the rest.\n"
+)
+
+// Config is used to create the tool.
+type Config struct {
+	// Name is the name of the tool.
+	// Optional: defaults to [DefaultName].
+	Name string
+	// Description tells the model when to use the tool.
+	// Optional: defaults to a generic description.
+	Description string
+	// MaxLength is the maximum number of characters of content returned
+	// by a call.
+	// Optional: defaults to [DefaultMaxLength].
+	MaxLength int
+	// MaxBodySize is the maximum size in bytes of the fetched pages.
+	// Optional: defaults to [DefaultMaxBodySize].
+	MaxBodySize int64
+	// Timeout is the maximum duration of a fetch.
+	// Optional: defaults to [DefaultTimeout].
+	Timeout time.Duration
+	// UserAgent is the User-Agent header of the requests.
+	// Optional: defaults to [DefaultUserAgent].
+	UserAgent string
+	// HTTPClient fetches the pages.
+	// Optional: defaults to a client refusing to connect to the loopback,
+	// private and link-local addresses, unless AllowPrivateNetworks is set.
+	HTTPClient *http.Client
+	// AllowPrivateNetworks allows the default client to fetch the pages of
+	// the private networks, e.g. of the intranet.
+	// Optional.
+	AllowPrivateNetworks bool
+	// RenderEndpoint is the URL of a headless browser service rendering
+	// the JavaScript of the pages, e.g. the /content API of browserless.
+	// The tool sends it a POST request with the JSON body {"url": "..."}
+	// and reads the rendered HTML from the response. Unless
+	// AllowPrivateNetworks is set, the pages whose host resolves to a
+	// non-public address aren't rendered; the redirects followed by the
+	// browser aren't checked.
+	// Optional: if empty, the pages aren't rendered.
+	RenderEndpoint string
+}
+
+// Args are the arguments of the tool.
+type Args struct {
+	URL    string `json:"url" jsonschema:"the http or https URL of the page"`
+	Offset int    `json:"offset,omitempty" jsonschema:"the character offset to read the content from, to continue reading a truncated page"`
+	Render bool   `json:"render,omitempty" jsonschema:"render the JavaScript of the page in a browser, for the pages whose content is built by scripts"`
+}
+
+// Result is the result of the tool.
+type Result struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Content     string `json:"content"`
+	TotalLength int    `json:"total_length"`
+	Truncated   bool   `json:"truncated,omitempty"`
+	NextOffset  int    `json:"next_offset,omitempty"`
+}
+
+// New creates the tool fetching the web pages.
+func New(cfg Config) (tool.Tool, error) {
+	if cfg.Name == "" {
+		cfg.Name = DefaultName
+	}
+	if cfg.Description == "" {
+		cfg.Description = defaultDescription
+	}
+	if cfg.MaxLength <= 0 {
+		cfg.MaxLength = DefaultMaxLength
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = DefaultMaxBodySize
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+	if cfg.RenderEndpoint != "" {
+		if _, err := url.ParseRequestURI(cfg.RenderEndpoint); err != nil {
+			return nil, fmt.Errorf("invalid render endpoint: %w", err)
+		}
+	}
+	f := &fetcher{cfg: cfg, client: cfg.HTTPClient, renderClient: http.DefaultClient}
+	if f.client == nil {
+		dialer := &net.Dialer{Timeout: cfg.Timeout}
+		if !cfg.AllowPrivateNetworks {
+			dialer.Control = publicOnly
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = dialer.DialContext
+		transport.Proxy = nil
+		f.client = &http.Client{Transport: transport}
+	}
+	return functiontool.New(functiontool.Config{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+	}, f.fetch)
+}
+
+type fetcher struct {
+	cfg          Config
+	client       *http.Client
+	renderClient *http.Client
+}
+
+func (f *fetcher) fetch(ctx tool.Context, args Args) (Result, error) {
+	u, err := url.Parse(args.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Result{}, fmt.Errorf("invalid URL %q: only http and https URLs are supported", args.URL)
+	}
+	if args.Offset < 0 {
+		return Result{}, fmt.Errorf("offset must not be negative, got %d", args.Offset)
+	}
+	fctx, cancel := context.WithTimeout(ctx, f.cfg.Timeout)
+	defer cancel()
+
+	var content, title string
+	if args.Render {
+		if f.cfg.RenderEndpoint == "" {
+			return Result{}, errors.New("rendering is not available, fetch the page without render")
+		}
+		// The browser service fetches the page itself: the address of the
+		// host is checked before, as the dialer of the default client does.
+		if !f.cfg.AllowPrivateNetworks {
+			if err := checkPublicHost(fctx, u.Hostname()); err != nil {
+				return Result{}, err
+			}
+		}
+		body, err := f.render(fctx, u)
+		if err != nil {
+			return Result{}, err
+		}
+		p, err := parse(body, "text/html; charset=utf-8", u)
+		if err != nil {
+			return Result{}, err
+		}
+		title, content = p.title, p.markdown
+	} else {
+		body, contentType, finalURL, err := f.get(fctx, u)
+		if err != nil {
+			return Result{}, err
+		}
+		u = finalURL
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		switch {
+		case mediaType == "text/html" || mediaType == "application/xhtml+xml" || mediaType == "":
+			p, err := parse(body, contentType, u)
+			if err != nil {
+				return Result{}, err
+			}
+			title, content = p.title, p.markdown
+		case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			r, err := charset.NewReader(bytes.NewReader(body), contentType)
+			if err != nil {
+				return Result{}, fmt.Errorf("failed to decode the page: %w", err)
+			}
+			b, err := io.ReadAll(r)
+			if err != nil {
+				return Result{}, fmt.Errorf("failed to decode the page: %w", err)
+			}
+			content = strings.TrimSpace(string(b))
+		default:
+			return Result{}, fmt.Errorf("unsupported content type %q", mediaType)
+		}
+	}
+
+	runes := []rune(content)
+	result := Result{URL: u.String(), Title: title, TotalLength: len(runes)}
+	if args.Offset > len(runes) {
+		return Result{}, fmt.Errorf("offset %d is after the end of the content, of %d characters", args.Offset, len(runes))
+	}
+	end := min(len(runes), args.Offset+f.cfg.MaxLength)
+	result.Content = string(runes[args.Offset:end])
+	if end < len(runes) {
+		result.Truncated = true
+		result.NextOffset = end
+	}
+	return result, nil
+}
+
+// get fetches the page, and returns its body, its content type and its URL
+// after the redirects.
+func (f *fetcher) get(ctx context.Context, u *url.URL) ([]byte, string, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.8")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to fetch the page: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", nil, fmt.Errorf("failed to fetch the page: %s", resp.Status)
+	}
+	body, err := f.read(resp.Body)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return body, resp.Header.Get("Content-Type"), resp.Request.URL, nil
+}
+
+// render returns the HTML of the page rendered by the headless browser
+// service.
+func (f *fetcher) render(ctx context.Context, u *url.URL) ([]byte, error) {
+	payload, err := json.Marshal(map[string]string{"url": u.String()})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.cfg.RenderEndpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("invalid render endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := f.renderClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the page: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to render the page: %s", resp.Status)
+	}
+	return f.read(resp.Body)
+}
+
+func (f *fetcher) read(r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, f.cfg.MaxBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the page: %w", err)
+	}
+	if int64(len(body)) > f.cfg.MaxBodySize {
+		return nil, fmt.Errorf("the page is larger than the limit of %d bytes", f.cfg.MaxBodySize)
+	}
+	return body, nil
+}
+
+// parse parses the HTML page, decoded from its charset, and extracts its
+// content.
+func parse(body []byte, contentType string, u *url.URL) (page, error) {
+	r, err := charset.NewReader(bytes.NewReader(body), contentType)
+	if err != nil {
+		return page{}, fmt.Errorf("failed to decode the page: %w", err)
+	}
+	doc, err := html.Parse(r)
+	if err != nil {
+		return page{}, fmt.Errorf("failed to parse the page: %w", err)
+	}
+	return extract(doc, u), nil
+}
+
+// publicOnly is the dialer control refusing the connections to the
+// loopback, private, link-local and unspecified addresses, after the DNS
+// resolution.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	return checkPublicIP(net.ParseIP(host))
+}
+
+// checkPublicHost resolves the host and refuses it if any of its addresses
+// isn't public.
+func checkPublicHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		return checkPublicIP(ip)
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %q: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if err := checkPublicIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sharedAddressSpace is the range of the carrier-grade NAT addresses
+// (RFC 6598), which net.IP.IsPrivate doesn't cover.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func checkPublicIP(ip net.IP) error {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || sharedAddressSpace.Contains(ip) || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("connection to the non-public address %s refused", ip)
+	}
+	return nil
+}
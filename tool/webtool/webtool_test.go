@@ -0,0 +1,264 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webtool_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	contextinternal "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/webtool"
+)
+
+const articlePage = `<!DOCTYPE html>
+<html>
+<head><title> Release notes </title><style>body { color: red }</style></head>
+<body>
+<header><a href="/">Home</a> <a href="/blog">Blog</a></header>
+<nav><ul><li><a href="/docs">Docs</a></li></ul></nav>
+<main>
+  <h1>Version   2.0</h1>
+  <p>The <strong>new</strong> version is <em>faster</em>.<br>See the <a href="/docs/upgrade">upgrade guide</a>
+  and <a href="javascript:void(0)">this</a>.</p>
+  <script>track()</script>
+  <h2>Changes</h2>
+  <ul>
+    <li>Call <code>Run()</code> to start
+      <ol start="3"><li>first</li><li>second</li></ol>
+    </li>
+    <li><p>Removed the v1 API.</p></li>
+  </ul>
+  <pre>go get example.com/pkg@v2
+go test ./...</pre>
+  <blockquote><p>Upgrade soon.</p><p>Really.</p></blockquote>
+  <table>
+    <thead><tr><th>Name</th><th>Speed</th></tr></thead>
+    <tbody><tr><td>v1</td><td>1x</td></tr><tr><td>v2 | new</td><td>2x</td></tr></tbody>
+  </table>
+  <img src="chart.png" alt="Speed chart"><img src="spacer.gif" alt="">
+</main>
+<footer>Copyright</footer>
+</body>
+</html>`
+
+const wantArticle = "# Version 2.0\n\n" +
+	"The **new** version is *faster*.\n" +
+	"See the [upgrade guide](%[1]s/docs/upgrade) and this.\n\n" +
+	"## Changes\n\n" +
+	"- Call `Run()` to start\n" +
+	"  3. first\n" +
+	"  4. second\n" +
+	"- Removed the v1 API.\n\n" +
+	"```\ngo get example.com/pkg@v2\ngo test ./...\n```\n\n" +
+	"> Upgrade soon.\n>\n> Really.\n\n" +
+	"| Name | Speed |\n| --- | --- |\n| v1 | 1x |\n| v2 \\| new | 2x |\n\n" +
+	"![Speed chart](%[1]s/chart.png)"
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(articlePage))
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/article", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/latin1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		w.Write([]byte("<html><body><p>Caf\xe9</p></body></html>"))
+	})
+	mux.HandleFunc("/data.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}` + "\n"))
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G'})
+	})
+	mux.HandleFunc("/app", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><div id="root"></div><script src="app.js"></script></body></html>`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func run(t *testing.T, tl tool.Tool, args map[string]any) (map[string]any, error) {
+	t.Helper()
+	ctx := toolinternal.NewToolContext(contextinternal.NewInvocationContext(t.Context(), contextinternal.InvocationContextParams{}), "", nil)
+	got, err := tl.(toolinternal.FunctionTool).Run(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	// The results are compared as JSON values.
+	b, _ := json.Marshal(got)
+	var m map[string]any
+	json.Unmarshal(b, &m)
+	return m, nil
+}
+
+func TestTool(t *testing.T) {
+	srv := newServer(t)
+	tl, err := webtool.New(webtool.Config{AllowPrivateNetworks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	article := strings.ReplaceAll(wantArticle, "%[1]s", srv.URL)
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "article",
+			args: map[string]any{"url": srv.URL + "/old"},
+			want: map[string]any{"url": srv.URL + "/article", "title": "Release notes", "content": article, "total_length": float64(len(article))},
+		},
+		{
+			name: "charset",
+			args: map[string]any{"url": srv.URL + "/latin1"},
+			want: map[string]any{"url": srv.URL + "/latin1", "content": "Café", "total_length": float64(4)},
+		},
+		{
+			name: "json",
+			args: map[string]any{"url": srv.URL + "/data.json"},
+			want: map[string]any{"url": srv.URL + "/data.json", "content": `{"ok": true}`, "total_length": float64(12)},
+		},
+		{name: "unsupported content", args: map[string]any{"url": srv.URL + "/image.png"}, wantErr: "unsupported content type"},
+		{name: "not found", args: map[string]any{"url": srv.URL + "/missing"}, wantErr: "404"},
+		{name: "scheme", args: map[string]any{"url": "file:///etc/passwd"}, wantErr: "only http and https"},
+		{name: "no render endpoint", args: map[string]any{"url": srv.URL + "/app", "render": true}, wantErr: "rendering is not available"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := run(t, tl, tc.args)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("Run() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Run() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTool_MaxLength(t *testing.T) {
+	srv := newServer(t)
+	tl, err := webtool.New(webtool.Config{AllowPrivateNetworks: true, MaxLength: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	article := []rune(strings.ReplaceAll(wantArticle, "%[1]s", srv.URL))
+	var read []rune
+	offset := 0
+	for range 100 {
+		got, err := run(t, tl, map[string]any{"url": srv.URL + "/article", "offset": offset})
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := []rune(got["content"].(string))
+		if len(content) > 20 {
+			t.Fatalf("content has %d characters, want at most 20", len(content))
+		}
+		read = append(read, content...)
+		if got["truncated"] != true {
+			break
+		}
+		offset = int(got["next_offset"].(float64))
+	}
+	if string(read) != string(article) {
+		t.Errorf("content read in parts = %q, want %q", string(read), string(article))
+	}
+}
+
+func TestTool_Render(t *testing.T) {
+	srv := newServer(t)
+	var rendered string
+	renderer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			URL string `json:"url"`
+		}
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		rendered = req.URL
+		w.Write([]byte(`<html><head><title>App</title></head><body><div id="root"><h1>Dashboard</h1><p>Loaded by script.</p></div></body></html>`))
+	}))
+	t.Cleanup(renderer.Close)
+	tl, err := webtool.New(webtool.Config{AllowPrivateNetworks: true, RenderEndpoint: renderer.URL + "/content"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := run(t, tl, map[string]any{"url": srv.URL + "/app"})
+	if err != nil || got["content"] != "" {
+		t.Errorf("Run() without render = %v, %v, want no content", got, err)
+	}
+	got, err = run(t, tl, map[string]any{"url": srv.URL + "/app", "render": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered != srv.URL+"/app" || got["title"] != "App" || got["content"] != "# Dashboard\n\nLoaded by script." {
+		t.Errorf("Run() with render = %v for the rendered URL %q, want the rendered content", got, rendered)
+	}
+}
+
+func TestTool_PrivateNetworks(t *testing.T) {
+	srv := newServer(t)
+	tl, err := webtool.New(webtool.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(t, tl, map[string]any{"url": srv.URL + "/article"}); err == nil || !strings.Contains(err.Error(), "non-public address") {
+		t.Errorf("Run() of a loopback URL error = %v, want the connection refused", err)
+	}
+
+	rendered := false
+	renderer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rendered = true
+		w.Write([]byte(`<html><body><p>Metadata</p></body></html>`))
+	}))
+	t.Cleanup(renderer.Close)
+	tl, err = webtool.New(webtool.Config{RenderEndpoint: renderer.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []string{srv.URL + "/app", "http://169.254.169.254/computeMetadata/v1/", "http://100.64.0.1/", "http://[::ffff:100.127.255.254]/", "http://localhost/"} {
+		if _, err := run(t, tl, map[string]any{"url": u, "render": true}); err == nil || !strings.Contains(err.Error(), "non-public address") {
+			t.Errorf("Run(%q) with render error = %v, want the address refused", u, err)
+		}
+	}
+	if rendered {
+		t.Error("the renderer was called for a non-public URL")
+	}
+}